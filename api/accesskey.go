@@ -1,8 +1,10 @@
 package api
 
 import (
+	"net/http"
 	"strconv"
 	"sublink/dto"
+	"sublink/middlewares"
 	"sublink/models"
 	"sublink/utils"
 	"time"
@@ -22,8 +24,30 @@ func GenerateAccessKey(c *gin.Context) {
 		utils.FailWithI18n(c, "用户不存在", "backend.user.notFound", nil)
 		return
 	}
+	if !canManageUserAccessKeys(c, user.ID) {
+		return
+	}
+
+	scopes, err := models.NormalizeAccessKeyScopes(userAccessKey.Scopes)
+	if err != nil {
+		utils.FailWithI18n(c, err.Error(), "backend.accessKeys.invalidScope", nil)
+		return
+	}
+	subscriptionIDs, err := models.NormalizeAccessKeySubscriptionIDs(userAccessKey.SubscriptionIDs)
+	if err != nil {
+		utils.FailWithI18n(c, err.Error(), "backend.accessKeys.invalidSubscription", nil)
+		return
+	}
+	allowedIPs, err := models.NormalizeAccessKeyAllowedIPs(userAccessKey.AllowedIPs)
+	if err != nil {
+		utils.FailWithI18n(c, err.Error(), "backend.accessKeys.invalidAllowedIP", nil)
+		return
+	}
 
 	var accessKey models.AccessKey
+	accessKey.Scopes = scopes
+	accessKey.SubscriptionIDs = subscriptionIDs
+	accessKey.AllowedIPs = allowedIPs
 	accessKey.ExpiredAt = userAccessKey.ExpiredAt
 	accessKey.Description = userAccessKey.Description
 	accessKey.UserID = user.ID
@@ -61,6 +85,14 @@ func DeleteAccessKey(c *gin.Context) {
 		utils.FailWithI18n(c, "删除Access Key失败", "backend.accessKeys.deleteFailed", nil)
 		return
 	}
+	existing, ok := models.GetAccessKeyByID(accessKeyID)
+	if !ok {
+		utils.FailWithI18n(c, "删除Access Key失败", "backend.accessKeys.deleteFailed", nil)
+		return
+	}
+	if !canManageUserAccessKeys(c, existing.UserID) {
+		return
+	}
 	accessKey.ID = accessKeyID
 	err = accessKey.Delete()
 	if err != nil {
@@ -84,6 +116,9 @@ func GetAccessKey(c *gin.Context) {
 		utils.FailWithI18n(c, "查询Access Key失败", "backend.accessKeys.queryFailed", nil)
 		return
 	}
+	if !canManageUserAccessKeys(c, userID) {
+		return
+	}
 
 	// 解析分页参数
 	page := 0
@@ -128,3 +163,32 @@ func GetAccessKey(c *gin.Context) {
 	}
 	utils.OkDetailedI18n(c, "查询Access Key成功", accessKeys, "backend.accessKeys.querySuccess", nil)
 }
+
+// canManageUserAccessKeys 只有管理员或 AccessKey 所属用户本人可以管理 AccessKey
+// API Key 继承所属用户的角色，限制归属可避免低权限用户为管理员生成 Key。
+func canManageUserAccessKeys(c *gin.Context, userID int) bool {
+	current, ok := currentUserFromContext(c)
+	if !ok {
+		return false
+	}
+	if current.ID == userID || current.IsAdmin() {
+		return true
+	}
+	utils.ResultI18n(c, http.StatusForbidden, http.StatusForbidden, "只能管理自己的 Access Key", nil, middlewares.RoleForbiddenI18nKey, nil)
+	return false
+}
+
+// GetAccessKeyScopes 获取可授予 AccessKey 的权限范围
+func GetAccessKeyScopes(c *gin.Context) {
+	utils.OkWithData(c, models.AccessKeyScopes)
+}
+
+// accessKeyFromContext 返回当前请求使用的 API Key，网页登录时返回 false
+func accessKeyFromContext(c *gin.Context) (models.AccessKey, bool) {
+	value, ok := c.Get(middlewares.AccessKeyContextKey)
+	if !ok {
+		return models.AccessKey{}, false
+	}
+	key, ok := value.(models.AccessKey)
+	return key, ok
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sublink/database"
+	"sublink/middlewares"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupScopedAccessKeyTest 创建带权限范围、订阅限制与 IP 白名单的 AccessKey，返回明文 Key
+func setupScopedAccessKeyTest(t *testing.T) (string, models.Subcription, models.Subcription) {
+	t.Helper()
+	users := setupUserRoleTest(t)
+	if err := database.DB.AutoMigrate(&models.Subcription{}, &models.SubscriptionShare{}); err != nil {
+		t.Fatalf("auto migrate subscriptions: %v", err)
+	}
+	allowed := models.Subcription{Name: "allowed-sub"}
+	denied := models.Subcription{Name: "denied-sub"}
+	for _, sub := range []*models.Subcription{&allowed, &denied} {
+		if err := database.DB.Create(sub).Error; err != nil {
+			t.Fatalf("create subscription: %v", err)
+		}
+	}
+	if err := models.InitSubcriptionCache(); err != nil {
+		t.Fatalf("init subscription cache: %v", err)
+	}
+
+	rec := performJSONRequestWithContext(t, GenerateAccessKey, map[string]any{
+		"username":        users[models.RoleAdmin].Username,
+		"description":     "agent",
+		"scopes":          []string{"nodes:read", "subscriptions:write", "templates:read"},
+		"subscriptionIds": []int{allowed.ID},
+		"allowedIps":      []string{"192.0.2.0/24"},
+	}, users[models.RoleAdmin].Username)
+	resp := decodeAPIResponse(t, rec)
+	if resp.Code != 200 {
+		t.Fatalf("generate access key failed: %+v", resp)
+	}
+	var data struct {
+		AccessKey string `json:"accessKey"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil || data.AccessKey == "" {
+		t.Fatalf("expected generated access key, got %s", resp.Data)
+	}
+	return data.AccessKey, allowed, denied
+}
+
+func performAccessKeyRequest(t *testing.T, apiKey, method, route, target, remoteAddr string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Handle(method, route, middlewares.AuthToken, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequestWithContext(context.Background(), method, target, nil)
+	req.Header.Set("X-API-Key", apiKey)
+	req.RemoteAddr = remoteAddr
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestAccessKeyScopeMatching(t *testing.T) {
+	key := models.AccessKey{Scopes: "airports:*,nodes:write"}
+	cases := map[string]bool{
+		"nodes:read":          true,
+		"nodes:write":         true,
+		"airports:pull":       true,
+		"tasks:run":           false,
+		"subscriptions:read":  false,
+		"accesskeys:write":    false,
+		"system:read":         false,
+		"subscriptions:write": false,
+	}
+	for scope, want := range cases {
+		if got := key.HasScope(scope); got != want {
+			t.Fatalf("HasScope(%q) = %v, want %v", scope, got, want)
+		}
+	}
+	if !(&models.AccessKey{}).HasScope("system:write") {
+		t.Fatal("expected legacy key without scopes to keep full access")
+	}
+	if _, err := models.NormalizeAccessKeyScopes([]string{"nodes:delete"}); err == nil {
+		t.Fatal("expected unknown scope to be rejected")
+	}
+	if scopes, err := models.NormalizeAccessKeyScopes([]string{"nodes:read", "*"}); err != nil || scopes != "" {
+		t.Fatalf("expected wildcard to mean unrestricted, got %q, %v", scopes, err)
+	}
+
+	routes := map[string]string{
+		"GET /api/v1/nodes/get":            "nodes:read",
+		"POST /api/v1/nodes/add":           "nodes:write",
+		"POST /api/v1/airports/:id/pull":   "airports:pull",
+		"POST /api/v1/node-check/run":      "tasks:run",
+		"POST /api/v1/accesskey/add":       "accesskeys:write",
+		"POST /api/v1/settings/webhook":    "system:write",
+		"POST /api/v1/subcription/preview": "subscriptions:read",
+		"GET /metrics":                     "metrics:read",
+		"GET /api/v1/users/me":             "",
+	}
+	for route, want := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if got := middlewares.RequiredAccessKeyScope(method, path); got != want {
+			t.Fatalf("RequiredAccessKeyScope(%s) = %q, want %q", route, got, want)
+		}
+	}
+}
+
+func TestAuthTokenEnforcesAccessKeyScopesAndIPs(t *testing.T) {
+	apiKey, _, _ := setupScopedAccessKeyTest(t)
+
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, "/api/v1/nodes/get", "/api/v1/nodes/get", "192.0.2.10:5000"); code != http.StatusNoContent {
+		t.Fatalf("expected nodes:read to pass, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodPost, "/api/v1/nodes/add", "/api/v1/nodes/add", "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected nodes:write to be forbidden, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodPost, "/api/v1/accesskey/add", "/api/v1/accesskey/add", "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected scoped key to be unable to create keys, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, "/api/v1/nodes/get", "/api/v1/nodes/get", "198.51.100.7:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected request outside allowlist to be forbidden, got %d", code)
+	}
+
+	var key models.AccessKey
+	if err := database.DB.First(&key).Error; err != nil {
+		t.Fatalf("load access key: %v", err)
+	}
+	if key.LastUsedIP != "192.0.2.10" || key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > time.Minute {
+		t.Fatalf("expected last usage to be recorded, got %+v", key)
+	}
+}
+
+func TestAuthTokenRestrictsAccessKeySubscriptions(t *testing.T) {
+	apiKey, allowed, denied := setupScopedAccessKeyTest(t)
+	route := "/api/v1/subcription/:id/chain-rules"
+
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, route, "/api/v1/subcription/"+strconv.Itoa(allowed.ID)+"/chain-rules", "192.0.2.10:5000"); code != http.StatusNoContent {
+		t.Fatalf("expected allowed subscription to pass, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, route, "/api/v1/subcription/"+strconv.Itoa(denied.ID)+"/chain-rules", "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected other subscription to be forbidden, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodDelete, "/api/v1/subcription/delete", "/api/v1/subcription/delete?id="+strconv.Itoa(denied.ID), "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected delete of other subscription to be forbidden, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodPost, "/api/v1/subcription/add", "/api/v1/subcription/add", "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected endpoints without a target subscription to be forbidden, got %d", code)
+	}
+
+	// 其他权限范围的接口带订阅参数时同样受订阅限制
+	renderRoute := "/api/v1/template/render"
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, renderRoute, renderRoute+"?filename=clash.yaml", "192.0.2.10:5000"); code != http.StatusNoContent {
+		t.Fatalf("expected template preview without subscription to pass, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, renderRoute, renderRoute+"?filename=clash.yaml&subscriptionId="+strconv.Itoa(allowed.ID), "192.0.2.10:5000"); code != http.StatusNoContent {
+		t.Fatalf("expected template preview of allowed subscription to pass, got %d", code)
+	}
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, renderRoute, renderRoute+"?filename=clash.yaml&subscriptionId="+strconv.Itoa(denied.ID), "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected template preview of other subscription to be forbidden, got %d", code)
+	}
+
+	share := models.SubscriptionShare{SubscriptionID: denied.ID, Token: "denied-share-token"}
+	if err := database.DB.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	validateRoute := "/api/v1/subcription/:id/validate-config"
+	target := "/api/v1/subcription/" + strconv.Itoa(allowed.ID) + "/validate-config?shareId=" + strconv.Itoa(share.ID)
+	if code := performAccessKeyRequest(t, apiKey, http.MethodGet, validateRoute, target, "192.0.2.10:5000"); code != http.StatusForbidden {
+		t.Fatalf("expected validation with another subscription's share to be forbidden, got %d", code)
+	}
+}
@@ -514,7 +514,7 @@ func AirportRefreshUsage(c *gin.Context) {
 	}
 
 	// 同步获取用量信息
-	usageInfo, err := node.UpdateAirportUsageInfo(id)
+	usageInfo, err := node.UpdateAirportUsageInfo(c.Request.Context(), id)
 	if err != nil {
 		utils.FailWithMsg(c, "获取用量信息失败: "+err.Error())
 		return
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"strconv"
+	"sublink/models"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAuditRetentionDays 审计日志最长保留天数
+const maxAuditRetentionDays = 3650
+
+// GetAuditLogs 分页查询审计日志
+// GET /api/v1/audit/logs?actor=&action=&resourceType=&resourceId=&success=&start=&end=&page=1&pageSize=20
+func GetAuditLogs(c *gin.Context) {
+	filter := models.AuditLogFilter{
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resourceType"),
+		ResourceID:   c.Query("resourceId"),
+	}
+	if value := c.Query("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.FailWithMsg(c, "success 参数错误")
+			return
+		}
+		filter.Success = &success
+	}
+	if value := c.Query("start"); value != "" {
+		start, err := parseAuditTime(value, false)
+		if err != nil {
+			utils.FailWithMsg(c, "开始时间格式错误")
+			return
+		}
+		filter.Start = &start
+	}
+	if value := c.Query("end"); value != "" {
+		end, err := parseAuditTime(value, true)
+		if err != nil {
+			utils.FailWithMsg(c, "结束时间格式错误")
+			return
+		}
+		filter.End = &end
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	logs, total, err := models.ListAuditLogs(filter, page, pageSize)
+	if err != nil {
+		utils.FailWithMsg(c, "获取审计日志失败")
+		return
+	}
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	utils.OkDetailed(c, "获取成功", gin.H{
+		"items":      logs,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": totalPages,
+	})
+}
+
+// parseAuditTime 解析 RFC3339 或 yyyy-MM-dd 格式的时间，结束日期按当天结束计算
+func parseAuditTime(value string, endOfDay bool) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+	return parsed, nil
+}
+
+// GetAuditLogOptions 获取审计日志筛选项（已出现过的操作者、资源类型与操作）
+func GetAuditLogOptions(c *gin.Context) {
+	options, err := models.ListAuditFilterOptions()
+	if err != nil {
+		utils.FailWithMsg(c, "获取筛选项失败")
+		return
+	}
+	utils.OkWithData(c, options)
+}
+
+// GetAuditSettings 获取审计日志设置
+func GetAuditSettings(c *gin.Context) {
+	utils.OkWithData(c, gin.H{"retentionDays": models.GetAuditRetentionDays()})
+}
+
+// UpdateAuditSettings 更新审计日志保留天数，保存后立即清理过期日志
+func UpdateAuditSettings(c *gin.Context) {
+	var req struct {
+		RetentionDays *int `json:"retentionDays"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.RetentionDays == nil {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	if *req.RetentionDays < 0 || *req.RetentionDays > maxAuditRetentionDays {
+		utils.FailWithMsg(c, "保留天数需在 0 到 3650 之间，0 表示永久保留")
+		return
+	}
+	if err := models.SetAuditRetentionDays(*req.RetentionDays); err != nil {
+		utils.FailWithMsg(c, "保存失败: "+err.Error())
+		return
+	}
+	deleted, err := models.CleanupExpiredAuditLogs()
+	if err != nil {
+		utils.Warn("清理过期审计日志失败: %v", err)
+	}
+	utils.OkDetailed(c, "保存成功", gin.H{"retentionDays": *req.RetentionDays, "deleted": deleted})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sublink/database"
+	"sublink/middlewares"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupAuditTest(t *testing.T) (map[string]models.User, *gin.Engine) {
+	t.Helper()
+	users := setupUserRoleTest(t)
+	if err := database.DB.AutoMigrate(&models.AuditLog{}, &models.SystemSetting{}); err != nil {
+		t.Fatalf("auto migrate audit tables: %v", err)
+	}
+	if err := models.InitSettingCache(); err != nil {
+		t.Fatalf("init setting cache: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = database.DB.Exec("DELETE FROM system_settings").Error
+		_ = models.InitSettingCache()
+	})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	// 模拟 AuthToken：按请求头设置当前用户，未设置时视为未登录
+	group := engine.Group("/api/v1/users", func(c *gin.Context) {
+		if username := c.GetHeader("X-Test-User"); username != "" {
+			c.Set("username", username)
+		}
+		c.Next()
+	}, middlewares.AuditLog)
+	group.POST("/create", UserCreate)
+	group.POST("/role", UserUpdateRole)
+	group.POST("/delete", UserDelete)
+	return users, engine
+}
+
+func performAuditRequest(t *testing.T, engine *gin.Engine, path, username string, body any) apiJSONResponse {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal request body: %v", err)
+	}
+	request := httptest.NewRequestWithContext(context.Background(), http.MethodPost, path, bytes.NewReader(raw))
+	request.Header.Set("Content-Type", "application/json")
+	if username != "" {
+		request.Header.Set("X-Test-User", username)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return decodeAPIResponse(t, recorder)
+}
+
+func listAuditLogs(t *testing.T, query string) ([]models.AuditLog, int64) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/audit/logs?"+query, nil)
+	GetAuditLogs(ctx)
+
+	resp := decodeAPIResponse(t, recorder)
+	var data struct {
+		Items []models.AuditLog `json:"items"`
+		Total int64             `json:"total"`
+	}
+	if resp.Code != 200 {
+		t.Fatalf("list audit logs failed: %+v", resp)
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("unmarshal audit logs: %v", err)
+	}
+	return data.Items, data.Total
+}
+
+func TestAuditLogRecordsMutationsWithSnapshots(t *testing.T) {
+	users, engine := setupAuditTest(t)
+	admin := users[models.RoleAdmin].Username
+
+	resp := performAuditRequest(t, engine, "/api/v1/users/create", admin, map[string]string{
+		"username": "auditor", "password": "s3cret-pass", "nickname": "Auditor", "role": models.RoleViewer,
+	})
+	if resp.Code != 200 {
+		t.Fatalf("create user failed: %+v", resp)
+	}
+	created := &models.User{Username: "auditor"}
+	if err := created.Find(); err != nil {
+		t.Fatalf("find created user: %v", err)
+	}
+	performAuditRequest(t, engine, "/api/v1/users/role", admin, map[string]any{"id": created.ID, "role": models.RoleOperator})
+	performAuditRequest(t, engine, "/api/v1/users/delete", admin, map[string]any{"id": created.ID})
+	// 未登录的请求与失败的操作
+	performAuditRequest(t, engine, "/api/v1/users/delete", "", map[string]any{"id": created.ID})
+	performAuditRequest(t, engine, "/api/v1/users/role", admin, map[string]any{"id": created.ID, "role": "root"})
+
+	logs, total := listAuditLogs(t, "")
+	if total != 4 {
+		t.Fatalf("expected 4 audit logs, got %d: %+v", total, logs)
+	}
+	for _, log := range logs {
+		if log.Actor != admin || log.ActorType != models.AuditActorUser || log.ResourceType != "user" {
+			t.Fatalf("unexpected audit actor or resource: %+v", log)
+		}
+		if strings.Contains(log.Before+log.After, "s3cret-pass") {
+			t.Fatalf("expected password to be redacted, got %+v", log)
+		}
+	}
+
+	createLog := logs[3]
+	if createLog.Action != "create" || !createLog.Success || !strings.Contains(createLog.After, `"role":"viewer"`) {
+		t.Fatalf("unexpected create audit log: %+v", createLog)
+	}
+	roleLog := logs[2]
+	if roleLog.Action != "role" || roleLog.ResourceID != strconv.Itoa(created.ID) ||
+		!strings.Contains(roleLog.Before, `"role":"viewer"`) || !strings.Contains(roleLog.After, `"role":"operator"`) {
+		t.Fatalf("expected before/after role snapshots, got %+v", roleLog)
+	}
+	deleteLog := logs[1]
+	if deleteLog.Action != "delete" || !strings.Contains(deleteLog.Before, `"username":"auditor"`) {
+		t.Fatalf("expected delete audit log with previous state, got %+v", deleteLog)
+	}
+	if failed := logs[0]; failed.Success || failed.Message == "" {
+		t.Fatalf("expected failed operation to be recorded, got %+v", failed)
+	}
+
+	filtered, total := listAuditLogs(t, "action=delete&success=true&pageSize=1")
+	if total != 1 || len(filtered) != 1 || filtered[0].ID != deleteLog.ID {
+		t.Fatalf("expected filtered delete log, got %d %+v", total, filtered)
+	}
+}
+
+func TestAuditLogRetentionCleanup(t *testing.T) {
+	setupAuditTest(t)
+	now := time.Now()
+	for _, age := range []time.Duration{0, 10 * 24 * time.Hour, 100 * 24 * time.Hour} {
+		log := &models.AuditLog{Actor: "admin-user", Action: "update", ResourceType: "node", CreatedAt: now.Add(-age)}
+		if err := models.CreateAuditLog(log); err != nil {
+			t.Fatalf("create audit log: %v", err)
+		}
+	}
+
+	if deleted, err := models.CleanupExpiredAuditLogs(); err != nil || deleted != 1 {
+		t.Fatalf("expected default retention to remove 1 log, got %d, %v", deleted, err)
+	}
+
+	rec := performJSONRequestWithContext(t, UpdateAuditSettings, map[string]int{"retentionDays": 7}, "admin-user")
+	if resp := decodeAPIResponse(t, rec); resp.Code != 200 {
+		t.Fatalf("update audit settings failed: %+v", resp)
+	}
+	if models.GetAuditRetentionDays() != 7 {
+		t.Fatalf("expected retention to be saved, got %d", models.GetAuditRetentionDays())
+	}
+	if _, total := listAuditLogs(t, ""); total != 1 {
+		t.Fatalf("expected saving retention to clean up old logs, got %d", total)
+	}
+
+	rec = performJSONRequestWithContext(t, UpdateAuditSettings, map[string]int{"retentionDays": -1}, "admin-user")
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected negative retention to be rejected")
+	}
+}
+
+func TestAuditSummaryRedactsResourceCredentials(t *testing.T) {
+	summary := models.AuditSummary("airport", map[string]any{
+		"name":       "airport-a",
+		"url":        "https://airport.example/sub?token=airport-secret",
+		"proxy_link": "ss://airport-proxy-secret@1.2.3.4:8388",
+		"proxyLink":  "ss://airport-proxy-secret@1.2.3.4:8388",
+	})
+	if strings.Contains(summary, "airport-secret") || strings.Contains(summary, "airport-proxy-secret") || !strings.Contains(summary, `"name":"airport-a"`) {
+		t.Fatalf("expected airport url and proxy link to be redacted, got %s", summary)
+	}
+	node := models.AuditSummary("node", map[string]any{
+		"name":      "node-a",
+		"link":      "vmess://node-uuid-secret@1.2.3.4:443",
+		"Link":      "trojan://node-password-secret@1.2.3.4:443",
+		"link_name": "HK 01",
+	})
+	if strings.Contains(node, "node-uuid-secret") || strings.Contains(node, "node-password-secret") || !strings.Contains(node, `"link_name":"HK 01"`) {
+		t.Fatalf("expected node link to be redacted, got %s", node)
+	}
+	webhook := models.AuditSummary("webhook", map[string]any{
+		"name": "hook",
+		"url":  "https://api.telegram.org/botwebhook-token-secret/sendMessage",
+	})
+	if strings.Contains(webhook, "webhook-token-secret") || !strings.Contains(webhook, `"name":"hook"`) {
+		t.Fatalf("expected webhook url to be redacted, got %s", webhook)
+	}
+	if tag := models.AuditSummary("tag", map[string]any{"name": "tag-a", "url": "https://example.com"}); !strings.Contains(tag, `"url":"https://example.com"`) {
+		t.Fatalf("unexpected summary for other resources: %s", tag)
+	}
+}
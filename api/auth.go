@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"sublink/config"
 	"sublink/middlewares"
 	"sublink/models"
@@ -15,19 +16,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// 获取token
-func GetToken(user *models.User) (string, error) {
+// GetToken 创建登录会话并签发 token，会话 ID 写入 jti 用于服务端注销
+func GetToken(c *gin.Context, user *models.User) (string, error) {
+	expiresAt := time.Now().Add(24 * time.Hour * 14) // 14天后过期
+	session, err := models.CreateUserSession(user, c.ClientIP(), c.Request.UserAgent(), expiresAt)
+	if err != nil {
+		return "", err
+	}
 	credentialSign := models.GenerateCredentialSign(user.Username, user.Password)
-	c := &middlewares.JwtClaims{
+	claims := &middlewares.JwtClaims{
 		Username:       user.Username,
 		CredentialSign: credentialSign,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 14)), // 14天后过期
-			IssuedAt:  jwt.NewNumericDate(time.Now()),                          // 签发时间
+			ID:        session.SessionID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()), // 签发时间
 			Subject:   user.Username,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(config.GetJwtSecret()))
 }
 
@@ -78,6 +85,11 @@ func UserLogin(c *gin.Context) {
 	captchaKey := c.PostForm("captchaKey")
 	ip := c.ClientIP()
 
+	if config.IsPasswordLoginDisabled() {
+		utils.FailWithI18n(c, "已禁用账号密码登录，请使用单点登录", "backend.auth.login.passwordDisabled", nil)
+		return
+	}
+
 	// 0. 检查IP是否被封禁
 	limiter := GetLoginLimiter()
 	if isBanned, banUntil := limiter.CheckBan(ip); isBanned {
@@ -131,7 +143,7 @@ func UserLogin(c *gin.Context) {
 	}
 	// 登录成功，清除失败记录
 	limiter.ClearFailures(ip)
-	if user.TOTPEnabled {
+	if methods := mfaMethods(user); len(methods) > 0 {
 		challengeToken, err := issuePendingMFAChallenge(user)
 		if err != nil {
 			utils.Error("生成 MFA 挑战失败: %v", err)
@@ -141,7 +153,7 @@ func UserLogin(c *gin.Context) {
 		utils.OkDetailedI18n(c, "需要进行二次验证", gin.H{
 			"requiresMFA":    true,
 			"challengeToken": challengeToken,
-			"methods":        []string{"totp", "recovery_code"},
+			"methods":        methods,
 		}, "backend.auth.login.mfaRequired", nil)
 		return
 	}
@@ -149,12 +161,16 @@ func UserLogin(c *gin.Context) {
 	respondLoginSuccess(c, user, ip)
 }
 
-// UserOut 用户退出登录
+// UserOut 用户退出登录，注销当前 token 对应的会话
+// 退出接口不经过鉴权中间件，token 已过期或无效时同样返回成功。
 func UserOut(c *gin.Context) {
-	// 拿到jwt中的username
-	if _, Is := c.Get("username"); Is {
-		utils.OkDetailedI18n(c, "退出成功", nil, "backend.auth.logout.success", nil)
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if claims, err := middlewares.ParseToken(token); err == nil && claims.ID != "" {
+		if err := models.RevokeUserSessionByID(claims.ID); err != nil {
+			utils.Error("注销登录会话失败: %v", err)
+		}
 	}
+	utils.OkDetailedI18n(c, "退出成功", nil, "backend.auth.logout.success", nil)
 }
 
 func notifyUserLogin(username, ip string) {
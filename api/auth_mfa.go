@@ -18,6 +18,13 @@ import (
 
 const mfaResetHeader = "X-MFA-Reset-Token"
 
+// 登录挑战用途
+const (
+	challengePurposeMFALogin        = "mfa_login"
+	challengePurposePasskeyLogin    = "passkey_login"
+	challengePurposePasskeyRegister = "passkey_register"
+)
+
 type pendingMFAClaims struct {
 	Username    string `json:"username"`
 	Purpose     string `json:"purpose"`
@@ -29,6 +36,7 @@ type MFAStatusResponse struct {
 	Enabled             bool `json:"enabled"`
 	PendingEnrollment   bool `json:"pendingEnrollment"`
 	RecoveryCodesRemain int  `json:"recoveryCodesRemaining"`
+	Passkeys            int  `json:"passkeys"`
 }
 
 type verifyMFARequest struct {
@@ -95,6 +103,12 @@ func generateChallengeID() (string, error) {
 }
 
 func issuePendingMFAChallenge(user *models.User) (string, error) {
+	return issueChallenge(user.Username, challengePurposeMFALogin, "")
+}
+
+// issueChallenge 创建一次性挑战并签发对应的挑战令牌
+// 免密码登录的挑战在签发时还不知道用户，username 为空。
+func issueChallenge(username, purpose, sessionData string) (string, error) {
 	challengeID, err := generateChallengeID()
 	if err != nil {
 		return "", err
@@ -102,22 +116,23 @@ func issuePendingMFAChallenge(user *models.User) (string, error) {
 	expiresAt := time.Now().Add(models.TOTPChallengeTTL)
 	challenge := &models.MFALoginChallenge{
 		ChallengeID: challengeID,
-		Username:    user.Username,
-		Purpose:     "mfa_login",
+		Username:    username,
+		Purpose:     purpose,
 		ExpiresAt:   expiresAt.Unix(),
 		MaxAttempts: models.TOTPChallengeMaxAttempts,
+		SessionData: sessionData,
 	}
 	if err := models.CreateMFALoginChallenge(challenge); err != nil {
 		return "", err
 	}
 	claims := &pendingMFAClaims{
-		Username:    user.Username,
-		Purpose:     "mfa_login",
+		Username:    username,
+		Purpose:     purpose,
 		ChallengeID: challengeID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   user.Username,
+			Subject:   username,
 			ID:        challengeID,
 		},
 	}
@@ -126,6 +141,11 @@ func issuePendingMFAChallenge(user *models.User) (string, error) {
 }
 
 func parsePendingMFAChallenge(tokenString string) (*pendingMFAClaims, *models.MFALoginChallenge, error) {
+	return parseChallenge(tokenString, challengePurposeMFALogin)
+}
+
+// parseChallenge 校验挑战令牌，并确认挑战仍可使用
+func parseChallenge(tokenString, purpose string) (*pendingMFAClaims, *models.MFALoginChallenge, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &pendingMFAClaims{}, func(token *jwt.Token) (any, error) {
 		return []byte(config.GetJwtSecret()), nil
 	})
@@ -133,14 +153,14 @@ func parsePendingMFAChallenge(tokenString string) (*pendingMFAClaims, *models.MF
 		return nil, nil, err
 	}
 	claims, ok := token.Claims.(*pendingMFAClaims)
-	if !ok || !token.Valid || claims.Purpose != "mfa_login" || strings.TrimSpace(claims.ChallengeID) == "" {
+	if !ok || !token.Valid || claims.Purpose != purpose || strings.TrimSpace(claims.ChallengeID) == "" {
 		return nil, nil, fmt.Errorf("invalid challenge token")
 	}
 	challenge, err := models.FindMFALoginChallengeByChallengeID(claims.ChallengeID)
 	if err != nil {
 		return nil, nil, err
 	}
-	if challenge.Purpose != "mfa_login" || challenge.Username != claims.Username {
+	if challenge.Purpose != purpose || challenge.Username != claims.Username {
 		return nil, nil, fmt.Errorf("invalid challenge token")
 	}
 	nowUnix := time.Now().Unix()
@@ -192,7 +212,7 @@ func requireMFAReauth(user *models.User, password, code string) error {
 }
 
 func respondLoginSuccess(c *gin.Context, user *models.User, ip string) {
-	token, err := GetToken(user)
+	token, err := GetToken(c, user)
 	if err != nil {
 		utils.Error("获取token失败: %v", err)
 		utils.FailWithI18n(c, "获取token失败", "backend.auth.login.tokenFailed", nil)
@@ -207,6 +227,7 @@ func buildMFAStatus(user *models.User) MFAStatusResponse {
 		Enabled:             user.TOTPEnabled,
 		PendingEnrollment:   strings.TrimSpace(user.TOTPPendingSecret) != "",
 		RecoveryCodesRemain: user.CountRecoveryCodes(),
+		Passkeys:            models.CountWebAuthnCredentials(user.ID),
 	}
 }
 
@@ -452,6 +473,9 @@ func ResetTOTP(c *gin.Context) {
 		utils.FailWithMsg(c, "重置 TOTP 失败")
 		return
 	}
+	if _, err := models.RevokeUserSessions(user.ID, ""); err != nil {
+		utils.Warn("注销用户登录会话失败: %v", err)
+	}
 	utils.OkWithMsg(c, "TOTP 已重置，请重新登录后重新绑定")
 }
 
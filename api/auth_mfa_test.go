@@ -32,7 +32,7 @@ func setupAuthMFATestDB(t *testing.T) {
 	oldCfg := *config.Get()
 
 	db := testutil.OpenMemoryDB(t, "auth_mfa_test")
-	if err := db.AutoMigrate(&models.User{}, &models.MFALoginChallenge{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.MFALoginChallenge{}, &models.WebAuthnCredential{}, &models.UserSession{}); err != nil {
 		t.Fatalf("auto migrate users: %v", err)
 	}
 
@@ -48,9 +48,14 @@ func setupAuthMFATestDB(t *testing.T) {
 	if err := models.InitUserCache(); err != nil {
 		t.Fatalf("init user cache: %v", err)
 	}
+	if err := models.InitUserSessionCache(); err != nil {
+		t.Fatalf("init user session cache: %v", err)
+	}
 
 	t.Cleanup(func() {
 		_ = db.Exec("DELETE FROM users").Error
+		_ = db.Exec("DELETE FROM user_sessions").Error
+		_ = models.InitUserSessionCache()
 		database.DB = oldDB
 		database.Dialect = oldDialect
 		database.IsInitialized = oldInitialized
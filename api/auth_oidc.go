@@ -0,0 +1,377 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sublink/config"
+	"sublink/models"
+	"sublink/utils"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateCookie     = "sublink_oidc"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+	oidcStateTTL        = 10 * time.Minute
+	oidcHTTPTimeout     = 15 * time.Second
+)
+
+var (
+	oidcProviderMu sync.Mutex
+	oidcProviders  = make(map[string]*oidc.Provider)
+)
+
+// oidcLoginState 授权请求的一次性状态，签名后保存在 Cookie 中
+// LinkUser 不为空时本次授权用于把身份关联到该本地用户，而不是登录。
+type oidcLoginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	LinkUser string `json:"linkUser,omitempty"`
+	Exp      int64  `json:"exp"`
+}
+
+// OIDCStatus 返回登录页需要的单点登录配置
+func OIDCStatus(c *gin.Context) {
+	cfg := config.GetOIDCConfig()
+	utils.OkWithData(c, gin.H{
+		"enabled":               cfg.Enabled(),
+		"displayName":           cfg.DisplayName,
+		"passwordLoginDisabled": config.IsPasswordLoginDisabled(),
+	})
+}
+
+// OIDCLogin 跳转到身份提供方进行授权
+// 关联身份只能由已登录用户通过 OIDCLinkURL 发起，这里不接受地址中携带的关联凭证。
+func OIDCLogin(c *gin.Context) {
+	cfg := config.GetOIDCConfig()
+	if !cfg.Enabled() {
+		redirectOIDCError(c, "未启用单点登录")
+		return
+	}
+	if c.Query("link") != "" {
+		redirectOIDCLinkResult(c, "oidc_link_error", "关联请求已失效，请在设置页重新发起")
+		return
+	}
+	authURL, err := startOIDCAuthorization(c, cfg, "")
+	if err != nil {
+		redirectOIDCError(c, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// startOIDCAuthorization 生成授权状态写入当前浏览器的 Cookie，返回身份提供方的授权地址
+// linkUser 不为空时回调会把身份关联到该用户；状态只保存在发起者浏览器中，无法转交给他人使用。
+func startOIDCAuthorization(c *gin.Context, cfg config.OIDCConfig, linkUser string) (string, error) {
+	provider, err := getOIDCProvider(cfg.Issuer)
+	if err != nil {
+		utils.Error("获取 OIDC 配置失败: %v", err)
+		return "", errors.New("无法连接身份提供方")
+	}
+
+	state := oidcLoginState{
+		State:    randomOIDCToken(),
+		Nonce:    randomOIDCToken(),
+		Verifier: oauth2.GenerateVerifier(),
+		LinkUser: linkUser,
+		Exp:      time.Now().Add(oidcStateTTL).Unix(),
+	}
+	cookie, err := signOIDCState(state)
+	if err != nil {
+		utils.Error("生成 OIDC 登录状态失败: %v", err)
+		return "", errors.New("生成登录状态失败")
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, cookie, int(oidcStateTTL.Seconds()), oidcStateCookiePath, "", isHTTPSRequest(c), true)
+
+	return oidcOAuth2Config(cfg, provider).AuthCodeURL(state.State, oidc.Nonce(state.Nonce), oauth2.S256ChallengeOption(state.Verifier)), nil
+}
+
+// OIDCCallback 处理身份提供方回调，完成登录后带着 token 跳转回前端
+func OIDCCallback(c *gin.Context) {
+	cfg := config.GetOIDCConfig()
+	if !cfg.Enabled() {
+		redirectOIDCError(c, "未启用单点登录")
+		return
+	}
+
+	rawState, _ := c.Cookie(oidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcStateCookiePath, "", isHTTPSRequest(c), true)
+	state, err := verifyOIDCState(rawState)
+	if err != nil || state.State != c.Query("state") {
+		utils.Warn("OIDC 回调状态校验失败: %v", err)
+		redirectOIDCError(c, "登录状态已失效，请重新登录")
+		return
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		utils.Warn("身份提供方拒绝授权: %s %s", errCode, c.Query("error_description"))
+		redirectOIDCError(c, "身份提供方拒绝授权: "+errCode)
+		return
+	}
+
+	identity, err := exchangeOIDCIdentity(c.Request.Context(), cfg, c.Query("code"), state)
+	if err != nil {
+		utils.Warn("OIDC 登录失败: %v", err)
+		redirectOIDCError(c, err.Error())
+		return
+	}
+	if state.LinkUser != "" {
+		linkOIDCIdentity(c, state.LinkUser, identity)
+		return
+	}
+	user, err := models.LoginOIDCUser(identity)
+	if err != nil {
+		if !errors.Is(err, models.ErrOIDCUserConflict) && !errors.Is(err, models.ErrOIDCNoRole) {
+			utils.Error("单点登录创建用户失败: %v", err)
+		}
+		redirectOIDCError(c, err.Error())
+		return
+	}
+
+	// 本地启用了 TOTP 或通行密钥的用户，单点登录后仍需完成二次验证
+	if methods := mfaMethods(user); len(methods) > 0 {
+		challengeToken, err := issuePendingMFAChallenge(user)
+		if err != nil {
+			utils.Error("生成 MFA 挑战失败: %v", err)
+			redirectOIDCError(c, "生成登录验证失败")
+			return
+		}
+		c.Redirect(http.StatusFound, oidcFrontendURL(url.Values{
+			"oidc_mfa":         {challengeToken},
+			"oidc_mfa_methods": {strings.Join(methods, ",")},
+			"oidc_user":        {user.Username},
+		}))
+		return
+	}
+
+	token, err := GetToken(c, user)
+	if err != nil {
+		utils.Error("获取token失败: %v", err)
+		redirectOIDCError(c, "获取token失败")
+		return
+	}
+	go notifyUserLogin(user.Username, c.ClientIP())
+	c.Redirect(http.StatusFound, oidcFrontendURL(url.Values{"oidc_token": {token}}))
+}
+
+// OIDCLinkURL 为当前登录用户发起关联单点登录身份的授权，返回身份提供方的授权地址
+// 关联状态写入发起者浏览器的 httpOnly Cookie，回调时必须带回，授权地址被转发给他人也无法完成关联。
+func OIDCLinkURL(c *gin.Context) {
+	cfg := config.GetOIDCConfig()
+	if !cfg.Enabled() {
+		utils.FailWithMsg(c, "未启用单点登录")
+		return
+	}
+	user, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	authURL, err := startOIDCAuthorization(c, cfg, user.Username)
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	utils.OkWithData(c, gin.H{"url": authURL})
+}
+
+// linkOIDCIdentity 把身份提供方返回的身份关联到发起关联的本地用户
+func linkOIDCIdentity(c *gin.Context, username string, identity models.OIDCIdentity) {
+	user, err := models.FindUserByUsername(username)
+	if err != nil {
+		redirectOIDCLinkResult(c, "oidc_link_error", "用户不存在")
+		return
+	}
+	if err := user.LinkOIDCSubject(identity.Subject); err != nil {
+		if !errors.Is(err, models.ErrOIDCSubjectLinked) {
+			utils.Error("关联单点登录身份失败: %v", err)
+		}
+		redirectOIDCLinkResult(c, "oidc_link_error", err.Error())
+		return
+	}
+	redirectOIDCLinkResult(c, "oidc_linked", "1")
+}
+
+// exchangeOIDCIdentity 用授权码换取并校验 ID Token，解析出本地用户身份
+func exchangeOIDCIdentity(ctx context.Context, cfg config.OIDCConfig, code string, state oidcLoginState) (models.OIDCIdentity, error) {
+	if code == "" {
+		return models.OIDCIdentity{}, errors.New("缺少授权码")
+	}
+	provider, err := getOIDCProvider(cfg.Issuer)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("无法连接身份提供方: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(oidc.ClientContext(ctx, &http.Client{Timeout: oidcHTTPTimeout}), oidcHTTPTimeout)
+	defer cancel()
+
+	oauthToken, err := oidcOAuth2Config(cfg, provider).Exchange(ctx, code, oauth2.VerifierOption(state.Verifier))
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("授权码换取令牌失败: %w", err)
+	}
+	rawIDToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return models.OIDCIdentity{}, errors.New("身份提供方未返回 id_token")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("id_token 校验失败: %w", err)
+	}
+	if idToken.Nonce != state.Nonce {
+		return models.OIDCIdentity{}, errors.New("id_token nonce 不匹配")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("解析 id_token 失败: %w", err)
+	}
+	username := firstStringClaim(claims, cfg.UsernameClaim, "preferred_username", "email")
+	if username == "" {
+		username = idToken.Subject
+	}
+	return models.OIDCIdentity{
+		Subject:  idToken.Issuer + "|" + idToken.Subject,
+		Username: username,
+		Nickname: firstStringClaim(claims, "name", "nickname"),
+		Role:     models.ResolveOIDCRole(stringListClaim(claims[cfg.GroupsClaim]), cfg.RoleMapping, cfg.DefaultRole),
+		SyncRole: len(cfg.RoleMapping) > 0,
+	}, nil
+}
+
+// getOIDCProvider 获取并缓存身份提供方的发现配置
+// 使用不会取消的 context，公钥集后续刷新时仍会使用它。
+func getOIDCProvider(issuer string) (*oidc.Provider, error) {
+	oidcProviderMu.Lock()
+	defer oidcProviderMu.Unlock()
+	if provider, ok := oidcProviders[issuer]; ok {
+		return provider, nil
+	}
+	ctx := oidc.ClientContext(context.Background(), &http.Client{Timeout: oidcHTTPTimeout})
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	oidcProviders[issuer] = provider
+	return provider, nil
+}
+
+func oidcOAuth2Config(cfg config.OIDCConfig, provider *oidc.Provider) *oauth2.Config {
+	scopes := []string{oidc.ScopeOpenID, "profile", "email"}
+	for _, scope := range cfg.Scopes {
+		if scope != oidc.ScopeOpenID && scope != "profile" && scope != "email" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+}
+
+// signOIDCState 使用 JWT 密钥对登录状态签名
+func signOIDCState(state oidcLoginState) (string, error) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(sha256.New, []byte(config.GetJwtSecret()))
+	_, _ = h.Write(body)
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+func verifyOIDCState(raw string) (oidcLoginState, error) {
+	var state oidcLoginState
+	payload, sig, ok := strings.Cut(raw, ".")
+	if !ok {
+		return state, errors.New("缺少登录状态")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return state, err
+	}
+	provided, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return state, err
+	}
+	h := hmac.New(sha256.New, []byte(config.GetJwtSecret()))
+	_, _ = h.Write(body)
+	if !hmac.Equal(provided, h.Sum(nil)) {
+		return state, errors.New("登录状态签名无效")
+	}
+	if err := json.Unmarshal(body, &state); err != nil {
+		return state, err
+	}
+	if time.Now().Unix() > state.Exp {
+		return state, errors.New("登录状态已过期")
+	}
+	return state, nil
+}
+
+// redirectOIDCError 带着错误信息跳转回前端登录页
+func redirectOIDCError(c *gin.Context, message string) {
+	c.Redirect(http.StatusFound, oidcFrontendURL(url.Values{"oidc_error": {message}}))
+}
+
+// redirectOIDCLinkResult 关联完成后跳转回前端设置页
+func redirectOIDCLinkResult(c *gin.Context, key, value string) {
+	c.Redirect(http.StatusFound, config.GetWebBasePath()+"/system/settings#"+url.Values{key: {value}}.Encode())
+}
+
+// oidcFrontendURL 前端登录页地址，结果放在 fragment 中，不会出现在服务器日志里
+func oidcFrontendURL(values url.Values) string {
+	return config.GetWebBasePath() + "/login#" + values.Encode()
+}
+
+func isHTTPSRequest(c *gin.Context) bool {
+	return c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+}
+
+func randomOIDCToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func firstStringClaim(claims map[string]any, names ...string) string {
+	for _, name := range names {
+		if value, ok := claims[name].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// stringListClaim 解析用户组 claim，兼容数组与逗号分隔字符串
+func stringListClaim(value any) []string {
+	switch v := value.(type) {
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, item := range v {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	case string:
+		return strings.Split(v, ",")
+	}
+	return nil
+}
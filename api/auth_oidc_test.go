@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sublink/config"
+	"sublink/middlewares"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockOIDCIssuer 本地模拟的 OIDC 身份提供方，签发带指定用户组的 ID Token
+type mockOIDCIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu        sync.Mutex
+	nonce     string
+	challenge string
+	subject   string
+	username  string
+	groups    []string
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	issuer := &mockOIDCIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer.server.URL,
+			"authorization_endpoint":                issuer.server.URL + "/authorize",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"jwks_uri":                              issuer.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.handleToken)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (m *mockOIDCIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_ = r.ParseForm()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "test-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	claims := jwt.MapClaims{
+		"iss":                m.server.URL,
+		"sub":                m.subject,
+		"aud":                "sublink",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              m.nonce,
+		"preferred_username": m.username,
+		"name":               "SSO " + m.username,
+		"groups":             m.groups,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func setupOIDCTest(t *testing.T) (*mockOIDCIssuer, *gin.Engine) {
+	t.Helper()
+	setupUserRoleTest(t)
+	issuer := newMockOIDCIssuer(t)
+	config.UpdateConfig(func(cfg *config.AppConfig) {
+		cfg.OIDC = config.OIDCConfig{
+			Issuer:               issuer.server.URL,
+			ClientID:             "sublink",
+			ClientSecret:         "secret",
+			RedirectURL:          "http://sublink.test/api/v1/auth/oidc/callback",
+			RoleMapping:          map[string]string{"ops": models.RoleOperator, "sre": models.RoleAdmin},
+			DisablePasswordLogin: true,
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/api/v1/auth/oidc/login", OIDCLogin)
+	engine.GET("/api/v1/auth/oidc/callback", OIDCCallback)
+	return issuer, engine
+}
+
+// performOIDCLogin 走完一次完整的授权码流程，返回回调最终跳转的前端地址
+func performOIDCLogin(t *testing.T, issuer *mockOIDCIssuer, engine *gin.Engine, subject, username string, groups []string) *url.URL {
+	t.Helper()
+	return performOIDCFlow(t, issuer, engine, "/api/v1/auth/oidc/login", subject, username, groups)
+}
+
+// performOIDCFlow 从指定的登录入口开始走完授权码流程
+func performOIDCFlow(t *testing.T, issuer *mockOIDCIssuer, engine *gin.Engine, loginPath, subject, username string, groups []string) *url.URL {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequestWithContext(context.Background(), http.MethodGet, loginPath, nil))
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("expected redirect to issuer, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	return completeOIDCFlow(t, issuer, engine, recorder.Header().Get("Location"), recorder.Result().Cookies(), subject, username, groups)
+}
+
+// completeOIDCFlow 模拟浏览器访问授权地址并带着 cookies 回调，返回回调最终跳转的前端地址
+func completeOIDCFlow(t *testing.T, issuer *mockOIDCIssuer, engine *gin.Engine, rawAuthURL string, cookies []*http.Cookie, subject, username string, groups []string) *url.URL {
+	t.Helper()
+	authURL, err := url.Parse(rawAuthURL)
+	if err != nil || !strings.HasPrefix(authURL.String(), issuer.server.URL+"/authorize") {
+		t.Fatalf("unexpected authorization url %q", rawAuthURL)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("nonce") == "" {
+		t.Fatalf("expected PKCE and nonce in authorization url, got %v", query)
+	}
+
+	issuer.mu.Lock()
+	issuer.nonce = query.Get("nonce")
+	issuer.challenge = query.Get("code_challenge")
+	issuer.subject, issuer.username, issuer.groups = subject, username, groups
+	issuer.mu.Unlock()
+
+	callback := httptest.NewRequestWithContext(context.Background(), http.MethodGet,
+		"/api/v1/auth/oidc/callback?code=test-code&state="+url.QueryEscape(query.Get("state")), nil)
+	for _, cookie := range cookies {
+		callback.AddCookie(cookie)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, callback)
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("expected redirect to frontend, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse frontend redirect: %v", err)
+	}
+	return location
+}
+
+// requestOIDCLink 以指定用户身份申请关联，返回授权地址与写入发起者浏览器的 cookies
+func requestOIDCLink(t *testing.T, username string) (string, []*http.Cookie) {
+	t.Helper()
+	rec := performJSONRequestWithContext(t, OIDCLinkURL, nil, username)
+	var resp struct {
+		Code int `json:"code"`
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Code != 200 || resp.Data.URL == "" {
+		t.Fatalf("expected link url, got %s", rec.Body.String())
+	}
+	return resp.Data.URL, rec.Result().Cookies()
+}
+
+func TestOIDCLoginProvisionsUserWithMappedRole(t *testing.T) {
+	issuer, engine := setupOIDCTest(t)
+
+	location := performOIDCLogin(t, issuer, engine, "user-1", "alice", []string{"staff", "ops"})
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if location.Path != "/login" || fragment.Get("oidc_token") == "" {
+		t.Fatalf("expected token in login fragment, got %s", location)
+	}
+	claims, err := middlewares.ParseToken(fragment.Get("oidc_token"))
+	if err != nil || claims.Username != "alice" {
+		t.Fatalf("expected valid token for alice, got %+v, %v", claims, err)
+	}
+	user := &models.User{Username: "alice"}
+	if err := user.Find(); err != nil {
+		t.Fatalf("expected provisioned user: %v", err)
+	}
+	if user.EffectiveRole() != models.RoleOperator || user.OIDCSubject != issuer.server.URL+"|user-1" {
+		t.Fatalf("unexpected provisioned user %+v", user)
+	}
+
+	// 用户组变化后再次登录，角色随映射更新
+	performOIDCLogin(t, issuer, engine, "user-1", "alice", []string{"sre"})
+	if role, _ := models.GetUserRole("alice"); role != models.RoleAdmin {
+		t.Fatalf("expected role to follow group mapping, got %s", role)
+	}
+}
+
+func TestOIDCLoginRejectsUnmappedAndConflictingUsers(t *testing.T) {
+	issuer, engine := setupOIDCTest(t)
+
+	location := performOIDCLogin(t, issuer, engine, "user-2", "bob", []string{"staff"})
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_error") == "" || fragment.Get("oidc_token") != "" {
+		t.Fatalf("expected unmapped user to be rejected, got %s", location)
+	}
+	if err := (&models.User{Username: "bob"}).Find(); err == nil {
+		t.Fatal("expected rejected user not to be provisioned")
+	}
+
+	location = performOIDCLogin(t, issuer, engine, "user-3", "admin-user", []string{"sre"})
+	fragment, _ = url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_error") == "" {
+		t.Fatalf("expected existing local user not to be taken over, got %s", location)
+	}
+}
+
+func TestPasswordLoginDisabledWhenOIDCEnabled(t *testing.T) {
+	setupOIDCTest(t)
+
+	rec := performFormRequest(t, UserLogin, map[string]string{"username": "admin-user", "password": "unused"})
+	resp := decodeAPIResponse(t, rec)
+	if resp.Code == 200 || !strings.Contains(rec.Body.String(), "backend.auth.login.passwordDisabled") {
+		t.Fatalf("expected password login to be disabled, got %+v", resp)
+	}
+}
+
+func TestOIDCLinkRequiresAuthenticatedRequest(t *testing.T) {
+	issuer, engine := setupOIDCTest(t)
+
+	// 身份提供方上的用户名与本地管理员相同，不能直接登录进该账号
+	location := performOIDCLogin(t, issuer, engine, "user-9", "admin-user", []string{"sre"})
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_error") == "" || fragment.Get("oidc_token") != "" {
+		t.Fatalf("expected login by username to be refused, got %s", location)
+	}
+
+	// 登录入口不再接受地址中的关联凭证
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/auth/oidc/login?link=forged.ticket", nil))
+	if location := recorder.Header().Get("Location"); !strings.Contains(location, "oidc_link_error") {
+		t.Fatalf("expected link ticket in login url to be rejected, got %q", location)
+	}
+
+	// 管理员登录后发起关联，关联完成后可通过单点登录进入
+	authURL, cookies := requestOIDCLink(t, "admin-user")
+	location = completeOIDCFlow(t, issuer, engine, authURL, cookies, "user-9", "someone-else", []string{"sre"})
+	if location.Path != "/system/settings" || !strings.Contains(location.Fragment, "oidc_linked=1") {
+		t.Fatalf("expected link success redirect, got %s", location)
+	}
+	user := &models.User{Username: "admin-user"}
+	if err := user.Find(); err != nil || user.OIDCSubject != issuer.server.URL+"|user-9" {
+		t.Fatalf("expected admin-user to be linked, got %+v, %v", user, err)
+	}
+
+	location = performOIDCLogin(t, issuer, engine, "user-9", "someone-else", []string{"sre"})
+	fragment, _ = url.ParseQuery(location.Fragment)
+	claims, err := middlewares.ParseToken(fragment.Get("oidc_token"))
+	if err != nil || claims.Username != "admin-user" {
+		t.Fatalf("expected linked login as admin-user, got %s", location)
+	}
+}
+
+func TestOIDCLoginRequiresLocalMFA(t *testing.T) {
+	issuer, engine := setupOIDCTest(t)
+	user, _ := createMFAEnabledUser(t, "carol", "password")
+	if err := user.LinkOIDCSubject(issuer.server.URL + "|user-5"); err != nil {
+		t.Fatalf("link subject: %v", err)
+	}
+
+	location := performOIDCLogin(t, issuer, engine, "user-5", "carol", []string{"sre"})
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_token") != "" || fragment.Get("oidc_mfa") == "" {
+		t.Fatalf("expected MFA challenge instead of token, got %s", location)
+	}
+	if !strings.Contains(fragment.Get("oidc_mfa_methods"), "totp") {
+		t.Fatalf("expected totp method, got %q", fragment.Get("oidc_mfa_methods"))
+	}
+	if _, _, err := parsePendingMFAChallenge(fragment.Get("oidc_mfa")); err != nil {
+		t.Fatalf("expected usable MFA challenge: %v", err)
+	}
+}
+
+func TestOIDCLinkCannotBeReplayedFromAnotherBrowser(t *testing.T) {
+	issuer, engine := setupOIDCTest(t)
+
+	// 管理员申请的授权地址被转发给受害者，受害者浏览器中没有发起时写入的状态 Cookie
+	authURL, _ := requestOIDCLink(t, "admin-user")
+	location := completeOIDCFlow(t, issuer, engine, authURL, nil, "victim-1", "victim", []string{"sre"})
+	fragment, _ := url.ParseQuery(location.Fragment)
+	if fragment.Get("oidc_error") == "" || strings.Contains(location.Fragment, "oidc_linked") {
+		t.Fatalf("expected replayed link to be rejected, got %s", location)
+	}
+
+	// 受害者自己发起登录时持有的是普通登录状态，也不会关联到管理员
+	location = performOIDCLogin(t, issuer, engine, "victim-1", "admin-user", []string{"sre"})
+	if strings.Contains(location.Fragment, "oidc_linked") {
+		t.Fatalf("expected plain login not to link, got %s", location)
+	}
+	user := &models.User{Username: "admin-user"}
+	if err := user.Find(); err != nil || user.OIDCSubject != "" {
+		t.Fatalf("expected admin-user to stay unlinked, got %+v, %v", user, err)
+	}
+}
@@ -0,0 +1,503 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sublink/config"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type passkeyChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+}
+
+type passkeyAssertionRequest struct {
+	ChallengeToken string          `json:"challengeToken" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+type beginPasskeyRegistrationRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+type finishPasskeyRegistrationRequest struct {
+	ChallengeToken string          `json:"challengeToken" binding:"required"`
+	Name           string          `json:"name"`
+	Credential     json.RawMessage `json:"credential" binding:"required"`
+}
+
+type deletePasskeyRequest struct {
+	ID       int    `json:"id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+// mfaMethods 用户可用的二次验证方式，为空表示无需二次验证
+func mfaMethods(user *models.User) []string {
+	var methods []string
+	if user.TOTPEnabled {
+		methods = append(methods, "totp", "recovery_code")
+	}
+	if models.CountWebAuthnCredentials(user.ID) > 0 {
+		methods = append(methods, "passkey")
+	}
+	return methods
+}
+
+// newWebAuthn 按配置创建 WebAuthn 实例
+// 未配置依赖方时优先使用系统域名设置，其次使用请求的来源，保证直接通过域名访问时开箱即用。
+func newWebAuthn(c *gin.Context) (*webauthn.WebAuthn, error) {
+	cfg := config.GetWebAuthnConfig()
+	origins := cfg.RPOrigins
+	if len(origins) == 0 {
+		origins = []string{defaultWebAuthnOrigin(c)}
+	}
+	rpID := cfg.RPID
+	if rpID == "" {
+		parsed, err := url.Parse(origins[0])
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("无法确定通行密钥依赖方 ID: %s", origins[0])
+		}
+		rpID = parsed.Hostname()
+	}
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     origins,
+	})
+}
+
+// defaultWebAuthnOrigin 未配置来源时使用的来源，优先取系统域名设置
+// 系统域名未带协议时沿用请求的协议。
+func defaultWebAuthnOrigin(c *gin.Context) string {
+	origin := requestOrigin(c)
+	domain, _ := models.GetSetting("system_domain")
+	domain = strings.TrimSpace(domain)
+	if domain == "" {
+		return origin
+	}
+	if !strings.Contains(domain, "://") {
+		domain = strings.SplitN(origin, "://", 2)[0] + "://" + domain
+	}
+	parsed, err := url.Parse(domain)
+	if err != nil || parsed.Host == "" {
+		return origin
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// requestOrigin 根据请求推导浏览器访问时的来源
+// 只有来自可信代理（trusted_proxies）的请求才采信 X-Forwarded-Host 与 X-Forwarded-Proto，
+// 否则任何客户端都能伪造请求头，让依赖方 ID 指向自己控制的域名。
+func requestOrigin(c *gin.Context) string {
+	trusted := fromTrustedProxy(c)
+	scheme := "http"
+	if c.Request.TLS != nil || (trusted && strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")) {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	if forwarded := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Host"), ",")[0]); trusted && forwarded != "" {
+		host = forwarded
+	}
+	if h, port, err := net.SplitHostPort(host); err == nil && ((scheme == "https" && port == "443") || (scheme == "http" && port == "80")) {
+		host = h
+	}
+	return scheme + "://" + host
+}
+
+// fromTrustedProxy 判断请求的直接来源是否在可信代理列表中
+func fromTrustedProxy(c *gin.Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range config.Get().TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if trustedIP := net.ParseIP(proxy); trustedIP != nil && trustedIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginPasskeyMFA 为待完成的二次验证生成通行密钥断言选项
+func BeginPasskeyMFA(c *gin.Context) {
+	var req passkeyChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	claims, challenge, err := parsePendingMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		utils.FailWithData(c, "登录验证已过期或不可用，请重新输入用户名和密码", gin.H{"errorType": "mfa"})
+		return
+	}
+	user, err := models.LoadUserForMFAChallenge(claims.Username)
+	if err != nil {
+		utils.FailWithData(c, "用户不存在", gin.H{"errorType": "credentials"})
+		return
+	}
+	waUser, err := models.LoadWebAuthnUser(user)
+	if err != nil || len(waUser.WebAuthnCredentials()) == 0 {
+		utils.FailWithData(c, "用户未绑定通行密钥", gin.H{"errorType": "mfa"})
+		return
+	}
+	wa, err := newWebAuthn(c)
+	if err != nil {
+		utils.Error("创建 WebAuthn 实例失败: %v", err)
+		utils.FailWithMsg(c, "通行密钥不可用")
+		return
+	}
+	options, session, err := wa.BeginLogin(waUser)
+	if err != nil {
+		utils.Error("生成通行密钥断言失败: %v", err)
+		utils.FailWithMsg(c, "生成通行密钥验证失败")
+		return
+	}
+	if err := saveChallengeSession(challenge, session); err != nil {
+		utils.Error("保存通行密钥会话失败: %v", err)
+		utils.FailWithMsg(c, "生成通行密钥验证失败")
+		return
+	}
+	utils.OkDetailed(c, "请使用通行密钥验证", gin.H{"options": options})
+}
+
+// VerifyPasskeyMFALogin 使用通行密钥完成二次验证
+func VerifyPasskeyMFALogin(c *gin.Context) {
+	var req passkeyAssertionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	claims, challenge, err := parsePendingMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		utils.FailWithData(c, "登录验证已过期或不可用，请重新输入用户名和密码", gin.H{"errorType": "mfa"})
+		return
+	}
+	user, err := models.LoadUserForMFAChallenge(claims.Username)
+	if err != nil {
+		utils.FailWithData(c, "用户不存在", gin.H{"errorType": "credentials"})
+		return
+	}
+	waUser, err := models.LoadWebAuthnUser(user)
+	if err != nil {
+		utils.FailWithData(c, "用户未绑定通行密钥", gin.H{"errorType": "mfa"})
+		return
+	}
+	credential, err := validatePasskeyAssertion(c, challenge, req.Credential, func(wa *webauthn.WebAuthn, session webauthn.SessionData, parsed *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error) {
+		return wa.ValidateLogin(waUser, session, parsed)
+	})
+	if err != nil {
+		utils.Warn("通行密钥二次验证失败: %v", err)
+		recordMFAChallengeFailure(challenge)
+		utils.FailWithData(c, "通行密钥验证失败", gin.H{"errorType": "mfa"})
+		return
+	}
+	if err := consumeMFAChallenge(challenge); err != nil {
+		utils.FailWithData(c, "登录验证已失效，请重新登录", gin.H{"errorType": "mfa"})
+		return
+	}
+	if err := models.UpdateWebAuthnCredentialUsage(user.ID, credential); err != nil {
+		utils.Warn("更新通行密钥使用记录失败: %v", err)
+	}
+	respondLoginSuccess(c, user, c.ClientIP())
+}
+
+// PasskeyStatus 返回登录页是否显示通行密钥登录
+func PasskeyStatus(c *gin.Context) {
+	utils.OkWithData(c, gin.H{"passwordless": config.GetWebAuthnConfig().Passwordless})
+}
+
+// BeginPasskeyLogin 开始通行密钥免密码登录
+func BeginPasskeyLogin(c *gin.Context) {
+	if !config.GetWebAuthnConfig().Passwordless {
+		utils.FailWithMsg(c, "未启用通行密钥免密码登录")
+		return
+	}
+	wa, err := newWebAuthn(c)
+	if err != nil {
+		utils.Error("创建 WebAuthn 实例失败: %v", err)
+		utils.FailWithMsg(c, "通行密钥不可用")
+		return
+	}
+	options, session, err := wa.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		utils.Error("生成通行密钥断言失败: %v", err)
+		utils.FailWithMsg(c, "生成通行密钥验证失败")
+		return
+	}
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		utils.FailWithMsg(c, "生成通行密钥验证失败")
+		return
+	}
+	challengeToken, err := issueChallenge("", challengePurposePasskeyLogin, string(sessionData))
+	if err != nil {
+		utils.Error("生成通行密钥登录挑战失败: %v", err)
+		utils.FailWithMsg(c, "生成通行密钥验证失败")
+		return
+	}
+	utils.OkDetailed(c, "请使用通行密钥登录", gin.H{
+		"challengeToken": challengeToken,
+		"options":        options,
+	})
+}
+
+// FinishPasskeyLogin 校验通行密钥断言并完成免密码登录
+// 通行密钥要求用户验证（指纹、PIN 等），本身即为多因素，不再进行 TOTP 验证。
+func FinishPasskeyLogin(c *gin.Context) {
+	if !config.GetWebAuthnConfig().Passwordless {
+		utils.FailWithMsg(c, "未启用通行密钥免密码登录")
+		return
+	}
+	var req passkeyAssertionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	_, challenge, err := parseChallenge(req.ChallengeToken, challengePurposePasskeyLogin)
+	if err != nil {
+		utils.FailWithData(c, "登录验证已过期，请重试", gin.H{"errorType": "passkey"})
+		return
+	}
+
+	var waUser *models.WebAuthnUser
+	credential, err := validatePasskeyAssertion(c, challenge, req.Credential, func(wa *webauthn.WebAuthn, session webauthn.SessionData, parsed *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error) {
+		return wa.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+			user, err := models.FindUserByWebAuthnHandle(userHandle)
+			if err != nil {
+				return nil, err
+			}
+			waUser, err = models.LoadWebAuthnUser(user)
+			return waUser, err
+		}, session, parsed)
+	})
+	if err != nil || waUser == nil {
+		utils.Warn("通行密钥登录失败: %v", err)
+		recordMFAChallengeFailure(challenge)
+		utils.FailWithData(c, "通行密钥验证失败", gin.H{"errorType": "passkey"})
+		return
+	}
+	if err := consumeMFAChallenge(challenge); err != nil {
+		utils.FailWithData(c, "登录验证已失效，请重试", gin.H{"errorType": "passkey"})
+		return
+	}
+	if err := models.UpdateWebAuthnCredentialUsage(waUser.User.ID, credential); err != nil {
+		utils.Warn("更新通行密钥使用记录失败: %v", err)
+	}
+	respondLoginSuccess(c, waUser.User, c.ClientIP())
+}
+
+// ListPasskeys 获取当前用户的通行密钥
+func ListPasskeys(c *gin.Context) {
+	user, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	passkeys, err := models.ListWebAuthnCredentials(user.ID)
+	if err != nil {
+		utils.Error("获取通行密钥失败: %v", err)
+		utils.FailWithMsg(c, "获取通行密钥失败")
+		return
+	}
+	utils.OkDetailed(c, "获取通行密钥成功", gin.H{
+		"passkeys":     passkeys,
+		"passwordless": config.GetWebAuthnConfig().Passwordless,
+	})
+}
+
+// BeginPasskeyRegistration 验证当前密码后生成通行密钥注册选项
+func BeginPasskeyRegistration(c *gin.Context) {
+	user, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	var req beginPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := requireMFAReauth(user, req.Password, req.Code); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	waUser, err := models.LoadWebAuthnUser(user)
+	if err != nil {
+		utils.Error("加载通行密钥失败: %v", err)
+		utils.FailWithMsg(c, "开始注册通行密钥失败")
+		return
+	}
+	if len(waUser.WebAuthnCredentials()) >= models.WebAuthnMaxCredentials {
+		utils.FailWithMsg(c, models.ErrWebAuthnCredentialLimit.Error())
+		return
+	}
+	wa, err := newWebAuthn(c)
+	if err != nil {
+		utils.Error("创建 WebAuthn 实例失败: %v", err)
+		utils.FailWithMsg(c, "通行密钥不可用")
+		return
+	}
+	options, session, err := wa.BeginRegistration(waUser,
+		webauthn.WithExclusions(webauthn.Credentials(waUser.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		utils.Error("生成通行密钥注册选项失败: %v", err)
+		utils.FailWithMsg(c, "开始注册通行密钥失败")
+		return
+	}
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		utils.FailWithMsg(c, "开始注册通行密钥失败")
+		return
+	}
+	challengeToken, err := issueChallenge(user.Username, challengePurposePasskeyRegister, string(sessionData))
+	if err != nil {
+		utils.Error("生成通行密钥注册挑战失败: %v", err)
+		utils.FailWithMsg(c, "开始注册通行密钥失败")
+		return
+	}
+	utils.OkDetailed(c, "请在认证器上完成注册", gin.H{
+		"challengeToken": challengeToken,
+		"options":        options,
+	})
+}
+
+// FinishPasskeyRegistration 校验认证器返回的凭证并保存
+func FinishPasskeyRegistration(c *gin.Context) {
+	user, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	var req finishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	claims, challenge, err := parseChallenge(req.ChallengeToken, challengePurposePasskeyRegister)
+	if err != nil || claims.Username != user.Username {
+		utils.FailWithMsg(c, "注册已过期，请重新开始")
+		return
+	}
+	waUser, err := models.LoadWebAuthnUser(user)
+	if err != nil {
+		utils.Error("加载通行密钥失败: %v", err)
+		utils.FailWithMsg(c, "注册通行密钥失败")
+		return
+	}
+	session, err := challengeSession(challenge)
+	if err != nil {
+		utils.FailWithMsg(c, "注册已过期，请重新开始")
+		return
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		recordMFAChallengeFailure(challenge)
+		utils.FailWithMsg(c, "通行密钥数据无效")
+		return
+	}
+	wa, err := newWebAuthn(c)
+	if err != nil {
+		utils.Error("创建 WebAuthn 实例失败: %v", err)
+		utils.FailWithMsg(c, "通行密钥不可用")
+		return
+	}
+	credential, err := wa.CreateCredential(waUser, session, parsed)
+	if err != nil {
+		utils.Warn("通行密钥注册校验失败: %v", err)
+		recordMFAChallengeFailure(challenge)
+		utils.FailWithMsg(c, "通行密钥注册校验失败")
+		return
+	}
+	if err := consumeMFAChallenge(challenge); err != nil {
+		utils.FailWithMsg(c, "注册已过期，请重新开始")
+		return
+	}
+	record, err := models.AddWebAuthnCredential(user.ID, req.Name, credential)
+	if err != nil {
+		if errors.Is(err, models.ErrWebAuthnCredentialLimit) {
+			utils.FailWithMsg(c, err.Error())
+			return
+		}
+		utils.Error("保存通行密钥失败: %v", err)
+		utils.FailWithMsg(c, "保存通行密钥失败")
+		return
+	}
+	utils.Info("用户 %s 注册了通行密钥 %s", user.Username, record.Name)
+	utils.OkDetailed(c, "通行密钥已添加", gin.H{
+		"passkey": record,
+		"status":  buildMFAStatus(user),
+	})
+}
+
+// DeletePasskey 验证当前密码后删除通行密钥
+func DeletePasskey(c *gin.Context) {
+	user, ok := requireCurrentUser(c)
+	if !ok {
+		return
+	}
+	var req deletePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := requireMFAReauth(user, req.Password, req.Code); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	if err := models.DeleteWebAuthnCredential(user.ID, req.ID); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	utils.OkDetailed(c, "通行密钥已删除", buildMFAStatus(user))
+}
+
+// validatePasskeyAssertion 解析并校验通行密钥断言
+func validatePasskeyAssertion(c *gin.Context, challenge *models.MFALoginChallenge, raw json.RawMessage,
+	validate func(*webauthn.WebAuthn, webauthn.SessionData, *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error)) (*webauthn.Credential, error) {
+	session, err := challengeSession(challenge)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	wa, err := newWebAuthn(c)
+	if err != nil {
+		return nil, err
+	}
+	return validate(wa, session, parsed)
+}
+
+func saveChallengeSession(challenge *models.MFALoginChallenge, session *webauthn.SessionData) error {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return models.SaveMFAChallengeSession(challenge, string(raw))
+}
+
+func challengeSession(challenge *models.MFALoginChallenge) (webauthn.SessionData, error) {
+	var session webauthn.SessionData
+	if strings.TrimSpace(challenge.SessionData) == "" {
+		return session, errors.New("请先开始通行密钥验证")
+	}
+	err := json.Unmarshal([]byte(challenge.SessionData), &session)
+	return session, err
+}
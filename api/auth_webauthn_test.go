@@ -0,0 +1,299 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"sublink/config"
+	"sublink/database"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+)
+
+// httptest 请求的 Host 为 example.com，未配置依赖方时按此推导
+const testPasskeyOrigin = "http://example.com"
+
+// testAuthenticator 软件实现的认证器，使用 none 证明格式与 ES256 密钥
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+type passkeyOptionsData struct {
+	ChallengeToken string `json:"challengeToken"`
+	Options        struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			RPID      string `json:"rpId"`
+			RP        struct {
+				ID string `json:"id"`
+			} `json:"rp"`
+			User struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	} `json:"options"`
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate credential key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+	return &testAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *testAuthenticator) authData(rpID string, flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	a.signCount++
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func clientDataJSON(t *testing.T, typ, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": testPasskeyOrigin})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+	return data
+}
+
+// register 根据注册选项生成 attestation 响应
+func (a *testAuthenticator) register(t *testing.T, options passkeyOptionsData) json.RawMessage {
+	t.Helper()
+	userHandle, err := base64.RawURLEncoding.DecodeString(options.Options.PublicKey.User.ID)
+	if err != nil {
+		t.Fatalf("decode user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	coseKey, err := webauthncbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("marshal cose key: %v", err)
+	}
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(options.Options.PublicKey.RP.ID, 0x45, attested), // UP | UV | AT
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation: %v", err)
+	}
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientDataJSON(t, "webauthn.create", options.Options.PublicKey.Challenge)),
+		"attestationObject": base64.RawURLEncoding.EncodeToString(attestation),
+	})
+}
+
+// assert 根据断言选项生成签名响应
+func (a *testAuthenticator) assert(t *testing.T, options passkeyOptionsData) json.RawMessage {
+	t.Helper()
+	authData := a.authData(options.Options.PublicKey.RPID, 0x05, nil) // UP | UV
+	clientData := clientDataJSON(t, "webauthn.get", options.Options.PublicKey.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientData),
+		"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+		"signature":         base64.RawURLEncoding.EncodeToString(signature),
+		"userHandle":        base64.RawURLEncoding.EncodeToString(a.userHandle),
+	})
+}
+
+func (a *testAuthenticator) credentialJSON(t *testing.T, response map[string]string) json.RawMessage {
+	t.Helper()
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	raw, err := json.Marshal(map[string]any{"id": id, "rawId": id, "type": "public-key", "response": response})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+	return raw
+}
+
+func decodePasskeyOptions(t *testing.T, resp apiJSONResponse) passkeyOptionsData {
+	t.Helper()
+	if resp.Code != 200 {
+		t.Fatalf("expected passkey options, got %+v", resp)
+	}
+	var data passkeyOptionsData
+	if err := json.Unmarshal(resp.Data, &data); err != nil || data.Options.PublicKey.Challenge == "" {
+		t.Fatalf("unexpected passkey options %s: %v", resp.Data, err)
+	}
+	return data
+}
+
+// registerTestPasskey 创建用户并为其注册一个通行密钥
+func registerTestPasskey(t *testing.T) (*models.User, *testAuthenticator) {
+	t.Helper()
+	user := &models.User{Username: "admin", Password: "123456", Role: models.RoleAdmin, Nickname: "管理员"}
+	if err := user.Create(); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	authenticator := newTestAuthenticator(t)
+
+	rec := performJSONRequestWithContext(t, BeginPasskeyRegistration, map[string]string{"password": "123456"}, user.Username)
+	options := decodePasskeyOptions(t, decodeAPIResponse(t, rec))
+	rec = performJSONRequestWithContext(t, FinishPasskeyRegistration, map[string]any{
+		"challengeToken": options.ChallengeToken,
+		"name":           "Laptop",
+		"credential":     authenticator.register(t, options),
+	}, user.Username)
+	if resp := decodeAPIResponse(t, rec); resp.Code != 200 {
+		t.Fatalf("finish passkey registration failed: %+v", resp)
+	}
+	return user, authenticator
+}
+
+func decodeAccessToken(t *testing.T, resp apiJSONResponse) string {
+	t.Helper()
+	var data struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected login success, got %+v", resp)
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil || data.AccessToken == "" {
+		t.Fatalf("expected access token, got %s", resp.Data)
+	}
+	return data.AccessToken
+}
+
+func TestPasskeyRegistrationAndSecondFactorLogin(t *testing.T) {
+	setupAuthMFATestDB(t)
+	user, authenticator := registerTestPasskey(t)
+
+	passkeys, err := models.ListWebAuthnCredentials(user.ID)
+	if err != nil || len(passkeys) != 1 || passkeys[0].Name != "Laptop" {
+		t.Fatalf("expected registered passkey, got %+v, %v", passkeys, err)
+	}
+
+	rec := performFormRequest(t, UserLogin, map[string]string{"username": "admin", "password": "123456"})
+	var login struct {
+		RequiresMFA    bool     `json:"requiresMFA"`
+		ChallengeToken string   `json:"challengeToken"`
+		Methods        []string `json:"methods"`
+	}
+	if err := json.Unmarshal(decodeAPIResponse(t, rec).Data, &login); err != nil {
+		t.Fatalf("unmarshal login response: %v", err)
+	}
+	if !login.RequiresMFA || len(login.Methods) != 1 || login.Methods[0] != "passkey" {
+		t.Fatalf("expected passkey second factor, got %+v", login)
+	}
+
+	rec = performJSONRequestWithContext(t, BeginPasskeyMFA, map[string]string{"challengeToken": login.ChallengeToken}, "")
+	options := decodePasskeyOptions(t, decodeAPIResponse(t, rec))
+	assertion := authenticator.assert(t, options)
+	rec = performJSONRequestWithContext(t, VerifyPasskeyMFALogin, map[string]any{
+		"challengeToken": login.ChallengeToken,
+		"credential":     assertion,
+	}, "")
+	decodeAccessToken(t, decodeAPIResponse(t, rec))
+
+	rec = performJSONRequestWithContext(t, VerifyPasskeyMFALogin, map[string]any{
+		"challengeToken": login.ChallengeToken,
+		"credential":     assertion,
+	}, "")
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected consumed challenge not to be reusable")
+	}
+
+	passkeys, _ = models.ListWebAuthnCredentials(user.ID)
+	if passkeys[0].LastUsedAt == nil {
+		t.Fatal("expected passkey usage to be recorded")
+	}
+}
+
+func TestPasskeyPasswordlessLogin(t *testing.T) {
+	setupAuthMFATestDB(t)
+	_, authenticator := registerTestPasskey(t)
+
+	rec := performJSONRequestWithContext(t, BeginPasskeyLogin, map[string]string{}, "")
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected passwordless login to be disabled by default")
+	}
+
+	config.UpdateConfig(func(cfg *config.AppConfig) {
+		cfg.WebAuthn.Passwordless = true
+	})
+	rec = performJSONRequestWithContext(t, BeginPasskeyLogin, map[string]string{}, "")
+	options := decodePasskeyOptions(t, decodeAPIResponse(t, rec))
+
+	forged := newTestAuthenticator(t)
+	forged.credentialID, forged.userHandle = authenticator.credentialID, authenticator.userHandle
+	rec = performJSONRequestWithContext(t, FinishPasskeyLogin, map[string]any{
+		"challengeToken": options.ChallengeToken,
+		"credential":     forged.assert(t, options),
+	}, "")
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected assertion signed by another key to be rejected")
+	}
+
+	rec = performJSONRequestWithContext(t, FinishPasskeyLogin, map[string]any{
+		"challengeToken": options.ChallengeToken,
+		"credential":     authenticator.assert(t, options),
+	}, "")
+	decodeAccessToken(t, decodeAPIResponse(t, rec))
+}
+
+func TestRequestOriginTrustsForwardedHeadersOnlyFromProxies(t *testing.T) {
+	setupAuthMFATestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(remoteAddr string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("POST", "/api/v1/auth/passkey/begin", nil)
+		ctx.Request.RemoteAddr = remoteAddr
+		ctx.Request.Header.Set("X-Forwarded-Host", "attacker.example")
+		ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+		return ctx
+	}
+
+	if origin := requestOrigin(newContext("203.0.113.7:4000")); origin != "http://example.com" {
+		t.Fatalf("forwarded headers from an untrusted peer must be ignored, got %s", origin)
+	}
+	if origin := requestOrigin(newContext("127.0.0.1:4000")); origin != "https://attacker.example" {
+		t.Fatalf("forwarded headers from a trusted proxy should be used, got %s", origin)
+	}
+
+	if err := database.DB.AutoMigrate(&models.SystemSetting{}); err != nil {
+		t.Fatalf("auto migrate settings: %v", err)
+	}
+	if err := models.SetSetting("system_domain", "https://sub.example.com/"); err != nil {
+		t.Fatalf("set system domain: %v", err)
+	}
+	t.Cleanup(func() { _ = models.SetSetting("system_domain", "") })
+	if origin := defaultWebAuthnOrigin(newContext("127.0.0.1:4000")); origin != "https://sub.example.com" {
+		t.Fatalf("expected system domain to take precedence, got %s", origin)
+	}
+}
@@ -1,162 +1,237 @@
 package api
 
 import (
-	"archive/zip"
+	"context"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"os"
 	"path/filepath"
-	"sublink/config"
+	"time"
+
+	"sublink/models"
+	"sublink/services"
+	"sublink/services/backup"
+	"sublink/services/scheduler"
 	"sublink/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Backup 即时生成备份包并下载，启用备份加密时下载的是加密备份
 func Backup(c *gin.Context) {
-	// 创建临时文件用于存储压缩包
+	settings, err := backup.LoadSettings()
+	if err != nil && settings.Encryption.Enabled {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
 	tmpFile, err := os.CreateTemp("", "backup-*.zip")
 	if err != nil {
 		utils.FailWithMsg(c, "Failed to create temp file")
 		return
 	}
-	defer func() { _ = os.Remove(tmpFile.Name()) }() // 确保函数退出时删除临时文件
-	defer func() { _ = tmpFile.Close() }()           // 确保函数退出时关闭临时文件
-
-	// 创建zip写入器
-	zipWriter := zip.NewWriter(tmpFile)
-	// defer zipWriter.Close() // 不在这里 defer，我们需要在发送文件前手动 Close
-
-	// 获取配置的数据库目录路径
-	dbPath := config.GetDBPath()
-
-	// 获取模板目录路径（基于当前工作目录）
-	templatePath := "template"
-	if cwd, err := os.Getwd(); err == nil {
-		templatePath = filepath.Join(cwd, "template")
-	}
-
-	// 备份目录配置：源路径 -> zip 内的目录名
-	type backupFolder struct {
-		sourcePath string // 实际文件系统路径
-		zipName    string // zip 中的目录名称
-	}
-	folders := []backupFolder{
-		{sourcePath: dbPath, zipName: "db"},
-		{sourcePath: templatePath, zipName: "template"},
-	}
-
-	// 遍历文件夹并添加到zip文件中
-	for _, folder := range folders {
-		baseDir := folder.sourcePath
-		zipPrefix := folder.zipName
-
-		// filepath.Walk 会遍历所有子文件和子目录
-		walkErr := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			// 跳过 GeoIP 数据库文件，该文件体积大且可重新下载
-			if !info.IsDir() && info.Name() == "GeoLite2-City.mmdb" {
-				return nil
-			}
-
-			// 计算相对路径
-			relPath, err := filepath.Rel(baseDir, path)
-			if err != nil {
-				return err
-			}
-
-			// 创建zip中的文件头
-			header, err := zip.FileInfoHeader(info)
-			if err != nil {
-				return err
-			}
-
-			// 构建 zip 中的路径：zipPrefix + 相对路径
-			if relPath == "." {
-				header.Name = zipPrefix
-			} else {
-				header.Name = filepath.ToSlash(filepath.Join(zipPrefix, relPath))
-			}
-
-			// 如果是目录，需要以/结尾
-			if info.IsDir() {
-				header.Name += "/"
-			} else {
-				// 设置压缩方法
-				header.Method = zip.Deflate
-			}
-
-			// 创建zip中的文件
-			writer, err := zipWriter.CreateHeader(header)
-			if err != nil {
-				return err
-			}
-
-			// 如果是文件，写入文件内容
-			if !info.IsDir() {
-				file, err := os.Open(path)
-				if err != nil {
-					return err // **[修正]** 只返回 error
-				}
-
-				// **[关键修正]** 不要使用 defer file.Close()！
-				// 立即复制并关闭文件，防止文件句柄泄露
-
-				_, err = io.Copy(writer, file)
-				closeErr := file.Close() // <--- 立即关闭
-
-				if err != nil {
-					return err // **[修正]** 只返回 error
-				}
-				if closeErr != nil {
-					return closeErr
-				}
-			}
-			return nil
-		})
-
-		// 在 Walk 循环结束后，统一检查错误
-		if walkErr != nil {
-			utils.FailWithMsg(c, "备份目录 '"+zipPrefix+"' 失败: "+walkErr.Error())
-			return
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	defer func() { _ = tmpFile.Close() }()
+
+	if _, err := backup.WriteConfiguredArchive(c.Request.Context(), tmpFile, settings); err != nil {
+		utils.FailWithMsg(c, "生成备份失败: "+err.Error())
+		return
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		utils.FailWithMsg(c, "Failed to seek temp file: "+err.Error())
+		return
+	}
+
+	fileName := backup.FilePrefix + time.Now().Format("20060102-150405") + backup.FileExt(settings)
+	contentType := "application/zip"
+	if settings.Encryption.Enabled {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if _, err := io.Copy(c.Writer, tmpFile); err != nil {
+		utils.Warn("发送备份文件失败: %v", err)
+	}
+}
+
+// GetBackupSettings 获取自动备份配置
+func GetBackupSettings(c *gin.Context) {
+	utils.OkWithData(c, backup.LoadPublicSettings())
+}
+
+// UpdateBackupSettings 保存自动备份配置并重新注册定时任务
+func UpdateBackupSettings(c *gin.Context) {
+	var req backup.Settings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误: "+err.Error())
+		return
+	}
+	if err := backup.SaveSettings(req); err != nil {
+		utils.FailWithMsg(c, "保存备份配置失败: "+err.Error())
+		return
+	}
+	if err := scheduler.GetSchedulerManager().StartBackupTask(); err != nil {
+		utils.FailWithMsg(c, "备份配置已保存，但定时任务注册失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "保存成功", backup.LoadPublicSettings())
+}
+
+// TestBackupS3 使用提交的配置测试 S3 连接，Secret 为空时使用已保存的值
+func TestBackupS3(c *gin.Context) {
+	var req backup.S3Settings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误: "+err.Error())
+		return
+	}
+	if req.SecretAccessKey == "" {
+		if current, err := backup.LoadSettings(); err == nil {
+			req.SecretAccessKey = current.S3.SecretAccessKey
 		}
 	}
+	client, err := backup.NewS3Client(req)
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	objects, err := client.ListObjects(ctx, client.ObjectKey(backup.FilePrefix))
+	if err != nil {
+		utils.FailWithMsg(c, "连接失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "连接成功", gin.H{"objects": len(objects)})
+}
+
+// RunBackupNow 立即执行一次备份（写入本地目录，并按配置上传与清理）
+func RunBackupNow(c *gin.Context) {
+	result, err := backup.RunBackup(c.Request.Context())
+	if err != nil {
+		utils.FailWithMsg(c, "备份失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "备份完成", result)
+}
 
-	// **[关键修正]** 必须在发送文件 *之前* 关闭 zipWriter，
-	// 这样才能将 zip 的中央目录结构写入文件
-	err = zipWriter.Close()
+// ListBackups 列出本地备份文件
+func ListBackups(c *gin.Context) {
+	settings, _ := backup.LoadSettings()
+	files, err := backup.ListLocal(backup.ResolveDir(settings))
 	if err != nil {
-		utils.FailWithMsg(c, "Failed to close zip writer: "+err.Error())
+		utils.FailWithMsg(c, "读取备份目录失败: "+err.Error())
 		return
 	}
+	utils.OkWithData(c, files)
+}
 
-	// 确保临时文件已完全写入（可选，但在某些系统上更安全）
-	err = tmpFile.Sync()
+// DownloadBackupFile 下载本地备份文件
+func DownloadBackupFile(c *gin.Context) {
+	name := c.Param("name")
+	path, err := backup.LocalPath(name)
 	if err != nil {
-		utils.FailWithMsg(c, "Failed to sync temp file: "+err.Error())
+		utils.FailWithMsg(c, err.Error())
 		return
 	}
+	c.FileAttachment(path, name)
+}
 
-	// **[修正]** 在所有操作都成功后，再设置响应头
-	c.Header("Content-Type", "application/zip")
-	c.Header("Content-Disposition", "attachment; filename=sublink-pro-backup.zip")
+// RestoreBackup 校验并恢复备份包
+// 支持上传文件（file）或指定本地备份文件名（name），加密备份需提供 passphrase（为空时使用已保存的密码）。
+// 解密与校验在请求内完成，通过后以任务方式执行恢复。
+func RestoreBackup(c *gin.Context) {
+	zipPath, sourceName, removeUpload, ok := resolveBackupSourceFile(c)
+	if !ok {
+		return
+	}
 
-	// 将文件指针重置到文件开头
-	_, err = tmpFile.Seek(0, 0)
+	source, err := services.PrepareBackupRestore(zipPath, sourceName, c.PostForm("passphrase"), removeUpload)
 	if err != nil {
-		utils.FailWithMsg(c, "Failed to seek temp file: "+err.Error())
+		utils.FailWithMsg(c, "备份校验失败: "+err.Error())
+		return
+	}
+
+	taskName := fmt.Sprintf("备份恢复: %s", sourceName)
+	task, ctx, err := services.GetTaskManager().CreateTask(models.TaskTypeBackupRestore, taskName, models.TaskTriggerManual, 1)
+	if err != nil {
+		source.Cleanup()
+		utils.FailWithMsg(c, "创建恢复任务失败: "+err.Error())
 		return
 	}
 
-	// 将文件内容发送给客户端
-	_, err = io.Copy(c.Writer, tmpFile)
+	go services.RunBackupRestoreTask(ctx, task.ID, source)
+
+	utils.OkDetailed(c, "恢复任务已启动", gin.H{
+		"taskId": task.ID,
+		"source": source,
+	})
+}
+
+// CheckBackupKey 校验加密备份的密码，不解压、不修改任何数据
+// 参数与 RestoreBackup 相同。
+func CheckBackupKey(c *gin.Context) {
+	path, _, removeUpload, ok := resolveBackupSourceFile(c)
+	if !ok {
+		return
+	}
+	if removeUpload {
+		defer func() { _ = os.Remove(path) }()
+	}
+
+	encrypted, err := services.CheckBackupPassphrase(path, c.PostForm("passphrase"))
 	if err != nil {
-		// 此时可能已经发送了部分响应，JSON 可能无效，但尽力而为
-		// utils.FailWithMsg(c, "Failed to send file to client: "+err.Error())
-		// Since headers might be sent, we can't cleanly send JSON. But let's leave it or log it.
-		// The original code tried to send JSON. I'll keep it consistent.
-		utils.FailWithMsg(c, "Failed to send file to client: "+err.Error())
+		utils.FailWithMsg(c, "密码校验失败: "+err.Error())
 		return
 	}
+	if !encrypted {
+		utils.OkDetailed(c, "备份未加密，无需密码", gin.H{"encrypted": false})
+		return
+	}
+	utils.OkDetailed(c, "密码正确", gin.H{"encrypted": true})
+}
+
+// resolveBackupSourceFile 从表单中解析备份来源：本地备份文件名（name）或上传文件（file）
+// 上传的文件会保存到迁移临时目录，removeUpload 为 true 时由调用方负责删除。
+func resolveBackupSourceFile(c *gin.Context) (path, sourceName string, removeUpload, ok bool) {
+	if name := c.PostForm("name"); name != "" {
+		localPath, err := backup.LocalPath(name)
+		if err != nil {
+			utils.FailWithMsg(c, err.Error())
+			return "", "", false, false
+		}
+		return localPath, name, false, true
+	}
+
+	uploadedFile, err := c.FormFile("file")
+	if err != nil {
+		utils.FailWithMsg(c, "请上传备份文件或选择本地备份")
+		return "", "", false, false
+	}
+	tempFile, err := createDatabaseMigrationUploadFile(filepath.Ext(uploadedFile.Filename))
+	if err != nil {
+		utils.FailWithMsg(c, "创建恢复临时文件失败")
+		return "", "", false, false
+	}
+	if err := saveUploadedFile(uploadedFile, tempFile); err != nil {
+		_ = os.Remove(tempFile.Name())
+		utils.FailWithMsg(c, "保存上传文件失败: "+err.Error())
+		return "", "", false, false
+	}
+	return tempFile.Name(), uploadedFile.Filename, true, true
+}
+
+// saveUploadedFile 把上传内容写入已创建的临时文件并关闭
+func saveUploadedFile(fileHeader *multipart.FileHeader, target *os.File) error {
+	src, err := fileHeader.Open()
+	if err != nil {
+		_ = target.Close()
+		return err
+	}
+	_, err = io.Copy(target, src)
+	_ = src.Close()
+	closeErr := target.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,15 +11,20 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sublink/cache"
 	"sublink/models"
 	"sublink/node"
 	"sublink/node/protocol"
+	"sublink/services/metrics"
+	"sublink/services/mihomo"
 	"sublink/services/substore"
+	"sublink/services/tracing"
 	"sublink/utils"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const subscriptionNameContextKey = "resolvedSubscriptionName"
@@ -87,12 +93,26 @@ var (
 	syntheticTemplateErr  error
 )
 
+// remoteSubscriptionClient 拉取嵌套远程订阅的客户端，外发请求携带链路上下文
+var remoteSubscriptionClient = &http.Client{Transport: tracing.Transport(nil)}
+
+// renderLog 订阅输出子系统日志
+var renderLog = utils.WithSubsystem(utils.SubsystemRender)
+
 func getRemoteSubscription(ctx context.Context, link string) (*http.Response, error) {
+	ctx, span := tracing.Start(ctx, "subscription.remote_fetch")
+	defer span.End()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
 	if err != nil {
+		tracing.End(span, err)
 		return nil, err
 	}
-	return http.DefaultClient.Do(req)
+	span.SetAttributes(attribute.String("server.address", req.URL.Host))
+	resp, err := remoteSubscriptionClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return resp, err
 }
 
 func setResolvedSubscriptionName(c *gin.Context, subName string) {
@@ -127,45 +147,71 @@ func GetClient(c *gin.Context) {
 	// 获取协议头
 	token := c.Query("token")
 	if token == "" {
-		utils.Warn("token为空")
+		renderLog.Warn("token为空")
 		_, _ = c.Writer.WriteString("Not Found")
 		return
 	}
+	start := time.Now()
+	ctx, span := tracing.StartServer(c.Request, "subscription.render")
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
 	clientType := resolveSubscriptionClient(c)
 	prepared, ok := prepareClientResponse(c, clientType, strings.ToLower(token))
 	if !ok {
 		return
 	}
+	span.SetAttributes(
+		attribute.String("sublink.client", prepared.ClientType),
+		attribute.String("sublink.mode", shareAccessMode(prepared)),
+		attribute.Int("sublink.subscription.id", prepared.Subscription.ID),
+		attribute.Int("sublink.share.id", prepared.ShareID),
+	)
 	setResolvedSubscriptionName(c, prepared.SubName)
 	if testGetClientAfterResolveSubscriptionNameHook != nil {
 		testGetClientAfterResolveSubscriptionNameHook(c)
 	}
 	c.Set("shareID", prepared.ShareID)
+	recordShareAccessEvent(c, strings.ToLower(token), prepared)
 	dispatchPreparedClientResponse(c, prepared)
+	metrics.ObserveSubscriptionRender(prepared.ClientType, shareAccessMode(prepared), time.Since(start))
 }
 
 func prepareClientResponse(c *gin.Context, clientType, token string) (preparedClientResponse, bool) {
 	share, err := models.GetSubscriptionShareByToken(token)
 	if err != nil {
-		utils.Warn("无效的分享token: %s", token)
+		renderLog.Warn("无效的分享token: %s", token)
 		return buildSyntheticFallbackResponse(clientType, "无效的分享链接"), true
 	}
+	// 分享强制指定客户端类型时，忽略请求参数与 User-Agent 识别结果
+	if share.ForceClient != "" {
+		clientType = share.ForceClient
+	}
+
+	signed, message := checkShareSignature(c, share)
+	if message != "" {
+		renderLog.Warn("分享签名链接校验失败: %s, %s", token, message)
+		return buildSyntheticFallbackResponse(clientType, message), true
+	}
 
 	if share.IsExpired() {
-		utils.Warn("分享链接已过期: %s", token)
+		renderLog.Warn("分享链接已过期: %s", token)
 		var expiredSub models.Subcription
 		expiredSub.ID = share.SubscriptionID
 		if err := expiredSub.Find(); err != nil {
-			utils.Warn("过期分享关联订阅不存在: %d", share.SubscriptionID)
+			renderLog.Warn("过期分享关联订阅不存在: %d", share.SubscriptionID)
 			return buildSyntheticFallbackResponse(clientType, "订阅不存在"), true
 		}
-		return buildPreparedExpiredShareResponse(expiredSub, clientType, "订阅已过期", share.ID)
+		message := "订阅已过期"
+		if share.IsAbuseDisabled() {
+			message = shareAbuseDisabledMessage
+		}
+		return buildPreparedExpiredShareResponse(c.Request.Context(), expiredSub, clientType, share.FallbackMessageOr(message), share.ID)
 	}
 
 	var sub models.Subcription
 	sub.ID = share.SubscriptionID
 	if err := sub.Find(); err != nil {
-		utils.Warn("订阅不存在: %d", share.SubscriptionID)
+		renderLog.Warn("订阅不存在: %d", share.SubscriptionID)
 		return buildSyntheticFallbackResponse(clientType, "订阅不存在"), true
 	}
 
@@ -183,28 +229,48 @@ func prepareClientResponse(c *gin.Context, clientType, token string) (preparedCl
 		return preparedClientResponse{}, false
 	}
 
+	// 设备绑定检查，签名链接由管理员单独签发，不受设备绑定限制
+	if !signed && !share.CheckDevice(c.ClientIP(), c.GetHeader("User-Agent"), time.Now()) {
+		renderLog.Warn("分享已绑定其他设备: %s, IP: %s", token, c.ClientIP())
+		return buildSyntheticFallbackResponse(clientType, share.FallbackMessageOr(shareDeviceRejectedMessage)), true
+	}
+
+	// 访问频率与不同 IP 数检查，超出限制时自动停用分享
+	if reason := share.CheckAccessLimit(c.ClientIP(), time.Now()); reason != "" {
+		disableAbusedShare(share, sub, reason)
+		return buildPreparedExpiredShareResponse(c.Request.Context(), sub, clientType, share.FallbackMessageOr(shareAbuseDisabledMessage), share.ID)
+	}
+
 	// 异步更新访问统计，避免订阅生成热路径等待数据库写入。
 	share.RecordAccessAsync()
-	prepared, ok := buildPreparedResponseFromSubscription(sub, clientType, share.ID)
+	prepared, ok := buildPreparedResponseFromSubscription(c.Request.Context(), sub, clientType, share.ID)
 	if !ok {
 		return preparedClientResponse{}, false
 	}
 	return prepared, true
 }
 
-func resolveSubscriptionClient(c *gin.Context) string {
-	clientIndex := strings.ToLower(strings.TrimSpace(c.Query("client")))
-	switch clientIndex {
+// normalizeClientType 规范化客户端类型，不支持的类型返回空字符串
+func normalizeClientType(raw string) string {
+	clientType := strings.ToLower(strings.TrimSpace(raw))
+	switch clientType {
 	case "clash", "mihomo", "surge", "v2ray", "uri", "v2ray-uri":
-		return clientIndex
+		return clientType
 	}
-	if substore.IsSupportedTarget(clientIndex) {
-		return clientIndex
+	if substore.IsSupportedTarget(clientType) {
+		return clientType
+	}
+	return ""
+}
+
+func resolveSubscriptionClient(c *gin.Context) string {
+	if clientType := normalizeClientType(c.Query("client")); clientType != "" {
+		return clientType
 	}
 
 	userAgent := c.GetHeader("User-Agent")
 	if userAgent == "" {
-		fmt.Println("User-Agent为空")
+		renderLog.Debug("User-Agent为空")
 	}
 	for _, client := range []string{"clash", "surge"} {
 		if strings.Contains(strings.ToLower(userAgent), strings.ToLower(client)) {
@@ -235,7 +301,7 @@ func dispatchPreparedClientResponse(c *gin.Context, prepared preparedClientRespo
 func buildSyntheticFallbackResponse(clientType, message string) preparedClientResponse {
 	config, err := buildSyntheticFallbackConfig()
 	if err != nil {
-		utils.Warn("构造 synthetic fallback 配置失败: %v", err)
+		renderLog.Warn("构造 synthetic fallback 配置失败: %v", err)
 	}
 	sub := models.Subcription{
 		Name:                  message,
@@ -323,15 +389,29 @@ func writeSyntheticTemplateFile(pattern, content string) (string, error) {
 	return file.Name(), nil
 }
 
-func buildPreparedResponseFromSubscription(sub models.Subcription, clientType string, shareID int) (preparedClientResponse, bool) {
+func buildPreparedResponseFromSubscription(ctx context.Context, sub models.Subcription, clientType string, shareID int) (preparedClientResponse, bool) {
 	preparedSub := sub
 	materializeClientType := clientType
 	if substore.IsSupportedTarget(clientType) || clientType == "uri" || clientType == "v2ray-uri" || clientType == "mihomo" {
 		materializeClientType = "clash"
 	}
-	if err := preparedSub.GetSub(materializeClientType); err != nil {
+	// 分享级覆盖：替换模板并在订阅过滤结果上进一步收窄节点
+	var share *models.SubscriptionShare
+	if shareID > 0 {
+		share = &models.SubscriptionShare{ID: shareID}
+		if err := share.Find(); err != nil {
+			share = nil
+		}
+	}
+	if share != nil {
+		preparedSub.Config = share.ApplyTemplateOverrides(preparedSub.Config)
+	}
+	if err := preparedSub.GetSubWithContext(ctx, materializeClientType); err != nil {
 		return preparedClientResponse{}, false
 	}
+	if share != nil {
+		preparedSub.Nodes = share.ApplyNodeOverrides(preparedSub.Nodes)
+	}
 	return preparedClientResponse{
 		ClientType:       clientType,
 		Mode:             clientResponseNormal,
@@ -342,8 +422,8 @@ func buildPreparedResponseFromSubscription(sub models.Subcription, clientType st
 	}, true
 }
 
-func buildPreparedExpiredShareResponse(sub models.Subcription, clientType, message string, shareID int) (preparedClientResponse, bool) {
-	prepared, ok := buildPreparedResponseFromSubscription(sub, clientType, shareID)
+func buildPreparedExpiredShareResponse(ctx context.Context, sub models.Subcription, clientType, message string, shareID int) (preparedClientResponse, bool) {
+	prepared, ok := buildPreparedResponseFromSubscription(ctx, sub, clientType, shareID)
 	if !ok {
 		return preparedClientResponse{}, false
 	}
@@ -444,13 +524,36 @@ func resolveClashDialerProxy(node models.Node, finalNodeName string, chainNodeDi
 	return normalizeDialerProxyName(dialerProxy, dialerProxyNameMap)
 }
 
+// buildTemplateRenderContext 构造模板渲染上下文：订阅信息、分享信息、节点统计与订阅级变量。
+func buildTemplateRenderContext(prepared preparedClientResponse, sub models.Subcription, vars map[string]string) *protocol.TemplateContext {
+	ctx := &protocol.TemplateContext{
+		Client:       prepared.ClientType,
+		Subscription: protocol.TemplateSubscription{ID: sub.ID, Name: sub.Name},
+		Nodes:        models.BuildTemplateNodeStats(sub.Nodes),
+		Vars:         vars,
+	}
+	if ctx.Vars == nil {
+		ctx.Vars = map[string]string{}
+	}
+	if prepared.ShareID > 0 {
+		share := models.SubscriptionShare{ID: prepared.ShareID}
+		if err := share.Find(); err == nil {
+			ctx.Share = protocol.TemplateShare{ID: share.ID, Name: share.Name, Present: true}
+			if expireAt, ok := share.ExpireTime(); ok {
+				ctx.Share.ExpireAt = expireAt.Format(time.RFC3339)
+			}
+		}
+	}
+	return ctx
+}
+
 func prepareRendererResponse(c *gin.Context, prepared preparedClientResponse) (resolvedPreparedResponse, bool) {
 	resolved := applyPreparedResponseMode(prepared)
 	sub := resolved.Subscription
 	if sub.RefreshUsageOnRequest {
-		node.RefreshUsageForSubscriptionNodes(sub.Nodes)
+		node.RefreshUsageForSubscriptionNodes(c.Request.Context(), sub.Nodes)
 	}
-	c.Writer.Header().Set("subscription-userinfo", getSubscriptionUsage(sub.Nodes))
+	c.Writer.Header().Set("subscription-userinfo", resolveSubscriptionUsage(prepared, sub))
 	if prepared.ClientType == "clash" {
 		c.Writer.Header().Set("profile-update-interval", strconv.Itoa(resolveSubscriptionUpdateIntervalHours(sub.UpdateInterval)))
 		c.Writer.Header().Set("profile-title", url.QueryEscape(resolved.SubName))
@@ -510,7 +613,7 @@ func GetV2ray(c *gin.Context) {
 		_, _ = c.Writer.WriteString("找不到这个订阅:" + subName)
 		return
 	}
-	prepared, ok := buildPreparedResponseFromSubscription(sub, "v2ray", 0)
+	prepared, ok := buildPreparedResponseFromSubscription(c.Request.Context(), sub, "v2ray", 0)
 	if !ok {
 		_, _ = c.Writer.WriteString("读取错误")
 		return
@@ -557,7 +660,7 @@ func renderPreparedV2ray(c *gin.Context, prepared preparedClientResponse) {
 		case (strings.HasPrefix(v.Link, "http://") || strings.HasPrefix(v.Link, "https://")) && !protocol.IsHTTPLink(v.Link):
 			resp, err := getRemoteSubscription(c.Request.Context(), v.Link)
 			if err != nil {
-				utils.Error("Error getting link: %v", err)
+				renderLog.Error("Error getting link: %v", err)
 				return
 			}
 			defer func() { _ = resp.Body.Close() }()
@@ -579,7 +682,9 @@ func renderPreparedV2ray(c *gin.Context, prepared preparedClientResponse) {
 	for _, script := range sub.ScriptsWithSort {
 		res, err := utils.RunScript(script.Content, baselist, "v2ray")
 		if err != nil {
-			utils.Error("Script execution failed: %v", err)
+			utils.WithSubsystem(utils.SubsystemScript).With("script_id", script.ID).With("subscription", sub.Name).
+				Error("脚本【%s】执行失败: %v", script.Name, err)
+			metrics.IncScriptError(metrics.ScriptKindSubscription)
 			continue
 		}
 		baselist = res
@@ -614,7 +719,7 @@ func GetClash(c *gin.Context) {
 		_, _ = c.Writer.WriteString("找不到这个订阅:" + subName)
 		return
 	}
-	prepared, ok := buildPreparedResponseFromSubscription(sub, "clash", 0)
+	prepared, ok := buildPreparedResponseFromSubscription(c.Request.Context(), sub, "clash", 0)
 	if !ok {
 		_, _ = c.Writer.WriteString("读取错误")
 		return
@@ -647,6 +752,22 @@ func buildPreparedMihomoYAML(c *gin.Context, prepared preparedClientResponse) (m
 		return mihomoBridgeOutput{Resolved: resolved}, false, true
 	}
 	sub := resolved.Subscription
+	body, err := renderMihomoYAML(c.Request.Context(), prepared, sub)
+	if err != nil {
+		_, _ = c.Writer.WriteString(err.Error())
+		return mihomoBridgeOutput{}, false, false
+	}
+	if prepared.Mode == clientResponseNormal {
+		body = rewriteClashProvidersToMirror(c, body)
+	}
+	if prepared.Mode == clientResponseNormal && subscriptionValidatesOutput(sub) {
+		body = guardRenderedClashConfig(sub.ID, prepared.ShareID, body)
+	}
+	return mihomoBridgeOutput{Body: body, Resolved: resolved}, true, true
+}
+
+// renderMihomoYAML 生成订阅最终输出的 Clash/mihomo 配置（包含链式代理、模板渲染与脚本处理）
+func renderMihomoYAML(ctx context.Context, prepared preparedClientResponse, sub models.Subcription) ([]byte, error) {
 	var urls []protocol.Urls
 
 	// 获取链式代理规则
@@ -691,7 +812,7 @@ func buildPreparedMihomoYAML(c *gin.Context, prepared preparedClientResponse) (m
 				}
 			}
 		}
-		utils.Debug("[ChainProxy] 收集完成: 目标节点=%d, 中间节点=%d", len(targetNodeDialerMap), len(chainNodeDialerMap))
+		renderLog.Debug("[ChainProxy] 收集完成: 目标节点=%d, 中间节点=%d", len(targetNodeDialerMap), len(chainNodeDialerMap))
 	}
 
 	// ========== 第二阶段：遍历节点生成配置 ==========
@@ -723,9 +844,9 @@ func buildPreparedMihomoYAML(c *gin.Context, prepared preparedClientResponse) (m
 			continue
 		//如果是订阅转换（以 http:// 或 https:// 开头，但不是HTTP/HTTPS代理节点）
 		case (strings.HasPrefix(v.Link, "http://") || strings.HasPrefix(v.Link, "https://")) && !protocol.IsHTTPLink(v.Link):
-			resp, err := getRemoteSubscription(c.Request.Context(), v.Link)
+			resp, err := getRemoteSubscription(ctx, v.Link)
 			if err != nil {
-				utils.Error("获取包含链接失败: %v", err)
+				renderLog.Error("获取包含链接失败: %v", err)
 				continue
 			}
 			defer func() { _ = resp.Body.Close() }()
@@ -750,14 +871,14 @@ func buildPreparedMihomoYAML(c *gin.Context, prepared preparedClientResponse) (m
 	var configs protocol.OutputConfig
 	err := json.Unmarshal([]byte(sub.Config), &configs)
 	if err != nil {
-		_, _ = c.Writer.WriteString("配置读取错误")
-		return mihomoBridgeOutput{}, false, false
+		return nil, errors.New("配置读取错误")
 	}
 
 	// 如果启用 Host 替换，填充 HostMap
 	if configs.ReplaceServerWithHost {
 		configs.HostMap = models.GetHostMap()
 	}
+	configs.TemplateContext = buildTemplateRenderContext(prepared, sub, configs.TemplateVars)
 
 	// 添加自定义代理组到配置
 	if len(customGroups) > 0 {
@@ -780,19 +901,51 @@ func buildPreparedMihomoYAML(c *gin.Context, prepared preparedClientResponse) (m
 
 	DecodeClash, err := protocol.EncodeClash(urls, configs)
 	if err != nil {
-		_, _ = c.Writer.WriteString(err.Error())
-		return mihomoBridgeOutput{}, false, false
+		return nil, err
 	}
 	// 执行脚本
 	for _, script := range sub.ScriptsWithSort {
 		res, err := utils.RunScript(script.Content, string(DecodeClash), "clash")
 		if err != nil {
-			utils.Error("Script execution failed: %v", err)
+			utils.WithSubsystem(utils.SubsystemScript).With("script_id", script.ID).With("subscription", sub.Name).
+				Error("脚本【%s】执行失败: %v", script.Name, err)
+			metrics.IncScriptError(metrics.ScriptKindSubscription)
 			continue
 		}
 		DecodeClash = []byte(res)
 	}
-	return mihomoBridgeOutput{Body: DecodeClash, Resolved: resolved}, true, true
+	return DecodeClash, nil
+}
+
+// subscriptionValidatesOutput 订阅是否开启了输出前配置校验
+func subscriptionValidatesOutput(sub models.Subcription) bool {
+	var configs protocol.OutputConfig
+	if sub.Config == "" || json.Unmarshal([]byte(sub.Config), &configs) != nil {
+		return false
+	}
+	return configs.ValidateOutput
+}
+
+// renderedConfigKey 渲染结果缓存键，分享可能有独立的输出，因此按订阅与分享区分
+func renderedConfigKey(subID, shareID int) string {
+	return fmt.Sprintf("%d:%d", subID, shareID)
+}
+
+// guardRenderedClashConfig 使用 mihomo 校验渲染结果
+// 校验通过时记录为最近一次正确结果；失败时回退到上次正确结果，没有可用结果时仍输出本次内容。
+func guardRenderedClashConfig(subID, shareID int, body []byte) []byte {
+	key := renderedConfigKey(subID, shareID)
+	result := mihomo.ValidateClashConfig(body)
+	if result.Valid {
+		cache.SetLastGoodRender(key, body)
+		return body
+	}
+	if last, ok := cache.GetLastGoodRender(key); ok {
+		renderLog.With("subscription_id", subID).Warn("订阅 %d 的配置校验失败，回退到 %s 的正确结果: %s", subID, last.RenderedAt.Format(time.DateTime), result.Error())
+		return last.Content
+	}
+	renderLog.With("subscription_id", subID).Warn("订阅 %d 的配置校验失败且没有可回退的结果: %s", subID, result.Error())
+	return body
 }
 
 func renderPreparedConvertedClient(c *gin.Context, prepared preparedClientResponse) {
@@ -811,7 +964,9 @@ func renderPreparedConvertedClient(c *gin.Context, prepared preparedClientRespon
 		c.String(http.StatusServiceUnavailable, "Sub-Store sidecar is not configured")
 		return
 	}
-	converted, err := client.Convert(c.Request.Context(), string(bridge.Body), prepared.ClientType)
+	ctx, span := tracing.Start(c.Request.Context(), "substore.convert", attribute.String("sublink.client", prepared.ClientType))
+	converted, err := client.Convert(ctx, string(bridge.Body), prepared.ClientType)
+	tracing.End(span, err)
 	if err != nil {
 		c.String(http.StatusBadGateway, "Sub-Store conversion failed: %s", err.Error())
 		return
@@ -844,7 +999,7 @@ func GetSurge(c *gin.Context) {
 		_, _ = c.Writer.WriteString("找不到这个订阅:" + subName)
 		return
 	}
-	prepared, ok := buildPreparedResponseFromSubscription(sub, "surge", 0)
+	prepared, ok := buildPreparedResponseFromSubscription(c.Request.Context(), sub, "surge", 0)
 	if !ok {
 		_, _ = c.Writer.WriteString("读取错误")
 		return
@@ -889,7 +1044,7 @@ func renderPreparedSurge(c *gin.Context, prepared preparedClientResponse) {
 		case (strings.HasPrefix(v.Link, "http://") || strings.HasPrefix(v.Link, "https://")) && !protocol.IsHTTPLink(v.Link):
 			resp, err := getRemoteSubscription(c.Request.Context(), v.Link)
 			if err != nil {
-				utils.Error("Error getting link: %v", err)
+				renderLog.Error("Error getting link: %v", err)
 				return
 			}
 			defer func() { _ = resp.Body.Close() }()
@@ -914,14 +1069,16 @@ func renderPreparedSurge(c *gin.Context, prepared preparedClientResponse) {
 	if configs.ReplaceServerWithHost {
 		configs.HostMap = models.GetHostMap()
 	}
+	configs.TemplateContext = buildTemplateRenderContext(prepared, sub, configs.TemplateVars)
 
-	// log.Println("surge路径:", configs)
 	DecodeClash, err := protocol.EncodeSurge(urls, configs)
 	if err != nil {
 		_, _ = c.Writer.WriteString(err.Error())
 		return
 	}
-	host := c.Request.Host
+	if prepared.Mode == clientResponseNormal {
+		DecodeClash = rewriteSurgeRuleSetsToMirror(c, DecodeClash)
+	}
 	url := c.Request.URL.String()
 	// 如果包含头部更新信息
 	if strings.Contains(DecodeClash, "#!MANAGED-CONFIG") {
@@ -929,6 +1086,26 @@ func renderPreparedSurge(c *gin.Context, prepared preparedClientResponse) {
 		_, _ = c.Writer.WriteString(DecodeClash)
 		return
 	}
+	domain := requestBaseURL(c)
+	// 否则就插入头部更新信息
+	interval := fmt.Sprintf("#!MANAGED-CONFIG %s interval=%d strict=false", domain+url, resolveSubscriptionUpdateIntervalSeconds(sub.UpdateInterval))
+	// 执行脚本
+	for _, script := range sub.ScriptsWithSort {
+		res, err := utils.RunScript(script.Content, DecodeClash, "surge")
+		if err != nil {
+			utils.WithSubsystem(utils.SubsystemScript).With("script_id", script.ID).With("subscription", sub.Name).
+				Error("脚本【%s】执行失败: %v", script.Name, err)
+			metrics.IncScriptError(metrics.ScriptKindSubscription)
+			continue
+		}
+		DecodeClash = res
+	}
+	_, _ = c.Writer.WriteString(interval + "\n" + DecodeClash)
+}
+
+// requestBaseURL 返回客户端访问本系统使用的地址，优先使用系统设置中的访问域名
+func requestBaseURL(c *gin.Context) string {
+	host := c.Request.Host
 	var domain string
 	if c.Request.TLS != nil {
 		domain = "https://" + host
@@ -944,18 +1121,23 @@ func renderPreparedSurge(c *gin.Context, prepared preparedClientResponse) {
 	if systemDomain != "" {
 		domain = systemDomain
 	}
-	// 否则就插入头部更新信息
-	interval := fmt.Sprintf("#!MANAGED-CONFIG %s interval=%d strict=false", domain+url, resolveSubscriptionUpdateIntervalSeconds(sub.UpdateInterval))
-	// 执行脚本
-	for _, script := range sub.ScriptsWithSort {
-		res, err := utils.RunScript(script.Content, DecodeClash, "surge")
-		if err != nil {
-			utils.Error("Script execution failed: %v", err)
-			continue
+	return domain
+}
+
+// resolveSubscriptionUsage 返回 subscription-userinfo 头，分享启用独立用量时报告分享自身的配额
+func resolveSubscriptionUsage(prepared preparedClientResponse, sub models.Subcription) string {
+	if prepared.ShareID > 0 && prepared.Mode == clientResponseNormal {
+		share := models.SubscriptionShare{ID: prepared.ShareID}
+		if err := share.Find(); err == nil && share.HasUsageQuota() {
+			usage := share.CalculateUsage(sub.CalculateUsageInfo())
+			return formatSubscriptionUserInfo(usage.Upload, usage.Download, usage.Total, usage.Expire)
 		}
-		DecodeClash = res
 	}
-	_, _ = c.Writer.WriteString(interval + "\n" + DecodeClash)
+	return getSubscriptionUsage(sub.Nodes)
+}
+
+func formatSubscriptionUserInfo(upload, download, total, expire int64) string {
+	return fmt.Sprintf("upload=%d; download=%d; total=%d; expire=%d", upload, download, total, expire)
 }
 
 // getSubscriptionUsage 计算订阅的流量使用情况
@@ -971,29 +1153,29 @@ func getSubscriptionUsage(nodes []models.Node) string {
 	var expire int64 = 0
 	now := time.Now().Unix()
 
-	utils.Debug("找到机场订阅数量: %d", len(airportIDs))
+	renderLog.Debug("找到机场订阅数量: %d", len(airportIDs))
 
 	for id := range airportIDs {
 		airport, err := models.GetAirportByID(id)
 		if err != nil {
-			utils.Warn("获取机场信息失败 %d: %v", id, err)
+			renderLog.Warn("获取机场信息失败 %d: %v", id, err)
 			continue
 		}
 		if airport == nil {
-			utils.Warn("机场 %d 数据为空", id)
+			renderLog.Warn("机场 %d 数据为空", id)
 			continue
 		}
 		if !airport.FetchUsageInfo {
-			utils.Debug("机场 %d 未开启获取流量信息", id)
+			renderLog.Debug("机场 %d 未开启获取流量信息", id)
 			continue
 		}
 		// 跳过已过期的机场
 		if airport.UsageExpire > 0 && airport.UsageExpire < now {
-			utils.Debug("机场 %d 已过期，跳过统计", id)
+			renderLog.Debug("机场 %d 已过期，跳过统计", id)
 			continue
 		}
 
-		utils.Debug("机场数据 %d usage: U=%d, D=%d, T=%d, E=%d", id, airport.UsageUpload, airport.UsageDownload, airport.UsageTotal, airport.UsageExpire)
+		renderLog.Debug("机场数据 %d usage: U=%d, D=%d, T=%d, E=%d", id, airport.UsageUpload, airport.UsageDownload, airport.UsageTotal, airport.UsageExpire)
 
 		// 累加流量（忽略负数）
 		if airport.UsageUpload > 0 {
@@ -1014,7 +1196,7 @@ func getSubscriptionUsage(nodes []models.Node) string {
 		}
 	}
 
-	result := fmt.Sprintf("upload=%d; download=%d; total=%d; expire=%d", upload, download, total, expire)
-	utils.Debug("完成机场用量信息 subscription-userinfo构造: %s", result)
+	result := formatSubscriptionUserInfo(upload, download, total, expire)
+	renderLog.Debug("完成机场用量信息 subscription-userinfo构造: %s", result)
 	return result
 }
@@ -55,6 +55,10 @@ func setupClientsAPITestDB(t *testing.T) {
 		&models.SubcriptionAirport{},
 		&models.SubcriptionScript{},
 		&models.SubscriptionShare{},
+		&models.ShareAccessWindow{},
+		&models.ShareDevice{},
+		&models.ShareAccessEvent{},
+		&models.ShareAccessDaily{},
 		&models.SubscriptionChainRule{},
 		&models.Script{},
 		&models.SystemSetting{},
@@ -75,6 +79,9 @@ func setupClientsAPITestDB(t *testing.T) {
 	if err := models.InitSubscriptionShareCache(); err != nil {
 		t.Fatalf("init subscription share cache: %v", err)
 	}
+	if err := models.InitShareDeviceCache(); err != nil {
+		t.Fatalf("init share device cache: %v", err)
+	}
 	if err := models.InitChainRuleCache(); err != nil {
 		t.Fatalf("init chain rule cache: %v", err)
 	}
@@ -1419,3 +1426,33 @@ func TestGetClientConcurrentSurgeRequestsKeepSubscriptionScoped(t *testing.T) {
 		t.Fatalf("expected two hook invocations, got %d", callCount)
 	}
 }
+
+func TestGuardRenderedClashConfigFallsBackToLastGoodRender(t *testing.T) {
+	good := []byte(`proxies:
+  - {name: HK-01, type: ss, server: hk.example.com, port: 8388, cipher: aes-256-gcm, password: secret}
+proxy-groups:
+  - {name: Proxy, type: select, proxies: [HK-01]}
+rules:
+  - MATCH,Proxy
+`)
+	broken := []byte(`proxies: []
+proxy-groups:
+  - {name: Proxy, type: select, proxies: [Missing]}
+rules:
+  - MATCH,Proxy
+`)
+
+	const subID, shareID = 987654, 3
+	if got := guardRenderedClashConfig(subID, shareID, broken); string(got) != string(broken) {
+		t.Fatalf("expected broken render to be served when no fallback exists")
+	}
+	if got := guardRenderedClashConfig(subID, shareID, good); string(got) != string(good) {
+		t.Fatalf("expected valid render to be served unchanged")
+	}
+	if got := guardRenderedClashConfig(subID, shareID, broken); string(got) != string(good) {
+		t.Fatalf("expected broken render to fall back to last good render, got:\n%s", got)
+	}
+	if got := guardRenderedClashConfig(subID, shareID+1, broken); string(got) != string(broken) {
+		t.Fatalf("expected fallback to be scoped per share")
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGetClientRecordsRenderSpansUnderIncomingTrace(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "trace-sub", "trace-token", "Trace Node")
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	gin.SetMode(gin.TestMode)
+	response := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(response)
+	ginContext.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/c/?token=trace-token&client=clash", nil)
+	ginContext.Request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	GetClient(ginContext)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	render, ok := spans["subscription.render"]
+	if !ok {
+		t.Fatalf("expected subscription.render span, got %v", spans)
+	}
+	if got := render.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected render span to continue incoming trace, got %s", got)
+	}
+	for _, name := range []string{"subscription.get_sub", "subscription.filter_scripts"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("expected %s span", name)
+		}
+		if span.SpanContext().TraceID() != render.SpanContext().TraceID() {
+			t.Fatalf("expected %s span in the render trace", name)
+		}
+	}
+	if spans["subscription.get_sub"].Parent().SpanID() != render.SpanContext().SpanID() {
+		t.Fatal("expected get_sub span to be a child of the render span")
+	}
+}
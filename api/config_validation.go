@@ -0,0 +1,59 @@
+package api
+
+import (
+	"strconv"
+	"sublink/cache"
+	"sublink/models"
+	"sublink/services/mihomo"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateSubscriptionConfig 渲染订阅最终的 Clash 配置并使用 mihomo 解析器校验
+// 可选 shareId 按指定分享渲染；返回校验结果、渲染文本以及最近一次校验通过的时间。
+func ValidateSubscriptionConfig(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || subID <= 0 {
+		utils.FailWithMsg(c, "订阅ID无效")
+		return
+	}
+	shareID, _ := strconv.Atoi(c.Query("shareId"))
+
+	sub, err := models.GetSubcriptionByID(subID)
+	if err != nil {
+		utils.FailWithMsg(c, "订阅不存在")
+		return
+	}
+	if shareID > 0 {
+		// 分享的模板与节点覆盖只能作用于其所属订阅
+		share := models.SubscriptionShare{ID: shareID}
+		if err := share.Find(); err != nil || share.SubscriptionID != subID {
+			utils.FailWithMsg(c, "分享不存在或不属于该订阅")
+			return
+		}
+	}
+	prepared, ok := buildPreparedResponseFromSubscription(c.Request.Context(), *sub, "clash", shareID)
+	if !ok {
+		utils.FailWithMsg(c, "读取订阅节点失败")
+		return
+	}
+	resolved := applyPreparedResponseMode(prepared)
+	body, err := renderMihomoYAML(c.Request.Context(), prepared, resolved.Subscription)
+	if err != nil {
+		utils.FailWithMsg(c, "配置渲染失败: "+err.Error())
+		return
+	}
+
+	result := mihomo.ValidateClashConfig(body)
+	data := gin.H{
+		"validation":     result,
+		"text":           string(body),
+		"validateOutput": subscriptionValidatesOutput(*sub),
+	}
+	if last, ok := cache.GetLastGoodRender(renderedConfigKey(subID, shareID)); ok {
+		data["lastGoodAt"] = last.RenderedAt.Format(time.DateTime)
+	}
+	utils.OkWithData(c, data)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateSubscriptionConfigRejectsShareOfAnotherSubscription(t *testing.T) {
+	setupClientsAPITestDB(t)
+	clashTemplate, surgeTemplate := writeTestClashTemplate(t), writeTestSurgeTemplate(t)
+	createClientSubscriptionFixture(t, clashTemplate, surgeTemplate, "validate-sub-a", "validate-token-a", "Validate Node A")
+	createClientSubscriptionFixture(t, clashTemplate, surgeTemplate, "validate-sub-b", "validate-token-b", "Validate Node B")
+	shareA, _ := models.GetSubscriptionShareByToken("validate-token-a")
+	shareB, _ := models.GetSubscriptionShareByToken("validate-token-b")
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Params = gin.Params{{Key: "id", Value: strconv.Itoa(shareA.SubscriptionID)}}
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/?shareId="+strconv.Itoa(shareB.ID), nil)
+	ValidateSubscriptionConfig(ctx)
+
+	if resp := decodeAPIResponse(t, recorder); resp.Code == http.StatusOK || !strings.Contains(recorder.Body.String(), "不属于该订阅") {
+		t.Fatalf("expected share of another subscription to be rejected, got %s", recorder.Body.String())
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"strings"
+	"sublink/models"
 	"sublink/utils"
 
 	"github.com/gin-gonic/gin"
@@ -22,3 +23,17 @@ func currentUsernameFromContext(c *gin.Context) (string, bool) {
 	}
 	return username, true
 }
+
+// currentUserFromContext 读取当前登录用户的完整信息
+func currentUserFromContext(c *gin.Context) (*models.User, bool) {
+	username, ok := currentUsernameFromContext(c)
+	if !ok {
+		return nil, false
+	}
+	user := &models.User{Username: username}
+	if err := user.Find(); err != nil {
+		utils.ForbiddenI18n(c, "当前用户身份无效", "backend.auth.currentUser.invalid", nil)
+		return nil, false
+	}
+	return user, true
+}
@@ -26,7 +26,7 @@ func ImportDatabaseMigration(c *gin.Context) {
 		utils.Forbidden(c, "当前用户不存在")
 		return
 	}
-	if !strings.EqualFold(currentUser.Role, "admin") {
+	if !currentUser.IsAdmin() {
 		utils.Forbidden(c, "仅管理员可执行数据库迁移")
 		return
 	}
@@ -95,6 +95,51 @@ func ImportDatabaseMigration(c *gin.Context) {
 	})
 }
 
+// CheckDatabaseCopy 连接并校验跨数据库迁移的源库与目标库，不建库也不写入任何数据
+func CheckDatabaseCopy(c *gin.Context) {
+	var req services.DatabaseCopyOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误: "+err.Error())
+		return
+	}
+	plan, err := services.CheckDatabaseCopy(req)
+	if err != nil {
+		utils.FailWithMsg(c, "校验失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "校验通过", plan)
+}
+
+// StartDatabaseCopy 把全部数据从源库复制到目标库（SQLite / MySQL / PostgreSQL 之间任意方向）
+// 源库 DSN 为空时使用当前数据库；校验在请求内完成，通过后以任务方式执行。
+func StartDatabaseCopy(c *gin.Context) {
+	var req services.DatabaseCopyOptions
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误: "+err.Error())
+		return
+	}
+	plan, err := services.PrepareDatabaseCopy(req)
+	if err != nil {
+		utils.FailWithMsg(c, "校验失败: "+err.Error())
+		return
+	}
+
+	taskName := fmt.Sprintf("跨数据库迁移: %s → %s", plan.Source.Dialect, plan.Target.Dialect)
+	task, ctx, err := services.GetTaskManager().CreateTask(models.TaskTypeDatabaseCopy, taskName, models.TaskTriggerManual, 1)
+	if err != nil {
+		plan.Close()
+		utils.FailWithMsg(c, "创建迁移任务失败: "+err.Error())
+		return
+	}
+
+	go services.RunDatabaseCopyTask(ctx, task.ID, plan)
+
+	utils.OkDetailed(c, "迁移任务已启动", gin.H{
+		"taskId": task.ID,
+		"plan":   plan,
+	})
+}
+
 func parseFormBool(raw string) bool {
 	raw = strings.TrimSpace(strings.ToLower(raw))
 	return raw == "1" || raw == "true" || raw == "yes" || raw == "on"
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"sublink/services"
+	"sublink/services/gitops"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportConfiguration 以 YAML 下载当前实例的声明式配置
+func ExportConfiguration(c *gin.Context) {
+	doc, err := services.ExportConfiguration()
+	if err != nil {
+		utils.FailWithMsg(c, "导出配置失败: "+err.Error())
+		return
+	}
+	data, err := gitops.Marshal(doc)
+	if err != nil {
+		utils.FailWithMsg(c, "导出配置失败: "+err.Error())
+		return
+	}
+	fileName := "sublinkpro-config-" + time.Now().Format("20060102-150405") + ".yaml"
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(200, "application/yaml; charset=utf-8", data)
+}
+
+// PlanConfiguration 计算应用配置文档所需的变更，不写入任何数据
+func PlanConfiguration(c *gin.Context) {
+	doc, ok := readConfigurationDocument(c)
+	if !ok {
+		return
+	}
+	plan, err := services.PlanConfiguration(doc, c.Query("prune") == "true")
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	utils.OkWithData(c, plan)
+}
+
+// ApplyConfiguration 应用配置文档，prune=true 时删除文档分区中未声明的实体
+func ApplyConfiguration(c *gin.Context) {
+	doc, ok := readConfigurationDocument(c)
+	if !ok {
+		return
+	}
+	plan, err := services.ApplyConfiguration(doc, c.Query("prune") == "true")
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	utils.OkDetailed(c, "配置已应用", plan)
+}
+
+// readConfigurationDocument 读取配置文档：multipart 上传的 file 字段，或直接作为请求体的 YAML
+func readConfigurationDocument(c *gin.Context) (*gitops.Document, bool) {
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.FailWithMsg(c, "请上传配置文件")
+			return nil, false
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.FailWithMsg(c, "读取配置文件失败")
+			return nil, false
+		}
+		defer func() { _ = file.Close() }()
+		reader = file
+	}
+	doc, err := gitops.Parse(reader)
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return nil, false
+	}
+	return doc, true
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"sublink/services/diagnostics"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout 就绪探针检查数据库的最长等待时间
+const readinessTimeout = 3 * time.Second
+
+// Healthz 存活探针，进程能处理请求即返回 200
+// GET /healthz
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": diagnostics.StatusOK})
+}
+
+// Readyz 就绪探针，数据库不可用时返回 503
+// GET /readyz
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	check := diagnostics.CheckDatabase(ctx)
+	if check.Status == diagnostics.StatusFail {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": check.Status, "message": check.Message})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": diagnostics.StatusOK})
+}
+
+// GetDiagnostics 获取系统自检报告
+// GET /api/v1/system/diagnostics
+func GetDiagnostics(c *gin.Context) {
+	utils.OkWithData(c, diagnostics.Run(c.Request.Context(), diagnostics.Options{Runtime: true}))
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sublink/database"
+	"sublink/services/diagnostics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func performProbe(t *testing.T, handler gin.HandlerFunc, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+	handler(ctx)
+	return recorder
+}
+
+func TestReadyzReflectsDatabaseAvailability(t *testing.T) {
+	setupSettingAPITestDB(t)
+	if rec := performProbe(t, Readyz, "/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected ready with database, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	database.DB = nil
+	if rec := performProbe(t, Readyz, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without database, got %d", rec.Code)
+	}
+	if rec := performProbe(t, Healthz, "/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("expected liveness to stay 200, got %d", rec.Code)
+	}
+}
+
+func TestGetDiagnosticsReturnsReport(t *testing.T) {
+	setupSettingAPITestDB(t)
+	resp := decodeAPIResponse(t, performProbe(t, GetDiagnostics, "/api/v1/system/diagnostics"))
+	var report diagnostics.Report
+	if err := json.Unmarshal(resp.Data, &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if resp.Code != 200 || len(report.Checks) == 0 || report.Checks[0].Name != "database" {
+		t.Fatalf("unexpected diagnostics response: %d %+v", resp.Code, report)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"sublink/models"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLogQueryLimit 单次查询最近日志的最大条数
+const maxLogQueryLimit = 500
+
+// logLevelOptions 可选的日志等级
+var logLevelOptions = map[string]bool{"debug": true, "info": true, "warn": true, "error": true, "fatal": true}
+
+// GetRecentLogs 查询内存中的最近日志
+// GET /api/v1/logs?level=&subsystem=&keyword=&field=airport_id=3&afterSeq=&since=&limit=200
+func GetRecentLogs(c *gin.Context) {
+	query := utils.LogQuery{
+		Level:     c.Query("level"),
+		Subsystem: c.Query("subsystem"),
+		Keyword:   c.Query("keyword"),
+	}
+	if query.Level != "" && !logLevelOptions[strings.ToLower(query.Level)] {
+		utils.FailWithMsg(c, "日志等级参数错误")
+		return
+	}
+	for _, item := range c.QueryArray("field") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || key == "" {
+			utils.FailWithMsg(c, "field 参数格式应为 key=value")
+			return
+		}
+		if query.Fields == nil {
+			query.Fields = make(map[string]string)
+		}
+		query.Fields[key] = value
+	}
+	if value := c.Query("afterSeq"); value != "" {
+		afterSeq, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.FailWithMsg(c, "afterSeq 参数错误")
+			return
+		}
+		query.AfterSeq = afterSeq
+	}
+	if value := c.Query("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.FailWithMsg(c, "since 参数格式错误")
+			return
+		}
+		query.Since = since
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if limit < 1 || limit > maxLogQueryLimit {
+		limit = 200
+	}
+	query.Limit = limit
+
+	entries, lastSeq := utils.QueryLogs(query)
+	if entries == nil {
+		entries = []utils.LogEntry{}
+	}
+	utils.OkWithData(c, gin.H{"items": entries, "lastSeq": lastSeq})
+}
+
+// GetLogLevels 获取全局日志等级与各子系统的等级覆盖
+func GetLogLevels(c *gin.Context) {
+	utils.OkWithData(c, gin.H{
+		"global":     strings.ToLower(utils.GetLogLevel()),
+		"format":     utils.GetLogFormat(),
+		"subsystems": utils.LogSubsystems,
+		"overrides":  utils.SubsystemLevels(),
+	})
+}
+
+// UpdateLogLevel 设置子系统日志等级，level 为空时恢复使用全局等级
+func UpdateLogLevel(c *gin.Context) {
+	var req struct {
+		Subsystem string `json:"subsystem"`
+		Level     string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	if !utils.IsLogSubsystem(req.Subsystem) {
+		utils.FailWithMsg(c, "未知的日志子系统")
+		return
+	}
+	req.Level = strings.ToLower(strings.TrimSpace(req.Level))
+	if req.Level != "" && !logLevelOptions[req.Level] {
+		utils.FailWithMsg(c, "日志等级参数错误")
+		return
+	}
+	if err := models.SetLogSubsystemLevel(req.Subsystem, req.Level); err != nil {
+		utils.FailWithMsg(c, "保存失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "保存成功", gin.H{"overrides": utils.SubsystemLevels()})
+}
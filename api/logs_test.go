@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetRecentLogsFiltersByAirport(t *testing.T) {
+	utils.SetLogLevel("info")
+	_, before := utils.QueryLogs(utils.LogQuery{Limit: 1})
+	utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", 11).Error("logs-api pull failed")
+	utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", 12).Error("logs-api pull failed")
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet,
+		"/api/v1/logs?subsystem=airport&level=error&field=airport_id%3D11&afterSeq="+strconv.FormatUint(before, 10), nil)
+	GetRecentLogs(ctx)
+
+	resp := decodeAPIResponse(t, recorder)
+	var data struct {
+		Items   []utils.LogEntry `json:"items"`
+		LastSeq uint64           `json:"lastSeq"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("decode logs: %v", err)
+	}
+	if resp.Code != 200 || len(data.Items) != 1 || data.Items[0].Fields["airport_id"] != float64(11) {
+		t.Fatalf("expected one log for airport 11, got %d %+v", resp.Code, data.Items)
+	}
+	if data.LastSeq < before+2 {
+		t.Fatalf("expected lastSeq to be at least %d, got %d", before+2, data.LastSeq)
+	}
+}
+
+func TestGetRecentLogsRejectsInvalidField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/logs?field=airport_id", nil)
+	GetRecentLogs(ctx)
+
+	if resp := decodeAPIResponse(t, recorder); resp.Code == 200 {
+		t.Fatal("expected malformed field filter to be rejected")
+	}
+}
+
+func TestUpdateLogLevelPersistsOverride(t *testing.T) {
+	setupSettingAPITestDB(t)
+	t.Cleanup(utils.ResetSubsystemLevels)
+
+	resp := decodeAPIResponse(t, performJSONRequest(t, UpdateLogLevel, http.MethodPost, map[string]string{"subsystem": "speedtest", "level": "DEBUG"}))
+	if resp.Code != 200 {
+		t.Fatalf("expected success, got %d %s", resp.Code, resp.Msg)
+	}
+	if levels := utils.SubsystemLevels(); levels["speedtest"] != "debug" {
+		t.Fatalf("expected runtime override, got %v", levels)
+	}
+
+	utils.ResetSubsystemLevels()
+	if err := models.LoadLogSubsystemLevels(); err != nil {
+		t.Fatalf("load persisted levels: %v", err)
+	}
+	if levels := utils.SubsystemLevels(); levels["speedtest"] != "debug" {
+		t.Fatalf("expected persisted override to be restored, got %v", levels)
+	}
+
+	for _, body := range []map[string]string{{"subsystem": "unknown", "level": "debug"}, {"subsystem": "speedtest", "level": "trace"}} {
+		if resp := decodeAPIResponse(t, performJSONRequest(t, UpdateLogLevel, http.MethodPost, body)); resp.Code == 200 {
+			t.Fatalf("expected %v to be rejected", body)
+		}
+	}
+}
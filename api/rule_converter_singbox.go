@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sublink/models"
+	"sublink/services/rulemirror"
+	"sublink/utils"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// singBoxRuleSetVersion sing-box 源格式规则集版本，版本 2 兼容 sing-box 1.10 及以上
+const singBoxRuleSetVersion = 2
+
+// singBoxPlaceholderGroup 复用 Clash 规则解析时使用的占位策略组，转换时会被去掉
+const singBoxPlaceholderGroup = "SING-BOX"
+
+const singBoxCompileTimeout = 30 * time.Second
+
+var errSingBoxUnavailable = errors.New("未找到 sing-box 可执行文件，无法生成 .srs 规则集")
+
+// singBoxRuleSetGroup 按派生文件合并并发的生成请求，不同镜像之间互不阻塞
+var singBoxRuleSetGroup singleflight.Group
+
+// SingBoxRuleSet sing-box 源格式规则集
+type SingBoxRuleSet struct {
+	Version int                   `json:"version"`
+	Rules   []SingBoxHeadlessRule `json:"rules"`
+}
+
+// SingBoxHeadlessRule sing-box 规则集中的一条规则
+// 同一条规则内不同类别的字段是“与”关系，因此每个类别单独生成一条规则。
+type SingBoxHeadlessRule struct {
+	Domain        []string `json:"domain,omitempty"`
+	DomainSuffix  []string `json:"domain_suffix,omitempty"`
+	DomainKeyword []string `json:"domain_keyword,omitempty"`
+	DomainRegex   []string `json:"domain_regex,omitempty"`
+	IPCIDR        []string `json:"ip_cidr,omitempty"`
+	SourceIPCIDR  []string `json:"source_ip_cidr,omitempty"`
+	Port          []uint16 `json:"port,omitempty"`
+	PortRange     []string `json:"port_range,omitempty"`
+	SourcePort    []uint16 `json:"source_port,omitempty"`
+	ProcessName   []string `json:"process_name,omitempty"`
+	ProcessPath   []string `json:"process_path,omitempty"`
+}
+
+// singBoxSourceType 根据 Clash provider 的 behavior 推断规则来源类型
+// Surge 规则集没有 behavior，内容为 YAML payload 时按 classical 处理。
+func singBoxSourceType(behavior, content string) string {
+	switch behavior {
+	case "domain":
+		return "clash-domain"
+	case "ipcidr":
+		return "clash-ipcidr"
+	case "classical":
+		return "clash-classical"
+	}
+	if _, ok := parseYAMLPayloadEntries(content); ok {
+		return "clash-classical"
+	}
+	return "surge"
+}
+
+// convertToSingBoxRuleSet 将远程规则内容转换为 sing-box 源格式规则集
+// 复用 ACL 规则集的解析逻辑，返回 JSON 内容与被跳过的规则数量。
+func convertToSingBoxRuleSet(content string, source parsedRulesetSource) ([]byte, int, error) {
+	ruleSet, skipped := buildSingBoxRuleSet(parseRemoteRules(content, source, singBoxPlaceholderGroup))
+	if len(ruleSet.Rules) == 0 {
+		return nil, skipped, errors.New("没有可转换为 sing-box 的规则")
+	}
+	data, err := json.MarshalIndent(ruleSet, "", "  ")
+	if err != nil {
+		return nil, skipped, err
+	}
+	return data, skipped, nil
+}
+
+// buildSingBoxRuleSet 将 TYPE,VALUE,GROUP 形式的规则映射为 sing-box 规则字段
+// sing-box 不支持的规则类型（如 GEOIP、USER-AGENT）会被跳过并计数。
+func buildSingBoxRuleSet(rules []string) (SingBoxRuleSet, int) {
+	var (
+		destination SingBoxHeadlessRule
+		source      SingBoxHeadlessRule
+		port        SingBoxHeadlessRule
+		sourcePort  SingBoxHeadlessRule
+		processName SingBoxHeadlessRule
+		processPath SingBoxHeadlessRule
+		skipped     int
+	)
+	seen := make(map[string]bool)
+
+	for _, rule := range rules {
+		rule = strings.TrimSuffix(strings.TrimSpace(rule), ",no-resolve")
+		rule = strings.TrimSuffix(rule, ","+singBoxPlaceholderGroup)
+		ruleType, value, ok := strings.Cut(rule, ",")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			skipped++
+			continue
+		}
+		ruleType = strings.ToUpper(strings.TrimSpace(ruleType))
+		key := ruleType + "," + value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch ruleType {
+		case "DOMAIN":
+			destination.Domain = append(destination.Domain, value)
+		case "DOMAIN-SUFFIX":
+			destination.DomainSuffix = append(destination.DomainSuffix, value)
+		case "DOMAIN-KEYWORD":
+			destination.DomainKeyword = append(destination.DomainKeyword, value)
+		case "DOMAIN-REGEX":
+			destination.DomainRegex = append(destination.DomainRegex, value)
+		case "DOMAIN-WILDCARD":
+			destination.DomainRegex = append(destination.DomainRegex, wildcardToRegex(value))
+		case "IP-CIDR", "IP-CIDR6":
+			destination.IPCIDR = append(destination.IPCIDR, value)
+		case "SRC-IP-CIDR", "SRC-IP":
+			source.SourceIPCIDR = append(source.SourceIPCIDR, value)
+		case "DST-PORT", "DEST-PORT":
+			if !appendSingBoxPort(&port.Port, &port.PortRange, value) {
+				skipped++
+			}
+		case "SRC-PORT", "IN-PORT":
+			if number, err := strconv.ParseUint(value, 10, 16); err == nil {
+				sourcePort.SourcePort = append(sourcePort.SourcePort, uint16(number))
+			} else {
+				skipped++
+			}
+		case "PROCESS-NAME":
+			processName.ProcessName = append(processName.ProcessName, value)
+		case "PROCESS-PATH":
+			processPath.ProcessPath = append(processPath.ProcessPath, value)
+		default:
+			skipped++
+		}
+	}
+
+	ruleSet := SingBoxRuleSet{Version: singBoxRuleSetVersion}
+	for _, rule := range []SingBoxHeadlessRule{destination, source, port, sourcePort, processName, processPath} {
+		if !rule.isEmpty() {
+			ruleSet.Rules = append(ruleSet.Rules, rule)
+		}
+	}
+	return ruleSet, skipped
+}
+
+// appendSingBoxPort 解析端口或端口范围（1000-2000），范围转换为 sing-box 的 1000:2000 写法
+func appendSingBoxPort(ports *[]uint16, ranges *[]string, value string) bool {
+	if start, end, ok := strings.Cut(value, "-"); ok {
+		if _, err := strconv.ParseUint(start, 10, 16); err != nil {
+			return false
+		}
+		if _, err := strconv.ParseUint(end, 10, 16); err != nil {
+			return false
+		}
+		*ranges = append(*ranges, start+":"+end)
+		return true
+	}
+	number, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return false
+	}
+	*ports = append(*ports, uint16(number))
+	return true
+}
+
+func (r SingBoxHeadlessRule) isEmpty() bool {
+	return len(r.Domain) == 0 && len(r.DomainSuffix) == 0 && len(r.DomainKeyword) == 0 &&
+		len(r.DomainRegex) == 0 && len(r.IPCIDR) == 0 && len(r.SourceIPCIDR) == 0 &&
+		len(r.Port) == 0 && len(r.PortRange) == 0 && len(r.SourcePort) == 0 &&
+		len(r.ProcessName) == 0 && len(r.ProcessPath) == 0
+}
+
+// ensureSingBoxRuleSet 返回镜像对应的 sing-box 规则集路径
+// 派生文件早于镜像本地副本时重新生成，镜像刷新失败时继续使用上次成功副本生成的结果。
+func ensureSingBoxRuleSet(mirror models.RuleMirror, binary bool) (string, error) {
+	jsonPath, err := singBoxRuleSetOnce(rulemirror.SingBoxFileName(mirror), func() (string, error) {
+		return ensureSingBoxSource(mirror)
+	})
+	if err != nil || !binary {
+		return jsonPath, err
+	}
+	return singBoxRuleSetOnce(rulemirror.SRSFileName(mirror), func() (string, error) {
+		return ensureSingBoxBinary(mirror, jsonPath)
+	})
+}
+
+// singBoxRuleSetOnce 同一派生文件同时只生成一次，并发请求共享结果
+func singBoxRuleSetOnce(fileName string, generate func() (string, error)) (string, error) {
+	path, err, _ := singBoxRuleSetGroup.Do(fileName, func() (any, error) {
+		return generate()
+	})
+	if err != nil {
+		return "", err
+	}
+	return path.(string), nil
+}
+
+// ensureSingBoxSource 生成源格式（JSON）规则集
+func ensureSingBoxSource(mirror models.RuleMirror) (string, error) {
+	rawPath, err := rulemirror.LocalPath(mirror.FileName)
+	if err != nil {
+		return "", err
+	}
+	rawInfo, err := os.Stat(rawPath)
+	if err != nil {
+		return "", err
+	}
+
+	jsonPath, err := rulemirror.LocalPath(rulemirror.SingBoxFileName(mirror))
+	if err != nil {
+		return "", err
+	}
+	if isDerivedFileCurrent(jsonPath, rawInfo) {
+		return jsonPath, nil
+	}
+	content, err := os.ReadFile(rawPath)
+	if err != nil {
+		return "", err
+	}
+	source := parsedRulesetSource{URL: mirror.URL, SourceType: singBoxSourceType(mirror.Behavior, string(content))}
+	data, skipped, err := convertToSingBoxRuleSet(string(content), source)
+	if err != nil {
+		return "", err
+	}
+	if err := rulemirror.WriteFileAtomic(jsonPath, data); err != nil {
+		return "", err
+	}
+	if skipped > 0 {
+		utils.Debug("规则 %s 转换为 sing-box 规则集时跳过 %d 条不支持的规则", mirror.URL, skipped)
+	}
+	return jsonPath, nil
+}
+
+// ensureSingBoxBinary 由源格式规则集编译二进制（.srs）规则集
+func ensureSingBoxBinary(mirror models.RuleMirror, jsonPath string) (string, error) {
+	jsonInfo, err := os.Stat(jsonPath)
+	if err != nil {
+		return "", err
+	}
+	srsPath, err := rulemirror.LocalPath(rulemirror.SRSFileName(mirror))
+	if err != nil {
+		return "", err
+	}
+	if !isDerivedFileCurrent(srsPath, jsonInfo) {
+		if err := compileSingBoxRuleSet(jsonPath, srsPath); err != nil {
+			return "", err
+		}
+	}
+	return srsPath, nil
+}
+
+func isDerivedFileCurrent(path string, source os.FileInfo) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.ModTime().Before(source.ModTime())
+}
+
+// compileSingBoxRuleSet 调用 sing-box rule-set compile 生成二进制规则集
+func compileSingBoxRuleSet(jsonPath, srsPath string) error {
+	binaryPath, ok := lookupSingBox()
+	if !ok {
+		return errSingBoxUnavailable
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), singBoxCompileTimeout)
+	defer cancel()
+
+	tmpPath := srsPath + ".tmp"
+	output, err := exec.CommandContext(ctx, binaryPath, "rule-set", "compile", "--output", tmpPath, jsonPath).CombinedOutput()
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return errors.New("sing-box 编译规则集失败: " + strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(tmpPath, srsPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// lookupSingBox 查找 sing-box 可执行文件
+func lookupSingBox() (string, bool) {
+	if info, err := os.Stat("/usr/local/bin/sing-box"); err == nil && !info.IsDir() {
+		return "/usr/local/bin/sing-box", true
+	}
+	path, err := exec.LookPath("sing-box")
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
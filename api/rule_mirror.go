@@ -0,0 +1,238 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sublink/models"
+	"sublink/services/rulemirror"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleMirrorItem 规则镜像列表项
+type RuleMirrorItem struct {
+	models.RuleMirror
+	Status      string `json:"status"`                // fresh / stale / failed / pending / disabled
+	SingBoxFile string `json:"singBoxFile,omitempty"` // sing-box 源格式规则集文件名，不支持转换时为空
+	SRSFile     string `json:"srsFile,omitempty"`     // sing-box 二进制规则集文件名，未安装 sing-box 时为空
+}
+
+// newRuleMirrorItem 构造规则镜像列表项
+func newRuleMirrorItem(mirror models.RuleMirror, srsAvailable bool) RuleMirrorItem {
+	item := RuleMirrorItem{RuleMirror: mirror, Status: ruleMirrorStatus(mirror)}
+	if mirror.SupportsSingBox() {
+		item.SingBoxFile = rulemirror.SingBoxFileName(mirror)
+		if srsAvailable {
+			item.SRSFile = rulemirror.SRSFileName(mirror)
+		}
+	}
+	return item
+}
+
+// ruleMirrorStatus 计算镜像状态：
+// fresh 本地副本在刷新间隔内；stale 本地副本已超过刷新间隔；failed 最近一次下载失败（仍可能有旧副本）；
+// pending 尚未下载；disabled 已停用
+func ruleMirrorStatus(mirror models.RuleMirror) string {
+	switch {
+	case !mirror.Enabled:
+		return "disabled"
+	case mirror.LastError != "":
+		return "failed"
+	case mirror.LastSuccessAt == nil:
+		return "pending"
+	}
+	interval := mirror.Interval
+	if interval <= 0 {
+		interval = models.DefaultRuleMirrorInterval
+	}
+	// 定时任务每小时执行一次，超过间隔一小时仍未刷新才视为过期
+	if time.Since(*mirror.LastSuccessAt) > time.Duration(interval+3600)*time.Second {
+		return "stale"
+	}
+	return "fresh"
+}
+
+// GetRuleMirrors 获取规则镜像设置与列表
+func GetRuleMirrors(c *gin.Context) {
+	mirrors := models.ListRuleMirrors()
+	_, srsAvailable := lookupSingBox()
+	items := make([]RuleMirrorItem, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		items = append(items, newRuleMirrorItem(mirror, srsAvailable))
+	}
+	utils.OkDetailed(c, "获取成功", gin.H{
+		"settings": rulemirror.LoadSettings(),
+		"items":    items,
+	})
+}
+
+// UpdateRuleMirrorSettings 更新规则镜像设置，启用后立即在后台同步
+func UpdateRuleMirrorSettings(c *gin.Context) {
+	var req rulemirror.Settings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	if err := rulemirror.SaveSettings(req); err != nil {
+		utils.FailWithMsg(c, "保存失败: "+err.Error())
+		return
+	}
+	if req.Enabled {
+		go refreshRuleMirrorsInBackground(false)
+	}
+	utils.OkWithMsg(c, "保存成功")
+}
+
+// RefreshRuleMirrors 立即刷新规则镜像
+// 指定 id 时同步下载单个镜像并返回结果；否则重新扫描模板并在后台下载全部镜像
+func RefreshRuleMirrors(c *gin.Context) {
+	var req struct {
+		ID int `json:"id"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	if req.ID > 0 {
+		mirror, err := rulemirror.RefreshOne(req.ID)
+		if err != nil {
+			utils.FailWithMsg(c, "下载失败: "+err.Error())
+			return
+		}
+		_, srsAvailable := lookupSingBox()
+		utils.OkDetailed(c, "刷新成功", newRuleMirrorItem(mirror, srsAvailable))
+		return
+	}
+
+	go refreshRuleMirrorsInBackground(true)
+	utils.OkWithMsg(c, "已开始刷新，请稍后查看状态")
+}
+
+// SetRuleMirrorEnabled 启用或停用单个规则镜像
+func SetRuleMirrorEnabled(c *gin.Context) {
+	var req struct {
+		ID      int  `json:"id"`
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	mirror, ok := models.GetRuleMirrorByID(req.ID)
+	if !ok {
+		utils.FailWithMsg(c, "规则镜像不存在")
+		return
+	}
+	if err := mirror.SetEnabled(req.Enabled); err != nil {
+		utils.FailWithMsg(c, "保存失败: "+err.Error())
+		return
+	}
+	utils.OkWithMsg(c, "保存成功")
+}
+
+func refreshRuleMirrorsInBackground(force bool) {
+	if _, err := rulemirror.Refresh(force); err != nil {
+		utils.Error("刷新规则镜像失败: %v", err)
+	}
+}
+
+// ServeRuleMirror 向客户端提供本地规则文件，使用分享 token 鉴权
+// 请求 <前缀>.sing-box.json 或 <前缀>.srs 时返回由本地副本转换的 sing-box 规则集
+func ServeRuleMirror(c *gin.Context) {
+	token := strings.ToLower(strings.TrimSpace(c.Query("token")))
+	if token == "" {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+	share, err := models.GetSubscriptionShareByToken(token)
+	if err != nil || share.IsExpired() {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+
+	fileName := c.Param("file")
+	mirror, ok := models.GetRuleMirrorByFileName(fileName)
+	derived := false
+	if !ok {
+		mirror, ok = singBoxRuleSetMirror(fileName)
+		derived = ok
+	}
+	if !ok || !mirror.IsFresh() {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+	path, err := rulemirror.LocalPath(mirror.FileName)
+	if err != nil {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		utils.Warn("规则镜像文件缺失: %s", path)
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+	if derived {
+		path, err = ensureSingBoxRuleSet(mirror, strings.HasSuffix(fileName, ".srs"))
+		if err != nil {
+			utils.Warn("生成 sing-box 规则集失败 %s: %v", mirror.URL, err)
+			c.String(http.StatusNotFound, "Not Found")
+			return
+		}
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	switch filepath.Ext(path) {
+	case ".json":
+		contentType = "application/json; charset=utf-8"
+	case ".srs", ".mrs":
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-cache")
+	c.File(path)
+}
+
+// singBoxRuleSetMirror 根据 sing-box 规则集文件名查找对应的镜像
+func singBoxRuleSetMirror(fileName string) (models.RuleMirror, bool) {
+	stem, found := strings.CutSuffix(fileName, ".sing-box.json")
+	if !found {
+		stem, found = strings.CutSuffix(fileName, ".srs")
+	}
+	if !found || stem == "" || strings.Contains(stem, ".") {
+		return models.RuleMirror{}, false
+	}
+	mirror, ok := models.GetRuleMirrorByStem(stem)
+	if !ok || !mirror.SupportsSingBox() {
+		return models.RuleMirror{}, false
+	}
+	return mirror, true
+}
+
+// ruleMirrorResolver 返回当前请求使用的镜像地址替换函数，未启用或缺少分享 token 时返回 nil
+func ruleMirrorResolver(c *gin.Context) rulemirror.URLResolver {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" || !rulemirror.Enabled() {
+		return nil
+	}
+	return rulemirror.Resolver(requestBaseURL(c), token)
+}
+
+// rewriteClashProvidersToMirror 将 Clash 输出中的 rule-providers 地址替换为本地镜像
+func rewriteClashProvidersToMirror(c *gin.Context, body []byte) []byte {
+	resolve := ruleMirrorResolver(c)
+	if resolve == nil {
+		return body
+	}
+	return []byte(rulemirror.RewriteClashProviders(string(body), resolve))
+}
+
+// rewriteSurgeRuleSetsToMirror 将 Surge 输出中的 RULE-SET 地址替换为本地镜像
+func rewriteSurgeRuleSetsToMirror(c *gin.Context, content string) string {
+	resolve := ruleMirrorResolver(c)
+	if resolve == nil {
+		return content
+	}
+	return rulemirror.RewriteSurgeRuleSets(content, resolve)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sublink/config"
+	"sublink/database"
+	"sublink/models"
+	"sublink/services/rulemirror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testMirroredRuleURL = "https://raw.githubusercontent.com/ACL4SSR/ACL4SSR/master/Clash/Providers/ProxyGFWlist.yaml"
+
+func setupRuleMirrorTest(t *testing.T) models.RuleMirror {
+	t.Helper()
+	setupClientsAPITestDB(t)
+	if err := database.DB.AutoMigrate(&models.RuleMirror{}); err != nil {
+		t.Fatalf("auto migrate rule mirrors: %v", err)
+	}
+	if err := models.InitRuleMirrorCache(); err != nil {
+		t.Fatalf("init rule mirror cache: %v", err)
+	}
+
+	oldDBPath := config.Get().DBPath
+	config.UpdateConfig(func(cfg *config.AppConfig) { cfg.DBPath = t.TempDir() })
+	t.Cleanup(func() {
+		config.UpdateConfig(func(cfg *config.AppConfig) { cfg.DBPath = oldDBPath })
+	})
+
+	if _, err := models.SyncRuleMirrors([]models.RuleMirrorReference{{URL: testMirroredRuleURL, Templates: []string{"acl.yaml"}}}); err != nil {
+		t.Fatalf("sync rule mirrors: %v", err)
+	}
+	mirror, ok := models.GetRuleMirrorByURL(testMirroredRuleURL)
+	if !ok {
+		t.Fatal("expected rule mirror to be created")
+	}
+	path, err := rulemirror.LocalPath(mirror.FileName)
+	if err != nil {
+		t.Fatalf("local path: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("create rule dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("payload:\n  - DOMAIN-SUFFIX,example.com\n"), 0644); err != nil {
+		t.Fatalf("write rule file: %v", err)
+	}
+	if err := mirror.RecordFetchSuccess(42, time.Now()); err != nil {
+		t.Fatalf("record fetch success: %v", err)
+	}
+	return mirror
+}
+
+func performRuleMirrorRequest(t *testing.T, fileName, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/c/rules/"+fileName+"?token="+token, nil)
+	ginContext.Params = gin.Params{{Key: "file", Value: fileName}}
+	ServeRuleMirror(ginContext)
+	return recorder
+}
+
+func TestServeRuleMirrorRequiresValidShareToken(t *testing.T) {
+	mirror := setupRuleMirrorTest(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "mirror-sub", "mirror-token", "mirror-node")
+
+	if rec := performRuleMirrorRequest(t, mirror.FileName, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without token, got %d", rec.Code)
+	}
+	if rec := performRuleMirrorRequest(t, mirror.FileName, "unknown-token"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown token, got %d", rec.Code)
+	}
+	if rec := performRuleMirrorRequest(t, "../rules.db", "mirror-token"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown file, got %d", rec.Code)
+	}
+
+	rec := performRuleMirrorRequest(t, mirror.FileName, "mirror-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for valid token, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "DOMAIN-SUFFIX,example.com") {
+		t.Fatalf("expected mirrored rule content, got %q", rec.Body.String())
+	}
+
+	expireClientSubscriptionShare(t, "mirror-token")
+	if rec := performRuleMirrorRequest(t, mirror.FileName, "mirror-token"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for expired share, got %d", rec.Code)
+	}
+}
+
+func TestGetClientRewritesRuleProvidersToMirror(t *testing.T) {
+	mirror := setupRuleMirrorTest(t)
+
+	template := testClashTemplate + `rules:
+  - RULE-SET,ProxyGFWlist,test
+  - RULE-SET,Other,test
+rule-providers:
+  ProxyGFWlist:
+    type: http
+    behavior: classical
+    url: ` + testMirroredRuleURL + `
+    format: yaml
+    path: ./providers/ProxyGFWlist.yaml
+    interval: 86400
+  Other:
+    type: http
+    behavior: classical
+    url: https://example.com/other.yaml
+    path: ./providers/Other.yaml
+    interval: 86400
+`
+	templatePath := filepath.Join(t.TempDir(), "mirror-template.yaml")
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	createClientSubscriptionFixture(t, templatePath, writeTestSurgeTemplate(t), "mirror-render-sub", "render-token", "render-node")
+
+	rec := performClientRequest(t, http.MethodGet, "/c/?token=render-token&client=clash")
+	if strings.Contains(rec.Body.String(), "/c/rules/") {
+		t.Fatalf("expected provider urls untouched while mirror is disabled, got:\n%s", rec.Body.String())
+	}
+
+	if err := rulemirror.SaveSettings(rulemirror.Settings{Enabled: true}); err != nil {
+		t.Fatalf("enable rule mirror: %v", err)
+	}
+	rec = performClientRequest(t, http.MethodGet, "/c/?token=render-token&client=clash")
+	body := rec.Body.String()
+	if !strings.Contains(body, "url: http://example.com/c/rules/"+mirror.FileName+"?token=render-token") {
+		t.Fatalf("expected mirrored provider url, got:\n%s", body)
+	}
+	if !strings.Contains(body, "url: https://example.com/other.yaml") {
+		t.Fatalf("expected unmirrored provider url to be kept, got:\n%s", body)
+	}
+}
+
+func TestBuildSingBoxRuleSetMapsRuleFields(t *testing.T) {
+	content := `DOMAIN,a.example.com
+DOMAIN-SUFFIX,example.org
+DOMAIN-KEYWORD,google
+DOMAIN-REGEX,^ad[0-9]{1,3}\.example\.net$
+IP-CIDR,10.0.0.0/8,no-resolve
+IP-CIDR6,2001:db8::/32,no-resolve
+PROCESS-NAME,Telegram
+DST-PORT,8000-9000
+USER-AGENT,Example*
+`
+	data, skipped, err := convertToSingBoxRuleSet(content, parseRulesetSource("https://example.com/rules.list"))
+	if err != nil {
+		t.Fatalf("convert rule set: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("expected USER-AGENT to be skipped, got %d skipped", skipped)
+	}
+
+	var ruleSet SingBoxRuleSet
+	if err := json.Unmarshal(data, &ruleSet); err != nil {
+		t.Fatalf("decode rule set: %v", err)
+	}
+	if ruleSet.Version != singBoxRuleSetVersion || len(ruleSet.Rules) != 3 {
+		t.Fatalf("expected destination, port and process rules, got %+v", ruleSet)
+	}
+	destination := ruleSet.Rules[0]
+	if len(destination.Domain) != 1 || destination.DomainSuffix[0] != "example.org" || destination.DomainKeyword[0] != "google" {
+		t.Fatalf("unexpected domain fields: %+v", destination)
+	}
+	if destination.DomainRegex[0] != `^ad[0-9]{1,3}\.example\.net$` {
+		t.Fatalf("expected regex with commas to be kept, got %q", destination.DomainRegex[0])
+	}
+	if len(destination.IPCIDR) != 2 || destination.IPCIDR[1] != "2001:db8::/32" {
+		t.Fatalf("unexpected ip_cidr: %+v", destination.IPCIDR)
+	}
+	if ruleSet.Rules[1].PortRange[0] != "8000:9000" || ruleSet.Rules[2].ProcessName[0] != "Telegram" {
+		t.Fatalf("unexpected port/process rules: %+v", ruleSet.Rules[1:])
+	}
+}
+
+func TestServeRuleMirrorSingBoxRuleSet(t *testing.T) {
+	mirror := setupRuleMirrorTest(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "singbox-sub", "singbox-token", "singbox-node")
+
+	fileName := rulemirror.SingBoxFileName(mirror)
+	if rec := performRuleMirrorRequest(t, fileName, "unknown-token"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown token, got %d", rec.Code)
+	}
+	rec := performRuleMirrorRequest(t, fileName, "singbox-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for sing-box rule set, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ruleSet SingBoxRuleSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &ruleSet); err != nil {
+		t.Fatalf("decode sing-box rule set: %v\n%s", err, rec.Body.String())
+	}
+	if len(ruleSet.Rules) != 1 || len(ruleSet.Rules[0].DomainSuffix) != 1 || ruleSet.Rules[0].DomainSuffix[0] != "example.com" {
+		t.Fatalf("unexpected sing-box rule set: %+v", ruleSet)
+	}
+}
+
+func TestEnsureSingBoxRuleSetConcurrentRequests(t *testing.T) {
+	mirror := setupRuleMirrorTest(t)
+
+	const workers = 8
+	paths := make([]string, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = ensureSingBoxRuleSet(mirror, false)
+		}(i)
+	}
+	wg.Wait()
+	for i := range paths {
+		if errs[i] != nil || paths[i] == "" || paths[i] != paths[0] {
+			t.Fatalf("request %d: unexpected result %q, %v", i, paths[i], errs[i])
+		}
+	}
+}
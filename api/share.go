@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"sublink/models"
+	"sublink/services/notifications"
 	"sublink/utils"
 	"time"
 
@@ -56,7 +57,135 @@ type ShareCreateReq struct {
 	Token          string `json:"token"` // 可选，为空则自动生成
 	ExpireType     int    `json:"expire_type"`
 	ExpireDays     int    `json:"expire_days"`
-	ExpireAt       string `json:"expire_at"` // ISO格式日期时间字符串
+	ExpireAt       string `json:"expire_at"`   // ISO格式日期时间字符串
+	SignedOnly     bool   `json:"signed_only"` // 仅允许签名链接访问
+	ShareAccessLimitReq
+	ShareOverrideReq
+	ShareQuotaReq
+}
+
+// ShareAccessLimitReq 分享访问限制参数
+type ShareAccessLimitReq struct {
+	LimitWindow int `json:"limit_window"` // 统计窗口（分钟），0 表示默认 60 分钟
+	MaxRequests int `json:"max_requests"` // 窗口内最大访问次数，0 表示不限制
+	MaxIPs      int `json:"max_ips"`      // 窗口内最多不同 IP 数，0 表示不限制
+	MaxDevices  int `json:"max_devices"`  // 绑定的最大设备数，0 表示不绑定设备
+}
+
+func (req ShareAccessLimitReq) validate() error {
+	if req.LimitWindow < 0 || req.LimitWindow > models.MaxShareLimitWindow {
+		return fmt.Errorf("统计窗口需在 0-%d 分钟之间", models.MaxShareLimitWindow)
+	}
+	if req.MaxRequests < 0 || req.MaxIPs < 0 {
+		return fmt.Errorf("访问次数与 IP 数量上限不能为负数")
+	}
+	if req.MaxDevices < 0 || req.MaxDevices > models.MaxShareDevices {
+		return fmt.Errorf("绑定设备数需在 0-%d 之间", models.MaxShareDevices)
+	}
+	return nil
+}
+
+// ShareOverrideReq 分享级覆盖参数，均为可选
+type ShareOverrideReq struct {
+	CountryWhitelist  string `json:"country_whitelist"`
+	CountryBlacklist  string `json:"country_blacklist"`
+	TagWhitelist      string `json:"tag_whitelist"`
+	TagBlacklist      string `json:"tag_blacklist"`
+	ProtocolWhitelist string `json:"protocol_whitelist"`
+	ProtocolBlacklist string `json:"protocol_blacklist"`
+	MaxNodes          int    `json:"max_nodes"`        // 最多输出的节点数，0 表示不限制
+	ForceClient       string `json:"force_client"`     // 强制输出的客户端类型
+	ClashTemplate     string `json:"clash_template"`   // 替换订阅的 Clash 模板
+	SurgeTemplate     string `json:"surge_template"`   // 替换订阅的 Surge 模板
+	FallbackMessage   string `json:"fallback_message"` // 自定义占位提示
+}
+
+func (req ShareOverrideReq) validate() error {
+	if req.MaxNodes < 0 {
+		return fmt.Errorf("节点数量上限不能为负数")
+	}
+	if strings.TrimSpace(req.ForceClient) != "" && normalizeClientType(req.ForceClient) == "" {
+		return fmt.Errorf("不支持的客户端类型: %s", req.ForceClient)
+	}
+	if len([]rune(req.FallbackMessage)) > 100 {
+		return fmt.Errorf("占位提示不能超过 100 个字符")
+	}
+	return nil
+}
+
+func (req ShareOverrideReq) applyTo(share *models.SubscriptionShare) {
+	share.CountryWhitelist = normalizeShareFilterList(req.CountryWhitelist, strings.ToUpper)
+	share.CountryBlacklist = normalizeShareFilterList(req.CountryBlacklist, strings.ToUpper)
+	share.TagWhitelist = normalizeShareFilterList(req.TagWhitelist, nil)
+	share.TagBlacklist = normalizeShareFilterList(req.TagBlacklist, nil)
+	share.ProtocolWhitelist = normalizeShareFilterList(req.ProtocolWhitelist, strings.ToLower)
+	share.ProtocolBlacklist = normalizeShareFilterList(req.ProtocolBlacklist, strings.ToLower)
+	share.MaxNodes = req.MaxNodes
+	share.ForceClient = normalizeClientType(req.ForceClient)
+	share.ClashTemplate = strings.TrimSpace(req.ClashTemplate)
+	share.SurgeTemplate = strings.TrimSpace(req.SurgeTemplate)
+	share.FallbackMessage = strings.TrimSpace(req.FallbackMessage)
+}
+
+// ShareQuotaReq 分享级用量配额参数
+type ShareQuotaReq struct {
+	QuotaBytes    int64  `json:"quota_bytes"`     // 虚拟流量配额（字节），0 表示按比例分摊上游配额
+	QuotaExpireAt string `json:"quota_expire_at"` // 报告给客户端的到期时间，为空时使用分享过期时间
+	UsageRatio    int    `json:"usage_ratio"`     // 分摊上游用量的百分比，0 表示均分剩余比例
+}
+
+// resolve 校验配额参数并解析到期时间
+func (req ShareQuotaReq) resolve() (*time.Time, error) {
+	if req.QuotaBytes < 0 {
+		return nil, fmt.Errorf("流量配额不能为负数")
+	}
+	if req.UsageRatio < 0 || req.UsageRatio > 100 {
+		return nil, fmt.Errorf("用量分摊比例需在 0-100 之间")
+	}
+	if strings.TrimSpace(req.QuotaExpireAt) == "" {
+		return nil, nil
+	}
+	return parseShareExpireAt(models.ExpireTypeDateTime, req.QuotaExpireAt)
+}
+
+// checkRatioTotal 校验同一订阅下各分享显式设置的分摊比例之和不超过 100
+func (req ShareQuotaReq) checkRatioTotal(subscriptionID, shareID int) error {
+	if req.UsageRatio <= 0 {
+		return nil
+	}
+	if others := models.OtherUsageRatioTotal(subscriptionID, shareID); others+req.UsageRatio > 100 {
+		return fmt.Errorf("同一订阅下各分享的用量分摊比例之和不能超过 100，其他分享已占用 %d", others)
+	}
+	return nil
+}
+
+func (req ShareQuotaReq) applyTo(share *models.SubscriptionShare, quotaExpireAt *time.Time) {
+	share.QuotaBytes = req.QuotaBytes
+	share.QuotaExpireAt = quotaExpireAt
+	share.UsageRatio = req.UsageRatio
+}
+
+// normalizeShareFilterList 规范化逗号分隔的过滤列表，去除空项与首尾空白
+func normalizeShareFilterList(raw string, transform func(string) string) string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if transform != nil {
+			item = transform(item)
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
+
+func (req ShareAccessLimitReq) applyTo(share *models.SubscriptionShare) {
+	share.LimitWindow = req.LimitWindow
+	share.MaxRequests = req.MaxRequests
+	share.MaxIPs = req.MaxIPs
+	share.MaxDevices = req.MaxDevices
 }
 
 // ShareUpdateReq 更新分享请求
@@ -68,6 +197,10 @@ type ShareUpdateReq struct {
 	ExpireDays int    `json:"expire_days"`
 	ExpireAt   string `json:"expire_at"`
 	Enabled    bool   `json:"enabled"`
+	SignedOnly bool   `json:"signed_only"`
+	ShareAccessLimitReq
+	ShareOverrideReq
+	ShareQuotaReq
 }
 
 func parseShareExpireAt(expireType int, raw string) (*time.Time, error) {
@@ -155,6 +288,23 @@ func ShareAdd(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
 		return
 	}
+	if err := req.ShareAccessLimitReq.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if err := req.ShareOverrideReq.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	quotaExpireAt, err := req.ShareQuotaReq.resolve()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if err := req.ShareQuotaReq.checkRatioTotal(req.SubscriptionID, 0); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
 
 	share := &models.SubscriptionShare{
 		SubscriptionID: req.SubscriptionID,
@@ -164,7 +314,11 @@ func ShareAdd(c *gin.Context) {
 		ExpireDays:     req.ExpireDays,
 		ExpireAt:       expireAt,
 		Enabled:        true,
+		SignedOnly:     req.SignedOnly,
 	}
+	req.ShareAccessLimitReq.applyTo(share)
+	req.ShareOverrideReq.applyTo(share)
+	req.ShareQuotaReq.applyTo(share, quotaExpireAt)
 
 	if err := share.Add(); err != nil {
 		utils.Error("创建分享失败: %v", err)
@@ -195,6 +349,23 @@ func ShareUpdate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
 		return
 	}
+	if err := req.ShareAccessLimitReq.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if err := req.ShareOverrideReq.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	quotaExpireAt, err := req.ShareQuotaReq.resolve()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if err := req.ShareQuotaReq.checkRatioTotal(share.SubscriptionID, share.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
 
 	// 更新字段
 	share.Name = req.Name
@@ -203,6 +374,10 @@ func ShareUpdate(c *gin.Context) {
 	share.ExpireDays = req.ExpireDays
 	share.ExpireAt = expireAt
 	share.Enabled = req.Enabled
+	share.SignedOnly = req.SignedOnly
+	req.ShareAccessLimitReq.applyTo(share)
+	req.ShareOverrideReq.applyTo(share)
+	req.ShareQuotaReq.applyTo(share, quotaExpireAt)
 
 	if err := share.Update(); err != nil {
 		utils.Error("更新分享失败: %v", err)
@@ -288,6 +463,27 @@ func ShareLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": 200, "data": logs})
 }
 
+// ShareAccessUsage 获取分享在访问频率限制下的当前窗口与历史窗口统计
+func ShareAccessUsage(c *gin.Context) {
+	shareId, err := strconv.Atoi(c.Query("shareId"))
+	if err != nil || shareId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "无效的分享ID"})
+		return
+	}
+
+	share := &models.SubscriptionShare{ID: shareId}
+	if err := share.Find(); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享不存在"})
+		return
+	}
+	usage, err := models.GetShareAccessUsage(share)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "获取分享访问统计失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": usage})
+}
+
 // ShareBatchAdd 批量创建分享
 func ShareBatchAdd(c *gin.Context) {
 	var req ShareBatchCreateReq
@@ -513,3 +709,35 @@ func ShareBatchUpdate(c *gin.Context) {
 		"msg":  fmt.Sprintf("成功更新 %d 个分享", successCount),
 	})
 }
+
+// shareAbuseDisabledMessage 分享被自动停用后返回给客户端的提示
+const shareAbuseDisabledMessage = "分享访问异常，已被停用"
+
+// disableAbusedShare 自动停用超出访问限制的分享并发送通知
+// 并发的超限请求只有真正完成停用的那一个会发送通知。
+func disableAbusedShare(share *models.SubscriptionShare, sub models.Subcription, reason string) {
+	disabled, err := share.DisableForAbuse(reason)
+	if err != nil {
+		utils.Error("自动停用分享失败: %v", err)
+		return
+	}
+	if !disabled {
+		return
+	}
+	utils.Warn("分享 %d 超出访问限制，自动停用: %s", share.ID, reason)
+
+	timeStr := time.Now().Format("2006-01-02 15:04:05")
+	notifications.Publish("security.share_abuse_disabled", notifications.Payload{
+		Title:   "分享已被自动停用",
+		Message: fmt.Sprintf("订阅 %s 的分享 %s 已被自动停用\n原因: %s\n时间: %s", sub.Name, share.Name, reason, timeStr),
+		Data: map[string]any{
+			"shareId":        share.ID,
+			"shareName":      share.Name,
+			"subscriptionId": sub.ID,
+			"subscription":   sub.Name,
+			"reason":         reason,
+			"time":           timeStr,
+		},
+		Time: timeStr,
+	})
+}
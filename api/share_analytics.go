@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"sublink/models"
+	"sublink/services/geoip"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordShareAccessEvent 记录一次分享拉取的客户端、地区与响应模式
+// 无效的 token 没有对应分享，不计入分享统计。
+func recordShareAccessEvent(c *gin.Context, token string, prepared preparedClientResponse) {
+	share, err := models.GetSubscriptionShareByToken(token)
+	if err != nil {
+		return
+	}
+	ip := c.ClientIP()
+	models.RecordShareAccessEventAsync(models.ShareAccessEvent{
+		ShareID:        share.ID,
+		SubscriptionID: share.SubscriptionID,
+		ClientType:     prepared.ClientType,
+		UserAgent:      c.GetHeader("User-Agent"),
+		IP:             ip,
+		Country:        shareAccessCountry(ip),
+		Mode:           shareAccessMode(prepared),
+		CreatedAt:      time.Now(),
+	})
+}
+
+// shareAccessMode 根据响应结果判断访问模式：过期与停用沿用原订阅外壳，其余占位订阅视为校验失败
+func shareAccessMode(prepared preparedClientResponse) string {
+	if prepared.Mode == clientResponseNormal {
+		return models.ShareAccessModeNormal
+	}
+	if prepared.FallbackIdentity == fallbackIdentityOriginalEnvelope {
+		return models.ShareAccessModeExpired
+	}
+	return models.ShareAccessModeFallback
+}
+
+// shareAccessCountry 优先使用本地 GeoIP 库，未加载时使用已缓存的 IP 信息
+func shareAccessCountry(ip string) string {
+	if geoip.IsAvailable() {
+		if code, err := geoip.GetCountryISOCode(ip); err == nil && code != "" {
+			return code
+		}
+	}
+	if info, ok := models.GetCachedIPInfo(ip); ok {
+		return info.CountryCode
+	}
+	return ""
+}
+
+// parseShareAnalyticsFilter 解析分享或订阅范围以及统计起始时间
+func parseShareAnalyticsFilter(c *gin.Context, defaultDays int) (models.ShareAnalyticsFilter, bool) {
+	subID, _ := strconv.Atoi(c.Query("subId"))
+	shareID, _ := strconv.Atoi(c.Query("shareId"))
+	if subID <= 0 && shareID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "订阅ID与分享ID至少提供一个"})
+		return models.ShareAnalyticsFilter{}, false
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultDays)))
+	if err != nil || days < 1 || days > models.MaxShareAnalyticsRetentionDays {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "统计天数需在 1-365 之间"})
+		return models.ShareAnalyticsFilter{}, false
+	}
+	since := time.Now().AddDate(0, 0, -(days - 1))
+	return models.ShareAnalyticsFilter{
+		SubscriptionID: subID,
+		ShareID:        shareID,
+		Since:          time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location()),
+	}, true
+}
+
+// ShareAnalyticsDaily 获取分享每日访问汇总
+func ShareAnalyticsDaily(c *gin.Context) {
+	filter, ok := parseShareAnalyticsFilter(c, 30)
+	if !ok {
+		return
+	}
+	rows, err := models.ListShareAccessDaily(filter)
+	if err != nil {
+		utils.Error("获取分享每日访问汇总失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "获取成功", "data": rows})
+}
+
+// ShareAnalyticsClients 获取访问最多的客户端
+func ShareAnalyticsClients(c *gin.Context) {
+	filter, ok := parseShareAnalyticsFilter(c, 7)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+	stats, err := models.TopShareClients(filter, limit)
+	if err != nil {
+		utils.Error("获取分享客户端统计失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "获取成功", "data": stats})
+}
+
+// ShareAnalyticsUnusual 获取异常访问列表
+func ShareAnalyticsUnusual(c *gin.Context) {
+	filter, ok := parseShareAnalyticsFilter(c, 1)
+	if !ok {
+		return
+	}
+	items, err := models.ListUnusualShareAccess(filter)
+	if err != nil {
+		utils.Error("获取分享异常访问失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "获取成功", "data": items})
+}
+
+// ShareAnalyticsSettings 获取访问明细保留天数
+func ShareAnalyticsSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "获取成功", "data": gin.H{"retention_days": models.GetShareAnalyticsRetentionDays()}})
+}
+
+// ShareAnalyticsUpdateSettings 更新访问明细保留天数，保存后立即清理过期明细
+func ShareAnalyticsUpdateSettings(c *gin.Context) {
+	var req struct {
+		RetentionDays int `json:"retention_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	if req.RetentionDays < 1 || req.RetentionDays > models.MaxShareAnalyticsRetentionDays {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "保留天数需在 1-365 之间"})
+		return
+	}
+	if err := models.SetShareAnalyticsRetentionDays(req.RetentionDays); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败: " + err.Error()})
+		return
+	}
+	deleted, err := models.CleanupExpiredShareAccessEvents()
+	if err != nil {
+		utils.Warn("清理过期分享访问明细失败: %v", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "保存成功", "data": gin.H{"retention_days": req.RetentionDays, "deleted": deleted}})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func performShareAnalyticsRequest(t *testing.T, handler gin.HandlerFunc, path string, out any) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+	handler(ctx)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("request %s failed: %d %s", path, recorder.Code, recorder.Body.String())
+	}
+	resp := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+}
+
+func TestGetClientRecordsShareAccessAnalytics(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "analytics-sub", "analytics-token", "Analytics Node")
+	share, err := models.GetSubscriptionShareByToken("analytics-token")
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+
+	path := "/c/?token=analytics-token&client=clash"
+	performClientRequestFromDevice(t, path, "203.0.113.10", "clash-verge/v1.7.0")
+	performClientRequestFromDevice(t, path, "203.0.113.10", "clash-verge/v1.7.0")
+	performClientRequestFromDevice(t, path, "198.51.100.20", "clash-verge/v1.7.0")
+	performClientRequestFromDevice(t, "/c/?token=unknown-token", "198.51.100.30", "curl/8.5.0")
+	expireClientSubscriptionShare(t, "analytics-token")
+	performClientRequestFromDevice(t, "/c/?token=analytics-token", "192.0.2.40", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)")
+	models.WaitForPendingAccessRecords()
+
+	query := "?shareId=" + strconv.Itoa(share.ID)
+	var daily []models.ShareAccessDaily
+	performShareAnalyticsRequest(t, ShareAnalyticsDaily, "/api/v1/shares/analytics/daily"+query, &daily)
+	if len(daily) != 1 || daily[0].Requests != 4 || daily[0].UniqueIPs != 3 || daily[0].Normal != 3 || daily[0].Expired != 1 {
+		t.Fatalf("unexpected daily rollup: %+v", daily)
+	}
+
+	var clients []models.ShareClientStat
+	performShareAnalyticsRequest(t, ShareAnalyticsClients, "/api/v1/shares/analytics/clients"+query, &clients)
+	if len(clients) != 2 || clients[0].UAFamily != "clash" || clients[0].ClientType != "clash" || clients[0].Requests != 3 || clients[0].UniqueIPs != 2 {
+		t.Fatalf("unexpected top clients: %+v", clients)
+	}
+
+	var unusual []models.ShareUnusualAccess
+	performShareAnalyticsRequest(t, ShareAnalyticsUnusual, "/api/v1/shares/analytics/unusual?subId="+strconv.Itoa(share.SubscriptionID), &unusual)
+	kinds := map[string]int{}
+	for _, item := range unusual {
+		kinds[item.Kind] = item.Count
+	}
+	if len(unusual) != 2 || kinds["expired"] != 1 || kinds["non_client"] != 1 {
+		t.Fatalf("unexpected unusual access: %+v", unusual)
+	}
+}
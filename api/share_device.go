@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shareDeviceRejectedMessage 未绑定设备访问时的提示
+const shareDeviceRejectedMessage = "该分享已绑定其他设备"
+
+// ShareDeviceResetReq 解除设备绑定请求
+type ShareDeviceResetReq struct {
+	ShareID  int `json:"share_id" binding:"required"`
+	DeviceID int `json:"device_id"` // 可选，为 0 时解除全部设备
+}
+
+// ShareDevices 获取分享绑定的设备
+func ShareDevices(c *gin.Context) {
+	shareID, err := strconv.Atoi(c.Query("shareId"))
+	if err != nil || shareID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "无效的分享ID"})
+		return
+	}
+	share := &models.SubscriptionShare{ID: shareID}
+	if err := share.Find(); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"max_devices": share.MaxDevices,
+			"devices":     models.ListShareDevices(share.ID),
+		},
+	})
+}
+
+// ShareResetDevices 解除分享的设备绑定，解除后下一次访问的设备重新绑定
+func ShareResetDevices(c *gin.Context) {
+	var req ShareDeviceResetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	share := &models.SubscriptionShare{ID: req.ShareID}
+	if err := share.Find(); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享不存在"})
+		return
+	}
+
+	removed, err := models.ResetShareDevices(share.ID, req.DeviceID)
+	if err != nil {
+		utils.Error("解除分享设备绑定失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "解除绑定失败"})
+		return
+	}
+	if req.DeviceID != 0 && removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "设备不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已解除绑定", "data": gin.H{"removed": removed}})
+}
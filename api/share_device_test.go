@@ -0,0 +1,82 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func performClientRequestFromDevice(t *testing.T, path, ip, userAgent string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+	ginContext.Request.RemoteAddr = ip + ":12345"
+	ginContext.Request.Header.Set("User-Agent", userAgent)
+	GetClient(ginContext)
+	return clientBodyText(recorder)
+}
+
+func TestGetClientBindsShareToFirstDevices(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "device-sub", "device-token", "Device Node")
+	share, err := models.GetSubscriptionShareByToken("device-token")
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+	share.MaxDevices = 1
+	if err := share.Update(); err != nil {
+		t.Fatalf("update share: %v", err)
+	}
+
+	path := "/c/?token=device-token&client=clash"
+	if body := performClientRequestFromDevice(t, path, "203.0.113.10", "clash-verge/v1.7.0"); !strings.Contains(body, "Device Node") {
+		t.Fatalf("expected first device to be bound, got %q", body)
+	}
+	// 同一客户端在同一网段内更换 IP 仍视为同一设备
+	if body := performClientRequestFromDevice(t, path, "203.0.113.99", "clash-verge/v1.8.0"); !strings.Contains(body, "Device Node") {
+		t.Fatalf("expected same device on new ip to be allowed, got %q", body)
+	}
+	for _, other := range []struct{ ip, ua string }{
+		{"203.0.113.10", "Shadowrocket/2070"},
+		{"198.51.100.10", "clash-verge/v1.7.0"},
+	} {
+		if body := performClientRequestFromDevice(t, path, other.ip, other.ua); !strings.Contains(body, shareDeviceRejectedMessage) {
+			t.Fatalf("expected device %+v to be rejected, got %q", other, body)
+		}
+	}
+
+	devices := models.ListShareDevices(share.ID)
+	if len(devices) != 1 || devices[0].UAFamily != "clash" || devices[0].Requests != 2 || devices[0].LastIP != "203.0.113.99" {
+		t.Fatalf("unexpected bound devices: %+v", devices)
+	}
+
+	raw, _ := json.Marshal(map[string]int{"share_id": share.ID})
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/shares/devices/reset", bytes.NewReader(raw))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ShareResetDevices(ctx)
+	if recorder.Code != http.StatusOK || len(models.ListShareDevices(share.ID)) != 0 {
+		t.Fatalf("reset devices failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if body := performClientRequestFromDevice(t, path, "198.51.100.10", "Shadowrocket/2070"); !strings.Contains(body, "Device Node") {
+		t.Fatalf("expected new device to bind after reset, got %q", body)
+	}
+
+	// 签名链接不受设备绑定限制（签名后分享仅允许签名访问，放在最后校验）
+	signedPath := signShareForTest(t, map[string]any{"id": share.ID, "client": "clash"})
+	if body := performClientRequestFromDevice(t, signedPath, "192.0.2.10", "Stash/2.4"); !strings.Contains(body, "Device Node") {
+		t.Fatalf("expected signed link to bypass device binding, got %q", body)
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"sublink/models"
+)
+
+func updateShareForTest(t *testing.T, token string, mutate func(share *models.SubscriptionShare)) {
+	t.Helper()
+	share, err := models.GetSubscriptionShareByToken(token)
+	if err != nil {
+		t.Fatalf("find share %s: %v", token, err)
+	}
+	mutate(share)
+	if err := share.Update(); err != nil {
+		t.Fatalf("update share %s: %v", token, err)
+	}
+}
+
+func TestGetClientAppliesShareNodeOverrides(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "override-sub", "override-token", "Override Node")
+
+	path := "/c/?token=override-token&client=clash"
+	if body := performClientRequest(t, http.MethodGet, path).Body.String(); !strings.Contains(body, "Override Node") {
+		t.Fatalf("expected node before override, got %q", body)
+	}
+
+	// 分享只允许 vmess 协议，订阅中的 ss 节点应被过滤
+	updateShareForTest(t, "override-token", func(share *models.SubscriptionShare) {
+		share.ProtocolWhitelist = "vmess"
+	})
+	if body := performClientRequest(t, http.MethodGet, path).Body.String(); strings.Contains(body, "Override Node") {
+		t.Fatalf("expected protocol override to filter node, got %q", body)
+	}
+
+	updateShareForTest(t, "override-token", func(share *models.SubscriptionShare) {
+		share.ProtocolWhitelist = ""
+		share.ProtocolBlacklist = "ss"
+	})
+	if body := performClientRequest(t, http.MethodGet, path).Body.String(); strings.Contains(body, "Override Node") {
+		t.Fatalf("expected protocol blacklist to filter node, got %q", body)
+	}
+}
+
+func TestGetClientAppliesShareOutputOverrides(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "output-sub", "output-token", "Output Node")
+
+	surgeTemplate := writeTestSurgeTemplateWithManagedConfig(t)
+	updateShareForTest(t, "output-token", func(share *models.SubscriptionShare) {
+		share.ForceClient = "surge"
+		share.SurgeTemplate = surgeTemplate
+	})
+
+	// 未指定 client 参数时按分享强制的客户端类型输出，并使用分享的模板
+	body := performClientRequest(t, http.MethodGet, "/c/?token=output-token&client=clash").Body.String()
+	if !strings.Contains(body, "#!MANAGED-CONFIG") || !strings.Contains(body, "Output Node") {
+		t.Fatalf("expected surge output from share template, got %q", body)
+	}
+
+	updateShareForTest(t, "output-token", func(share *models.SubscriptionShare) {
+		share.FallbackMessage = "请联系管理员续期"
+	})
+	expireClientSubscriptionShare(t, "output-token")
+	body = performClientRequest(t, http.MethodGet, "/c/?token=output-token").Body.String()
+	if !strings.Contains(body, "请联系管理员续期") || strings.Contains(body, "订阅已过期") {
+		t.Fatalf("expected custom fallback message, got %q", body)
+	}
+}
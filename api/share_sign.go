@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sublink/models"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultShareSignHours 签名链接默认有效期（小时）
+	defaultShareSignHours = 24
+	// maxShareSignHours 签名链接最长有效期（小时）
+	maxShareSignHours = 30 * 24
+)
+
+// ShareSignReq 生成签名链接请求
+type ShareSignReq struct {
+	ID          int    `json:"id" binding:"required"`
+	ExpireHours int    `json:"expire_hours"` // 有效期（小时），0 表示默认 24 小时
+	Client      string `json:"client"`       // 可选，绑定客户端类型
+	IP          string `json:"ip"`           // 可选，绑定访问 IP
+}
+
+// checkShareSignature 校验订阅请求中的签名参数，返回是否为有效签名链接，失败时返回提示信息
+// 未携带签名时仅在分享要求签名访问时拒绝。
+func checkShareSignature(c *gin.Context, share *models.SubscriptionShare) (bool, string) {
+	signature := c.Query("sig")
+	if signature == "" {
+		if share.SignedOnly {
+			return false, "该分享仅允许通过签名链接访问"
+		}
+		return false, ""
+	}
+
+	expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return false, models.ErrShareSignatureInvalid.Error()
+	}
+	params := models.ShareSignature{
+		Token:      share.Token,
+		ExpiresAt:  expiresAt,
+		ClientType: strings.ToLower(strings.TrimSpace(c.Query("client"))),
+		IP:         c.Query("ip"),
+	}
+	if err := params.Verify(signature, c.ClientIP(), time.Now()); err != nil {
+		if errors.Is(err, models.ErrShareSignatureInvalid) || errors.Is(err, models.ErrShareSignatureExpired) ||
+			errors.Is(err, models.ErrShareSignatureIPMismatch) {
+			return false, err.Error()
+		}
+		utils.Error("校验分享签名失败: %v", err)
+		return false, models.ErrShareSignatureInvalid.Error()
+	}
+	return true, ""
+}
+
+// buildSignedShareQuery 构造签名链接的查询参数
+func buildSignedShareQuery(params models.ShareSignature, signature string) string {
+	query := url.Values{}
+	query.Set("token", params.Token)
+	query.Set("expires", strconv.FormatInt(params.ExpiresAt, 10))
+	if params.ClientType != "" {
+		query.Set("client", params.ClientType)
+	}
+	if params.IP != "" {
+		query.Set("ip", params.IP)
+	}
+	query.Set("sig", signature)
+	return query.Encode()
+}
+
+// shareBaseURL 返回订阅链接的访问地址，优先使用系统域名设置
+func shareBaseURL(c *gin.Context) string {
+	if domain, _ := models.GetSetting("system_domain"); domain != "" {
+		if !strings.HasPrefix(domain, "http") {
+			domain = "http://" + domain
+		}
+		return strings.TrimRight(domain, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// ShareSign 为分享生成临时签名链接，无需创建新的分享
+// 签发后分享只允许签名链接访问，原有的普通链接随之失效。
+func ShareSign(c *gin.Context) {
+	var req ShareSignReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	if req.ExpireHours == 0 {
+		req.ExpireHours = defaultShareSignHours
+	}
+	if req.ExpireHours < 0 || req.ExpireHours > maxShareSignHours {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "有效期需在 1-" + strconv.Itoa(maxShareSignHours) + " 小时之间"})
+		return
+	}
+	clientType := ""
+	if strings.TrimSpace(req.Client) != "" {
+		if clientType = normalizeClientType(req.Client); clientType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "不支持的客户端类型"})
+			return
+		}
+	}
+	ip := strings.TrimSpace(req.IP)
+	if ip != "" && net.ParseIP(ip) == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "IP地址格式错误"})
+		return
+	}
+
+	share := &models.SubscriptionShare{ID: req.ID}
+	if err := share.Find(); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享不存在"})
+		return
+	}
+	if share.IsExpired() {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "分享已过期或已停用"})
+		return
+	}
+
+	// 签名链接包含分享 token，签发后关闭普通链接访问，避免去掉签名参数后永久可用
+	signedOnlyEnabled := !share.SignedOnly
+	if err := share.RequireSignedAccess(); err != nil {
+		utils.Error("开启仅签名访问失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "生成签名链接失败"})
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.ExpireHours) * time.Hour)
+	params := models.ShareSignature{
+		Token:      share.Token,
+		ExpiresAt:  expiresAt.Unix(),
+		ClientType: clientType,
+		IP:         ip,
+	}
+	signature, err := params.Sign()
+	if err != nil {
+		utils.Error("生成签名链接失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "生成签名链接失败"})
+		return
+	}
+
+	path := "/c/?" + buildSignedShareQuery(params, signature)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "生成成功",
+		"data": gin.H{
+			"url":        shareBaseURL(c) + path,
+			"path":       path,
+			"expires_at": time.Unix(params.ExpiresAt, 0),
+			// signed_only_enabled 为 true 表示本次签发把分享切换为仅签名访问
+			"signed_only_enabled": signedOnlyEnabled,
+		},
+	})
+}
+
+// ShareRotateSignKey 更换签名密钥，使已签发的签名链接全部失效
+func ShareRotateSignKey(c *gin.Context) {
+	if err := models.RotateShareSignSecret(); err != nil {
+		utils.Error("更换签名密钥失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "更换签名密钥失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "签名密钥已更换，已签发的签名链接全部失效"})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func signShareForTest(t *testing.T, body map[string]any) string {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal sign request: %v", err)
+	}
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/shares/sign", bytes.NewReader(raw))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ShareSign(ctx)
+
+	var resp struct {
+		Code int `json:"code"`
+		Data struct {
+			Path string `json:"path"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || resp.Code != 200 {
+		t.Fatalf("sign share failed: %v %s", err, recorder.Body.String())
+	}
+	return resp.Data.Path
+}
+
+// clientBodyText 返回订阅内容，v2ray 格式输出会先进行 base64 解码
+func clientBodyText(recorder *httptest.ResponseRecorder) string {
+	body := recorder.Body.String()
+	if decoded, err := base64.StdEncoding.DecodeString(body); err == nil {
+		return string(decoded)
+	}
+	return body
+}
+
+func TestGetClientAcceptsSignedShareURL(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "signed-sub", "signed-token", "Signed Node")
+
+	path := signShareForTest(t, map[string]any{"id": 1, "expire_hours": 1, "client": "clash", "ip": "203.0.113.5"})
+	if body := clientBodyText(performClientRequestFromIP(t, path, "203.0.113.5")); !strings.Contains(body, "Signed Node") {
+		t.Fatalf("expected signed url to return nodes, got %q", body)
+	}
+	// 签发后分享切换为仅签名访问，去掉签名参数的链接不再可用
+	if body := clientBodyText(performClientRequestFromIP(t, "/c/?token=signed-token&client=clash", "203.0.113.5")); strings.Contains(body, "Signed Node") {
+		t.Fatalf("expected stripped signed url to be rejected, got %q", body)
+	}
+	if share, err := models.GetSubscriptionShareByToken("signed-token"); err != nil || !share.SignedOnly {
+		t.Fatalf("expected share to require signed access after signing, got %+v, %v", share, err)
+	}
+	if body := clientBodyText(performClientRequestFromIP(t, path, "203.0.113.6")); !strings.Contains(body, models.ErrShareSignatureIPMismatch.Error()) {
+		t.Fatalf("expected ip-bound url to reject other ip, got %q", body)
+	}
+	tampered := strings.Replace(path, "client=clash", "client=surge", 1)
+	if body := clientBodyText(performClientRequestFromIP(t, tampered, "203.0.113.5")); !strings.Contains(body, models.ErrShareSignatureInvalid.Error()) {
+		t.Fatalf("expected tampered client binding to be rejected, got %q", body)
+	}
+
+	expired := models.ShareSignature{Token: "signed-token", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	signature, err := expired.Sign()
+	if err != nil {
+		t.Fatalf("sign expired url: %v", err)
+	}
+	expiredPath := "/c/?" + buildSignedShareQuery(expired, signature)
+	if body := clientBodyText(performClientRequestFromIP(t, expiredPath, "203.0.113.5")); !strings.Contains(body, models.ErrShareSignatureExpired.Error()) {
+		t.Fatalf("expected expired signed url to be rejected, got %q", body)
+	}
+
+	// 更换密钥后已签发的链接失效
+	if err := models.RotateShareSignSecret(); err != nil {
+		t.Fatalf("rotate sign secret: %v", err)
+	}
+	if body := clientBodyText(performClientRequestFromIP(t, path, "203.0.113.5")); !strings.Contains(body, models.ErrShareSignatureInvalid.Error()) {
+		t.Fatalf("expected url signed with old key to be rejected, got %q", body)
+	}
+}
+
+func TestGetClientSignedOnlyShareRejectsPlainToken(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "signed-only-sub", "signed-only-token", "Guest Node")
+	share, err := models.GetSubscriptionShareByToken("signed-only-token")
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+	share.SignedOnly = true
+	if err := share.Update(); err != nil {
+		t.Fatalf("update share: %v", err)
+	}
+
+	if body := clientBodyText(performClientRequestFromIP(t, "/c/?token=signed-only-token&client=clash", "198.51.100.9")); strings.Contains(body, "Guest Node") {
+		t.Fatalf("expected plain token to be rejected for signed-only share, got %q", body)
+	}
+	path := signShareForTest(t, map[string]any{"id": share.ID})
+	if !strings.Contains(path, "expires=") || strings.Contains(path, "ip=") {
+		t.Fatalf("unexpected signed path: %s", path)
+	}
+	if body := clientBodyText(performClientRequestFromIP(t, path+"&client=clash", "198.51.100.9")); !strings.Contains(body, models.ErrShareSignatureInvalid.Error()) {
+		t.Fatalf("expected appended client type to break signature, got %q", body)
+	}
+	// 未绑定客户端类型时按 User-Agent 识别，此处输出为 v2ray 订阅
+	if body := clientBodyText(performClientRequestFromIP(t, path, "198.51.100.9")); !strings.Contains(body, "Guest Node") {
+		t.Fatalf("expected signed url to return nodes, got %q", body)
+	}
+}
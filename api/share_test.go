@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func performClientRequestFromIP(t *testing.T, path, ip string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+	ginContext.Request.RemoteAddr = ip + ":12345"
+	GetClient(ginContext)
+	return recorder
+}
+
+func setShareAccessLimitForTest(t *testing.T, token string, maxRequests, maxIPs int) *models.SubscriptionShare {
+	t.Helper()
+	share, err := models.GetSubscriptionShareByToken(token)
+	if err != nil {
+		t.Fatalf("find share %s: %v", token, err)
+	}
+	share.MaxRequests = maxRequests
+	share.MaxIPs = maxIPs
+	if err := share.Update(); err != nil {
+		t.Fatalf("update share limits: %v", err)
+	}
+	return share
+}
+
+func shareUsageForTest(t *testing.T, shareID string) models.ShareAccessUsage {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/shares/access-usage?shareId="+shareID, nil)
+	ShareAccessUsage(ctx)
+
+	var resp struct {
+		Code int                     `json:"code"`
+		Data models.ShareAccessUsage `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || resp.Code != 200 {
+		t.Fatalf("share access usage failed: %v %s", err, recorder.Body.String())
+	}
+	return resp.Data
+}
+
+func TestGetClientDisablesShareOverRequestLimit(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "limited-sub", "limited-token", "Limited Node")
+	setShareAccessLimitForTest(t, "limited-token", 2, 0)
+
+	for i := 0; i < 2; i++ {
+		body := performClientRequestFromIP(t, "/c/?token=limited-token&client=clash", "203.0.113.1").Body.String()
+		if !strings.Contains(body, "Limited Node") {
+			t.Fatalf("expected request %d within limit to return nodes, got %q", i+1, body)
+		}
+	}
+	body := performClientRequestFromIP(t, "/c/?token=limited-token&client=clash", "203.0.113.1").Body.String()
+	if !strings.Contains(body, shareAbuseDisabledMessage) || strings.Contains(body, "Limited Node") {
+		t.Fatalf("expected request over limit to return fallback, got %q", body)
+	}
+
+	disabled, err := models.GetSubscriptionShareByToken("limited-token")
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+	if disabled.Enabled || !disabled.IsAbuseDisabled() || disabled.AbuseReason == "" {
+		t.Fatalf("expected share to be disabled for abuse, got %+v", disabled)
+	}
+	body = performClientRequestFromIP(t, "/c/?token=limited-token&client=clash", "203.0.113.1").Body.String()
+	if !strings.Contains(body, shareAbuseDisabledMessage) {
+		t.Fatalf("expected disabled share to keep returning fallback, got %q", body)
+	}
+
+	usage := shareUsageForTest(t, "1")
+	if usage.MaxRequests != 2 || usage.Current.Requests != 3 || usage.Current.DistinctIPs != 1 || usage.AbuseReason == "" {
+		t.Fatalf("unexpected share usage: %+v", usage)
+	}
+
+	// 重新启用后清除停用记录，并从新的窗口开始计数
+	disabled.Enabled = true
+	if err := disabled.Update(); err != nil {
+		t.Fatalf("re-enable share: %v", err)
+	}
+	models.WaitForPendingAccessRecords()
+	body = performClientRequestFromIP(t, "/c/?token=limited-token&client=clash", "203.0.113.1").Body.String()
+	if !strings.Contains(body, "Limited Node") {
+		t.Fatalf("expected re-enabled share to return nodes, got %q", body)
+	}
+	usage = shareUsageForTest(t, "1")
+	if usage.AbuseDisabledAt != nil || usage.Current.Requests != 1 || len(usage.History) != 1 || usage.History[0].Requests != 3 {
+		t.Fatalf("expected previous window in history after re-enable, got %+v", usage)
+	}
+}
+
+func TestGetClientDisablesShareOverDistinctIPLimit(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "ip-sub", "ip-token", "IP Node")
+	setShareAccessLimitForTest(t, "ip-token", 0, 2)
+
+	for _, ip := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.1"} {
+		if body := performClientRequestFromIP(t, "/c/?token=ip-token&client=clash", ip).Body.String(); !strings.Contains(body, "IP Node") {
+			t.Fatalf("expected request from %s within limit to return nodes, got %q", ip, body)
+		}
+	}
+	body := performClientRequestFromIP(t, "/c/?token=ip-token&client=clash", "198.51.100.3").Body.String()
+	if !strings.Contains(body, shareAbuseDisabledMessage) {
+		t.Fatalf("expected third distinct IP to disable share, got %q", body)
+	}
+}
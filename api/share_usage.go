@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareUsage 获取订阅下各分享分摊后的用量
+// 上游用量来自订阅节点所属机场，按分享的节点覆盖与分摊比例换算为分享自身的用量。
+func ShareUsage(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Query("subId"))
+	if err != nil || subID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "无效的订阅ID"})
+		return
+	}
+	sub, err := models.GetSubcriptionByID(subID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "订阅不存在"})
+		return
+	}
+	if err := sub.GetSub("preview"); err != nil {
+		utils.Error("获取订阅节点失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "获取订阅节点失败"})
+		return
+	}
+
+	upload, download, total, expire := sub.CalculateUsageInfo()
+	shares := make([]models.ShareUsage, 0)
+	for _, share := range models.GetSharesBySubscriptionID(subID) {
+		if !share.HasUsageQuota() {
+			continue
+		}
+		shareSub := *sub
+		shareSub.Nodes = share.ApplyNodeOverrides(sub.Nodes)
+		shares = append(shares, share.CalculateUsage(shareSub.CalculateUsageInfo()))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "获取成功",
+		"data": gin.H{
+			"upstream": gin.H{"upload": upload, "download": download, "total": total, "expire": expire},
+			"shares":   shares,
+		},
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"sublink/database"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func attachUsageAirportForTest(t *testing.T, token string) {
+	t.Helper()
+	if err := database.DB.AutoMigrate(&models.Airport{}, &models.GroupAirportSort{}); err != nil {
+		t.Fatalf("auto migrate airports: %v", err)
+	}
+	airport := &models.Airport{
+		Name:           "usage-airport",
+		URL:            "https://example.com/subscription",
+		Enabled:        true,
+		FetchUsageInfo: true,
+		UsageUpload:    1000,
+		UsageDownload:  3000,
+		UsageTotal:     10000,
+	}
+	if err := airport.Add(); err != nil {
+		t.Fatalf("add airport: %v", err)
+	}
+	// 机场缓存为全局缓存，测试结束时移除避免影响其他用例
+	t.Cleanup(func() { _ = airport.Del() })
+	share, err := models.GetSubscriptionShareByToken(token)
+	if err != nil {
+		t.Fatalf("find share: %v", err)
+	}
+	var relation models.SubcriptionNode
+	if err := database.DB.Where("subcription_id = ?", share.SubscriptionID).First(&relation).Error; err != nil {
+		t.Fatalf("find subscription node: %v", err)
+	}
+	if err := database.DB.Model(&models.Node{}).Where("id = ?", relation.NodeID).
+		Updates(map[string]any{"source": "airport", "source_id": airport.ID}).Error; err != nil {
+		t.Fatalf("attach node to airport: %v", err)
+	}
+	if err := models.InitNodeCache(); err != nil {
+		t.Fatalf("refresh node cache: %v", err)
+	}
+}
+
+func TestGetClientReportsShareQuotaInUsageHeader(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "quota-sub", "quota-token", "Quota Node")
+	attachUsageAirportForTest(t, "quota-token")
+
+	path := "/c/?token=quota-token&client=clash"
+	if got := performClientRequest(t, http.MethodGet, path).Header().Get("subscription-userinfo"); got != "upload=1000; download=3000; total=10000; expire=0" {
+		t.Fatalf("expected upstream usage before quota, got %q", got)
+	}
+
+	updateShareForTest(t, "quota-token", func(share *models.SubscriptionShare) {
+		share.UsageRatio = 25
+		share.QuotaBytes = 2000
+	})
+	if got := performClientRequest(t, http.MethodGet, path).Header().Get("subscription-userinfo"); got != "upload=250; download=750; total=2000; expire=0" {
+		t.Fatalf("expected share quota usage, got %q", got)
+	}
+
+	share, _ := models.GetSubscriptionShareByToken("quota-token")
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/shares/usage?subId="+strconv.Itoa(share.SubscriptionID), nil)
+	ShareUsage(ctx)
+	var resp struct {
+		Data struct {
+			Upstream map[string]int64    `json:"upstream"`
+			Shares   []models.ShareUsage `json:"shares"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode usage response: %v", err)
+	}
+	if resp.Data.Upstream["total"] != 10000 || len(resp.Data.Shares) != 1 || resp.Data.Shares[0].Download != 750 {
+		t.Fatalf("unexpected usage response: %s", recorder.Body.String())
+	}
+}
+
+func TestShareAddRejectsUsageRatioTotalOverHundred(t *testing.T) {
+	setupClientsAPITestDB(t)
+	createClientSubscriptionFixture(t, writeTestClashTemplate(t), writeTestSurgeTemplate(t), "ratio-sub", "ratio-token", "Ratio Node")
+	updateShareForTest(t, "ratio-token", func(share *models.SubscriptionShare) {
+		share.UsageRatio = 70
+	})
+	share, _ := models.GetSubscriptionShareByToken("ratio-token")
+
+	for _, tc := range []struct {
+		ratio int
+		code  int
+	}{
+		{40, http.StatusBadRequest},
+		{30, http.StatusOK},
+	} {
+		raw, _ := json.Marshal(map[string]any{"subscription_id": share.SubscriptionID, "name": "ratio-" + strconv.Itoa(tc.ratio), "usage_ratio": tc.ratio})
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/shares/add", bytes.NewReader(raw))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ShareAdd(ctx)
+		if recorder.Code != tc.code {
+			t.Fatalf("ratio %d: expected %d, got %d %s", tc.ratio, tc.code, recorder.Code, recorder.Body.String())
+		}
+	}
+}
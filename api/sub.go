@@ -117,6 +117,12 @@ func SubGet(c *gin.Context) {
 		}
 	}
 
+	// 限定订阅的 API Key 只能看到被授权的订阅
+	if key, ok := accessKeyFromContext(c); ok && key.IsSubscriptionRestricted() {
+		subGetForAccessKey(c, key, page, pageSize)
+		return
+	}
+
 	// 如果提供了分页参数，返回分页响应
 	if page > 0 && pageSize > 0 {
 		subs, total, err := Sub.ListPaginated(page, pageSize)
@@ -147,6 +153,37 @@ func SubGet(c *gin.Context) {
 	utils.OkDetailed(c, "node get", Subs)
 }
 
+// subGetForAccessKey 按 API Key 的订阅限制过滤订阅列表
+func subGetForAccessKey(c *gin.Context, key models.AccessKey, page, pageSize int) {
+	var Sub models.Subcription
+	all, err := Sub.List()
+	if err != nil {
+		utils.FailWithMsg(c, "获取订阅列表失败")
+		return
+	}
+	subs := make([]models.Subcription, 0)
+	for _, sub := range all {
+		if key.AllowsSubscription(sub.ID) {
+			subs = append(subs, sub)
+		}
+	}
+	if page <= 0 || pageSize <= 0 {
+		utils.OkDetailed(c, "node get", subs)
+		return
+	}
+
+	total := len(subs)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	utils.OkDetailed(c, "获取成功", gin.H{
+		"items":      subs[start:end],
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + pageSize - 1) / pageSize,
+	})
+}
+
 // 添加节点
 func SubAdd(c *gin.Context) {
 	var sub models.Subcription
@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sublink/cache"
 	"sublink/database"
 	"sublink/models"
+	"sublink/node/protocol"
 	"sublink/services/ai"
 	"sublink/utils"
 	"time"
@@ -255,6 +257,10 @@ func UpdateTemp(c *gin.Context) {
 		utils.FailWithMsg(c, "文件名或内容不能为空")
 		return
 	}
+	if err := validateTemplateText(filename, text, category); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
 
 	// 默认类别为 clash
 	if category == "" {
@@ -287,6 +293,11 @@ func UpdateTemp(c *gin.Context) {
 
 	// 如果新旧文件名不同，则检查新文件是否已存在
 	if oldFullPath != newFullPath {
+		// 被其他模板引用的模板改名后引用会失效
+		if dependents, err := getTemplateDependents(oldname); err == nil && len(dependents) > 0 {
+			utils.FailWithMsg(c, "模板正被以下模板引用，无法改名: "+strings.Join(dependents, ", "))
+			return
+		}
 		if _, err := os.Stat(newFullPath); err == nil {
 			utils.FailWithMsg(c, "新文件名已存在，请选择其他名称")
 			return
@@ -434,6 +445,10 @@ func AddTemp(c *gin.Context) {
 		utils.FailWithMsg(c, "文件名或内容不能为空")
 		return
 	}
+	if err := validateTemplateText(filename, text, category); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
 
 	// 默认类别为 clash
 	if category == "" {
@@ -474,6 +489,18 @@ func DelTemp(c *gin.Context) {
 		return
 	}
 
+	// 被其他模板 include/extends 的基础模板不允许删除
+	dependents, err := getTemplateDependents(filename)
+	if err != nil {
+		utils.Error("检查模板引用失败: %v", err)
+		utils.FailWithMsg(c, "服务器错误：检查模板引用失败")
+		return
+	}
+	if len(dependents) > 0 {
+		utils.FailWithMsg(c, "模板正被以下模板引用，无法删除: "+strings.Join(dependents, ", "))
+		return
+	}
+
 	// 删除文件
 	err = os.Remove(fullPath)
 	if err != nil {
@@ -515,13 +542,72 @@ func buildTemplateMatchValues(filename string) map[string]struct{} {
 	return matchValues
 }
 
+// getTemplateDependents 沿 include/extends 引用图查找直接或间接引用该模板的其他模板
+func getTemplateDependents(filename string) ([]string, error) {
+	entries, err := os.ReadDir(baseTemplateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	// 反向引用图：被引用模板 -> 引用它的模板
+	referencedBy := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		text, err := readTemplateText(entry.Name())
+		if err != nil {
+			continue
+		}
+		extends, includes := protocol.TemplateReferences(text)
+		if extends != "" {
+			includes = append(includes, extends)
+		}
+		for _, ref := range includes {
+			referencedBy[ref] = append(referencedBy[ref], entry.Name())
+		}
+	}
+
+	target := filepath.Base(normalizeTemplateUsageValue(filename))
+	visited := map[string]bool{target: true}
+	queue := []string{target}
+	dependents := make([]string, 0)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, name := range referencedBy[current] {
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			dependents = append(dependents, name)
+			queue = append(queue, name)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+// getTemplateUsageSubscriptions 查找使用该模板的订阅，包括通过 include/extends 间接使用的订阅
 func getTemplateUsageSubscriptions(filename string) ([]string, error) {
 	var subs []models.Subcription
 	if err := database.DB.Find(&subs).Error; err != nil {
 		return nil, err
 	}
 
+	dependents, err := getTemplateDependents(filename)
+	if err != nil {
+		return nil, err
+	}
 	matchValues := buildTemplateMatchValues(filename)
+	for _, dependent := range dependents {
+		for value := range buildTemplateMatchValues(dependent) {
+			matchValues[value] = struct{}{}
+		}
+	}
 	usedBy := make([]string, 0)
 
 	for _, sub := range subs {
@@ -562,10 +648,110 @@ func GetTemplateUsage(c *gin.Context) {
 		utils.FailWithMsg(c, "获取模板使用情况失败")
 		return
 	}
+	dependents, err := getTemplateDependents(filename)
+	if err != nil {
+		utils.FailWithMsg(c, "获取模板使用情况失败")
+		return
+	}
 
 	utils.OkWithData(c, gin.H{
 		"subscriptions": usedBy,
 		"count":         len(usedBy),
+		"templates":     dependents,
+	})
+}
+
+// validateTemplateText 校验模板语法与 include/extends 引用（含循环引用），filename 为保存后的文件名
+func validateTemplateText(filename, text, category string) error {
+	if !protocol.HasTemplateReferences(text) {
+		_, err := protocol.ValidateTemplateSyntax(text)
+		return err
+	}
+	loader := protocol.WithTemplateOverride(templateTextLoader, filepath.Base(filename), text)
+	if category == "" {
+		category = models.InferTemplateCategory(filename)
+	}
+	_, err := protocol.ResolveTemplate(filename, text, category, protocol.SampleTemplateContext(), loader)
+	return err
+}
+
+// templateTextLoader 以模板目录为根读取被引用的模板
+func templateTextLoader(name string) (string, error) {
+	text, err := readTemplateText(name)
+	if err != nil {
+		return "", fmt.Errorf("读取被引用模板 %s 失败: %w", name, err)
+	}
+	return text, nil
+}
+
+// readTemplateText 读取模板文件内容，优先使用模板内容缓存
+func readTemplateText(filename string) (string, error) {
+	if cached, ok := cache.GetTemplateContent(filename); ok {
+		return cached, nil
+	}
+	fullPath, err := safeFilePath(filename)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", err
+	}
+	cache.SetTemplateContent(filename, string(data))
+	return string(data), nil
+}
+
+// RenderTemplatePreview 预览模板变量与条件区块渲染结果
+// 指定 subscriptionId 时使用该订阅（及可选分享）的真实上下文，否则使用示例上下文。
+func RenderTemplatePreview(c *gin.Context) {
+	filename := c.Query("filename")
+	if filename == "" {
+		utils.FailWithMsg(c, "文件名不能为空")
+		return
+	}
+	text, err := readTemplateText(filename)
+	if err != nil {
+		utils.FailWithMsg(c, "读取模板失败: "+err.Error())
+		return
+	}
+
+	tmplCtx := protocol.SampleTemplateContext()
+	if subID, _ := strconv.Atoi(c.Query("subscriptionId")); subID > 0 {
+		clientType := c.DefaultQuery("client", models.InferTemplateCategory(filename))
+		shareID, _ := strconv.Atoi(c.Query("shareId"))
+		sub, err := models.GetSubcriptionByID(subID)
+		if err != nil {
+			utils.FailWithMsg(c, "订阅不存在")
+			return
+		}
+		if err := sub.GetSub(clientType); err != nil {
+			utils.FailWithMsg(c, "读取订阅节点失败: "+err.Error())
+			return
+		}
+		var configs protocol.OutputConfig
+		if sub.Config != "" {
+			if err := json.Unmarshal([]byte(sub.Config), &configs); err != nil {
+				utils.FailWithMsg(c, "订阅配置读取错误")
+				return
+			}
+		}
+		tmplCtx = buildTemplateRenderContext(preparedClientResponse{ClientType: clientType, ShareID: shareID}, *sub, configs.TemplateVars)
+	}
+
+	// 展开 include/extends 后渲染，预览结果即订阅实际使用的完整模板
+	category := models.InferTemplateCategory(filename)
+	rendered, err := protocol.ResolveTemplate(filename, text, category, tmplCtx, templateTextLoader)
+	if err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	extends, includes := protocol.TemplateReferences(text)
+	utils.OkWithData(c, gin.H{
+		"text":          rendered,
+		"hasDirectives": protocol.HasTemplateDirectives(text),
+		"extends":       extends,
+		"includes":      includes,
+		"context":       tmplCtx,
 	})
 }
 
@@ -754,6 +940,7 @@ func StartTemplateAIEditSessionStream(c *gin.Context) {
 
 	usedBy, _ := getTemplateUsageSubscriptions(req.Filename)
 	preview, err := ai.BuildTemplateEditPreviewCandidate(ai.TemplateEditPreviewInput{
+		Filename:      req.Filename,
 		Category:      req.Category,
 		BaseText:      req.CurrentText,
 		BaseHash:      baseHash,
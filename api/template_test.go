@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -415,6 +416,69 @@ func TestTemplateEditSessionStaleBase(t *testing.T) {
 	assertTemplateAPIErrorCode(t, response, templateEditStaleBaseCode)
 }
 
+func TestDelTempBlocksTemplateReferencedByOthers(t *testing.T) {
+	setupTemplateAPITestDB(t)
+
+	templateDir := t.TempDir()
+	baseTemplateDir = templateDir
+	writeTemplateFileForTest(t, templateDir, "base.yaml", "mode: rule\nrules:\n  - MATCH,DIRECT\n")
+	writeTemplateFileForTest(t, templateDir, "child.yaml", "#!extends base.yaml\nrules:\n  - MATCH,Proxy\n")
+
+	recorder := performTemplateFormRequest(t, DelTemp, "/api/v1/template/delete", "filename=base.yaml")
+	response := decodeAPIResponse(t, recorder)
+	if response.Code == 200 {
+		t.Fatalf("expected deleting referenced base template to fail")
+	}
+	if !strings.Contains(response.Msg, "child.yaml") {
+		t.Fatalf("expected message to list dependent template, got %q", response.Msg)
+	}
+	if _, err := os.Stat(filepath.Join(templateDir, "base.yaml")); err != nil {
+		t.Fatalf("expected base template to remain: %v", err)
+	}
+
+	recorder = performTemplateHandlerRequest(t, RenderTemplatePreview, http.MethodGet, "/api/v1/template/render?filename=child.yaml", nil, "")
+	response = decodeAPIResponse(t, recorder)
+	if response.Code != 200 {
+		t.Fatalf("expected preview success, got %d: %s", response.Code, response.Msg)
+	}
+	var preview struct {
+		Text    string `json:"text"`
+		Extends string `json:"extends"`
+	}
+	if err := json.Unmarshal(response.Data, &preview); err != nil {
+		t.Fatalf("unmarshal preview: %v", err)
+	}
+	if preview.Extends != "base.yaml" || !strings.Contains(preview.Text, "mode: rule") || !strings.Contains(preview.Text, "MATCH,Proxy") {
+		t.Fatalf("expected fully resolved preview, got %+v", preview)
+	}
+}
+
+func TestUpdateTempRejectsTemplateReferenceCycle(t *testing.T) {
+	setupTemplateAPITestDB(t)
+
+	templateDir := t.TempDir()
+	baseTemplateDir = templateDir
+	writeTemplateFileForTest(t, templateDir, "a.yaml", "mode: rule\n")
+	writeTemplateFileForTest(t, templateDir, "b.yaml", "#!include a.yaml\n")
+
+	recorder := performTemplateFormRequest(t, UpdateTemp, "/api/v1/template/update", "filename=a.yaml&oldname=a.yaml&category=clash&text="+url.QueryEscape("#!include b.yaml\nmode: rule\n"))
+	response := decodeAPIResponse(t, recorder)
+	if response.Code == 200 || !strings.Contains(response.Msg, "循环") {
+		t.Fatalf("expected cycle to be rejected, got %d: %s", response.Code, response.Msg)
+	}
+}
+
+func performTemplateFormRequest(t *testing.T, handler gin.HandlerFunc, target string, form string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginContext, _ := gin.CreateTestContext(recorder)
+	ginContext.Request = httptest.NewRequestWithContext(context.Background(), http.MethodPost, target, strings.NewReader(form))
+	ginContext.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler(ginContext)
+	return recorder
+}
+
 func performTemplateHandlerRequest(t *testing.T, handler gin.HandlerFunc, method string, target string, body any, sessionID string) *httptest.ResponseRecorder {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
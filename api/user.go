@@ -15,6 +15,7 @@ type User struct {
 	ID       int
 	Username string
 	Nickname string
+	Role     string
 	Avatar   string
 	Mobile   string
 	Email    string
@@ -60,10 +61,12 @@ func UserMe(c *gin.Context) {
 			"enabled":    aiEnabled,
 			"configured": aiConfigured,
 		},
-		"nickname": user.Nickname,
-		"userId":   user.ID,
-		"username": user.Username,
-		"roles":    []string{"ADMIN"},
+		"nickname":   user.Nickname,
+		"userId":     user.ID,
+		"username":   user.Username,
+		"role":       user.EffectiveRole(),
+		"oidcLinked": user.OIDCSubject != "",
+		"roles":      []string{strings.ToUpper(user.EffectiveRole())},
 		"mfa": gin.H{
 			"enabled":                user.TOTPEnabled,
 			"pendingEnrollment":      buildMFAStatus(user).PendingEnrollment,
@@ -91,6 +94,7 @@ func UserPages(c *gin.Context) {
 			ID:       users[i].ID,
 			Username: users[i].Username,
 			Nickname: users[i].Nickname,
+			Role:     users[i].EffectiveRole(),
 			Avatar:   "",
 		})
 	}
@@ -237,6 +241,12 @@ func UserUpdateProfile(c *gin.Context) {
 		utils.FailWithMsg(c, "个人资料更新失败: "+err.Error())
 		return
 	}
+	// 登录会话与用户名绑定，修改用户名后需重新登录
+	if req.Username != user.Username {
+		if _, err := models.RevokeUserSessions(user.ID, ""); err != nil {
+			utils.Warn("注销用户登录会话失败: %v", err)
+		}
+	}
 
 	utils.OkWithMsg(c, "个人资料更新成功")
 }
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserCreateRequest 管理员创建用户请求
+type UserCreateRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Nickname string `json:"nickname"`
+	Role     string `json:"role"`
+}
+
+// UserCreate 创建用户，未指定角色时默认为只读
+func UserCreate(c *gin.Context) {
+	var req UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "请求参数错误: "+err.Error())
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		utils.FailWithMsg(c, "用户名不能为空")
+		return
+	}
+	if len(req.Password) < 6 {
+		utils.FailWithMsg(c, "密码长度不能小于6位")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if !models.IsValidRole(req.Role) {
+		utils.FailWithMsg(c, "无效的角色")
+		return
+	}
+	existing := &models.User{Username: req.Username}
+	if err := existing.Find(); err == nil {
+		utils.FailWithMsg(c, "用户名已存在")
+		return
+	}
+
+	user := &models.User{
+		Username: req.Username,
+		Password: req.Password,
+		Nickname: strings.TrimSpace(req.Nickname),
+		Role:     req.Role,
+	}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+	if err := user.Create(); err != nil {
+		utils.Error("创建用户失败: %v", err)
+		utils.FailWithMsg(c, "创建用户失败: "+err.Error())
+		return
+	}
+	utils.OkDetailed(c, "创建用户成功", User{ID: user.ID, Username: user.Username, Nickname: user.Nickname, Role: user.Role})
+}
+
+// UserUpdateRole 修改用户角色，至少保留一个管理员
+func UserUpdateRole(c *gin.Context) {
+	var req struct {
+		ID   int    `json:"id"`
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	user, ok := models.GetUserByID(req.ID)
+	if !ok {
+		utils.FailWithMsg(c, "用户不存在")
+		return
+	}
+	if err := user.SetRole(req.Role); err != nil {
+		utils.FailWithMsg(c, err.Error())
+		return
+	}
+	utils.OkWithMsg(c, "角色修改成功")
+}
+
+// UserResetPassword 管理员重置其他用户密码，原有登录会话随之失效
+func UserResetPassword(c *gin.Context) {
+	var req struct {
+		ID       int    `json:"id"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	if len(req.Password) < 6 {
+		utils.FailWithMsg(c, "密码长度不能小于6位")
+		return
+	}
+	user, ok := models.GetUserByID(req.ID)
+	if !ok {
+		utils.FailWithMsg(c, "用户不存在")
+		return
+	}
+	if err := user.Set(&models.User{Password: req.Password}); err != nil {
+		utils.Error("重置密码失败: %v", err)
+		utils.FailWithMsg(c, "重置密码失败")
+		return
+	}
+	utils.OkWithMsg(c, "密码重置成功")
+}
+
+// UserDelete 删除用户，不能删除自己或最后一个管理员
+func UserDelete(c *gin.Context) {
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	current, ok := currentUserFromContext(c)
+	if !ok {
+		return
+	}
+	if current.ID == req.ID {
+		utils.FailWithMsg(c, "不能删除当前登录的用户")
+		return
+	}
+	user, ok := models.GetUserByID(req.ID)
+	if !ok {
+		utils.FailWithMsg(c, "用户不存在")
+		return
+	}
+	if err := user.DeleteUser(); err != nil {
+		if !errors.Is(err, models.ErrLastAdmin) {
+			utils.Error("删除用户失败: %v", err)
+		}
+		utils.FailWithMsg(c, "删除用户失败: "+err.Error())
+		return
+	}
+	utils.OkWithMsg(c, "删除用户成功")
+}
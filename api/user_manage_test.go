@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sublink/database"
+	"sublink/middlewares"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupUserRoleTest 创建管理员、运维、只读三个用户，直接写库以跳过较慢的 bcrypt
+func setupUserRoleTest(t *testing.T) map[string]models.User {
+	t.Helper()
+	setupAuthMFATestDB(t)
+	if err := database.DB.AutoMigrate(&models.AccessKey{}); err != nil {
+		t.Fatalf("auto migrate access keys: %v", err)
+	}
+	users := map[string]models.User{}
+	for _, role := range []string{models.RoleAdmin, models.RoleOperator, models.RoleViewer} {
+		user := models.User{Username: role + "-user", Password: "unused", Role: role, Nickname: role}
+		if err := database.DB.Create(&user).Error; err != nil {
+			t.Fatalf("create %s user: %v", role, err)
+		}
+		users[role] = user
+	}
+	if err := models.InitUserCache(); err != nil {
+		t.Fatalf("init user cache: %v", err)
+	}
+	if err := models.InitAccessKeyCache(); err != nil {
+		t.Fatalf("init access key cache: %v", err)
+	}
+	return users
+}
+
+func performRoleRequest(t *testing.T, username string, required gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/", func(c *gin.Context) {
+		c.Set("username", username)
+		c.Next()
+	}, required, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil))
+	return recorder
+}
+
+func TestRequireRoleEnforcesRoleLevels(t *testing.T) {
+	setupUserRoleTest(t)
+
+	cases := []struct {
+		username string
+		required gin.HandlerFunc
+		allowed  bool
+	}{
+		{"viewer-user", middlewares.RequireOperator, false},
+		{"operator-user", middlewares.RequireOperator, true},
+		{"operator-user", middlewares.RequireAdmin, false},
+		{"admin-user", middlewares.RequireAdmin, true},
+		{"missing-user", middlewares.RequireOperator, false},
+	}
+	for _, tc := range cases {
+		rec := performRoleRequest(t, tc.username, tc.required)
+		if tc.allowed && rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected access, got %d %s", tc.username, rec.Code, rec.Body.String())
+		}
+		if !tc.allowed && rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403, got %d", tc.username, rec.Code)
+		}
+	}
+
+	rec := performRoleRequest(t, "viewer-user", middlewares.RequireOperator)
+	var body struct {
+		I18nKey string `json:"i18nKey"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.I18nKey != middlewares.RoleForbiddenI18nKey {
+		t.Fatalf("expected role forbidden i18n key, got %s", rec.Body.String())
+	}
+}
+
+func TestUserRoleManagementKeepsLastAdmin(t *testing.T) {
+	users := setupUserRoleTest(t)
+	admin := users[models.RoleAdmin]
+
+	rec := performJSONRequestWithContext(t, UserUpdateRole, map[string]any{"id": admin.ID, "role": models.RoleViewer}, admin.Username)
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected demoting the last admin to fail")
+	}
+
+	operator := users[models.RoleOperator]
+	rec = performJSONRequestWithContext(t, UserUpdateRole, map[string]any{"id": operator.ID, "role": models.RoleAdmin}, admin.Username)
+	if resp := decodeAPIResponse(t, rec); resp.Code != 200 {
+		t.Fatalf("expected promotion to succeed, got %+v", resp)
+	}
+	if role, _ := models.GetUserRole(operator.Username); role != models.RoleAdmin {
+		t.Fatalf("expected promoted role in cache, got %q", role)
+	}
+
+	rec = performJSONRequestWithContext(t, UserDelete, map[string]any{"id": admin.ID}, admin.Username)
+	if resp := decodeAPIResponse(t, rec); resp.Code == 200 {
+		t.Fatal("expected deleting the current user to fail")
+	}
+	viewer := users[models.RoleViewer]
+	rec = performJSONRequestWithContext(t, UserDelete, map[string]any{"id": viewer.ID}, admin.Username)
+	if resp := decodeAPIResponse(t, rec); resp.Code != 200 {
+		t.Fatalf("expected viewer deletion to succeed, got %+v", resp)
+	}
+	if _, ok := models.GetUserByID(viewer.ID); ok {
+		t.Fatal("expected deleted user to be removed from cache")
+	}
+}
+
+func TestGenerateAccessKeyOnlyForOwnAccountUnlessAdmin(t *testing.T) {
+	users := setupUserRoleTest(t)
+
+	rec := performJSONRequestWithContext(t, GenerateAccessKey, map[string]any{"username": users[models.RoleAdmin].Username}, users[models.RoleViewer].Username)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected viewer to be refused an admin access key, got %d %s", rec.Code, rec.Body.String())
+	}
+}
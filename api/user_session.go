@@ -0,0 +1,96 @@
+package api
+
+import (
+	"sublink/middlewares"
+	"sublink/models"
+	"sublink/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserSessionResponse 登录会话信息
+type UserSessionResponse struct {
+	ID         int       `json:"id"`
+	ClientIP   string    `json:"clientIp"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"` // 是否为当前请求使用的会话
+}
+
+// UserSessions 获取当前用户的活跃登录会话
+func UserSessions(c *gin.Context) {
+	user, ok := currentUserFromContext(c)
+	if !ok {
+		return
+	}
+	current := c.GetString(middlewares.SessionContextKey)
+	sessions := models.ListUserSessions(user.ID)
+	list := make([]UserSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, UserSessionResponse{
+			ID:         session.ID,
+			ClientIP:   session.ClientIP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.SessionID == current,
+		})
+	}
+	utils.OkWithData(c, list)
+}
+
+// UserRevokeSession 注销当前用户的指定会话
+func UserRevokeSession(c *gin.Context) {
+	var req struct {
+		ID int `json:"id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ID == 0 {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	user, ok := currentUserFromContext(c)
+	if !ok {
+		return
+	}
+	found, err := models.RevokeUserSession(user.ID, req.ID)
+	if err != nil {
+		utils.Error("注销登录会话失败: %v", err)
+		utils.FailWithMsg(c, "注销会话失败")
+		return
+	}
+	if !found {
+		utils.FailWithMsg(c, "会话不存在")
+		return
+	}
+	utils.OkWithMsg(c, "会话已注销")
+}
+
+// UserRevokeAllSessions 退出所有设备，keepCurrent 为 true 时保留当前会话
+func UserRevokeAllSessions(c *gin.Context) {
+	var req struct {
+		KeepCurrent bool `json:"keepCurrent"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMsg(c, "参数错误")
+		return
+	}
+	user, ok := currentUserFromContext(c)
+	if !ok {
+		return
+	}
+	except := ""
+	if req.KeepCurrent {
+		except = c.GetString(middlewares.SessionContextKey)
+	}
+	count, err := models.RevokeUserSessions(user.ID, except)
+	if err != nil {
+		utils.Error("注销登录会话失败: %v", err)
+		utils.FailWithMsg(c, "注销会话失败")
+		return
+	}
+	utils.OkDetailed(c, "已注销全部会话", gin.H{"revoked": count})
+}
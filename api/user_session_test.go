@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sublink/middlewares"
+	"sublink/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupUserSessionTest(t *testing.T) (map[string]models.User, *gin.Engine) {
+	t.Helper()
+	users := setupUserRoleTest(t)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.DELETE("/api/v1/auth/logout", UserOut)
+	group := engine.Group("/api/v1/users", middlewares.AuthToken)
+	group.GET("/sessions", UserSessions)
+	group.POST("/sessions/revoke", UserRevokeSession)
+	group.POST("/sessions/revoke-all", UserRevokeAllSessions)
+	group.POST("/reset-password", UserResetPassword)
+	return users, engine
+}
+
+func loginTokenForTest(t *testing.T, user models.User, userAgent string) string {
+	t.Helper()
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/auth/login", nil)
+	ctx.Request.Header.Set("User-Agent", userAgent)
+	token, err := GetToken(ctx, &user)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return token
+}
+
+func performSessionRequest(t *testing.T, engine *gin.Engine, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatalf("marshal request body: %v", err)
+		}
+	}
+	request := httptest.NewRequestWithContext(context.Background(), method, path, bytes.NewReader(raw))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func listSessionsForTest(t *testing.T, engine *gin.Engine, token string) []UserSessionResponse {
+	t.Helper()
+	resp := decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodGet, "/api/v1/users/sessions", token, nil))
+	if resp.Code != 200 {
+		t.Fatalf("list sessions failed: %+v", resp)
+	}
+	var sessions []UserSessionResponse
+	if err := json.Unmarshal(resp.Data, &sessions); err != nil {
+		t.Fatalf("unmarshal sessions: %v", err)
+	}
+	return sessions
+}
+
+func TestUserSessionsListAndRevoke(t *testing.T) {
+	users, engine := setupUserSessionTest(t)
+	admin := users[models.RoleAdmin]
+	laptop := loginTokenForTest(t, admin, "laptop-browser")
+	phone := loginTokenForTest(t, admin, "phone-browser")
+	loginTokenForTest(t, users[models.RoleViewer], "viewer-browser")
+
+	sessions := listSessionsForTest(t, engine, laptop)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions for admin, got %+v", sessions)
+	}
+	var phoneID int
+	for _, session := range sessions {
+		if session.UserAgent == "laptop-browser" && !session.Current {
+			t.Fatalf("expected laptop session to be marked current, got %+v", session)
+		}
+		if session.UserAgent == "phone-browser" {
+			phoneID = session.ID
+		}
+	}
+
+	resp := decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodPost, "/api/v1/users/sessions/revoke", laptop, map[string]int{"id": phoneID}))
+	if resp.Code != 200 {
+		t.Fatalf("revoke session failed: %+v", resp)
+	}
+	if rec := performSessionRequest(t, engine, http.MethodGet, "/api/v1/users/sessions", phone, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected revoked token to be rejected, got %d", rec.Code)
+	}
+
+	// 不能注销其他用户的会话
+	viewerSessions := models.ListUserSessions(users[models.RoleViewer].ID)
+	resp = decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodPost, "/api/v1/users/sessions/revoke", laptop,
+		map[string]int{"id": viewerSessions[0].ID}))
+	if resp.Code == 200 || len(models.ListUserSessions(users[models.RoleViewer].ID)) != 1 {
+		t.Fatalf("expected revoking another user's session to fail, got %+v", resp)
+	}
+
+	if rec := performSessionRequest(t, engine, http.MethodDelete, "/api/v1/auth/logout", laptop, nil); rec.Code != http.StatusOK {
+		t.Fatalf("logout failed: %d", rec.Code)
+	}
+	if rec := performSessionRequest(t, engine, http.MethodGet, "/api/v1/users/sessions", laptop, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected token to be rejected after logout, got %d", rec.Code)
+	}
+}
+
+func TestUserSessionsRevokeAllAndPasswordReset(t *testing.T) {
+	users, engine := setupUserSessionTest(t)
+	admin := users[models.RoleAdmin]
+	current := loginTokenForTest(t, admin, "current")
+	other := loginTokenForTest(t, admin, "other")
+
+	resp := decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodPost, "/api/v1/users/sessions/revoke-all", current,
+		map[string]bool{"keepCurrent": true}))
+	if resp.Code != 200 {
+		t.Fatalf("revoke all sessions failed: %+v", resp)
+	}
+	if rec := performSessionRequest(t, engine, http.MethodGet, "/api/v1/users/sessions", other, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected other session to be revoked, got %d", rec.Code)
+	}
+	if sessions := listSessionsForTest(t, engine, current); len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("expected only current session to remain, got %+v", sessions)
+	}
+
+	// 管理员重置密码后，目标用户的全部会话失效
+	operator := users[models.RoleOperator]
+	operatorToken := loginTokenForTest(t, operator, "operator")
+	resp = decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodPost, "/api/v1/users/reset-password", current,
+		map[string]any{"id": operator.ID, "password": "new-password"}))
+	if resp.Code != 200 {
+		t.Fatalf("reset password failed: %+v", resp)
+	}
+	if rec := performSessionRequest(t, engine, http.MethodGet, "/api/v1/users/sessions", operatorToken, nil); rec.Code != http.StatusForbidden ||
+		len(models.ListUserSessions(operator.ID)) != 0 {
+		t.Fatalf("expected password reset to revoke sessions, got %d", rec.Code)
+	}
+
+	resp = decodeAPIResponse(t, performSessionRequest(t, engine, http.MethodPost, "/api/v1/users/sessions/revoke-all", current, map[string]bool{}))
+	if resp.Code != 200 || len(models.ListUserSessions(admin.ID)) != 0 {
+		t.Fatalf("expected log out everywhere to revoke current session, got %+v", resp)
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// RenderedConfig 最近一次校验通过的订阅配置
+type RenderedConfig struct {
+	Key        string    // 订阅ID:分享ID
+	Content    []byte    // 配置内容
+	RenderedAt time.Time // 渲染时间
+}
+
+// renderedConfigCache 校验通过的配置缓存（订阅ID:分享ID -> 配置）
+// 开启输出前校验时，渲染结果校验失败会回退到这里保存的上一次正确结果。
+var renderedConfigCache *MapCache[string, RenderedConfig]
+var renderedConfigOnce sync.Once
+
+// getRenderedConfigCache 获取或初始化渲染结果缓存
+func getRenderedConfigCache() *MapCache[string, RenderedConfig] {
+	renderedConfigOnce.Do(func() {
+		renderedConfigCache = NewMapCache(func(rc RenderedConfig) string { return rc.Key })
+	})
+	return renderedConfigCache
+}
+
+// GetLastGoodRender 获取最近一次校验通过的配置
+func GetLastGoodRender(key string) (RenderedConfig, bool) {
+	return getRenderedConfigCache().Get(key)
+}
+
+// SetLastGoodRender 记录校验通过的配置
+func SetLastGoodRender(key string, content []byte) {
+	getRenderedConfigCache().Set(key, RenderedConfig{
+		Key:        key,
+		Content:    content,
+		RenderedAt: time.Now(),
+	})
+}
+
+// InitRenderedConfigCache 初始化渲染结果缓存（注册到管理器）
+func InitRenderedConfigCache() {
+	Manager.Register("renderedConfig", getRenderedConfigCache())
+}
@@ -3,10 +3,10 @@ package config
 import (
 	"crypto/rand"
 	"encoding/hex"
-	"log"
 	"os"
 	"strconv"
 	"strings"
+	"sublink/utils"
 	"sync"
 
 	"gopkg.in/yaml.v3"
@@ -15,6 +15,8 @@ import (
 // 环境变量前缀
 const envPrefix = "SUBLINK_"
 
+var logger = utils.WithSubsystem(utils.SubsystemConfig)
+
 // 默认配置值
 const (
 	DefaultPort             = 8000
@@ -29,6 +31,9 @@ const (
 	DefaultCaptchaMode      = CaptchaModeTraditional // 默认传统验证码
 )
 
+// DefaultTracingSampleRatio 默认链路追踪采样比例，全部采样
+const DefaultTracingSampleRatio = 1.0
+
 var DefaultTrustedProxies = []string{
 	"127.0.0.1",
 	"::1",
@@ -47,25 +52,31 @@ const (
 
 // AppConfig 应用配置结构
 type AppConfig struct {
-	Port               int      `yaml:"port"`                 // 服务端口
-	JwtSecret          string   `yaml:"jwt_secret"`           // JWT密钥
-	APIEncryptionKey   string   `yaml:"api_encryption_key"`   // API加密密钥
-	ExpireDays         int      `yaml:"expire_days"`          // Token过期天数
-	LoginFailCount     int      `yaml:"login_fail_count"`     // 登录失败次数限制
-	LoginFailWindow    int      `yaml:"login_fail_window"`    // 登录失败窗口时间(分钟)
-	LoginBanDuration   int      `yaml:"login_ban_duration"`   // 登录失败封禁时间(分钟)
-	DSN                string   `yaml:"dsn"`                  // 数据库 DSN（支持 sqlite/mysql/postgres）
-	DBPath             string   `yaml:"db_path"`              // 本地数据目录 / SQLite 默认数据库目录
-	LogPath            string   `yaml:"log_path"`             // 日志目录
-	LogLevel           string   `yaml:"log_level"`            // 日志等级 (debug/info/warn/error/fatal)
-	GeoIPPath          string   `yaml:"geoip_path"`           // GeoIP数据库路径
-	CaptchaMode        int      `yaml:"captcha_mode"`         // 验证码模式 (1=关闭, 2=传统, 3=Turnstile)
-	TurnstileSiteKey   string   `yaml:"turnstile_site_key"`   // Cloudflare Turnstile Site Key
-	TurnstileSecretKey string   `yaml:"turnstile_secret_key"` // Cloudflare Turnstile Secret Key
-	TurnstileProxyLink string   `yaml:"turnstile_proxy_link"` // Turnstile 验证代理链接（mihomo 格式）
-	WebBasePath        string   `yaml:"web_base_path"`        // 前端基础路径（用于隐藏站点入口）
-	TrustedProxies     []string `yaml:"trusted_proxies"`      // 可信反向代理列表（支持 IP/CIDR）
-	MFAResetSecret     string   `yaml:"-"`
+	Port               int            `yaml:"port"`                 // 服务端口
+	JwtSecret          string         `yaml:"jwt_secret"`           // JWT密钥
+	APIEncryptionKey   string         `yaml:"api_encryption_key"`   // API加密密钥
+	ExpireDays         int            `yaml:"expire_days"`          // Token过期天数
+	LoginFailCount     int            `yaml:"login_fail_count"`     // 登录失败次数限制
+	LoginFailWindow    int            `yaml:"login_fail_window"`    // 登录失败窗口时间(分钟)
+	LoginBanDuration   int            `yaml:"login_ban_duration"`   // 登录失败封禁时间(分钟)
+	DSN                string         `yaml:"dsn"`                  // 数据库 DSN（支持 sqlite/mysql/postgres）
+	DBPath             string         `yaml:"db_path"`              // 本地数据目录 / SQLite 默认数据库目录
+	LogPath            string         `yaml:"log_path"`             // 日志目录
+	LogLevel           string         `yaml:"log_level"`            // 日志等级 (debug/info/warn/error/fatal)
+	LogFormat          string         `yaml:"log_format"`           // 日志输出格式 (text/json)
+	GeoIPPath          string         `yaml:"geoip_path"`           // GeoIP数据库路径
+	CaptchaMode        int            `yaml:"captcha_mode"`         // 验证码模式 (1=关闭, 2=传统, 3=Turnstile)
+	TurnstileSiteKey   string         `yaml:"turnstile_site_key"`   // Cloudflare Turnstile Site Key
+	TurnstileSecretKey string         `yaml:"turnstile_secret_key"` // Cloudflare Turnstile Secret Key
+	TurnstileProxyLink string         `yaml:"turnstile_proxy_link"` // Turnstile 验证代理链接（mihomo 格式）
+	WebBasePath        string         `yaml:"web_base_path"`        // 前端基础路径（用于隐藏站点入口）
+	TrustedProxies     []string       `yaml:"trusted_proxies"`      // 可信反向代理列表（支持 IP/CIDR）
+	MetricsListen      string         `yaml:"metrics_listen"`       // 独立的监控指标监听地址，为空时仅通过 API Key 访问
+	TracingEndpoint    string         `yaml:"tracing_endpoint"`     // OTLP/HTTP 链路追踪上报地址，为空时不启用
+	TracingSampleRatio float64        `yaml:"tracing_sample_ratio"` // 链路追踪采样比例 (0, 1]
+	MFAResetSecret     string         `yaml:"-"`
+	OIDC               OIDCConfig     `yaml:"oidc,omitempty"`     // OIDC 单点登录配置
+	WebAuthn           WebAuthnConfig `yaml:"webauthn,omitempty"` // 通行密钥配置
 }
 
 // CommandLineConfig 命令行配置（仅存储用户指定的值）
@@ -238,7 +249,7 @@ func Load() *AppConfig {
 
 	globalConfig = cfg
 
-	log.Printf("配置加载完成: Port=%d, ExpireDays=%d, DBPath=%s, LogPath=%s",
+	logger.Info("配置加载完成: Port=%d, ExpireDays=%d, DBPath=%s, LogPath=%s",
 		cfg.Port, cfg.ExpireDays, cfg.DBPath, cfg.LogPath)
 
 	return cfg
@@ -255,7 +266,7 @@ func LoadBootstrap() *AppConfig {
 	cfg := buildBaseConfigInternal(configPath)
 	globalConfig = cfg
 
-	log.Printf("基础配置加载完成: Port=%d, DBPath=%s, LogPath=%s, DSN=%t",
+	logger.Info("基础配置加载完成: Port=%d, DBPath=%s, LogPath=%s, DSN=%t",
 		cfg.Port, cfg.DBPath, cfg.LogPath, cfg.DSN != "")
 
 	return cfg
@@ -365,7 +376,7 @@ func GetCaptchaConfig() CaptchaConfig {
 			// 降级为传统验证码
 			cfg.Mode = CaptchaModeTraditional
 			cfg.Degraded = true
-			log.Printf("警告: Turnstile 配置不完整，降级为传统验证码")
+			logger.Warn("Turnstile 配置不完整，降级为传统验证码")
 		}
 	default:
 		// 传统验证码
@@ -408,6 +419,17 @@ func GetWebBasePath() string {
 	return ""
 }
 
+// GetMetricsListen 获取独立的监控指标监听地址
+func GetMetricsListen() string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	if globalConfig != nil {
+		return globalConfig.MetricsListen
+	}
+	return ""
+}
+
 // normalizeBasePath 规范化基础路径
 // - 确保以 / 开头（非空时）
 // - 去除尾部斜杠
@@ -447,6 +469,7 @@ func applyDefaults(cfg *AppConfig) {
 	cfg.WebBasePath = "" // 默认为空，表示根路径
 	cfg.TrustedProxies = append([]string(nil), DefaultTrustedProxies...)
 	cfg.MFAResetSecret = ""
+	cfg.TracingSampleRatio = DefaultTracingSampleRatio
 }
 
 // buildBaseConfigInternal 构建不依赖数据库的基础配置
@@ -464,16 +487,16 @@ func loadFromFileInternal(cfg *AppConfig, configPath string) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			log.Printf("配置文件 %s 不存在，使用默认配置", configPath)
+			logger.Warn("配置文件 %s 不存在，使用默认配置", configPath)
 		} else {
-			log.Printf("读取配置文件失败: %v", err)
+			logger.Error("读取配置文件失败: %v", err)
 		}
 		return
 	}
 
 	fileCfg := &AppConfig{}
 	if err := yaml.Unmarshal(data, fileCfg); err != nil {
-		log.Printf("解析配置文件失败: %v", err)
+		logger.Error("解析配置文件失败: %v", err)
 		return
 	}
 
@@ -505,6 +528,9 @@ func loadFromFileInternal(cfg *AppConfig, configPath string) {
 	if fileCfg.LogLevel != "" {
 		cfg.LogLevel = fileCfg.LogLevel
 	}
+	if fileCfg.LogFormat != "" {
+		cfg.LogFormat = strings.ToLower(strings.TrimSpace(fileCfg.LogFormat))
+	}
 	if fileCfg.GeoIPPath != "" {
 		cfg.GeoIPPath = fileCfg.GeoIPPath
 	}
@@ -535,6 +561,17 @@ func loadFromFileInternal(cfg *AppConfig, configPath string) {
 	if fileCfg.TrustedProxies != nil {
 		cfg.TrustedProxies = normalizeTrustedProxies(fileCfg.TrustedProxies)
 	}
+	if fileCfg.MetricsListen != "" {
+		cfg.MetricsListen = strings.TrimSpace(fileCfg.MetricsListen)
+	}
+	if fileCfg.TracingEndpoint != "" {
+		cfg.TracingEndpoint = strings.TrimSpace(fileCfg.TracingEndpoint)
+	}
+	if fileCfg.TracingSampleRatio > 0 && fileCfg.TracingSampleRatio <= 1 {
+		cfg.TracingSampleRatio = fileCfg.TracingSampleRatio
+	}
+	mergeOIDCConfig(cfg, fileCfg.OIDC)
+	mergeWebAuthnConfig(cfg, fileCfg.WebAuthn)
 }
 
 // loadFromEnvInternal 从环境变量加载（内部使用，不获取锁）
@@ -576,6 +613,9 @@ func loadFromEnvInternal(cfg *AppConfig) {
 	if logLevel := os.Getenv(envPrefix + "LOG_LEVEL"); logLevel != "" {
 		cfg.LogLevel = logLevel
 	}
+	if logFormat := os.Getenv(envPrefix + "LOG_FORMAT"); logFormat != "" {
+		cfg.LogFormat = strings.ToLower(strings.TrimSpace(logFormat))
+	}
 	if geoipPath := os.Getenv(envPrefix + "GEOIP_PATH"); geoipPath != "" {
 		cfg.GeoIPPath = geoipPath
 	}
@@ -611,6 +651,19 @@ func loadFromEnvInternal(cfg *AppConfig) {
 	if trustedProxies, ok := os.LookupEnv(envPrefix + "TRUSTED_PROXIES"); ok {
 		cfg.TrustedProxies = normalizeTrustedProxies(strings.Split(trustedProxies, ","))
 	}
+	if metricsListen := os.Getenv(envPrefix + "METRICS_LISTEN"); metricsListen != "" {
+		cfg.MetricsListen = strings.TrimSpace(metricsListen)
+	}
+	if tracingEndpoint := os.Getenv(envPrefix + "TRACING_ENDPOINT"); tracingEndpoint != "" {
+		cfg.TracingEndpoint = strings.TrimSpace(tracingEndpoint)
+	}
+	if ratio := os.Getenv(envPrefix + "TRACING_SAMPLE_RATIO"); ratio != "" {
+		if r, err := strconv.ParseFloat(ratio, 64); err == nil && r > 0 && r <= 1 {
+			cfg.TracingSampleRatio = r
+		}
+	}
+	loadOIDCFromEnvInternal(cfg)
+	loadWebAuthnFromEnvInternal(cfg)
 }
 
 // loadFromCmdLineInternal 从命令行参数加载（内部使用，不获取锁）
@@ -654,12 +707,12 @@ func handleSecretsInternal(cfg *AppConfig) {
 			// 只有当数据库值不同时才更新
 			if dbSecret != cfg.JwtSecret {
 				if err := secretSetterFunc("jwt_secret", cfg.JwtSecret); err != nil {
-					log.Printf("同步 JWT Secret 到数据库失败: %v", err)
+					logger.Error("同步 JWT Secret 到数据库失败: %v", err)
 				} else {
 					if envJwtSecret != "" {
-						log.Println("已将环境变量 SUBLINK_JWT_SECRET 同步到数据库")
+						logger.Info("已将环境变量 SUBLINK_JWT_SECRET 同步到数据库")
 					} else {
-						log.Println("已将配置文件中的 JWT Secret 同步到数据库")
+						logger.Info("已将配置文件中的 JWT Secret 同步到数据库")
 					}
 				}
 			}
@@ -674,10 +727,10 @@ func handleSecretsInternal(cfg *AppConfig) {
 	// 如果仍然没有值，自动生成
 	if cfg.JwtSecret == "" {
 		cfg.JwtSecret = generateRandomKey(32)
-		log.Println("JWT Secret 未配置，已自动生成并保存到数据库")
+		logger.Info("JWT Secret 未配置，已自动生成并保存到数据库")
 		if secretSetterFunc != nil {
 			if err := secretSetterFunc("jwt_secret", cfg.JwtSecret); err != nil {
-				log.Printf("保存 JWT Secret 到数据库失败: %v", err)
+				logger.Error("保存 JWT Secret 到数据库失败: %v", err)
 			}
 		}
 	}
@@ -697,12 +750,12 @@ func handleSecretsInternal(cfg *AppConfig) {
 			// 只有当数据库值不同时才更新
 			if dbKey != cfg.APIEncryptionKey {
 				if err := secretSetterFunc("api_encryption_key", cfg.APIEncryptionKey); err != nil {
-					log.Printf("同步 API 加密密钥到数据库失败: %v", err)
+					logger.Error("同步 API 加密密钥到数据库失败: %v", err)
 				} else {
 					if envApiKey != "" {
-						log.Println("已将环境变量 SUBLINK_API_ENCRYPTION_KEY 同步到数据库")
+						logger.Info("已将环境变量 SUBLINK_API_ENCRYPTION_KEY 同步到数据库")
 					} else {
-						log.Println("已将配置文件中的 API 加密密钥同步到数据库")
+						logger.Info("已将配置文件中的 API 加密密钥同步到数据库")
 					}
 				}
 			}
@@ -717,12 +770,12 @@ func handleSecretsInternal(cfg *AppConfig) {
 	// 如果仍然没有值，自动生成
 	if cfg.APIEncryptionKey == "" {
 		cfg.APIEncryptionKey = generateRandomKey(32)
-		log.Println("API 加密密钥未配置，已自动生成")
+		logger.Info("API 加密密钥未配置，已自动生成")
 		if secretSetterFunc != nil {
 			if err := secretSetterFunc("api_encryption_key", cfg.APIEncryptionKey); err != nil {
-				log.Printf("保存 API 加密密钥到数据库失败: %v", err)
+				logger.Error("保存 API 加密密钥到数据库失败: %v", err)
 			} else {
-				log.Println("API 加密密钥已保存到数据库")
+				logger.Info("API 加密密钥已保存到数据库")
 			}
 		}
 	}
@@ -733,7 +786,7 @@ func generateRandomKey(length int) string {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
 		// 如果随机数生成失败，使用备用方案
-		log.Printf("生成随机密钥失败: %v，使用备用方案", err)
+		logger.Warn("生成随机密钥失败: %v，使用备用方案", err)
 		return "fallback-key-please-change-" + strconv.FormatInt(int64(os.Getpid()), 16)
 	}
 	return hex.EncodeToString(bytes)
@@ -774,12 +827,18 @@ func SaveToFile() error {
 		DBPath:             cfg.DBPath,
 		LogPath:            cfg.LogPath,
 		LogLevel:           cfg.LogLevel,
+		LogFormat:          cfg.LogFormat,
 		GeoIPPath:          cfg.GeoIPPath,
 		CaptchaMode:        cfg.CaptchaMode,
 		TurnstileSiteKey:   cfg.TurnstileSiteKey,
 		TurnstileProxyLink: cfg.TurnstileProxyLink,
 		WebBasePath:        cfg.WebBasePath,
 		TrustedProxies:     append([]string(nil), cfg.TrustedProxies...),
+		MetricsListen:      cfg.MetricsListen,
+		TracingEndpoint:    cfg.TracingEndpoint,
+		TracingSampleRatio: cfg.TracingSampleRatio,
+		OIDC:               cfg.OIDC,
+		WebAuthn:           cfg.WebAuthn,
 	}
 
 	// 生成 YAML 内容（包含注释）
@@ -839,7 +898,7 @@ func MigrateFromOldConfig() bool {
 		// 检查数据库中是否已有值
 		if secretGetterFunc == nil || secretGetterFunc("jwt_secret") == "" {
 			if err := secretSetterFunc("jwt_secret", oldCfg.JwtSecret); err == nil {
-				log.Println("已将 JWT Secret 从配置文件迁移到数据库")
+				logger.Info("已将 JWT Secret 从配置文件迁移到数据库")
 				migrated = true
 			}
 		}
@@ -849,7 +908,7 @@ func MigrateFromOldConfig() bool {
 	if oldCfg.APIEncryptionKey != "" && secretSetterFunc != nil {
 		if secretGetterFunc == nil || secretGetterFunc("api_encryption_key") == "" {
 			if err := secretSetterFunc("api_encryption_key", oldCfg.APIEncryptionKey); err == nil {
-				log.Println("已将 API 加密密钥从配置文件迁移到数据库")
+				logger.Info("已将 API 加密密钥从配置文件迁移到数据库")
 				migrated = true
 			}
 		}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 默认 OIDC 配置
+const (
+	DefaultOIDCUsernameClaim = "preferred_username"
+	DefaultOIDCGroupsClaim   = "groups"
+	DefaultOIDCDisplayName   = "SSO"
+)
+
+// OIDCConfig OIDC 单点登录配置
+type OIDCConfig struct {
+	Issuer               string            `yaml:"issuer"`                 // Issuer 地址，用于发现授权/令牌端点
+	ClientID             string            `yaml:"client_id"`              // 客户端 ID
+	ClientSecret         string            `yaml:"client_secret"`          // 客户端密钥
+	RedirectURL          string            `yaml:"redirect_url"`           // 回调地址，需指向 /api/v1/auth/oidc/callback
+	Scopes               []string          `yaml:"scopes"`                 // 额外申请的 scope，openid 总会包含
+	DisplayName          string            `yaml:"display_name"`           // 登录按钮显示名称
+	UsernameClaim        string            `yaml:"username_claim"`         // 用户名取值的 claim
+	GroupsClaim          string            `yaml:"groups_claim"`           // 用户组取值的 claim
+	RoleMapping          map[string]string `yaml:"role_mapping"`           // 用户组到角色的映射
+	DefaultRole          string            `yaml:"default_role"`           // 未匹配任何用户组时的角色，为空则拒绝登录
+	DisablePasswordLogin bool              `yaml:"disable_password_login"` // 启用 OIDC 后禁用账号密码登录
+}
+
+// Enabled 是否已配置 OIDC
+func (cfg OIDCConfig) Enabled() bool {
+	return cfg.Issuer != "" && cfg.ClientID != "" && cfg.RedirectURL != ""
+}
+
+// GetOIDCConfig 获取 OIDC 配置（已填充默认值）
+func GetOIDCConfig() OIDCConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	var cfg OIDCConfig
+	if globalConfig != nil {
+		cfg = globalConfig.OIDC
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = DefaultOIDCUsernameClaim
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = DefaultOIDCDisplayName
+	}
+	return cfg
+}
+
+// IsPasswordLoginDisabled 是否禁用账号密码登录
+// 仅在 OIDC 配置完整时生效，避免配置错误导致无法登录。
+func IsPasswordLoginDisabled() bool {
+	cfg := GetOIDCConfig()
+	return cfg.Enabled() && cfg.DisablePasswordLogin
+}
+
+// mergeOIDCConfig 合并配置文件中的 OIDC 配置
+func mergeOIDCConfig(cfg *AppConfig, fileCfg OIDCConfig) {
+	if fileCfg.Issuer != "" {
+		cfg.OIDC.Issuer = strings.TrimSuffix(fileCfg.Issuer, "/")
+	}
+	if fileCfg.ClientID != "" {
+		cfg.OIDC.ClientID = fileCfg.ClientID
+	}
+	if fileCfg.ClientSecret != "" {
+		cfg.OIDC.ClientSecret = fileCfg.ClientSecret
+	}
+	if fileCfg.RedirectURL != "" {
+		cfg.OIDC.RedirectURL = fileCfg.RedirectURL
+	}
+	if fileCfg.Scopes != nil {
+		cfg.OIDC.Scopes = fileCfg.Scopes
+	}
+	if fileCfg.DisplayName != "" {
+		cfg.OIDC.DisplayName = fileCfg.DisplayName
+	}
+	if fileCfg.UsernameClaim != "" {
+		cfg.OIDC.UsernameClaim = fileCfg.UsernameClaim
+	}
+	if fileCfg.GroupsClaim != "" {
+		cfg.OIDC.GroupsClaim = fileCfg.GroupsClaim
+	}
+	if fileCfg.RoleMapping != nil {
+		cfg.OIDC.RoleMapping = fileCfg.RoleMapping
+	}
+	if fileCfg.DefaultRole != "" {
+		cfg.OIDC.DefaultRole = fileCfg.DefaultRole
+	}
+	cfg.OIDC.DisablePasswordLogin = cfg.OIDC.DisablePasswordLogin || fileCfg.DisablePasswordLogin
+}
+
+// loadOIDCFromEnvInternal 从环境变量加载 OIDC 配置
+// SUBLINK_OIDC_ROLE_MAPPING 格式为 "group=role,group2=role2"。
+func loadOIDCFromEnvInternal(cfg *AppConfig) {
+	if issuer := os.Getenv(envPrefix + "OIDC_ISSUER"); issuer != "" {
+		cfg.OIDC.Issuer = strings.TrimSuffix(issuer, "/")
+	}
+	if clientID := os.Getenv(envPrefix + "OIDC_CLIENT_ID"); clientID != "" {
+		cfg.OIDC.ClientID = clientID
+	}
+	if clientSecret := os.Getenv(envPrefix + "OIDC_CLIENT_SECRET"); clientSecret != "" {
+		cfg.OIDC.ClientSecret = clientSecret
+	}
+	if redirectURL := os.Getenv(envPrefix + "OIDC_REDIRECT_URL"); redirectURL != "" {
+		cfg.OIDC.RedirectURL = redirectURL
+	}
+	if scopes := os.Getenv(envPrefix + "OIDC_SCOPES"); scopes != "" {
+		cfg.OIDC.Scopes = normalizeTrustedProxies(strings.Split(scopes, ","))
+	}
+	if displayName := os.Getenv(envPrefix + "OIDC_DISPLAY_NAME"); displayName != "" {
+		cfg.OIDC.DisplayName = displayName
+	}
+	if claim := os.Getenv(envPrefix + "OIDC_USERNAME_CLAIM"); claim != "" {
+		cfg.OIDC.UsernameClaim = claim
+	}
+	if claim := os.Getenv(envPrefix + "OIDC_GROUPS_CLAIM"); claim != "" {
+		cfg.OIDC.GroupsClaim = claim
+	}
+	if mapping := os.Getenv(envPrefix + "OIDC_ROLE_MAPPING"); mapping != "" {
+		cfg.OIDC.RoleMapping = make(map[string]string)
+		for _, pair := range strings.Split(mapping, ",") {
+			group, role, ok := strings.Cut(pair, "=")
+			if ok && strings.TrimSpace(group) != "" {
+				cfg.OIDC.RoleMapping[strings.TrimSpace(group)] = strings.TrimSpace(role)
+			}
+		}
+	}
+	if role := os.Getenv(envPrefix + "OIDC_DEFAULT_ROLE"); role != "" {
+		cfg.OIDC.DefaultRole = role
+	}
+	if disabled := os.Getenv(envPrefix + "OIDC_DISABLE_PASSWORD_LOGIN"); disabled != "" {
+		cfg.OIDC.DisablePasswordLogin, _ = strconv.ParseBool(disabled)
+	}
+}
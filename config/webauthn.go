@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultWebAuthnDisplayName 默认的依赖方显示名称
+const DefaultWebAuthnDisplayName = "SublinkPro"
+
+// WebAuthnConfig 通行密钥（WebAuthn）配置
+// RPID 与 RPOrigins 为空时使用系统域名设置，未设置时按请求推导；只有可信代理传递的 X-Forwarded-* 头才会被采信。
+type WebAuthnConfig struct {
+	RPID          string   `yaml:"rp_id"`           // 依赖方 ID，一般为访问域名
+	RPDisplayName string   `yaml:"rp_display_name"` // 认证器中显示的名称
+	RPOrigins     []string `yaml:"rp_origins"`      // 允许的来源，如 https://sub.example.com
+	Passwordless  bool     `yaml:"passwordless"`    // 是否允许使用通行密钥免密码登录
+}
+
+// GetWebAuthnConfig 获取通行密钥配置（已填充默认值）
+func GetWebAuthnConfig() WebAuthnConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	var cfg WebAuthnConfig
+	if globalConfig != nil {
+		cfg = globalConfig.WebAuthn
+	}
+	if cfg.RPDisplayName == "" {
+		cfg.RPDisplayName = DefaultWebAuthnDisplayName
+	}
+	return cfg
+}
+
+// mergeWebAuthnConfig 合并配置文件中的通行密钥配置
+func mergeWebAuthnConfig(cfg *AppConfig, fileCfg WebAuthnConfig) {
+	if fileCfg.RPID != "" {
+		cfg.WebAuthn.RPID = fileCfg.RPID
+	}
+	if fileCfg.RPDisplayName != "" {
+		cfg.WebAuthn.RPDisplayName = fileCfg.RPDisplayName
+	}
+	if fileCfg.RPOrigins != nil {
+		cfg.WebAuthn.RPOrigins = fileCfg.RPOrigins
+	}
+	cfg.WebAuthn.Passwordless = cfg.WebAuthn.Passwordless || fileCfg.Passwordless
+}
+
+// loadWebAuthnFromEnvInternal 从环境变量加载通行密钥配置
+func loadWebAuthnFromEnvInternal(cfg *AppConfig) {
+	if rpID := os.Getenv(envPrefix + "WEBAUTHN_RP_ID"); rpID != "" {
+		cfg.WebAuthn.RPID = rpID
+	}
+	if name := os.Getenv(envPrefix + "WEBAUTHN_RP_DISPLAY_NAME"); name != "" {
+		cfg.WebAuthn.RPDisplayName = name
+	}
+	if origins := os.Getenv(envPrefix + "WEBAUTHN_RP_ORIGINS"); origins != "" {
+		cfg.WebAuthn.RPOrigins = normalizeTrustedProxies(strings.Split(origins, ","))
+	}
+	if passwordless := os.Getenv(envPrefix + "WEBAUTHN_PASSWORDLESS"); passwordless != "" {
+		cfg.WebAuthn.Passwordless, _ = strconv.ParseBool(passwordless)
+	}
+}
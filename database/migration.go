@@ -2,6 +2,7 @@ package database
 
 import (
 	"sublink/utils"
+	"sync"
 	"time"
 )
 
@@ -10,8 +11,46 @@ type Migration struct {
 	CreatedAt time.Time
 }
 
+// migrationPlan 非 nil 时处于只读规划模式：迁移不会执行，只记录尚未执行的迁移 ID
+var (
+	migrationPlanMu sync.Mutex
+	migrationPlan   *[]string
+)
+
+// PlanMigrations 以只读方式走一遍迁移流程，返回尚未执行的迁移 ID
+// run 中调用的 RunAutoMigrate/RunCustomMigration 只查询迁移记录，不建表也不执行迁移逻辑。
+func PlanMigrations(run func()) []string {
+	migrationPlanMu.Lock()
+	defer migrationPlanMu.Unlock()
+
+	pending := []string{}
+	migrationPlan = &pending
+	defer func() { migrationPlan = nil }()
+	run()
+	return pending
+}
+
+// planMigration 规划模式下记录尚未执行的迁移，返回 true 表示处于规划模式、调用方应直接返回
+func planMigration(migrationID string) bool {
+	if migrationPlan == nil {
+		return false
+	}
+	if DB.Migrator().HasTable(&Migration{}) {
+		var count int64
+		DB.Model(&Migration{}).Where("id = ?", migrationID).Count(&count)
+		if count > 0 {
+			return true
+		}
+	}
+	*migrationPlan = append(*migrationPlan, migrationID)
+	return true
+}
+
 // RunAutoMigrate 执行自动迁移，如果 migrationID 已存在则跳过
 func RunAutoMigrate(migrationID string, dst ...any) error {
+	if planMigration(migrationID) {
+		return nil
+	}
 	// 确保 Migration 表存在
 	if !DB.Migrator().HasTable(&Migration{}) {
 		if err := DB.AutoMigrate(&Migration{}); err != nil {
@@ -40,6 +79,9 @@ func RunAutoMigrate(migrationID string, dst ...any) error {
 
 // RunCustomMigration 执行自定义迁移逻辑，如果 migrationID 已存在则跳过
 func RunCustomMigration(migrationID string, action func() error) error {
+	if planMigration(migrationID) {
+		return nil
+	}
 	// 确保 Migration 表存在
 	if !DB.Migrator().HasTable(&Migration{}) {
 		if err := DB.AutoMigrate(&Migration{}); err != nil {
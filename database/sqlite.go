@@ -38,12 +38,16 @@ var Dialect = DialectSQLite
 // IsInitialized 标记数据库是否已初始化迁移
 var IsInitialized bool
 
+// currentDSN 当前全局连接的方言与脱敏 DSN
+var currentDSN DSNInfo
+
 type dbConnectionInfo struct {
 	Dialect            string
 	Dialector          gorm.Dialector
 	BootstrapDialector gorm.Dialector
 	DBName             string
 	RedactedDSN        string
+	SQLitePath         string // SQLite 数据库文件路径，内存库与 file: URI 为空
 }
 
 // isDemoMode 判断是否为演示模式（避免循环导入）
@@ -64,7 +68,7 @@ func Init() error {
 		rawDSN = defaultSQLiteDSN(config.GetDBPath())
 	}
 
-	db, info, err := openDatabase(rawDSN, newGormConfig())
+	db, info, err := openDatabase(rawDSN, newGormConfig(), true)
 	if err != nil {
 		DB = nil
 		utils.Error("连接数据库失败: %v", err)
@@ -72,9 +76,13 @@ func Init() error {
 	}
 
 	configureConnectionPool(db, info.Dialect)
+	if err := RegisterWriteGuard(db); err != nil {
+		utils.Warn("注册数据库写入保护失败: %v", err)
+	}
 
 	DB = db
 	Dialect = info.Dialect
+	currentDSN = DSNInfo{Dialect: info.Dialect, RedactedDSN: info.RedactedDSN}
 	utils.Info("数据库已初始化: driver=%s, dsn=%s", info.Dialect, info.RedactedDSN)
 	return nil
 }
@@ -106,15 +114,70 @@ func OpenMigrationSourceSQLite(dbPath string) (*gorm.DB, error) {
 	return gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 }
 
-func openDatabase(rawDSN string, gormConfig *gorm.Config) (*gorm.DB, *dbConnectionInfo, error) {
+// DSNInfo 描述按 DSN 打开的数据库连接
+type DSNInfo struct {
+	Dialect     string `json:"dialect"`
+	RedactedDSN string `json:"dsn"`
+}
+
+// ErrDatabaseNotExist 按 DSN 打开已有数据库时，目标库或 SQLite 文件不存在
+var ErrDatabaseNotExist = errors.New("数据库不存在")
+
+// OpenDSN 按 DSN 打开一个独立的数据库连接，不修改全局 DB，供跨数据库迁移使用。
+// MySQL 与 PostgreSQL 的目标库不存在时会自动创建，SQLite 会创建所在目录与数据库文件。
+func OpenDSN(rawDSN string) (*gorm.DB, *DSNInfo, error) {
+	db, info, err := openDatabase(rawDSN, newGormConfig(), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, &DSNInfo{Dialect: info.Dialect, RedactedDSN: info.RedactedDSN}, nil
+}
+
+// OpenExistingDSN 与 OpenDSN 相同，但不会建库，也不会创建 SQLite 目录或文件。
+// 库不存在时返回 ErrDatabaseNotExist，此时仍返回 DSN 信息供调用方展示。
+func OpenExistingDSN(rawDSN string) (*gorm.DB, *DSNInfo, error) {
+	db, info, err := openDatabase(rawDSN, newGormConfig(), false)
+	if info == nil {
+		return nil, nil, err
+	}
+	dsnInfo := &DSNInfo{Dialect: info.Dialect, RedactedDSN: info.RedactedDSN}
+	if err != nil {
+		return nil, dsnInfo, err
+	}
+	return db, dsnInfo, nil
+}
+
+// CurrentDSN 返回当前全局连接的方言与脱敏 DSN，未通过 Init 初始化时 DSN 为空
+func CurrentDSN() DSNInfo {
+	info := currentDSN
+	if info.Dialect == "" {
+		info.Dialect = Dialect
+	}
+	return info
+}
+
+// openDatabase 按 DSN 打开数据库；create 为 false 时库不存在返回 ErrDatabaseNotExist（连同连接信息）
+func openDatabase(rawDSN string, gormConfig *gorm.Config, create bool) (*gorm.DB, *dbConnectionInfo, error) {
 	info, err := buildConnectionInfo(rawDSN)
 	if err != nil {
 		return nil, nil, err
 	}
+	if info.SQLitePath != "" {
+		if create {
+			if err := ensureSQLiteDir(info.SQLitePath); err != nil {
+				return nil, nil, err
+			}
+		} else if _, err := os.Stat(info.SQLitePath); errors.Is(err, os.ErrNotExist) {
+			return nil, info, fmt.Errorf("%w: %s", ErrDatabaseNotExist, info.SQLitePath)
+		}
+	}
 
 	db, err := gorm.Open(info.Dialector, gormConfig)
 	if err != nil {
-		if info.BootstrapDialector != nil && isMissingDatabaseError(info.Dialect, err) {
+		if !create && isMissingDatabaseError(info.Dialect, err) {
+			return nil, info, fmt.Errorf("%w: %s", ErrDatabaseNotExist, info.DBName)
+		}
+		if create && info.BootstrapDialector != nil && isMissingDatabaseError(info.Dialect, err) {
 			if createErr := createDatabase(info, gormConfig); createErr != nil {
 				return nil, nil, fmt.Errorf("%w; 自动建库失败: %v", err, createErr)
 			}
@@ -178,15 +241,12 @@ func buildSQLiteConnectionInfo(rawDSN string) (*dbConnectionInfo, error) {
 		"_foreign_keys": "ON",
 	})
 
-	if err := ensureSQLiteDir(driverDSN); err != nil {
-		return nil, err
-	}
-
 	return &dbConnectionInfo{
 		Dialect:     DialectSQLite,
 		Dialector:   sqlite.Open(driverDSN),
 		DBName:      baseSQLiteName(driverDSN),
 		RedactedDSN: driverDSN,
+		SQLitePath:  sqliteFilePath(driverDSN),
 	}, nil
 }
 
@@ -198,21 +258,20 @@ func baseSQLiteName(driverDSN string) string {
 	return filepath.Base(base)
 }
 
-func ensureSQLiteDir(driverDSN string) error {
+// sqliteFilePath 返回 SQLite 数据库文件路径，内存库与 file: URI 返回空
+func sqliteFilePath(driverDSN string) string {
 	base := driverDSN
 	if idx := strings.Index(base, "?"); idx >= 0 {
 		base = base[:idx]
 	}
-
-	if base == "" || strings.HasPrefix(base, "file::memory:") || strings.HasPrefix(base, ":memory:") {
-		return nil
-	}
-
-	if strings.HasPrefix(base, "file:") {
-		return nil
+	if base == "" || strings.HasPrefix(base, "file:") || strings.HasPrefix(base, ":memory:") {
+		return ""
 	}
+	return base
+}
 
-	dir := filepath.Dir(base)
+func ensureSQLiteDir(path string) error {
+	dir := filepath.Dir(path)
 	if dir == "." || dir == "" {
 		return nil
 	}
@@ -522,6 +581,9 @@ func initMemorySqlite() error {
 		sqlDB.SetMaxOpenConns(1)
 		sqlDB.SetConnMaxLifetime(0) // 不过期
 	}
+	if err := RegisterWriteGuard(db); err != nil {
+		utils.Warn("注册数据库写入保护失败: %v", err)
+	}
 
 	DB = db
 	Dialect = DialectSQLite
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrWritesFrozen 数据库写入已被冻结
+var ErrWritesFrozen = errors.New("数据库正在迁移或等待重启切换，暂不允许写入")
+
+// writeGuardExemptTables 冻结期间仍允许写入的数据表
+// 任务记录属于正在运行的实例，迁移任务自身需要更新进度。
+var writeGuardExemptTables = map[string]bool{
+	"tasks": true,
+}
+
+var (
+	writeFreezeMu     sync.RWMutex
+	writeFreezeReason string
+)
+
+// FreezeWrites 冻结全局连接上的写入，reason 不能为空，会附在写入错误中
+// 用于跨库迁移等场景：复制期间或切换配置后、重启前写入当前库的数据都会丢失。
+func FreezeWrites(reason string) {
+	writeFreezeMu.Lock()
+	defer writeFreezeMu.Unlock()
+	writeFreezeReason = reason
+}
+
+// UnfreezeWrites 解除写入冻结
+func UnfreezeWrites() {
+	writeFreezeMu.Lock()
+	defer writeFreezeMu.Unlock()
+	writeFreezeReason = ""
+}
+
+// WritesFrozen 返回写入是否被冻结及原因
+func WritesFrozen() (bool, string) {
+	writeFreezeMu.RLock()
+	defer writeFreezeMu.RUnlock()
+	return writeFreezeReason != "", writeFreezeReason
+}
+
+// RegisterWriteGuard 在连接上注册写入冻结检查，覆盖 Create/Update/Delete 与原生 Exec
+func RegisterWriteGuard(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("sublink:write_guard", checkWriteFrozen); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("sublink:write_guard", checkWriteFrozen); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("sublink:write_guard", checkWriteFrozen); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("sublink:write_guard", checkWriteFrozen)
+}
+
+func checkWriteFrozen(db *gorm.DB) {
+	frozen, reason := WritesFrozen()
+	if !frozen || writeGuardExemptTables[db.Statement.Table] {
+		return
+	}
+	_ = db.AddError(fmt.Errorf("%w：%s", ErrWritesFrozen, reason))
+}
@@ -26,6 +26,12 @@ type UserAccessKey struct {
 	UserName    string     `json:"username" binding:"required"`
 	ExpiredAt   *time.Time `json:"expiredAt"`
 	Description string     `json:"description"`
+	// 权限范围，为空表示继承所属用户的全部权限
+	Scopes []string `json:"scopes"`
+	// 限定可访问的订阅 ID，为空表示不限制
+	SubscriptionIDs []int `json:"subscriptionIds"`
+	// 允许调用的来源 IP 或 CIDR，为空表示不限制
+	AllowedIPs []string `json:"allowedIps"`
 }
 
 // AirportRequest 机场添加/更新请求体结构
@@ -0,0 +1,214 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessKeyContextKey 通过 API Key 认证时，在上下文中保存对应的 AccessKey
+const AccessKeyContextKey = "accessKey"
+
+// AccessKeyScopeForbiddenI18nKey API Key 权限范围不足时返回的 i18n key
+const AccessKeyScopeForbiddenI18nKey = "backend.accessKeys.scopeForbidden"
+
+// apiKeyScopeResources 接口路径前缀对应的权限资源
+// 未匹配的接口归为 system；accesskey 不在可授予范围内，只有未限定权限的 Key 可以管理 Key。
+var apiKeyScopeResources = []struct {
+	prefix   string
+	resource string
+}{
+	{"/api/v1/nodes", "nodes"},
+	{"/api/v1/node-check", "nodes"},
+	{"/api/v1/tags", "nodes"},
+	{"/api/v1/group-sort", "nodes"},
+	{"/api/v1/country-rules", "nodes"},
+	{"/api/v1/geoip", "nodes"},
+	{"/api/v1/subcription", "subscriptions"},
+	{"/api/v1/shares", "subscriptions"},
+	{"/api/v1/template", "templates"},
+	{"/api/v1/rule-mirrors", "templates"},
+	{"/api/v1/airports", "airports"},
+	{"/api/v1/tasks", "tasks"},
+	{"/api/v1/hosts", "hosts"},
+	{"/api/v1/script", "scripts"},
+	{"/api/v1/accesskey", "accesskeys"},
+	{"/metrics", "metrics"},
+}
+
+// apiKeyRouteScopes 需要特殊权限范围的接口，key 为 "METHOD 路由模板"，空值表示无需权限范围
+var apiKeyRouteScopes = map[string]string{
+	"GET /api/v1/users/me":                     "",
+	"POST /api/v1/subcription/preview":         "subscriptions:read",
+	"POST /api/v1/airports/pull-all":           "airports:pull",
+	"POST /api/v1/airports/:id/pull":           "airports:pull",
+	"POST /api/v1/airports/:id/refresh-usage":  "airports:pull",
+	"POST /api/v1/node-check/run":              "tasks:run",
+	"POST /api/v1/node-check/profiles/:id/run": "tasks:run",
+	"POST /api/v1/tags/rules/trigger":          "tasks:run",
+	"POST /api/v1/tasks/:id/stop":              "tasks:run",
+	"POST /api/v1/gitops/plan":                 "system:read",
+}
+
+// RequiredAccessKeyScope 返回请求所需的权限范围
+// 默认 GET 请求需要 resource:read，其他请求需要 resource:write。
+func RequiredAccessKeyScope(method, route string) string {
+	if scope, ok := apiKeyRouteScopes[method+" "+route]; ok {
+		return scope
+	}
+	resource := "system"
+	for _, item := range apiKeyScopeResources {
+		if route == item.prefix || strings.HasPrefix(route, item.prefix+"/") {
+			resource = item.resource
+			break
+		}
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return resource + ":read"
+	}
+	return resource + ":write"
+}
+
+// subscriptionIDResolver 从请求中解析目标订阅 ID
+type subscriptionIDResolver func(c *gin.Context) (int, bool)
+
+// subscriptionIDResolvers 限定订阅的 Key 可调用的订阅/分享接口，及其目标订阅的解析方式
+// 路径中带 :id 的订阅接口统一按路径参数解析，未列出且无法确定目标订阅的接口一律拒绝。
+var subscriptionIDResolvers = map[string]subscriptionIDResolver{
+	"DELETE /api/v1/subcription/delete": queryInt("id"),
+	"POST /api/v1/subcription/copy":     queryInt("id"),
+	"POST /api/v1/subcription/update":   subscriptionByFormName("oldname"),
+	"GET /api/v1/shares/get":            queryInt("subId"),
+	"GET /api/v1/shares/logs":           shareSubscription(queryInt("shareId")),
+	"GET /api/v1/shares/access-usage":   shareSubscription(queryInt("shareId")),
+	"DELETE /api/v1/shares/delete":      shareSubscription(queryInt("id")),
+	"POST /api/v1/shares/refresh":       shareSubscription(queryInt("id")),
+	"POST /api/v1/shares/add":           jsonBodyInt("subscription_id"),
+	"POST /api/v1/shares/update":        shareSubscription(jsonBodyInt("id")),
+}
+
+// subscriptionQueryResolvers 指向订阅的查询参数
+// 不论接口属于哪个权限范围（如模板预览、配置校验），带有这些参数时都要校验订阅限制。
+var subscriptionQueryResolvers = map[string]subscriptionIDResolver{
+	"subscriptionId": queryInt("subscriptionId"),
+	"subId":          queryInt("subId"),
+	"shareId":        shareSubscription(queryInt("shareId")),
+}
+
+// subscriptionUnrestrictedRoutes 不涉及具体订阅的接口，订阅列表由接口自身按 Key 过滤
+var subscriptionUnrestrictedRoutes = map[string]bool{
+	"GET /api/v1/subcription/get":              true,
+	"GET /api/v1/subcription/protocol-meta":    true,
+	"GET /api/v1/subcription/node-fields-meta": true,
+}
+
+// authorizeAccessKey 校验 API Key 的权限范围与订阅限制
+func authorizeAccessKey(c *gin.Context, key *models.AccessKey) error {
+	route := c.FullPath()
+	method := c.Request.Method
+	required := RequiredAccessKeyScope(method, route)
+	if required != "" && !key.HasScope(required) {
+		return errors.New("API Key 缺少权限范围: " + required)
+	}
+	if !key.IsSubscriptionRestricted() {
+		return nil
+	}
+	for param, resolver := range subscriptionQueryResolvers {
+		if c.Query(param) == "" {
+			continue
+		}
+		if subID, found := resolver(c); !found || !key.AllowsSubscription(subID) {
+			return errors.New("该 API Key 无权访问此订阅")
+		}
+	}
+	if !strings.HasPrefix(required, "subscriptions:") || subscriptionUnrestrictedRoutes[method+" "+route] {
+		return nil
+	}
+
+	resolver, ok := subscriptionIDResolvers[method+" "+route]
+	if !ok && strings.HasPrefix(route, "/api/v1/subcription/:id") {
+		resolver, ok = paramInt("id"), true
+	}
+	if !ok {
+		return errors.New("该 API Key 仅限访问指定订阅，不支持此接口")
+	}
+	subID, found := resolver(c)
+	if !found || !key.AllowsSubscription(subID) {
+		return errors.New("该 API Key 无权访问此订阅")
+	}
+	return nil
+}
+
+// abortAccessKeyForbidden 返回 API Key 权限不足
+func abortAccessKeyForbidden(c *gin.Context, err error) {
+	utils.ResultI18n(c, http.StatusForbidden, http.StatusForbidden, err.Error(), nil, AccessKeyScopeForbiddenI18nKey, nil)
+	c.Abort()
+}
+
+func paramInt(name string) subscriptionIDResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Param(name))
+		return id, err == nil
+	}
+}
+
+func queryInt(name string) subscriptionIDResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, err := strconv.Atoi(c.Query(name))
+		return id, err == nil
+	}
+}
+
+// jsonBodyInt 读取 JSON 请求体中的整数字段，读取后恢复请求体供后续处理
+func jsonBodyInt(field string) subscriptionIDResolver {
+	return func(c *gin.Context) (int, bool) {
+		if c.Request.Body == nil {
+			return 0, false
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return 0, false
+		}
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return 0, false
+		}
+		value, ok := payload[field].(float64)
+		return int(value), ok
+	}
+}
+
+// subscriptionByFormName 按表单中的订阅名称解析订阅 ID
+func subscriptionByFormName(field string) subscriptionIDResolver {
+	return func(c *gin.Context) (int, bool) {
+		sub := models.Subcription{Name: c.PostForm(field)}
+		if sub.Name == "" || sub.Find() != nil {
+			return 0, false
+		}
+		return sub.ID, true
+	}
+}
+
+// shareSubscription 由分享 ID 解析其所属订阅
+func shareSubscription(shareID subscriptionIDResolver) subscriptionIDResolver {
+	return func(c *gin.Context) (int, bool) {
+		id, ok := shareID(c)
+		if !ok {
+			return 0, false
+		}
+		share := models.SubscriptionShare{ID: id}
+		if err := share.Find(); err != nil {
+			return 0, false
+		}
+		return share.SubscriptionID, true
+	}
+}
@@ -0,0 +1,295 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// auditMaxBodySize 审计时读取的请求体上限，超出时不记录请求参数
+	auditMaxBodySize = 64 << 10
+	// auditMaxResponseSize 用于判断操作结果而缓存的响应体上限
+	auditMaxResponseSize = 4 << 10
+)
+
+// auditResourceTypes 接口路径前缀对应的审计资源类型，按顺序匹配，子路径需放在父路径之前
+// 未匹配的接口使用路径第一段作为资源类型。
+var auditResourceTypes = []struct {
+	prefix       string
+	resourceType string
+}{
+	{"/api/v1/nodes", "node"},
+	{"/api/v1/subcription/:id/chain-rules", "chain_rule"},
+	{"/api/v1/subcription", "subscription"},
+	{"/api/v1/shares", "share"},
+	{"/api/v1/template", "template"},
+	{"/api/v1/airports", "airport"},
+	{"/api/v1/tags/rules", "tag_rule"},
+	{"/api/v1/tags/node", "node_tag"},
+	{"/api/v1/tags", "tag"},
+	{"/api/v1/hosts", "host"},
+	{"/api/v1/script", "script"},
+	{"/api/v1/country-rules", "country_rule"},
+	{"/api/v1/rule-mirrors", "rule_mirror"},
+	{"/api/v1/node-check/profiles", "node_check"},
+	{"/api/v1/node-check", "node_check"},
+	{"/api/v1/settings/webhooks", "webhook"},
+	{"/api/v1/settings", "setting"},
+	{"/api/v1/users/mfa", "mfa"},
+	{"/api/v1/users", "user"},
+	{"/api/v1/accesskey", "access_key"},
+}
+
+// auditIdentifierFields 请求参数中用于定位资源的字段，按优先级排列
+var auditIdentifierFields = []string{"id", "ID", "Id", "oldname", "oldName", "filename", "username", "name"}
+
+// auditMethodActions 路由中没有操作名时，按 HTTP 方法推断操作
+var auditMethodActions = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "update",
+	http.MethodDelete: "delete",
+}
+
+// auditResponseWriter 缓存响应体开头部分，用于读取接口返回的 code 与 msg
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(data []byte) {
+	if remaining := auditMaxResponseSize - w.body.Len(); remaining > 0 {
+		if len(data) > remaining {
+			data = data[:remaining]
+		}
+		w.body.Write(data)
+	}
+}
+
+// AuditLog 审计日志中间件，记录已登录用户发起的修改类请求
+// 挂载在需要鉴权的路由组上并位于 AuthToken 之后，未登录的请求不会读取请求体或查询变更前快照。
+func AuditLog(c *gin.Context) {
+	method := c.Request.Method
+	route := c.FullPath()
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions ||
+		route == "" || !strings.HasPrefix(route, "/api/") || c.GetString("username") == "" {
+		c.Next()
+		return
+	}
+
+	resourceType, action := auditRouteInfo(method, route)
+	payload := auditRequestPayload(c)
+	identifier, identifierField := auditResourceIdentifier(c, payload)
+	var before string
+	if models.HasAuditSnapshot(resourceType) {
+		before = models.AuditSnapshot(resourceType, identifier)
+	}
+
+	writer := &auditResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	username := c.GetString("username")
+	if username == "" {
+		return
+	}
+
+	entry := &models.AuditLog{
+		Actor:        username,
+		ActorType:    models.AuditActorUser,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   truncateRunes(identifier, 191),
+		Method:       method,
+		Path:         truncateRunes(c.Request.URL.Path, 255),
+		ClientIP:     c.ClientIP(),
+		Before:       before,
+	}
+	if value, ok := c.Get(AccessKeyContextKey); ok {
+		if key, ok := value.(models.AccessKey); ok {
+			entry.ActorType = models.AuditActorAccessKey
+			entry.AccessKeyID = key.ID
+		}
+	}
+	entry.Success, entry.Message = auditResult(writer)
+
+	if models.HasAuditSnapshot(resourceType) && method != http.MethodDelete {
+		// 重命名类接口使用旧名称定位，变更后需按新名称读取
+		afterIdentifier := identifier
+		if strings.HasPrefix(strings.ToLower(identifierField), "old") {
+			if name, ok := payload["name"].(string); ok && name != "" {
+				afterIdentifier = name
+			}
+		}
+		entry.After = models.AuditSnapshot(resourceType, afterIdentifier)
+	}
+	if entry.After == "" && len(payload) > 0 {
+		entry.After = models.AuditSummary(resourceType, payload)
+	}
+
+	if err := models.CreateAuditLog(entry); err != nil {
+		utils.Warn("写入审计日志失败: %v", err)
+	}
+}
+
+// auditRouteInfo 根据路由模板推断资源类型与操作名称
+func auditRouteInfo(method, route string) (string, string) {
+	resourceType, prefix := "", ""
+	for _, item := range auditResourceTypes {
+		if route == item.prefix || strings.HasPrefix(route, item.prefix+"/") {
+			resourceType, prefix = item.resourceType, item.prefix
+			break
+		}
+	}
+	if resourceType == "" {
+		rest := strings.TrimPrefix(strings.TrimPrefix(route, "/api/v1/"), "/api/")
+		first, _, _ := strings.Cut(rest, "/")
+		resourceType = strings.ReplaceAll(first, "-", "_")
+		prefix = route[:len(route)-len(rest)] + first
+	}
+
+	var parts []string
+	for _, segment := range strings.Split(strings.TrimPrefix(route, prefix), "/") {
+		if segment != "" && !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			parts = append(parts, segment)
+		}
+	}
+	if len(parts) == 0 {
+		return resourceType, auditMethodActions[method]
+	}
+	return resourceType, strings.Join(parts, ".")
+}
+
+// auditRequestPayload 读取查询参数与请求体（JSON 或表单），读取后恢复请求体供后续处理
+func auditRequestPayload(c *gin.Context) map[string]any {
+	payload := make(map[string]any)
+	for key, values := range c.Request.URL.Query() {
+		payload[key] = auditFormValue(values)
+	}
+
+	contentType := c.ContentType()
+	if c.Request.Body == nil || c.Request.ContentLength > auditMaxBodySize ||
+		(contentType != gin.MIMEJSON && contentType != gin.MIMEPOSTForm) {
+		return payload
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, auditMaxBodySize+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil || len(body) > auditMaxBodySize {
+		return payload
+	}
+
+	if contentType == gin.MIMEJSON {
+		var data map[string]any
+		if err := json.Unmarshal(body, &data); err == nil {
+			for key, value := range data {
+				payload[key] = value
+			}
+		}
+		return payload
+	}
+	if values, err := url.ParseQuery(string(body)); err == nil {
+		for key, value := range values {
+			payload[key] = auditFormValue(value)
+		}
+	}
+	return payload
+}
+
+func auditFormValue(values []string) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// auditResourceIdentifier 解析目标资源标识：优先使用最后一个路径参数，其次是请求参数中的 ID 或名称
+func auditResourceIdentifier(c *gin.Context, payload map[string]any) (string, string) {
+	if len(c.Params) > 0 {
+		param := c.Params[len(c.Params)-1]
+		return param.Value, param.Key
+	}
+	for _, field := range auditIdentifierFields {
+		if value := auditIdentifierValue(payload[field]); value != "" {
+			return value, field
+		}
+	}
+	if ids, ok := payload["ids"].([]any); ok && len(ids) > 0 {
+		values := make([]string, 0, len(ids))
+		for _, id := range ids {
+			values = append(values, auditIdentifierValue(id))
+		}
+		return strings.Join(values, ","), "ids"
+	}
+	return "", ""
+}
+
+func auditIdentifierValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// auditResult 根据 HTTP 状态与响应中的业务 code 判断操作是否成功
+// 响应体只缓存了开头部分，这里按顺序读取顶层字段，code 与 msg 位于响应最前面。
+func auditResult(writer *auditResponseWriter) (bool, string) {
+	success := writer.Status() < http.StatusBadRequest
+	decoder := json.NewDecoder(bytes.NewReader(writer.body.Bytes()))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return success, ""
+	}
+	var code *int
+	var msg string
+	for code == nil || msg == "" {
+		key, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		switch key {
+		case "code":
+			_ = json.Unmarshal(value, &code)
+		case "msg":
+			_ = json.Unmarshal(value, &msg)
+		}
+	}
+	if code == nil {
+		return success, ""
+	}
+	return success && *code == utils.SUCCESS, truncateRunes(msg, 512)
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
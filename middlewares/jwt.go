@@ -22,6 +22,9 @@ func getJwtSecret() []byte {
 	return []byte(secret)
 }
 
+// SessionContextKey 上下文中保存当前登录会话 ID（JWT 的 jti）的键
+const SessionContextKey = "sessionId"
+
 // JwtClaims jwt声明
 type JwtClaims struct {
 	Username       string `json:"username"`
@@ -35,13 +38,21 @@ func AuthToken(c *gin.Context) {
 	accessKey := c.GetHeader("X-API-Key")
 
 	if accessKey != "" {
-		username, bool, err := validApiKey(accessKey)
-		if err != nil || !bool {
+		key, err := validApiKey(accessKey, c.ClientIP())
+		if err != nil {
 			utils.Forbidden(c, err.Error())
 			c.Abort()
 			return
 		}
-		c.Set("username", username)
+		if err := authorizeAccessKey(c, key); err != nil {
+			abortAccessKeyForbidden(c, err)
+			return
+		}
+		if err := key.RecordUsage(c.ClientIP()); err != nil {
+			utils.Warn("记录 API Key 使用信息失败: %v", err)
+		}
+		c.Set("username", key.Username)
+		c.Set(AccessKeyContextKey, *key)
 		c.Next()
 		return
 	}
@@ -86,7 +97,19 @@ func AuthToken(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	// 验证登录会话，会话被注销后 token 立即失效
+	if !models.ValidateUserSession(mc.ID, mc.Username, c.ClientIP()) {
+		errMsg := "登录会话已失效，请重新登录"
+		if strings.HasSuffix(c.Request.URL.Path, "/api/sse") {
+			sendSSEAuthError(c, errMsg)
+			return
+		}
+		utils.Forbidden(c, errMsg)
+		c.Abort()
+		return
+	}
 	c.Set("username", mc.Username)
+	c.Set(SessionContextKey, mc.ID)
 	c.Next()
 }
 
@@ -126,12 +149,13 @@ func sendSSEAuthError(c *gin.Context, message string) {
 	c.Abort()
 }
 
-func validApiKey(apiKey string) (string, bool, error) {
+// validApiKey 校验 API Key 并检查来源 IP 白名单
+func validApiKey(apiKey, clientIP string) (*models.AccessKey, error) {
 
 	// 快速格式验证
 	parts := strings.Split(apiKey, "_")
 	if len(parts) != 3 {
-		return "", false, fmt.Errorf("API Key格式错误")
+		return nil, fmt.Errorf("API Key格式错误")
 	}
 
 	encryptionKey := config.GetAPIEncryptionKey()
@@ -143,22 +167,24 @@ func validApiKey(apiKey string) (string, bool, error) {
 	// 解密用户ID
 	userID, err := utils.DecryptUserIDCompact(parts[1], []byte(encryptionKey))
 	if err != nil {
-		return "", false, fmt.Errorf("解密用户ID失败: %w", err)
+		return nil, fmt.Errorf("解密用户ID失败: %w", err)
 	}
 
 	// 数据库查询
 	keys, err := models.FindValidAccessKeys(userID)
 	if err != nil {
-		return "", false, fmt.Errorf("查询Access Key失败: %w", err)
+		return nil, fmt.Errorf("查询Access Key失败: %w", err)
 	}
 
 	// bcrypt验证
 	for _, key := range keys {
 		if key.VerifyKey(apiKey) {
-
-			return key.Username, true, nil
+			if !key.AllowsIP(clientIP) {
+				return nil, fmt.Errorf("API Key 不允许从 %s 访问", clientIP)
+			}
+			return &key, nil
 		}
 	}
 
-	return "", false, fmt.Errorf("无效的API Key")
+	return nil, fmt.Errorf("无效的API Key")
 }
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"net/http"
+	"sublink/models"
+	"sublink/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleForbiddenI18nKey 角色权限不足时返回的 i18n key，前端据此区分“无权限”与“登录失效”
+const RoleForbiddenI18nKey = "backend.auth.role.forbidden"
+
+// RequireRole 要求当前用户至少具备指定角色，需放在 AuthToken 之后
+// 角色每次从用户缓存读取，管理员调整角色后立即生效，无需重新登录。
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetString("username")
+		current, ok := models.GetUserRole(username)
+		if !ok {
+			utils.ForbiddenI18n(c, "当前用户不存在", "backend.auth.currentUser.invalid", nil)
+			c.Abort()
+			return
+		}
+		if !models.RoleAtLeast(current, role) {
+			utils.ResultI18n(c, http.StatusForbidden, http.StatusForbidden, "当前角色无权执行此操作", nil, RoleForbiddenI18nKey, map[string]any{"role": role})
+			c.Abort()
+			return
+		}
+		c.Set("role", current)
+		c.Next()
+	}
+}
+
+var (
+	// RequireOperator 运维及以上角色可访问：编辑数据、执行任务
+	RequireOperator = RequireRole(models.RoleOperator)
+	// RequireAdmin 仅管理员可访问：删除数据、系统设置、用户管理
+	RequireAdmin = RequireRole(models.RoleAdmin)
+)
@@ -21,6 +21,14 @@ type AccessKey struct {
 	CreatedAt     time.Time  `gorm:"autoCreateTime"`
 	ExpiredAt     *time.Time `gorm:"index"`
 	Description   string     `gorm:"type:varchar(255)"`
+	// 权限范围，逗号分隔；为空表示拥有所属用户的全部权限（兼容旧 Key）
+	Scopes string `gorm:"type:text"`
+	// 限定可访问的订阅 ID，逗号分隔；为空表示不限制
+	SubscriptionIDs string `gorm:"type:text"`
+	// 允许调用的来源 IP 或 CIDR，逗号分隔；为空表示不限制
+	AllowedIPs string     `gorm:"type:text"`
+	LastUsedAt *time.Time `gorm:"index"`
+	LastUsedIP string     `gorm:"size:64"`
 }
 
 // accessKeyCache 使用新的泛型缓存
@@ -113,6 +121,22 @@ func (accessKey *AccessKey) Delete() error {
 	return nil
 }
 
+// GetAccessKeyByID 根据 ID 获取 AccessKey
+func GetAccessKeyByID(id int) (AccessKey, bool) {
+	return accessKeyCache.Get(id)
+}
+
+// DeleteAccessKeysByUserID 删除用户的全部 AccessKey (Write-Through)
+func DeleteAccessKeysByUserID(userID int) error {
+	if err := database.DB.Unscoped().Where("user_id = ?", userID).Delete(&AccessKey{}).Error; err != nil {
+		return err
+	}
+	for _, key := range accessKeyCache.GetByIndex("userID", strconv.Itoa(userID)) {
+		accessKeyCache.Delete(key.ID)
+	}
+	return nil
+}
+
 // GenerateAPIKey 生成一个新的 API Key,单用户系统直接全随机不编码用户信息
 func (accessKey *AccessKey) GenerateAPIKey() (string, error) {
 	// 优先使用 config 包获取加密密钥
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sublink/database"
+	"time"
+)
+
+// ScopeAll 全部权限，等同于未设置权限范围
+const ScopeAll = "*"
+
+// AccessKeyScopes 可授予 AccessKey 的权限范围
+// 资源:read 只读，资源:write 可写且包含只读；tasks:run 与 airports:pull 为触发类操作，需要单独授予。
+var AccessKeyScopes = []string{
+	"nodes:read", "nodes:write",
+	"subscriptions:read", "subscriptions:write",
+	"templates:read", "templates:write",
+	"airports:read", "airports:write", "airports:pull",
+	"tasks:read", "tasks:write", "tasks:run",
+	"hosts:read", "hosts:write",
+	"scripts:read", "scripts:write",
+	"system:read", "system:write",
+	"metrics:read",
+}
+
+// accessKeyUsageInterval 同一来源的使用记录最短写库间隔，避免每次请求都写数据库
+const accessKeyUsageInterval = time.Minute
+
+// splitAccessKeyList 解析逗号分隔的列表字段
+func splitAccessKeyList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// NormalizeAccessKeyScopes 校验并去重权限范围
+// 返回空字符串表示不限制权限。
+func NormalizeAccessKeyScopes(scopes []string) (string, error) {
+	valid := make(map[string]bool, len(AccessKeyScopes))
+	resources := make(map[string]bool)
+	for _, scope := range AccessKeyScopes {
+		valid[scope] = true
+		resource, _, _ := strings.Cut(scope, ":")
+		resources[resource] = true
+	}
+
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if scope == ScopeAll {
+			return "", nil
+		}
+		resource, action, _ := strings.Cut(scope, ":")
+		if !valid[scope] && !(action == "*" && resources[resource]) {
+			return "", fmt.Errorf("无效的权限范围: %s", scope)
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	sort.Strings(result)
+	return strings.Join(result, ","), nil
+}
+
+// NormalizeAccessKeySubscriptionIDs 校验订阅 ID 是否存在
+func NormalizeAccessKeySubscriptionIDs(ids []int) (string, error) {
+	seen := make(map[int]bool)
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		if _, err := GetSubcriptionByID(id); err != nil {
+			return "", fmt.Errorf("订阅不存在: %d", id)
+		}
+		seen[id] = true
+		result = append(result, strconv.Itoa(id))
+	}
+	return strings.Join(result, ","), nil
+}
+
+// NormalizeAccessKeyAllowedIPs 校验 IP 白名单，支持单个 IP 与 CIDR
+func NormalizeAccessKeyAllowedIPs(entries []string) (string, error) {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return "", fmt.Errorf("无效的 CIDR: %s", entry)
+			}
+		} else if net.ParseIP(entry) == nil {
+			return "", fmt.Errorf("无效的 IP: %s", entry)
+		}
+		result = append(result, entry)
+	}
+	return strings.Join(result, ","), nil
+}
+
+// ScopeList 返回权限范围列表
+func (accessKey *AccessKey) ScopeList() []string {
+	return splitAccessKeyList(accessKey.Scopes)
+}
+
+// HasScope 判断 Key 是否拥有指定权限范围
+// 未设置权限范围的旧 Key 拥有全部权限；resource:* 匹配该资源的全部操作，resource:write 包含 resource:read。
+func (accessKey *AccessKey) HasScope(required string) bool {
+	scopes := accessKey.ScopeList()
+	if len(scopes) == 0 {
+		return true
+	}
+	resource, action, _ := strings.Cut(required, ":")
+	for _, scope := range scopes {
+		if scope == ScopeAll || scope == required || scope == resource+":*" {
+			return true
+		}
+		if action == "read" && scope == resource+":write" {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscriptionIDList 返回限定的订阅 ID 列表
+func (accessKey *AccessKey) SubscriptionIDList() []int {
+	ids := make([]int, 0)
+	for _, item := range splitAccessKeyList(accessKey.SubscriptionIDs) {
+		if id, err := strconv.Atoi(item); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsSubscriptionRestricted 是否限定了可访问的订阅
+func (accessKey *AccessKey) IsSubscriptionRestricted() bool {
+	return len(accessKey.SubscriptionIDList()) > 0
+}
+
+// AllowsSubscription 判断 Key 是否可以访问指定订阅
+func (accessKey *AccessKey) AllowsSubscription(id int) bool {
+	ids := accessKey.SubscriptionIDList()
+	if len(ids) == 0 {
+		return true
+	}
+	for _, allowed := range ids {
+		if allowed == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP 判断来源 IP 是否在白名单内
+func (accessKey *AccessKey) AllowsIP(ip string) bool {
+	entries := splitAccessKeyList(accessKey.AllowedIPs)
+	if len(entries) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordUsage 记录最近一次使用时间与来源 IP (Write-Through)
+// 同一 IP 在间隔内的重复调用直接跳过，避免频繁写库。
+func (accessKey *AccessKey) RecordUsage(ip string) error {
+	now := time.Now()
+	cached, ok := accessKeyCache.Get(accessKey.ID)
+	if ok && cached.LastUsedIP == ip && cached.LastUsedAt != nil && now.Sub(*cached.LastUsedAt) < accessKeyUsageInterval {
+		return nil
+	}
+	if err := database.DB.Model(&AccessKey{}).Where("id = ?", accessKey.ID).Updates(map[string]any{
+		"last_used_at": now,
+		"last_used_ip": ip,
+	}).Error; err != nil {
+		return err
+	}
+	accessKey.LastUsedAt = &now
+	accessKey.LastUsedIP = ip
+	if ok {
+		cached.LastUsedAt = &now
+		cached.LastUsedIP = ip
+		accessKeyCache.Set(cached.ID, cached)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sublink/cache"
 	"sublink/database"
+	"sublink/services/metrics"
 	"sublink/utils"
 	"time"
 
@@ -304,6 +305,7 @@ func (a *Airport) Del() error {
 		return err
 	}
 	airportCache.Delete(a.ID)
+	metrics.DeleteAirport(a.ID)
 	// 级联清理该机场在分组排序表中的记录
 	CleanupAirportSortRecords(a.ID)
 	return nil
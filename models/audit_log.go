@@ -0,0 +1,268 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sublink/database"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// 审计日志操作者类型
+const (
+	AuditActorUser      = "user"       // 通过登录令牌操作
+	AuditActorAccessKey = "access_key" // 通过 API Key 操作
+)
+
+const (
+	// auditRetentionSettingKey 审计日志保留天数的系统设置 key
+	auditRetentionSettingKey = "audit_log_retention_days"
+	// DefaultAuditRetentionDays 默认保留天数
+	DefaultAuditRetentionDays = 90
+	// auditSummaryMaxLength 变更摘要最大长度，超出部分截断
+	auditSummaryMaxLength = 4000
+	// auditRedacted 敏感字段的替换值
+	auditRedacted = "***"
+)
+
+// AuditLog 审计日志，记录每一次修改类操作
+type AuditLog struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	Actor        string    `gorm:"size:100;index" json:"actor"`       // 操作用户名
+	ActorType    string    `gorm:"size:20" json:"actorType"`          // user / access_key
+	AccessKeyID  int       `json:"accessKeyId"`                       // 通过 API Key 操作时的 Key ID
+	Action       string    `gorm:"size:100;index" json:"action"`      // 操作，如 delete、batch-update、webhooks.test
+	ResourceType string    `gorm:"size:64;index" json:"resourceType"` // 资源类型，如 node、template、share
+	ResourceID   string    `gorm:"size:191;index" json:"resourceId"`  // 资源标识，ID 或名称
+	Method       string    `gorm:"size:10" json:"method"`             // HTTP 方法
+	Path         string    `gorm:"size:255" json:"path"`              // 请求路径（不含查询参数）
+	ClientIP     string    `gorm:"size:64" json:"clientIp"`           // 来源 IP
+	Success      bool      `gorm:"index" json:"success"`              // 操作是否成功
+	Message      string    `gorm:"size:512" json:"message"`           // 接口返回的提示信息
+	Before       string    `gorm:"type:text" json:"before"`           // 变更前摘要（JSON）
+	After        string    `gorm:"type:text" json:"after"`            // 变更后摘要或请求参数（JSON）
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"createdAt"`
+}
+
+// AuditLogFilter 审计日志查询条件
+type AuditLogFilter struct {
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	Success      *bool
+	Start        *time.Time
+	End          *time.Time
+}
+
+// auditResource 可生成变更前后快照的资源
+type auditResource struct {
+	model           any
+	noIDColumn      bool     // 没有自增 ID 列，只能按名称定位
+	nameColumn      string   // 按名称定位资源时使用的列，为空表示只支持 ID
+	sensitiveFields []string // 字段名不含敏感关键字、但内容带凭据的字段，按规范化后的字段名匹配
+}
+
+// auditResources 资源类型到数据模型的映射
+var auditResources = map[string]auditResource{
+	"node":         {model: &Node{}, nameColumn: "name", sensitiveFields: []string{"link"}}, // 节点链接中带有 uuid 或密码
+	"subscription": {model: &Subcription{}, nameColumn: "name"},
+	"template":     {model: &Template{}, nameColumn: "name"},
+	"share":        {model: &SubscriptionShare{}},
+	"airport":      {model: &Airport{}, nameColumn: "name", sensitiveFields: []string{"url", "proxylink"}}, // 订阅地址与代理链接中带有机场令牌
+	"tag":          {model: &Tag{}, noIDColumn: true, nameColumn: "name"},
+	"host":         {model: &Host{}},
+	"script":       {model: &Script{}, nameColumn: "name"},
+	"country_rule": {model: &CountryRule{}},
+	"rule_mirror":  {model: &RuleMirror{}},
+	"node_check":   {model: &NodeCheckProfile{}},
+	"webhook":      {model: &Webhook{}, sensitiveFields: []string{"url"}}, // 回调地址中常带有机器人或 Slack 令牌
+	"user":         {model: &User{}, nameColumn: "username"},
+	"access_key":   {model: &AccessKey{}},
+}
+
+// auditSensitiveKeywords 字段名包含这些关键字时内容会被隐藏
+var auditSensitiveKeywords = []string{"password", "secret", "token", "hash", "totp", "credential", "recovery", "privatekey", "apikey", "accesskey", "passphrase", "dsn"}
+
+// CreateAuditLog 写入一条审计日志
+func CreateAuditLog(log *AuditLog) error {
+	return database.DB.Create(log).Error
+}
+
+// ListAuditLogs 分页查询审计日志，按时间倒序
+func ListAuditLogs(filter AuditLogFilter, page, pageSize int) ([]AuditLog, int64, error) {
+	var logs []AuditLog
+	var total int64
+
+	query := database.DB.Model(&AuditLog{})
+	if filter.Actor != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "actor"}, Value: filter.Actor})
+	}
+	if filter.Action != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "action"}, Value: filter.Action})
+	}
+	if filter.ResourceType != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "resource_type"}, Value: filter.ResourceType})
+	}
+	if filter.ResourceID != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "resource_id"}, Value: filter.ResourceID})
+	}
+	if filter.Success != nil {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: "success"}, Value: *filter.Success})
+	}
+	if filter.Start != nil {
+		query = query.Where("created_at >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("created_at <= ?", *filter.End)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// ListAuditFilterOptions 返回已出现过的操作者、资源类型与操作，用于前端筛选
+func ListAuditFilterOptions() (map[string][]string, error) {
+	options := make(map[string][]string, 3)
+	for key, column := range map[string]string{"actors": "actor", "resourceTypes": "resource_type", "actions": "action"} {
+		var values []string
+		if err := database.DB.Model(&AuditLog{}).Distinct(column).Where(column+" <> ''").Pluck(column, &values).Error; err != nil {
+			return nil, err
+		}
+		sort.Strings(values)
+		options[key] = values
+	}
+	return options, nil
+}
+
+// CleanupAuditLogs 删除指定时间之前的审计日志
+func CleanupAuditLogs(before time.Time) (int64, error) {
+	result := database.DB.Where("created_at < ?", before).Delete(&AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+// GetAuditRetentionDays 获取审计日志保留天数，0 表示永久保留
+func GetAuditRetentionDays() int {
+	value, err := GetSetting(auditRetentionSettingKey)
+	if err != nil || value == "" {
+		return DefaultAuditRetentionDays
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return DefaultAuditRetentionDays
+	}
+	return days
+}
+
+// SetAuditRetentionDays 保存审计日志保留天数
+func SetAuditRetentionDays(days int) error {
+	return SetSetting(auditRetentionSettingKey, strconv.Itoa(days))
+}
+
+// CleanupExpiredAuditLogs 按保留天数清理过期审计日志
+func CleanupExpiredAuditLogs() (int64, error) {
+	days := GetAuditRetentionDays()
+	if days == 0 {
+		return 0, nil
+	}
+	return CleanupAuditLogs(time.Now().AddDate(0, 0, -days))
+}
+
+// HasAuditSnapshot 资源类型是否支持生成快照
+func HasAuditSnapshot(resourceType string) bool {
+	_, ok := auditResources[resourceType]
+	return ok
+}
+
+// AuditSnapshot 读取资源当前内容并生成脱敏后的摘要，资源不存在时返回空字符串
+// 纯数字标识按 ID 查找，其余按名称列查找。
+func AuditSnapshot(resourceType, identifier string) string {
+	resource, ok := auditResources[resourceType]
+	if !ok || identifier == "" || database.DB == nil {
+		return ""
+	}
+	query := database.DB.Model(resource.model)
+	if id, err := strconv.Atoi(identifier); err == nil && !resource.noIDColumn {
+		query = query.Where("id = ?", id)
+	} else if resource.nameColumn != "" {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: resource.nameColumn}, Value: identifier})
+	} else {
+		return ""
+	}
+	row := map[string]any{}
+	if err := query.Limit(1).Take(&row).Error; err != nil {
+		return ""
+	}
+	return AuditSummary(resourceType, row)
+}
+
+// AuditSummary 将数据转换为脱敏、截断后的 JSON 摘要，resourceType 决定额外隐藏的资源专属字段
+func AuditSummary(resourceType string, value any) string {
+	if value == nil {
+		return ""
+	}
+	raw, err := json.Marshal(redactAuditValue("", value, auditResources[resourceType].sensitiveFields))
+	if err != nil {
+		return ""
+	}
+	if len(raw) > auditSummaryMaxLength {
+		return strings.ToValidUTF8(string(raw[:auditSummaryMaxLength]), "") + "...(truncated)"
+	}
+	return string(raw)
+}
+
+// redactAuditValue 递归隐藏敏感字段，fields 为资源专属的敏感字段
+func redactAuditValue(key string, value any, fields []string) any {
+	if isAuditSensitiveKey(key) || slices.Contains(fields, normalizeAuditKey(key)) {
+		if value == nil || value == "" {
+			return value
+		}
+		return auditRedacted
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = redactAuditValue(k, item, fields)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = redactAuditValue("", item, fields)
+		}
+		return result
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+func isAuditSensitiveKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	normalized := normalizeAuditKey(key)
+	for _, keyword := range auditSensitiveKeywords {
+		if strings.Contains(normalized, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAuditKey 统一字段名大小写与分隔符，使 proxyLink 与 proxy_link 等写法一致
+func normalizeAuditKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
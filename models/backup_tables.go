@@ -0,0 +1,44 @@
+package models
+
+import "sublink/database"
+
+// BackupModels 返回需要完整备份与恢复的数据表模型
+// 顺序即写入顺序：被引用的表在前（如 subcriptions 在 sub_logs 之前），清空时按相反顺序执行
+func BackupModels() []any {
+	return []any{
+		&User{},
+		&MFALoginChallenge{},
+		&WebAuthnCredential{},
+		&UserSession{},
+		&SystemSetting{},
+		&AccessKey{},
+		&Webhook{},
+		&Script{},
+		&Template{},
+		&Tag{},
+		&TagRule{},
+		&Node{},
+		&Airport{},
+		&Subcription{},
+		&SubcriptionNode{},
+		&SubcriptionGroup{},
+		&SubcriptionAirport{},
+		&SubcriptionScript{},
+		&SubLogs{},
+		&SubscriptionShare{},
+		&SubscriptionChainRule{},
+		&GroupAirportSort{},
+		&NodeCheckProfile{},
+		&CountryRule{},
+		&RuleMirror{},
+		&Host{},
+		&IPInfo{},
+		&Task{},
+		&AuditLog{},
+		&ShareAccessWindow{},
+		&ShareDevice{},
+		&ShareAccessEvent{},
+		&ShareAccessDaily{},
+		&database.Migration{},
+	}
+}
@@ -181,6 +181,7 @@ func RunMigrations() error {
 	}{
 		{name: "User", model: &User{}},
 		{name: "MFALoginChallenge", model: &MFALoginChallenge{}},
+		{name: "WebAuthnCredential", model: &WebAuthnCredential{}},
 		{name: "Subcription", model: &Subcription{}},
 		{name: "Node", model: &Node{}},
 		{name: "SubLogs", model: &SubLogs{}},
@@ -203,6 +204,13 @@ func RunMigrations() error {
 		{name: "GroupAirportSort", model: &GroupAirportSort{}},
 		{name: "NodeCheckProfile", model: &NodeCheckProfile{}},
 		{name: "CountryRule", model: &CountryRule{}},
+		{name: "RuleMirror", model: &RuleMirror{}},
+		{name: "AuditLog", model: &AuditLog{}},
+		{name: "UserSession", model: &UserSession{}},
+		{name: "ShareAccessWindow", model: &ShareAccessWindow{}},
+		{name: "ShareDevice", model: &ShareDevice{}},
+		{name: "ShareAccessEvent", model: &ShareAccessEvent{}},
+		{name: "ShareAccessDaily", model: &ShareAccessDaily{}},
 	}
 
 	for _, table := range baseTables {
@@ -213,6 +221,60 @@ func RunMigrations() error {
 		utils.Info("数据表%s创建成功", table.name)
 	}
 
+	runVersionedMigrations(db)
+
+	// 初始化用户数据
+	err := db.First(&User{}).Error
+	if err == gorm.ErrRecordNotFound {
+		adminPassword := "123456"
+		if envPass := os.Getenv("SUBLINK_ADMIN_PASSWORD"); envPass != "" {
+			adminPassword = envPass
+		}
+		admin := &User{
+			Username: "admin",
+			Password: adminPassword,
+			Role:     "admin",
+			Nickname: "管理员",
+		}
+		err = admin.Create()
+		if err != nil {
+			utils.Error("初始化添加用户数据失败")
+		}
+	} else {
+		// Check if we need to update admin password from env
+		if envPass := os.Getenv("SUBLINK_ADMIN_PASSWORD_REST"); envPass != "" {
+			var admin User
+			if err := db.First(&admin).Error; err == nil {
+				// Update admin password
+				updateUser := &User{Password: envPass}
+				if err := admin.Set(updateUser); err != nil {
+					utils.Error("Failed to update admin password from env: %v", err)
+				} else {
+					utils.Info("Admin password updated from environment variable")
+				}
+			}
+		}
+	}
+
+	// 设置初始化标志为 true
+	database.IsInitialized = true
+	utils.Info("数据库初始化成功")
+	return nil
+}
+
+// PendingMigrations 返回尚未执行的迁移 ID，不修改数据库
+// 供 doctor 等诊断命令在不升级数据库的前提下报告迁移状态。
+func PendingMigrations() ([]string, error) {
+	db := database.DB
+	if db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return database.PlanMigrations(func() { runVersionedMigrations(db) }), nil
+}
+
+// runVersionedMigrations 按顺序执行带版本记录的迁移，已执行的迁移会被跳过
+// 只能包含 database.RunAutoMigrate/RunCustomMigration 调用，PendingMigrations 会以规划模式重放此函数。
+func runVersionedMigrations(db *gorm.DB) {
 	if err := database.RunCustomMigration("0027_add_user_pending_mfa_columns", func() error {
 		if !db.Migrator().HasColumn(&User{}, "totp_pending_recovery_codes") {
 			if err := db.Migrator().AddColumn(&User{}, "TOTPPendingRecoveryCodes"); err != nil {
@@ -1189,44 +1251,6 @@ DIRECT = direct
 	}); err != nil {
 		utils.Error("执行迁移 0023_normalize_subscription_share_timestamps 失败: %v", err)
 	}
-
-	// 初始化用户数据
-	err := db.First(&User{}).Error
-	if err == gorm.ErrRecordNotFound {
-		adminPassword := "123456"
-		if envPass := os.Getenv("SUBLINK_ADMIN_PASSWORD"); envPass != "" {
-			adminPassword = envPass
-		}
-		admin := &User{
-			Username: "admin",
-			Password: adminPassword,
-			Role:     "admin",
-			Nickname: "管理员",
-		}
-		err = admin.Create()
-		if err != nil {
-			utils.Error("初始化添加用户数据失败")
-		}
-	} else {
-		// Check if we need to update admin password from env
-		if envPass := os.Getenv("SUBLINK_ADMIN_PASSWORD_REST"); envPass != "" {
-			var admin User
-			if err := db.First(&admin).Error; err == nil {
-				// Update admin password
-				updateUser := &User{Password: envPass}
-				if err := admin.Set(updateUser); err != nil {
-					utils.Error("Failed to update admin password from env: %v", err)
-				} else {
-					utils.Info("Admin password updated from environment variable")
-				}
-			}
-		}
-	}
-
-	// 设置初始化标志为 true
-	database.IsInitialized = true
-	utils.Info("数据库初始化成功")
-	return nil
 }
 
 // seedDefaultCountryRules 添加默认国家规则
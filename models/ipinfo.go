@@ -89,6 +89,15 @@ func ClearAllIPInfo() error {
 	return nil
 }
 
+// GetCachedIPInfo 仅从内存缓存获取未过期的IP信息，不发起网络请求
+func GetCachedIPInfo(ip string) (IPInfo, bool) {
+	info, ok := ipInfoCache.Get(ip)
+	if !ok || time.Since(info.UpdatedAt) >= ipInfoCacheTTL {
+		return IPInfo{}, false
+	}
+	return info, true
+}
+
 // GetIPInfo 获取IP信息（多级缓存）
 func GetIPInfo(ip string) (*IPInfo, error) {
 	if ip == "" {
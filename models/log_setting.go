@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"sublink/utils"
+)
+
+// logSubsystemLevelsSettingKey 子系统日志等级覆盖的系统设置 key，值为 JSON 对象
+const logSubsystemLevelsSettingKey = "log_subsystem_levels"
+
+// LoadLogSubsystemLevels 从系统设置加载子系统日志等级覆盖并立即生效
+func LoadLogSubsystemLevels() error {
+	// 未保存过时沿用全局等级
+	value, err := GetSetting(logSubsystemLevelsSettingKey)
+	if err != nil || value == "" {
+		return nil
+	}
+	var levels map[string]string
+	if err := json.Unmarshal([]byte(value), &levels); err != nil {
+		return err
+	}
+	utils.ResetSubsystemLevels()
+	for subsystem, level := range levels {
+		if err := utils.SetSubsystemLevel(subsystem, level); err != nil {
+			utils.Warn("忽略无效的子系统日志等级 %s=%s: %v", subsystem, level, err)
+		}
+	}
+	return nil
+}
+
+// SetLogSubsystemLevel 设置子系统日志等级并保存，level 为空时恢复使用全局等级
+func SetLogSubsystemLevel(subsystem, level string) error {
+	if err := utils.SetSubsystemLevel(subsystem, level); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(utils.SubsystemLevels())
+	if err != nil {
+		return err
+	}
+	return SetSetting(logSubsystemLevelsSettingKey, string(raw))
+}
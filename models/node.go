@@ -14,6 +14,7 @@ import (
 	"sublink/constants"
 	"sublink/database"
 	"sublink/node/protocol"
+	"sublink/services/metrics"
 	"sublink/utils"
 	"time"
 
@@ -1559,6 +1560,7 @@ func (node *Node) Del() error {
 	}
 	// 再更新缓存
 	nodeCache.Delete(node.ID)
+	metrics.DeleteNodeLatency(node.ID)
 	return nil
 }
 
@@ -1613,6 +1615,7 @@ func DeleteAutoSubscriptionNodes(sourceId int) error {
 	for _, n := range nodesToDelete {
 		nodeCache.Delete(n.ID)
 	}
+	metrics.DeleteNodeLatency(nodeIDs...)
 	return nil
 }
 
@@ -1646,6 +1649,7 @@ func BatchDel(ids []int) error {
 	for _, id := range ids {
 		nodeCache.Delete(id)
 	}
+	metrics.DeleteNodeLatency(ids...)
 	return nil
 }
 
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sublink/cache"
+	"sublink/database"
+	"sublink/utils"
+	"time"
+)
+
+// DefaultRuleMirrorInterval 规则镜像默认刷新间隔（秒）
+const DefaultRuleMirrorInterval = 86400
+
+// RuleMirror 规则集镜像
+// 记录模板中引用的远程规则文件，由系统定期下载到本地并在订阅输出中替换为本地地址
+type RuleMirror struct {
+	ID            int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	URL           string     `gorm:"type:text" json:"url"`                                  // 原始规则地址
+	FileName      string     `gorm:"size:64;uniqueIndex" json:"fileName"`                   // 本地文件名（由地址哈希生成）
+	Templates     string     `gorm:"type:text" json:"templates"`                            // 引用该规则的模板，逗号分隔
+	UseProxy      bool       `gorm:"default:false" json:"useProxy"`                         // 是否使用代理下载（继承引用模板的设置）
+	ProxyLink     string     `gorm:"type:text" json:"proxyLink"`                            // 代理节点链接
+	Behavior      string     `gorm:"size:16" json:"behavior"`                               // Clash provider 的 behavior，用于转换 sing-box 规则集
+	Interval      int        `gorm:"column:refresh_interval;default:86400" json:"interval"` // 刷新间隔（秒）
+	Enabled       bool       `gorm:"default:true" json:"enabled"`                           // 是否启用镜像
+	Size          int64      `gorm:"default:0" json:"size"`                                 // 本地文件大小
+	LastFetchAt   *time.Time `json:"lastFetchAt"`                                           // 最近一次尝试下载时间
+	LastSuccessAt *time.Time `json:"lastSuccessAt"`                                         // 最近一次下载成功时间
+	LastError     string     `gorm:"type:text" json:"lastError"`                            // 最近一次下载失败原因，成功后清空
+	FailCount     int        `gorm:"default:0" json:"failCount"`                            // 连续失败次数
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// ruleMirrorCache 规则镜像缓存
+var ruleMirrorCache *cache.MapCache[int, RuleMirror]
+
+func init() {
+	ruleMirrorCache = cache.NewMapCache(func(m RuleMirror) int { return m.ID })
+	ruleMirrorCache.AddIndex("url", func(m RuleMirror) string { return m.URL })
+	ruleMirrorCache.AddIndex("fileName", func(m RuleMirror) string { return m.FileName })
+	ruleMirrorCache.AddIndex("stem", func(m RuleMirror) string { return RuleMirrorStem(m.FileName) })
+}
+
+// InitRuleMirrorCache 初始化规则镜像缓存
+func InitRuleMirrorCache() error {
+	utils.Info("开始加载规则镜像到缓存")
+	var mirrors []RuleMirror
+	if err := database.DB.Find(&mirrors).Error; err != nil {
+		return err
+	}
+	ruleMirrorCache.LoadAll(mirrors)
+	utils.Info("规则镜像缓存初始化完成，共加载 %d 条记录", ruleMirrorCache.Count())
+	cache.Manager.Register("rule_mirror", ruleMirrorCache)
+	return nil
+}
+
+// RuleMirrorFileName 根据规则地址生成本地文件名，保留原始扩展名便于客户端识别格式
+func RuleMirrorFileName(rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	name := hex.EncodeToString(sum[:])[:16]
+	ext := ""
+	if parsed, err := url.Parse(rawURL); err == nil {
+		ext = strings.ToLower(path.Ext(parsed.Path))
+	}
+	switch ext {
+	case ".yaml", ".yml", ".txt", ".list", ".json", ".srs", ".mrs":
+		return name + ext
+	default:
+		return name + ".txt"
+	}
+}
+
+// RuleMirrorStem 返回镜像文件名去掉扩展名后的部分，sing-box 规则集等派生文件共用该前缀
+func RuleMirrorStem(fileName string) string {
+	stem, _, _ := strings.Cut(fileName, ".")
+	return stem
+}
+
+// SupportsSingBox 镜像能否转换为 sing-box 规则集
+// 上游本身是二进制规则或 JSON 规则时不做转换。
+func (m *RuleMirror) SupportsSingBox() bool {
+	switch path.Ext(m.FileName) {
+	case ".srs", ".mrs", ".json":
+		return false
+	default:
+		return true
+	}
+}
+
+// IsFresh 镜像是否有可用的本地副本
+func (m *RuleMirror) IsFresh() bool {
+	return m.Enabled && m.LastSuccessAt != nil
+}
+
+// IsDue 镜像是否需要刷新
+func (m *RuleMirror) IsDue(now time.Time) bool {
+	if !m.Enabled {
+		return false
+	}
+	if m.LastFetchAt == nil {
+		return true
+	}
+	// 失败的镜像不等待完整间隔，下次任务执行时重试
+	if m.LastError != "" {
+		return true
+	}
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultRuleMirrorInterval
+	}
+	return now.Sub(*m.LastFetchAt) >= time.Duration(interval)*time.Second
+}
+
+// ListRuleMirrors 获取全部规则镜像（按地址排序）
+func ListRuleMirrors() []RuleMirror {
+	return ruleMirrorCache.GetAllSorted(func(a, b RuleMirror) bool { return a.URL < b.URL })
+}
+
+// GetRuleMirrorByID 根据 ID 获取规则镜像
+func GetRuleMirrorByID(id int) (RuleMirror, bool) {
+	return ruleMirrorCache.Get(id)
+}
+
+// GetRuleMirrorByURL 根据原始地址获取规则镜像
+func GetRuleMirrorByURL(rawURL string) (RuleMirror, bool) {
+	items := ruleMirrorCache.GetByIndex("url", rawURL)
+	if len(items) == 0 {
+		return RuleMirror{}, false
+	}
+	return items[0], true
+}
+
+// GetRuleMirrorByFileName 根据本地文件名获取规则镜像
+func GetRuleMirrorByFileName(fileName string) (RuleMirror, bool) {
+	items := ruleMirrorCache.GetByIndex("fileName", fileName)
+	if len(items) == 0 {
+		return RuleMirror{}, false
+	}
+	return items[0], true
+}
+
+// GetRuleMirrorByStem 根据文件名前缀获取规则镜像
+func GetRuleMirrorByStem(stem string) (RuleMirror, bool) {
+	items := ruleMirrorCache.GetByIndex("stem", stem)
+	if len(items) == 0 {
+		return RuleMirror{}, false
+	}
+	return items[0], true
+}
+
+// RuleMirrorReference 模板中引用的一个远程规则
+type RuleMirrorReference struct {
+	URL       string
+	Interval  int
+	Behavior  string
+	Templates []string
+	UseProxy  bool
+	ProxyLink string
+}
+
+// SyncRuleMirrors 按模板引用同步镜像列表 (Write-Through)
+// 新引用的规则会被创建，已有记录更新引用模板与代理设置，不再被任何模板引用的记录会被删除并返回。
+func SyncRuleMirrors(refs []RuleMirrorReference) (removed []RuleMirror, err error) {
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		seen[ref.URL] = true
+		templates := append([]string(nil), ref.Templates...)
+		sort.Strings(templates)
+		interval := ref.Interval
+		if interval <= 0 {
+			interval = DefaultRuleMirrorInterval
+		}
+
+		mirror, ok := GetRuleMirrorByURL(ref.URL)
+		if !ok {
+			mirror = RuleMirror{
+				URL:       ref.URL,
+				FileName:  RuleMirrorFileName(ref.URL),
+				Templates: strings.Join(templates, ","),
+				UseProxy:  ref.UseProxy,
+				ProxyLink: ref.ProxyLink,
+				Behavior:  ref.Behavior,
+				Interval:  interval,
+				Enabled:   true,
+			}
+			if err := database.DB.Create(&mirror).Error; err != nil {
+				return nil, err
+			}
+			ruleMirrorCache.Set(mirror.ID, mirror)
+			continue
+		}
+
+		mirror.Templates = strings.Join(templates, ",")
+		mirror.UseProxy = ref.UseProxy
+		mirror.ProxyLink = ref.ProxyLink
+		mirror.Behavior = ref.Behavior
+		mirror.Interval = interval
+		if err := database.DB.Model(&RuleMirror{}).Where("id = ?", mirror.ID).Updates(map[string]any{
+			"templates":        mirror.Templates,
+			"use_proxy":        mirror.UseProxy,
+			"proxy_link":       mirror.ProxyLink,
+			"behavior":         mirror.Behavior,
+			"refresh_interval": mirror.Interval,
+		}).Error; err != nil {
+			return nil, err
+		}
+		ruleMirrorCache.Set(mirror.ID, mirror)
+	}
+
+	for _, mirror := range ruleMirrorCache.GetAll() {
+		if seen[mirror.URL] {
+			continue
+		}
+		if err := database.DB.Delete(&RuleMirror{}, mirror.ID).Error; err != nil {
+			return removed, err
+		}
+		ruleMirrorCache.Delete(mirror.ID)
+		removed = append(removed, mirror)
+	}
+	return removed, nil
+}
+
+// SetEnabled 启用或停用镜像 (Write-Through)
+func (m *RuleMirror) SetEnabled(enabled bool) error {
+	if err := database.DB.Model(&RuleMirror{}).Where("id = ?", m.ID).Update("enabled", enabled).Error; err != nil {
+		return err
+	}
+	m.Enabled = enabled
+	ruleMirrorCache.Set(m.ID, *m)
+	return nil
+}
+
+// RecordFetchSuccess 记录下载成功 (Write-Through)
+func (m *RuleMirror) RecordFetchSuccess(size int64, at time.Time) error {
+	if err := database.DB.Model(&RuleMirror{}).Where("id = ?", m.ID).Updates(map[string]any{
+		"size":            size,
+		"last_fetch_at":   at,
+		"last_success_at": at,
+		"last_error":      "",
+		"fail_count":      0,
+	}).Error; err != nil {
+		return err
+	}
+	m.Size = size
+	m.LastFetchAt = &at
+	m.LastSuccessAt = &at
+	m.LastError = ""
+	m.FailCount = 0
+	ruleMirrorCache.Set(m.ID, *m)
+	return nil
+}
+
+// RecordFetchFailure 记录下载失败，保留上次成功的本地副本 (Write-Through)
+func (m *RuleMirror) RecordFetchFailure(reason string, at time.Time) error {
+	if err := database.DB.Model(&RuleMirror{}).Where("id = ?", m.ID).Updates(map[string]any{
+		"last_fetch_at": at,
+		"last_error":    reason,
+		"fail_count":    m.FailCount + 1,
+	}).Error; err != nil {
+		return err
+	}
+	m.LastFetchAt = &at
+	m.LastError = reason
+	m.FailCount++
+	ruleMirrorCache.Set(m.ID, *m)
+	return nil
+}
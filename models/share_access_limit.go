@@ -0,0 +1,230 @@
+package models
+
+import (
+	"fmt"
+	"sublink/database"
+	"sublink/utils"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultShareLimitWindow 未设置统计窗口时使用的默认窗口（分钟）
+	DefaultShareLimitWindow = 60
+	// MaxShareLimitWindow 统计窗口上限（分钟），即 7 天
+	MaxShareLimitWindow = 7 * 24 * 60
+	// shareAccessWindowRetention 历史窗口统计保留时长
+	shareAccessWindowRetention = 30 * 24 * time.Hour
+	// shareAccessHistoryLimit 查询历史窗口时返回的最大条数
+	shareAccessHistoryLimit = 48
+)
+
+// ShareAccessWindow 分享在一个统计窗口内的访问次数与不同 IP 数
+// 窗口结束后写入数据库，作为历史统计。
+type ShareAccessWindow struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	ShareID     int       `gorm:"index" json:"share_id"`
+	WindowStart time.Time `gorm:"index" json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	Requests    int       `json:"requests"`
+	DistinctIPs int       `json:"distinct_ips"`
+}
+
+// ShareAccessUsage 分享访问限制与当前、历史窗口统计
+type ShareAccessUsage struct {
+	LimitWindow     int                 `json:"limit_window"`
+	MaxRequests     int                 `json:"max_requests"`
+	MaxIPs          int                 `json:"max_ips"`
+	Current         ShareAccessWindow   `json:"current"`
+	History         []ShareAccessWindow `json:"history"`
+	AbuseReason     string              `json:"abuse_reason"`
+	AbuseDisabledAt *time.Time          `json:"abuse_disabled_at"`
+}
+
+// shareAccessCounter 单个分享当前窗口的内存计数
+type shareAccessCounter struct {
+	start    time.Time
+	end      time.Time
+	requests int
+	ips      map[string]struct{}
+}
+
+var (
+	shareAccessCounters   = make(map[int]*shareAccessCounter)
+	shareAccessCountersMu sync.Mutex
+)
+
+// HasAccessLimit 分享是否设置了访问频率或 IP 数量限制
+func (s *SubscriptionShare) HasAccessLimit() bool {
+	return s.MaxRequests > 0 || s.MaxIPs > 0
+}
+
+// EffectiveLimitWindow 返回统计窗口长度（分钟）
+func (s *SubscriptionShare) EffectiveLimitWindow() int {
+	if s.LimitWindow <= 0 {
+		return DefaultShareLimitWindow
+	}
+	return s.LimitWindow
+}
+
+// IsAbuseDisabled 分享是否因超出访问限制被自动停用
+func (s *SubscriptionShare) IsAbuseDisabled() bool {
+	return !s.Enabled && s.AbuseDisabledAt != nil
+}
+
+// CheckAccessLimit 记录一次访问并检查是否超出限制，超出时返回原因
+// 计数只保存在内存中，窗口结束后的统计异步写入数据库；未设置限制的分享不计数。
+func (s *SubscriptionShare) CheckAccessLimit(ip string, now time.Time) string {
+	if !s.HasAccessLimit() {
+		return ""
+	}
+	window := time.Duration(s.EffectiveLimitWindow()) * time.Minute
+
+	shareAccessCountersMu.Lock()
+	counter := shareAccessCounters[s.ID]
+	var finished *ShareAccessWindow
+	if counter == nil || !now.Before(counter.end) {
+		if counter != nil && counter.requests > 0 {
+			finished = counter.snapshot(s.ID)
+		}
+		start := now.Truncate(window)
+		counter = &shareAccessCounter{start: start, end: start.Add(window), ips: make(map[string]struct{})}
+		shareAccessCounters[s.ID] = counter
+	}
+	counter.requests++
+	counter.ips[ip] = struct{}{}
+	requests, distinctIPs := counter.requests, len(counter.ips)
+	shareAccessCountersMu.Unlock()
+
+	if finished != nil {
+		saveShareAccessWindowAsync(*finished)
+	}
+
+	if s.MaxRequests > 0 && requests > s.MaxRequests {
+		return fmt.Sprintf("%d 分钟内访问 %d 次，超过上限 %d 次", s.EffectiveLimitWindow(), requests, s.MaxRequests)
+	}
+	if s.MaxIPs > 0 && distinctIPs > s.MaxIPs {
+		return fmt.Sprintf("%d 分钟内有 %d 个不同 IP 访问，超过上限 %d 个", s.EffectiveLimitWindow(), distinctIPs, s.MaxIPs)
+	}
+	return ""
+}
+
+func (c *shareAccessCounter) snapshot(shareID int) *ShareAccessWindow {
+	return &ShareAccessWindow{
+		ShareID:     shareID,
+		WindowStart: c.start,
+		WindowEnd:   c.end,
+		Requests:    c.requests,
+		DistinctIPs: len(c.ips),
+	}
+}
+
+// saveShareAccessWindowAsync 异步保存已结束的窗口统计，并清理过期历史
+func saveShareAccessWindowAsync(window ShareAccessWindow) {
+	accessRecordWG.Add(1)
+	go func() {
+		defer accessRecordWG.Done()
+		db := database.DB
+		if db == nil {
+			return
+		}
+		if err := db.Create(&window).Error; err != nil {
+			utils.Warn("保存分享访问统计失败: %v", err)
+			return
+		}
+		if err := db.Where("window_start < ?", time.Now().Add(-shareAccessWindowRetention)).Delete(&ShareAccessWindow{}).Error; err != nil {
+			utils.Warn("清理分享访问统计失败: %v", err)
+		}
+	}()
+}
+
+// ResetShareAccessCounter 结束分享的当前窗口并从零开始计数，已有计数保存为历史
+// 用于重新启用被自动停用的分享。
+func ResetShareAccessCounter(shareID int) {
+	shareAccessCountersMu.Lock()
+	counter := shareAccessCounters[shareID]
+	delete(shareAccessCounters, shareID)
+	shareAccessCountersMu.Unlock()
+
+	if counter != nil && counter.requests > 0 {
+		finished := counter.snapshot(shareID)
+		finished.WindowEnd = time.Now()
+		saveShareAccessWindowAsync(*finished)
+	}
+}
+
+// DisableForAbuse 因超出访问限制自动停用分享 (Write-Through)
+// 仅停用仍处于启用状态的分享，返回是否由本次调用完成停用；并发的超限请求只有一个会返回 true。
+func (s *SubscriptionShare) DisableForAbuse(reason string) (bool, error) {
+	now := time.Now()
+	result := database.DB.Model(&SubscriptionShare{}).Where("id = ? AND enabled = ?", s.ID, true).Updates(map[string]any{
+		"enabled":           false,
+		"abuse_disabled_at": now,
+		"abuse_reason":      reason,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	s.Enabled = false
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	s.AbuseDisabledAt = &now
+	s.AbuseReason = reason
+	if cached, ok := subscriptionShareCache.Get(s.ID); ok {
+		cached.Enabled = false
+		cached.AbuseDisabledAt = &now
+		cached.AbuseReason = reason
+		subscriptionShareCache.Set(cached.ID, cached)
+	}
+	return true, nil
+}
+
+// GetShareAccessUsage 获取分享的访问限制、当前窗口与最近的历史窗口统计
+func GetShareAccessUsage(share *SubscriptionShare) (ShareAccessUsage, error) {
+	usage := ShareAccessUsage{
+		LimitWindow:     share.EffectiveLimitWindow(),
+		MaxRequests:     share.MaxRequests,
+		MaxIPs:          share.MaxIPs,
+		AbuseReason:     share.AbuseReason,
+		AbuseDisabledAt: share.AbuseDisabledAt,
+		History:         []ShareAccessWindow{},
+	}
+
+	now := time.Now()
+	shareAccessCountersMu.Lock()
+	counter := shareAccessCounters[share.ID]
+	if counter != nil {
+		if now.Before(counter.end) {
+			usage.Current = *counter.snapshot(share.ID)
+		} else if counter.requests > 0 {
+			// 窗口已结束但尚无新访问触发写库，作为历史的第一条返回
+			usage.History = append(usage.History, *counter.snapshot(share.ID))
+		}
+	}
+	shareAccessCountersMu.Unlock()
+	if usage.Current.WindowStart.IsZero() {
+		window := time.Duration(usage.LimitWindow) * time.Minute
+		usage.Current = ShareAccessWindow{ShareID: share.ID, WindowStart: now.Truncate(window), WindowEnd: now.Truncate(window).Add(window)}
+	}
+
+	var history []ShareAccessWindow
+	if err := database.DB.Where("share_id = ?", share.ID).Order("window_start DESC").Limit(shareAccessHistoryLimit).Find(&history).Error; err != nil {
+		return usage, err
+	}
+	usage.History = append(usage.History, history...)
+	return usage, nil
+}
+
+// clearShareAccessCounter 丢弃分享的当前计数
+func clearShareAccessCounter(shareID int) {
+	shareAccessCountersMu.Lock()
+	defer shareAccessCountersMu.Unlock()
+	delete(shareAccessCounters, shareID)
+}
+
+// DeleteShareAccessWindows 删除分享的当前计数与历史访问统计
+func DeleteShareAccessWindows(shareID int) error {
+	clearShareAccessCounter(shareID)
+	return database.DB.Where("share_id = ?", shareID).Delete(&ShareAccessWindow{}).Error
+}
@@ -0,0 +1,323 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sublink/database"
+	"sublink/utils"
+
+	"gorm.io/gorm"
+)
+
+// 分享访问的响应模式
+const (
+	ShareAccessModeNormal   = "normal"   // 正常输出订阅
+	ShareAccessModeExpired  = "expired"  // 分享已过期或被停用
+	ShareAccessModeFallback = "fallback" // 签名、设备等校验失败返回的占位订阅
+)
+
+const (
+	// shareAnalyticsRetentionSettingKey 分享访问明细保留天数的系统设置 key
+	shareAnalyticsRetentionSettingKey = "share_analytics_retention_days"
+	// DefaultShareAnalyticsRetentionDays 默认保留天数
+	DefaultShareAnalyticsRetentionDays = 30
+	// MaxShareAnalyticsRetentionDays 最长保留天数
+	MaxShareAnalyticsRetentionDays = 365
+
+	// unusualCountryThreshold 同一分享来自不同国家数达到该值时视为异常
+	unusualCountryThreshold = 3
+)
+
+// ShareAccessEvent 分享访问明细，每次拉取记录一条，超过保留天数后清理
+type ShareAccessEvent struct {
+	ID             int       `gorm:"primaryKey" json:"id"`
+	ShareID        int       `gorm:"index" json:"share_id"`
+	SubscriptionID int       `gorm:"index" json:"subscription_id"`
+	ClientType     string    `gorm:"size:32" json:"client_type"` // 实际输出的客户端类型
+	UAFamily       string    `gorm:"size:32" json:"ua_family"`   // User-Agent 所属客户端
+	UserAgent      string    `gorm:"size:255" json:"user_agent"`
+	IP             string    `gorm:"size:64;index" json:"ip"`
+	Country        string    `gorm:"size:8" json:"country"` // 国家代码
+	ASN            string    `gorm:"size:32" json:"asn"`
+	Mode           string    `gorm:"size:16" json:"mode"` // 响应模式
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
+
+// ShareAccessDaily 分享每日访问汇总，明细清理后仍然保留
+type ShareAccessDaily struct {
+	ID             int    `gorm:"primaryKey" json:"id"`
+	ShareID        int    `gorm:"uniqueIndex:idx_share_access_daily_share_day" json:"share_id"`
+	SubscriptionID int    `gorm:"index" json:"subscription_id"`
+	Day            string `gorm:"uniqueIndex:idx_share_access_daily_share_day;size:10" json:"day"` // 日期 yyyy-MM-dd
+	Requests       int    `json:"requests"`
+	UniqueIPs      int    `gorm:"column:unique_ips" json:"unique_ips"`
+	Normal         int    `json:"normal"`
+	Expired        int    `json:"expired"`
+	Fallback       int    `json:"fallback"`
+}
+
+// ShareAnalyticsFilter 分享访问统计查询条件，分享ID优先于订阅ID
+type ShareAnalyticsFilter struct {
+	SubscriptionID int
+	ShareID        int
+	Since          time.Time
+}
+
+// ShareClientStat 按客户端汇总的访问次数
+type ShareClientStat struct {
+	UAFamily   string `json:"ua_family"`
+	ClientType string `json:"client_type"`
+	Requests   int    `json:"requests"`
+	UniqueIPs  int    `json:"unique_ips"`
+}
+
+// ShareUnusualAccess 异常访问记录
+type ShareUnusualAccess struct {
+	ShareID   int      `json:"share_id"`
+	ShareName string   `json:"share_name"`
+	Kind      string   `json:"kind"` // expired / fallback / non_client / many_countries
+	Count     int      `json:"count"`
+	Countries []string `json:"countries,omitempty"`
+}
+
+// shareAnalyticsMu 保证同一天的去重判断与汇总累加串行执行
+var shareAnalyticsMu sync.Mutex
+
+// RecordShareAccessEvent 写入一条访问明细并累加当天的汇总
+func RecordShareAccessEvent(event ShareAccessEvent) error {
+	db := database.DB
+	if db == nil || event.ShareID <= 0 {
+		return nil
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if event.UAFamily == "" {
+		event.UAFamily = UserAgentFamily(event.UserAgent)
+	}
+	if event.ASN == "" {
+		event.ASN = ipASN(event.IP)
+	}
+	if len(event.UserAgent) > 255 {
+		event.UserAgent = event.UserAgent[:255]
+	}
+
+	shareAnalyticsMu.Lock()
+	defer shareAnalyticsMu.Unlock()
+
+	dayStart := startOfDay(event.CreatedAt)
+	var seen int64
+	if err := db.Model(&ShareAccessEvent{}).
+		Where("share_id = ? AND ip = ? AND created_at >= ?", event.ShareID, event.IP, dayStart).
+		Count(&seen).Error; err != nil {
+		return err
+	}
+	if err := db.Create(&event).Error; err != nil {
+		return err
+	}
+
+	daily := ShareAccessDaily{ShareID: event.ShareID, Day: dayStart.Format("2006-01-02")}
+	if err := db.Where(&daily).Attrs(ShareAccessDaily{SubscriptionID: event.SubscriptionID}).FirstOrCreate(&daily).Error; err != nil {
+		return err
+	}
+	updates := map[string]any{"requests": gorm.Expr("requests + ?", 1)}
+	switch event.Mode {
+	case ShareAccessModeExpired:
+		updates["expired"] = gorm.Expr("expired + ?", 1)
+	case ShareAccessModeFallback:
+		updates["fallback"] = gorm.Expr("fallback + ?", 1)
+	default:
+		updates["normal"] = gorm.Expr("normal + ?", 1)
+	}
+	if seen == 0 {
+		updates["unique_ips"] = gorm.Expr("unique_ips + ?", 1)
+	}
+	return db.Model(&ShareAccessDaily{}).Where("id = ?", daily.ID).Updates(updates).Error
+}
+
+// shareAccessEventQueueSize 待写入访问明细的队列长度，队列满时丢弃新事件
+const shareAccessEventQueueSize = 1024
+
+var (
+	shareAccessEventQueue    = make(chan ShareAccessEvent, shareAccessEventQueueSize)
+	shareAccessEventWriter   sync.Once
+	shareAccessEventsDropped atomic.Int64
+)
+
+// RecordShareAccessEventAsync 异步记录访问明细，避免订阅拉取热路径等待数据库写入
+// 事件进入有界队列由单个写入协程顺序落库，队列满时丢弃并计数，避免突发流量堆积协程与连接。
+func RecordShareAccessEventAsync(event ShareAccessEvent) {
+	if event.ShareID <= 0 {
+		return
+	}
+	shareAccessEventWriter.Do(func() { go writeShareAccessEvents() })
+	accessRecordWG.Add(1)
+	select {
+	case shareAccessEventQueue <- event:
+	default:
+		accessRecordWG.Done()
+		if dropped := shareAccessEventsDropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			utils.Warn("分享访问明细写入队列已满，已丢弃 %d 条", dropped)
+		}
+	}
+}
+
+// DroppedShareAccessEvents 返回因写入队列已满而丢弃的访问明细数量
+func DroppedShareAccessEvents() int64 {
+	return shareAccessEventsDropped.Load()
+}
+
+// writeShareAccessEvents 顺序写入队列中的访问明细
+func writeShareAccessEvents() {
+	for event := range shareAccessEventQueue {
+		if err := RecordShareAccessEvent(event); err != nil {
+			utils.Warn("记录分享访问明细失败: %v", err)
+		}
+		accessRecordWG.Done()
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// scope 按分享或订阅限定查询范围
+func (f ShareAnalyticsFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.ShareID > 0 {
+		return db.Where("share_id = ?", f.ShareID)
+	}
+	return db.Where("subscription_id = ?", f.SubscriptionID)
+}
+
+// ListShareAccessDaily 查询每日汇总，按订阅查询时合并该订阅下所有分享
+// 合并后的不同 IP 数为各分享之和。
+func ListShareAccessDaily(filter ShareAnalyticsFilter) ([]ShareAccessDaily, error) {
+	var rows []ShareAccessDaily
+	query := filter.scope(database.DB.Model(&ShareAccessDaily{})).
+		Where("day >= ?", filter.Since.Format("2006-01-02"))
+	if filter.ShareID > 0 {
+		err := query.Order("day ASC").Find(&rows).Error
+		return rows, err
+	}
+	err := query.Select("day, SUM(requests) AS requests, SUM(unique_ips) AS unique_ips, " +
+		"SUM(normal) AS normal, SUM(expired) AS expired, SUM(fallback) AS fallback").
+		Group("day").Order("day ASC").Scan(&rows).Error
+	for i := range rows {
+		rows[i].SubscriptionID = filter.SubscriptionID
+	}
+	return rows, err
+}
+
+// TopShareClients 按客户端统计访问次数，返回访问最多的前 limit 个
+func TopShareClients(filter ShareAnalyticsFilter, limit int) ([]ShareClientStat, error) {
+	var stats []ShareClientStat
+	err := filter.scope(database.DB.Model(&ShareAccessEvent{})).
+		Where("created_at >= ?", filter.Since).
+		Select("ua_family, client_type, COUNT(*) AS requests, COUNT(DISTINCT ip) AS unique_ips").
+		Group("ua_family, client_type").
+		Order("requests DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// ListUnusualShareAccess 汇总异常访问：访问过期或停用的分享、校验失败、非客户端拉取以及来自多个国家的访问
+func ListUnusualShareAccess(filter ShareAnalyticsFilter) ([]ShareUnusualAccess, error) {
+	var result []ShareUnusualAccess
+	base := func() *gorm.DB {
+		return filter.scope(database.DB.Model(&ShareAccessEvent{})).Where("created_at >= ?", filter.Since)
+	}
+	type shareCount struct {
+		ShareID int
+		Count   int
+	}
+
+	kinds := []struct {
+		kind  string
+		query *gorm.DB
+	}{
+		{"expired", base().Where("mode = ?", ShareAccessModeExpired)},
+		{"fallback", base().Where("mode = ?", ShareAccessModeFallback)},
+		{"non_client", base().Where("ua_family IN ?", []string{"browser", "curl", "unknown"})},
+	}
+	for _, item := range kinds {
+		var counts []shareCount
+		if err := item.query.Select("share_id, COUNT(*) AS count").Group("share_id").Scan(&counts).Error; err != nil {
+			return nil, err
+		}
+		for _, count := range counts {
+			result = append(result, ShareUnusualAccess{ShareID: count.ShareID, Kind: item.kind, Count: count.Count})
+		}
+	}
+
+	var countries []struct {
+		ShareID int
+		Country string
+	}
+	if err := base().Where("country <> ''").Distinct("share_id", "country").Scan(&countries).Error; err != nil {
+		return nil, err
+	}
+	byShare := make(map[int][]string)
+	for _, row := range countries {
+		byShare[row.ShareID] = append(byShare[row.ShareID], row.Country)
+	}
+	for shareID, codes := range byShare {
+		if len(codes) < unusualCountryThreshold {
+			continue
+		}
+		sort.Strings(codes)
+		result = append(result, ShareUnusualAccess{ShareID: shareID, Kind: "many_countries", Count: len(codes), Countries: codes})
+	}
+
+	for i := range result {
+		share := SubscriptionShare{ID: result[i].ShareID}
+		if err := share.Find(); err == nil {
+			result[i].ShareName = share.Name
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].ShareID < result[j].ShareID
+	})
+	return result, nil
+}
+
+// DeleteShareAnalytics 删除分享的访问明细与每日汇总
+func DeleteShareAnalytics(shareID int) error {
+	if err := database.DB.Where("share_id = ?", shareID).Delete(&ShareAccessEvent{}).Error; err != nil {
+		return err
+	}
+	return database.DB.Where("share_id = ?", shareID).Delete(&ShareAccessDaily{}).Error
+}
+
+// GetShareAnalyticsRetentionDays 获取访问明细保留天数
+func GetShareAnalyticsRetentionDays() int {
+	value, err := GetSetting(shareAnalyticsRetentionSettingKey)
+	if err != nil || value == "" {
+		return DefaultShareAnalyticsRetentionDays
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 || days > MaxShareAnalyticsRetentionDays {
+		return DefaultShareAnalyticsRetentionDays
+	}
+	return days
+}
+
+// SetShareAnalyticsRetentionDays 保存访问明细保留天数
+func SetShareAnalyticsRetentionDays(days int) error {
+	return SetSetting(shareAnalyticsRetentionSettingKey, strconv.Itoa(days))
+}
+
+// CleanupExpiredShareAccessEvents 按保留天数清理访问明细，每日汇总不受影响
+func CleanupExpiredShareAccessEvents() (int64, error) {
+	before := time.Now().AddDate(0, 0, -GetShareAnalyticsRetentionDays())
+	result := database.DB.Where("created_at < ?", before).Delete(&ShareAccessEvent{})
+	return result.RowsAffected, result.Error
+}
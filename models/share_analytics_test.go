@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"sublink/database"
+)
+
+func TestCleanupExpiredShareAccessEventsKeepsDailyRollups(t *testing.T) {
+	setupSubscriptionShareTestDB(t)
+	if err := database.DB.AutoMigrate(&ShareAccessEvent{}, &ShareAccessDaily{}); err != nil {
+		t.Fatalf("auto migrate share analytics: %v", err)
+	}
+
+	old := time.Now().AddDate(0, 0, -(DefaultShareAnalyticsRetentionDays + 5))
+	for _, event := range []ShareAccessEvent{
+		{ShareID: 1, SubscriptionID: 1, IP: "203.0.113.1", UserAgent: "clash-verge/v1.7.0", Mode: ShareAccessModeNormal, CreatedAt: old},
+		{ShareID: 1, SubscriptionID: 1, IP: "203.0.113.1", UserAgent: "clash-verge/v1.7.0", Mode: ShareAccessModeNormal},
+		{ShareID: 1, SubscriptionID: 1, IP: "203.0.113.1", UserAgent: "curl/8.5.0", Mode: ShareAccessModeFallback},
+	} {
+		if err := RecordShareAccessEvent(event); err != nil {
+			t.Fatalf("record event: %v", err)
+		}
+	}
+
+	deleted, err := CleanupExpiredShareAccessEvents()
+	if err != nil || deleted != 1 {
+		t.Fatalf("cleanup deleted %d, err %v", deleted, err)
+	}
+
+	var daily []ShareAccessDaily
+	if err := database.DB.Order("day ASC").Find(&daily).Error; err != nil {
+		t.Fatalf("list daily rollups: %v", err)
+	}
+	if len(daily) != 2 || daily[1].Requests != 2 || daily[1].UniqueIPs != 1 || daily[1].Fallback != 1 || daily[0].Requests != 1 {
+		t.Fatalf("unexpected daily rollups: %+v", daily)
+	}
+}
+
+func TestRecordShareAccessEventAsyncBoundsPendingWrites(t *testing.T) {
+	setupSubscriptionShareTestDB(t)
+	if err := database.DB.AutoMigrate(&ShareAccessEvent{}, &ShareAccessDaily{}); err != nil {
+		t.Fatalf("auto migrate share analytics: %v", err)
+	}
+
+	droppedBefore := DroppedShareAccessEvents()
+	total := shareAccessEventQueueSize * 2
+	for i := 0; i < total; i++ {
+		RecordShareAccessEventAsync(ShareAccessEvent{ShareID: 1, SubscriptionID: 1, IP: "203.0.113.1", Mode: ShareAccessModeNormal})
+	}
+	WaitForPendingAccessRecords()
+
+	var stored int64
+	if err := database.DB.Model(&ShareAccessEvent{}).Count(&stored).Error; err != nil {
+		t.Fatalf("count events: %v", err)
+	}
+	dropped := DroppedShareAccessEvents() - droppedBefore
+	if stored+dropped != int64(total) || stored == 0 {
+		t.Fatalf("expected every event to be stored or dropped, stored %d dropped %d of %d", stored, dropped, total)
+	}
+}
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sublink/cache"
+	"sublink/database"
+	"sublink/utils"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxShareDevices 分享可绑定设备数上限
+	MaxShareDevices = 100
+	// shareDeviceTouchInterval 设备最近访问信息的最小写库间隔
+	shareDeviceTouchInterval = time.Minute
+)
+
+// ShareDevice 分享绑定的设备指纹
+// 指纹由客户端网络（IP 网段，已知 ASN 时还要求 ASN 一致）与 User-Agent 所属客户端组成，
+// 同一客户端在同一网段内更换 IP 仍视为同一设备，同一运营商的其他网段视为新设备。
+type ShareDevice struct {
+	ID          int       `gorm:"primaryKey" json:"id"`
+	ShareID     int       `gorm:"index" json:"share_id"`
+	UAFamily    string    `gorm:"size:64" json:"ua_family"`   // User-Agent 所属客户端，如 clash、surge、browser
+	ASN         string    `gorm:"size:32" json:"asn"`         // 自治系统号，如 AS13335，未知时为空
+	IPPrefix    string    `gorm:"size:64" json:"ip_prefix"`   // IPv4 /24 或 IPv6 /48 网段
+	LastIP      string    `gorm:"size:64" json:"last_ip"`     // 最近访问 IP
+	UserAgent   string    `gorm:"size:255" json:"user_agent"` // 最近访问的完整 User-Agent
+	Requests    int       `json:"requests"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// shareDeviceCache 设备绑定缓存，按分享 ID 建立索引
+var shareDeviceCache *cache.MapCache[int, ShareDevice]
+
+// shareDeviceLocks 每个分享一把锁，保证同一分享同时只有一个请求在绑定新设备，避免并发超出数量上限
+// 不同分享之间互不等待。
+var shareDeviceLocks sync.Map
+
+// lockShareDevices 锁定分享的设备绑定，返回解锁函数
+func lockShareDevices(shareID int) func() {
+	value, _ := shareDeviceLocks.LoadOrStore(shareID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func init() {
+	shareDeviceCache = cache.NewMapCache(func(d ShareDevice) int { return d.ID })
+	shareDeviceCache.AddIndex("shareID", func(d ShareDevice) string { return strconv.Itoa(d.ShareID) })
+}
+
+// InitShareDeviceCache 初始化分享设备绑定缓存
+func InitShareDeviceCache() error {
+	utils.Info("开始加载分享绑定设备到缓存")
+	var devices []ShareDevice
+	if err := database.DB.Find(&devices).Error; err != nil {
+		return err
+	}
+
+	shareDeviceCache.LoadAll(devices)
+	utils.Info("分享绑定设备缓存初始化完成，共加载 %d 台设备", shareDeviceCache.Count())
+
+	cache.Manager.Register("shareDevice", shareDeviceCache)
+	return nil
+}
+
+// UserAgentFamily 识别 User-Agent 所属客户端，忽略版本号
+func UserAgentFamily(userAgent string) string {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return "unknown"
+	}
+	families := []struct{ keyword, family string }{
+		{"stash", "stash"}, // Stash 的 User-Agent 同时包含 Clash
+		{"mihomo", "mihomo"},
+		{"clash", "clash"},
+		{"surge", "surge"},
+		{"shadowrocket", "shadowrocket"},
+		{"quantumult", "quantumult"},
+		{"loon", "loon"},
+		{"egern", "egern"},
+		{"surfboard", "surfboard"},
+		{"sing-box", "sing-box"},
+		{"sfa", "sing-box"},
+		{"sfi", "sing-box"},
+		{"hiddify", "hiddify"},
+		{"nekobox", "nekobox"},
+		{"v2rayng", "v2rayng"},
+		{"v2rayn", "v2rayn"},
+		{"v2box", "v2box"},
+		{"streisand", "streisand"},
+	}
+	for _, item := range families {
+		if strings.Contains(ua, item.keyword) {
+			return item.family
+		}
+	}
+	if strings.HasPrefix(ua, "mozilla/") {
+		return "browser"
+	}
+	// 其他客户端取第一个产品标识，如 curl/8.0 -> curl
+	product := strings.FieldsFunc(ua, func(r rune) bool { return r == '/' || r == ' ' || r == ';' })
+	if len(product) == 0 {
+		return "unknown"
+	}
+	return truncateSessionField(product[0], 64)
+}
+
+// ipNetworkPrefix 返回 IP 所在网段，IPv4 取 /24，IPv6 取 /48
+func ipNetworkPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// ipASN 从已缓存的 IP 信息中取自治系统号，不发起网络请求
+func ipASN(ip string) string {
+	info, ok := GetCachedIPInfo(ip)
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(info.AS)
+	if len(fields) == 0 || !strings.HasPrefix(strings.ToUpper(fields[0]), "AS") {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// matches 判断访问是否来自该设备：客户端与 IP 网段都相同
+// 双方都解析出 ASN 时还要求 ASN 一致；同一运营商下的其他网段不视为同一设备。
+func (d ShareDevice) matches(uaFamily, asn, prefix string) bool {
+	if d.UAFamily != uaFamily || d.IPPrefix != prefix {
+		return false
+	}
+	return asn == "" || d.ASN == "" || d.ASN == asn
+}
+
+// CheckDevice 检查访问设备是否已绑定，未达到上限时绑定新设备
+// 未开启设备绑定（MaxDevices 为 0）时始终允许。
+func (s *SubscriptionShare) CheckDevice(ip, userAgent string, now time.Time) bool {
+	if s.MaxDevices <= 0 {
+		return true
+	}
+	uaFamily := UserAgentFamily(userAgent)
+	asn := ipASN(ip)
+	prefix := ipNetworkPrefix(ip)
+	userAgent = truncateSessionField(userAgent, 255)
+
+	unlock := lockShareDevices(s.ID)
+	defer unlock()
+
+	devices := shareDeviceCache.GetByIndex("shareID", strconv.Itoa(s.ID))
+	for _, device := range devices {
+		if device.matches(uaFamily, asn, prefix) {
+			touchShareDevice(device, ip, userAgent, asn, now)
+			return true
+		}
+	}
+	if len(devices) >= s.MaxDevices {
+		return false
+	}
+
+	device := ShareDevice{
+		ShareID:     s.ID,
+		UAFamily:    uaFamily,
+		ASN:         asn,
+		IPPrefix:    prefix,
+		LastIP:      ip,
+		UserAgent:   userAgent,
+		Requests:    1,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	if err := database.DB.Create(&device).Error; err != nil {
+		// 写库失败时放行，避免因数据库异常阻断正常订阅
+		utils.Warn("绑定分享设备失败: %v", err)
+		return true
+	}
+	shareDeviceCache.Set(device.ID, device)
+	return true
+}
+
+// touchShareDevice 更新设备最近访问信息，按间隔写库
+func touchShareDevice(device ShareDevice, ip, userAgent, asn string, now time.Time) {
+	persist := now.Sub(device.LastSeenAt) >= shareDeviceTouchInterval || device.LastIP != ip || (device.ASN == "" && asn != "")
+	device.Requests++
+	device.LastIP = ip
+	device.UserAgent = userAgent
+	device.LastSeenAt = now
+	if device.ASN == "" {
+		device.ASN = asn
+	}
+	if persist {
+		if err := database.DB.Model(&ShareDevice{}).Where("id = ?", device.ID).Updates(map[string]any{
+			"requests":     device.Requests,
+			"last_ip":      device.LastIP,
+			"user_agent":   device.UserAgent,
+			"last_seen_at": device.LastSeenAt,
+			"asn":          device.ASN,
+		}).Error; err != nil {
+			utils.Warn("更新分享设备失败: %v", err)
+		}
+	}
+	shareDeviceCache.Set(device.ID, device)
+}
+
+// ListShareDevices 获取分享绑定的设备，按首次访问时间排序
+func ListShareDevices(shareID int) []ShareDevice {
+	return shareDeviceCache.FilterSorted(func(d ShareDevice) bool {
+		return d.ShareID == shareID
+	}, func(a, b ShareDevice) bool {
+		return a.FirstSeenAt.Before(b.FirstSeenAt)
+	})
+}
+
+// ResetShareDevices 解除分享的设备绑定，deviceID 为 0 时解除全部 (Write-Through)
+// 返回解除的设备数量。
+func ResetShareDevices(shareID, deviceID int) (int, error) {
+	unlock := lockShareDevices(shareID)
+	defer unlock()
+
+	var removed []ShareDevice
+	for _, device := range shareDeviceCache.GetByIndex("shareID", strconv.Itoa(shareID)) {
+		if deviceID == 0 || device.ID == deviceID {
+			removed = append(removed, device)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	query := database.DB.Where("share_id = ?", shareID)
+	if deviceID != 0 {
+		query = query.Where("id = ?", deviceID)
+	}
+	if err := query.Delete(&ShareDevice{}).Error; err != nil {
+		return 0, err
+	}
+	for _, device := range removed {
+		shareDeviceCache.Delete(device.ID)
+	}
+	return len(removed), nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"sublink/database"
+)
+
+func TestUserAgentFamily(t *testing.T) {
+	cases := map[string]string{
+		"clash-verge/v1.7.0":                        "clash",
+		"ClashMetaForAndroid/2.10.1.Meta":           "clash",
+		"Stash/2.4.6 Clash/1.9.0":                   "stash",
+		"mihomo/1.18.5":                             "mihomo",
+		"Surge iOS/3040":                            "surge",
+		"Shadowrocket/2070 CFNetwork/1494.0.7":      "shadowrocket",
+		"SFA/1.9.0 (sing-box 1.9.0)":                "sing-box",
+		"v2rayNG/1.8.19":                            "v2rayng",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64)": "browser",
+		"curl/8.5.0":                                "curl",
+		"":                                          "unknown",
+	}
+	for ua, want := range cases {
+		if got := UserAgentFamily(ua); got != want {
+			t.Errorf("UserAgentFamily(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+func TestIPNetworkPrefix(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.77":       "203.0.113.0/24",
+		"2001:db8:1234:5::1": "2001:db8:1234::/48",
+		"not-an-ip":          "not-an-ip",
+	}
+	for ip, want := range cases {
+		if got := ipNetworkPrefix(ip); got != want {
+			t.Errorf("ipNetworkPrefix(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
+
+func TestShareDeviceMatchesRequiresPrefix(t *testing.T) {
+	device := ShareDevice{UAFamily: "clash", ASN: "AS4134", IPPrefix: "203.0.113.0/24"}
+	cases := []struct {
+		uaFamily, asn, prefix string
+		want                  bool
+	}{
+		{"clash", "AS4134", "203.0.113.0/24", true},
+		{"clash", "", "203.0.113.0/24", true},
+		{"clash", "AS4134", "198.51.100.0/24", false},
+		{"clash", "AS9808", "203.0.113.0/24", false},
+		{"surge", "AS4134", "203.0.113.0/24", false},
+	}
+	for _, tc := range cases {
+		if got := device.matches(tc.uaFamily, tc.asn, tc.prefix); got != tc.want {
+			t.Errorf("matches(%q, %q, %q) = %v, want %v", tc.uaFamily, tc.asn, tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestCheckDeviceLocksPerShare(t *testing.T) {
+	setupSubscriptionShareTestDB(t)
+	if err := database.DB.AutoMigrate(&ShareDevice{}); err != nil {
+		t.Fatalf("auto migrate share devices: %v", err)
+	}
+
+	// 另一个分享正在绑定设备时，本分享的检查不需要等待
+	unlock := lockShareDevices(1)
+	defer unlock()
+	done := make(chan bool, 1)
+	go func() {
+		share := &SubscriptionShare{ID: 2, MaxDevices: 1}
+		done <- share.CheckDevice("203.0.113.10", "clash-verge/v1.7.0", time.Now())
+	}()
+	select {
+	case allowed := <-done:
+		if !allowed {
+			t.Fatal("expected first device to be bound")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected device check of another share not to wait for the lock")
+	}
+	shareDeviceCache.Delete(ListShareDevices(2)[0].ID)
+}
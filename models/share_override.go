@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// HasNodeOverrides 分享是否设置了额外的节点过滤或数量限制
+func (s *SubscriptionShare) HasNodeOverrides() bool {
+	return s.CountryWhitelist != "" || s.CountryBlacklist != "" ||
+		s.TagWhitelist != "" || s.TagBlacklist != "" ||
+		s.ProtocolWhitelist != "" || s.ProtocolBlacklist != "" ||
+		s.MaxNodes > 0
+}
+
+// ApplyNodeOverrides 在订阅过滤结果上叠加分享的国家、标签、协议过滤，并限制节点数量
+// 分享只能进一步收窄订阅的节点，不会加入订阅之外的节点。
+func (s *SubscriptionShare) ApplyNodeOverrides(nodes []Node) []Node {
+	if !s.HasNodeOverrides() {
+		return nodes
+	}
+	// 复用订阅的过滤逻辑，未设置的条件不生效
+	filter := Subcription{
+		CountryWhitelist:  s.CountryWhitelist,
+		CountryBlacklist:  s.CountryBlacklist,
+		TagWhitelist:      s.TagWhitelist,
+		TagBlacklist:      s.TagBlacklist,
+		ProtocolWhitelist: s.ProtocolWhitelist,
+		ProtocolBlacklist: s.ProtocolBlacklist,
+	}
+	result := filter.ApplyFilters(nodes)
+	if s.MaxNodes > 0 && len(result) > s.MaxNodes {
+		result = result[:s.MaxNodes]
+	}
+	return result
+}
+
+// ApplyTemplateOverrides 使用分享指定的 Clash / Surge 模板替换订阅配置中的模板
+// 配置中的其他字段保持不变，解析失败时返回原配置。
+func (s *SubscriptionShare) ApplyTemplateOverrides(config string) string {
+	if s.ClashTemplate == "" && s.SurgeTemplate == "" {
+		return config
+	}
+	fields := map[string]any{}
+	if strings.TrimSpace(config) != "" {
+		if err := json.Unmarshal([]byte(config), &fields); err != nil {
+			return config
+		}
+	}
+	if s.ClashTemplate != "" {
+		fields["clash"] = s.ClashTemplate
+	}
+	if s.SurgeTemplate != "" {
+		fields["surge"] = s.SurgeTemplate
+	}
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return config
+	}
+	return string(encoded)
+}
+
+// FallbackMessageOr 返回分享自定义的占位提示，未设置时使用默认提示
+func (s *SubscriptionShare) FallbackMessageOr(defaultMessage string) string {
+	if message := strings.TrimSpace(s.FallbackMessage); message != "" {
+		return message
+	}
+	return defaultMessage
+}
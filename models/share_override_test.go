@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShareApplyNodeOverrides(t *testing.T) {
+	nodes := []Node{
+		{Name: "hk-1", LinkCountry: "HK", Protocol: "ss"},
+		{Name: "jp-1", LinkCountry: "JP", Protocol: "vmess"},
+		{Name: "hk-2", LinkCountry: "HK", Protocol: "vmess"},
+		{Name: "us-1", LinkCountry: "US", Protocol: "ss"},
+	}
+
+	share := SubscriptionShare{}
+	if got := share.ApplyNodeOverrides(nodes); len(got) != len(nodes) {
+		t.Fatalf("expected no override to keep all nodes, got %d", len(got))
+	}
+
+	share.CountryWhitelist = "HK,JP"
+	share.MaxNodes = 2
+	got := share.ApplyNodeOverrides(nodes)
+	if len(got) != 2 || got[0].Name != "hk-1" || got[1].Name != "jp-1" {
+		t.Fatalf("unexpected nodes after country and max override: %+v", got)
+	}
+
+	share = SubscriptionShare{ProtocolBlacklist: "ss"}
+	got = share.ApplyNodeOverrides(nodes)
+	if len(got) != 2 || got[0].Name != "jp-1" || got[1].Name != "hk-2" {
+		t.Fatalf("unexpected nodes after protocol override: %+v", got)
+	}
+}
+
+func TestShareApplyTemplateOverrides(t *testing.T) {
+	share := SubscriptionShare{ClashTemplate: "./template/share.yaml"}
+	config := `{"clash":"./template/clash.yaml","surge":"./template/surge.conf","udp":true}`
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(share.ApplyTemplateOverrides(config)), &fields); err != nil {
+		t.Fatalf("decode overridden config: %v", err)
+	}
+	if fields["clash"] != "./template/share.yaml" || fields["surge"] != "./template/surge.conf" || fields["udp"] != true {
+		t.Fatalf("unexpected overridden config: %+v", fields)
+	}
+	if got := (&SubscriptionShare{}).ApplyTemplateOverrides(config); got != config {
+		t.Fatalf("expected config unchanged without overrides, got %q", got)
+	}
+}
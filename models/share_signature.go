@@ -0,0 +1,126 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sublink/database"
+	"sync"
+	"time"
+)
+
+// shareSignSecretKey 签名链接密钥在系统设置中的键名
+const shareSignSecretKey = "share_sign_secret"
+
+var (
+	// ErrShareSignatureInvalid 签名缺失或不匹配
+	ErrShareSignatureInvalid = errors.New("签名链接无效")
+	// ErrShareSignatureExpired 签名链接已过期
+	ErrShareSignatureExpired = errors.New("签名链接已过期")
+	// ErrShareSignatureIPMismatch 签名链接绑定的 IP 与访问 IP 不一致
+	ErrShareSignatureIPMismatch = errors.New("签名链接不允许当前 IP 访问")
+
+	shareSignSecretMu sync.Mutex
+)
+
+// ShareSignature 签名链接的参数，ClientType 与 IP 为空表示不绑定
+type ShareSignature struct {
+	Token      string
+	ExpiresAt  int64 // Unix 时间戳（秒）
+	ClientType string
+	IP         string
+}
+
+// payload 参与签名的内容，各字段以换行分隔
+func (p ShareSignature) payload() string {
+	return strings.Join([]string{strings.ToLower(p.Token), strconv.FormatInt(p.ExpiresAt, 10), p.ClientType, p.IP}, "\n")
+}
+
+// getShareSignSecret 获取签名密钥，首次使用时自动生成并保存
+func getShareSignSecret() ([]byte, error) {
+	shareSignSecretMu.Lock()
+	defer shareSignSecretMu.Unlock()
+
+	if secret, err := GetSetting(shareSignSecretKey); err == nil && secret != "" {
+		return []byte(secret), nil
+	}
+	secret, err := generateShareSignSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := SetSetting(shareSignSecretKey, secret); err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+func generateShareSignSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RotateShareSignSecret 更换签名密钥，已签发的签名链接全部失效
+func RotateShareSignSecret() error {
+	shareSignSecretMu.Lock()
+	defer shareSignSecretMu.Unlock()
+
+	secret, err := generateShareSignSecret()
+	if err != nil {
+		return err
+	}
+	return SetSetting(shareSignSecretKey, secret)
+}
+
+// Sign 计算签名
+func (p ShareSignature) Sign() (string, error) {
+	secret, err := getShareSignSecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(p.payload()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify 校验签名、有效期与 IP 绑定
+func (p ShareSignature) Verify(signature, clientIP string, now time.Time) error {
+	expected, err := p.Sign()
+	if err != nil {
+		return err
+	}
+	if signature == "" || !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrShareSignatureInvalid
+	}
+	if now.Unix() > p.ExpiresAt {
+		return ErrShareSignatureExpired
+	}
+	if p.IP != "" && p.IP != clientIP {
+		return ErrShareSignatureIPMismatch
+	}
+	return nil
+}
+
+// RequireSignedAccess 将分享切换为仅允许签名链接访问 (Write-Through)
+// 签名链接中带有分享 token，签发后若仍允许普通链接，去掉签名参数即可永久访问。
+func (s *SubscriptionShare) RequireSignedAccess() error {
+	if s.SignedOnly {
+		return nil
+	}
+	if err := database.DB.Model(&SubscriptionShare{}).Where("id = ?", s.ID).Update("signed_only", true).Error; err != nil {
+		return err
+	}
+	s.SignedOnly = true
+	if cached, ok := subscriptionShareCache.Get(s.ID); ok {
+		cached.SignedOnly = true
+		subscriptionShareCache.Set(cached.ID, cached)
+	}
+	return nil
+}
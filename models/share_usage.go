@@ -0,0 +1,87 @@
+package models
+
+// ShareUsage 分享的用量信息，用于 subscription-userinfo 头与用量统计
+type ShareUsage struct {
+	ShareID    int     `json:"share_id"`
+	Name       string  `json:"name"`
+	Ratio      float64 `json:"ratio"`       // 实际分摊比例（百分比）
+	Upload     int64   `json:"upload"`      // 分摊后的已上传流量（字节）
+	Download   int64   `json:"download"`    // 分摊后的已下载流量（字节）
+	Total      int64   `json:"total"`       // 报告的总配额（字节）
+	Expire     int64   `json:"expire"`      // 报告的到期时间（Unix时间戳）
+	QuotaBytes int64   `json:"quota_bytes"` // 分享设置的虚拟配额，0 表示按比例分摊上游配额
+	Exceeded   bool    `json:"exceeded"`    // 已用流量是否超出配额
+}
+
+// HasUsageQuota 分享是否启用了独立的用量统计
+func (s *SubscriptionShare) HasUsageQuota() bool {
+	return s.QuotaBytes > 0 || s.UsageRatio > 0 || s.QuotaExpireAt != nil
+}
+
+// EffectiveUsageRatio 返回分享实际分摊上游用量的百分比
+// 显式设置的比例直接生效；未设置比例的分享均分同一订阅中剩余的比例。
+func (s *SubscriptionShare) EffectiveUsageRatio() float64 {
+	if s.UsageRatio > 0 {
+		return float64(min(s.UsageRatio, 100))
+	}
+
+	explicit := 0
+	auto := 0
+	for _, sibling := range GetSharesBySubscriptionID(s.SubscriptionID) {
+		if sibling.ID == s.ID {
+			sibling = *s
+		}
+		if !sibling.Enabled || !sibling.HasUsageQuota() {
+			continue
+		}
+		if sibling.UsageRatio > 0 {
+			explicit += sibling.UsageRatio
+		} else {
+			auto++
+		}
+	}
+	// 当前分享未保存或已停用时仍按一份计算
+	if !s.Enabled || auto == 0 {
+		auto++
+	}
+	remaining := max(100-explicit, 0)
+	return float64(remaining) / float64(auto)
+}
+
+// OtherUsageRatioTotal 返回同一订阅下除 excludeID 外各分享显式设置的分摊比例之和
+// 停用的分享也计入，避免重新启用后比例之和超过 100。
+func OtherUsageRatioTotal(subscriptionID, excludeID int) int {
+	total := 0
+	for _, sibling := range GetSharesBySubscriptionID(subscriptionID) {
+		if sibling.ID != excludeID {
+			total += sibling.UsageRatio
+		}
+	}
+	return total
+}
+
+// CalculateUsage 将上游机场用量按分享比例换算为分享自身的用量
+// 设置了虚拟配额时报告该配额，否则按比例分摊上游配额；到期时间依次取用量到期时间、分享过期时间、上游到期时间。
+func (s *SubscriptionShare) CalculateUsage(upload, download, total, expire int64) ShareUsage {
+	ratio := s.EffectiveUsageRatio()
+	usage := ShareUsage{
+		ShareID:    s.ID,
+		Name:       s.Name,
+		Ratio:      ratio,
+		Upload:     int64(float64(upload) * ratio / 100),
+		Download:   int64(float64(download) * ratio / 100),
+		Total:      int64(float64(total) * ratio / 100),
+		Expire:     expire,
+		QuotaBytes: s.QuotaBytes,
+	}
+	if s.QuotaBytes > 0 {
+		usage.Total = s.QuotaBytes
+	}
+	if s.QuotaExpireAt != nil {
+		usage.Expire = s.QuotaExpireAt.Unix()
+	} else if expireAt, ok := s.ExpireTime(); ok {
+		usage.Expire = expireAt.Unix()
+	}
+	usage.Exceeded = usage.Total > 0 && usage.Upload+usage.Download >= usage.Total
+	return usage
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareCalculateUsageSplitsUpstreamByRatio(t *testing.T) {
+	setupSubscriptionShareTestDB(t)
+
+	quotaExpire := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	shares := []*SubscriptionShare{
+		{SubscriptionID: 7, Name: "explicit", Enabled: true, UsageRatio: 50},
+		{SubscriptionID: 7, Name: "quota", Enabled: true, QuotaBytes: 300},
+		{SubscriptionID: 7, Name: "expire", Enabled: true, QuotaExpireAt: &quotaExpire},
+		{SubscriptionID: 7, Name: "plain", Enabled: true},
+	}
+	for _, share := range shares {
+		if err := share.Add(); err != nil {
+			t.Fatalf("add share %s: %v", share.Name, err)
+		}
+	}
+
+	explicit := shares[0].CalculateUsage(100, 200, 1000, 1700000000)
+	if explicit.Ratio != 50 || explicit.Upload != 50 || explicit.Download != 100 || explicit.Total != 500 || explicit.Expire != 1700000000 {
+		t.Fatalf("unexpected explicit share usage: %+v", explicit)
+	}
+
+	// 未设置比例的两个分享均分剩余的 50%
+	quota := shares[1].CalculateUsage(100, 200, 1000, 1700000000)
+	if quota.Ratio != 25 || quota.Upload != 25 || quota.Download != 50 || quota.Total != 300 || quota.Exceeded {
+		t.Fatalf("unexpected quota share usage: %+v", quota)
+	}
+	expire := shares[2].CalculateUsage(100, 200, 1000, 1700000000)
+	if expire.Ratio != 25 || expire.Total != 250 || expire.Expire != quotaExpire.Unix() {
+		t.Fatalf("unexpected expire share usage: %+v", expire)
+	}
+
+	if shares[3].HasUsageQuota() {
+		t.Fatal("expected share without quota settings to report upstream usage")
+	}
+
+	exceeded := shares[1].CalculateUsage(800, 800, 1000, 0)
+	if !exceeded.Exceeded {
+		t.Fatalf("expected quota to be exceeded: %+v", exceeded)
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -9,9 +10,12 @@ import (
 	"sublink/database"
 	"sublink/dto"
 	"sublink/node/protocol"
+	"sublink/services/metrics"
+	"sublink/services/tracing"
 	"sublink/utils"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -671,6 +675,17 @@ func (sub *Subcription) ApplyFilters(nodes []Node) []Node {
 
 // 读取订阅
 func (sub *Subcription) GetSub(clientType string) error {
+	return sub.GetSubWithContext(context.Background(), clientType)
+}
+
+// GetSubWithContext 读取订阅，ctx 用于关联链路追踪
+func (sub *Subcription) GetSubWithContext(ctx context.Context, clientType string) (err error) {
+	ctx, span := tracing.Start(ctx, "subscription.get_sub",
+		attribute.Int("sublink.subscription.id", sub.ID),
+		attribute.String("sublink.client", clientType),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// 定义节点排序项结构
 	type NodeSortItem struct {
 		Node
@@ -679,7 +694,7 @@ func (sub *Subcription) GetSub(clientType string) error {
 
 	// 获取直接选择的节点及其排序
 	var directNodeItems []NodeSortItem
-	err := database.DB.Table("nodes").
+	err = database.DB.Table("nodes").
 		Select("nodes.*, subcription_nodes.sort").
 		Joins("left join subcription_nodes ON subcription_nodes.node_id = nodes.id").
 		Where("subcription_nodes.subcription_id = ?", sub.ID).
@@ -840,7 +855,13 @@ func (sub *Subcription) GetSub(clientType string) error {
 	sub.ScriptsWithSort = scriptsWithSort
 
 	// 执行节点过滤脚本
+	_, scriptSpan := tracing.Start(ctx, "subscription.filter_scripts",
+		attribute.Int("sublink.scripts", len(scriptsWithSort)),
+		attribute.Int("sublink.nodes.before", len(sub.Nodes)),
+	)
 	sub.Nodes = sub.ApplyNodeFilterScripts(sub.Nodes, scriptsWithSort, clientType)
+	scriptSpan.SetAttributes(attribute.Int("sublink.nodes.after", len(sub.Nodes)))
+	scriptSpan.End()
 
 	return nil
 }
@@ -1574,7 +1595,9 @@ func (sub *Subcription) ApplyNodeFilterScripts(nodes []Node, scripts []ScriptWit
 			if strings.Contains(err.Error(), "filterNode function not found") {
 				continue
 			}
-			utils.Error("节点过滤脚本执行失败: %v", err)
+			utils.WithSubsystem(utils.SubsystemScript).With("script_id", script.ID).With("subscription", sub.Name).
+				Error("节点过滤脚本【%s】执行失败: %v", script.Name, err)
+			metrics.IncScriptError(metrics.ScriptKindNodeFilter)
 			continue
 		}
 		var newNodes []Node
@@ -25,19 +25,45 @@ const (
 
 // SubscriptionShare 订阅分享表
 type SubscriptionShare struct {
-	ID             int        `gorm:"primaryKey" json:"id"`
-	SubscriptionID int        `gorm:"index" json:"subscription_id"`     // 关联订阅ID
-	Token          string     `gorm:"uniqueIndex;size:64" json:"token"` // 分享token（支持自定义或自动生成）
-	Name           string     `gorm:"size:100" json:"name"`             // 分享名称/备注
-	ExpireType     int        `gorm:"default:0" json:"expire_type"`     // 过期类型
-	ExpireDays     int        `gorm:"default:0" json:"expire_days"`     // 过期天数
-	ExpireAt       *time.Time `json:"expire_at"`                        // 过期时间
-	IsLegacy       bool       `gorm:"default:false" json:"is_legacy"`   // 是否为迁移的老链接
-	Enabled        bool       `gorm:"default:true" json:"enabled"`      // 是否启用
-	AccessCount    int        `gorm:"default:0" json:"access_count"`    // 访问次数
-	LastAccessAt   *time.Time `json:"last_access_at"`                   // 最后访问时间
-	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	ID              int        `gorm:"primaryKey" json:"id"`
+	SubscriptionID  int        `gorm:"index" json:"subscription_id"`     // 关联订阅ID
+	Token           string     `gorm:"uniqueIndex;size:64" json:"token"` // 分享token（支持自定义或自动生成）
+	Name            string     `gorm:"size:100" json:"name"`             // 分享名称/备注
+	ExpireType      int        `gorm:"default:0" json:"expire_type"`     // 过期类型
+	ExpireDays      int        `gorm:"default:0" json:"expire_days"`     // 过期天数
+	ExpireAt        *time.Time `json:"expire_at"`                        // 过期时间
+	IsLegacy        bool       `gorm:"default:false" json:"is_legacy"`   // 是否为迁移的老链接
+	Enabled         bool       `gorm:"default:true" json:"enabled"`      // 是否启用
+	AccessCount     int        `gorm:"default:0" json:"access_count"`    // 访问次数
+	LastAccessAt    *time.Time `json:"last_access_at"`                   // 最后访问时间
+	LimitWindow     int        `gorm:"default:0" json:"limit_window"`    // 访问限制统计窗口（分钟），0 表示默认 60 分钟
+	MaxRequests     int        `gorm:"default:0" json:"max_requests"`    // 窗口内最大访问次数，0 表示不限制
+	MaxIPs          int        `gorm:"default:0" json:"max_ips"`         // 窗口内最多不同 IP 数，0 表示不限制
+	AbuseDisabledAt *time.Time `json:"abuse_disabled_at"`                // 超出访问限制被自动停用的时间
+	AbuseReason     string     `gorm:"size:255" json:"abuse_reason"`     // 自动停用原因
+	SignedOnly      bool       `gorm:"default:false" json:"signed_only"` // 仅允许通过签名链接访问
+	MaxDevices      int        `gorm:"default:0" json:"max_devices"`     // 绑定的最大设备数，0 表示不绑定设备
+
+	// 分享级覆盖：在订阅过滤结果上叠加过滤条件，并可替换输出格式与模板
+	CountryWhitelist  string `gorm:"size:255" json:"country_whitelist"`  // 国家白名单（逗号分隔）
+	CountryBlacklist  string `gorm:"size:255" json:"country_blacklist"`  // 国家黑名单（逗号分隔）
+	TagWhitelist      string `gorm:"size:255" json:"tag_whitelist"`      // 标签白名单（逗号分隔）
+	TagBlacklist      string `gorm:"size:255" json:"tag_blacklist"`      // 标签黑名单（逗号分隔）
+	ProtocolWhitelist string `gorm:"size:255" json:"protocol_whitelist"` // 协议白名单（逗号分隔）
+	ProtocolBlacklist string `gorm:"size:255" json:"protocol_blacklist"` // 协议黑名单（逗号分隔）
+	MaxNodes          int    `gorm:"default:0" json:"max_nodes"`         // 最多输出的节点数，0 表示不限制
+	ForceClient       string `gorm:"size:32" json:"force_client"`        // 强制输出的客户端类型，为空时自动识别
+	ClashTemplate     string `gorm:"size:255" json:"clash_template"`     // 替换订阅的 Clash 模板
+	SurgeTemplate     string `gorm:"size:255" json:"surge_template"`     // 替换订阅的 Surge 模板
+	FallbackMessage   string `gorm:"size:255" json:"fallback_message"`   // 过期、停用等情况下占位节点显示的提示
+
+	// 分享级用量：按比例分摊上游机场用量，并向客户端报告虚拟配额
+	QuotaBytes    int64      `gorm:"default:0" json:"quota_bytes"` // 虚拟流量配额（字节），0 表示按比例分摊上游配额
+	QuotaExpireAt *time.Time `json:"quota_expire_at"`              // 向客户端报告的到期时间，为空时使用分享过期时间
+	UsageRatio    int        `gorm:"default:0" json:"usage_ratio"` // 分摊上游用量的百分比，0 表示与其他分享均分剩余比例
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // subscriptionShareCache 使用泛型缓存
@@ -98,6 +124,8 @@ func (s *SubscriptionShare) normalizeOptionalFields() {
 		s.ExpireAt = normalizeOptionalTime(s.ExpireAt)
 	}
 
+	s.QuotaExpireAt = normalizeOptionalTime(s.QuotaExpireAt)
+
 	if s.AccessCount <= 0 {
 		s.LastAccessAt = nil
 	} else {
@@ -202,6 +230,7 @@ func (s *SubscriptionShare) Add() error {
 		return err
 	}
 	subscriptionShareCache.Set(s.ID, *s)
+	clearShareAccessCounter(s.ID)
 	return nil
 }
 
@@ -214,17 +243,48 @@ func (s *SubscriptionShare) Update() error {
 		return fmt.Errorf("token 已被使用，请更换")
 	}
 
+	// 重新启用后清除自动停用记录，并从新的窗口开始计数
+	reenabled := s.Enabled && s.AbuseDisabledAt != nil
+	if reenabled {
+		s.AbuseDisabledAt = nil
+		s.AbuseReason = ""
+	}
+
 	err := database.DB.Model(s).Updates(map[string]any{
-		"name":        s.Name,
-		"token":       s.Token,
-		"expire_type": s.ExpireType,
-		"expire_days": s.ExpireDays,
-		"expire_at":   s.ExpireAt,
-		"enabled":     s.Enabled,
+		"name":               s.Name,
+		"token":              s.Token,
+		"expire_type":        s.ExpireType,
+		"expire_days":        s.ExpireDays,
+		"expire_at":          s.ExpireAt,
+		"enabled":            s.Enabled,
+		"limit_window":       s.LimitWindow,
+		"max_requests":       s.MaxRequests,
+		"max_ips":            s.MaxIPs,
+		"abuse_disabled_at":  s.AbuseDisabledAt,
+		"abuse_reason":       s.AbuseReason,
+		"signed_only":        s.SignedOnly,
+		"max_devices":        s.MaxDevices,
+		"country_whitelist":  s.CountryWhitelist,
+		"country_blacklist":  s.CountryBlacklist,
+		"tag_whitelist":      s.TagWhitelist,
+		"tag_blacklist":      s.TagBlacklist,
+		"protocol_whitelist": s.ProtocolWhitelist,
+		"protocol_blacklist": s.ProtocolBlacklist,
+		"max_nodes":          s.MaxNodes,
+		"force_client":       s.ForceClient,
+		"clash_template":     s.ClashTemplate,
+		"surge_template":     s.SurgeTemplate,
+		"fallback_message":   s.FallbackMessage,
+		"quota_bytes":        s.QuotaBytes,
+		"quota_expire_at":    s.QuotaExpireAt,
+		"usage_ratio":        s.UsageRatio,
 	}).Error
 	if err != nil {
 		return err
 	}
+	if reenabled {
+		ResetShareAccessCounter(s.ID)
+	}
 
 	// 更新缓存
 	var updated SubscriptionShare
@@ -241,6 +301,15 @@ func (s *SubscriptionShare) Delete() error {
 		return err
 	}
 	subscriptionShareCache.Delete(s.ID)
+	if err := DeleteShareAccessWindows(s.ID); err != nil {
+		utils.Warn("删除分享访问统计失败: %v", err)
+	}
+	if _, err := ResetShareDevices(s.ID, 0); err != nil {
+		utils.Warn("删除分享绑定设备失败: %v", err)
+	}
+	if err := DeleteShareAnalytics(s.ID); err != nil {
+		utils.Warn("删除分享访问统计明细失败: %v", err)
+	}
 	return nil
 }
 
@@ -422,23 +491,28 @@ func (s *SubscriptionShare) IsExpired() bool {
 	if !s.Enabled {
 		return true
 	}
+	expireTime, ok := s.ExpireTime()
+	if !ok {
+		return false
+	}
+	return time.Now().After(expireTime)
+}
 
+// ExpireTime 返回分享的实际过期时间，永不过期时第二个返回值为 false
+func (s *SubscriptionShare) ExpireTime() (time.Time, bool) {
 	switch s.ExpireType {
-	case ExpireTypeNever:
-		return false
 	case ExpireTypeDays:
 		if s.ExpireDays <= 0 {
-			return false
+			return time.Time{}, false
 		}
-		expireTime := s.CreatedAt.AddDate(0, 0, s.ExpireDays)
-		return time.Now().After(expireTime)
+		return s.CreatedAt.AddDate(0, 0, s.ExpireDays), true
 	case ExpireTypeDateTime:
 		if s.ExpireAt == nil || s.ExpireAt.IsZero() {
-			return false
+			return time.Time{}, false
 		}
-		return time.Now().After(*s.ExpireAt)
+		return *s.ExpireAt, true
 	default:
-		return false
+		return time.Time{}, false
 	}
 }
 
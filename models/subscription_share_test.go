@@ -245,3 +245,35 @@ func assertShareNames(t *testing.T, shares []SubscriptionShare, expected []strin
 		}
 	}
 }
+
+func TestSubscriptionShareDisableForAbuseOnlyOnce(t *testing.T) {
+	setupSubscriptionShareTestDB(t)
+
+	share := &SubscriptionShare{SubscriptionID: 1, Name: "limited", Enabled: true, MaxRequests: 1}
+	if err := share.Add(); err != nil {
+		t.Fatalf("add share: %v", err)
+	}
+	first, second := *share, *share
+
+	disabled, err := first.DisableForAbuse("too many requests")
+	if err != nil || !disabled {
+		t.Fatalf("expected first call to disable share, got %v %v", disabled, err)
+	}
+	disabled, err = second.DisableForAbuse("too many requests")
+	if err != nil || disabled {
+		t.Fatalf("expected second call to be a no-op, got %v %v", disabled, err)
+	}
+}
+
+func TestSubscriptionShareCheckAccessLimitSkipsUnlimitedShares(t *testing.T) {
+	share := &SubscriptionShare{ID: 987654}
+	if reason := share.CheckAccessLimit("203.0.113.1", time.Now()); reason != "" {
+		t.Fatalf("expected no limit reason, got %q", reason)
+	}
+	shareAccessCountersMu.Lock()
+	_, tracked := shareAccessCounters[share.ID]
+	shareAccessCountersMu.Unlock()
+	if tracked {
+		t.Fatal("expected no counter for share without limits")
+	}
+}
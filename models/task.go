@@ -26,10 +26,12 @@ const (
 type TaskType string
 
 const (
-	TaskTypeSpeedTest         TaskType = "speed_test"   // 节点测速
-	TaskTypeSubUpdate         TaskType = "sub_update"   // 订阅更新
-	TaskTypeTagRule           TaskType = "tag_rule"     // 标签规则
-	TaskTypeDatabaseMigration TaskType = "db_migration" // 数据库迁移
+	TaskTypeSpeedTest         TaskType = "speed_test"     // 节点测速
+	TaskTypeSubUpdate         TaskType = "sub_update"     // 订阅更新
+	TaskTypeTagRule           TaskType = "tag_rule"       // 标签规则
+	TaskTypeDatabaseMigration TaskType = "db_migration"   // 数据库迁移
+	TaskTypeBackupRestore     TaskType = "backup_restore" // 备份恢复
+	TaskTypeDatabaseCopy      TaskType = "db_copy"        // 跨数据库迁移
 )
 
 // TaskTrigger 任务触发方式
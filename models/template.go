@@ -4,9 +4,11 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sublink/cache"
 	"sublink/database"
+	"sublink/node/protocol"
 	"sublink/utils"
 	"time"
 
@@ -41,6 +43,44 @@ func InferTemplateCategory(fileName string) string {
 	return "clash"
 }
 
+// BuildTemplateNodeStats 汇总节点统计信息，供模板以 .Nodes 引用
+func BuildTemplateNodeStats(nodes []Node) protocol.TemplateNodeStats {
+	stats := protocol.TemplateNodeStats{
+		Count:          len(nodes),
+		CountryCounts:  make(map[string]int),
+		ProtocolCounts: make(map[string]int),
+	}
+	groupSet := make(map[string]bool)
+	tagSet := make(map[string]bool)
+	for i := range nodes {
+		if country := strings.TrimSpace(nodes[i].LinkCountry); country != "" {
+			stats.CountryCounts[country]++
+		}
+		if proto := strings.TrimSpace(nodes[i].Protocol); proto != "" {
+			stats.ProtocolCounts[proto]++
+		}
+		if group := strings.TrimSpace(nodes[i].Group); group != "" && !groupSet[group] {
+			groupSet[group] = true
+			stats.Groups = append(stats.Groups, group)
+		}
+		for _, tag := range nodes[i].GetTagNames() {
+			if !tagSet[tag] {
+				tagSet[tag] = true
+				stats.Tags = append(stats.Tags, tag)
+			}
+		}
+	}
+	for country := range stats.CountryCounts {
+		stats.Countries = append(stats.Countries, country)
+	}
+	for proto := range stats.ProtocolCounts {
+		stats.Protocols = append(stats.Protocols, proto)
+	}
+	sort.Strings(stats.Countries)
+	sort.Strings(stats.Protocols)
+	return stats
+}
+
 // InitTemplateCache 初始化模板缓存
 func InitTemplateCache() error {
 	utils.Info("开始加载模板到缓存")
@@ -29,6 +29,7 @@ type User struct {
 	TOTPPendingSecret        string
 	TOTPPendingRecoveryCodes string `gorm:"type:text"`
 	TOTPRecoveryCodes        string `gorm:"type:text"`
+	OIDCSubject              string `gorm:"size:255;index"` // OIDC 身份标识（issuer|sub），用于单点登录关联
 }
 
 type MFALoginChallenge struct {
@@ -40,6 +41,7 @@ type MFALoginChallenge struct {
 	ConsumedAt   int64
 	AttemptCount int
 	MaxAttempts  int
+	SessionData  string `gorm:"type:text"` // 通行密钥挑战的会话数据
 }
 
 // userCache 使用新的泛型缓存
@@ -48,6 +50,7 @@ var userCache *cache.MapCache[int, User]
 func init() {
 	userCache = cache.NewMapCache(func(u User) int { return u.ID })
 	userCache.AddIndex("username", func(u User) string { return u.Username })
+	userCache.AddIndex("oidcSubject", func(u User) string { return u.OIDCSubject })
 }
 
 // InitUserCache 初始化用户缓存
@@ -110,6 +113,12 @@ func (user *User) Set(UpdateUser *User) error {
 	if err := database.DB.Where("username = ?", user.Username).First(&updated).Error; err == nil {
 		userCache.Set(updated.ID, updated)
 	}
+	// 密码变更后注销全部登录会话
+	if UpdateUser.Password != "" && user.ID != 0 {
+		if _, err := RevokeUserSessions(user.ID, ""); err != nil {
+			utils.Warn("注销用户登录会话失败: %v", err)
+		}
+	}
 	return nil
 }
 
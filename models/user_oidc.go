@@ -0,0 +1,145 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sublink/database"
+	"sublink/utils"
+)
+
+// ErrOIDCUserConflict 本地已存在同名用户，需要登录后在个人设置中手动关联
+var ErrOIDCUserConflict = errors.New("本地已存在同名用户，请使用该账号登录后在个人设置中关联单点登录")
+
+// ErrOIDCSubjectLinked 单点登录身份已关联其他本地用户
+var ErrOIDCSubjectLinked = errors.New("该单点登录身份已关联其他用户")
+
+// ErrOIDCNoRole 用户组未映射到任何角色且未配置默认角色
+var ErrOIDCNoRole = errors.New("当前账号未被授权访问")
+
+// OIDCIdentity OIDC 登录得到的用户身份
+type OIDCIdentity struct {
+	Subject  string // issuer|sub
+	Username string
+	Nickname string
+	Role     string // 由用户组映射或默认角色得到的角色，为空表示未授权
+	SyncRole bool   // 配置了用户组映射时，每次登录都按映射结果更新角色
+}
+
+// GetUserByOIDCSubject 根据 OIDC 身份标识查找用户
+func GetUserByOIDCSubject(subject string) (User, bool) {
+	if subject == "" {
+		return User{}, false
+	}
+	users := userCache.GetByIndex("oidcSubject", subject)
+	if len(users) == 0 {
+		return User{}, false
+	}
+	return users[0], true
+}
+
+// LoginOIDCUser 根据 OIDC 身份查找或创建本地用户 (Write-Through)
+// 只按 issuer|sub 匹配已关联的用户；用户名和邮箱可以在身份提供方修改，不能作为关联依据。
+// 首次登录自动创建用户，密码为随机值，只能通过单点登录进入。
+func LoginOIDCUser(identity OIDCIdentity) (*User, error) {
+	if user, ok := GetUserByOIDCSubject(identity.Subject); ok {
+		return &user, syncOIDCRole(&user, identity)
+	}
+
+	existing := &User{Username: identity.Username}
+	if err := existing.Find(); err == nil {
+		return nil, ErrOIDCUserConflict
+	}
+
+	if identity.Role == "" {
+		return nil, ErrOIDCNoRole
+	}
+	password, err := randomOIDCPassword()
+	if err != nil {
+		return nil, err
+	}
+	user := &User{
+		Username:    identity.Username,
+		Password:    password,
+		Nickname:    identity.Nickname,
+		Role:        identity.Role,
+		OIDCSubject: identity.Subject,
+	}
+	if user.Nickname == "" {
+		user.Nickname = user.Username
+	}
+	if err := user.Create(); err != nil {
+		return nil, err
+	}
+	utils.Info("已通过单点登录创建用户 %s，角色 %s", user.Username, user.Role)
+	return user, nil
+}
+
+// syncOIDCRole 按用户组映射同步角色
+// 未配置映射时保留本地角色；最后一个管理员不会被降级。
+func syncOIDCRole(user *User, identity OIDCIdentity) error {
+	if !identity.SyncRole {
+		return nil
+	}
+	if identity.Role == "" {
+		return ErrOIDCNoRole
+	}
+	if user.EffectiveRole() == identity.Role {
+		return nil
+	}
+	if err := user.SetRole(identity.Role); err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			utils.Warn("单点登录未降级用户 %s: %v", user.Username, err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// LinkOIDCSubject 将已登录的本地用户与 OIDC 身份关联 (Write-Through)
+// 只能由用户本人在登录状态下发起，同一身份不能关联多个用户。
+func (user *User) LinkOIDCSubject(subject string) error {
+	if linked, ok := GetUserByOIDCSubject(subject); ok && linked.ID != user.ID {
+		return ErrOIDCSubjectLinked
+	}
+	if err := database.DB.Model(&User{}).Where("id = ?", user.ID).Update("OIDCSubject", subject).Error; err != nil {
+		return err
+	}
+	user.OIDCSubject = subject
+	userCache.Set(user.ID, *user)
+	utils.Info("用户 %s 已关联单点登录身份", user.Username)
+	return nil
+}
+
+// ResolveOIDCRole 根据用户组映射解析角色，多个用户组命中时取最高角色
+// 没有命中时使用默认角色，默认角色为空时返回空字符串。
+func ResolveOIDCRole(groups []string, mapping map[string]string, defaultRole string) string {
+	role := ""
+	for _, group := range groups {
+		mapped := strings.ToLower(strings.TrimSpace(mapping[group]))
+		if !IsValidRole(mapped) {
+			continue
+		}
+		if role == "" || RoleAtLeast(mapped, role) {
+			role = mapped
+		}
+	}
+	if role != "" {
+		return role
+	}
+	defaultRole = strings.ToLower(strings.TrimSpace(defaultRole))
+	if IsValidRole(defaultRole) {
+		return defaultRole
+	}
+	return ""
+}
+
+func randomOIDCPassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
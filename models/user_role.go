@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"sublink/database"
+)
+
+// 用户角色
+const (
+	RoleAdmin    = "admin"    // 管理员：全部权限，包括删除、系统设置与用户管理
+	RoleOperator = "operator" // 运维：可编辑节点、订阅等数据并执行任务，不能删除
+	RoleViewer   = "viewer"   // 只读：仅可查看
+)
+
+// roleLevels 角色权限等级，高等级包含低等级的全部权限
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ErrLastAdmin 操作会导致系统中没有管理员
+var ErrLastAdmin = errors.New("至少需要保留一个管理员")
+
+// IsValidRole 是否为合法角色
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAtLeast 判断角色是否具备目标角色的权限
+func RoleAtLeast(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
+}
+
+// EffectiveRole 返回用户的实际角色
+// 旧版本只有单个用户且可能未写入角色，空角色按管理员处理。
+func (user *User) EffectiveRole() string {
+	role := strings.ToLower(strings.TrimSpace(user.Role))
+	if role == "" {
+		return RoleAdmin
+	}
+	if !IsValidRole(role) {
+		return RoleViewer
+	}
+	return role
+}
+
+// IsAdmin 是否为管理员
+func (user *User) IsAdmin() bool {
+	return user.EffectiveRole() == RoleAdmin
+}
+
+// GetUserRole 根据用户名获取角色
+func GetUserRole(username string) (string, bool) {
+	user := &User{Username: username}
+	if err := user.Find(); err != nil {
+		return "", false
+	}
+	return user.EffectiveRole(), true
+}
+
+// GetUserByID 根据 ID 获取用户
+func GetUserByID(id int) (User, bool) {
+	return userCache.Get(id)
+}
+
+// countAdmins 统计管理员数量
+func countAdmins() int {
+	count := 0
+	for _, user := range userCache.GetAll() {
+		if user.IsAdmin() {
+			count++
+		}
+	}
+	return count
+}
+
+// SetRole 修改用户角色 (Write-Through)
+// 不允许把最后一个管理员降级。
+func (user *User) SetRole(role string) error {
+	if !IsValidRole(role) {
+		return errors.New("无效的角色")
+	}
+	if user.IsAdmin() && role != RoleAdmin && countAdmins() <= 1 {
+		return ErrLastAdmin
+	}
+	if err := database.DB.Model(&User{}).Where("id = ?", user.ID).Update("role", role).Error; err != nil {
+		return err
+	}
+	user.Role = role
+	userCache.Set(user.ID, *user)
+	return nil
+}
+
+// DeleteUser 删除用户及其 AccessKey、通行密钥与登录会话 (Write-Through)
+// 不允许删除最后一个管理员。
+func (user *User) DeleteUser() error {
+	if user.IsAdmin() && countAdmins() <= 1 {
+		return ErrLastAdmin
+	}
+	if err := DeleteAccessKeysByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := DeleteWebAuthnCredentialsByUserID(user.ID); err != nil {
+		return err
+	}
+	if _, err := RevokeUserSessions(user.ID, ""); err != nil {
+		return err
+	}
+	return user.Del()
+}
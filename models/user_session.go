@@ -0,0 +1,189 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sublink/cache"
+	"sublink/database"
+	"sublink/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userSessionTouchInterval 最近活跃时间的最小写库间隔，避免每个请求都写数据库
+const userSessionTouchInterval = time.Minute
+
+// UserSession 登录会话，每次登录签发的 JWT 对应一条记录（通过 jti 关联）
+// 会话被删除后对应的令牌立即失效，用于单个会话注销与“退出所有设备”。
+type UserSession struct {
+	ID         int       `json:"id"`
+	SessionID  string    `gorm:"uniqueIndex;size:64" json:"-"` // JWT 的 jti
+	UserID     int       `gorm:"index" json:"-"`
+	Username   string    `gorm:"size:255" json:"-"`
+	ClientIP   string    `gorm:"size:64" json:"clientIp"`
+	UserAgent  string    `gorm:"size:512" json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `gorm:"index" json:"expiresAt"`
+}
+
+// userSessionCache 会话缓存，按 jti 索引，鉴权时无需查询数据库
+var userSessionCache *cache.MapCache[string, UserSession]
+
+func init() {
+	userSessionCache = cache.NewMapCache(func(s UserSession) string { return s.SessionID })
+	userSessionCache.AddIndex("userID", func(s UserSession) string { return strconv.Itoa(s.UserID) })
+}
+
+// InitUserSessionCache 初始化会话缓存，同时清理已过期的会话
+func InitUserSessionCache() error {
+	utils.Info("开始加载登录会话到缓存")
+	if err := database.DB.Where("expires_at < ?", time.Now()).Delete(&UserSession{}).Error; err != nil {
+		return err
+	}
+	var sessions []UserSession
+	if err := database.DB.Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	userSessionCache.LoadAll(sessions)
+	utils.Info("登录会话缓存初始化完成，共加载 %d 个会话", userSessionCache.Count())
+
+	cache.Manager.Register("userSession", userSessionCache)
+	return nil
+}
+
+// CreateUserSession 为用户创建新的登录会话 (Write-Through)
+// 创建时顺带清理该用户已过期的会话。
+func CreateUserSession(user *User, clientIP, userAgent string, expiresAt time.Time) (*UserSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &UserSession{
+		SessionID:  hex.EncodeToString(buf),
+		UserID:     user.ID,
+		Username:   user.Username,
+		ClientIP:   clientIP,
+		UserAgent:  truncateSessionField(userAgent, 512),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := database.DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	userSessionCache.Set(session.SessionID, *session)
+
+	for _, expired := range userSessionCache.GetByIndex("userID", strconv.Itoa(user.ID)) {
+		if expired.ExpiresAt.Before(now) {
+			if err := deleteUserSessions(database.DB.Where("id = ?", expired.ID), []UserSession{expired}); err != nil {
+				utils.Warn("清理过期登录会话失败: %v", err)
+			}
+		}
+	}
+	return session, nil
+}
+
+// ValidateUserSession 校验会话是否存在、未过期且属于该用户，并按间隔刷新最近活跃信息
+func ValidateUserSession(sessionID, username, clientIP string) bool {
+	if sessionID == "" {
+		return false
+	}
+	session, ok := userSessionCache.Get(sessionID)
+	if !ok || session.Username != username {
+		return false
+	}
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		return false
+	}
+	if now.Sub(session.LastSeenAt) >= userSessionTouchInterval || session.ClientIP != clientIP {
+		if err := database.DB.Model(&UserSession{}).Where("id = ?", session.ID).Updates(map[string]any{
+			"last_seen_at": now,
+			"client_ip":    clientIP,
+		}).Error; err != nil {
+			utils.Warn("更新登录会话活跃时间失败: %v", err)
+			return true
+		}
+		session.LastSeenAt = now
+		session.ClientIP = clientIP
+		userSessionCache.Set(session.SessionID, session)
+	}
+	return true
+}
+
+// GetUserSession 根据 jti 获取会话
+func GetUserSession(sessionID string) (UserSession, bool) {
+	return userSessionCache.Get(sessionID)
+}
+
+// ListUserSessions 获取用户未过期的会话，按最近活跃时间倒序
+func ListUserSessions(userID int) []UserSession {
+	now := time.Now()
+	return userSessionCache.FilterSorted(func(s UserSession) bool {
+		return s.UserID == userID && s.ExpiresAt.After(now)
+	}, func(a, b UserSession) bool {
+		return a.LastSeenAt.After(b.LastSeenAt)
+	})
+}
+
+// RevokeUserSession 注销用户的指定会话 (Write-Through)
+func RevokeUserSession(userID, id int) (bool, error) {
+	for _, session := range userSessionCache.GetByIndex("userID", strconv.Itoa(userID)) {
+		if session.ID == id {
+			return true, deleteUserSessions(database.DB.Where("id = ?", id), []UserSession{session})
+		}
+	}
+	return false, nil
+}
+
+// RevokeUserSessionByID 根据 jti 注销会话，用于退出登录
+func RevokeUserSessionByID(sessionID string) error {
+	session, ok := userSessionCache.Get(sessionID)
+	if !ok {
+		return nil
+	}
+	return deleteUserSessions(database.DB.Where("id = ?", session.ID), []UserSession{session})
+}
+
+// RevokeUserSessions 注销用户的全部会话，exceptSessionID 不为空时保留该会话 (Write-Through)
+// 修改密码、管理员重置密码、重置 MFA 与删除用户时调用。
+func RevokeUserSessions(userID int, exceptSessionID string) (int, error) {
+	var sessions []UserSession
+	for _, session := range userSessionCache.GetByIndex("userID", strconv.Itoa(userID)) {
+		if session.SessionID != exceptSessionID {
+			sessions = append(sessions, session)
+		}
+	}
+	query := database.DB.Where("user_id = ?", userID)
+	if exceptSessionID != "" {
+		query = query.Where("session_id <> ?", exceptSessionID)
+	}
+	if err := deleteUserSessions(query, sessions); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// deleteUserSessions 删除数据库中的会话并同步移出缓存
+func deleteUserSessions(query *gorm.DB, sessions []UserSession) error {
+	if err := query.Delete(&UserSession{}).Error; err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		userSessionCache.Delete(session.SessionID)
+	}
+	return nil
+}
+
+func truncateSessionField(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
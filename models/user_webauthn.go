@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sublink/database"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// WebAuthnMaxCredentials 每个用户最多可绑定的通行密钥数量
+const WebAuthnMaxCredentials = 10
+
+// ErrWebAuthnCredentialLimit 通行密钥数量已达上限
+var ErrWebAuthnCredentialLimit = errors.New("通行密钥数量已达上限")
+
+// WebAuthnCredential 用户绑定的通行密钥
+type WebAuthnCredential struct {
+	ID           int        `json:"id"`
+	UserID       int        `gorm:"index" json:"-"`
+	Name         string     `gorm:"size:128" json:"name"`
+	CredentialID string     `gorm:"uniqueIndex;size:255" json:"-"` // base64url 编码的凭证 ID
+	Credential   string     `gorm:"type:text" json:"-"`            // webauthn.Credential 的 JSON
+	CreatedAt    time.Time  `json:"createdAt"`
+	LastUsedAt   *time.Time `json:"lastUsedAt"`
+}
+
+// WebAuthnUser 适配 webauthn.User 接口
+type WebAuthnUser struct {
+	User        *User
+	credentials []webauthn.Credential
+}
+
+// WebAuthnID 用户句柄，使用用户 ID，免密码登录时据此找回用户
+func (u *WebAuthnUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(u.User.ID))
+}
+
+func (u *WebAuthnUser) WebAuthnName() string {
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnDisplayName() string {
+	if u.User.Nickname != "" {
+		return u.User.Nickname
+	}
+	return u.User.Username
+}
+
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// LoadWebAuthnUser 加载用户及其全部通行密钥
+func LoadWebAuthnUser(user *User) (*WebAuthnUser, error) {
+	records, err := ListWebAuthnCredentials(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, record := range records {
+		var credential webauthn.Credential
+		if err := json.Unmarshal([]byte(record.Credential), &credential); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return &WebAuthnUser{User: user, credentials: credentials}, nil
+}
+
+// FindUserByWebAuthnHandle 根据用户句柄查找用户
+func FindUserByWebAuthnHandle(handle []byte) (*User, error) {
+	id, err := strconv.Atoi(string(handle))
+	if err != nil {
+		return nil, err
+	}
+	var user User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListWebAuthnCredentials 获取用户的通行密钥列表
+func ListWebAuthnCredentials(userID int) ([]WebAuthnCredential, error) {
+	var records []WebAuthnCredential
+	err := database.DB.Where("user_id = ?", userID).Order("id").Find(&records).Error
+	return records, err
+}
+
+// CountWebAuthnCredentials 统计用户的通行密钥数量
+func CountWebAuthnCredentials(userID int) int {
+	var count int64
+	if err := database.DB.Model(&WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0
+	}
+	return int(count)
+}
+
+// AddWebAuthnCredential 保存新注册的通行密钥
+func AddWebAuthnCredential(userID int, name string, credential *webauthn.Credential) (*WebAuthnCredential, error) {
+	if CountWebAuthnCredentials(userID) >= WebAuthnMaxCredentials {
+		return nil, ErrWebAuthnCredentialLimit
+	}
+	raw, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len([]rune(name)) > 64 {
+		name = string([]rune(name)[:64])
+	}
+	record := &WebAuthnCredential{
+		UserID:       userID,
+		Name:         name,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Credential:   string(raw),
+	}
+	if err := database.DB.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// UpdateWebAuthnCredentialUsage 登录成功后更新签名计数与最近使用时间
+func UpdateWebAuthnCredentialUsage(userID int, credential *webauthn.Credential) error {
+	raw, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return database.DB.Model(&WebAuthnCredential{}).
+		Where("user_id = ? AND credential_id = ?", userID, base64.RawURLEncoding.EncodeToString(credential.ID)).
+		Updates(map[string]any{"credential": string(raw), "last_used_at": &now}).Error
+}
+
+// DeleteWebAuthnCredential 删除用户的一个通行密钥
+func DeleteWebAuthnCredential(userID, id int) error {
+	result := database.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("通行密钥不存在")
+	}
+	return nil
+}
+
+// DeleteWebAuthnCredentialsByUserID 删除用户的全部通行密钥
+func DeleteWebAuthnCredentialsByUserID(userID int) error {
+	return database.DB.Where("user_id = ?", userID).Delete(&WebAuthnCredential{}).Error
+}
+
+// SaveMFAChallengeSession 保存通行密钥挑战的会话数据
+func SaveMFAChallengeSession(challenge *MFALoginChallenge, session string) error {
+	if err := database.DB.Model(&MFALoginChallenge{}).Where("id = ?", challenge.ID).Update("session_data", session).Error; err != nil {
+		return err
+	}
+	challenge.SessionData = session
+	return nil
+}
@@ -63,7 +63,7 @@ func DecodeAnyTLSURL(s string) (AnyTLS, error) {
 	name := u.Fragment
 	host, port, err := net.SplitHostPort(u.Host)
 	if err != nil {
-		fmt.Println("AnyTLS SplitHostPort error", err)
+		utils.Warn("AnyTLS SplitHostPort error: %v", err)
 		return AnyTLS{}, err
 	}
 	anyTLS.Server = host
@@ -73,7 +73,7 @@ func DecodeAnyTLSURL(s string) (AnyTLS, error) {
 	}
 	anyTLS.Port, err = strconv.Atoi(rawPort)
 	if err != nil {
-		fmt.Println("AnyTLS Port conversion failed:", err)
+		utils.Warn("AnyTLS Port conversion failed: %v", err)
 		return AnyTLS{}, err
 	}
 	anyTLS.Password = u.User.Username()
@@ -82,7 +82,7 @@ func DecodeAnyTLSURL(s string) (AnyTLS, error) {
 		anyTLS.SkipCertVerify, err = strconv.ParseBool(skipCertVerify)
 	}
 	if err != nil {
-		fmt.Println("AnyTLS SkipCertVerify conversion failed:", err)
+		utils.Warn("AnyTLS SkipCertVerify conversion failed: %v", err)
 		return AnyTLS{}, err
 	}
 	anyTLS.SNI = u.Query().Get("sni")
@@ -373,14 +373,15 @@ func EncodeClash(urls []Urls, config OutputConfig) ([]byte, error) {
 	}
 
 	// 生成Clash配置文件
-	return DecodeClash(proxys, config.Clash, config.CustomProxyGroups)
+	return DecodeClash(proxys, config.Clash, config.TemplateContext, config.CustomProxyGroups)
 }
 
 // DecodeClash 用于解析 Clash 配置文件并合并新节点
 // proxys: 新增的节点列表
 // yamlfile: 模板文件路径或 URL
+// tmplCtx: 模板渲染上下文（可为空，模板包含变量或条件区块时使用）
 // customGroups: 自定义代理组列表（可选，由链式代理规则生成）
-func DecodeClash(proxys []Proxy, yamlfile string, customGroups ...[]CustomProxyGroup) ([]byte, error) {
+func DecodeClash(proxys []Proxy, yamlfile string, tmplCtx *TemplateContext, customGroups ...[]CustomProxyGroup) ([]byte, error) {
 	// 读取 YAML 文件
 	var data []byte
 	var err error
@@ -416,9 +417,15 @@ func DecodeClash(proxys []Proxy, yamlfile string, customGroups ...[]CustomProxyG
 			cache.SetTemplateContent(filename, string(data))
 		}
 	}
+	// 展开 include/extends 引用并渲染模板变量与条件区块
+	rendered, err := ResolveTemplate(templateReferenceName(yamlfile), string(data), "clash", tmplCtx, templateLoaderFor(yamlfile))
+	if err != nil {
+		utils.Error("error: %v", err)
+		return nil, err
+	}
 	// 解析 YAML 文件
 	config := make(map[string]any)
-	err = yaml.Unmarshal(data, &config)
+	err = yaml.Unmarshal([]byte(rendered), &config)
 	if err != nil {
 		utils.Error("error: %v", err)
 		return nil, err
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -817,3 +819,177 @@ proxy-groups:
 		t.Fatalf("include-all-providers 丢失: %#v", thirdGroup["include-all-providers"])
 	}
 }
+
+// TestEncodeClashRendersTemplateContext 验证模板变量与条件区块按订阅上下文渲染
+func TestEncodeClashRendersTemplateContext(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "clash-context-template.yaml")
+	template := `#!template
+dns:
+  nameserver:
+{{- range split (default "223.5.5.5" .Vars.dns) }}
+    - {{ . }}
+{{- end }}
+proxies: []
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: []
+{{- if contains .Nodes.Countries "HK" }}
+  - name: HK
+    type: select
+    proxies: []
+{{- end }}
+{{- if contains .Nodes.Countries "US" }}
+  - name: US
+    type: select
+    proxies: []
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0o600); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+
+	ss := Ss{
+		Name:   "Context-SS",
+		Server: "context.example.com",
+		Port:   8388,
+		Param: Param{
+			Cipher:   "aes-256-gcm",
+			Password: "password",
+		},
+	}
+
+	data, err := EncodeClash([]Urls{{Url: EncodeSSURL(ss)}}, OutputConfig{
+		Clash: templatePath,
+		TemplateContext: &TemplateContext{
+			Nodes: TemplateNodeStats{Count: 1, Countries: []string{"HK"}},
+			Vars:  map[string]string{"dns": "1.1.1.1, 8.8.8.8"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("EncodeClash 失败: %v", err)
+	}
+
+	output := string(data)
+	assertContains(t, "DNS 变量", output, "- 1.1.1.1")
+	assertContains(t, "DNS 变量", output, "- 8.8.8.8")
+	assertContains(t, "条件代理组", output, "name: HK")
+	if strings.Contains(output, "name: US") {
+		t.Fatalf("未命中条件的代理组不应输出:\n%s", output)
+	}
+}
+
+// TestRenderTemplateRejectsInvalidSyntax 验证模板语法错误会被报告
+func TestRenderTemplateRejectsInvalidSyntax(t *testing.T) {
+	if _, err := RenderTemplate("#!template\ndns: {{ if .Vars.dns }}", nil); err == nil {
+		t.Fatal("期望未闭合的条件区块返回错误")
+	}
+	if _, err := ValidateTemplateSyntax("#!template\nname: {{ .Subscription.Unknown }}"); err == nil {
+		t.Fatal("期望引用未知字段时返回错误")
+	}
+	plain := "proxies: []\n"
+	rendered, err := RenderTemplate(plain, nil)
+	if err != nil || rendered != plain {
+		t.Fatalf("未使用模板指令的内容应原样返回, got %q, err=%v", rendered, err)
+	}
+}
+
+// TestRenderTemplateRequiresDirective 验证未声明 #!template 的模板中字面量 {{ 原样保留
+func TestRenderTemplateRequiresDirective(t *testing.T) {
+	literal := "rules:\n  - DOMAIN-KEYWORD,{{ not a directive\n"
+	rendered, err := RenderTemplate(literal, SampleTemplateContext())
+	if err != nil || rendered != literal {
+		t.Fatalf("未启用渲染的模板应原样返回, got %q, err=%v", rendered, err)
+	}
+}
+
+// TestRenderTemplateLimitsSteps 验证整数 range 与递归子模板受执行步数限制
+func TestRenderTemplateLimitsSteps(t *testing.T) {
+	for name, content := range map[string]string{
+		"range":     "#!template\n{{ range 100000000 }}{{ end }}",
+		"nested":    "#!template\n{{ range 10000 }}{{ range 10000 }}{{ end }}{{ end }}",
+		"recursion": "#!template\n{{ define \"a\" }}{{ template \"a\" . }}{{ template \"a\" . }}{{ end }}{{ template \"a\" . }}",
+	} {
+		if _, err := RenderTemplate(content, nil); err == nil {
+			t.Fatalf("%s: 期望超过执行步数上限时返回错误", name)
+		}
+	}
+	if _, err := RenderTemplate("#!template\n{{ range 100 }}x{{ end }}", nil); err != nil {
+		t.Fatalf("少量循环不应受限: %v", err)
+	}
+}
+
+// TestEncodeClashResolvesTemplateExtendsAndInclude 验证子模板继承基础模板并只覆盖声明的顶层键
+func TestEncodeClashResolvesTemplateExtendsAndInclude(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"clash-extends-dns.yaml": "enable: true\nnameserver:\n  - 223.5.5.5\n",
+		"clash-extends-base.yaml": `mode: rule
+dns:
+  #!include clash-extends-dns.yaml
+proxies: []
+proxy-groups:
+  - name: Base
+    type: select
+    proxies: []
+rules:
+  - MATCH,Base
+`,
+		"clash-extends-child.yaml": `#!extends clash-extends-base.yaml
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: []
+rules:
+  - MATCH,Proxy
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("写入模板失败: %v", err)
+		}
+	}
+
+	ss := Ss{
+		Name:   "Extends-SS",
+		Server: "extends.example.com",
+		Port:   8388,
+		Param:  Param{Cipher: "aes-256-gcm", Password: "password"},
+	}
+	data, err := EncodeClash([]Urls{{Url: EncodeSSURL(ss)}}, OutputConfig{Clash: filepath.Join(tempDir, "clash-extends-child.yaml")})
+	if err != nil {
+		t.Fatalf("EncodeClash 失败: %v", err)
+	}
+
+	output := string(data)
+	assertContains(t, "继承的基础配置", output, "mode: rule")
+	assertContains(t, "包含的 DNS 片段", output, "223.5.5.5")
+	assertContains(t, "覆盖的规则", output, "MATCH,Proxy")
+	if strings.Contains(output, "name: Base") || strings.Contains(output, "MATCH,Base") {
+		t.Fatalf("子模板声明的键应整体覆盖基础模板:\n%s", output)
+	}
+}
+
+// TestResolveTemplateDetectsCycle 验证 include/extends 循环引用会被拒绝
+func TestResolveTemplateDetectsCycle(t *testing.T) {
+	templates := map[string]string{
+		"cycle-a.yaml": "#!extends cycle-b.yaml\nmode: rule\n",
+		"cycle-b.yaml": "#!extends cycle-a.yaml\nmode: global\n",
+		"cycle-c.yaml": "dns:\n  #!include cycle-c.yaml\n",
+	}
+	loader := func(name string) (string, error) {
+		content, ok := templates[name]
+		if !ok {
+			return "", fmt.Errorf("missing %s", name)
+		}
+		return content, nil
+	}
+
+	for _, name := range []string{"cycle-a.yaml", "cycle-c.yaml"} {
+		_, err := ResolveTemplate(name, templates[name], "clash", nil, loader)
+		if !errors.Is(err, ErrTemplateCycle) {
+			t.Fatalf("%s 期望检测到循环引用, got %v", name, err)
+		}
+	}
+}
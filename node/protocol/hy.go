@@ -108,16 +108,16 @@ func DecodeHYURL(s string) (HY, error) {
 		name = server + ":" + u.Port()
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("server:", server)
-		fmt.Println("port:", port)
-		fmt.Println("insecure:", insecure)
-		fmt.Println("peer:", peer)
-		fmt.Println("auth:", auth)
-		fmt.Println("upMbps:", upMbps)
-		fmt.Println("downMbps:", downMbps)
-		fmt.Println("alpn:", alpn)
-		fmt.Println("protocol:", protocol)
-		fmt.Println("name:", name)
+		utils.Debug("server: %v", server)
+		utils.Debug("port: %v", port)
+		utils.Debug("insecure: %v", insecure)
+		utils.Debug("peer: %v", peer)
+		utils.Debug("auth: %v", auth)
+		utils.Debug("upMbps: %v", upMbps)
+		utils.Debug("downMbps: %v", downMbps)
+		utils.Debug("alpn: %v", alpn)
+		utils.Debug("protocol: %v", protocol)
+		utils.Debug("name: %v", name)
 	}
 	return HY{
 		Host:     server,
@@ -134,21 +134,21 @@ func DecodeHY2URL(s string) (HY2, error) {
 		name = server + ":" + u.Port()
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("password:", password)
-		fmt.Println("server:", server)
-		fmt.Println("port:", port)
-		fmt.Println("mport:", mport)
-		fmt.Println("insecure:", insecure)
-		fmt.Println("auth:", auth)
-		fmt.Println("upMbps:", upMbps)
-		fmt.Println("downMbps:", downMbps)
-		fmt.Println("alpn:", alpn)
-		fmt.Println("sni:", sni)
-		fmt.Println("obfs:", obfs)
-		fmt.Println("obfsPassword:", obfsPassword)
-		fmt.Println("fp:", clientFingerprint)
-		fmt.Println("fingerprint:", fingerprint)
-		fmt.Println("name:", name)
+		utils.Debug("password: %v", password)
+		utils.Debug("server: %v", server)
+		utils.Debug("port: %v", port)
+		utils.Debug("mport: %v", mport)
+		utils.Debug("insecure: %v", insecure)
+		utils.Debug("auth: %v", auth)
+		utils.Debug("upMbps: %v", upMbps)
+		utils.Debug("downMbps: %v", downMbps)
+		utils.Debug("alpn: %v", alpn)
+		utils.Debug("sni: %v", sni)
+		utils.Debug("obfs: %v", obfs)
+		utils.Debug("obfsPassword: %v", obfsPassword)
+		utils.Debug("fp: %v", clientFingerprint)
+		utils.Debug("fingerprint: %v", fingerprint)
+		utils.Debug("name: %v", name)
 	}
 	return HY2{
 		Password:          password,
@@ -48,7 +48,7 @@ func DecodeSocks5URL(s string) (Socks5, error) {
 	name := u.Fragment
 	host, port, err := net.SplitHostPort(u.Host)
 	if err != nil {
-		fmt.Println("Socks5 SplitHostPort error", err)
+		utils.Warn("Socks5 SplitHostPort error: %v", err)
 		return Socks5{}, err
 	}
 	rawPort := port
@@ -58,7 +58,7 @@ func DecodeSocks5URL(s string) (Socks5, error) {
 	socks5.Server = host
 	socks5.Port, err = strconv.Atoi(rawPort)
 	if err != nil {
-		fmt.Println("Socks5 Port conversion failed:", err)
+		utils.Warn("Socks5 Port conversion failed: %v", err)
 		return Socks5{}, err
 	}
 	socks5.Password, _ = u.User.Password()
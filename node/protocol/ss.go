@@ -2,7 +2,6 @@ package protocol
 
 import (
 	"fmt"
-	"log"
 	"net"
 	"net/url"
 	"strconv"
@@ -64,11 +63,11 @@ type SsPlugin struct {
 func parseSSURL(s string) (auth, addr, name string, plugin SsPlugin) {
 	u, err := url.Parse(s)
 	if err != nil {
-		log.Println("ss url parse fail.", err)
+		renderLog.Warn("SS 链接解析失败: %v", err)
 		return "", "", "", SsPlugin{}
 	}
 	if u.Scheme != "ss" {
-		log.Println("ss url parse fail, not ss url.")
+		renderLog.Warn("SS 链接解析失败: 不是 ss 链接")
 		return "", "", "", SsPlugin{}
 	}
 	// 处理url全编码的情况（整个链接base64编码）
@@ -332,21 +331,21 @@ func DecodeSSURL(s string) (Ss, error) {
 	}
 	// 开发环境输出结果
 	if utils.CheckEnvironment() {
-		fmt.Println("Param:", param)
-		fmt.Println("Server", server)
-		fmt.Println("Port", port)
-		fmt.Println("Name:", name)
-		fmt.Println("Cipher:", cipher)
-		fmt.Println("Password:", password)
+		utils.Debug("Param: %v", param)
+		utils.Debug("Server: %v", server)
+		utils.Debug("Port: %v", port)
+		utils.Debug("Name: %v", name)
+		utils.Debug("Cipher: %v", cipher)
+		utils.Debug("Password: %v", password)
 		if plugin.Name != "" {
-			fmt.Println("Plugin:", plugin.Name)
-			fmt.Println("Plugin Mode:", plugin.Mode)
-			fmt.Println("Plugin Host:", plugin.Host)
-			fmt.Println("Plugin Path:", plugin.Path)
-			fmt.Println("Plugin Tls:", plugin.Tls)
-			fmt.Println("Plugin Mux:", plugin.Mux)
-			fmt.Println("Plugin Password:", plugin.Password)
-			fmt.Println("Plugin Version:", plugin.Version)
+			utils.Debug("Plugin: %v", plugin.Name)
+			utils.Debug("Plugin Mode: %v", plugin.Mode)
+			utils.Debug("Plugin Host: %v", plugin.Host)
+			utils.Debug("Plugin Path: %v", plugin.Path)
+			utils.Debug("Plugin Tls: %v", plugin.Tls)
+			utils.Debug("Plugin Mux: %v", plugin.Mux)
+			utils.Debug("Plugin Password: %v", plugin.Password)
+			utils.Debug("Plugin Version: %v", plugin.Version)
 		}
 
 	}
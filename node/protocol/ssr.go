@@ -90,7 +90,7 @@ func DecodeSSRURL(s string) (Ssr, error) {
 			for _, param := range params {
 				parts := strings.SplitN(param, "=", 2)
 				if len(parts) != 2 {
-					fmt.Println("Invalid parameter: ", param)
+					utils.Warn("Invalid parameter: %v", param)
 					continue
 				}
 				paramMap[parts[0]] = parts[1]
@@ -104,9 +104,9 @@ func DecodeSSRURL(s string) (Ssr, error) {
 		protoparam = utils.Base64Decode(paramMap["protoparam"])
 		defer func() {
 			if utils.CheckEnvironment() {
-				fmt.Println("remarks", remarks)
-				fmt.Println("obfsparam", obfsparam)
-				fmt.Println("protoparam", protoparam)
+				utils.Debug("remarks: %v", remarks)
+				utils.Debug("obfsparam: %v", obfsparam)
+				utils.Debug("protoparam: %v", protoparam)
 			}
 		}()
 	}
@@ -126,12 +126,12 @@ func DecodeSSRURL(s string) (Ssr, error) {
 		remarks = server + ":" + strconv.Itoa(port)
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("password", password)
-		fmt.Println("obfs", obfs)
-		fmt.Println("method", method)
-		fmt.Println("protocol", protocol)
-		fmt.Println("port", port)
-		fmt.Println("server", server)
+		utils.Debug("password: %v", password)
+		utils.Debug("obfs: %v", obfs)
+		utils.Debug("method: %v", method)
+		utils.Debug("protocol: %v", protocol)
+		utils.Debug("port: %v", port)
+		utils.Debug("server: %v", server)
 	}
 	return Ssr{
 		Server:   server,
@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sublink/cache"
+	"sublink/utils"
 )
 
+var renderLog = utils.WithSubsystem(utils.SubsystemRender)
+
 // appendSurgeSSPlugin 将 SS 插件配置转换为 Surge 格式并追加到代理字符串
 // Surge 主要支持 obfs (simple-obfs) 插件
 func appendSurgeSSPlugin(proxy string, plugin SsPlugin) string {
@@ -67,7 +69,7 @@ func EncodeSurge(urls []string, config OutputConfig) (string, error) {
 		}
 		proxyLine, groupName, err := surgeCapable.ToSurgeLine(link, config)
 		if err != nil {
-			log.Println(err)
+			renderLog.Warn("Surge 节点转换失败: %v", err)
 			continue
 		}
 		if proxyLine != "" {
@@ -77,7 +79,7 @@ func EncodeSurge(urls []string, config OutputConfig) (string, error) {
 			groups = append(groups, groupName)
 		}
 	}
-	return DecodeSurge(proxys, groups, config.Surge)
+	return DecodeSurge(proxys, groups, config.Surge, config.TemplateContext)
 }
 
 func replaceSurgeHost(server string, config OutputConfig) string {
@@ -91,24 +93,25 @@ func replaceSurgeHost(server string, config OutputConfig) string {
 
 // DecodeSurge 读取 Surge 模板并合并节点与代理组。
 // 该流程会尽量保留模板中的自动匹配组语义，只在需要时补节点或 DIRECT 后备项。
-func DecodeSurge(proxys, groups []string, file string) (string, error) {
+// tmplCtx 为模板渲染上下文，可为空。
+func DecodeSurge(proxys, groups []string, file string, tmplCtx *TemplateContext) (string, error) {
 	var surge []byte
 	var err error
 	if strings.Contains(file, "://") {
 		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, file, nil)
 		if err != nil {
-			log.Println("http.Get error", err)
+			renderLog.Error("下载 Surge 模板失败: %v", err)
 			return "", err
 		}
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
-			log.Println("http.Get error", err)
+			renderLog.Error("下载 Surge 模板失败: %v", err)
 			return "", err
 		}
 		defer func() { _ = resp.Body.Close() }()
 		surge, err = io.ReadAll(resp.Body)
 		if err != nil {
-			log.Printf("error: %v", err)
+			renderLog.Error("读取 Surge 模板失败: %v", err)
 			return "", err
 		}
 	} else {
@@ -119,7 +122,7 @@ func DecodeSurge(proxys, groups []string, file string) (string, error) {
 		} else {
 			surge, err = os.ReadFile(file)
 			if err != nil {
-				log.Println(err)
+				renderLog.Error("读取 Surge 模板失败: %v", err)
 				return "", err
 			}
 			// 写入缓存
@@ -127,8 +130,15 @@ func DecodeSurge(proxys, groups []string, file string) (string, error) {
 		}
 	}
 
+	// 展开 include/extends 引用并渲染模板变量与条件区块
+	rendered, err := ResolveTemplate(templateReferenceName(file), string(surge), "surge", tmplCtx, templateLoaderFor(file))
+	if err != nil {
+		renderLog.Error("渲染 Surge 模板失败: %v", err)
+		return "", err
+	}
+
 	// 按行处理模板文件
-	lines := strings.Split(string(surge), "\n")
+	lines := strings.Split(rendered, "\n")
 	var result []string
 	currentSection := ""
 	grouplist := strings.Join(groups, ", ")
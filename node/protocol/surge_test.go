@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -437,3 +438,56 @@ func TestEncodeSurge(t *testing.T) {
 	assertContains(t, "Surge 代理组", output, "Proxy = select, Surge-SS, Surge-Trojan")
 	assertContains(t, "Surge 自动组", output, "Auto = url-test, url=http://www.gstatic.com/generate_204, interval=300, Surge-SS, Surge-Trojan")
 }
+
+// TestEncodeSurgeRendersShareSection 验证 Surge 模板可按分享信息输出条件区块
+func TestEncodeSurgeRendersShareSection(t *testing.T) {
+	tempDir := t.TempDir()
+	templatePath := filepath.Join(tempDir, "surge-share-template.conf")
+	template := "#!template\n[General]\n{{- if .Share.Present }}\n# share={{ .Share.Name }}\n{{- end }}\n[Proxy]\n\n[Proxy Group]\nProxy = select\n"
+	if err := os.WriteFile(templatePath, []byte(template), 0o600); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+
+	ss := Ss{
+		Name:   "Share-SS",
+		Server: "share.example.com",
+		Port:   8388,
+		Param: Param{
+			Cipher:   "aes-256-gcm",
+			Password: "password",
+		},
+	}
+
+	output, err := EncodeSurge([]string{EncodeSSURL(ss)}, OutputConfig{
+		Surge:           templatePath,
+		TemplateContext: &TemplateContext{Share: TemplateShare{ID: 3, Name: "family", Present: true}},
+	})
+	if err != nil {
+		t.Fatalf("EncodeSurge 失败: %v", err)
+	}
+	assertContains(t, "分享条件区块", output, "# share=family")
+	assertContains(t, "Surge 代理组", output, "Proxy = select, Share-SS")
+}
+
+// TestResolveSurgeTemplateOverridesSections 验证 Surge 子模板按区段覆盖基础模板
+func TestResolveSurgeTemplateOverridesSections(t *testing.T) {
+	base := "#!MANAGED-CONFIG https://example.com/base\n[General]\nloglevel = notify\n\n[Proxy]\n\n[Rule]\nFINAL,DIRECT\n"
+	child := "#!extends surge-base.conf\n[Rule]\nFINAL,Proxy\n"
+	loader := func(name string) (string, error) {
+		if name != "surge-base.conf" {
+			return "", fmt.Errorf("missing %s", name)
+		}
+		return base, nil
+	}
+
+	output, err := ResolveTemplate("surge-child.conf", child, "surge", nil, loader)
+	if err != nil {
+		t.Fatalf("ResolveTemplate 失败: %v", err)
+	}
+	assertContains(t, "保留基础模板头部", output, "#!MANAGED-CONFIG https://example.com/base")
+	assertContains(t, "保留基础区段", output, "loglevel = notify")
+	assertContains(t, "覆盖规则区段", output, "FINAL,Proxy")
+	if strings.Contains(output, "FINAL,DIRECT") {
+		t.Fatalf("子模板的 [Rule] 应覆盖基础模板:\n%s", output)
+	}
+}
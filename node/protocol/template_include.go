@@ -0,0 +1,375 @@
+package protocol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sublink/cache"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 模板引用指令
+// #!include <文件名>  在当前位置插入另一个模板片段（保留指令行的缩进）
+// #!extends <文件名>  以另一个模板为基础，当前模板只覆盖需要修改的部分
+// 指令写成注释形式，未解析时不会破坏 YAML/Surge 结构。
+const (
+	templateIncludeDirective = "#!include"
+	templateExtendsDirective = "#!extends"
+	maxTemplateIncludeDepth  = 16
+)
+
+// DefaultTemplateDir 远程模板引用本地片段时使用的模板目录
+const DefaultTemplateDir = "./template"
+
+// ErrTemplateCycle 模板引用存在循环
+var ErrTemplateCycle = errors.New("模板引用存在循环")
+
+// TemplateLoader 按文件名读取被引用的模板内容
+type TemplateLoader func(name string) (string, error)
+
+// TemplateFileLoader 返回从模板目录读取内容的加载器，优先使用模板内容缓存
+func TemplateFileLoader(dir string) TemplateLoader {
+	return func(name string) (string, error) {
+		if cached, ok := cache.GetTemplateContent(name); ok {
+			return cached, nil
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", fmt.Errorf("读取被引用模板 %s 失败: %w", name, err)
+		}
+		cache.SetTemplateContent(name, string(data))
+		return string(data), nil
+	}
+}
+
+// WithTemplateOverride 在加载器基础上用给定内容替换指定模板，用于保存前校验候选内容
+func WithTemplateOverride(loader TemplateLoader, name, content string) TemplateLoader {
+	name = normalizeTemplateReference(name)
+	return func(ref string) (string, error) {
+		if ref == name {
+			return content, nil
+		}
+		return loader(ref)
+	}
+}
+
+// TemplateReferences 解析模板直接引用的模板，返回继承的基础模板与包含的片段
+func TemplateReferences(content string) (extends string, includes []string) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRenderedTemplateSize)
+	for scanner.Scan() {
+		kind, name, ok := parseTemplateReference(scanner.Text())
+		if !ok {
+			continue
+		}
+		if kind == templateExtendsDirective {
+			if extends == "" {
+				extends = name
+			}
+			continue
+		}
+		if !slices.Contains(includes, name) {
+			includes = append(includes, name)
+		}
+	}
+	return extends, includes
+}
+
+// HasTemplateReferences 判断模板是否使用了 include/extends 指令
+func HasTemplateReferences(content string) bool {
+	extends, includes := TemplateReferences(content)
+	return extends != "" || len(includes) > 0
+}
+
+// parseTemplateReference 解析单行引用指令，返回指令类型与规范化后的文件名
+func parseTemplateReference(line string) (kind, name string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, directive := range []string{templateIncludeDirective, templateExtendsDirective} {
+		rest, found := strings.CutPrefix(trimmed, directive)
+		if !found || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		name = normalizeTemplateReference(rest)
+		if name == "" {
+			return "", "", false
+		}
+		return directive, name, true
+	}
+	return "", "", false
+}
+
+// normalizeTemplateReference 规范化引用的文件名，只保留文件名部分以避免目录穿越
+func normalizeTemplateReference(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	value = strings.ReplaceAll(value, "\\", "/")
+	name := filepath.Base(value)
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// ResolveTemplate 展开模板的 include/extends 引用并渲染变量与条件区块
+// name 为当前模板文件名（用于循环检测，可为空）；format 为 clash 或 surge，决定继承时的合并方式。
+// 未使用引用指令的模板与 RenderTemplate 结果一致。
+func ResolveTemplate(name, content, format string, ctx *TemplateContext, loader TemplateLoader) (string, error) {
+	if loader == nil {
+		loader = TemplateFileLoader(DefaultTemplateDir)
+	}
+	r := &templateResolver{format: format, ctx: ctx, loader: loader}
+	return r.resolve(normalizeTemplateReference(name), content)
+}
+
+// templateResolver 记录引用链以检测循环
+type templateResolver struct {
+	format string
+	ctx    *TemplateContext
+	loader TemplateLoader
+	stack  []string
+}
+
+func (r *templateResolver) enter(name string) error {
+	if name == "" {
+		return nil
+	}
+	if slices.Contains(r.stack, name) {
+		return fmt.Errorf("%w: %s -> %s", ErrTemplateCycle, strings.Join(r.stack, " -> "), name)
+	}
+	if len(r.stack) >= maxTemplateIncludeDepth {
+		return fmt.Errorf("模板引用层级超过 %d 层", maxTemplateIncludeDepth)
+	}
+	r.stack = append(r.stack, name)
+	return nil
+}
+
+func (r *templateResolver) leave(name string) {
+	if name != "" {
+		r.stack = r.stack[:len(r.stack)-1]
+	}
+}
+
+// resolve 展开 include、渲染当前模板，再与基础模板合并
+func (r *templateResolver) resolve(name, content string) (string, error) {
+	if err := r.enter(name); err != nil {
+		return "", err
+	}
+	defer r.leave(name)
+
+	expanded, parent, err := r.expand(content, true)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := RenderTemplate(expanded, r.ctx)
+	if err != nil {
+		return "", err
+	}
+	if parent == "" {
+		return rendered, nil
+	}
+
+	parentText, err := r.loader(parent)
+	if err != nil {
+		return "", err
+	}
+	base, err := r.resolve(parent, parentText)
+	if err != nil {
+		return "", err
+	}
+	if r.format == "surge" {
+		return mergeSurgeTemplates(base, rendered), nil
+	}
+	return mergeClashTemplates(base, rendered)
+}
+
+// expand 将 include 指令替换为片段内容；allowExtends 为假时（片段内）不允许继承
+func (r *templateResolver) expand(content string, allowExtends bool) (string, string, error) {
+	if !strings.Contains(content, templateIncludeDirective) && !strings.Contains(content, templateExtendsDirective) {
+		return content, "", nil
+	}
+	var parent string
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		kind, ref, ok := parseTemplateReference(line)
+		if !ok {
+			result = append(result, line)
+			continue
+		}
+		if kind == templateExtendsDirective {
+			if !allowExtends {
+				return "", "", fmt.Errorf("被包含的模板片段 %s 不能使用 %s", ref, templateExtendsDirective)
+			}
+			if parent != "" {
+				return "", "", fmt.Errorf("模板只能继承一个基础模板")
+			}
+			parent = ref
+			continue
+		}
+
+		fragment, err := r.loader(ref)
+		if err != nil {
+			return "", "", err
+		}
+		if err := r.enter(ref); err != nil {
+			return "", "", err
+		}
+		fragment, _, err = r.expand(fragment, false)
+		r.leave(ref)
+		if err != nil {
+			return "", "", err
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, fragmentLine := range strings.Split(strings.TrimRight(fragment, "\r\n"), "\n") {
+			if fragmentLine == "" {
+				result = append(result, "")
+				continue
+			}
+			result = append(result, indent+fragmentLine)
+		}
+	}
+	return strings.Join(result, "\n"), parent, nil
+}
+
+// mergeClashTemplates 按顶层键合并 YAML：子模板中出现的键整体覆盖基础模板，其余保留
+func mergeClashTemplates(base, child string) (string, error) {
+	var baseDoc, childDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(base), &baseDoc); err != nil {
+		return "", fmt.Errorf("解析基础模板失败: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(child), &childDoc); err != nil {
+		return "", fmt.Errorf("解析继承模板失败: %w", err)
+	}
+	childMap := yamlDocumentMapping(&childDoc)
+	if childMap == nil {
+		return base, nil
+	}
+	baseMap := yamlDocumentMapping(&baseDoc)
+	if baseMap == nil {
+		return child, nil
+	}
+
+	for i := 0; i+1 < len(childMap.Content); i += 2 {
+		key, value := childMap.Content[i], childMap.Content[i+1]
+		replaced := false
+		for j := 0; j+1 < len(baseMap.Content); j += 2 {
+			if baseMap.Content[j].Value == key.Value {
+				baseMap.Content[j+1] = value
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			baseMap.Content = append(baseMap.Content, key, value)
+		}
+	}
+
+	out, err := yaml.Marshal(&baseDoc)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// yamlDocumentMapping 返回文档根节点的映射，非映射文档返回 nil
+func yamlDocumentMapping(doc *yaml.Node) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	if root := doc.Content[0]; root.Kind == yaml.MappingNode {
+		return root
+	}
+	return nil
+}
+
+// surgeSection Surge 配置中的一个区段
+type surgeSection struct {
+	header string
+	lines  []string
+}
+
+// splitSurgeSections 拆分 Surge 配置为区段前的头部与各区段
+func splitSurgeSections(content string) ([]string, []surgeSection) {
+	var preamble []string
+	var sections []surgeSection
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			sections = append(sections, surgeSection{header: trimmed})
+			continue
+		}
+		if len(sections) == 0 {
+			preamble = append(preamble, line)
+			continue
+		}
+		last := &sections[len(sections)-1]
+		last.lines = append(last.lines, line)
+	}
+	return preamble, sections
+}
+
+// mergeSurgeTemplates 按区段合并 Surge 配置：子模板中的区段整体覆盖基础模板的同名区段
+func mergeSurgeTemplates(base, child string) string {
+	basePreamble, baseSections := splitSurgeSections(base)
+	childPreamble, childSections := splitSurgeSections(child)
+
+	preamble := basePreamble
+	if strings.TrimSpace(strings.Join(childPreamble, "")) != "" {
+		preamble = childPreamble
+	}
+
+	merged := baseSections
+	for _, section := range childSections {
+		index := slices.IndexFunc(merged, func(s surgeSection) bool { return s.header == section.header })
+		if index >= 0 {
+			merged[index] = section
+		} else {
+			merged = append(merged, section)
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range preamble {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for i, section := range merged {
+		if i > 0 || b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(section.header)
+		b.WriteString("\n")
+		lines := section.lines
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// templateReferenceName 返回模板在引用链中的名称，远程模板不参与循环检测
+func templateReferenceName(path string) string {
+	if strings.Contains(path, "://") {
+		return ""
+	}
+	return filepath.Base(path)
+}
+
+// templateLoaderFor 返回模板引用片段的加载器：本地模板从所在目录读取，远程模板从默认模板目录读取
+func templateLoaderFor(path string) TemplateLoader {
+	if strings.Contains(path, "://") {
+		return TemplateFileLoader(DefaultTemplateDir)
+	}
+	return TemplateFileLoader(filepath.Dir(path))
+}
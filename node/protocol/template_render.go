@@ -0,0 +1,249 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateRenderDirective 启用模板渲染的指令行，写成注释形式，与 #!include/#!extends 一致。
+// 只有包含该指令行的模板才会进入渲染流程，存量模板中的字面量 {{ 保持原样输出。
+const templateRenderDirective = "#!template"
+
+// maxRenderedTemplateSize 渲染结果的大小上限，防止 range 等指令把模板放大到异常体积。
+const maxRenderedTemplateSize = 8 << 20
+
+// maxTemplateSteps 单次渲染中 range 迭代与子模板调用的总次数上限，
+// 防止对整数 range 或递归调用子模板等不产生输出的写法长时间占用 CPU。
+const maxTemplateSteps = 100000
+
+// templateStepFunc 渲染时注入每个 range 循环体与子模板开头的计数函数
+const templateStepFunc = "templateStep"
+
+// errTemplateStepLimit 渲染步数超过上限
+var errTemplateStepLimit = fmt.Errorf("模板循环或子模板调用超过 %d 次上限", maxTemplateSteps)
+
+// TemplateContext 模板渲染上下文
+// 仅包含纯数据字段，不暴露任何方法，模板无法借此访问数据库或文件系统。
+type TemplateContext struct {
+	Client       string               // 当前请求的客户端类型 (clash/surge/...)
+	Subscription TemplateSubscription // 订阅信息
+	Share        TemplateShare        // 分享信息（未通过分享访问时为零值）
+	Nodes        TemplateNodeStats    // 节点统计信息
+	Vars         map[string]string    // 订阅设置中的自定义变量
+}
+
+// TemplateSubscription 模板可见的订阅信息
+type TemplateSubscription struct {
+	ID   int
+	Name string
+}
+
+// TemplateShare 模板可见的分享信息
+type TemplateShare struct {
+	ID       int
+	Name     string
+	Present  bool   // 是否通过分享链接访问
+	ExpireAt string // 过期时间（RFC3339，永不过期为空）
+}
+
+// TemplateNodeStats 模板可见的节点统计
+type TemplateNodeStats struct {
+	Count          int
+	Countries      []string       // 去重后的国家代码，按字母排序
+	CountryCounts  map[string]int // 国家代码 -> 节点数
+	Protocols      []string       // 去重后的协议类型，按字母排序
+	ProtocolCounts map[string]int // 协议类型 -> 节点数
+	Groups         []string       // 去重后的节点分组
+	Tags           []string       // 去重后的节点标签
+}
+
+// templateFuncs 模板可用的函数白名单
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"trim":     strings.TrimSpace,
+	"split":    splitTemplateList,
+	"default":  templateDefault,
+	"contains": templateContains,
+	"quote":    templateQuote,
+	// 占位，渲染时替换为带计数的实现
+	templateStepFunc: func() (string, error) { return "", nil },
+}
+
+// splitTemplateList 按逗号拆分变量值并去除空白项，便于在模板中 range 列表型变量
+func splitTemplateList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// templateDefault 值为空时返回默认值，用法: {{ default "8.8.8.8" .Vars.dns }}
+func templateDefault(fallback string, value any) string {
+	str := fmt.Sprint(value)
+	if value == nil || str == "" || str == "<no value>" {
+		return fallback
+	}
+	return str
+}
+
+// templateContains 判断列表或字符串是否包含指定值，用法: {{ if contains .Nodes.Countries "HK" }}
+func templateContains(collection any, item string) bool {
+	switch v := collection.(type) {
+	case []string:
+		return slices.Contains(v, item)
+	case string:
+		return strings.Contains(v, item)
+	case map[string]int:
+		_, ok := v[item]
+		return ok
+	case map[string]string:
+		_, ok := v[item]
+		return ok
+	}
+	return false
+}
+
+// templateQuote 以 YAML 双引号字符串形式输出，避免变量中的特殊字符破坏配置结构
+func templateQuote(value any) string {
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
+
+// HasTemplateDirectives 判断模板是否通过 #!template 指令行启用了模板渲染
+func HasTemplateDirectives(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == templateRenderDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTemplateText 解析模板文本，未知变量按零值处理
+// 解析后在每个 range 循环体与子模板开头插入计数调用，渲染时按 maxTemplateSteps 限制执行量。
+func parseTemplateText(content string) (*template.Template, error) {
+	tmpl, err := template.New("config").Funcs(templateFuncs).Option("missingkey=zero").Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	step, err := parse.Parse("step", "{{"+templateStepFunc+"}}", "{{", "}}", templateFuncs)
+	if err != nil {
+		return nil, err
+	}
+	stepNode := step["step"].Root.Nodes[0]
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		if t.Name() != tmpl.Name() {
+			t.Tree.Root.Nodes = append([]parse.Node{stepNode.Copy()}, t.Tree.Root.Nodes...)
+		}
+		insertTemplateSteps(t.Tree.Root, stepNode)
+	}
+	return tmpl, nil
+}
+
+// insertTemplateSteps 在 list 中所有 range 循环体开头插入计数调用
+func insertTemplateSteps(list *parse.ListNode, step parse.Node) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.RangeNode:
+			insertTemplateSteps(n.List, step)
+			insertTemplateSteps(n.ElseList, step)
+			n.List.Nodes = append([]parse.Node{step.Copy()}, n.List.Nodes...)
+		case *parse.IfNode:
+			insertTemplateSteps(n.List, step)
+			insertTemplateSteps(n.ElseList, step)
+		case *parse.WithNode:
+			insertTemplateSteps(n.List, step)
+			insertTemplateSteps(n.ElseList, step)
+		case *parse.ListNode:
+			insertTemplateSteps(n, step)
+		}
+	}
+}
+
+// RenderTemplate 使用上下文渲染模板中的变量与条件区块
+// 模板未通过 #!template 启用渲染时原样返回；ctx 为空时使用零值上下文，使条件区块全部按假处理。
+func RenderTemplate(content string, ctx *TemplateContext) (string, error) {
+	if !HasTemplateDirectives(content) {
+		return content, nil
+	}
+	tmpl, err := parseTemplateText(content)
+	if err != nil {
+		return "", fmt.Errorf("模板语法错误: %w", err)
+	}
+	if ctx == nil {
+		ctx = &TemplateContext{}
+	}
+	steps := 0
+	tmpl.Funcs(template.FuncMap{templateStepFunc: func() (string, error) {
+		steps++
+		if steps > maxTemplateSteps {
+			return "", errTemplateStepLimit
+		}
+		return "", nil
+	}})
+	var buf limitedBuffer
+	buf.limit = maxRenderedTemplateSize
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		if errors.Is(err, errTemplateStepLimit) {
+			err = errTemplateStepLimit
+		}
+		return "", fmt.Errorf("模板渲染失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ValidateTemplateSyntax 校验模板指令语法，并用示例上下文试渲染以发现未知字段
+// 返回试渲染后的文本，供调用方继续做 YAML/Surge 结构校验。
+func ValidateTemplateSyntax(content string) (string, error) {
+	if !HasTemplateDirectives(content) {
+		return content, nil
+	}
+	return RenderTemplate(content, SampleTemplateContext())
+}
+
+// SampleTemplateContext 返回用于校验和预览的示例上下文
+func SampleTemplateContext() *TemplateContext {
+	return &TemplateContext{
+		Client:       "clash",
+		Subscription: TemplateSubscription{ID: 1, Name: "sample"},
+		Share:        TemplateShare{ID: 1, Name: "sample", Present: true},
+		Nodes: TemplateNodeStats{
+			Count:          2,
+			Countries:      []string{"HK", "JP"},
+			CountryCounts:  map[string]int{"HK": 1, "JP": 1},
+			Protocols:      []string{"ss", "vless"},
+			ProtocolCounts: map[string]int{"ss": 1, "vless": 1},
+			Groups:         []string{"default"},
+			Tags:           []string{},
+		},
+		Vars: map[string]string{},
+	}
+}
+
+// limitedBuffer 带容量上限的输出缓冲
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("渲染结果超过 %d 字节上限", b.limit)
+	}
+	return b.Buffer.Write(p)
+}
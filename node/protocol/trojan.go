@@ -256,22 +256,22 @@ func DecodeTrojanURL(s string) (Trojan, error) {
 		name = hostname + ":" + u.Port()
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("password:", password)
-		fmt.Println("password:", u.User.Username())
-		fmt.Println("hostname:", hostname)
-		fmt.Println("port:", port)
-		fmt.Println("peer:", peer)
-		fmt.Println("allowInsecure:", allowInsecure)
-		fmt.Println("sni:", sni)
-		fmt.Println("type:", types)
-		fmt.Println("path:", path)
-		fmt.Println("security:", security)
-		fmt.Println("fp:", fp)
-		fmt.Println("fingerprint:", fingerprint)
-		fmt.Println("alpn:", alpn)
-		fmt.Println("host:", host)
-		fmt.Println("flow:", flow)
-		fmt.Println("name:", name)
+		utils.Debug("password: %v", password)
+		utils.Debug("password: %v", u.User.Username())
+		utils.Debug("hostname: %v", hostname)
+		utils.Debug("port: %v", port)
+		utils.Debug("peer: %v", peer)
+		utils.Debug("allowInsecure: %v", allowInsecure)
+		utils.Debug("sni: %v", sni)
+		utils.Debug("type: %v", types)
+		utils.Debug("path: %v", path)
+		utils.Debug("security: %v", security)
+		utils.Debug("fp: %v", fp)
+		utils.Debug("fingerprint: %v", fingerprint)
+		utils.Debug("alpn: %v", alpn)
+		utils.Debug("host: %v", host)
+		utils.Debug("flow: %v", flow)
+		utils.Debug("name: %v", name)
 	}
 	// 解析 allowInsecure 参数
 	insecureVal := 0
@@ -67,7 +67,6 @@ func DecodeTuicURL(s string) (Tuic, error) {
 		return Tuic{}, fmt.Errorf("uuid格式错误:%s", uuid)
 	}
 	password, _ := u.User.Password()
-	// log.Println(password)
 	// password = Base64Decode2(password)
 	server := u.Hostname()
 	rawPort := u.Port()
@@ -103,19 +102,19 @@ func DecodeTuicURL(s string) (Tuic, error) {
 		version = 4
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("password:", password)
-		fmt.Println("server:", server)
-		fmt.Println("port:", port)
-		fmt.Println("congestion_control:", Congestioncontrol)
-		fmt.Println("insecure:", insecure)
-		fmt.Println("uuid:", uuid)
-		fmt.Println("udprelay_mode:", Udprelay_mode)
-		fmt.Println("alpn:", alpn)
-		fmt.Println("sni:", sni)
-		fmt.Println("disablesni:", Disablesni)
-		fmt.Println("name:", name)
-		fmt.Println("version:", version)
-		fmt.Println("token", token)
+		utils.Debug("password: %v", password)
+		utils.Debug("server: %v", server)
+		utils.Debug("port: %v", port)
+		utils.Debug("congestion_control: %v", Congestioncontrol)
+		utils.Debug("insecure: %v", insecure)
+		utils.Debug("uuid: %v", uuid)
+		utils.Debug("udprelay_mode: %v", Udprelay_mode)
+		utils.Debug("alpn: %v", alpn)
+		utils.Debug("sni: %v", sni)
+		utils.Debug("disablesni: %v", Disablesni)
+		utils.Debug("name: %v", name)
+		utils.Debug("version: %v", version)
+		utils.Debug("token: %v", token)
 	}
 	return Tuic{
 		Name:               name,
@@ -3,13 +3,16 @@ package protocol
 // OutputConfig 订阅输出配置
 // 控制 Clash/Surge 等客户端配置的生成参数
 type OutputConfig struct {
-	Clash                 string             `json:"clash"`                 // Clash 模板路径或 URL
-	Surge                 string             `json:"surge"`                 // Surge 模板路径或 URL
-	Udp                   bool               `json:"udp"`                   // 是否启用 UDP
-	Cert                  bool               `json:"cert"`                  // 是否跳过证书验证
-	ReplaceServerWithHost bool               `json:"replaceServerWithHost"` // 是否使用 Host 替换服务器地址
-	HostMap               map[string]string  `json:"-"`                     // 运行时填充的 Host 映射，不序列化
-	CustomProxyGroups     []CustomProxyGroup `json:"-"`                     // 运行时填充的自定义代理组，不序列化
+	Clash                 string             `json:"clash"`                  // Clash 模板路径或 URL
+	Surge                 string             `json:"surge"`                  // Surge 模板路径或 URL
+	Udp                   bool               `json:"udp"`                    // 是否启用 UDP
+	Cert                  bool               `json:"cert"`                   // 是否跳过证书验证
+	ReplaceServerWithHost bool               `json:"replaceServerWithHost"`  // 是否使用 Host 替换服务器地址
+	ValidateOutput        bool               `json:"validateOutput"`         // 输出前使用 mihomo 校验 Clash 配置，失败时回退到上次正确结果
+	HostMap               map[string]string  `json:"-"`                      // 运行时填充的 Host 映射，不序列化
+	CustomProxyGroups     []CustomProxyGroup `json:"-"`                      // 运行时填充的自定义代理组，不序列化
+	TemplateVars          map[string]string  `json:"templateVars,omitempty"` // 订阅级模板变量，渲染时以 .Vars 暴露
+	TemplateContext       *TemplateContext   `json:"-"`                      // 运行时填充的模板渲染上下文，不序列化
 }
 
 // CustomProxyGroup 自定义代理组（由链式代理规则生成）
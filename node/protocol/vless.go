@@ -352,31 +352,31 @@ func DecodeVLESSURL(s string) (VLESS, error) {
 	}
 
 	if utils.CheckEnvironment() {
-		fmt.Println("uuid:", uuid)
-		fmt.Println("hostname:", hostname)
-		fmt.Println("port:", port)
-		fmt.Println("encryption:", encryption)
-		fmt.Println("security:", security)
-		fmt.Println("type:", types)
-		fmt.Println("flow:", flow)
-		fmt.Println("headerType:", headerType)
-		fmt.Println("pbk:", pbk)
-		fmt.Println("sid:", sid)
-		fmt.Println("fp:", fp)
-		fmt.Println("fingerprint:", fingerprint)
-		fmt.Println("alpn:", alpn)
-		fmt.Println("sni:", sni)
-		fmt.Println("ech:", ech)
-		fmt.Println("path:", path)
-		fmt.Println("host:", host)
-		fmt.Println("serviceName:", serviceName)
-		fmt.Println("mode:", mode)
-		fmt.Println("packetEncoding:", packetEncoding)
-		fmt.Println("maxEarlyData:", maxEarlyData)
-		fmt.Println("earlyDataHeader:", earlyDataHeader)
-		fmt.Println("httpUpgrade:", httpUpgrade)
-		fmt.Println("method:", method)
-		fmt.Println("name:", name)
+		utils.Debug("uuid: %v", uuid)
+		utils.Debug("hostname: %v", hostname)
+		utils.Debug("port: %v", port)
+		utils.Debug("encryption: %v", encryption)
+		utils.Debug("security: %v", security)
+		utils.Debug("type: %v", types)
+		utils.Debug("flow: %v", flow)
+		utils.Debug("headerType: %v", headerType)
+		utils.Debug("pbk: %v", pbk)
+		utils.Debug("sid: %v", sid)
+		utils.Debug("fp: %v", fp)
+		utils.Debug("fingerprint: %v", fingerprint)
+		utils.Debug("alpn: %v", alpn)
+		utils.Debug("sni: %v", sni)
+		utils.Debug("ech: %v", ech)
+		utils.Debug("path: %v", path)
+		utils.Debug("host: %v", host)
+		utils.Debug("serviceName: %v", serviceName)
+		utils.Debug("mode: %v", mode)
+		utils.Debug("packetEncoding: %v", packetEncoding)
+		utils.Debug("maxEarlyData: %v", maxEarlyData)
+		utils.Debug("earlyDataHeader: %v", earlyDataHeader)
+		utils.Debug("httpUpgrade: %v", httpUpgrade)
+		utils.Debug("method: %v", method)
+		utils.Debug("name: %v", name)
 	}
 
 	return VLESS{
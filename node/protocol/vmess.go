@@ -94,16 +94,16 @@ func DecodeVMESSURL(s string) (Vmess, error) {
 		vmess.Ps = vmess.Add + ":" + utils.GetPortString(vmess.Port)
 	}
 	if utils.CheckEnvironment() {
-		fmt.Println("服务器地址", vmess.Add)
-		fmt.Println("端口", vmess.Port)
-		fmt.Println("path", vmess.Path)
-		fmt.Println("uuid", vmess.Id)
-		fmt.Println("alterId", vmess.Aid)
-		fmt.Println("cipher", vmess.Scy)
-		fmt.Println("client-fingerprint", vmess.Fp)
-		fmt.Println("network", vmess.Net)
-		fmt.Println("tls", vmess.Tls)
-		fmt.Println("备注", vmess.Ps)
+		utils.Debug("服务器地址: %v", vmess.Add)
+		utils.Debug("端口: %v", vmess.Port)
+		utils.Debug("path: %v", vmess.Path)
+		utils.Debug("uuid: %v", vmess.Id)
+		utils.Debug("alterId: %v", vmess.Aid)
+		utils.Debug("cipher: %v", vmess.Scy)
+		utils.Debug("client-fingerprint: %v", vmess.Fp)
+		utils.Debug("network: %v", vmess.Net)
+		utils.Debug("tls: %v", vmess.Tls)
+		utils.Debug("备注: %v", vmess.Ps)
 	}
 	return vmess, nil
 }
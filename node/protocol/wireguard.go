@@ -160,17 +160,17 @@ func DecodeWireGuardURL(s string) (WireGuard, error) {
 	}
 
 	if utils.CheckEnvironment() {
-		fmt.Println("WireGuard解析结果:")
-		fmt.Println("  name:", name)
-		fmt.Println("  server:", server)
-		fmt.Println("  port:", port)
-		fmt.Println("  privateKey:", privateKey)
-		fmt.Println("  publicKey:", publicKey)
-		fmt.Println("  preSharedKey:", preSharedKey)
-		fmt.Println("  ip:", ip)
-		fmt.Println("  ipv6:", ipv6)
-		fmt.Println("  mtu:", mtu)
-		fmt.Println("  reserved:", reserved)
+		utils.Debug("WireGuard解析结果:")
+		utils.Debug("name: %v", name)
+		utils.Debug("server: %v", server)
+		utils.Debug("port: %v", port)
+		utils.Debug("privateKey: %v", privateKey)
+		utils.Debug("publicKey: %v", publicKey)
+		utils.Debug("preSharedKey: %v", preSharedKey)
+		utils.Debug("ip: %v", ip)
+		utils.Debug("ipv6: %v", ipv6)
+		utils.Debug("mtu: %v", mtu)
+		utils.Debug("reserved: %v", reserved)
 	}
 
 	return WireGuard{
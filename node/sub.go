@@ -18,6 +18,7 @@ import (
 	"sublink/node/protocol"
 	"sublink/services/mihomo"
 	"sublink/services/notifications"
+	"sublink/services/tracing"
 	"sublink/utils"
 	"time"
 
@@ -234,6 +235,7 @@ func applyRequestHeaders(req *http.Request, userAgent string, requestHeaders mod
 }
 
 func expandClashProxyProviders(ctx context.Context, client *http.Client, rootSubscriptionURL string, config *ClashConfig, userAgent string, requestHeaders models.AirportRequestHeaders) error {
+	logger := utils.WithSubsystem(utils.SubsystemAirport)
 	providers := selectClashProxyProviders(config)
 	if len(providers) == 0 {
 		return nil
@@ -263,7 +265,7 @@ func expandClashProxyProviders(ctx context.Context, client *http.Client, rootSub
 
 		providerProxies, err := fetchClashProxyProvider(ctx, client, item.Name, providerURL, rootHost, userAgent, requestHeaders)
 		if err != nil {
-			utils.Warn("proxy-provider【%s】获取或解析失败: %v", item.Name, err)
+			logger.Warn("proxy-provider【%s】获取或解析失败: %v", item.Name, err)
 			providerErrors = append(providerErrors, fmt.Sprintf("%s: %v", item.Name, err))
 			continue
 		}
@@ -346,6 +348,7 @@ func orderedClashProviderNames(config *ClashConfig) []string {
 }
 
 func fetchClashProxyProvider(ctx context.Context, client *http.Client, providerName string, providerURL string, rootSubscriptionHost string, userAgent string, requestHeaders models.AirportRequestHeaders) ([]protocol.Proxy, error) {
+	logger := utils.WithSubsystem(utils.SubsystemAirport)
 	parsedProviderURL, err := validateClashProviderURL(providerURL)
 	if err != nil {
 		return nil, err
@@ -388,7 +391,7 @@ func fetchClashProxyProvider(ctx context.Context, client *http.Client, providerN
 		return nil, fmt.Errorf("未找到节点")
 	}
 
-	utils.Info("proxy-provider【%s】导入节点数量：%d", providerName, len(proxies))
+	logger.Info("proxy-provider【%s】导入节点数量：%d", providerName, len(proxies))
 	return proxies, nil
 }
 
@@ -507,6 +510,7 @@ func parseClashConfigData(ctx context.Context, client *http.Client, rootSubscrip
 // fetchUsageInfo: 是否获取用量信息
 // skipTLSVerify: 是否跳过TLS证书验证
 func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr string, subName string, downloadWithProxy bool, proxyLink string, userAgent string, requestHeaders models.AirportRequestHeaders, reporter TaskReporter, fetchUsageInfo bool, skipTLSVerify bool) ([]int, *UsageInfo, error) {
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", id).With("airport", subName)
 	// 检查任务是否已被取消
 	select {
 	case <-ctx.Done():
@@ -528,12 +532,12 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 		if proxyLink != "" {
 			// 使用指定的代理链接
 			proxyNodeLink = proxyLink
-			utils.Info("使用指定代理下载订阅")
+			logger.Info("使用指定代理下载订阅")
 		} else {
 			// 如果没有指定代理，尝试自动选择最佳代理
 			// 获取最近测速成功的节点（延迟最低且速度大于0）
 			if bestNode, err := models.GetBestProxyNode(); err == nil && bestNode != nil {
-				utils.Info("自动选择最佳代理节点: %s 节点延迟：%dms  节点速度：%2fMB/s", bestNode.Name, bestNode.DelayTime, bestNode.Speed)
+				logger.Info("自动选择最佳代理节点: %s 节点延迟：%dms  节点速度：%2fMB/s", bestNode.Name, bestNode.DelayTime, bestNode.Speed)
 				proxyNodeLink = bestNode.Link
 			}
 		}
@@ -542,9 +546,9 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 			// 使用 mihomo 内核创建代理适配器
 			proxyAdapter, err := mihomo.GetMihomoAdapter(proxyNodeLink)
 			if err != nil {
-				utils.Error("创建 mihomo 代理适配器失败: %v，将直接下载", err)
+				logger.Error("创建 mihomo 代理适配器失败: %v，将直接下载", err)
 			} else {
-				utils.Info("使用 mihomo 内核代理下载订阅")
+				logger.Info("使用 mihomo 内核代理下载订阅")
 				// 创建自定义 Transport，使用 mihomo adapter 进行代理连接
 				client.Transport = &http.Transport{
 					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -573,14 +577,16 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 				}
 			}
 		} else {
-			utils.Warn("未找到可用代理，将直接下载")
+			logger.Warn("未找到可用代理，将直接下载")
 		}
 	}
 
+	client.Transport = tracing.Transport(client.Transport)
+
 	// 创建请求并设置 User-Agent（使用传入的 context）
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
 	if err != nil {
-		utils.Error("URL %s，创建请求失败:  %v", urlStr, err)
+		logger.Error("URL %s，创建请求失败:  %v", urlStr, err)
 		return nil, nil, err
 	}
 
@@ -588,7 +594,7 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 
 	resp, err := client.Do(req)
 	if err != nil {
-		utils.Error("URL %s，获取Clash配置失败:  %v", urlStr, err)
+		logger.Error("URL %s，获取Clash配置失败:  %v", urlStr, err)
 		// 检测是否为 TLS 证书相关错误，给出更明确的提示
 		var title, message string
 		if isTLSError(err) {
@@ -625,23 +631,23 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 		if subUserInfo != "" {
 			usageInfo = ParseSubscriptionUserInfo(subUserInfo)
 			if usageInfo != nil {
-				utils.Info("订阅【%s】获取用量信息成功: 上传=%d, 下载=%d, 总量=%d, 过期=%d",
+				logger.Info("订阅【%s】获取用量信息成功: 上传=%d, 下载=%d, 总量=%d, 过期=%d",
 					subName, usageInfo.Upload, usageInfo.Download, usageInfo.Total, usageInfo.Expire)
 			} else {
 				// header 存在但解析失败
-				utils.Warn("订阅【%s】用量信息 header 解析失败", subName)
+				logger.Warn("订阅【%s】用量信息 header 解析失败", subName)
 				usageInfo = FailedUsageInfo()
 			}
 		} else {
 			// 开启了获取但机场未返回 header
-			utils.Warn("订阅【%s】未返回用量信息 header，机场可能不支持", subName)
+			logger.Warn("订阅【%s】未返回用量信息 header，机场可能不支持", subName)
 			usageInfo = FailedUsageInfo()
 		}
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		utils.Error("URL %s，读取Clash配置失败:  %v", urlStr, err)
+		logger.Error("URL %s，读取Clash配置失败:  %v", urlStr, err)
 		// 发送读取失败通知
 		notifications.Publish("subscription.sync_failed", notifications.Payload{
 			Title:   "订阅更新失败",
@@ -673,7 +679,7 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 			errorMessage = providerErr.Error()
 		}
 
-		utils.Error("URL %s，解析失败或未找到节点 (YAML error: %v, provider error: %v)", urlStr, errYaml, providerErr)
+		logger.Error("URL %s，解析失败或未找到节点 (YAML error: %v, provider error: %v)", urlStr, errYaml, providerErr)
 		// 发送解析失败通知
 		notifications.Publish("subscription.sync_failed", notifications.Payload{
 			Title:   "订阅更新失败",
@@ -699,6 +705,7 @@ func LoadClashConfigFromURLWithReporter(ctx context.Context, id int, urlStr stri
 // subName: 订阅名称
 // usageInfo: 订阅用量信息 (可选)
 func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Proxy, subName string, reporter TaskReporter, usageInfo *UsageInfo) ([]int, error) {
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", id).With("airport", subName)
 	if reporter == nil {
 		reporter = &NoOpTaskReporter{}
 	}
@@ -712,7 +719,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	// 确保任务结束时处理异常
 	defer func() {
 		if r := recover(); r != nil {
-			utils.Error("订阅更新任务执行过程中发生严重错误: %v", r)
+			logger.Error("订阅更新任务执行过程中发生严重错误: %v", r)
 			reporter.ReportFail(fmt.Sprintf("任务异常: %v", r))
 		}
 	}()
@@ -720,7 +727,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	// 获取机场的Group信息
 	airport, err := models.GetAirportByID(id)
 	if err != nil {
-		utils.Error("获取机场 %s 的Group失败:  %v", subName, err)
+		logger.Error("获取机场 %s 的Group失败:  %v", subName, err)
 	}
 
 	// 1. 读取全局节点处理配置
@@ -745,7 +752,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		originalCount := len(proxys)
 		proxys = applyAirportNodeFilter(globalAirport, proxys)
 		if len(proxys) < originalCount {
-			utils.Info("🌐全局过滤后节点数量：%d（原始：%d，过滤掉：%d）",
+			logger.Info("🌐全局过滤后节点数量：%d（原始：%d，过滤掉：%d）",
 				len(proxys), originalCount, originalCount-len(proxys))
 		}
 	}
@@ -755,20 +762,20 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		originalCount := len(proxys)
 		proxys = applyAirportNodeFilter(airport, proxys)
 		if len(proxys) < originalCount {
-			utils.Info("📦机场【%s】过滤后节点数量：%d（原始：%d，过滤掉：%d）", subName, len(proxys), originalCount, originalCount-len(proxys))
+			logger.Info("📦机场【%s】过滤后节点数量：%d（原始：%d，过滤掉：%d）", subName, len(proxys), originalCount, originalCount-len(proxys))
 		}
 		// 应用高级去重规则
 		beforeDedup := len(proxys)
 		proxys = applyAirportDeduplication(airport, proxys)
 		if len(proxys) < beforeDedup {
-			utils.Info("🔄机场【%s】去重后节点数量：%d（去重前：%d，去重掉：%d）", subName, len(proxys), beforeDedup, beforeDedup-len(proxys))
+			logger.Info("🔄机场【%s】去重后节点数量：%d（去重前：%d，去重掉：%d）", subName, len(proxys), beforeDedup, beforeDedup-len(proxys))
 		}
 	}
 
 	// 5. 先应用全局重命名规则
 	if globalPreprocess != "" {
 		proxys = applyAirportNodeRename(globalAirport, proxys)
-		utils.Info("🌐应用全局节点名称预处理规则")
+		logger.Info("🌐应用全局节点名称预处理规则")
 	}
 
 	// 6. 再应用机场特定重命名规则
@@ -781,7 +788,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	// 1. 获取该订阅当前在数据库中的所有节点
 	existingNodes, err := models.ListBySourceID(id)
 	if err != nil {
-		utils.Info("获取订阅【%s】现有节点失败: %v", subName, err)
+		logger.Info("获取订阅【%s】现有节点失败: %v", subName, err)
 		existingNodes = []models.Node{} // 确保后续逻辑不会panic
 	}
 
@@ -872,7 +879,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		allNodeHashes = models.GetNodeContentHashesBySourceID(id)
 	}
 
-	utils.Info("📄订阅【%s】获取到订阅数量【%d】，现有节点数量【%d】，哈希数量【%d】，跨机场去重【%v】", subName, len(proxys), len(existingNodes), len(allNodeHashes), enableCrossDedup)
+	logger.Info("📄订阅【%s】获取到订阅数量【%d】，现有节点数量【%d】，哈希数量【%d】，跨机场去重【%v】", subName, len(proxys), len(existingNodes), len(allNodeHashes), enableCrossDedup)
 
 	// 更新任务总数（此时已知道需要处理的节点数量）
 	reporter.UpdateTotal(len(proxys))
@@ -896,11 +903,11 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 			return false
 		}
 		if err := models.UpdateNodeFields(existingNode.ID, map[string]any{"link_country": country}); err != nil {
-			utils.Warn("回填节点【%s】国家失败: %v", nodeName, err)
+			logger.Warn("回填节点【%s】国家失败: %v", nodeName, err)
 			return false
 		}
 		countryBackfilledNodeIDs = append(countryBackfilledNodeIDs, existingNode.ID)
-		utils.Debug("🌍 现存节点【%s】回填国家: %s", nodeName, country)
+		logger.Debug("🌍 现存节点【%s】回填国家: %s", nodeName, country)
 		return true
 	}
 
@@ -909,13 +916,13 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		// 定期检查任务是否已取消或超时（每处理一个节点检查一次）
 		select {
 		case <-ctx.Done():
-			utils.Warn("任务在处理节点时被取消或超时，已处理 %d/%d 个节点", processedCount, len(proxys))
+			logger.Warn("任务在处理节点时被取消或超时，已处理 %d/%d 个节点", processedCount, len(proxys))
 			reporter.ReportFail("任务执行超时或被取消")
 			return nil, fmt.Errorf("任务已取消或超时")
 		default:
 		}
 
-		utils.Info("💾准备存储节点【%s】", proxy.Name)
+		logger.Info("💾准备存储节点【%s】", proxy.Name)
 		var Node models.Node
 
 		// 预处理：去除名称空格，处理 IPv6 地址
@@ -925,14 +932,14 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		// 计算节点内容哈希（用于全库去重）
 		contentHash := protocol.GenerateProxyContentHash(proxy)
 		if contentHash == "" {
-			utils.Warn("节点【%s】生成内容哈希失败，跳过", proxy.Name)
+			logger.Warn("节点【%s】生成内容哈希失败，跳过", proxy.Name)
 			continue
 		}
 
 		// 使用公共函数生成节点链接
 		link := GenerateProxyLink(proxy)
 		if link == "" {
-			utils.Warn("节点【%s】生成链接失败，跳过", proxy.Name)
+			logger.Warn("节点【%s】生成链接失败，跳过", proxy.Name)
 			continue
 		}
 
@@ -956,7 +963,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		if airport != nil && airport.AutoFillCountry && Node.LinkCountry == "" {
 			if country := models.ParseCountryFromNodeName(proxy.Name); country != "" {
 				Node.LinkCountry = country
-				utils.Debug("🌍 新节点【%s】自动填充国家: %s", proxy.Name, country)
+				logger.Debug("🌍 新节点【%s】自动填充国家: %s", proxy.Name, country)
 			}
 		}
 
@@ -980,11 +987,11 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 							nodesToUpdate = append(nodesToUpdate, models.BuildNodeInfoUpdate(existingByName, proxy.Name, link, Node.SourceSort))
 							updateCount++
 							nodeStatus = "updated"
-							utils.Info("✏️ 信息节点【%s】链接/顺序已变更，将更新", proxy.Name)
+							logger.Info("✏️ 信息节点【%s】链接/顺序已变更，将更新", proxy.Name)
 						} else if backfilledCountry {
 							nodeStatus = "updated"
 						} else {
-							utils.Debug("⏭️ 信息节点【%s】在本机场已存在，跳过", proxy.Name)
+							logger.Debug("⏭️ 信息节点【%s】在本机场已存在，跳过", proxy.Name)
 						}
 					} else {
 						// 该名称的信息节点不存在（上游新增了一个信息节点），入库
@@ -992,7 +999,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 						skipCount--
 						addSuccessCount++
 						nodeStatus = "added"
-						utils.Info("📌 信息节点【%s】为新名称，允许入库", proxy.Name)
+						logger.Info("📌 信息节点【%s】为新名称，允许入库", proxy.Name)
 					}
 				} else {
 					// 普通节点：用 hash 匹配，检查名称或链接是否变化
@@ -1003,11 +1010,11 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 						nodesToUpdate = append(nodesToUpdate, models.BuildNodeInfoUpdate(existingNode, proxy.Name, link, Node.SourceSort))
 						updateCount++
 						nodeStatus = "updated"
-						utils.Info("✏️ 节点【%s】原始名称/链接/顺序已变更，将更新 [旧原始名称: %s]", proxy.Name, existingNode.LinkName)
+						logger.Info("✏️ 节点【%s】原始名称/链接/顺序已变更，将更新 [旧原始名称: %s]", proxy.Name, existingNode.LinkName)
 					} else if backfilledCountry {
 						nodeStatus = "updated"
 					} else {
-						utils.Debug("⏭️ 节点【%s】在本机场已存在，跳过", proxy.Name)
+						logger.Debug("⏭️ 节点【%s】在本机场已存在，跳过", proxy.Name)
 					}
 				}
 			} else if enableCrossDedup {
@@ -1019,9 +1026,9 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 						skipCount--
 						addSuccessCount++
 						nodeStatus = "added"
-						utils.Info("📌 节点【%s】与已有节点配置相同但名称不同（信息节点），允许入库 [已有: %s]", proxy.Name, existingNode.Name)
+						logger.Info("📌 节点【%s】与已有节点配置相同但名称不同（信息节点），允许入库 [已有: %s]", proxy.Name, existingNode.Name)
 					} else {
-						utils.Warn("⚠️ 节点【%s】与其他机场重复，跳过 [现有节点: %s] [来源: %s] [分组: %s] [SourceID: %d]", proxy.Name, existingNode.Name, existingNode.Source, existingNode.Group, existingNode.SourceID)
+						logger.Warn("⚠️ 节点【%s】与其他机场重复，跳过 [现有节点: %s] [来源: %s] [分组: %s] [SourceID: %d]", proxy.Name, existingNode.Name, existingNode.Source, existingNode.Group, existingNode.SourceID)
 					}
 				} else {
 					// hash存在于allNodeHashes但缓存中找不到，说明是本次拉取中的内部重复
@@ -1031,11 +1038,11 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 						addSuccessCount++
 						nodeStatus = "added"
 						allNodeHashes[contentHash] = true
-						utils.Info("📌 节点【%s】与本次拉取中其他节点配置相同但名称不同（信息节点），允许入库", proxy.Name)
+						logger.Info("📌 节点【%s】与本次拉取中其他节点配置相同但名称不同（信息节点），允许入库", proxy.Name)
 					} else {
 						hashData := protocol.NormalizeProxyForHash(proxy)
 						jsonBytes, _ := json.Marshal(hashData)
-						utils.Warn("🔄 节点【%s】与本次拉取中的其他节点重复（相同配置），跳过\n    HashData: %s", proxy.Name, string(jsonBytes))
+						logger.Warn("🔄 节点【%s】与本次拉取中的其他节点重复（相同配置），跳过\n    HashData: %s", proxy.Name, string(jsonBytes))
 					}
 				}
 			} else {
@@ -1046,11 +1053,11 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 					addSuccessCount++
 					nodeStatus = "added"
 					allNodeHashes[contentHash] = true
-					utils.Info("📌 节点【%s】与本次拉取中其他节点配置相同但名称不同（信息节点），允许入库", proxy.Name)
+					logger.Info("📌 节点【%s】与本次拉取中其他节点配置相同但名称不同（信息节点），允许入库", proxy.Name)
 				} else {
 					hashData := protocol.NormalizeProxyForHash(proxy)
 					jsonBytes, _ := json.Marshal(hashData)
-					utils.Warn("🔄 节点【%s】与本次拉取中的其他节点重复（相同配置），跳过\n    HashData: %s", proxy.Name, string(jsonBytes))
+					logger.Warn("🔄 节点【%s】与本次拉取中的其他节点重复（相同配置），跳过\n    HashData: %s", proxy.Name, string(jsonBytes))
 				}
 			}
 		} else {
@@ -1099,18 +1106,18 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 		// 检查任务是否已取消或超时（批量操作前检查）
 		select {
 		case <-ctx.Done():
-			utils.Warn("任务在批量添加节点前被取消或超时")
+			logger.Warn("任务在批量添加节点前被取消或超时")
 			reporter.ReportFail("任务执行超时或被取消")
 			return nil, fmt.Errorf("任务已取消或超时")
 		default:
 		}
 
 		if err := models.BatchAddNodes(nodesToAdd); err != nil {
-			utils.Error("❌批量添加节点失败：%v", err)
+			logger.Error("❌批量添加节点失败：%v", err)
 			// 重置计数，因为添加失败
 			addSuccessCount = 0
 		} else {
-			utils.Info("✅批量添加 %d 个节点成功", len(nodesToAdd))
+			logger.Info("✅批量添加 %d 个节点成功", len(nodesToAdd))
 		}
 	}
 
@@ -1118,10 +1125,10 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	actualUpdateCount := 0
 	if len(nodesToUpdate) > 0 {
 		if cnt, err := models.BatchUpdateNodeInfo(nodesToUpdate); err != nil {
-			utils.Error("❌批量更新节点信息失败：%v", err)
+			logger.Error("❌批量更新节点信息失败：%v", err)
 		} else {
 			actualUpdateCount = cnt
-			utils.Info("✏️批量更新 %d 个节点的名称/链接", actualUpdateCount)
+			logger.Info("✏️批量更新 %d 个节点的名称/链接", actualUpdateCount)
 		}
 	}
 
@@ -1129,14 +1136,14 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	deleteCount := 0
 	if len(nodeIDsToDelete) > 0 {
 		if err := models.BatchDel(nodeIDsToDelete); err != nil {
-			utils.Error("❌批量删除节点失败：%v", err)
+			logger.Error("❌批量删除节点失败：%v", err)
 		} else {
 			deleteCount = len(nodeIDsToDelete)
-			utils.Info("🗑️批量删除 %d 个失效节点", deleteCount)
+			logger.Info("🗑️批量删除 %d 个失效节点", deleteCount)
 		}
 	}
 
-	utils.Info("✅订阅【%s】节点同步完成，总节点【%d】个，成功处理【%d】个，新增节点【%d】个，更新节点【%d】个，已存在节点【%d】个，删除失效【%d】个", subName, len(proxys), addSuccessCount+skipCount, addSuccessCount, actualUpdateCount, skipCount, deleteCount)
+	logger.Info("✅订阅【%s】节点同步完成，总节点【%d】个，成功处理【%d】个，新增节点【%d】个，更新节点【%d】个，已存在节点【%d】个，删除失效【%d】个", subName, len(proxys), addSuccessCount+skipCount, addSuccessCount, actualUpdateCount, skipCount, deleteCount)
 
 	// 收集变更和新增的节点ID（用于更新后仅检测变化节点的功能）
 	changedNodeIDs := make([]int, 0, addSuccessCount+actualUpdateCount)
@@ -1155,7 +1162,7 @@ func scheduleClashToNodeLinks(ctx context.Context, id int, proxys []protocol.Pro
 	// 重新查找机场以获取最新信息并更新成功次数
 	airport, err = models.GetAirportByID(id)
 	if err != nil {
-		utils.Error("获取机场 %s 失败:  %v", subName, err)
+		logger.Error("获取机场 %s 失败:  %v", subName, err)
 		return nil, err
 	}
 	airport.SuccessCount = addSuccessCount + skipCount
@@ -1326,6 +1333,7 @@ func applyAirportNodeRename(airport *models.Airport, proxys []protocol.Proxy) []
 // applyAirportDeduplication 应用机场高级去重规则
 // 根据机场配置的去重规则对代理节点进行去重
 func applyAirportDeduplication(airport *models.Airport, proxys []protocol.Proxy) []protocol.Proxy {
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", airport.ID)
 	if airport == nil || airport.DeduplicationRule == "" {
 		return proxys
 	}
@@ -1333,7 +1341,7 @@ func applyAirportDeduplication(airport *models.Airport, proxys []protocol.Proxy)
 	// 解析去重配置
 	var config models.DeduplicationConfig
 	if err := json.Unmarshal([]byte(airport.DeduplicationRule), &config); err != nil {
-		utils.Warn("解析机场去重规则失败: %v", err)
+		logger.Warn("解析机场去重规则失败: %v", err)
 		return proxys
 	}
 
@@ -9,19 +9,22 @@ import (
 	"strconv"
 	"sublink/models"
 	"sublink/services/mihomo"
+	"sublink/services/tracing"
 	"sublink/utils"
 	"sync"
 	"time"
 
 	"github.com/metacubex/mihomo/constant"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // FetchAirportUsageInfo 独立获取单个机场的用量信息
 // 仅请求订阅地址获取 subscription-userinfo header，不解析节点内容
-func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
+func FetchAirportUsageInfo(ctx context.Context, airport *models.Airport) (*UsageInfo, error) {
 	if airport == nil {
 		return nil, fmt.Errorf("机场对象为空")
 	}
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", airport.ID).With("airport", airport.Name)
 
 	if !airport.FetchUsageInfo {
 		return nil, fmt.Errorf("机场未开启用量信息获取")
@@ -40,11 +43,11 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 
 		if airport.ProxyLink != "" {
 			proxyNodeLink = airport.ProxyLink
-			utils.Info("用量获取使用指定代理")
+			logger.Info("用量获取使用指定代理")
 		} else {
 			// 自动选择最佳代理
 			if bestNode, err := models.GetBestProxyNode(); err == nil && bestNode != nil {
-				utils.Info("用量获取自动选择代理节点: %s", bestNode.Name)
+				logger.Info("用量获取自动选择代理节点: %s", bestNode.Name)
 				proxyNodeLink = bestNode.Link
 			}
 		}
@@ -52,7 +55,7 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 		if proxyNodeLink != "" {
 			proxyAdapter, err := mihomo.GetMihomoAdapter(proxyNodeLink)
 			if err != nil {
-				utils.Error("创建代理适配器失败: %v，将直接请求", err)
+				logger.Error("创建代理适配器失败: %v，将直接请求", err)
 			} else {
 				client.Transport = &http.Transport{
 					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -80,6 +83,8 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 		}
 	}
 
+	client.Transport = tracing.Transport(client.Transport)
+
 	// 设置通用 User-Agent
 	userAgent := "clash.meta"
 	if airport.UserAgent != "" {
@@ -88,7 +93,7 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 
 	// 优先使用 HEAD 请求，减少数据传输
 	var resp *http.Response
-	headReq, err := http.NewRequestWithContext(context.Background(), http.MethodHead, airport.URL, nil)
+	headReq, err := http.NewRequestWithContext(ctx, http.MethodHead, airport.URL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -101,12 +106,12 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 			_ = resp.Body.Close()
 		}
 		if err != nil {
-			utils.Debug("机场【%s】HEAD 请求失败: %v，尝试 GET 请求", airport.Name, err)
+			logger.Debug("机场【%s】HEAD 请求失败: %v，尝试 GET 请求", airport.Name, err)
 		} else {
-			utils.Debug("机场【%s】HEAD 请求返回状态码 %d，尝试 GET 请求", airport.Name, resp.StatusCode)
+			logger.Debug("机场【%s】HEAD 请求返回状态码 %d，尝试 GET 请求", airport.Name, resp.StatusCode)
 		}
 
-		getReq, err := http.NewRequestWithContext(context.Background(), http.MethodGet, airport.URL, nil)
+		getReq, err := http.NewRequestWithContext(ctx, http.MethodGet, airport.URL, nil)
 		if err != nil {
 			return nil, fmt.Errorf("创建请求失败: %v", err)
 		}
@@ -122,17 +127,17 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 	// 解析 subscription-userinfo header
 	subUserInfo := resp.Header.Get("subscription-userinfo")
 	if subUserInfo == "" {
-		utils.Warn("机场【%s】未返回用量信息 header", airport.Name)
+		logger.Warn("机场【%s】未返回用量信息 header", airport.Name)
 		return FailedUsageInfo(), nil
 	}
 
 	usageInfo := ParseSubscriptionUserInfo(subUserInfo)
 	if usageInfo == nil {
-		utils.Warn("机场【%s】用量信息 header 解析失败", airport.Name)
+		logger.Warn("机场【%s】用量信息 header 解析失败", airport.Name)
 		return FailedUsageInfo(), nil
 	}
 
-	utils.Info("机场【%s】用量获取成功: 上传=%d, 下载=%d, 总量=%d, 过期=%d",
+	logger.Info("机场【%s】用量获取成功: 上传=%d, 下载=%d, 总量=%d, 过期=%d",
 		airport.Name, usageInfo.Upload, usageInfo.Download, usageInfo.Total, usageInfo.Expire)
 
 	return usageInfo, nil
@@ -140,7 +145,8 @@ func FetchAirportUsageInfo(airport *models.Airport) (*UsageInfo, error) {
 
 // UpdateAirportUsageInfo 获取并保存机场用量到数据库
 // 返回最新的 UsageInfo 或错误
-func UpdateAirportUsageInfo(airportID int) (*UsageInfo, error) {
+func UpdateAirportUsageInfo(ctx context.Context, airportID int) (*UsageInfo, error) {
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", airportID)
 	airport, err := models.GetAirportByID(airportID)
 	if err != nil {
 		return nil, fmt.Errorf("获取机场失败: %v", err)
@@ -154,7 +160,7 @@ func UpdateAirportUsageInfo(airportID int) (*UsageInfo, error) {
 		return nil, fmt.Errorf("机场【%s】未开启用量信息获取", airport.Name)
 	}
 
-	usageInfo, err := FetchAirportUsageInfo(airport)
+	usageInfo, err := FetchAirportUsageInfo(ctx, airport)
 	if err != nil {
 		return nil, err
 	}
@@ -162,10 +168,10 @@ func UpdateAirportUsageInfo(airportID int) (*UsageInfo, error) {
 	// 保存到数据库
 	if usageInfo != nil {
 		if err := airport.UpdateUsageInfo(usageInfo.Upload, usageInfo.Download, usageInfo.Total, usageInfo.Expire); err != nil {
-			utils.Error("保存机场【%s】用量信息失败: %v", airport.Name, err)
+			logger.Error("保存机场【%s】用量信息失败: %v", airport.Name, err)
 			return usageInfo, fmt.Errorf("保存用量信息失败: %v", err)
 		}
-		utils.Info("机场【%s】用量信息已保存", airport.Name)
+		logger.Info("机场【%s】用量信息已保存", airport.Name)
 	}
 
 	return usageInfo, nil
@@ -182,7 +188,8 @@ type UsageResult struct {
 // BatchUpdateAirportUsage 批量更新多个机场的用量信息
 // 并发获取每个机场的用量信息并更新到数据库
 // 返回各机场的用量结果映射
-func BatchUpdateAirportUsage(airportIDs []int) map[int]*UsageResult {
+func BatchUpdateAirportUsage(ctx context.Context, airportIDs []int) map[int]*UsageResult {
+	logger := utils.WithSubsystem(utils.SubsystemAirport)
 	var wg sync.WaitGroup
 	var resultsMap sync.Map
 
@@ -218,7 +225,7 @@ func BatchUpdateAirportUsage(airportIDs []int) map[int]*UsageResult {
 				return
 			}
 
-			usageInfo, err := UpdateAirportUsageInfo(airportID)
+			usageInfo, err := UpdateAirportUsageInfo(ctx, airportID)
 			resultsMap.Store(airportID, &UsageResult{
 				AirportID:   airportID,
 				AirportName: airport.Name,
@@ -236,12 +243,12 @@ func BatchUpdateAirportUsage(airportIDs []int) map[int]*UsageResult {
 	resultsMap.Range(func(key, value any) bool {
 		airportID, ok := key.(int)
 		if !ok {
-			utils.Error("机场用量结果键类型异常: %T", key)
+			logger.Error("机场用量结果键类型异常: %T", key)
 			return true
 		}
 		result, ok := value.(*UsageResult)
 		if !ok {
-			utils.Error("机场用量结果值类型异常: %T", value)
+			logger.Error("机场用量结果值类型异常: %T", value)
 			return true
 		}
 		results[airportID] = result
@@ -253,7 +260,8 @@ func BatchUpdateAirportUsage(airportIDs []int) map[int]*UsageResult {
 
 // RefreshUsageForSubscriptionNodes 为订阅的节点刷新关联机场的用量信息
 // 收集节点所属的所有机场ID，批量获取用量信息
-func RefreshUsageForSubscriptionNodes(nodes []models.Node) {
+func RefreshUsageForSubscriptionNodes(ctx context.Context, nodes []models.Node) {
+	logger := utils.WithSubsystem(utils.SubsystemAirport)
 	// 收集所有开启 FetchUsageInfo 的机场ID
 	airportIDs := make(map[int]bool)
 	for _, node := range nodes {
@@ -263,7 +271,7 @@ func RefreshUsageForSubscriptionNodes(nodes []models.Node) {
 	}
 
 	if len(airportIDs) == 0 {
-		utils.Debug("没有需要刷新用量的机场")
+		logger.Debug("没有需要刷新用量的机场")
 		return
 	}
 
@@ -273,10 +281,12 @@ func RefreshUsageForSubscriptionNodes(nodes []models.Node) {
 		ids = append(ids, id)
 	}
 
-	utils.Info("开始刷新 %d 个机场的用量信息", len(ids))
+	logger.Info("开始刷新 %d 个机场的用量信息", len(ids))
+	ctx, span := tracing.Start(ctx, "subscription.refresh_usage", attribute.Int("sublink.airports", len(ids)))
+	defer span.End()
 
 	// 批量更新用量
-	results := BatchUpdateAirportUsage(ids)
+	results := BatchUpdateAirportUsage(ctx, ids)
 
 	// 统计结果
 	successCount := 0
@@ -285,7 +295,7 @@ func RefreshUsageForSubscriptionNodes(nodes []models.Node) {
 	for _, result := range results {
 		if result.Error != nil {
 			failCount++
-			utils.Error("机场【%s】用量刷新失败: %v", result.AirportName, result.Error)
+			logger.Error("机场【%s】用量刷新失败: %v", result.AirportName, result.Error)
 		} else if result.UsageInfo != nil {
 			successCount++
 		} else {
@@ -293,5 +303,6 @@ func RefreshUsageForSubscriptionNodes(nodes []models.Node) {
 		}
 	}
 
-	utils.Info("用量刷新完成: 成功=%d, 失败=%d, 跳过=%d", successCount, failCount, skipCount)
+	span.SetAttributes(attribute.Int("sublink.airports.failed", failCount))
+	logger.Info("用量刷新完成: 成功=%d, 失败=%d, 跳过=%d", successCount, failCount, skipCount)
 }
@@ -9,11 +9,12 @@ import (
 
 func AccessKey(r *gin.Engine) {
 	accessKeyGroup := r.Group("/api/v1/accesskey")
-	accessKeyGroup.Use(middlewares.AuthToken)
+	accessKeyGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// 演示模式下禁止创建/删除 AccessKey
 		accessKeyGroup.POST("/add", middlewares.DemoModeRestrict, api.GenerateAccessKey)
 		accessKeyGroup.DELETE("/delete/:accessKeyId", middlewares.DemoModeRestrict, api.DeleteAccessKey)
 		accessKeyGroup.GET("/get/:userId", api.GetAccessKey)
+		accessKeyGroup.GET("/scopes", api.GetAccessKeyScopes)
 	}
 }
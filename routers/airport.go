@@ -10,20 +10,20 @@ import (
 // Airport 注册机场管理相关路由
 func Airport(r *gin.Engine) {
 	airportGroup := r.Group("/api/v1/airports")
-	airportGroup.Use(middlewares.AuthToken)
+	airportGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// 列表和详情
 		airportGroup.GET("", api.AirportList)
 		airportGroup.GET("/:id", api.AirportGet)
 		// 增删改（演示模式下限制）
-		airportGroup.POST("", middlewares.DemoModeRestrict, api.AirportAdd)
-		airportGroup.POST("/batch-update", middlewares.DemoModeRestrict, api.AirportBatchUpdate)
-		airportGroup.PUT("/:id", middlewares.DemoModeRestrict, api.AirportUpdate)
-		airportGroup.DELETE("/:id", middlewares.DemoModeRestrict, api.AirportDelete)
+		airportGroup.POST("", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportAdd)
+		airportGroup.POST("/batch-update", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportBatchUpdate)
+		airportGroup.PUT("/:id", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportUpdate)
+		airportGroup.DELETE("/:id", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.AirportDelete)
 		// 手动拉取
-		airportGroup.POST("/pull-all", middlewares.DemoModeRestrict, api.AirportPullAll)
-		airportGroup.POST("/:id/pull", middlewares.DemoModeRestrict, api.AirportPull)
+		airportGroup.POST("/pull-all", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportPullAll)
+		airportGroup.POST("/:id/pull", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportPull)
 		// 刷新用量信息
-		airportGroup.POST("/:id/refresh-usage", middlewares.DemoModeRestrict, api.AirportRefreshUsage)
+		airportGroup.POST("/:id/refresh-usage", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.AirportRefreshUsage)
 	}
 }
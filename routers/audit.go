@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"sublink/api"
+	"sublink/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit 注册审计日志相关路由，仅管理员可访问
+func Audit(r *gin.Engine) {
+	auditGroup := r.Group("/api/v1/audit")
+	auditGroup.Use(middlewares.AuthToken, middlewares.AuditLog, middlewares.RequireAdmin)
+	{
+		auditGroup.GET("/logs", api.GetAuditLogs)
+		auditGroup.GET("/logs/options", api.GetAuditLogOptions)
+		auditGroup.GET("/settings", api.GetAuditSettings)
+		auditGroup.POST("/settings", middlewares.DemoModeRestrict, api.UpdateAuditSettings)
+	}
+}
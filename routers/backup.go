@@ -9,10 +9,18 @@ import (
 
 func Backup(r *gin.Engine) {
 	BackupGroup := r.Group("/api/v1/backup")
-	BackupGroup.Use(middlewares.AuthToken)
+	BackupGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// 演示模式下禁止备份
-		BackupGroup.GET("/download", middlewares.DemoModeRestrict, api.Backup)
+		BackupGroup.GET("/download", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.Backup)
+		BackupGroup.GET("/settings", middlewares.RequireAdmin, api.GetBackupSettings)
+		BackupGroup.POST("/settings", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.UpdateBackupSettings)
+		BackupGroup.POST("/s3/test", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.TestBackupS3)
+		BackupGroup.POST("/run", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.RunBackupNow)
+		BackupGroup.GET("/list", middlewares.RequireAdmin, api.ListBackups)
+		BackupGroup.GET("/files/:name", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.DownloadBackupFile)
+		BackupGroup.POST("/restore", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.RestoreBackup)
+		BackupGroup.POST("/check-key", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.CheckBackupKey)
 	}
 
 }
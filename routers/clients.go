@@ -16,6 +16,8 @@ func Clients(r *gin.Engine) {
 		// ClientsGroup.GET("/surge/:subname", api.GetSurge)
 		ClientsGroup.GET("/", api.GetClient)
 		ClientsGroup.HEAD("/", api.GetClient)
+		// 规则镜像文件（使用分享 token 鉴权）
+		ClientsGroup.GET("/rules/:file", api.ServeRuleMirror)
 	}
 
 }
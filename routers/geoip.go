@@ -10,12 +10,12 @@ import (
 // GeoIP 注册 GeoIP 相关路由
 func GeoIP(r *gin.Engine) {
 	geoipGroup := r.Group("/api/v1/geoip")
-	geoipGroup.Use(middlewares.AuthToken)
+	geoipGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		geoipGroup.GET("/config", api.GetGeoIPConfig)
-		geoipGroup.PUT("/config", api.SaveGeoIPConfig)
+		geoipGroup.PUT("/config", middlewares.RequireAdmin, api.SaveGeoIPConfig)
 		geoipGroup.GET("/status", api.GetGeoIPStatus)
-		geoipGroup.POST("/download", api.DownloadGeoIP)
-		geoipGroup.POST("/stop", api.StopGeoIPDownload)
+		geoipGroup.POST("/download", middlewares.RequireOperator, api.DownloadGeoIP)
+		geoipGroup.POST("/stop", middlewares.RequireOperator, api.StopGeoIPDownload)
 	}
 }
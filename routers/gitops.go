@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"sublink/api"
+	"sublink/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GitOps(r *gin.Engine) {
+	GitOpsGroup := r.Group("/api/v1/gitops")
+	GitOpsGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
+	{
+		// 导出内容包含机场地址与分享 token，演示模式下禁止
+		GitOpsGroup.GET("/export", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.ExportConfiguration)
+		GitOpsGroup.POST("/plan", middlewares.RequireAdmin, api.PlanConfiguration)
+		GitOpsGroup.POST("/apply", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.ApplyConfiguration)
+	}
+
+}
@@ -9,10 +9,10 @@ import (
 
 func GroupSort(r *gin.Engine) {
 	groupSortGroup := r.Group("/api/v1/group-sort")
-	groupSortGroup.Use(middlewares.AuthToken)
+	groupSortGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		groupSortGroup.GET("/groups", api.GroupSortGroups)
 		groupSortGroup.GET("/detail", api.GroupSortDetail)
-		groupSortGroup.POST("/save", api.GroupSortSave)
+		groupSortGroup.POST("/save", middlewares.RequireOperator, api.GroupSortSave)
 	}
 }
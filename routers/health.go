@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"sublink/api"
+	"sublink/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Health 注册存活/就绪探针与系统自检路由，探针无需登录，自检报告仅管理员可访问
+func Health(r *gin.Engine) {
+	r.GET("/healthz", api.Healthz)
+	r.GET("/readyz", api.Readyz)
+
+	systemGroup := r.Group("/api/v1/system")
+	systemGroup.Use(middlewares.AuthToken, middlewares.AuditLog, middlewares.RequireAdmin)
+	{
+		systemGroup.GET("/diagnostics", api.GetDiagnostics)
+	}
+}
@@ -10,24 +10,24 @@ import (
 // Host 注册 Host 相关路由
 func Host(r *gin.Engine) {
 	hostGroup := r.Group("/api/v1/hosts")
-	hostGroup.Use(middlewares.AuthToken)
+	hostGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// Host 管理
 		hostGroup.GET("/list", api.HostList)
-		hostGroup.POST("/add", api.HostAdd)
-		hostGroup.POST("/update", api.HostUpdate)
-		hostGroup.DELETE("/delete", api.HostDelete)
-		hostGroup.DELETE("/batch-delete", api.HostBatchDelete)
+		hostGroup.POST("/add", middlewares.RequireOperator, api.HostAdd)
+		hostGroup.POST("/update", middlewares.RequireOperator, api.HostUpdate)
+		hostGroup.DELETE("/delete", middlewares.RequireAdmin, api.HostDelete)
+		hostGroup.DELETE("/batch-delete", middlewares.RequireAdmin, api.HostBatchDelete)
 
 		// 文本模式
 		hostGroup.GET("/export", api.HostExport)
-		hostGroup.POST("/sync", api.HostSync)
+		hostGroup.POST("/sync", middlewares.RequireAdmin, api.HostSync)
 
 		// 模块设置
 		hostGroup.GET("/settings", api.GetHostSettings)
-		hostGroup.POST("/settings", api.UpdateHostSettings)
+		hostGroup.POST("/settings", middlewares.RequireAdmin, api.UpdateHostSettings)
 
 		// Pin 固定
-		hostGroup.POST("/pin", api.HostSetPinned)
+		hostGroup.POST("/pin", middlewares.RequireOperator, api.HostSetPinned)
 	}
 }
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"sublink/api"
+	"sublink/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logs 注册运行日志查询与日志等级路由，仅管理员可访问
+func Logs(r *gin.Engine) {
+	logsGroup := r.Group("/api/v1/logs")
+	logsGroup.Use(middlewares.AuthToken, middlewares.AuditLog, middlewares.RequireAdmin)
+	{
+		logsGroup.GET("", api.GetRecentLogs)
+		logsGroup.GET("/levels", api.GetLogLevels)
+		logsGroup.POST("/levels", middlewares.DemoModeRestrict, api.UpdateLogLevel)
+	}
+}
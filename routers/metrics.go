@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"sublink/middlewares"
+	"sublink/services/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 注册 Prometheus 指标导出路由，需管理员账号或带 metrics:read 权限的 API Key
+func Metrics(r *gin.Engine) {
+	r.GET("/metrics", middlewares.AuthToken, middlewares.RequireAdmin, gin.WrapH(metrics.Handler()))
+}
@@ -9,34 +9,34 @@ import (
 
 func Nodes(r *gin.Engine) {
 	NodesGroup := r.Group("/api/v1/nodes")
-	NodesGroup.Use(middlewares.AuthToken)
+	NodesGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
-		NodesGroup.POST("/add", api.NodeAdd)
-		NodesGroup.DELETE("/delete", api.NodeDel)
-		NodesGroup.DELETE("/batch-delete", api.NodeBatchDel)
-		NodesGroup.POST("/batch-update-group", api.NodeBatchUpdateGroup)
-		NodesGroup.POST("/batch-update-dialer-proxy", api.NodeBatchUpdateDialerProxy)
-		NodesGroup.POST("/batch-update-source", api.NodeBatchUpdateSource)
-		NodesGroup.POST("/batch-update-country", api.NodeBatchUpdateCountry)
-		NodesGroup.POST("/batch-fill-country", api.NodeBatchFillCountry)
+		NodesGroup.POST("/add", middlewares.RequireOperator, api.NodeAdd)
+		NodesGroup.DELETE("/delete", middlewares.RequireAdmin, api.NodeDel)
+		NodesGroup.DELETE("/batch-delete", middlewares.RequireAdmin, api.NodeBatchDel)
+		NodesGroup.POST("/batch-update-group", middlewares.RequireOperator, api.NodeBatchUpdateGroup)
+		NodesGroup.POST("/batch-update-dialer-proxy", middlewares.RequireOperator, api.NodeBatchUpdateDialerProxy)
+		NodesGroup.POST("/batch-update-source", middlewares.RequireOperator, api.NodeBatchUpdateSource)
+		NodesGroup.POST("/batch-update-country", middlewares.RequireOperator, api.NodeBatchUpdateCountry)
+		NodesGroup.POST("/batch-fill-country", middlewares.RequireOperator, api.NodeBatchFillCountry)
 		NodesGroup.GET("/get", api.NodeGet)
 		NodesGroup.GET("/selector", api.NodeSelector)
 		NodesGroup.GET("/selector/by-ids", api.NodeSelectorByIDs)
 		NodesGroup.GET("/ids", api.NodeGetIDs)
-		NodesGroup.POST("/update", api.NodeUpdadte)
+		NodesGroup.POST("/update", middlewares.RequireOperator, api.NodeUpdadte)
 		NodesGroup.GET("/groups", api.GetGroups)
 		NodesGroup.GET("/group-stats", api.NodeGroupStats)
 		NodesGroup.GET("/sources", api.GetSources)
 		NodesGroup.GET("/countries", api.GetNodeCountries)
 		NodesGroup.GET("/ip-info", api.GetIPDetails)
 		NodesGroup.GET("/ip-cache/stats", api.GetIPCacheStats)
-		NodesGroup.DELETE("/ip-cache", api.ClearIPCache)
+		NodesGroup.DELETE("/ip-cache", middlewares.RequireOperator, api.ClearIPCache)
 		NodesGroup.GET("/protocols", api.GetNodeProtocols)
 		// 节点原始信息相关
 		NodesGroup.GET("/protocol-ui-meta", api.GetProtocolUIMeta)
 		NodesGroup.GET("/parse-link", api.ParseNodeLinkAPI)
 		NodesGroup.GET("/raw-info", api.GetNodeRawInfo)
-		NodesGroup.POST("/update-raw", middlewares.DemoModeRestrict, api.UpdateNodeRawInfo)
+		NodesGroup.POST("/update-raw", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.UpdateNodeRawInfo)
 	}
 
 }
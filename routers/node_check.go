@@ -10,19 +10,19 @@ import (
 // NodeCheck 注册节点检测策略相关路由
 func NodeCheck(r *gin.Engine) {
 	group := r.Group("/api/v1/node-check")
-	group.Use(middlewares.AuthToken)
+	group.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		group.GET("/meta", api.GetNodeCheckMeta)
 
 		// 策略管理
 		group.GET("/profiles", api.ListNodeCheckProfiles)
 		group.GET("/profiles/:id", api.GetNodeCheckProfile)
-		group.POST("/profiles", middlewares.DemoModeRestrict, api.CreateNodeCheckProfile)
-		group.PUT("/profiles/:id", middlewares.DemoModeRestrict, api.UpdateNodeCheckProfile)
-		group.DELETE("/profiles/:id", middlewares.DemoModeRestrict, api.DeleteNodeCheckProfile)
-		group.POST("/profiles/:id/run", middlewares.DemoModeRestrict, api.RunNodeCheckWithProfile)
+		group.POST("/profiles", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.CreateNodeCheckProfile)
+		group.PUT("/profiles/:id", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.UpdateNodeCheckProfile)
+		group.DELETE("/profiles/:id", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.DeleteNodeCheckProfile)
+		group.POST("/profiles/:id/run", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.RunNodeCheckWithProfile)
 
 		// 执行检测
-		group.POST("/run", middlewares.DemoModeRestrict, api.RunNodeCheck)
+		group.POST("/run", middlewares.RequireOperator, middlewares.DemoModeRestrict, api.RunNodeCheck)
 	}
 }
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"sublink/api"
+	"sublink/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RuleMirror 注册规则镜像相关路由
+func RuleMirror(r *gin.Engine) {
+	ruleMirrorGroup := r.Group("/api/v1/rule-mirrors")
+	ruleMirrorGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
+	{
+		ruleMirrorGroup.GET("/list", api.GetRuleMirrors)
+		ruleMirrorGroup.POST("/settings", middlewares.RequireAdmin, api.UpdateRuleMirrorSettings)
+		ruleMirrorGroup.POST("/refresh", middlewares.RequireOperator, api.RefreshRuleMirrors)
+		ruleMirrorGroup.POST("/enabled", middlewares.RequireOperator, api.SetRuleMirrorEnabled)
+	}
+}
@@ -9,12 +9,12 @@ import (
 
 func Script(r *gin.Engine) {
 	ScriptGroup := r.Group("/api/v1/script")
-	ScriptGroup.Use(middlewares.AuthToken)
+	ScriptGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// 演示模式下禁止修改脚本
-		ScriptGroup.POST("/add", middlewares.DemoModeRestrict, api.ScriptAdd)
-		ScriptGroup.DELETE("/delete", middlewares.DemoModeRestrict, api.ScriptDel)
-		ScriptGroup.POST("/update", middlewares.DemoModeRestrict, api.ScriptUpdate)
+		ScriptGroup.POST("/add", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.ScriptAdd)
+		ScriptGroup.DELETE("/delete", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.ScriptDel)
+		ScriptGroup.POST("/update", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.ScriptUpdate)
 		ScriptGroup.GET("/usage", api.GetScriptUsage)
 		ScriptGroup.GET("/list", api.ScriptList)
 	}
@@ -10,16 +10,32 @@ import (
 // Share 注册分享管理路由
 func Share(r *gin.Engine) {
 	shareGroup := r.Group("/api/v1/shares")
-	shareGroup.Use(middlewares.AuthToken)
+	shareGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
-		shareGroup.GET("/get", api.ShareGet)                   // 获取订阅的所有分享（支持分页）
-		shareGroup.POST("/add", api.ShareAdd)                  // 创建新分享
-		shareGroup.POST("/update", api.ShareUpdate)            // 更新分享
-		shareGroup.DELETE("/delete", api.ShareDelete)          // 删除分享
-		shareGroup.POST("/refresh", api.ShareRefreshToken)     // 刷新Token
-		shareGroup.GET("/logs", api.ShareLogs)                 // 获取分享访问日志
-		shareGroup.POST("/batch-add", api.ShareBatchAdd)       // 批量创建分享
-		shareGroup.POST("/batch-delete", api.ShareBatchDelete) // 批量删除分享
-		shareGroup.POST("/batch-update", api.ShareBatchUpdate) // 批量更新分享
+		shareGroup.GET("/get", api.ShareGet)                                                  // 获取订阅的所有分享（支持分页）
+		shareGroup.POST("/add", middlewares.RequireOperator, api.ShareAdd)                    // 创建新分享
+		shareGroup.POST("/update", middlewares.RequireOperator, api.ShareUpdate)              // 更新分享
+		shareGroup.DELETE("/delete", middlewares.RequireAdmin, api.ShareDelete)               // 删除分享
+		shareGroup.POST("/refresh", middlewares.RequireOperator, api.ShareRefreshToken)       // 刷新Token
+		shareGroup.GET("/logs", api.ShareLogs)                                                // 获取分享访问日志
+		shareGroup.GET("/access-usage", api.ShareAccessUsage)                                 // 获取分享访问限制统计
+		shareGroup.POST("/batch-add", middlewares.RequireOperator, api.ShareBatchAdd)         // 批量创建分享
+		shareGroup.POST("/batch-delete", middlewares.RequireAdmin, api.ShareBatchDelete)      // 批量删除分享
+		shareGroup.POST("/batch-update", middlewares.RequireOperator, api.ShareBatchUpdate)   // 批量更新分享
+		shareGroup.POST("/sign", middlewares.RequireOperator, api.ShareSign)                  // 生成临时签名链接
+		shareGroup.POST("/sign-key/rotate", middlewares.RequireAdmin, api.ShareRotateSignKey) // 更换签名密钥
+		shareGroup.GET("/devices", api.ShareDevices)                                          // 获取分享绑定的设备
+		shareGroup.POST("/devices/reset", middlewares.RequireOperator, api.ShareResetDevices) // 解除设备绑定
+		shareGroup.GET("/usage", api.ShareUsage)                                              // 获取分享分摊后的用量
+	}
+
+	// 分享访问统计
+	analyticsGroup := shareGroup.Group("/analytics")
+	{
+		analyticsGroup.GET("/daily", api.ShareAnalyticsDaily)                                        // 获取每日访问汇总
+		analyticsGroup.GET("/clients", api.ShareAnalyticsClients)                                    // 获取访问最多的客户端
+		analyticsGroup.GET("/unusual", api.ShareAnalyticsUnusual)                                    // 获取异常访问列表
+		analyticsGroup.GET("/settings", api.ShareAnalyticsSettings)                                  // 获取访问明细保留天数
+		analyticsGroup.POST("/settings", middlewares.RequireAdmin, api.ShareAnalyticsUpdateSettings) // 更新访问明细保留天数
 	}
 }
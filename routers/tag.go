@@ -9,28 +9,28 @@ import (
 
 func Tag(r *gin.Engine) {
 	tagGroup := r.Group("/api/v1/tags")
-	tagGroup.Use(middlewares.AuthToken)
+	tagGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		// 标签管理
 		tagGroup.GET("/list", api.TagGet)
 		tagGroup.GET("/groups", api.TagGroupList)
-		tagGroup.POST("/add", api.TagAdd)
-		tagGroup.POST("/update", api.TagUpdate)
-		tagGroup.DELETE("/delete", api.TagDelete)
+		tagGroup.POST("/add", middlewares.RequireOperator, api.TagAdd)
+		tagGroup.POST("/update", middlewares.RequireOperator, api.TagUpdate)
+		tagGroup.DELETE("/delete", middlewares.RequireAdmin, api.TagDelete)
 
 		// 规则管理
 		tagGroup.GET("/rules", api.TagRuleGet)
-		tagGroup.POST("/rules/add", api.TagRuleAdd)
-		tagGroup.POST("/rules/update", api.TagRuleUpdate)
-		tagGroup.DELETE("/rules/delete", api.TagRuleDelete)
-		tagGroup.POST("/rules/trigger", api.TagRuleTrigger)
+		tagGroup.POST("/rules/add", middlewares.RequireOperator, api.TagRuleAdd)
+		tagGroup.POST("/rules/update", middlewares.RequireOperator, api.TagRuleUpdate)
+		tagGroup.DELETE("/rules/delete", middlewares.RequireAdmin, api.TagRuleDelete)
+		tagGroup.POST("/rules/trigger", middlewares.RequireOperator, api.TagRuleTrigger)
 
 		// 节点标签操作
-		tagGroup.POST("/node/add", api.NodeAddTag)
-		tagGroup.POST("/node/remove", api.NodeRemoveTag)
-		tagGroup.POST("/node/batch-add", api.NodeBatchAddTag)
-		tagGroup.POST("/node/batch-set", api.NodeBatchSetTags)
-		tagGroup.POST("/node/batch-remove", api.NodeBatchRemoveTags)
+		tagGroup.POST("/node/add", middlewares.RequireOperator, api.NodeAddTag)
+		tagGroup.POST("/node/remove", middlewares.RequireOperator, api.NodeRemoveTag)
+		tagGroup.POST("/node/batch-add", middlewares.RequireOperator, api.NodeBatchAddTag)
+		tagGroup.POST("/node/batch-set", middlewares.RequireOperator, api.NodeBatchSetTags)
+		tagGroup.POST("/node/batch-remove", middlewares.RequireOperator, api.NodeBatchRemoveTags)
 		tagGroup.GET("/node/tags", api.GetNodeTags)
 	}
 }
@@ -9,19 +9,20 @@ import (
 
 func Templates(r *gin.Engine) {
 	TempsGroup := r.Group("/api/v1/template")
-	TempsGroup.Use(middlewares.AuthToken)
+	TempsGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
-		TempsGroup.POST("/add", api.AddTemp)
-		TempsGroup.POST("/delete", api.DelTemp)
+		TempsGroup.POST("/add", middlewares.RequireOperator, api.AddTemp)
+		TempsGroup.POST("/delete", middlewares.RequireAdmin, api.DelTemp)
 		TempsGroup.GET("/usage", api.GetTemplateUsage)
+		TempsGroup.GET("/render", api.RenderTemplatePreview)
 		TempsGroup.GET("/get", api.GetTempS)
-		TempsGroup.POST("/update", api.UpdateTemp)
+		TempsGroup.POST("/update", middlewares.RequireOperator, api.UpdateTemp)
 		TempsGroup.GET("/presets", api.GetACL4SSRPresets)
-		TempsGroup.POST("/convert", api.ConvertRules)
-		TempsGroup.POST("/ai/edit-sessions/stream", api.StartTemplateAIEditSessionStream)
+		TempsGroup.POST("/convert", middlewares.RequireOperator, api.ConvertRules)
+		TempsGroup.POST("/ai/edit-sessions/stream", middlewares.RequireOperator, api.StartTemplateAIEditSessionStream)
 		TempsGroup.GET("/ai/edit-sessions/:sessionId", api.GetTemplateAIEditSession)
-		TempsGroup.POST("/ai/edit-sessions/:sessionId/accept", api.AcceptTemplateAIEditSession)
-		TempsGroup.POST("/ai/edit-sessions/:sessionId/discard", api.DiscardTemplateAIEditSession)
+		TempsGroup.POST("/ai/edit-sessions/:sessionId/accept", middlewares.RequireOperator, api.AcceptTemplateAIEditSession)
+		TempsGroup.POST("/ai/edit-sessions/:sessionId/discard", middlewares.RequireOperator, api.DiscardTemplateAIEditSession)
 		TempsGroup.POST("/ai/generate", api.TemplateAILegacyRemoved)
 		TempsGroup.POST("/ai/generate-stream", api.TemplateAILegacyRemoved)
 		TempsGroup.POST("/ai/validate", api.TemplateAILegacyRemoved)
@@ -9,7 +9,7 @@ import (
 
 func Total(r *gin.Engine) {
 	TotalGroup := r.Group("/api/v1/total")
-	TotalGroup.Use(middlewares.AuthToken)
+	TotalGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		TotalGroup.GET("/sub", api.SubTotal)
 		TotalGroup.GET("/node", api.NodesTotal)
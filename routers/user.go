@@ -13,12 +13,20 @@ func User(r *gin.Engine) {
 		authGroup.POST("/login", api.UserLogin)
 		authGroup.POST("/mfa/verify-totp", api.VerifyTOTPLogin)
 		authGroup.POST("/mfa/verify-recovery-code", api.VerifyRecoveryCodeLogin)
+		authGroup.POST("/mfa/passkey/begin", api.BeginPasskeyMFA)
+		authGroup.POST("/mfa/verify-passkey", api.VerifyPasskeyMFALogin)
+		authGroup.GET("/passkey", api.PasskeyStatus)
+		authGroup.POST("/passkey/begin", api.BeginPasskeyLogin)
+		authGroup.POST("/passkey/finish", api.FinishPasskeyLogin)
 		authGroup.POST("/mfa/reset", middlewares.DemoModeRestrict, api.ResetTOTP)
 		authGroup.DELETE("/logout", api.UserOut)
 		authGroup.GET("/captcha", api.GetCaptcha)
+		authGroup.GET("/oidc", api.OIDCStatus)
+		authGroup.GET("/oidc/login", api.OIDCLogin)
+		authGroup.GET("/oidc/callback", api.OIDCCallback)
 	}
 	userGroup := r.Group("/api/v1/users")
-	userGroup.Use(middlewares.AuthToken)
+	userGroup.Use(middlewares.AuthToken, middlewares.AuditLog)
 	{
 		userGroup.GET("/me", api.UserMe)
 		userGroup.GET("/mfa", api.GetMFAStatus)
@@ -27,7 +35,20 @@ func User(r *gin.Engine) {
 		userGroup.POST("/mfa/totp/confirm", middlewares.DemoModeRestrict, api.ConfirmTOTPEnrollment)
 		userGroup.POST("/mfa/totp/disable", middlewares.DemoModeRestrict, api.DisableTOTP)
 		userGroup.POST("/mfa/recovery-codes/regenerate", middlewares.DemoModeRestrict, api.RegenerateRecoveryCodes)
-		userGroup.GET("/page", api.UserPages)
+		userGroup.GET("/mfa/passkeys", api.ListPasskeys)
+		userGroup.POST("/mfa/passkeys/begin", middlewares.DemoModeRestrict, api.BeginPasskeyRegistration)
+		userGroup.POST("/mfa/passkeys/finish", middlewares.DemoModeRestrict, api.FinishPasskeyRegistration)
+		userGroup.POST("/mfa/passkeys/delete", middlewares.DemoModeRestrict, api.DeletePasskey)
+		userGroup.POST("/oidc/link", middlewares.DemoModeRestrict, api.OIDCLinkURL)
+		userGroup.GET("/sessions", api.UserSessions)
+		userGroup.POST("/sessions/revoke", middlewares.DemoModeRestrict, api.UserRevokeSession)
+		userGroup.POST("/sessions/revoke-all", middlewares.DemoModeRestrict, api.UserRevokeAllSessions)
+		// 用户管理（仅管理员）
+		userGroup.GET("/page", middlewares.RequireAdmin, api.UserPages)
+		userGroup.POST("/create", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.UserCreate)
+		userGroup.POST("/role", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.UserUpdateRole)
+		userGroup.POST("/reset-password", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.UserResetPassword)
+		userGroup.POST("/delete", middlewares.RequireAdmin, middlewares.DemoModeRestrict, api.UserDelete)
 		userGroup.POST("/update", middlewares.DemoModeRestrict, api.UserSet)
 		// 演示模式下禁止修改用户资料和密码
 		userGroup.POST("/update-profile", middlewares.DemoModeRestrict, api.UserUpdateProfile)
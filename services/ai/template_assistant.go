@@ -447,6 +447,7 @@ func GenerateCandidateStream(ctx context.Context, user *models.User, req Generat
 		return nil, fmt.Errorf("AI 编辑操作无法应用: %w", err)
 	}
 	validation := services.ValidateTemplateCandidate(services.TemplateValidationInput{
+		Filename:      req.Filename,
 		Category:      req.Category,
 		OriginalText:  req.CurrentText,
 		CandidateText: candidateText,
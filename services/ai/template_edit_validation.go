@@ -10,6 +10,7 @@ import (
 )
 
 type TemplateEditPreviewInput struct {
+	Filename      string
 	Category      string
 	BaseText      string
 	BaseHash      string
@@ -56,6 +57,7 @@ func BuildTemplateEditPreviewCandidate(input TemplateEditPreviewInput) (Template
 	state.CandidateText = candidate
 	state.CandidateHash = BuildRevisionHash(candidate)
 	validation := services.ValidateTemplateCandidate(services.TemplateValidationInput{
+		Filename:      input.Filename,
 		Category:      input.Category,
 		OriginalText:  input.BaseText,
 		CandidateText: candidate,
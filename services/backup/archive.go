@@ -0,0 +1,250 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sublink/config"
+	"sublink/database"
+	"sublink/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// ManifestName 备份包中的清单文件名
+	ManifestName = "manifest.json"
+	// SnapshotName 备份包中数据库快照的路径，与旧版 backup.zip 的 db 目录结构兼容
+	SnapshotName = "db/sublink.db"
+	// FormatName 备份包格式标识
+	FormatName = "sublinkpro-backup"
+	// FormatVersion 备份包格式版本
+	FormatVersion = 1
+
+	geoIPFileName = "GeoLite2-City.mmdb"
+)
+
+// Manifest 备份包清单
+type Manifest struct {
+	Format     string           `json:"format"`
+	Version    int              `json:"version"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	Dialect    string           `json:"dialect"`
+	Migrations []string         `json:"migrations"`
+	Tables     map[string]int64 `json:"tables"`
+}
+
+// OpenSQLite 打开 SQLite 文件并关闭 GORM 日志，用于读写备份快照
+func OpenSQLite(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+// CloseDB 关闭数据库连接
+func CloseDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
+
+// WriteArchive 生成备份包并写入 w
+// 包内包含：manifest.json、当前数据库的 SQLite 快照（任何数据库类型均导出为 SQLite）、
+// 数据目录中的配置文件，以及模板目录。
+func WriteArchive(ctx context.Context, w io.Writer) (*Manifest, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	tempDir, err := os.MkdirTemp("", "sublink-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建备份临时目录失败: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	snapshotPath := filepath.Join(tempDir, "sublink.db")
+	manifest, err := writeSnapshot(ctx, snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	zipWriter := zip.NewWriter(w)
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipEntry(zipWriter, ManifestName, manifestData); err != nil {
+		return nil, err
+	}
+	if err := addZipFile(zipWriter, SnapshotName, snapshotPath); err != nil {
+		return nil, err
+	}
+	if err := addZipDir(zipWriter, config.GetDBPath(), "db", skipDataDirEntry); err != nil {
+		return nil, fmt.Errorf("备份数据目录失败: %w", err)
+	}
+	if err := addZipDir(zipWriter, TemplateDir(), "template", nil); err != nil {
+		return nil, fmt.Errorf("备份模板目录失败: %w", err)
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteConfiguredArchive 按备份配置生成备份包，启用加密时写入的是加密流
+func WriteConfiguredArchive(ctx context.Context, w io.Writer, settings Settings) (*Manifest, error) {
+	if !settings.Encryption.Enabled {
+		return WriteArchive(ctx, w)
+	}
+	encWriter, err := NewEncryptWriter(w, settings.Encryption.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := WriteArchive(ctx, encWriter)
+	if err != nil {
+		return nil, err
+	}
+	if err := encWriter.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// FileExt 返回备份文件扩展名，加密备份为 .zip.enc
+func FileExt(settings Settings) string {
+	if settings.Encryption.Enabled {
+		return ".zip" + EncryptedExt
+	}
+	return ".zip"
+}
+
+// writeSnapshot 把当前数据库复制到新的 SQLite 文件
+func writeSnapshot(ctx context.Context, snapshotPath string) (*Manifest, error) {
+	snapshot, err := OpenSQLite(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库快照失败: %w", err)
+	}
+	defer CloseDB(snapshot)
+
+	tables := models.BackupModels()
+	if err := snapshot.AutoMigrate(tables...); err != nil {
+		return nil, fmt.Errorf("初始化数据库快照失败: %w", err)
+	}
+	copied, err := CopyTables(ctx, database.DB, snapshot, tables, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []string
+	if err := snapshot.Model(&database.Migration{}).Order("id").Pluck("id", &migrations).Error; err != nil {
+		return nil, err
+	}
+	return &Manifest{
+		Format:     FormatName,
+		Version:    FormatVersion,
+		CreatedAt:  time.Now(),
+		Dialect:    database.Dialect,
+		Migrations: migrations,
+		Tables:     copied,
+	}, nil
+}
+
+// TemplateDir 模板目录（基于当前工作目录）
+func TemplateDir() string {
+	if cwd, err := os.Getwd(); err == nil {
+		return filepath.Join(cwd, "template")
+	}
+	return "template"
+}
+
+// skipDataDirEntry 数据目录中不进入备份的内容：数据库文件（已由快照替代）、GeoIP 库、临时目录与本地备份目录
+func skipDataDirEntry(relPath string, info os.FileInfo) bool {
+	top := strings.Split(filepath.ToSlash(relPath), "/")[0]
+	if top == ".tmp" || top == "backups" {
+		return true
+	}
+	if info.IsDir() {
+		return false
+	}
+	name := strings.ToLower(info.Name())
+	if name == strings.ToLower(geoIPFileName) {
+		return true
+	}
+	for _, suffix := range []string{".db", ".sqlite", ".sqlite3", "-wal", "-shm", "-journal"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeZipEntry(zipWriter *zip.Writer, name string, data []byte) error {
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func addZipFile(zipWriter *zip.Writer, name, sourcePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, file)
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// addZipDir 把目录中的文件写入 zip 的 zipPrefix 下，目录不存在时跳过
+func addZipDir(zipWriter *zip.Writer, baseDir, zipPrefix string, skip func(relPath string, info os.FileInfo) bool) error {
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if skip != nil && skip(relPath, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return addZipFile(zipWriter, zipPrefix+"/"+filepath.ToSlash(relPath), path)
+	})
+}
@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// copyBatchSize 逐表复制时每批读取与写入的行数
+const copyBatchSize = 500
+
+// CopyProgress 每复制完一批数据后回调，copied 为该表已复制的行数
+type CopyProgress func(table string, copied int64)
+
+// TableName 解析模型对应的数据表名
+func TableName(db *gorm.DB, model any) (string, error) {
+	statement := &gorm.Statement{DB: db}
+	if err := statement.Parse(model); err != nil {
+		return "", err
+	}
+	return statement.Schema.Table, nil
+}
+
+// CopyTables 按模型顺序把 src 中的数据逐表复制到 dst，返回每张表复制的行数
+// dst 中对应的表需已存在；src 中不存在的表会被跳过。
+// 行数据以列名映射写入，避免 GORM 在零值字段上套用 default 标签（例如 false 被写成 true）。
+func CopyTables(ctx context.Context, src, dst *gorm.DB, tables []any, progress CopyProgress) (map[string]int64, error) {
+	copied := make(map[string]int64, len(tables))
+	for _, model := range tables {
+		statement := &gorm.Statement{DB: src}
+		if err := statement.Parse(model); err != nil {
+			return copied, fmt.Errorf("解析数据表模型失败: %w", err)
+		}
+		sch := statement.Schema
+		if !src.Migrator().HasTable(sch.Table) {
+			continue
+		}
+		count, err := copyTable(ctx, src, dst, model, sch, progress)
+		copied[sch.Table] = count
+		if err != nil {
+			return copied, fmt.Errorf("复制数据表 %s 失败: %w", sch.Table, err)
+		}
+	}
+	return copied, nil
+}
+
+func copyTable(ctx context.Context, src, dst *gorm.DB, model any, sch *schema.Schema, progress CopyProgress) (int64, error) {
+	if len(sch.PrimaryFieldDBNames) == 0 {
+		return 0, fmt.Errorf("数据表缺少主键")
+	}
+	columns := existingColumns(src, sch)
+	orderBy := clause.OrderBy{}
+	for _, name := range sch.PrimaryFieldDBNames {
+		orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{Column: clause.Column{Name: name}})
+	}
+
+	sliceType := reflect.SliceOf(reflect.TypeOf(model).Elem())
+	var total int64
+	for offset := 0; ; offset += copyBatchSize {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		batch := reflect.New(sliceType)
+		if err := src.WithContext(ctx).Model(model).Select(columns).Order(orderBy).
+			Limit(copyBatchSize).Offset(offset).Find(batch.Interface()).Error; err != nil {
+			return total, err
+		}
+		records := batch.Elem()
+		if records.Len() == 0 {
+			break
+		}
+
+		rows := make([]map[string]any, 0, records.Len())
+		for i := 0; i < records.Len(); i++ {
+			record := records.Index(i)
+			row := make(map[string]any, len(columns))
+			for _, name := range columns {
+				value, _ := sch.FieldsByDBName[name].ValueOf(ctx, record)
+				row[name] = value
+			}
+			rows = append(rows, row)
+		}
+		if err := dst.WithContext(ctx).Table(sch.Table).Create(&rows).Error; err != nil {
+			return total, err
+		}
+
+		total += int64(len(rows))
+		if progress != nil {
+			progress(sch.Table, total)
+		}
+		if records.Len() < copyBatchSize {
+			break
+		}
+	}
+	return total, nil
+}
+
+// existingColumns 返回源表中实际存在的列，兼容旧版本备份缺少新字段的情况
+func existingColumns(db *gorm.DB, sch *schema.Schema) []string {
+	columns := make([]string, 0, len(sch.DBNames))
+	for _, name := range sch.DBNames {
+		if db.Migrator().HasColumn(sch.Table, name) {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
+
+// CountRows 统计各模型对应数据表的行数，不存在的表记为 -1
+func CountRows(ctx context.Context, db *gorm.DB, tables []any) (map[string]int64, error) {
+	counts := make(map[string]int64, len(tables))
+	for _, model := range tables {
+		table, err := TableName(db, model)
+		if err != nil {
+			return counts, err
+		}
+		if !db.Migrator().HasTable(table) {
+			counts[table] = -1
+			continue
+		}
+		var count int64
+		if err := db.WithContext(ctx).Table(table).Count(&count).Error; err != nil {
+			return counts, fmt.Errorf("统计数据表 %s 行数失败: %w", table, err)
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// AutoIncrementTables 返回使用自增整型 id 主键的数据表，用于写入显式 ID 后重置序列
+func AutoIncrementTables(db *gorm.DB, tables []any) []string {
+	result := make([]string, 0, len(tables))
+	for _, model := range tables {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(model); err != nil {
+			continue
+		}
+		field := statement.Schema.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement || field.DBName != "id" || !db.Migrator().HasTable(statement.Schema.Table) {
+			continue
+		}
+		result = append(result, statement.Schema.Table)
+	}
+	return result
+}
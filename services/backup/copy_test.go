@@ -0,0 +1,62 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sublink/internal/testutil"
+	"sublink/models"
+)
+
+func TestCopyTablesPreservesFalseDefaultsAndCompositeKeys(t *testing.T) {
+	src := testutil.OpenMemoryDB(t, "backup_copy_src")
+	dst := testutil.OpenMemoryDB(t, "backup_copy_dst")
+	t.Cleanup(func() {
+		testutil.CloseDB(t, src)
+		testutil.CloseDB(t, dst)
+	})
+	tables := []any{&models.CountryRule{}, &models.SubcriptionGroup{}}
+	if err := src.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate src: %v", err)
+	}
+	if err := dst.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate dst: %v", err)
+	}
+
+	src.Create(&models.CountryRule{ID: 3, CountryCode: "HK", CountryName: "香港", Pattern: "HK", Enabled: true})
+	src.Create(&models.CountryRule{ID: 9, CountryCode: "US", CountryName: "美国", Pattern: "US"})
+	src.Model(&models.CountryRule{}).Where("id = ?", 9).Update("enabled", false)
+	for i := 0; i < copyBatchSize+3; i++ {
+		src.Create(&models.SubcriptionGroup{SubcriptionID: 1 + i%2, GroupName: fmt.Sprintf("g%03d", i), Sort: i})
+	}
+	groupCount := int64(copyBatchSize + 3)
+
+	var progressCalls int
+	copied, err := CopyTables(context.Background(), src, dst, tables, func(string, int64) { progressCalls++ })
+	if err != nil {
+		t.Fatalf("copy tables: %v", err)
+	}
+	if copied["country_rules"] != 2 || copied["subcription_groups"] != groupCount {
+		t.Fatalf("unexpected copied counts: %+v (groups=%d)", copied, groupCount)
+	}
+	if progressCalls < 3 {
+		t.Fatalf("expected per-batch progress callbacks, got %d", progressCalls)
+	}
+
+	var disabled models.CountryRule
+	if err := dst.First(&disabled, 9).Error; err != nil {
+		t.Fatalf("load copied rule: %v", err)
+	}
+	if disabled.Enabled {
+		t.Fatalf("expected disabled rule to stay disabled after copy")
+	}
+
+	counts, err := CountRows(context.Background(), dst, append(tables, &models.Tag{}))
+	if err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if counts["subcription_groups"] != groupCount || counts["tags"] != -1 {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+}
@@ -0,0 +1,356 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 加密备份文件格式（所有整数为大端序）：
+//
+//	magic(8) | version(1) | scrypt logN(1) | r(1) | p(1) | salt(16) | noncePrefix(7) | chunkSize(4)
+//	chunk...
+//
+// 明文按 chunkSize 分块，每块使用 AES-256-GCM 独立加密，附加数据为整个文件头。
+// 每块的 nonce 为 noncePrefix | 块序号(4) | 末块标记(1)，可以检测分块被重排、截断或追加。
+const (
+	// EncryptedExt 加密备份在 .zip 之后追加的扩展名
+	EncryptedExt = ".enc"
+	// MinPassphraseLength 备份密码的最小长度
+	MinPassphraseLength = 8
+
+	encryptionMagic   = "SLPBKENC"
+	encryptionVersion = 1
+	encryptionLogN    = 15
+	encryptionR       = 8
+	encryptionP       = 1
+	// maxEncryptionLogN 读取时允许的最大 logN，只比写入值高一级，scrypt 单次派生最多占用 128·r·2^16 = 64 MiB
+	maxEncryptionLogN = encryptionLogN + 1
+	saltSize          = 16
+	noncePrefixSize   = 7
+	defaultChunkSize  = 64 * 1024
+	headerSize        = len(encryptionMagic) + 4 + saltSize + noncePrefixSize + 4
+)
+
+var (
+	// ErrWrongPassphrase 密码错误或文件头部已损坏
+	ErrWrongPassphrase = errors.New("备份密码错误")
+	// ErrPassphraseRequired 备份已加密但未提供密码
+	ErrPassphraseRequired = errors.New("备份已加密，请提供备份密码")
+	// ErrCorruptedArchive 加密备份内容被截断或篡改
+	ErrCorruptedArchive = errors.New("加密备份已损坏或被截断")
+)
+
+type encryptionHeader struct {
+	raw         []byte
+	logN        uint8
+	r           uint8
+	p           uint8
+	salt        []byte
+	noncePrefix []byte
+	chunkSize   uint32
+}
+
+// IsEncryptedFile 判断文件是否为加密备份
+func IsEncryptedFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = file.Close() }()
+
+	magic := make([]byte, len(encryptionMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(magic) == encryptionMagic, nil
+}
+
+// ValidatePassphrase 校验备份密码强度
+func ValidatePassphrase(passphrase string) error {
+	if len([]rune(passphrase)) < MinPassphraseLength {
+		return fmt.Errorf("备份密码长度不能少于 %d 个字符", MinPassphraseLength)
+	}
+	return nil
+}
+
+func deriveKey(passphrase string, header *encryptionHeader) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), header.salt, 1<<header.logN, int(header.r), int(header.p), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, index uint32, final bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// encryptWriter 分块加密写入器，必须调用 Close 写入末块
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header *encryptionHeader
+	buf    []byte
+	index  uint32
+	closed bool
+}
+
+// NewEncryptWriter 返回一个把写入内容加密后写到 w 的写入器，Close 不会关闭 w
+func NewEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	header := &encryptionHeader{
+		logN:        encryptionLogN,
+		r:           encryptionR,
+		p:           encryptionP,
+		salt:        make([]byte, saltSize),
+		noncePrefix: make([]byte, noncePrefixSize),
+		chunkSize:   defaultChunkSize,
+	}
+	if _, err := rand.Read(header.salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(header.noncePrefix); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 0, headerSize)
+	raw = append(raw, encryptionMagic...)
+	raw = append(raw, encryptionVersion, header.logN, header.r, header.p)
+	raw = append(raw, header.salt...)
+	raw = append(raw, header.noncePrefix...)
+	raw = binary.BigEndian.AppendUint32(raw, header.chunkSize)
+	header.raw = raw
+
+	aead, err := deriveKey(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: header, buf: make([]byte, 0, header.chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("加密写入器已关闭")
+	}
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有后续数据时，才能确定当前块不是末块
+		if len(e.buf) == cap(e.buf) {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) flush(final bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.header.noncePrefix, e.index, final), e.buf, e.header.raw)
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// Close 写入末块
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+// decryptReader 分块解密读取器
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  *encryptionHeader
+	chunk   []byte
+	plain   []byte
+	index   uint32
+	done    bool
+	pending error
+}
+
+// NewDecryptReader 读取加密备份的文件头并解密第一块
+// 密码错误时立即返回 ErrWrongPassphrase，不会输出任何明文。
+func NewDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	header, err := readEncryptionHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := deriveKey(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	reader := &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		chunk:  make([]byte, int(header.chunkSize)+aead.Overhead()),
+	}
+	if err := reader.next(); err != nil {
+		if errors.Is(err, ErrCorruptedArchive) {
+			return nil, ErrWrongPassphrase
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+func readEncryptionHeader(r io.Reader) (*encryptionHeader, error) {
+	raw := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("读取加密备份头部失败: %w", err)
+	}
+	if string(raw[:len(encryptionMagic)]) != encryptionMagic {
+		return nil, fmt.Errorf("不是加密备份文件")
+	}
+	offset := len(encryptionMagic)
+	if raw[offset] != encryptionVersion {
+		return nil, fmt.Errorf("不支持的加密备份版本 %d", raw[offset])
+	}
+	header := &encryptionHeader{
+		raw:  raw,
+		logN: raw[offset+1],
+		r:    raw[offset+2],
+		p:    raw[offset+3],
+	}
+	offset += 4
+	header.salt = raw[offset : offset+saltSize]
+	offset += saltSize
+	header.noncePrefix = raw[offset : offset+noncePrefixSize]
+	offset += noncePrefixSize
+	header.chunkSize = binary.BigEndian.Uint32(raw[offset:])
+
+	// 限制参数范围，避免构造的文件头耗尽内存或 CPU；r、p 只接受写入时使用的值
+	if header.logN < 10 || header.logN > maxEncryptionLogN || header.r != encryptionR || header.p != encryptionP {
+		return nil, fmt.Errorf("加密备份的密钥派生参数无效")
+	}
+	if header.chunkSize < 1024 || header.chunkSize > 16*1024*1024 {
+		return nil, fmt.Errorf("加密备份的分块大小无效")
+	}
+	return header, nil
+}
+
+// next 读取并解密下一块；读到 EOF 前的最后一块必须带末块标记
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	final := false
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		final = true
+	case err != nil:
+		return err
+	default:
+		if _, peekErr := d.r.Peek(1); errors.Is(peekErr, io.EOF) {
+			final = true
+		} else if peekErr != nil {
+			return peekErr
+		}
+	}
+	if n < d.aead.Overhead() {
+		return ErrCorruptedArchive
+	}
+	plain, err := d.aead.Open(d.chunk[:0], chunkNonce(d.header.noncePrefix, d.index, final), d.chunk[:n], d.header.raw)
+	if err != nil {
+		return ErrCorruptedArchive
+	}
+	d.plain = plain
+	d.index++
+	d.done = final
+	return nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.pending != nil {
+			return 0, d.pending
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			d.pending = err
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// CheckPassphraseFile 校验加密备份的密码，只解密第一块，不写出任何内容
+func CheckPassphraseFile(path, passphrase string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	_, err = NewDecryptReader(file, passphrase)
+	return err
+}
+
+// DecryptFile 把加密备份完整解密到 dst，失败时删除已写入的 dst
+func DecryptFile(src, dst, passphrase string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	reader, err := NewDecryptReader(in, passphrase)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, reader)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encryptBytes(t *testing.T, plain []byte, passphrase string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewEncryptWriter(&buf, passphrase)
+	if err != nil {
+		t.Fatalf("new encrypt writer: %v", err)
+	}
+	// 分多次写入，覆盖跨块缓冲
+	for len(plain) > 0 {
+		n := min(len(plain), 10000)
+		if _, err := writer.Write(plain[:n]); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		plain = plain[n:]
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decryptBytes(data []byte, passphrase string) ([]byte, error) {
+	reader, err := NewDecryptReader(bytes.NewReader(data), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 100, defaultChunkSize, 3*defaultChunkSize + 17} {
+		plain := bytes.Repeat([]byte("sublinkpro"), size/10+1)[:size]
+		encrypted := encryptBytes(t, plain, "correct horse")
+		if bytes.Contains(encrypted, []byte("sublinkpro")) && size > 0 {
+			t.Fatalf("size %d: ciphertext contains plaintext", size)
+		}
+		got, err := decryptBytes(encrypted, "correct horse")
+		if err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: round trip mismatch (%d bytes)", size, len(got))
+		}
+	}
+}
+
+func TestDecryptRejectsWrongPassphraseAndTampering(t *testing.T) {
+	plain := bytes.Repeat([]byte{7}, 2*defaultChunkSize+5)
+	encrypted := encryptBytes(t, plain, "correct horse")
+
+	if _, err := NewDecryptReader(bytes.NewReader(encrypted), "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected wrong passphrase, got %v", err)
+	}
+	if _, err := NewDecryptReader(bytes.NewReader(encrypted), ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("expected passphrase required, got %v", err)
+	}
+
+	chunk := defaultChunkSize + 16
+	truncated := encrypted[:headerSize+2*chunk]
+	if _, err := decryptBytes(truncated, "correct horse"); !errors.Is(err, ErrCorruptedArchive) {
+		t.Fatalf("expected truncated archive to be rejected, got %v", err)
+	}
+
+	tampered := append([]byte(nil), encrypted...)
+	tampered[headerSize+chunk+10] ^= 0xff
+	if _, err := decryptBytes(tampered, "correct horse"); !errors.Is(err, ErrCorruptedArchive) {
+		t.Fatalf("expected tampered archive to be rejected, got %v", err)
+	}
+
+	// 篡改文件头中的参数同样会导致认证失败
+	tamperedHeader := append([]byte(nil), encrypted...)
+	tamperedHeader[headerSize-1] ^= 0x01
+	if _, err := decryptBytes(tamperedHeader, "correct horse"); err == nil {
+		t.Fatalf("expected tampered header to be rejected")
+	}
+}
+
+func TestDecryptRejectsExpensiveKeyDerivationParameters(t *testing.T) {
+	encrypted := encryptBytes(t, []byte("payload"), "correct horse")
+	offset := len(encryptionMagic) + 1
+	for name, patch := range map[string][3]byte{
+		"logN": {maxEncryptionLogN + 1, encryptionR, encryptionP},
+		"r":    {encryptionLogN, encryptionR * 2, encryptionP},
+		"p":    {encryptionLogN, encryptionR, encryptionP + 1},
+	} {
+		crafted := append([]byte(nil), encrypted...)
+		copy(crafted[offset:], patch[:])
+		if _, err := NewDecryptReader(bytes.NewReader(crafted), "correct horse"); err == nil || errors.Is(err, ErrWrongPassphrase) {
+			t.Fatalf("%s: expected header to be rejected before key derivation, got %v", name, err)
+		}
+	}
+}
+
+func TestDecryptFileAndCheckPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "backup.zip.enc")
+	if err := os.WriteFile(src, encryptBytes(t, []byte("PK\x03\x04 payload"), "correct horse"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if encrypted, err := IsEncryptedFile(src); err != nil || !encrypted {
+		t.Fatalf("expected encrypted file, got %v %v", encrypted, err)
+	}
+	if err := CheckPassphraseFile(src, "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("expected wrong passphrase, got %v", err)
+	}
+
+	dst := filepath.Join(dir, "backup.zip")
+	if err := DecryptFile(src, dst, "wrong horse"); err == nil {
+		t.Fatalf("expected decrypt with wrong passphrase to fail")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected no output for wrong passphrase")
+	}
+	if err := DecryptFile(src, dst, "correct horse"); err != nil {
+		t.Fatalf("decrypt file: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "PK\x03\x04 payload" {
+		t.Fatalf("unexpected decrypted content %q", data)
+	}
+	if encrypted, err := IsEncryptedFile(dst); err != nil || encrypted {
+		t.Fatalf("expected plain zip to be detected as unencrypted, got %v %v", encrypted, err)
+	}
+}
@@ -0,0 +1,301 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm        = "AWS4-HMAC-SHA256"
+	s3Service          = "s3"
+	s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3RequestTimeout   = 10 * time.Minute
+	s3ErrorBodyLimit   = 4 << 10
+)
+
+// S3Object S3 对象摘要
+type S3Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// S3Client 精简的 S3 兼容客户端，仅实现备份所需的上传、列举与删除，使用 Signature V4 签名
+type S3Client struct {
+	endpoint   *url.URL
+	settings   S3Settings
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Client 根据配置创建 S3 客户端
+func NewS3Client(settings S3Settings) (*S3Client, error) {
+	settings.Endpoint = strings.TrimRight(strings.TrimSpace(settings.Endpoint), "/")
+	if err := validateS3Settings(settings); err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(settings.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Region == "" {
+		settings.Region = defaultS3Region
+	}
+	return &S3Client{
+		endpoint:   endpoint,
+		settings:   settings,
+		httpClient: &http.Client{Timeout: s3RequestTimeout},
+		now:        time.Now,
+	}, nil
+}
+
+// ObjectKey 在配置的前缀下拼接对象名
+func (c *S3Client) ObjectKey(name string) string {
+	if c.settings.Prefix == "" {
+		return name
+	}
+	return c.settings.Prefix + "/" + name
+}
+
+// PutFile 上传本地文件
+func (c *S3Client) PutFile(ctx context.Context, key, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, key, nil, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	contentType := "application/zip"
+	if strings.HasSuffix(key, EncryptedExt) {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := c.do(req, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ListObjects 列出前缀下的全部对象
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) ([]S3Object, error) {
+	var objects []S3Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := c.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(req, s3EmptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("解析 S3 列表响应失败: %w", err)
+		}
+		for _, item := range result.Contents {
+			objects = append(objects, S3Object{Key: item.Key, Size: item.Size, LastModified: item.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// DeleteObject 删除对象
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, s3EmptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// newRequest 构造请求，path-style 时 bucket 位于路径中，否则位于域名中
+func (c *S3Client) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := *c.endpoint
+	basePath := strings.TrimRight(target.Path, "/")
+	if c.settings.PathStyle {
+		target.Path = basePath + "/" + c.settings.Bucket
+	} else {
+		target.Host = c.settings.Bucket + "." + target.Host
+		target.Path = basePath
+	}
+	if key != "" {
+		target.Path = target.Path + "/" + key
+	} else if !c.settings.PathStyle {
+		target.Path += "/"
+	}
+	target.RawPath = s3EscapePath(target.Path)
+	target.RawQuery = ""
+	if query != nil {
+		target.RawQuery = s3CanonicalQuery(query)
+	}
+	return http.NewRequestWithContext(ctx, method, target.String(), body)
+}
+
+// do 签名并发送请求，非 2xx 响应转为错误
+func (c *S3Client) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	signS3Request(req, c.settings.AccessKeyID, c.settings.SecretAccessKey, c.settings.Region, payloadHash, c.now())
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, s3ErrorBodyLimit))
+		_ = resp.Body.Close()
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("S3 请求失败: %s %s (%s)", resp.Status, s3Err.Code, s3Err.Message)
+		}
+		return nil, fmt.Errorf("S3 请求失败: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// signS3Request 为请求添加 AWS Signature V4 签名头
+// 参与签名的头部：host、x-amz-*，以及请求中已存在的 content-type、content-md5、range
+func signS3Request(req *http.Request, accessKey, secretKey, region, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath 按 S3 规则逐段编码路径，保留分隔符 /
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery 按键排序并编码查询参数
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape 只保留 RFC 3986 非保留字符，其余按 %XX 编码
+func s3Escape(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", b)
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,251 @@
+package backup
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sublink/config"
+	"sublink/models"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	settingEnabled       = "backup_enabled"
+	settingCron          = "backup_cron"
+	settingRetentionDays = "backup_retention_days"
+	settingDir           = "backup_dir"
+	settingS3Enabled     = "backup_s3_enabled"
+	settingS3Endpoint    = "backup_s3_endpoint"
+	settingS3Region      = "backup_s3_region"
+	settingS3Bucket      = "backup_s3_bucket"
+	settingS3Prefix      = "backup_s3_prefix"
+	settingS3AccessKey   = "backup_s3_access_key"
+	settingS3SecretKey   = "backup_s3_secret_key_encrypted"
+	settingS3PathStyle   = "backup_s3_path_style"
+	settingEncryption    = "backup_encryption_enabled"
+	settingPassphrase    = "backup_passphrase_encrypted"
+
+	// DefaultCron 默认每天 04:00 执行自动备份
+	DefaultCron          = "0 4 * * *"
+	defaultRetentionDays = 7
+	defaultS3Region      = "us-east-1"
+)
+
+// S3Settings S3 兼容存储配置
+type S3Settings struct {
+	Enabled         bool   `json:"enabled"`
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+	PathStyle       bool   `json:"pathStyle"`
+}
+
+// EncryptionSettings 备份加密配置，启用后手动下载与自动备份都会加密
+type EncryptionSettings struct {
+	Enabled    bool   `json:"enabled"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+// Settings 自动备份配置
+type Settings struct {
+	Enabled       bool               `json:"enabled"`
+	Cron          string             `json:"cron"`
+	RetentionDays int                `json:"retentionDays"`
+	Dir           string             `json:"dir"`
+	S3            S3Settings         `json:"s3"`
+	Encryption    EncryptionSettings `json:"encryption"`
+}
+
+// PublicSettings 返回给前端的配置，不包含 S3 Secret 与备份密码明文
+type PublicSettings struct {
+	Settings
+	EffectiveDir      string `json:"effectiveDir"`
+	HasS3Secret       bool   `json:"hasS3Secret"`
+	MaskedS3Secret    string `json:"maskedS3Secret"`
+	S3SecretInvalid   bool   `json:"s3SecretInvalid"`
+	HasPassphrase     bool   `json:"hasPassphrase"`
+	PassphraseInvalid bool   `json:"passphraseInvalid"`
+}
+
+// LoadSettings 从系统设置读取自动备份配置，S3 Secret 会被解密
+func LoadSettings() (Settings, error) {
+	settings := Settings{
+		Enabled:       settingValue(settingEnabled) == "true",
+		Cron:          settingValue(settingCron),
+		RetentionDays: defaultRetentionDays,
+		Dir:           settingValue(settingDir),
+		S3: S3Settings{
+			Enabled:     settingValue(settingS3Enabled) == "true",
+			Endpoint:    settingValue(settingS3Endpoint),
+			Region:      settingValue(settingS3Region),
+			Bucket:      settingValue(settingS3Bucket),
+			Prefix:      settingValue(settingS3Prefix),
+			AccessKeyID: settingValue(settingS3AccessKey),
+			PathStyle:   settingValue(settingS3PathStyle) != "false",
+		},
+		Encryption: EncryptionSettings{
+			Enabled: settingValue(settingEncryption) == "true",
+		},
+	}
+	if settings.Cron == "" {
+		settings.Cron = DefaultCron
+	}
+	if raw := settingValue(settingRetentionDays); raw != "" {
+		if days, err := strconv.Atoi(raw); err == nil && days >= 0 {
+			settings.RetentionDays = days
+		}
+	}
+	if settings.S3.Region == "" {
+		settings.S3.Region = defaultS3Region
+	}
+
+	passphrase, passphraseErr := models.DecryptUserAISecret(settingValue(settingPassphrase))
+	if passphraseErr == nil {
+		settings.Encryption.Passphrase = passphrase
+	}
+	secret, err := models.DecryptUserAISecret(settingValue(settingS3SecretKey))
+	if err != nil {
+		return settings, fmt.Errorf("解密 S3 Secret 失败: %w", err)
+	}
+	settings.S3.SecretAccessKey = secret
+	if passphraseErr != nil {
+		return settings, fmt.Errorf("解密备份密码失败: %w", passphraseErr)
+	}
+	return settings, nil
+}
+
+// LoadPublicSettings 读取可返回给前端的配置
+func LoadPublicSettings() PublicSettings {
+	settings, err := LoadSettings()
+	public := PublicSettings{Settings: settings, EffectiveDir: ResolveDir(settings)}
+	public.S3SecretInvalid = err != nil
+	if settings.S3.SecretAccessKey != "" {
+		public.HasS3Secret = true
+		public.MaskedS3Secret = models.MaskSecret(settings.S3.SecretAccessKey)
+	}
+	public.S3.SecretAccessKey = ""
+	public.HasPassphrase = settings.Encryption.Passphrase != ""
+	public.PassphraseInvalid = settingValue(settingPassphrase) != "" && !public.HasPassphrase
+	public.Encryption.Passphrase = ""
+	return public
+}
+
+// SaveSettings 校验并保存自动备份配置
+// SecretAccessKey 或 Passphrase 为空时保留已保存的值
+func SaveSettings(settings Settings) error {
+	settings = normalizeSettings(settings)
+	if settings.S3.SecretAccessKey == "" || settings.Encryption.Passphrase == "" {
+		current, _ := LoadSettings()
+		if settings.S3.SecretAccessKey == "" {
+			settings.S3.SecretAccessKey = current.S3.SecretAccessKey
+		}
+		if settings.Encryption.Passphrase == "" {
+			settings.Encryption.Passphrase = current.Encryption.Passphrase
+		}
+	}
+	if err := ValidateSettings(settings); err != nil {
+		return err
+	}
+
+	encryptedSecret, err := models.EncryptUserAISecret(settings.S3.SecretAccessKey)
+	if err != nil {
+		return err
+	}
+	encryptedPassphrase, err := models.EncryptUserAISecret(settings.Encryption.Passphrase)
+	if err != nil {
+		return err
+	}
+	values := [][2]string{
+		{settingEnabled, strconv.FormatBool(settings.Enabled)},
+		{settingCron, settings.Cron},
+		{settingRetentionDays, strconv.Itoa(settings.RetentionDays)},
+		{settingDir, settings.Dir},
+		{settingS3Enabled, strconv.FormatBool(settings.S3.Enabled)},
+		{settingS3Endpoint, settings.S3.Endpoint},
+		{settingS3Region, settings.S3.Region},
+		{settingS3Bucket, settings.S3.Bucket},
+		{settingS3Prefix, settings.S3.Prefix},
+		{settingS3AccessKey, settings.S3.AccessKeyID},
+		{settingS3SecretKey, encryptedSecret},
+		{settingS3PathStyle, strconv.FormatBool(settings.S3.PathStyle)},
+		{settingEncryption, strconv.FormatBool(settings.Encryption.Enabled)},
+		{settingPassphrase, encryptedPassphrase},
+	}
+	for _, item := range values {
+		if err := models.SetSetting(item[0], item[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateSettings 校验自动备份配置
+func ValidateSettings(settings Settings) error {
+	if _, err := cron.ParseStandard(settings.Cron); err != nil {
+		return fmt.Errorf("Cron 表达式无效: %w", err)
+	}
+	if settings.RetentionDays < 0 {
+		return fmt.Errorf("保留天数不能为负数")
+	}
+	if settings.Encryption.Enabled {
+		if err := ValidatePassphrase(settings.Encryption.Passphrase); err != nil {
+			return err
+		}
+	}
+	if settings.S3.Enabled {
+		return validateS3Settings(settings.S3)
+	}
+	return nil
+}
+
+func validateS3Settings(s3 S3Settings) error {
+	parsed, err := url.Parse(s3.Endpoint)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("S3 Endpoint 必须是 http 或 https 地址")
+	}
+	if s3.Bucket == "" {
+		return fmt.Errorf("S3 Bucket 不能为空")
+	}
+	if s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+		return fmt.Errorf("S3 Access Key 与 Secret 不能为空")
+	}
+	return nil
+}
+
+// ResolveDir 返回本地备份目录，未配置时使用数据目录下的 backups
+func ResolveDir(settings Settings) string {
+	if settings.Dir != "" {
+		return settings.Dir
+	}
+	return filepath.Join(config.GetDBPath(), "backups")
+}
+
+func normalizeSettings(settings Settings) Settings {
+	settings.Cron = strings.Join(strings.Fields(settings.Cron), " ")
+	if settings.Cron == "" {
+		settings.Cron = DefaultCron
+	}
+	settings.Dir = strings.TrimSpace(settings.Dir)
+	settings.S3.Endpoint = strings.TrimRight(strings.TrimSpace(settings.S3.Endpoint), "/")
+	settings.S3.Region = strings.TrimSpace(settings.S3.Region)
+	if settings.S3.Region == "" {
+		settings.S3.Region = defaultS3Region
+	}
+	settings.S3.Bucket = strings.TrimSpace(settings.S3.Bucket)
+	settings.S3.Prefix = strings.Trim(strings.TrimSpace(settings.S3.Prefix), "/")
+	settings.S3.AccessKeyID = strings.TrimSpace(settings.S3.AccessKeyID)
+	settings.S3.SecretAccessKey = strings.TrimSpace(settings.S3.SecretAccessKey)
+	return settings
+}
+
+func settingValue(key string) string {
+	value, _ := models.GetSetting(key)
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,263 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sublink/utils"
+)
+
+const (
+	// FilePrefix 自动备份与手动触发备份的文件名前缀
+	FilePrefix = "sublinkpro-backup-"
+	// PreRestorePrefix 恢复前快照的文件名前缀
+	PreRestorePrefix = "sublinkpro-pre-restore-"
+
+	fileTimeLayout = "20060102-150405"
+)
+
+var (
+	runMutex sync.Mutex
+
+	backupNamePattern = regexp.MustCompile(`^sublinkpro-(backup|pre-restore)-[0-9]{8}-[0-9]{6}(-[0-9]+)?\.zip(\.enc)?$`)
+)
+
+// FileInfo 本地备份文件信息
+type FileInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RunResult 一次备份的执行结果
+type RunResult struct {
+	File        FileInfo `json:"file"`
+	S3Key       string   `json:"s3Key,omitempty"`
+	Pruned      []string `json:"pruned,omitempty"`
+	PrunedS3    []string `json:"prunedS3,omitempty"`
+	Manifest    Manifest `json:"manifest"`
+	Warnings    []string `json:"warnings,omitempty"`
+	ElapsedTime string   `json:"elapsedTime"`
+}
+
+// RunBackup 按当前配置执行一次备份：写入本地目录、上传 S3（如启用）并清理过期备份
+func RunBackup(ctx context.Context) (*RunResult, error) {
+	settings, err := LoadSettings()
+	if err != nil && (settings.S3.Enabled || settings.Encryption.Enabled) {
+		return nil, err
+	}
+	return runBackup(ctx, settings, FilePrefix)
+}
+
+// CreateSnapshot 在本地备份目录写入一份带指定前缀的备份，不上传、不清理
+// 用于恢复前的安全快照，启用加密时同样加密。
+func CreateSnapshot(ctx context.Context, prefix string) (*FileInfo, error) {
+	settings, _ := LoadSettings()
+	settings.S3.Enabled = false
+	settings.RetentionDays = 0
+	result, err := runBackup(ctx, settings, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &result.File, nil
+}
+
+func runBackup(ctx context.Context, settings Settings, prefix string) (*RunResult, error) {
+	if !runMutex.TryLock() {
+		return nil, fmt.Errorf("已有备份任务正在执行")
+	}
+	defer runMutex.Unlock()
+
+	start := time.Now()
+	dir := ResolveDir(settings)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	name := uniqueFileName(dir, prefix, FileExt(settings), start)
+	finalPath := filepath.Join(dir, name)
+	tmpFile, err := os.CreateTemp(dir, ".backup-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	manifest, err := WriteConfiguredArchive(ctx, tmpFile, settings)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	closeErr := tmpFile.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return nil, fmt.Errorf("保存备份文件失败: %w", err)
+	}
+	info, err := os.Stat(finalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{
+		File:     FileInfo{Name: name, Size: info.Size(), CreatedAt: start},
+		Manifest: *manifest,
+	}
+
+	if settings.S3.Enabled {
+		client, err := NewS3Client(settings.S3)
+		if err == nil {
+			key := client.ObjectKey(name)
+			if err = client.PutFile(ctx, key, finalPath); err == nil {
+				result.S3Key = key
+			}
+		}
+		if err != nil {
+			// 本地备份已完成，上传失败只记录告警，不影响本地保留
+			result.Warnings = append(result.Warnings, "上传 S3 失败: "+err.Error())
+			utils.Warn("备份上传 S3 失败: %v", err)
+		}
+	}
+
+	if settings.RetentionDays > 0 {
+		cutoff := start.AddDate(0, 0, -settings.RetentionDays)
+		pruned, err := PruneLocal(dir, cutoff)
+		result.Pruned = pruned
+		if err != nil {
+			result.Warnings = append(result.Warnings, "清理本地备份失败: "+err.Error())
+		}
+		if settings.S3.Enabled && result.S3Key != "" {
+			prunedS3, err := PruneS3(ctx, settings.S3, cutoff)
+			result.PrunedS3 = prunedS3
+			if err != nil {
+				result.Warnings = append(result.Warnings, "清理 S3 备份失败: "+err.Error())
+			}
+		}
+	}
+
+	result.ElapsedTime = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// uniqueFileName 生成带时间戳的文件名，同一秒内重复时追加序号
+func uniqueFileName(dir, prefix, ext string, now time.Time) string {
+	base := prefix + now.Format(fileTimeLayout)
+	name := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// ValidName 校验备份文件名，防止路径穿越
+func ValidName(name string) bool {
+	return backupNamePattern.MatchString(name)
+}
+
+// parseFileTime 从文件名解析备份时间
+func parseFileTime(name string) (time.Time, bool) {
+	if !ValidName(name) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimPrefix(strings.TrimPrefix(name, FilePrefix), PreRestorePrefix)
+	if len(stamp) < len(fileTimeLayout) {
+		return time.Time{}, false
+	}
+	parsed, err := time.ParseInLocation(fileTimeLayout, stamp[:len(fileTimeLayout)], time.Local)
+	return parsed, err == nil
+}
+
+// ListLocal 列出本地备份目录中的备份文件，按时间倒序
+func ListLocal(dir string) ([]FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []FileInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	files := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		createdAt, ok := parseFileTime(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{Name: entry.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name > files[j].Name })
+	return files, nil
+}
+
+// LocalPath 返回本地备份文件的完整路径，文件名非法或文件不存在时返回错误
+func LocalPath(name string) (string, error) {
+	if !ValidName(name) {
+		return "", fmt.Errorf("备份文件名无效")
+	}
+	settings, _ := LoadSettings()
+	path := filepath.Join(ResolveDir(settings), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("备份文件不存在")
+	}
+	return path, nil
+}
+
+// PruneLocal 删除早于 cutoff 的本地自动备份，恢复前快照同样适用保留策略
+func PruneLocal(dir string, cutoff time.Time) ([]string, error) {
+	files, err := ListLocal(dir)
+	if err != nil {
+		return nil, err
+	}
+	var pruned []string
+	for _, file := range files {
+		if !file.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, file.Name)
+	}
+	return pruned, nil
+}
+
+// PruneS3 删除 S3 前缀下早于 cutoff 的备份对象，只处理符合备份命名规则的对象
+func PruneS3(ctx context.Context, settings S3Settings, cutoff time.Time) ([]string, error) {
+	client, err := NewS3Client(settings)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := client.ListObjects(ctx, client.ObjectKey(FilePrefix))
+	if err != nil {
+		return nil, err
+	}
+	var pruned []string
+	for _, object := range objects {
+		createdAt, ok := parseFileTime(filepath.Base(object.Key))
+		if !ok || !createdAt.Before(cutoff) {
+			continue
+		}
+		if err := client.DeleteObject(ctx, object.Key); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, object.Key)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sublink/database"
+	"sublink/internal/testutil"
+	"sublink/models"
+)
+
+func setupBackupTestDB(t *testing.T) string {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	t.Setenv("SUBLINK_DB_PATH", dataDir)
+	t.Setenv("SUBLINK_API_ENCRYPTION_KEY", "backup-test-key-0123456789abcdef0123")
+	t.Chdir(t.TempDir())
+
+	oldDB := database.DB
+	oldDialect := database.Dialect
+	db := testutil.OpenMemoryDB(t, "backup_test")
+	if err := db.AutoMigrate(models.BackupModels()...); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	database.DB = db
+	database.Dialect = database.DialectSQLite
+	if err := models.InitSettingCache(); err != nil {
+		t.Fatalf("init setting cache: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB = oldDB
+		database.Dialect = oldDialect
+		if oldDB != nil {
+			_ = models.InitSettingCache()
+		}
+		testutil.CloseDB(t, db)
+	})
+	return dataDir
+}
+
+func TestSaveSettingsEncryptsS3SecretAndKeepsItWhenBlank(t *testing.T) {
+	setupBackupTestDB(t)
+
+	settings := Settings{Cron: "0 3 * * *", RetentionDays: 3, S3: S3Settings{
+		Enabled: true, Endpoint: "http://minio:9000/", Bucket: "b", AccessKeyID: "ak", SecretAccessKey: "super-secret-key",
+	}}
+	if err := SaveSettings(settings); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if raw, _ := models.GetSetting(settingS3SecretKey); raw == "" || raw == "super-secret-key" {
+		t.Fatalf("expected encrypted secret, got %q", raw)
+	}
+
+	settings.S3.SecretAccessKey = ""
+	settings.RetentionDays = 5
+	if err := SaveSettings(settings); err != nil {
+		t.Fatalf("save without secret: %v", err)
+	}
+	loaded, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.S3.SecretAccessKey != "super-secret-key" || loaded.RetentionDays != 5 || loaded.S3.Endpoint != "http://minio:9000" {
+		t.Fatalf("unexpected loaded settings: %+v", loaded)
+	}
+	if public := LoadPublicSettings(); public.S3.SecretAccessKey != "" || !public.HasS3Secret {
+		t.Fatalf("public settings leaked secret: %+v", public)
+	}
+
+	if err := SaveSettings(Settings{Cron: "every day"}); err == nil {
+		t.Fatalf("expected invalid cron to be rejected")
+	}
+}
+
+func TestRunBackupWritesArchiveUploadsAndPrunes(t *testing.T) {
+	dataDir := setupBackupTestDB(t)
+	fake, server := newFakeS3Server(t, "backups")
+
+	if err := os.MkdirAll("template", 0755); err != nil {
+		t.Fatalf("mkdir template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("template", "clash.yaml"), []byte("proxies: []\n"), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "GeoLite2-City.mmdb"), []byte("geo"), 0644); err != nil {
+		t.Fatalf("write geoip: %v", err)
+	}
+	database.DB.Create(&models.Tag{Name: "hk", Color: "#fff"})
+
+	if err := SaveSettings(Settings{Enabled: true, RetentionDays: 7, S3: S3Settings{
+		Enabled: true, Endpoint: server.URL, Bucket: "backups", Prefix: "sublink",
+		AccessKeyID: "minio", SecretAccessKey: "minio-secret", PathStyle: true,
+	}}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	backupDir := filepath.Join(dataDir, "backups")
+	old := FilePrefix + time.Now().AddDate(0, 0, -10).Format(fileTimeLayout) + ".zip"
+	recent := FilePrefix + time.Now().AddDate(0, 0, -2).Format(fileTimeLayout) + ".zip"
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatalf("mkdir backups: %v", err)
+	}
+	for _, name := range []string{old, recent} {
+		if err := os.WriteFile(filepath.Join(backupDir, name), []byte("old"), 0644); err != nil {
+			t.Fatalf("write old backup: %v", err)
+		}
+		fake.objects["sublink/"+name] = []byte("old")
+	}
+	fake.objects["sublink/notes.txt"] = []byte("keep")
+
+	result, err := RunBackup(context.Background())
+	if err != nil {
+		t.Fatalf("run backup: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", result.Warnings)
+	}
+	if result.S3Key != "sublink/"+result.File.Name {
+		t.Fatalf("unexpected s3 key %q", result.S3Key)
+	}
+	if len(result.Pruned) != 1 || result.Pruned[0] != old || len(result.PrunedS3) != 1 {
+		t.Fatalf("unexpected prune result: %+v / %+v", result.Pruned, result.PrunedS3)
+	}
+	wantKeys := []string{"sublink/notes.txt", "sublink/" + recent, result.S3Key}
+	if keys := fake.keys(); len(keys) != 3 || keys[0] != wantKeys[0] || keys[1] != wantKeys[1] || keys[2] != wantKeys[2] {
+		t.Fatalf("unexpected bucket contents: %v", keys)
+	}
+
+	files, err := ListLocal(backupDir)
+	if err != nil || len(files) != 2 || files[0].Name != result.File.Name {
+		t.Fatalf("unexpected local backups: %+v (%v)", files, err)
+	}
+
+	reader, err := zip.OpenReader(filepath.Join(backupDir, result.File.Name))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+	entries := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		entries[file.Name] = file
+	}
+	for _, name := range []string{ManifestName, SnapshotName, "template/clash.yaml"} {
+		if entries[name] == nil {
+			t.Fatalf("archive is missing %s, has %v", name, entries)
+		}
+	}
+	if entries["db/GeoLite2-City.mmdb"] != nil {
+		t.Fatalf("archive should not contain the GeoIP database")
+	}
+	for name := range entries {
+		if filepath.Dir(name) == "db/backups" {
+			t.Fatalf("archive should not contain local backups: %s", name)
+		}
+	}
+
+	manifestFile, _ := entries[ManifestName].Open()
+	var manifest Manifest
+	err = json.NewDecoder(manifestFile).Decode(&manifest)
+	_ = manifestFile.Close()
+	if err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if manifest.Format != FormatName || manifest.Tables["tags"] != 1 || manifest.Tables["system_settings"] == 0 {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+}
+
+func TestRunBackupEncryptsWhenEnabled(t *testing.T) {
+	dataDir := setupBackupTestDB(t)
+	database.DB.Create(&models.Tag{Name: "hk", Color: "#fff"})
+
+	if err := SaveSettings(Settings{Encryption: EncryptionSettings{Enabled: true, Passphrase: "short"}}); err == nil {
+		t.Fatalf("expected short passphrase to be rejected")
+	}
+	if err := SaveSettings(Settings{Encryption: EncryptionSettings{Enabled: true, Passphrase: "correct horse"}}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	if raw, _ := models.GetSetting(settingPassphrase); raw == "" || raw == "correct horse" {
+		t.Fatalf("expected encrypted passphrase, got %q", raw)
+	}
+	if public := LoadPublicSettings(); public.Encryption.Passphrase != "" || !public.HasPassphrase {
+		t.Fatalf("public settings leaked passphrase: %+v", public)
+	}
+
+	result, err := RunBackup(context.Background())
+	if err != nil {
+		t.Fatalf("run backup: %v", err)
+	}
+	if filepath.Ext(result.File.Name) != EncryptedExt || !ValidName(result.File.Name) {
+		t.Fatalf("expected encrypted backup name, got %q", result.File.Name)
+	}
+	path := filepath.Join(dataDir, "backups", result.File.Name)
+	if encrypted, err := IsEncryptedFile(path); err != nil || !encrypted {
+		t.Fatalf("expected encrypted backup file, got %v %v", encrypted, err)
+	}
+	if files, err := ListLocal(filepath.Dir(path)); err != nil || len(files) != 1 {
+		t.Fatalf("expected encrypted backup to be listed, got %+v (%v)", files, err)
+	}
+
+	plainPath := filepath.Join(t.TempDir(), "plain.zip")
+	if err := DecryptFile(path, plainPath, "correct horse"); err != nil {
+		t.Fatalf("decrypt backup: %v", err)
+	}
+	reader, err := zip.OpenReader(plainPath)
+	if err != nil {
+		t.Fatalf("open decrypted archive: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+	if len(reader.File) == 0 || reader.File[0].Name != ManifestName {
+		t.Fatalf("unexpected decrypted archive entries")
+	}
+}
+
+func TestValidNameRejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../sublinkpro-backup-20260101-040000.zip", "sublinkpro-backup-20260101-040000.zip/..", "backup.zip"} {
+		if ValidName(name) {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+	if !ValidName("sublinkpro-pre-restore-20260101-040000-2.zip") {
+		t.Fatalf("expected pre-restore snapshot name to be valid")
+	}
+}
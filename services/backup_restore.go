@@ -0,0 +1,572 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sublink/database"
+	"sublink/models"
+	"sublink/services/backup"
+	"sublink/services/scheduler"
+	"sublink/utils"
+
+	"gorm.io/gorm"
+)
+
+// BackupRestoreSource 已校验、已解压的待恢复备份
+type BackupRestoreSource struct {
+	Name        string           `json:"name"`
+	Legacy      bool             `json:"legacy"`
+	Encrypted   bool             `json:"encrypted"`
+	Manifest    *backup.Manifest `json:"manifest,omitempty"`
+	Tables      map[string]int64 `json:"tables"`
+	HasTemplate bool             `json:"hasTemplate"`
+
+	dir           string
+	dbPath        string
+	templateDir   string
+	uploadPath    string
+	decryptedPath string
+}
+
+// BackupRestoreResult 备份恢复结果，供任务中心展示
+type BackupRestoreResult struct {
+	SourceName        string           `json:"sourceName"`
+	Snapshot          string           `json:"snapshot"`
+	Restored          map[string]int64 `json:"restored"`
+	TemplatesRestored bool             `json:"templatesRestored"`
+	Warnings          []string         `json:"warnings"`
+}
+
+// Cleanup 清理解压目录以及上传的临时文件
+func (s *BackupRestoreSource) Cleanup() {
+	if s.dir != "" {
+		if err := os.RemoveAll(s.dir); err != nil {
+			utils.Warn("清理备份恢复临时目录失败: %v", err)
+		}
+	}
+	for _, path := range []string{s.uploadPath, s.decryptedPath} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			utils.Warn("清理备份临时文件失败: %v", err)
+		}
+	}
+}
+
+// backupRestoreModels 恢复时覆盖的数据表
+// 迁移记录以当前程序为准，任务记录保留当前实例的历史（恢复任务自身也记录在其中）。
+// 登录会话与 MFA 挑战同样保留当前状态：登录密钥不随恢复变化，若还原备份中的会话，
+// 执行恢复的管理员会被登出，备份之后注销的会话也会重新生效。
+func backupRestoreModels() []any {
+	all := models.BackupModels()
+	result := make([]any, 0, len(all))
+	for _, model := range all {
+		switch model.(type) {
+		case *database.Migration, *models.Task, *models.UserSession, *models.MFALoginChallenge:
+			continue
+		}
+		result = append(result, model)
+	}
+	return result
+}
+
+// PrepareBackupRestore 解密（如需）、解压并校验备份包，校验失败时不会触碰当前数据
+// passphrase 为空时使用已保存的备份密码；removeUpload 为 true 时，Cleanup 会一并删除 zipPath。
+func PrepareBackupRestore(zipPath, name, passphrase string, removeUpload bool) (*BackupRestoreSource, error) {
+	source := &BackupRestoreSource{Name: name}
+	if removeUpload {
+		source.uploadPath = zipPath
+	}
+	encrypted, err := backup.IsEncryptedFile(zipPath)
+	if err != nil {
+		source.Cleanup()
+		return nil, fmt.Errorf("读取备份文件失败: %w", err)
+	}
+	if encrypted {
+		source.Encrypted = true
+		decryptedPath, err := decryptBackupArchive(zipPath, passphrase)
+		if err != nil {
+			source.Cleanup()
+			return nil, err
+		}
+		source.decryptedPath = decryptedPath
+		zipPath = decryptedPath
+	}
+	if !looksLikeZipFile(zipPath) {
+		source.Cleanup()
+		return nil, fmt.Errorf("备份文件不是有效的 zip 压缩包")
+	}
+	dir, err := extractMigrationZip(zipPath)
+	if err != nil {
+		source.Cleanup()
+		return nil, err
+	}
+	source.dir = dir
+	if err := validateBackupRestoreSource(source); err != nil {
+		source.Cleanup()
+		return nil, err
+	}
+	return source, nil
+}
+
+// CheckBackupPassphrase 校验备份密码，只读取文件头与第一块，不解压也不触碰当前数据
+// 返回值 encrypted 表示备份是否加密，未加密的备份不需要密码。
+func CheckBackupPassphrase(path, passphrase string) (bool, error) {
+	encrypted, err := backup.IsEncryptedFile(path)
+	if err != nil || !encrypted {
+		return false, err
+	}
+	return true, backup.CheckPassphraseFile(path, resolveBackupPassphrase(passphrase))
+}
+
+// resolveBackupPassphrase 未提供密码时回退到已保存的备份密码
+func resolveBackupPassphrase(passphrase string) string {
+	if passphrase != "" {
+		return passphrase
+	}
+	settings, _ := backup.LoadSettings()
+	return settings.Encryption.Passphrase
+}
+
+// decryptBackupArchive 把加密备份解密到迁移临时目录，返回解密后的 zip 路径
+func decryptBackupArchive(path, passphrase string) (string, error) {
+	tempRoot, err := ensureDatabaseMigrationTempRoot()
+	if err != nil {
+		return "", err
+	}
+	tempFile, err := os.CreateTemp(tempRoot, "decrypted-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("创建解密临时文件失败: %w", err)
+	}
+	decryptedPath := tempFile.Name()
+	_ = tempFile.Close()
+	if err := backup.DecryptFile(path, decryptedPath, resolveBackupPassphrase(passphrase)); err != nil {
+		_ = os.Remove(decryptedPath)
+		return "", err
+	}
+	return decryptedPath, nil
+}
+
+func validateBackupRestoreSource(source *BackupRestoreSource) error {
+	manifestPath := filepath.Join(source.dir, backup.ManifestName)
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		var manifest backup.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("备份清单解析失败: %w", err)
+		}
+		if manifest.Format != backup.FormatName {
+			return fmt.Errorf("不是 SublinkPro 备份文件")
+		}
+		if manifest.Version > backup.FormatVersion {
+			return fmt.Errorf("备份格式版本 %d 高于当前程序支持的版本 %d，请先升级", manifest.Version, backup.FormatVersion)
+		}
+		source.Manifest = &manifest
+		source.dbPath = filepath.Join(source.dir, filepath.FromSlash(backup.SnapshotName))
+	} else {
+		// 旧版 backup.zip：只有 db 与 template 目录
+		source.Legacy = true
+		dbPath, err := findSQLiteDatabaseFile(filepath.Join(source.dir, "db"))
+		if err != nil {
+			return err
+		}
+		source.dbPath = dbPath
+	}
+
+	templateDir := filepath.Join(source.dir, "template")
+	if info, err := os.Stat(templateDir); err == nil && info.IsDir() {
+		source.templateDir = templateDir
+		source.HasTemplate = true
+	}
+
+	snapshot, err := backup.OpenSQLite(source.dbPath)
+	if err != nil {
+		return fmt.Errorf("打开备份数据库失败: %w", err)
+	}
+	defer backup.CloseDB(snapshot)
+
+	var integrity string
+	if err := snapshot.Raw("PRAGMA quick_check").Scan(&integrity).Error; err != nil {
+		return fmt.Errorf("备份数据库无法读取: %w", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("备份数据库校验失败: %s", integrity)
+	}
+	for _, model := range []any{&models.User{}, &models.SystemSetting{}} {
+		if !snapshot.Migrator().HasTable(model) {
+			return fmt.Errorf("备份数据库缺少必要的数据表")
+		}
+	}
+	if err := checkBackupMigrations(snapshot); err != nil {
+		return err
+	}
+
+	tables, err := backup.CountRows(context.Background(), snapshot, backupRestoreModels())
+	if err != nil {
+		return err
+	}
+	source.Tables = tables
+	return nil
+}
+
+// checkBackupMigrations 拒绝来自更新版本程序的备份或源库，避免其数据结构无法被当前程序识别
+func checkBackupMigrations(snapshot *gorm.DB) error {
+	if !snapshot.Migrator().HasTable(&database.Migration{}) {
+		return nil
+	}
+	var backupIDs []string
+	if err := snapshot.Model(&database.Migration{}).Pluck("id", &backupIDs).Error; err != nil {
+		return fmt.Errorf("读取备份迁移记录失败: %w", err)
+	}
+	var currentIDs []string
+	if err := database.DB.Model(&database.Migration{}).Pluck("id", &currentIDs).Error; err != nil {
+		return fmt.Errorf("读取当前迁移记录失败: %w", err)
+	}
+	known := make(map[string]struct{}, len(currentIDs))
+	for _, id := range currentIDs {
+		known[id] = struct{}{}
+	}
+	for _, id := range backupIDs {
+		if _, ok := known[id]; !ok {
+			return fmt.Errorf("数据来自更新版本的 SublinkPro（迁移 %s 未知），请先升级后再操作", id)
+		}
+	}
+	return nil
+}
+
+// RunBackupRestoreTask 在任务中心中执行备份恢复
+func RunBackupRestoreTask(ctx context.Context, taskID string, source *BackupRestoreSource) {
+	tm := GetTaskManager()
+	defer source.Cleanup()
+
+	if databaseMigrationRunning.Swap(true) {
+		_ = tm.FailTask(taskID, "已有数据库迁移或恢复任务在运行，请稍后再试")
+		return
+	}
+	defer databaseMigrationRunning.Store(false)
+
+	if err := ensureDatabaseMigrationCanRun(taskID); err != nil {
+		_ = tm.FailTask(taskID, err.Error())
+		return
+	}
+
+	result, err := executeBackupRestore(ctx, taskID, source)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.Warn("备份恢复任务已取消: %s", taskID)
+			return
+		}
+		_ = tm.FailTask(taskID, err.Error())
+		return
+	}
+
+	message := "备份恢复完成"
+	if len(result.Warnings) > 0 {
+		message = fmt.Sprintf("备份恢复完成，但有 %d 条警告", len(result.Warnings))
+	}
+	_ = tm.CompleteTask(taskID, message, result)
+}
+
+func executeBackupRestore(ctx context.Context, taskID string, source *BackupRestoreSource) (*BackupRestoreResult, error) {
+	tables := backupRestoreModels()
+	if err := GetTaskManager().UpdateTotal(taskID, len(tables)+4); err != nil {
+		utils.Warn("更新备份恢复任务总步骤失败: %v", err)
+	}
+	step := 0
+	reportStep := func(label string) error {
+		if err := checkDatabaseMigrationContext(ctx); err != nil {
+			return err
+		}
+		step++
+		return GetTaskManager().UpdateProgress(taskID, step, label, nil)
+	}
+
+	result := &BackupRestoreResult{SourceName: source.Name, Warnings: make([]string, 0)}
+	if source.Legacy {
+		result.Warnings = append(result.Warnings, "旧版备份包不含清单，已按数据库文件直接恢复")
+	}
+
+	// 恢复前快照：失败时不触碰任何数据
+	snapshot, err := backup.CreateSnapshot(ctx, backup.PreRestorePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("创建恢复前快照失败，已取消恢复: %w", err)
+	}
+	result.Snapshot = snapshot.Name
+	if err := reportStep("已创建恢复前快照 " + snapshot.Name); err != nil {
+		return nil, err
+	}
+
+	// 自动备份配置属于当前实例，恢复后按当前值重新写入（S3 Secret 需用恢复后的密钥重新加密）
+	backupSettings, backupSettingsErr := backup.LoadSettings()
+
+	restored, err := applyBackupRestore(ctx, source, tables, reportStep)
+	if err != nil {
+		return nil, err
+	}
+	result.Restored = restored
+	result.TemplatesRestored = source.templateDir != ""
+	if err := reportStep("数据与模板已恢复"); err != nil {
+		return nil, err
+	}
+
+	if err := reseedSequences(database.DB, backup.AutoIncrementTables(database.DB, tables)); err != nil {
+		result.Warnings = append(result.Warnings, "重置自增序列失败: "+err.Error())
+	}
+	if err := reportStep("自增序列已重置"); err != nil {
+		return nil, err
+	}
+
+	if err := reloadRuntimeStateAfterMigration(); err != nil {
+		return nil, fmt.Errorf("数据已恢复，但刷新运行时状态失败: %w；建议重启服务", err)
+	}
+	if backupSettingsErr == nil {
+		if err := backup.SaveSettings(backupSettings); err != nil {
+			result.Warnings = append(result.Warnings, "恢复自动备份配置失败: "+err.Error())
+		} else if !models.IsDemoMode() {
+			if err := scheduler.GetSchedulerManager().StartBackupTask(); err != nil {
+				result.Warnings = append(result.Warnings, "重启自动备份任务失败: "+err.Error())
+			}
+		}
+	}
+	if err := reportStep("配置、缓存和定时任务已刷新"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyBackupRestore 在单个事务中清空并写入数据表，随后替换模板目录
+// 任一步骤失败时数据库事务回滚，模板目录恢复原状。
+func applyBackupRestore(ctx context.Context, source *BackupRestoreSource, tables []any, reportStep func(string) error) (map[string]int64, error) {
+	sourceDB, err := backup.OpenSQLite(source.dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份数据库失败: %w", err)
+	}
+	defer backup.CloseDB(sourceDB)
+
+	restored := make(map[string]int64, len(tables))
+	templateSwap := &templateDirSwap{}
+	err = database.WithTransaction(func(tx *gorm.DB) error {
+		preserved, err := loadPreservedRestoreSettings(tx)
+		if err != nil {
+			return err
+		}
+		targetTables := make([]any, 0, len(tables))
+		for _, model := range tables {
+			if tx.Migrator().HasTable(model) {
+				targetTables = append(targetTables, model)
+			}
+		}
+		for i := len(targetTables) - 1; i >= 0; i-- {
+			table, err := tableName(tx, targetTables[i])
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM " + quoteMigrationIdentifier(table)).Error; err != nil {
+				return fmt.Errorf("清空数据表 %s 失败: %w", table, err)
+			}
+		}
+
+		for _, model := range targetTables {
+			copied, err := backup.CopyTables(ctx, sourceDB, tx, []any{model}, nil)
+			if err != nil {
+				return err
+			}
+			for table, count := range copied {
+				restored[table] = count
+				if err := reportStep(fmt.Sprintf("%s 已恢复 %d 条", table, count)); err != nil {
+					return err
+				}
+			}
+		}
+
+		for key, value := range preserved {
+			if err := upsertSystemSetting(tx, key, value); err != nil {
+				return err
+			}
+		}
+		if err := pruneRestoredUserSessions(tx); err != nil {
+			return err
+		}
+		if source.templateDir != "" {
+			if err := templateSwap.Stage(source.templateDir); err != nil {
+				return fmt.Errorf("准备模板目录失败: %w", err)
+			}
+			if err := templateSwap.Swap(); err != nil {
+				return fmt.Errorf("替换模板目录失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if rollbackErr := templateSwap.Rollback(); rollbackErr != nil {
+			utils.Error("恢复失败后回滚模板目录失败: %v", rollbackErr)
+		}
+		return nil, fmt.Errorf("恢复失败，数据已回滚: %w", err)
+	}
+	templateSwap.Commit()
+	return restored, nil
+}
+
+// loadPreservedRestoreSettings 恢复时保留的当前实例设置：登录密钥（避免当前会话失效）
+func loadPreservedRestoreSettings(tx *gorm.DB) (map[string]string, error) {
+	result := make(map[string]string)
+	var setting models.SystemSetting
+	err := tx.Where(map[string]any{"key": "jwt_secret"}).Take(&setting).Error
+	if err == nil {
+		result[setting.Key] = setting.Value
+		return result, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, nil
+	}
+	return nil, fmt.Errorf("读取保留设置失败: %w", err)
+}
+
+// pruneRestoredUserSessions 删除恢复后已无对应用户的会话
+// 用户 ID 与用户名需同时匹配，避免会话挂到恢复后恰好复用了相同 ID 的其他用户上。
+func pruneRestoredUserSessions(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable(&models.UserSession{}) {
+		return nil
+	}
+	err := tx.Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = user_sessions.user_id AND users.username = user_sessions.username)").
+		Delete(&models.UserSession{}).Error
+	if err != nil {
+		return fmt.Errorf("清理登录会话失败: %w", err)
+	}
+	return nil
+}
+
+func upsertSystemSetting(tx *gorm.DB, key, value string) error {
+	if err := tx.Where(map[string]any{"key": key}).Delete(&models.SystemSetting{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.SystemSetting{Key: key, Value: value}).Error
+}
+
+// templateDirSwap 在模板目录内部逐项替换模板，数据库事务失败时可回滚
+// 模板目录在 Docker 部署中通常是挂载点，不能对目录本身重命名，
+// 因此暂存目录与旧文件备份都放在模板目录内，只对其中的条目做同文件系统内的重命名。
+type templateDirSwap struct {
+	target string
+	staged string
+	backup string
+	// movedOut 已移入备份目录的原有条目，movedIn 已从暂存目录移入的新条目
+	movedOut []string
+	movedIn  []string
+}
+
+// Stage 把备份中的模板复制到模板目录内的暂存目录
+func (s *templateDirSwap) Stage(sourceDir string) error {
+	s.target = templateDirPath()
+	if err := os.MkdirAll(s.target, 0755); err != nil {
+		return err
+	}
+	stamp := time.Now().Format("20060102150405")
+	s.staged = filepath.Join(s.target, ".restore-"+stamp)
+	s.backup = filepath.Join(s.target, ".before-restore-"+stamp)
+	if err := os.RemoveAll(s.staged); err != nil {
+		return err
+	}
+	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		relativePath, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(s.staged, relativePath)
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, 0755)
+		}
+		return copyFile(path, targetPath)
+	})
+}
+
+// Swap 将模板目录中的原有条目逐个移入备份目录，再把暂存的条目逐个移入
+// 任一步失败时撤销已完成的移动。
+func (s *templateDirSwap) Swap() error {
+	if err := os.MkdirAll(s.backup, 0755); err != nil {
+		return err
+	}
+	current, err := os.ReadDir(s.target)
+	if err != nil {
+		return err
+	}
+	for _, entry := range current {
+		name := entry.Name()
+		if filepath.Join(s.target, name) == s.staged || filepath.Join(s.target, name) == s.backup {
+			continue
+		}
+		if err := os.Rename(filepath.Join(s.target, name), filepath.Join(s.backup, name)); err != nil {
+			return s.failSwap(err)
+		}
+		s.movedOut = append(s.movedOut, name)
+	}
+
+	staged, err := os.ReadDir(s.staged)
+	if err != nil {
+		return s.failSwap(err)
+	}
+	for _, entry := range staged {
+		name := entry.Name()
+		if err := os.Rename(filepath.Join(s.staged, name), filepath.Join(s.target, name)); err != nil {
+			return s.failSwap(err)
+		}
+		s.movedIn = append(s.movedIn, name)
+	}
+	return nil
+}
+
+// failSwap 撤销已完成的移动并返回原始错误
+func (s *templateDirSwap) failSwap(err error) error {
+	if rollbackErr := s.Rollback(); rollbackErr != nil {
+		utils.Error("回滚模板目录失败: %v", rollbackErr)
+	}
+	return err
+}
+
+// Rollback 撤销 Swap 并清理暂存与备份目录
+func (s *templateDirSwap) Rollback() error {
+	if s.target == "" {
+		return nil
+	}
+	var firstErr error
+	for _, name := range s.movedIn {
+		if err := os.RemoveAll(filepath.Join(s.target, name)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.movedIn = nil
+	for _, name := range s.movedOut {
+		if err := os.Rename(filepath.Join(s.backup, name), filepath.Join(s.target, name)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.movedOut = nil
+	if firstErr != nil {
+		// 备份目录中仍有未移回的旧模板，保留以便手动恢复
+		return firstErr
+	}
+	_ = os.RemoveAll(s.staged)
+	_ = os.RemoveAll(s.backup)
+	return nil
+}
+
+// Commit 确认替换并删除暂存目录与旧模板备份
+func (s *templateDirSwap) Commit() {
+	if s.target == "" {
+		return
+	}
+	for _, dir := range []string{s.staged, s.backup} {
+		if err := os.RemoveAll(dir); err != nil {
+			utils.Warn("清理模板恢复临时目录失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sublink/database"
+	"sublink/internal/testutil"
+	"sublink/models"
+	"sublink/services/backup"
+)
+
+func setupBackupRestoreTestDB(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("SUBLINK_DB_PATH", t.TempDir())
+	t.Setenv("SUBLINK_API_ENCRYPTION_KEY", "restore-test-key-0123456789abcdef0123")
+	t.Chdir(t.TempDir())
+
+	oldDB := database.DB
+	oldDialect := database.Dialect
+	db := testutil.OpenMemoryDB(t, "backup_restore_test")
+	if err := db.AutoMigrate(models.BackupModels()...); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	database.DB = db
+	database.Dialect = database.DialectSQLite
+	if err := models.InitSettingCache(); err != nil {
+		t.Fatalf("init setting cache: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB = oldDB
+		database.Dialect = oldDialect
+		if oldDB != nil {
+			_ = models.InitSettingCache()
+		}
+		testutil.CloseDB(t, db)
+	})
+}
+
+func writeTestTemplate(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll("template", 0755); err != nil {
+		t.Fatalf("mkdir template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("template", name), []byte(content), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+}
+
+func readTestTemplate(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("template", name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// writeTestArchive 生成当前数据库的备份包
+func writeTestArchive(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sublinkpro-backup-20260101-040000.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	if _, err := backup.WriteArchive(context.Background(), file); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+	return path
+}
+
+func tagNames(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	if err := database.DB.Model(&models.Tag{}).Order("name").Pluck("name", &names).Error; err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	return names
+}
+
+func TestApplyBackupRestoreReplacesDataAndTemplates(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init"})
+	database.DB.Create(&models.Tag{Name: "hk"})
+	database.DB.Create(&models.SystemSetting{Key: "jwt_secret", Value: "backup-jwt"})
+	database.DB.Create(&models.SystemSetting{Key: "site_name", Value: "backup-site"})
+	writeTestTemplate(t, "clash.yaml", "from-backup")
+	archive := writeTestArchive(t)
+
+	database.DB.Where("name = ?", "hk").Delete(&models.Tag{})
+	database.DB.Create(&models.Tag{Name: "us"})
+	database.DB.Model(&models.SystemSetting{}).Where("key = ?", "jwt_secret").Update("value", "current-jwt")
+	database.DB.Model(&models.SystemSetting{}).Where("key = ?", "site_name").Update("value", "current-site")
+	writeTestTemplate(t, "clash.yaml", "modified")
+	writeTestTemplate(t, "extra.yaml", "extra")
+	templateDirBefore, err := os.Stat("template")
+	if err != nil {
+		t.Fatalf("stat template dir: %v", err)
+	}
+
+	source, err := PrepareBackupRestore(archive, filepath.Base(archive), "", false)
+	if err != nil {
+		t.Fatalf("prepare restore: %v", err)
+	}
+	defer source.Cleanup()
+	if source.Legacy || source.Manifest == nil || source.Tables["tags"] != 1 || !source.HasTemplate {
+		t.Fatalf("unexpected restore source: %+v", source)
+	}
+
+	restored, err := applyBackupRestore(context.Background(), source, backupRestoreModels(), func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("apply restore: %v", err)
+	}
+	if restored["tags"] != 1 {
+		t.Fatalf("unexpected restored counts: %+v", restored)
+	}
+	if names := tagNames(t); len(names) != 1 || names[0] != "hk" {
+		t.Fatalf("expected tags from backup, got %v", names)
+	}
+
+	var jwt, site models.SystemSetting
+	database.DB.Where("key = ?", "jwt_secret").Take(&jwt)
+	database.DB.Where("key = ?", "site_name").Take(&site)
+	if jwt.Value != "current-jwt" || site.Value != "backup-site" {
+		t.Fatalf("unexpected settings after restore: jwt=%q site=%q", jwt.Value, site.Value)
+	}
+
+	if got := readTestTemplate(t, "clash.yaml"); got != "from-backup" {
+		t.Fatalf("expected template from backup, got %q", got)
+	}
+	if got := readTestTemplate(t, "extra.yaml"); got != "" {
+		t.Fatalf("expected templates to be replaced as a whole, extra.yaml=%q", got)
+	}
+	// 模板目录可能是挂载点，只替换其中的条目，目录本身保持不变
+	templateDirAfter, err := os.Stat("template")
+	if err != nil || !os.SameFile(templateDirBefore, templateDirAfter) {
+		t.Fatalf("expected template directory to be updated in place, err=%v", err)
+	}
+	entries, _ := os.ReadDir("template")
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			t.Fatalf("leftover template swap directory: %s", entry.Name())
+		}
+	}
+}
+
+func TestApplyBackupRestoreKeepsCurrentSessions(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	admin := models.User{Username: "admin", Password: "unused", Role: models.RoleAdmin}
+	database.DB.Create(&admin)
+	database.DB.Create(&models.UserSession{SessionID: "revoked-after-backup", UserID: admin.ID, Username: "admin"})
+	archive := writeTestArchive(t)
+
+	// 备份之后：旧会话被注销，管理员重新登录；另一个用户只存在于当前实例
+	database.DB.Where("session_id = ?", "revoked-after-backup").Delete(&models.UserSession{})
+	database.DB.Create(&models.UserSession{SessionID: "current-admin", UserID: admin.ID, Username: "admin"})
+	other := models.User{Username: "temp", Password: "unused", Role: models.RoleViewer}
+	database.DB.Create(&other)
+	database.DB.Create(&models.UserSession{SessionID: "orphan", UserID: other.ID, Username: "temp"})
+
+	source, err := PrepareBackupRestore(archive, filepath.Base(archive), "", false)
+	if err != nil {
+		t.Fatalf("prepare restore: %v", err)
+	}
+	defer source.Cleanup()
+	if _, err := applyBackupRestore(context.Background(), source, backupRestoreModels(), func(string) error { return nil }); err != nil {
+		t.Fatalf("apply restore: %v", err)
+	}
+
+	var sessionIDs []string
+	database.DB.Model(&models.UserSession{}).Order("session_id").Pluck("session_id", &sessionIDs)
+	if len(sessionIDs) != 1 || sessionIDs[0] != "current-admin" {
+		t.Fatalf("expected only the current admin session to remain, got %v", sessionIDs)
+	}
+}
+
+func TestApplyBackupRestoreRollsBackOnFailure(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&models.Tag{Name: "hk"})
+	writeTestTemplate(t, "clash.yaml", "from-backup")
+	archive := writeTestArchive(t)
+
+	database.DB.Where("name = ?", "hk").Delete(&models.Tag{})
+	database.DB.Create(&models.Tag{Name: "us"})
+	writeTestTemplate(t, "clash.yaml", "current")
+
+	source, err := PrepareBackupRestore(archive, filepath.Base(archive), "", false)
+	if err != nil {
+		t.Fatalf("prepare restore: %v", err)
+	}
+	defer source.Cleanup()
+
+	stopErr := errors.New("stop")
+	_, err = applyBackupRestore(context.Background(), source, backupRestoreModels(), func(label string) error {
+		if strings.HasPrefix(label, "tags ") {
+			return stopErr
+		}
+		return nil
+	})
+	if !errors.Is(err, stopErr) {
+		t.Fatalf("expected restore to fail, got %v", err)
+	}
+	if names := tagNames(t); len(names) != 1 || names[0] != "us" {
+		t.Fatalf("expected current tags after rollback, got %v", names)
+	}
+	if got := readTestTemplate(t, "clash.yaml"); got != "current" {
+		t.Fatalf("expected current template after rollback, got %q", got)
+	}
+}
+
+func TestPrepareBackupRestoreDecryptsEncryptedArchives(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&models.Tag{Name: "hk"})
+	plain := writeTestArchive(t)
+
+	encrypted := plain + backup.EncryptedExt
+	out, err := os.Create(encrypted)
+	if err != nil {
+		t.Fatalf("create encrypted archive: %v", err)
+	}
+	writer, err := backup.NewEncryptWriter(out, "correct horse")
+	if err != nil {
+		t.Fatalf("new encrypt writer: %v", err)
+	}
+	data, _ := os.ReadFile(plain)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	_ = out.Close()
+
+	if ok, err := CheckBackupPassphrase(plain, ""); ok || err != nil {
+		t.Fatalf("expected plain archive to need no passphrase, got %v %v", ok, err)
+	}
+	if _, err := CheckBackupPassphrase(encrypted, "wrong horse"); !errors.Is(err, backup.ErrWrongPassphrase) {
+		t.Fatalf("expected wrong passphrase, got %v", err)
+	}
+	if _, err := PrepareBackupRestore(encrypted, "enc.zip.enc", "", false); !errors.Is(err, backup.ErrPassphraseRequired) {
+		t.Fatalf("expected passphrase to be required, got %v", err)
+	}
+
+	source, err := PrepareBackupRestore(encrypted, "enc.zip.enc", "correct horse", false)
+	if err != nil {
+		t.Fatalf("prepare encrypted restore: %v", err)
+	}
+	if !source.Encrypted || source.Tables["tags"] != 1 {
+		t.Fatalf("unexpected restore source: %+v", source)
+	}
+	decryptedPath := source.decryptedPath
+	source.Cleanup()
+	if _, err := os.Stat(decryptedPath); !os.IsNotExist(err) {
+		t.Fatalf("expected decrypted archive to be removed on cleanup")
+	}
+
+	// 未提供密码时使用已保存的备份密码
+	if err := backup.SaveSettings(backup.Settings{Encryption: backup.EncryptionSettings{Enabled: true, Passphrase: "correct horse"}}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+	if ok, err := CheckBackupPassphrase(encrypted, ""); !ok || err != nil {
+		t.Fatalf("expected saved passphrase to be used, got %v %v", ok, err)
+	}
+}
+
+func TestPrepareBackupRestoreRejectsInvalidArchives(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init"})
+	database.DB.Create(&database.Migration{ID: "9999_future"})
+	archive := writeTestArchive(t)
+	database.DB.Where("id = ?", "9999_future").Delete(&database.Migration{})
+
+	if _, err := PrepareBackupRestore(archive, "future.zip", "", false); err == nil || !strings.Contains(err.Error(), "9999_future") {
+		t.Fatalf("expected newer backup to be rejected, got %v", err)
+	}
+
+	notZip := filepath.Join(t.TempDir(), "upload.zip")
+	if err := os.WriteFile(notZip, []byte("not a zip"), 0644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := PrepareBackupRestore(notZip, "upload.zip", "", true); err == nil {
+		t.Fatalf("expected invalid zip to be rejected")
+	}
+	if _, err := os.Stat(notZip); !os.IsNotExist(err) {
+		t.Fatalf("expected rejected upload to be removed")
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+
+	"sublink/cache"
+	"sublink/database"
+	"sublink/models"
+	"sublink/services/gitops"
+	"sublink/services/scheduler"
+	"sublink/utils"
+)
+
+// ExportConfiguration 导出当前实例的声明式配置
+func ExportConfiguration() (*gitops.Document, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return gitops.Export(database.DB, templateDirPath())
+}
+
+// PlanConfiguration 计算应用配置文档所需的变更，不写入任何数据
+func PlanConfiguration(doc *gitops.Document, prune bool) (*gitops.Plan, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return gitops.BuildPlan(database.DB, doc, gitops.Options{Prune: prune, TemplateDir: templateDirPath()})
+}
+
+// ApplyConfiguration 应用配置文档，并刷新受影响的缓存与定时任务
+// 与数据库迁移、恢复共用互斥标记，避免在整库替换期间写入配置。
+func ApplyConfiguration(doc *gitops.Document, prune bool) (*gitops.Plan, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if databaseMigrationRunning.Swap(true) {
+		return nil, fmt.Errorf("已有数据库迁移或恢复任务在运行，请稍后再试")
+	}
+	defer databaseMigrationRunning.Store(false)
+
+	plan, err := gitops.Apply(database.DB, doc, gitops.Options{Prune: prune, TemplateDir: templateDirPath()})
+	if err != nil {
+		return nil, err
+	}
+	if plan.HasChanges() {
+		if err := reloadRuntimeStateAfterConfigApply(); err != nil {
+			utils.Warn("应用声明式配置后刷新缓存失败: %v", err)
+			plan.Warnings = append(plan.Warnings, "配置已写入，但刷新缓存失败，请重启服务: "+err.Error())
+		}
+	}
+	utils.Info("声明式配置已应用: 创建 %d，更新 %d，删除 %d，未变化 %d", plan.Create, plan.Update, plan.Delete, plan.Unchanged)
+	return plan, nil
+}
+
+// reloadRuntimeStateAfterConfigApply 重新加载声明式配置涉及的缓存并重建定时任务
+func reloadRuntimeStateAfterConfigApply() error {
+	cache.InvalidateAllTemplateContent()
+
+	initializers := []func() error{
+		models.InitNodeCache,
+		models.InitNodeCheckProfileCache,
+		models.InitAirportCache,
+		models.InitGroupAirportSortCache,
+		models.InitTagCache,
+		models.InitTagRuleCache,
+		models.InitCountryRuleCache,
+		models.InitScriptCache,
+		models.InitTemplateCache,
+		models.InitSubcriptionCache,
+		models.InitSubLogsCache,
+		models.InitChainRuleCache,
+		models.InitSubscriptionShareCache,
+		models.InitShareDeviceCache,
+	}
+	for _, initializer := range initializers {
+		if err := initializer(); err != nil {
+			return err
+		}
+	}
+	utils.SetTagGroupTagsFunc(models.GetTagNamesByGroupName)
+
+	if !models.IsDemoMode() {
+		return scheduler.GetSchedulerManager().ReloadFromDatabase()
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sublink/config"
+	"sublink/database"
+	"sublink/models"
+	"sublink/services/backup"
+	"sublink/utils"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseCopyOptions 跨数据库迁移参数
+type DatabaseCopyOptions struct {
+	// SourceDSN 为空时以当前数据库为源
+	SourceDSN string `json:"sourceDsn"`
+	TargetDSN string `json:"targetDsn"`
+	// SwitchConfig 为 true 时，迁移并校验成功后把配置文件中的 DSN 切换为目标库，重启后生效
+	SwitchConfig bool `json:"switchConfig"`
+}
+
+// DatabaseCopyPlan 已校验的跨数据库迁移，持有源库与目标库连接
+type DatabaseCopyPlan struct {
+	Source       database.DSNInfo `json:"source"`
+	Target       database.DSNInfo `json:"target"`
+	Tables       map[string]int64 `json:"tables"`
+	SwitchConfig bool             `json:"switchConfig"`
+	// TargetMissing 仅校验时出现：目标库尚不存在，开始迁移时会自动创建
+	TargetMissing bool `json:"targetMissing,omitempty"`
+
+	sourceDB    *gorm.DB
+	targetDB    *gorm.DB
+	targetDSN   string
+	closeSource bool
+}
+
+// DatabaseCopyResult 跨数据库迁移结果，供任务中心展示
+type DatabaseCopyResult struct {
+	Source          database.DSNInfo `json:"source"`
+	Target          database.DSNInfo `json:"target"`
+	Copied          map[string]int64 `json:"copied"`
+	Verified        bool             `json:"verified"`
+	ConfigSwitched  bool             `json:"configSwitched"`
+	RestartRequired bool             `json:"restartRequired"`
+	Warnings        []string         `json:"warnings"`
+	ElapsedTime     string           `json:"elapsedTime"`
+}
+
+// Close 关闭迁移过程中打开的数据库连接，当前数据库连接不会被关闭
+func (p *DatabaseCopyPlan) Close() {
+	if p.closeSource && p.sourceDB != nil {
+		backup.CloseDB(p.sourceDB)
+	}
+	if p.targetDB != nil {
+		backup.CloseDB(p.targetDB)
+	}
+}
+
+// databaseCopyModels 跨库迁移复制的数据表
+// 任务记录属于正在运行的实例（迁移任务自身也在其中），不复制。
+func databaseCopyModels() []any {
+	all := models.BackupModels()
+	result := make([]any, 0, len(all))
+	for _, model := range all {
+		if _, ok := model.(*models.Task); ok {
+			continue
+		}
+		result = append(result, model)
+	}
+	return result
+}
+
+// PrepareDatabaseCopy 打开并校验源库与目标库，校验失败时不会写入任何数据
+// 目标库必须为空（数据表不存在或没有数据），以免覆盖已有实例或误把源库当作目标；
+// 目标库不存在时会自动创建，因此只应在确定开始迁移时调用。
+func PrepareDatabaseCopy(options DatabaseCopyOptions) (*DatabaseCopyPlan, error) {
+	return prepareDatabaseCopy(options, true)
+}
+
+// CheckDatabaseCopy 只读校验源库与目标库，不会建库，也不会创建 SQLite 文件
+// 目标库不存在时校验通过并标记 TargetMissing。
+func CheckDatabaseCopy(options DatabaseCopyOptions) (*DatabaseCopyPlan, error) {
+	plan, err := prepareDatabaseCopy(options, false)
+	if err != nil {
+		return nil, err
+	}
+	plan.Close()
+	return plan, nil
+}
+
+func prepareDatabaseCopy(options DatabaseCopyOptions, createTarget bool) (*DatabaseCopyPlan, error) {
+	options.SourceDSN = strings.TrimSpace(options.SourceDSN)
+	options.TargetDSN = strings.TrimSpace(options.TargetDSN)
+	if options.TargetDSN == "" {
+		return nil, fmt.Errorf("目标数据库 DSN 不能为空")
+	}
+
+	plan := &DatabaseCopyPlan{SwitchConfig: options.SwitchConfig, targetDSN: options.TargetDSN}
+	if options.SourceDSN == "" {
+		if database.DB == nil {
+			return nil, fmt.Errorf("数据库未初始化")
+		}
+		plan.sourceDB = database.DB
+		plan.Source = database.CurrentDSN()
+	} else {
+		db, info, err := database.OpenExistingDSN(options.SourceDSN)
+		if err != nil {
+			return nil, fmt.Errorf("连接源数据库失败: %w", err)
+		}
+		plan.sourceDB, plan.Source, plan.closeSource = db, *info, true
+	}
+
+	openTarget := database.OpenExistingDSN
+	if createTarget {
+		openTarget = database.OpenDSN
+	}
+	targetDB, targetInfo, err := openTarget(options.TargetDSN)
+	switch {
+	case err == nil:
+		plan.targetDB, plan.Target = targetDB, *targetInfo
+	case !createTarget && errors.Is(err, database.ErrDatabaseNotExist):
+		plan.Target, plan.TargetMissing = *targetInfo, true
+	default:
+		plan.Close()
+		return nil, fmt.Errorf("连接目标数据库失败: %w", err)
+	}
+
+	if err := validateDatabaseCopyPlan(plan); err != nil {
+		plan.Close()
+		return nil, err
+	}
+	return plan, nil
+}
+
+func validateDatabaseCopyPlan(plan *DatabaseCopyPlan) error {
+	if plan.Source.RedactedDSN != "" && plan.Source.RedactedDSN == plan.Target.RedactedDSN {
+		return fmt.Errorf("源数据库与目标数据库相同")
+	}
+	for _, model := range []any{&models.User{}, &models.SystemSetting{}} {
+		if !plan.sourceDB.Migrator().HasTable(model) {
+			return fmt.Errorf("源数据库缺少必要的数据表，不是 SublinkPro 数据库")
+		}
+	}
+	if err := checkBackupMigrations(plan.sourceDB); err != nil {
+		return err
+	}
+
+	tables := databaseCopyModels()
+	if plan.targetDB != nil {
+		targetCounts, err := backup.CountRows(context.Background(), plan.targetDB, tables)
+		if err != nil {
+			return fmt.Errorf("检查目标数据库失败: %w", err)
+		}
+		for _, model := range tables {
+			table, _ := backup.TableName(plan.targetDB, model)
+			if count := targetCounts[table]; count > 0 {
+				return fmt.Errorf("目标数据库不为空（表 %s 已有 %d 行），请使用新建的空数据库", table, count)
+			}
+		}
+	}
+
+	sourceCounts, err := backup.CountRows(context.Background(), plan.sourceDB, tables)
+	if err != nil {
+		return fmt.Errorf("统计源数据库失败: %w", err)
+	}
+	plan.Tables = sourceCounts
+	return nil
+}
+
+// RunDatabaseCopyTask 在任务中心中执行跨数据库迁移
+func RunDatabaseCopyTask(ctx context.Context, taskID string, plan *DatabaseCopyPlan) {
+	tm := GetTaskManager()
+	defer plan.Close()
+
+	if databaseMigrationRunning.Swap(true) {
+		_ = tm.FailTask(taskID, "已有数据库迁移或恢复任务在运行，请稍后再试")
+		return
+	}
+	defer databaseMigrationRunning.Store(false)
+
+	if err := ensureDatabaseMigrationCanRun(taskID); err != nil {
+		_ = tm.FailTask(taskID, err.Error())
+		return
+	}
+
+	result, err := executeDatabaseCopy(ctx, taskID, plan)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.Warn("跨数据库迁移任务已取消: %s", taskID)
+			return
+		}
+		_ = tm.FailTask(taskID, err.Error())
+		return
+	}
+
+	message := "跨数据库迁移完成"
+	if len(result.Warnings) > 0 {
+		message = fmt.Sprintf("跨数据库迁移完成，但有 %d 条警告", len(result.Warnings))
+	}
+	_ = tm.CompleteTask(taskID, message, result)
+}
+
+// executeDatabaseCopy 执行复制、校验与配置切换
+// 源库为当前数据库或需要切换配置时，复制期间冻结当前库的写入，否则复制开始后的写入不会进入目标库；
+// 切换配置后保持冻结直到重启，避免写入即将被弃用的旧库。
+func executeDatabaseCopy(ctx context.Context, taskID string, plan *DatabaseCopyPlan) (result *DatabaseCopyResult, _ error) {
+	start := time.Now()
+	if plan.SwitchConfig || plan.sourceDB == database.DB {
+		database.FreezeWrites("跨数据库迁移进行中")
+		defer func() {
+			if result == nil || !result.ConfigSwitched {
+				database.UnfreezeWrites()
+			}
+		}()
+	}
+	tables := databaseCopyModels()
+	if err := GetTaskManager().UpdateTotal(taskID, len(tables)+4); err != nil {
+		utils.Warn("更新跨数据库迁移任务总步骤失败: %v", err)
+	}
+	step := 0
+	reportStep := func(label string) error {
+		if err := checkDatabaseMigrationContext(ctx); err != nil {
+			return err
+		}
+		step++
+		return GetTaskManager().UpdateProgress(taskID, step, label, nil)
+	}
+
+	result = &DatabaseCopyResult{Source: plan.Source, Target: plan.Target, Warnings: make([]string, 0)}
+
+	if err := plan.targetDB.AutoMigrate(tables...); err != nil {
+		return nil, fmt.Errorf("创建目标数据库表结构失败: %w", err)
+	}
+	if err := reportStep("已创建目标数据库表结构"); err != nil {
+		return nil, err
+	}
+
+	sourceCounts, copied, err := copyDatabaseTables(ctx, plan, tables, reportStep)
+	if err != nil {
+		return nil, err
+	}
+	result.Copied = copied
+
+	if err := reseedSequences(plan.targetDB, backup.AutoIncrementTables(plan.targetDB, tables)); err != nil {
+		result.Warnings = append(result.Warnings, "重置目标库自增序列失败: "+err.Error())
+	}
+	if err := reportStep("目标库自增序列已重置"); err != nil {
+		return nil, err
+	}
+
+	if err := verifyDatabaseCopy(ctx, plan.targetDB, tables, sourceCounts); err != nil {
+		return nil, err
+	}
+	result.Verified = true
+	if err := reportStep("行数校验通过"); err != nil {
+		return nil, err
+	}
+
+	if plan.SwitchConfig {
+		warnings, err := switchDatabaseConfig(plan.targetDSN)
+		if err != nil {
+			return nil, fmt.Errorf("数据已迁移并校验通过，但切换配置失败: %w", err)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+		result.ConfigSwitched = true
+		result.RestartRequired = true
+		database.FreezeWrites("已切换到新数据库，请重启服务")
+	}
+	if err := reportStep("迁移完成"); err != nil {
+		return nil, err
+	}
+
+	result.ElapsedTime = time.Since(start).Round(time.Millisecond).String()
+	return result, nil
+}
+
+// copyDatabaseTables 在源库的只读快照中统计并复制全部数据表，目标库写入在同一事务中完成
+// 返回源库快照中的行数与实际复制的行数；任一步骤失败时目标库写入会被回滚。
+func copyDatabaseTables(ctx context.Context, plan *DatabaseCopyPlan, tables []any, reportStep func(string) error) (map[string]int64, map[string]int64, error) {
+	var sourceCounts map[string]int64
+	copied := make(map[string]int64, len(tables))
+
+	err := plan.sourceDB.Transaction(func(src *gorm.DB) error {
+		counts, err := backup.CountRows(ctx, src, tables)
+		if err != nil {
+			return fmt.Errorf("统计源数据库失败: %w", err)
+		}
+		sourceCounts = counts
+
+		return plan.targetDB.Transaction(func(dst *gorm.DB) error {
+			for _, model := range tables {
+				tableCopied, err := backup.CopyTables(ctx, src, dst, []any{model}, nil)
+				if err != nil {
+					return err
+				}
+				for table, count := range tableCopied {
+					copied[table] = count
+					if err := reportStep(fmt.Sprintf("%s 已复制 %d 行", table, count)); err != nil {
+						return err
+					}
+				}
+				if len(tableCopied) == 0 {
+					table, _ := backup.TableName(src, model)
+					if err := reportStep(table + " 在源库中不存在，已跳过"); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	}, sourceSnapshotTxOptions(plan.sourceDB))
+	if err != nil {
+		return nil, nil, fmt.Errorf("复制数据失败，目标库已回滚: %w", err)
+	}
+	return sourceCounts, copied, nil
+}
+
+// sourceSnapshotTxOptions 让源库读取在一致的快照中进行
+// SQLite 的读事务本身就是快照，MySQL 与 PostgreSQL 使用可重复读的只读事务。
+func sourceSnapshotTxOptions(db *gorm.DB) *sql.TxOptions {
+	if db.Dialector.Name() == database.DialectSQLite {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+// verifyDatabaseCopy 逐表比较目标库与源库快照的行数
+func verifyDatabaseCopy(ctx context.Context, target *gorm.DB, tables []any, sourceCounts map[string]int64) error {
+	targetCounts, err := backup.CountRows(ctx, target, tables)
+	if err != nil {
+		return fmt.Errorf("统计目标数据库失败: %w", err)
+	}
+	var mismatches []string
+	for table, expected := range sourceCounts {
+		if expected < 0 {
+			continue
+		}
+		if actual := targetCounts[table]; actual != expected {
+			mismatches = append(mismatches, fmt.Sprintf("%s（源库 %d 行，目标库 %d 行）", table, expected, actual))
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("行数校验失败: %s", strings.Join(mismatches, "；"))
+	}
+	return nil
+}
+
+// switchDatabaseConfig 把配置文件中的 DSN 切换为目标库，返回需要提示的告警
+func switchDatabaseConfig(targetDSN string) ([]string, error) {
+	config.UpdateConfig(func(cfg *config.AppConfig) {
+		cfg.DSN = targetDSN
+	})
+	if err := config.SaveToFile(); err != nil {
+		return nil, err
+	}
+	var warnings []string
+	if os.Getenv("SUBLINK_DSN") != "" {
+		warnings = append(warnings, "已设置环境变量 SUBLINK_DSN，它会覆盖配置文件中的 DSN，请同步修改后再重启")
+	}
+	return warnings, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sublink/config"
+	"sublink/database"
+	"sublink/models"
+	"sublink/services/backup"
+
+	"gorm.io/gorm"
+)
+
+// openCopyTestDB 按 DSN 打开测试用的 SQLite 文件库
+func openCopyTestDB(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, _, err := database.OpenDSN(dsn)
+	if err != nil {
+		t.Fatalf("open %s: %v", dsn, err)
+	}
+	t.Cleanup(func() { backup.CloseDB(db) })
+	return db
+}
+
+func seedCopySource(t *testing.T, dsn string) {
+	t.Helper()
+	db := openCopyTestDB(t, dsn)
+	if err := db.AutoMigrate(models.BackupModels()...); err != nil {
+		t.Fatalf("migrate source: %v", err)
+	}
+	db.Create(&database.Migration{ID: "0001_init"})
+	db.Create(&models.User{Username: "admin"})
+	db.Create(&models.SystemSetting{Key: "site_name", Value: "source"})
+	for _, name := range []string{"hk", "jp", "us"} {
+		db.Create(&models.Tag{Name: name})
+	}
+	db.Create(&models.Task{ID: "old-task", Type: models.TaskTypeTagRule, Name: "old"})
+}
+
+func TestDatabaseCopyCopiesVerifiesAndSwitchesConfig(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init"})
+	if err := database.RegisterWriteGuard(database.DB); err != nil {
+		t.Fatalf("register write guard: %v", err)
+	}
+	t.Cleanup(func() {
+		config.UpdateConfig(func(cfg *config.AppConfig) { cfg.DSN = "" })
+		database.UnfreezeWrites()
+	})
+
+	dir := t.TempDir()
+	sourceDSN := "sqlite://" + filepath.Join(dir, "source.db")
+	targetDSN := "sqlite://" + filepath.Join(dir, "target.db")
+	seedCopySource(t, sourceDSN)
+
+	plan, err := PrepareDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN, TargetDSN: targetDSN, SwitchConfig: true})
+	if err != nil {
+		t.Fatalf("prepare copy: %v", err)
+	}
+	defer plan.Close()
+	if plan.Source.Dialect != database.DialectSQLite || plan.Tables["tags"] != 3 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	task, _, err := GetTaskManager().CreateTask(models.TaskTypeDatabaseCopy, "copy", models.TaskTriggerManual, 1)
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	result, err := executeDatabaseCopy(t.Context(), task.ID, plan)
+	_ = GetTaskManager().CompleteTask(task.ID, "done", nil)
+	if err != nil {
+		t.Fatalf("execute copy: %v", err)
+	}
+	if !result.Verified || !result.ConfigSwitched || result.Copied["tags"] != 3 || result.Copied["migrations"] != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if _, ok := result.Copied["tasks"]; ok {
+		t.Fatalf("task history should not be copied")
+	}
+	// 切换配置后、重启前写入旧库的数据会丢失，写入保持冻结
+	if err := database.DB.Create(&models.Tag{Name: "late"}).Error; !errors.Is(err, database.ErrWritesFrozen) {
+		t.Fatalf("expected writes to the old database to be frozen after switching, got %v", err)
+	}
+
+	target := openCopyTestDB(t, targetDSN)
+	var site models.SystemSetting
+	if err := target.Where("key = ?", "site_name").Take(&site).Error; err != nil || site.Value != "source" {
+		t.Fatalf("expected settings in target, got %+v (%v)", site, err)
+	}
+	if config.GetDSN() != targetDSN {
+		t.Fatalf("expected config DSN to be switched, got %q", config.GetDSN())
+	}
+	data, err := os.ReadFile(config.GetConfigFilePath())
+	if err != nil || !strings.Contains(string(data), "target.db") {
+		t.Fatalf("expected config file to contain target DSN: %v", err)
+	}
+}
+
+func TestPrepareDatabaseCopyRejectsUnsafeTargets(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init"})
+
+	dir := t.TempDir()
+	sourceDSN := "sqlite://" + filepath.Join(dir, "source.db")
+	seedCopySource(t, sourceDSN)
+
+	if _, err := PrepareDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN}); err == nil {
+		t.Fatalf("expected empty target DSN to be rejected")
+	}
+	if _, err := PrepareDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN, TargetDSN: sourceDSN}); err == nil {
+		t.Fatalf("expected identical source and target to be rejected")
+	}
+
+	targetDSN := "sqlite://" + filepath.Join(dir, "target.db")
+	target := openCopyTestDB(t, targetDSN)
+	if err := target.AutoMigrate(&models.Tag{}); err != nil {
+		t.Fatalf("migrate target: %v", err)
+	}
+	target.Create(&models.Tag{Name: "existing"})
+	_, err := PrepareDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN, TargetDSN: targetDSN})
+	if err == nil || !strings.Contains(err.Error(), "tags") {
+		t.Fatalf("expected non-empty target to be rejected, got %v", err)
+	}
+
+	newer := openCopyTestDB(t, sourceDSN)
+	newer.Create(&database.Migration{ID: "9999_future"})
+	_, err = PrepareDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN, TargetDSN: "sqlite://" + filepath.Join(dir, "other.db")})
+	if err == nil || !strings.Contains(err.Error(), "9999_future") {
+		t.Fatalf("expected source from a newer version to be rejected, got %v", err)
+	}
+}
+
+func TestCheckDatabaseCopyDoesNotCreateTarget(t *testing.T) {
+	setupBackupRestoreTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init"})
+
+	dir := t.TempDir()
+	sourceDSN := "sqlite://" + filepath.Join(dir, "source.db")
+	seedCopySource(t, sourceDSN)
+
+	targetPath := filepath.Join(dir, "nested", "target.db")
+	plan, err := CheckDatabaseCopy(DatabaseCopyOptions{SourceDSN: sourceDSN, TargetDSN: "sqlite://" + targetPath})
+	if err != nil {
+		t.Fatalf("check copy: %v", err)
+	}
+	if !plan.TargetMissing || plan.Tables["tags"] != 3 {
+		t.Fatalf("expected missing target to be reported, got %+v", plan)
+	}
+	if _, err := os.Stat(filepath.Dir(targetPath)); !os.IsNotExist(err) {
+		t.Fatalf("check must not create the target directory or file, stat err = %v", err)
+	}
+
+	if _, err := CheckDatabaseCopy(DatabaseCopyOptions{SourceDSN: "sqlite://" + filepath.Join(dir, "missing.db"), TargetDSN: "sqlite://" + targetPath}); err == nil {
+		t.Fatal("expected missing source to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Fatalf("check must not create the source file, stat err = %v", err)
+	}
+}
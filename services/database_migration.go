@@ -1428,6 +1428,7 @@ func reloadRuntimeStateAfterMigration() error {
 		models.InitNodeCache,
 		models.InitSettingCache,
 		models.InitUserCache,
+		models.InitUserSessionCache,
 		models.InitScriptCache,
 		models.InitAirportCache,
 		models.InitGroupAirportSortCache,
@@ -1443,6 +1444,7 @@ func reloadRuntimeStateAfterMigration() error {
 		models.InitHostCache,
 		models.InitSubscriptionShareCache,
 		models.InitChainRuleCache,
+		models.InitRuleMirrorCache,
 	}
 
 	for _, initializer := range initializers {
@@ -1479,24 +1481,30 @@ func reloadRuntimeStateAfterMigration() error {
 }
 
 func reseedTargetSequences(tx *gorm.DB) error {
-	switch {
-	case database.IsMySQL():
-		return reseedMySQLAutoIncrement(tx)
-	case database.IsPostgres():
-		return reseedPostgresSequences(tx)
+	return reseedSequences(tx, sequenceTables(tx))
+}
+
+// reseedSequences 将指定数据表的自增值 / 序列重置到当前最大 ID 之后
+// 按 tx 自身的方言处理，tx 可以是全局 DB 之外的连接（例如跨库迁移的目标库）。
+func reseedSequences(tx *gorm.DB, tables []string) error {
+	switch tx.Dialector.Name() {
+	case database.DialectMySQL:
+		return reseedMySQLAutoIncrement(tx, tables)
+	case database.DialectPostgres:
+		return reseedPostgresSequences(tx, tables)
 	default:
 		return nil
 	}
 }
 
-func reseedMySQLAutoIncrement(tx *gorm.DB) error {
-	for _, table := range sequenceTables(tx) {
+func reseedMySQLAutoIncrement(tx *gorm.DB, tables []string) error {
+	for _, table := range tables {
 		var maxID int64
-		query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", quoteMigrationIdentifier(table))
+		query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", tx.Statement.Quote(table))
 		if err := tx.Raw(query).Scan(&maxID).Error; err != nil {
 			return fmt.Errorf("读取表 %s 最大ID失败: %w", table, err)
 		}
-		resetSQL := fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", quoteMigrationIdentifier(table), maxID+1)
+		resetSQL := fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", tx.Statement.Quote(table), maxID+1)
 		if err := tx.Exec(resetSQL).Error; err != nil {
 			return fmt.Errorf("重置表 %s 自增值失败: %w", table, err)
 		}
@@ -1504,10 +1512,10 @@ func reseedMySQLAutoIncrement(tx *gorm.DB) error {
 	return nil
 }
 
-func reseedPostgresSequences(tx *gorm.DB) error {
-	for _, table := range sequenceTables(tx) {
+func reseedPostgresSequences(tx *gorm.DB, tables []string) error {
+	for _, table := range tables {
 		var maxID int64
-		query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", quoteMigrationIdentifier(table))
+		query := fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s", tx.Statement.Quote(table))
 		if err := tx.Raw(query).Scan(&maxID).Error; err != nil {
 			return fmt.Errorf("读取表 %s 最大ID失败: %w", table, err)
 		}
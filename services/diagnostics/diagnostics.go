@@ -0,0 +1,382 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sublink/database"
+	"sublink/models"
+	"sublink/node/protocol"
+	"sublink/services/cloudflared"
+	"sublink/services/geoip"
+	"sublink/services/scheduler"
+	"sublink/services/substore"
+	"sublink/services/telegram"
+)
+
+// 检查结果状态
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// subStoreProbeTimeout Sub-Store 连通性探测的最长等待时间
+const subStoreProbeTimeout = 5 * time.Second
+
+// Check 单项检查结果
+type Check struct {
+	Name    string         `json:"name"`
+	Status  string         `json:"status"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Report 自检报告，Status 取所有检查项中最差的状态
+type Report struct {
+	Status      string    `json:"status"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Checks      []Check   `json:"checks"`
+}
+
+// Options 自检选项
+type Options struct {
+	// Runtime 为 true 表示在服务进程内执行，会核对定时任务、Telegram 连接与 cloudflared 进程等运行时状态；
+	// 命令行 doctor 子命令在独立进程中运行，只检查配置与外部依赖
+	Runtime bool
+}
+
+// Run 执行全部自检项并汇总报告
+func Run(ctx context.Context, opts Options) Report {
+	checks := []Check{
+		withPendingMigrations(CheckDatabase(ctx)),
+		checkGeoIP(),
+		checkScheduler(opts),
+		checkTelegram(opts),
+		checkCloudflared(opts),
+		checkSubStore(ctx),
+		checkTemplates(ctx),
+	}
+	report := Report{Status: StatusOK, GeneratedAt: time.Now(), Checks: checks}
+	for _, check := range checks {
+		if statusRank(check.Status) > statusRank(report.Status) {
+			report.Status = check.Status
+		}
+	}
+	return report
+}
+
+// CheckDatabase 检查数据库连通性与迁移版本，同时用于就绪探针
+func CheckDatabase(ctx context.Context) Check {
+	check := Check{Name: "database", Details: map[string]any{"dialect": database.Dialect}}
+	if database.DB == nil {
+		check.Status = StatusFail
+		check.Message = "数据库未初始化"
+		return check
+	}
+	sqlDB, err := database.DB.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = "数据库连接失败: " + err.Error()
+		return check
+	}
+
+	db := database.DB.WithContext(ctx)
+	if !db.Migrator().HasTable(&database.Migration{}) {
+		check.Status = StatusWarn
+		check.Message = "数据库连接正常，但未找到迁移记录表"
+		return check
+	}
+	var count int64
+	if err := db.Model(&database.Migration{}).Count(&count).Error; err != nil {
+		check.Status = StatusWarn
+		check.Message = "读取迁移记录失败: " + err.Error()
+		return check
+	}
+	check.Details["migrations"] = count
+	var latest database.Migration
+	if err := db.Order("created_at DESC").Order("id DESC").Limit(1).Find(&latest).Error; err == nil && latest.ID != "" {
+		check.Details["latestMigration"] = latest.ID
+		check.Details["latestMigrationAt"] = latest.CreatedAt
+	}
+	check.Status = StatusOK
+	check.Message = fmt.Sprintf("数据库连接正常，已执行 %d 个迁移", count)
+	return check
+}
+
+// withPendingMigrations 在数据库检查结果中补充尚未执行的迁移，存在待执行迁移时降级为告警
+// 就绪探针只调用 CheckDatabase，不会重放迁移流程。
+func withPendingMigrations(check Check) Check {
+	if check.Status == StatusFail {
+		return check
+	}
+	pending, err := models.PendingMigrations()
+	if err != nil {
+		check.Status = StatusWarn
+		check.Message = "检查待执行迁移失败: " + err.Error()
+		return check
+	}
+	if len(pending) == 0 {
+		return check
+	}
+	check.Details["pendingMigrations"] = pending
+	check.Status = StatusWarn
+	check.Message = fmt.Sprintf("数据库连接正常，有 %d 个迁移尚未执行，将在服务下次启动时执行", len(pending))
+	return check
+}
+
+// checkGeoIP 检查 GeoIP 数据库文件是否可用
+func checkGeoIP() Check {
+	info := geoip.GetDBInfo()
+	check := Check{Name: "geoip", Details: map[string]any{"path": info.Path, "size": info.Size}}
+	if !info.Available {
+		check.Status = StatusWarn
+		check.Message = "GeoIP 数据库不可用，节点落地地区识别将失效"
+		return check
+	}
+	check.Details["modTime"] = info.ModTime
+	check.Status = StatusOK
+	check.Message = "GeoIP 数据库可用"
+	return check
+}
+
+// checkScheduler 核对已注册的定时任务数量与启用的机场、节点检测策略数量
+func checkScheduler(opts Options) Check {
+	check := Check{Name: "scheduler"}
+	airports, profiles, err := expectedJobCounts()
+	if err != nil {
+		check.Status = StatusWarn
+		check.Message = "统计启用的定时任务失败: " + err.Error()
+		return check
+	}
+	check.Details = map[string]any{"enabledAirports": airports, "enabledProfiles": profiles}
+	if models.IsDemoMode() {
+		check.Status = StatusOK
+		check.Message = "演示模式下定时任务已禁用"
+		return check
+	}
+	if !opts.Runtime {
+		check.Status = StatusOK
+		check.Message = fmt.Sprintf("应加载 %d 个机场任务、%d 个节点检测任务", airports, profiles)
+		return check
+	}
+
+	stats := scheduler.GetSchedulerManager().JobStats()
+	check.Details["airportJobs"] = stats.Airport
+	check.Details["nodeCheckJobs"] = stats.NodeCheck
+	check.Details["systemJobs"] = stats.System
+	if stats.Airport != airports || stats.NodeCheck != profiles {
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("定时任务数量不一致：机场 %d/%d，节点检测 %d/%d", stats.Airport, airports, stats.NodeCheck, profiles)
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = fmt.Sprintf("已注册 %d 个机场任务、%d 个节点检测任务", stats.Airport, stats.NodeCheck)
+	return check
+}
+
+// expectedJobCounts 直接查询数据库统计应注册的机场与节点检测任务数量，不依赖缓存
+func expectedJobCounts() (int, int, error) {
+	if database.DB == nil {
+		return 0, 0, errors.New("数据库未初始化")
+	}
+	var airports int64
+	if err := database.DB.Model(&models.Airport{}).Where("enabled = ?", true).Count(&airports).Error; err != nil {
+		return 0, 0, err
+	}
+	var profiles int64
+	if err := database.DB.Model(&models.NodeCheckProfile{}).Where("enabled = ? AND cron_expr <> ?", true, "").Count(&profiles).Error; err != nil {
+		return 0, 0, err
+	}
+	return int(airports), int(profiles), nil
+}
+
+// checkTelegram 检查 Telegram 机器人配置与连接状态
+func checkTelegram(opts Options) Check {
+	check := Check{Name: "telegram"}
+	cfg, err := telegram.LoadConfig()
+	if err != nil {
+		check.Status = StatusWarn
+		check.Message = "读取 Telegram 配置失败: " + err.Error()
+		return check
+	}
+	check.Details = map[string]any{"enabled": cfg.Enabled}
+	if !cfg.Enabled {
+		check.Status = StatusOK
+		check.Message = "Telegram 机器人未启用"
+		return check
+	}
+	if cfg.BotToken == "" {
+		check.Status = StatusWarn
+		check.Message = "Telegram 机器人已启用但未配置 Bot Token"
+		return check
+	}
+	if !opts.Runtime {
+		check.Status = StatusOK
+		check.Message = "Telegram 机器人已启用（离线检查不验证连接）"
+		return check
+	}
+
+	status := telegram.GetStatus()
+	check.Details["connected"] = status["connected"]
+	check.Details["botUsername"] = status["botUsername"]
+	if connected, _ := status["connected"].(bool); !connected {
+		check.Status = StatusWarn
+		check.Message = "Telegram 机器人未连接"
+		if lastErr, _ := status["error"].(string); lastErr != "" {
+			check.Message += ": " + lastErr
+		}
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "Telegram 机器人已连接"
+	return check
+}
+
+// checkCloudflared 检查 cloudflared 安装、配置与进程状态
+func checkCloudflared(opts Options) Check {
+	status := cloudflared.DefaultManager().Status()
+	check := Check{Name: "cloudflared", Details: map[string]any{
+		"installed": status.Installed,
+		"enabled":   status.Enabled,
+		"running":   status.Running,
+		"version":   status.Version,
+	}}
+	switch {
+	case !status.Enabled:
+		check.Status = StatusOK
+		check.Message = "cloudflared 未启用"
+	case !status.Installed:
+		check.Status = StatusWarn
+		check.Message = "cloudflared 已启用，但未找到可执行文件"
+	case !status.HasToken:
+		check.Status = StatusWarn
+		check.Message = "cloudflared 已启用，但未配置 Tunnel Token"
+	case opts.Runtime && !status.Running:
+		check.Status = StatusWarn
+		check.Message = "cloudflared 已启用但未运行"
+		if status.LastError != "" {
+			check.Message += ": " + status.LastError
+		}
+	default:
+		check.Status = StatusOK
+		check.Message = "cloudflared 配置正常"
+		if opts.Runtime {
+			check.Message = "cloudflared 运行中"
+		}
+	}
+	return check
+}
+
+// checkSubStore 检查 Sub-Store 服务是否可达，收到任意 HTTP 响应即视为可达
+func checkSubStore(ctx context.Context) Check {
+	cfg, effective := substore.EffectiveConfig()
+	check := Check{Name: "substore", Details: map[string]any{"enabled": effective.Enabled.Value}}
+	if !effective.Configured {
+		check.Status = StatusOK
+		check.Message = "Sub-Store 未启用"
+		return check
+	}
+	check.Details["baseUrl"] = cfg.BaseURL
+
+	timeout := cfg.Timeout
+	if timeout <= 0 || timeout > subStoreProbeTimeout {
+		timeout = subStoreProbeTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, cfg.BaseURL, nil)
+	if err != nil {
+		check.Status = StatusWarn
+		check.Message = "Sub-Store 地址无效: " + err.Error()
+		return check
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		check.Status = StatusWarn
+		check.Message = "Sub-Store 无法访问: " + err.Error()
+		return check
+	}
+	_ = resp.Body.Close()
+	check.Details["httpStatus"] = resp.StatusCode
+	check.Details["latencyMs"] = time.Since(start).Milliseconds()
+	check.Status = StatusOK
+	check.Message = "Sub-Store 可访问"
+	return check
+}
+
+// checkTemplates 检查数据库中登记的模板文件是否存在于模板目录
+func checkTemplates(ctx context.Context) Check {
+	check := Check{Name: "templates", Details: map[string]any{"dir": protocol.DefaultTemplateDir}}
+	if database.DB == nil {
+		check.Status = StatusWarn
+		check.Message = "数据库未初始化，跳过模板检查"
+		return check
+	}
+	var templates []models.Template
+	if err := database.DB.WithContext(ctx).Select("name").Find(&templates).Error; err != nil {
+		check.Status = StatusWarn
+		check.Message = "读取模板列表失败: " + err.Error()
+		return check
+	}
+	missing := make([]string, 0)
+	for _, tmpl := range templates {
+		if _, err := os.Stat(filepath.Join(protocol.DefaultTemplateDir, tmpl.Name)); err != nil {
+			missing = append(missing, tmpl.Name)
+		}
+	}
+	sort.Strings(missing)
+	check.Details["total"] = len(templates)
+	if len(missing) > 0 {
+		check.Details["missing"] = missing
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%d 个模板文件缺失，使用这些模板的订阅将无法生成", len(missing))
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = fmt.Sprintf("%d 个模板文件均存在", len(templates))
+	return check
+}
+
+// statusRank 状态严重程度，数值越大越严重
+func statusRank(status string) int {
+	switch status {
+	case StatusFail:
+		return 2
+	case StatusWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// statusLabels 文本报告中各状态的显示标签
+var statusLabels = map[string]string{StatusOK: "[ OK ]", StatusWarn: "[WARN]", StatusFail: "[FAIL]"}
+
+// WriteText 以纯文本格式输出自检报告，供命令行 doctor 子命令使用
+func WriteText(w io.Writer, report Report) {
+	fmt.Fprintf(w, "SublinkPro 自检报告 (%s)\n\n", report.GeneratedAt.Format("2006-01-02 15:04:05"))
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s %-12s %s\n", statusLabels[check.Status], check.Name, check.Message)
+		keys := make([]string, 0, len(check.Details))
+		for key := range check.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "       - %s: %v\n", key, check.Details[key])
+		}
+	}
+	fmt.Fprintf(w, "\n总体状态: %s\n", report.Status)
+}
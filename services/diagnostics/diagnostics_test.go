@@ -0,0 +1,113 @@
+package diagnostics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sublink/database"
+	"sublink/internal/testutil"
+	"sublink/models"
+)
+
+func setupDiagnosticsTestDB(t *testing.T) {
+	t.Helper()
+
+	oldDB := database.DB
+	oldDialect := database.Dialect
+	db := testutil.OpenMemoryDB(t, "diagnostics_test")
+	if err := db.AutoMigrate(&database.Migration{}, &models.Airport{}, &models.NodeCheckProfile{}, &models.Template{}, &models.SystemSetting{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	database.DB = db
+	database.Dialect = database.DialectSQLite
+	t.Cleanup(func() {
+		database.DB = oldDB
+		database.Dialect = oldDialect
+		testutil.CloseDB(t, db)
+	})
+}
+
+func findCheck(t *testing.T, report Report, name string) Check {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("check %q not found in %+v", name, report.Checks)
+	return Check{}
+}
+
+func TestCheckDatabaseReportsLatestMigration(t *testing.T) {
+	setupDiagnosticsTestDB(t)
+	now := time.Now()
+	database.DB.Create(&database.Migration{ID: "0001_init", CreatedAt: now.Add(-time.Hour)})
+	database.DB.Create(&database.Migration{ID: "0002_share_usage", CreatedAt: now})
+
+	check := CheckDatabase(context.Background())
+	if check.Status != StatusOK || check.Details["migrations"] != int64(2) || check.Details["latestMigration"] != "0002_share_usage" {
+		t.Fatalf("unexpected database check: %+v", check)
+	}
+}
+
+func TestPendingMigrationsAreReportedWithoutApplying(t *testing.T) {
+	setupDiagnosticsTestDB(t)
+	database.DB.Create(&database.Migration{ID: "0001_init", CreatedAt: time.Now()})
+
+	check := withPendingMigrations(CheckDatabase(context.Background()))
+	pending, ok := check.Details["pendingMigrations"].([]string)
+	if check.Status != StatusWarn || !ok || len(pending) == 0 {
+		t.Fatalf("expected pending migrations to be reported, got %+v", check)
+	}
+	var count int64
+	database.DB.Model(&database.Migration{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("doctor must not apply migrations, migration records = %d", count)
+	}
+	if database.DB.Migrator().HasTable(&models.UserSession{}) {
+		t.Fatal("doctor must not create tables")
+	}
+}
+
+func TestCheckDatabaseFailsWithoutConnection(t *testing.T) {
+	oldDB := database.DB
+	database.DB = nil
+	t.Cleanup(func() { database.DB = oldDB })
+
+	if check := CheckDatabase(context.Background()); check.Status != StatusFail {
+		t.Fatalf("expected fail without database, got %+v", check)
+	}
+}
+
+func TestRunOfflineReportsMissingTemplatesAndExpectedJobs(t *testing.T) {
+	setupDiagnosticsTestDB(t)
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("template", 0755); err != nil {
+		t.Fatalf("mkdir template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("template", "clash.yaml"), []byte("proxies: []\n"), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	database.DB.Create(&[]models.Template{{Name: "clash.yaml"}, {Name: "surge.conf"}})
+	database.DB.Create(&[]models.Airport{{Name: "a", URL: "https://a.example", Enabled: true}, {Name: "b", URL: "https://b.example"}})
+	database.DB.Create(&models.NodeCheckProfile{Name: "hourly", Enabled: true, CronExpr: "0 * * * *"})
+
+	report := Run(context.Background(), Options{})
+	if report.Status != StatusFail {
+		t.Fatalf("expected missing template to fail the report, got %s", report.Status)
+	}
+
+	templates := findCheck(t, report, "templates")
+	missing, _ := templates.Details["missing"].([]string)
+	if templates.Status != StatusFail || len(missing) != 1 || missing[0] != "surge.conf" {
+		t.Fatalf("unexpected templates check: %+v", templates)
+	}
+
+	sched := findCheck(t, report, "scheduler")
+	if sched.Status != StatusOK || sched.Details["enabledAirports"] != 1 || sched.Details["enabledProfiles"] != 1 {
+		t.Fatalf("unexpected scheduler check: %+v", sched)
+	}
+}
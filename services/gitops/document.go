@@ -0,0 +1,309 @@
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"sublink/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 声明式配置文档的版本标识
+const (
+	APIVersion = "sublinkpro/v1"
+	Kind       = "Configuration"
+)
+
+// maxDocumentSize 单个配置文档的大小上限
+const maxDocumentSize = 32 << 20
+
+// Document 声明式配置文档
+// 各实体按名称（分享按 token）关联，不包含数据库 ID，可以在多个实例之间复用。
+// 某个分区在文档中缺省时 apply 不会修改该类实体；写成空列表并开启 prune 时会删除该类全部实体。
+type Document struct {
+	APIVersion        string                 `yaml:"apiVersion"`
+	Kind              string                 `yaml:"kind"`
+	ExportedAt        *time.Time             `yaml:"exportedAt,omitempty"`
+	NodeCheckProfiles []NodeCheckProfileSpec `yaml:"nodeCheckProfiles"`
+	Airports          []AirportSpec          `yaml:"airports"`
+	Groups            []GroupSpec            `yaml:"groups"`
+	Tags              []TagSpec              `yaml:"tags"`
+	TagRules          []TagRuleSpec          `yaml:"tagRules"`
+	CountryRules      []CountryRuleSpec      `yaml:"countryRules"`
+	Scripts           []ScriptSpec           `yaml:"scripts"`
+	Templates         []TemplateSpec         `yaml:"templates"`
+	Webhooks          []WebhookSpec          `yaml:"webhooks"`
+	Subscriptions     []SubscriptionSpec     `yaml:"subscriptions"`
+	Shares            []ShareSpec            `yaml:"shares"`
+}
+
+// NodeCheckProfileSpec 节点检测策略，按名称关联
+type NodeCheckProfileSpec struct {
+	Name                string `yaml:"name"`
+	Enabled             bool   `yaml:"enabled,omitempty"`
+	CronExpr            string `yaml:"cronExpr,omitempty"`
+	Mode                string `yaml:"mode,omitempty"`
+	TestURL             string `yaml:"testUrl,omitempty"`
+	LatencyURL          string `yaml:"latencyUrl,omitempty"`
+	Timeout             int    `yaml:"timeout,omitempty"`
+	Groups              string `yaml:"groups,omitempty"`
+	Tags                string `yaml:"tags,omitempty"`
+	LatencyConcurrency  int    `yaml:"latencyConcurrency,omitempty"`
+	SpeedConcurrency    int    `yaml:"speedConcurrency,omitempty"`
+	DetectCountry       bool   `yaml:"detectCountry,omitempty"`
+	LandingIPURL        string `yaml:"landingIpUrl,omitempty"`
+	IncludeHandshake    bool   `yaml:"includeHandshake,omitempty"`
+	SpeedRecordMode     string `yaml:"speedRecordMode,omitempty"`
+	PeakSampleInterval  int    `yaml:"peakSampleInterval,omitempty"`
+	PreserveSpeedResult bool   `yaml:"preserveSpeedResult,omitempty"`
+	TrafficByGroup      bool   `yaml:"trafficByGroup,omitempty"`
+	TrafficBySource     bool   `yaml:"trafficBySource,omitempty"`
+	TrafficByNode       bool   `yaml:"trafficByNode,omitempty"`
+	DetectQuality       bool   `yaml:"detectQuality,omitempty"`
+	QualityCheckURL     string `yaml:"qualityCheckUrl,omitempty"`
+	DetectUnlock        bool   `yaml:"detectUnlock,omitempty"`
+	UnlockProviders     string `yaml:"unlockProviders,omitempty"`
+}
+
+// AirportSpec 机场，按名称关联；更新后检测策略以策略名称引用
+type AirportSpec struct {
+	Name                         string                       `yaml:"name"`
+	URL                          string                       `yaml:"url,omitempty"`
+	Group                        string                       `yaml:"group,omitempty"`
+	CronExpr                     string                       `yaml:"cronExpr,omitempty"`
+	Enabled                      bool                         `yaml:"enabled,omitempty"`
+	DownloadWithProxy            bool                         `yaml:"downloadWithProxy,omitempty"`
+	ProxyLink                    string                       `yaml:"proxyLink,omitempty"`
+	UserAgent                    string                       `yaml:"userAgent,omitempty"`
+	RequestHeaders               models.AirportRequestHeaders `yaml:"requestHeaders,omitempty"`
+	FetchUsageInfo               bool                         `yaml:"fetchUsageInfo,omitempty"`
+	SkipTLSVerify                bool                         `yaml:"skipTLSVerify,omitempty"`
+	UpdateAfterDetect            bool                         `yaml:"updateAfterDetect,omitempty"`
+	UpdateAfterDetectProfile     string                       `yaml:"updateAfterDetectProfile,omitempty"`
+	UpdateAfterDetectChangedOnly bool                         `yaml:"updateAfterDetectChangedOnly,omitempty"`
+	Remark                       string                       `yaml:"remark,omitempty"`
+	Logo                         string                       `yaml:"logo,omitempty"`
+	NodeNameWhitelist            string                       `yaml:"nodeNameWhitelist,omitempty"`
+	NodeNameBlacklist            string                       `yaml:"nodeNameBlacklist,omitempty"`
+	ProtocolWhitelist            string                       `yaml:"protocolWhitelist,omitempty"`
+	ProtocolBlacklist            string                       `yaml:"protocolBlacklist,omitempty"`
+	NodeNamePreprocess           string                       `yaml:"nodeNamePreprocess,omitempty"`
+	DeduplicationRule            string                       `yaml:"deduplicationRule,omitempty"`
+	NodeNameUniquify             bool                         `yaml:"nodeNameUniquify,omitempty"`
+	NodeNamePrefix               string                       `yaml:"nodeNamePrefix,omitempty"`
+	NodeNameIntraUniquify        bool                         `yaml:"nodeNameIntraUniquify,omitempty"`
+	AutoFillCountry              bool                         `yaml:"autoFillCountry,omitempty"`
+	BackfillExistingCountry      bool                         `yaml:"backfillExistingCountry,omitempty"`
+}
+
+// GroupSpec 分组内机场排序，机场按名称引用，列表顺序即排序
+type GroupSpec struct {
+	Name     string   `yaml:"name"`
+	Airports []string `yaml:"airports"`
+}
+
+// TagSpec 标签
+type TagSpec struct {
+	Name        string `yaml:"name"`
+	Group       string `yaml:"group,omitempty"`
+	Color       string `yaml:"color,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// TagRuleSpec 标签规则，按 标签/规则名称 关联
+type TagRuleSpec struct {
+	Tag         string `yaml:"tag"`
+	Name        string `yaml:"name"`
+	Enabled     bool   `yaml:"enabled,omitempty"`
+	TriggerType string `yaml:"triggerType,omitempty"`
+	Conditions  string `yaml:"conditions,omitempty"`
+}
+
+// CountryRuleSpec 国家识别规则，按国家代码关联
+type CountryRuleSpec struct {
+	CountryCode string `yaml:"countryCode"`
+	CountryName string `yaml:"countryName,omitempty"`
+	Pattern     string `yaml:"pattern,omitempty"`
+	Enabled     bool   `yaml:"enabled,omitempty"`
+	Priority    int    `yaml:"priority,omitempty"`
+}
+
+// ScriptSpec 脚本
+type ScriptSpec struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+	Content string `yaml:"content"`
+}
+
+// TemplateSpec 模板，Content 为模板目录中的文件内容
+type TemplateSpec struct {
+	Name             string `yaml:"name"`
+	Category         string `yaml:"category,omitempty"`
+	RuleSource       string `yaml:"ruleSource,omitempty"`
+	UseProxy         bool   `yaml:"useProxy,omitempty"`
+	ProxyLink        string `yaml:"proxyLink,omitempty"`
+	EnableIncludeAll bool   `yaml:"enableIncludeAll,omitempty"`
+	Content          string `yaml:"content"`
+}
+
+// WebhookSpec Webhook
+type WebhookSpec struct {
+	Name        string `yaml:"name"`
+	URL         string `yaml:"url,omitempty"`
+	Method      string `yaml:"method,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+	Headers     string `yaml:"headers,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+	Enabled     bool   `yaml:"enabled,omitempty"`
+	EventKeys   string `yaml:"eventKeys,omitempty"`
+}
+
+// SubscriptionSpec 订阅及其过滤条件、关联分组/机场/脚本与链式代理规则
+// 手动选择的节点属于实例数据，不在声明式配置范围内，apply 不会修改。
+type SubscriptionSpec struct {
+	Name                  string          `yaml:"name"`
+	Config                string          `yaml:"config,omitempty"`
+	Groups                []string        `yaml:"groups,omitempty"`
+	Airports              []string        `yaml:"airports,omitempty"`
+	Scripts               []string        `yaml:"scripts,omitempty"`
+	IPWhitelist           string          `yaml:"ipWhitelist,omitempty"`
+	IPBlacklist           string          `yaml:"ipBlacklist,omitempty"`
+	DelayTime             int             `yaml:"delayTime,omitempty"`
+	MinSpeed              float64         `yaml:"minSpeed,omitempty"`
+	CountryWhitelist      string          `yaml:"countryWhitelist,omitempty"`
+	CountryBlacklist      string          `yaml:"countryBlacklist,omitempty"`
+	NodeNameRule          string          `yaml:"nodeNameRule,omitempty"`
+	NodeNamePreprocess    string          `yaml:"nodeNamePreprocess,omitempty"`
+	NodeNameWhitelist     string          `yaml:"nodeNameWhitelist,omitempty"`
+	NodeNameBlacklist     string          `yaml:"nodeNameBlacklist,omitempty"`
+	TagWhitelist          string          `yaml:"tagWhitelist,omitempty"`
+	TagBlacklist          string          `yaml:"tagBlacklist,omitempty"`
+	ProtocolWhitelist     string          `yaml:"protocolWhitelist,omitempty"`
+	ProtocolBlacklist     string          `yaml:"protocolBlacklist,omitempty"`
+	DeduplicationRule     string          `yaml:"deduplicationRule,omitempty"`
+	RefreshUsageOnRequest bool            `yaml:"refreshUsageOnRequest,omitempty"`
+	UpdateInterval        int             `yaml:"updateInterval,omitempty"`
+	MaxFraudScore         int             `yaml:"maxFraudScore,omitempty"`
+	OnlyResidential       bool            `yaml:"onlyResidential,omitempty"`
+	OnlyNative            bool            `yaml:"onlyNative,omitempty"`
+	ResidentialType       string          `yaml:"residentialType,omitempty"`
+	IPType                string          `yaml:"ipType,omitempty"`
+	QualityStatus         string          `yaml:"qualityStatus,omitempty"`
+	UnlockProvider        string          `yaml:"unlockProvider,omitempty"`
+	UnlockStatus          string          `yaml:"unlockStatus,omitempty"`
+	UnlockKeyword         string          `yaml:"unlockKeyword,omitempty"`
+	UnlockRules           string          `yaml:"unlockRules,omitempty"`
+	UnlockRuleMode        string          `yaml:"unlockRuleMode,omitempty"`
+	ChainRules            []ChainRuleSpec `yaml:"chainRules,omitempty"`
+}
+
+// ChainRuleSpec 链式代理规则，列表顺序即排序
+type ChainRuleSpec struct {
+	Name         string `yaml:"name"`
+	Enabled      bool   `yaml:"enabled,omitempty"`
+	ChainConfig  string `yaml:"chainConfig,omitempty"`
+	TargetConfig string `yaml:"targetConfig,omitempty"`
+}
+
+// ShareSpec 订阅分享，按 token 关联，订阅以名称引用
+// 访问次数、自动停用原因等运行状态不在声明式配置范围内。
+type ShareSpec struct {
+	Token             string     `yaml:"token"`
+	Subscription      string     `yaml:"subscription"`
+	Name              string     `yaml:"name,omitempty"`
+	Enabled           bool       `yaml:"enabled,omitempty"`
+	ExpireType        int        `yaml:"expireType,omitempty"`
+	ExpireDays        int        `yaml:"expireDays,omitempty"`
+	ExpireAt          *time.Time `yaml:"expireAt,omitempty"`
+	LimitWindow       int        `yaml:"limitWindow,omitempty"`
+	MaxRequests       int        `yaml:"maxRequests,omitempty"`
+	MaxIPs            int        `yaml:"maxIPs,omitempty"`
+	SignedOnly        bool       `yaml:"signedOnly,omitempty"`
+	MaxDevices        int        `yaml:"maxDevices,omitempty"`
+	CountryWhitelist  string     `yaml:"countryWhitelist,omitempty"`
+	CountryBlacklist  string     `yaml:"countryBlacklist,omitempty"`
+	TagWhitelist      string     `yaml:"tagWhitelist,omitempty"`
+	TagBlacklist      string     `yaml:"tagBlacklist,omitempty"`
+	ProtocolWhitelist string     `yaml:"protocolWhitelist,omitempty"`
+	ProtocolBlacklist string     `yaml:"protocolBlacklist,omitempty"`
+	MaxNodes          int        `yaml:"maxNodes,omitempty"`
+	ForceClient       string     `yaml:"forceClient,omitempty"`
+	ClashTemplate     string     `yaml:"clashTemplate,omitempty"`
+	SurgeTemplate     string     `yaml:"surgeTemplate,omitempty"`
+	FallbackMessage   string     `yaml:"fallbackMessage,omitempty"`
+	QuotaBytes        int64      `yaml:"quotaBytes,omitempty"`
+	QuotaExpireAt     *time.Time `yaml:"quotaExpireAt,omitempty"`
+	UsageRatio        int        `yaml:"usageRatio,omitempty"`
+}
+
+// Parse 解析并校验配置文档，未知字段视为错误，避免拼写错误被静默忽略
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("配置文档超过 %d MB", maxDocumentSize>>20)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var doc Document
+	if err := decoder.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("配置文档为空")
+		}
+		return nil, fmt.Errorf("解析配置文档失败: %w", err)
+	}
+	if doc.APIVersion != APIVersion {
+		return nil, fmt.Errorf("不支持的配置文档版本 %q，当前支持 %s", doc.APIVersion, APIVersion)
+	}
+	if doc.Kind != Kind {
+		return nil, fmt.Errorf("不支持的配置文档类型 %q，应为 %s", doc.Kind, Kind)
+	}
+	doc.normalize()
+	return &doc, nil
+}
+
+// Marshal 把配置文档编码为 YAML
+func Marshal(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalize 统一空列表、时间精度与时区，使文档与数据库中的取值可以直接比较
+func (d *Document) normalize() {
+	for i := range d.Airports {
+		d.Airports[i].RequestHeaders = emptyToNil(d.Airports[i].RequestHeaders)
+	}
+	for i := range d.Subscriptions {
+		d.Subscriptions[i].Groups = emptyToNil(d.Subscriptions[i].Groups)
+		d.Subscriptions[i].Airports = emptyToNil(d.Subscriptions[i].Airports)
+		d.Subscriptions[i].Scripts = emptyToNil(d.Subscriptions[i].Scripts)
+		d.Subscriptions[i].ChainRules = emptyToNil(d.Subscriptions[i].ChainRules)
+	}
+	for i := range d.Shares {
+		d.Shares[i].ExpireAt = normalizeTime(d.Shares[i].ExpireAt)
+		d.Shares[i].QuotaExpireAt = normalizeTime(d.Shares[i].QuotaExpireAt)
+	}
+}
+
+func normalizeTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	value := t.UTC().Truncate(time.Second)
+	return &value
+}
@@ -0,0 +1,331 @@
+package gitops
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// engine 按依赖顺序组织各类实体：创建与更新按顺序执行，删除按逆序执行
+type engine struct {
+	st *state
+
+	profiles      *section[NodeCheckProfileSpec]
+	airports      *section[AirportSpec]
+	groups        *section[GroupSpec]
+	tags          *section[TagSpec]
+	tagRules      *section[TagRuleSpec]
+	countryRules  *section[CountryRuleSpec]
+	scripts       *section[ScriptSpec]
+	templates     *section[TemplateSpec]
+	webhooks      *section[WebhookSpec]
+	subscriptions *section[SubscriptionSpec]
+	shares        *section[ShareSpec]
+}
+
+func newEngine(db *gorm.DB, options Options) *engine {
+	return &engine{
+		st:            &state{tx: db, templateDir: options.TemplateDir, templateWrites: make(map[string]string)},
+		profiles:      profileSection(),
+		airports:      airportSection(),
+		groups:        groupSection(),
+		tags:          tagSection(),
+		tagRules:      tagRuleSection(),
+		countryRules:  countryRuleSection(),
+		scripts:       scriptSection(),
+		templates:     templateSection(),
+		webhooks:      webhookSection(),
+		subscriptions: subscriptionSection(),
+		shares:        shareSection(),
+	}
+}
+
+func (e *engine) runners() []runner {
+	return []runner{
+		e.profiles, e.airports, e.groups, e.tags, e.tagRules, e.countryRules,
+		e.scripts, e.templates, e.webhooks, e.subscriptions, e.shares,
+	}
+}
+
+// loadSection 读取某类实体的当前配置，导出时空分区输出为空列表
+func loadSection[S any](s *section[S], st *state) ([]S, error) {
+	specs, err := s.load(st)
+	if err != nil {
+		return nil, fmt.Errorf("读取%s失败: %w", s.kind, err)
+	}
+	if specs == nil {
+		specs = []S{}
+	}
+	return specs, nil
+}
+
+// Export 导出当前实例的全部声明式配置
+func Export(db *gorm.DB, templateDir string) (*Document, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	doc := &Document{APIVersion: APIVersion, Kind: Kind, ExportedAt: &now}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		e := newEngine(tx, Options{TemplateDir: templateDir})
+		var err error
+		if doc.NodeCheckProfiles, err = loadSection(e.profiles, e.st); err != nil {
+			return err
+		}
+		if doc.Airports, err = loadSection(e.airports, e.st); err != nil {
+			return err
+		}
+		if doc.Groups, err = loadSection(e.groups, e.st); err != nil {
+			return err
+		}
+		if doc.Tags, err = loadSection(e.tags, e.st); err != nil {
+			return err
+		}
+		if doc.TagRules, err = loadSection(e.tagRules, e.st); err != nil {
+			return err
+		}
+		if doc.CountryRules, err = loadSection(e.countryRules, e.st); err != nil {
+			return err
+		}
+		if doc.Scripts, err = loadSection(e.scripts, e.st); err != nil {
+			return err
+		}
+		if doc.Templates, err = loadSection(e.templates, e.st); err != nil {
+			return err
+		}
+		if doc.Webhooks, err = loadSection(e.webhooks, e.st); err != nil {
+			return err
+		}
+		if doc.Subscriptions, err = loadSection(e.subscriptions, e.st); err != nil {
+			return err
+		}
+		doc.Shares, err = loadSection(e.shares, e.st)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// BuildPlan 计算把文档应用到当前实例所需的变更，不写入任何数据
+func BuildPlan(db *gorm.DB, doc *Document, options Options) (*Plan, error) {
+	var plan *Plan
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = newEngine(tx, options).plan(doc, options.Prune)
+		return err
+	})
+	return plan, err
+}
+
+// Apply 计算并在同一事务中执行变更；文档与实例一致时不做任何写入
+// 模板文件在事务提交后写入，失败时作为告警返回，再次执行 apply 即可补齐。
+func Apply(db *gorm.DB, doc *Document, options Options) (*Plan, error) {
+	var plan *Plan
+	var e *engine
+	err := db.Transaction(func(tx *gorm.DB) error {
+		e = newEngine(tx, options)
+		var err error
+		if plan, err = e.plan(doc, options.Prune); err != nil {
+			return err
+		}
+		if !plan.HasChanges() {
+			return nil
+		}
+		for _, r := range e.runners() {
+			if err := r.applyUpserts(e.st); err != nil {
+				return err
+			}
+		}
+		runners := e.runners()
+		for i := len(runners) - 1; i >= 0; i-- {
+			if err := runners[i].applyDeletes(e.st); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.Applied = true
+	plan.Warnings = append(plan.Warnings, e.st.syncTemplateFiles()...)
+	return plan, nil
+}
+
+func (e *engine) plan(doc *Document, prune bool) (*Plan, error) {
+	plan := &Plan{Changes: make([]Change, 0), Prune: prune, Warnings: make([]string, 0)}
+	for _, r := range e.runners() {
+		if err := r.diff(e.st, doc, prune); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.validate(doc); err != nil {
+		return nil, err
+	}
+	for _, r := range e.runners() {
+		changes, unchanged := r.result()
+		plan.Unchanged += unchanged
+		for _, change := range changes {
+			switch change.Action {
+			case ActionCreate:
+				plan.Create++
+			case ActionUpdate:
+				plan.Update++
+			case ActionDelete:
+				plan.Delete++
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+	return plan, nil
+}
+
+// validate 校验应用后的配置：取值合法，且跨实体引用的名称都存在
+func (e *engine) validate(doc *Document) error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	validCron := func(expr string) bool {
+		if expr == "" {
+			return true
+		}
+		_, err := parser.Parse(expr)
+		return err == nil
+	}
+	validJSON := func(value string) bool {
+		return value == "" || json.Valid([]byte(value))
+	}
+
+	profiles := e.profiles.finalKeys(doc)
+	airports := e.airports.finalKeys(doc)
+	tags := e.tags.finalKeys(doc)
+	scripts := e.scripts.finalKeys(doc)
+	subscriptions := e.subscriptions.finalKeys(doc)
+
+	for _, p := range e.profiles.final(doc) {
+		if !validCron(p.CronExpr) {
+			add("节点检测策略 %s 的 Cron 表达式无效: %s", p.Name, p.CronExpr)
+		}
+	}
+	for _, a := range e.airports.final(doc) {
+		if !validCron(a.CronExpr) {
+			add("机场 %s 的 Cron 表达式无效: %s", a.Name, a.CronExpr)
+		}
+		if a.UpdateAfterDetectProfile != "" && !profiles[a.UpdateAfterDetectProfile] {
+			add("机场 %s 引用的节点检测策略不存在: %s", a.Name, a.UpdateAfterDetectProfile)
+		}
+	}
+	for _, g := range e.groups.final(doc) {
+		if len(g.Airports) == 0 {
+			add("分组 %s 至少需要一个机场", g.Name)
+		}
+		seen := make(map[string]bool)
+		for _, name := range g.Airports {
+			if !airports[name] {
+				add("分组 %s 引用的机场不存在: %s", g.Name, name)
+			}
+			if seen[name] {
+				add("分组 %s 中机场重复: %s", g.Name, name)
+			}
+			seen[name] = true
+		}
+	}
+	for _, r := range e.tagRules.final(doc) {
+		if !tags[r.Tag] {
+			add("标签规则 %s/%s 引用的标签不存在", r.Tag, r.Name)
+		}
+		if !validJSON(r.Conditions) {
+			add("标签规则 %s/%s 的条件不是有效的 JSON", r.Tag, r.Name)
+		}
+	}
+	for _, t := range e.templates.final(doc) {
+		if !validTemplateName(t.Name) {
+			add("模板名称无效: %s", t.Name)
+		}
+	}
+	for _, s := range e.subscriptions.final(doc) {
+		for _, name := range s.Airports {
+			if !airports[name] {
+				add("订阅 %s 引用的机场不存在: %s", s.Name, name)
+			}
+		}
+		for _, name := range s.Scripts {
+			if !scripts[name] {
+				add("订阅 %s 引用的脚本不存在: %s", s.Name, name)
+			}
+		}
+		for _, rule := range s.ChainRules {
+			if !validJSON(rule.ChainConfig) || !validJSON(rule.TargetConfig) {
+				add("订阅 %s 的链式代理规则 %s 不是有效的 JSON", s.Name, rule.Name)
+			}
+		}
+	}
+	for _, s := range e.shares.final(doc) {
+		if !subscriptions[s.Subscription] {
+			add("分享 %s 引用的订阅不存在: %s", maskToken(s.Token), s.Subscription)
+		}
+		if len(s.Token) > 64 {
+			add("分享 %s 的 token 长度不能超过 64 个字符", maskToken(s.Token))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("配置校验失败: " + strings.Join(problems, "；"))
+	}
+	return nil
+}
+
+// syncTemplateFiles 写入或删除模板文件，返回失败项的告警
+func (st *state) syncTemplateFiles() []string {
+	var warnings []string
+	if len(st.templateWrites) > 0 {
+		if err := os.MkdirAll(st.templateDir, 0755); err != nil {
+			return []string{"创建模板目录失败: " + err.Error()}
+		}
+	}
+	for name, content := range st.templateWrites {
+		if err := writeFileAtomic(filepath.Join(st.templateDir, name), []byte(content)); err != nil {
+			warnings = append(warnings, fmt.Sprintf("写入模板 %s 失败: %v", name, err))
+		}
+	}
+	for _, name := range st.templateRemoves {
+		if err := os.Remove(filepath.Join(st.templateDir, name)); err != nil && !os.IsNotExist(err) {
+			warnings = append(warnings, fmt.Sprintf("删除模板文件 %s 失败: %v", name, err))
+		}
+	}
+	return warnings
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免正在渲染的订阅读到半个模板
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gitops-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
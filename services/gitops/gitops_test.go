@@ -0,0 +1,215 @@
+package gitops
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sublink/internal/testutil"
+	"sublink/models"
+
+	"gorm.io/gorm"
+)
+
+func openGitOpsTestDB(t *testing.T, prefix string) *gorm.DB {
+	t.Helper()
+	db := testutil.OpenMemoryDB(t, prefix)
+	if err := db.AutoMigrate(models.BackupModels()...); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+	t.Cleanup(func() { testutil.CloseDB(t, db) })
+	return db
+}
+
+// seedSource 写入一套覆盖各类实体与引用关系的配置
+func seedSource(t *testing.T, db *gorm.DB, templateDir string) {
+	t.Helper()
+	expireAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	profile := models.NodeCheckProfile{Name: "daily", CronExpr: "0 3 * * *", Mode: "tcp", Timeout: 5}
+	airport := models.Airport{Name: "hk", URL: "https://example.com/sub", CronExpr: "0 */6 * * *", Enabled: true, Group: "airport"}
+	script := models.Script{Name: "rename", Content: "function filterNode(nodes) { return nodes }"}
+	items := []any{
+		&profile,
+		&models.Tag{Name: "fast", GroupName: "speed", Color: "#ff0000"},
+		&models.CountryRule{CountryCode: "HK", CountryName: "香港", Pattern: "HK|香港", Enabled: true},
+		&script,
+		&models.Template{Name: "clash.yaml", Category: "clash"},
+		&models.Webhook{Name: "notify", URL: "https://hooks.example.com", Method: "POST", ContentType: "application/json"},
+	}
+	for _, item := range items {
+		if err := db.Create(item).Error; err != nil {
+			t.Fatalf("seed %T: %v", item, err)
+		}
+	}
+	airport.UpdateAfterDetectProfileID = profile.ID
+	if err := db.Create(&airport).Error; err != nil {
+		t.Fatalf("seed airport: %v", err)
+	}
+	db.Create(&models.TagRule{TagName: "fast", Name: "speed > 10", TriggerType: "speed_test", Conditions: `{"logic":"and","conditions":[]}`})
+	db.Create(&models.GroupAirportSort{GroupName: "airport", AirportID: airport.ID, Sort: 0})
+
+	sub := models.Subcription{Name: "main", Config: `{"clash":"./template/clash.yaml"}`, DelayTime: 300}
+	if err := db.Create(&sub).Error; err != nil {
+		t.Fatalf("seed subscription: %v", err)
+	}
+	db.Create(&models.SubcriptionGroup{SubcriptionID: sub.ID, GroupName: "airport"})
+	db.Create(&models.SubcriptionAirport{SubcriptionID: sub.ID, AirportID: airport.ID})
+	db.Create(&models.SubcriptionScript{SubcriptionID: sub.ID, ScriptID: script.ID})
+	db.Create(&models.SubscriptionChainRule{SubscriptionID: sub.ID, Name: "relay", Enabled: true, ChainConfig: "[]", TargetConfig: `{"type":"all"}`})
+
+	share := models.SubscriptionShare{SubscriptionID: sub.ID, Token: "share-token-0001", Name: "family", ExpireAt: &expireAt}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("seed share: %v", err)
+	}
+	// 关闭分享，覆盖 default:true 的布尔字段
+	db.Model(&share).Update("enabled", false)
+
+	if err := os.WriteFile(filepath.Join(templateDir, "clash.yaml"), []byte("proxies: []\n"), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+}
+
+// roundTrip 把文档编码为 YAML 再解析，与实际通过文件传递的流程一致
+func roundTrip(t *testing.T, doc *Document) *Document {
+	t.Helper()
+	data, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	parsed, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, data)
+	}
+	return parsed
+}
+
+func TestExportApplyRoundTripIsIdempotent(t *testing.T) {
+	sourceDir, targetDir := t.TempDir(), t.TempDir()
+	source := openGitOpsTestDB(t, "gitops_source")
+	target := openGitOpsTestDB(t, "gitops_target")
+	seedSource(t, source, sourceDir)
+
+	exported, err := Export(source, sourceDir)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	doc := roundTrip(t, exported)
+	if len(doc.Subscriptions) != 1 || doc.Subscriptions[0].Airports[0] != "hk" || doc.Airports[0].UpdateAfterDetectProfile != "daily" {
+		t.Fatalf("references should be exported by name: %+v %+v", doc.Subscriptions, doc.Airports)
+	}
+
+	plan, err := Apply(target, doc, Options{TemplateDir: targetDir})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if plan.Create != 11 || plan.Update != 0 || plan.Delete != 0 || len(plan.Warnings) != 0 {
+		t.Fatalf("unexpected first plan: %+v", plan)
+	}
+
+	plan, err = Apply(target, doc, Options{TemplateDir: targetDir, Prune: true})
+	if err != nil {
+		t.Fatalf("apply again: %v", err)
+	}
+	if plan.HasChanges() || plan.Unchanged != 11 {
+		t.Fatalf("second apply should be a no-op, got %+v", plan)
+	}
+
+	var share models.SubscriptionShare
+	if err := target.Where("token = ?", "share-token-0001").First(&share).Error; err != nil || share.Enabled {
+		t.Fatalf("expected disabled share in target, got %+v (%v)", share, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(targetDir, "clash.yaml")); string(content) != "proxies: []\n" {
+		t.Fatalf("unexpected template content %q", content)
+	}
+
+	reExported, err := Export(target, targetDir)
+	if err != nil {
+		t.Fatalf("export target: %v", err)
+	}
+	reExported.ExportedAt = exported.ExportedAt
+	want, _ := Marshal(exported)
+	got, _ := Marshal(reExported)
+	if !bytes.Equal(want, got) {
+		t.Fatalf("target export differs from source export:\n%s\n---\n%s", want, got)
+	}
+}
+
+func TestApplyUpdatesAndPrunesOnlyDeclaredSections(t *testing.T) {
+	dir := t.TempDir()
+	db := openGitOpsTestDB(t, "gitops_prune")
+	seedSource(t, db, dir)
+
+	exported, err := Export(db, dir)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	doc := roundTrip(t, exported)
+	doc.Airports[0].CronExpr = "0 * * * *"
+	doc.Airports[0].Group = "relay"
+	doc.Webhooks = []WebhookSpec{}
+	doc.Tags = nil
+	doc.TagRules = nil
+
+	plan, err := BuildPlan(db, doc, Options{TemplateDir: dir})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if plan.Update != 1 || plan.Delete != 0 || plan.Changes[0].Kind != "airports" || strings.Join(plan.Changes[0].Fields, ",") != "group,cronExpr" {
+		t.Fatalf("unexpected plan without prune: %+v", plan)
+	}
+
+	db.Create(&models.Node{Name: "n1", Link: "ss://n1", SourceID: 1, Group: "airport", Tags: "fast"})
+	plan, err = Apply(db, doc, Options{TemplateDir: dir, Prune: true})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if plan.Update != 1 || plan.Delete != 1 || plan.Changes[1].Kind != "webhooks" {
+		t.Fatalf("unexpected plan with prune: %+v", plan)
+	}
+
+	var webhooks, tags int64
+	db.Model(&models.Webhook{}).Count(&webhooks)
+	db.Model(&models.Tag{}).Count(&tags)
+	if webhooks != 0 || tags != 1 {
+		t.Fatalf("expected webhooks pruned and omitted tags kept, got %d webhooks %d tags", webhooks, tags)
+	}
+	var node models.Node
+	db.First(&node)
+	if node.Group != "relay" {
+		t.Fatalf("expected airport nodes to follow the new group, got %q", node.Group)
+	}
+}
+
+func TestApplyRejectsInvalidDocuments(t *testing.T) {
+	dir := t.TempDir()
+	db := openGitOpsTestDB(t, "gitops_invalid")
+	seedSource(t, db, dir)
+
+	if _, err := Parse(strings.NewReader("apiVersion: sublinkpro/v1\nkind: Configuration\nairport: []\n")); err == nil {
+		t.Fatalf("expected unknown field to be rejected")
+	}
+	if _, err := Parse(strings.NewReader("apiVersion: sublinkpro/v9\nkind: Configuration\n")); err == nil {
+		t.Fatalf("expected unsupported version to be rejected")
+	}
+
+	// 删除仍被机场与订阅引用的策略和脚本
+	doc := &Document{APIVersion: APIVersion, Kind: Kind, NodeCheckProfiles: []NodeCheckProfileSpec{}, Scripts: []ScriptSpec{}}
+	_, err := Apply(db, doc, Options{TemplateDir: dir, Prune: true})
+	if err == nil || !strings.Contains(err.Error(), "daily") || !strings.Contains(err.Error(), "rename") {
+		t.Fatalf("expected dangling references to be rejected, got %v", err)
+	}
+
+	doc = &Document{APIVersion: APIVersion, Kind: Kind, Templates: []TemplateSpec{{Name: "../escape.yaml"}}}
+	if _, err := Apply(db, doc, Options{TemplateDir: dir}); err == nil {
+		t.Fatalf("expected template path traversal to be rejected")
+	}
+
+	var profiles int64
+	db.Model(&models.NodeCheckProfile{}).Count(&profiles)
+	if profiles != 1 {
+		t.Fatalf("rejected apply must not write, got %d profiles", profiles)
+	}
+}
@@ -0,0 +1,264 @@
+package gitops
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Action 计划中的变更类型
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Change 计划中的单项变更
+type Change struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Action Action   `json:"action"`
+	Fields []string `json:"fields,omitempty"` // 更新时发生变化的字段
+}
+
+// Plan 把配置文档应用到当前实例所需的变更
+type Plan struct {
+	Changes   []Change `json:"changes"`
+	Create    int      `json:"create"`
+	Update    int      `json:"update"`
+	Delete    int      `json:"delete"`
+	Unchanged int      `json:"unchanged"`
+	Prune     bool     `json:"prune"`
+	Applied   bool     `json:"applied"`
+	Warnings  []string `json:"warnings"`
+}
+
+// Options 计划与应用参数
+type Options struct {
+	// Prune 为 true 时删除文档中已出现的分区里未声明的实体
+	Prune bool
+	// TemplateDir 模板文件所在目录
+	TemplateDir string
+}
+
+// HasChanges 计划中是否存在需要执行的变更
+func (p *Plan) HasChanges() bool {
+	return len(p.Changes) > 0
+}
+
+// runner 单类实体的比较与应用
+type runner interface {
+	diff(st *state, doc *Document, prune bool) error
+	applyUpserts(st *state) error
+	applyDeletes(st *state) error
+	result() ([]Change, int)
+}
+
+// section 以唯一键比较文档与数据库中同一类实体
+type section[S any] struct {
+	kind   string
+	specs  func(*Document) []S
+	key    func(S) string
+	label  func(S) string
+	load   func(*state) ([]S, error)
+	save   func(st *state, old *S, spec S) error
+	remove func(st *state, old S) error
+
+	current   []S
+	upserts   []sectionUpsert[S]
+	deletes   []S
+	changes   []Change
+	unchanged int
+}
+
+type sectionUpsert[S any] struct {
+	old  *S
+	spec S
+}
+
+func (s *section[S]) displayName(spec S) string {
+	if s.label != nil {
+		return s.label(spec)
+	}
+	return s.key(spec)
+}
+
+func (s *section[S]) diff(st *state, doc *Document, prune bool) error {
+	current, err := s.load(st)
+	if err != nil {
+		return fmt.Errorf("读取%s失败: %w", s.kind, err)
+	}
+	s.current = current
+	byKey := make(map[string]int, len(current))
+	for i, spec := range current {
+		key := s.key(spec)
+		if _, ok := byKey[key]; ok {
+			return fmt.Errorf("当前实例中存在重复的 %s: %s，请先处理后再应用", s.kind, s.displayName(spec))
+		}
+		byKey[key] = i
+	}
+
+	desired := s.specs(doc)
+	if desired == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(desired))
+	for _, spec := range desired {
+		key := s.key(spec)
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%s 中存在名称为空的条目", s.kind)
+		}
+		if seen[key] {
+			return fmt.Errorf("%s 中存在重复的条目: %s", s.kind, s.displayName(spec))
+		}
+		seen[key] = true
+
+		index, ok := byKey[key]
+		if !ok {
+			s.upserts = append(s.upserts, sectionUpsert[S]{spec: spec})
+			s.changes = append(s.changes, Change{Kind: s.kind, Name: s.displayName(spec), Action: ActionCreate})
+			continue
+		}
+		old := current[index]
+		fields := changedFields(old, spec)
+		if len(fields) == 0 {
+			s.unchanged++
+			continue
+		}
+		s.upserts = append(s.upserts, sectionUpsert[S]{old: &old, spec: spec})
+		s.changes = append(s.changes, Change{Kind: s.kind, Name: s.displayName(spec), Action: ActionUpdate, Fields: fields})
+	}
+
+	if prune {
+		for _, spec := range current {
+			if !seen[s.key(spec)] {
+				s.deletes = append(s.deletes, spec)
+				s.changes = append(s.changes, Change{Kind: s.kind, Name: s.displayName(spec), Action: ActionDelete})
+			}
+		}
+	}
+	return nil
+}
+
+func (s *section[S]) applyUpserts(st *state) error {
+	for _, item := range s.upserts {
+		if err := s.save(st, item.old, item.spec); err != nil {
+			return fmt.Errorf("写入 %s %s 失败: %w", s.kind, s.displayName(item.spec), err)
+		}
+	}
+	return nil
+}
+
+func (s *section[S]) applyDeletes(st *state) error {
+	for _, spec := range s.deletes {
+		if err := s.remove(st, spec); err != nil {
+			return fmt.Errorf("删除 %s %s 失败: %w", s.kind, s.displayName(spec), err)
+		}
+	}
+	return nil
+}
+
+func (s *section[S]) result() ([]Change, int) {
+	return s.changes, s.unchanged
+}
+
+// final 返回应用后的实体：文档包含该分区时为文档内容，否则为当前实例中的内容（prune 只作用于已出现的分区）
+func (s *section[S]) final(doc *Document) []S {
+	if desired := s.specs(doc); desired != nil {
+		return desired
+	}
+	return s.current
+}
+
+// finalKeys 返回应用后该类实体的键集合，用于校验跨实体引用
+func (s *section[S]) finalKeys(doc *Document) map[string]bool {
+	keys := make(map[string]bool)
+	for _, spec := range s.final(doc) {
+		keys[s.key(spec)] = true
+	}
+	return keys
+}
+
+// changedFields 逐字段比较，返回发生变化的 YAML 字段名
+func changedFields(old, desired any) []string {
+	oldValue := reflect.ValueOf(old)
+	newValue := reflect.ValueOf(desired)
+	fieldType := oldValue.Type()
+	var fields []string
+	for i := 0; i < fieldType.NumField(); i++ {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(fieldType.Field(i).Tag.Get("yaml"), ",")
+		if name == "" {
+			name = fieldType.Field(i).Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// state 计划与应用过程共享的上下文
+type state struct {
+	tx          *gorm.DB
+	templateDir string
+
+	templateWrites  map[string]string
+	templateRemoves []string
+}
+
+// nameIDs 读取实体的 名称 -> ID 映射
+func (st *state) nameIDs(model any) (map[string]int, error) {
+	var rows []struct {
+		ID   int
+		Name string
+	}
+	if err := st.tx.Model(model).Select("id", "name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if _, ok := result[row.Name]; !ok {
+			result[row.Name] = row.ID
+		}
+	}
+	return result, nil
+}
+
+// idNames 读取实体的 ID -> 名称 映射
+func (st *state) idNames(model any) (map[int]string, error) {
+	var rows []struct {
+		ID   int
+		Name string
+	}
+	if err := st.tx.Model(model).Select("id", "name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int]string, len(rows))
+	for _, row := range rows {
+		result[row.ID] = row.Name
+	}
+	return result, nil
+}
+
+// WriteText 以可读文本输出计划
+func WriteText(w io.Writer, plan *Plan) {
+	symbols := map[Action]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}
+	for _, change := range plan.Changes {
+		line := fmt.Sprintf("%s %s/%s", symbols[change.Action], change.Kind, change.Name)
+		if len(change.Fields) > 0 {
+			line += " (" + strings.Join(change.Fields, ", ") + ")"
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+	_, _ = fmt.Fprintf(w, "创建 %d，更新 %d，删除 %d，未变化 %d\n", plan.Create, plan.Update, plan.Delete, plan.Unchanged)
+	for _, warning := range plan.Warnings {
+		_, _ = fmt.Fprintln(w, "警告: "+warning)
+	}
+}
@@ -0,0 +1,963 @@
+package gitops
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"sublink/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// createRow 插入新记录后按声明的值再保存一次
+// gorm 插入时会用 default 标签替换零值（例如 enabled: false），再次保存才能得到文档中声明的值。
+func createRow(tx *gorm.DB, row any, fill func()) error {
+	fill()
+	if err := tx.Omit(clause.Associations).Create(row).Error; err != nil {
+		return err
+	}
+	fill()
+	return tx.Omit(clause.Associations).Save(row).Error
+}
+
+// saveRow 按唯一键读取已有记录并整行更新；old 为空时插入新记录
+func saveRow[M any](tx *gorm.DB, exists bool, row *M, where string, key any, fill func()) error {
+	if !exists {
+		return createRow(tx, row, fill)
+	}
+	if err := tx.Where(where, key).First(row).Error; err != nil {
+		return err
+	}
+	fill()
+	return tx.Omit(clause.Associations).Save(row).Error
+}
+
+// emptyToNil 把空切片统一为 nil，使文档与数据库中的取值可以直接比较
+func emptyToNil[T any](items []T) []T {
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+// ---- 节点检测策略 ----
+
+func profileSpec(p models.NodeCheckProfile) NodeCheckProfileSpec {
+	return NodeCheckProfileSpec{
+		Name:                p.Name,
+		Enabled:             p.Enabled,
+		CronExpr:            p.CronExpr,
+		Mode:                p.Mode,
+		TestURL:             p.TestURL,
+		LatencyURL:          p.LatencyURL,
+		Timeout:             p.Timeout,
+		Groups:              p.Groups,
+		Tags:                p.Tags,
+		LatencyConcurrency:  p.LatencyConcurrency,
+		SpeedConcurrency:    p.SpeedConcurrency,
+		DetectCountry:       p.DetectCountry,
+		LandingIPURL:        p.LandingIPURL,
+		IncludeHandshake:    p.IncludeHandshake,
+		SpeedRecordMode:     p.SpeedRecordMode,
+		PeakSampleInterval:  p.PeakSampleInterval,
+		PreserveSpeedResult: p.PreserveSpeedResult,
+		TrafficByGroup:      p.TrafficByGroup,
+		TrafficBySource:     p.TrafficBySource,
+		TrafficByNode:       p.TrafficByNode,
+		DetectQuality:       p.DetectQuality,
+		QualityCheckURL:     p.QualityCheckURL,
+		DetectUnlock:        p.DetectUnlock,
+		UnlockProviders:     p.UnlockProviders,
+	}
+}
+
+func fillProfile(p *models.NodeCheckProfile, s NodeCheckProfileSpec) {
+	p.Name = s.Name
+	p.Enabled = s.Enabled
+	p.CronExpr = s.CronExpr
+	p.Mode = s.Mode
+	p.TestURL = s.TestURL
+	p.LatencyURL = s.LatencyURL
+	p.Timeout = s.Timeout
+	p.Groups = s.Groups
+	p.Tags = s.Tags
+	p.LatencyConcurrency = s.LatencyConcurrency
+	p.SpeedConcurrency = s.SpeedConcurrency
+	p.DetectCountry = s.DetectCountry
+	p.LandingIPURL = s.LandingIPURL
+	p.IncludeHandshake = s.IncludeHandshake
+	p.SpeedRecordMode = s.SpeedRecordMode
+	p.PeakSampleInterval = s.PeakSampleInterval
+	p.PreserveSpeedResult = s.PreserveSpeedResult
+	p.TrafficByGroup = s.TrafficByGroup
+	p.TrafficBySource = s.TrafficBySource
+	p.TrafficByNode = s.TrafficByNode
+	p.DetectQuality = s.DetectQuality
+	p.QualityCheckURL = s.QualityCheckURL
+	p.DetectUnlock = s.DetectUnlock
+	p.UnlockProviders = s.UnlockProviders
+}
+
+func profileSection() *section[NodeCheckProfileSpec] {
+	return &section[NodeCheckProfileSpec]{
+		kind:  "nodeCheckProfiles",
+		specs: func(d *Document) []NodeCheckProfileSpec { return d.NodeCheckProfiles },
+		key:   func(s NodeCheckProfileSpec) string { return s.Name },
+		load: func(st *state) ([]NodeCheckProfileSpec, error) {
+			var rows []models.NodeCheckProfile
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]NodeCheckProfileSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, profileSpec(row))
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *NodeCheckProfileSpec, spec NodeCheckProfileSpec) error {
+			var row models.NodeCheckProfile
+			return saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() { fillProfile(&row, spec) })
+		},
+		remove: func(st *state, old NodeCheckProfileSpec) error {
+			return st.tx.Where("name = ?", old.Name).Delete(&models.NodeCheckProfile{}).Error
+		},
+	}
+}
+
+// ---- 机场 ----
+
+func airportSpec(a models.Airport, profileNames map[int]string) AirportSpec {
+	return AirportSpec{
+		Name:                         a.Name,
+		URL:                          a.URL,
+		Group:                        a.Group,
+		CronExpr:                     a.CronExpr,
+		Enabled:                      a.Enabled,
+		DownloadWithProxy:            a.DownloadWithProxy,
+		ProxyLink:                    a.ProxyLink,
+		UserAgent:                    a.UserAgent,
+		RequestHeaders:               emptyToNil(a.RequestHeaders),
+		FetchUsageInfo:               a.FetchUsageInfo,
+		SkipTLSVerify:                a.SkipTLSVerify,
+		UpdateAfterDetect:            a.UpdateAfterDetect,
+		UpdateAfterDetectProfile:     profileNames[a.UpdateAfterDetectProfileID],
+		UpdateAfterDetectChangedOnly: a.UpdateAfterDetectChangedOnly,
+		Remark:                       a.Remark,
+		Logo:                         a.Logo,
+		NodeNameWhitelist:            a.NodeNameWhitelist,
+		NodeNameBlacklist:            a.NodeNameBlacklist,
+		ProtocolWhitelist:            a.ProtocolWhitelist,
+		ProtocolBlacklist:            a.ProtocolBlacklist,
+		NodeNamePreprocess:           a.NodeNamePreprocess,
+		DeduplicationRule:            a.DeduplicationRule,
+		NodeNameUniquify:             a.NodeNameUniquify,
+		NodeNamePrefix:               a.NodeNamePrefix,
+		NodeNameIntraUniquify:        a.NodeNameIntraUniquify,
+		AutoFillCountry:              a.AutoFillCountry,
+		BackfillExistingCountry:      a.BackfillExistingCountry,
+	}
+}
+
+func fillAirport(a *models.Airport, s AirportSpec, profileIDs map[string]int) {
+	a.Name = s.Name
+	a.URL = s.URL
+	a.Group = s.Group
+	a.CronExpr = s.CronExpr
+	a.Enabled = s.Enabled
+	a.DownloadWithProxy = s.DownloadWithProxy
+	a.ProxyLink = s.ProxyLink
+	a.UserAgent = s.UserAgent
+	a.RequestHeaders = s.RequestHeaders
+	a.FetchUsageInfo = s.FetchUsageInfo
+	a.SkipTLSVerify = s.SkipTLSVerify
+	a.UpdateAfterDetect = s.UpdateAfterDetect
+	a.UpdateAfterDetectProfileID = profileIDs[s.UpdateAfterDetectProfile]
+	a.UpdateAfterDetectChangedOnly = s.UpdateAfterDetectChangedOnly
+	a.Remark = s.Remark
+	a.Logo = s.Logo
+	a.NodeNameWhitelist = s.NodeNameWhitelist
+	a.NodeNameBlacklist = s.NodeNameBlacklist
+	a.ProtocolWhitelist = s.ProtocolWhitelist
+	a.ProtocolBlacklist = s.ProtocolBlacklist
+	a.NodeNamePreprocess = s.NodeNamePreprocess
+	a.DeduplicationRule = s.DeduplicationRule
+	a.NodeNameUniquify = s.NodeNameUniquify
+	a.NodeNamePrefix = s.NodeNamePrefix
+	a.NodeNameIntraUniquify = s.NodeNameIntraUniquify
+	a.AutoFillCountry = s.AutoFillCountry
+	a.BackfillExistingCountry = s.BackfillExistingCountry
+}
+
+func airportSection() *section[AirportSpec] {
+	return &section[AirportSpec]{
+		kind:  "airports",
+		specs: func(d *Document) []AirportSpec { return d.Airports },
+		key:   func(s AirportSpec) string { return s.Name },
+		load: func(st *state) ([]AirportSpec, error) {
+			profileNames, err := st.idNames(&models.NodeCheckProfile{})
+			if err != nil {
+				return nil, err
+			}
+			var rows []models.Airport
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]AirportSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, airportSpec(row, profileNames))
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *AirportSpec, spec AirportSpec) error {
+			profileIDs, err := st.nameIDs(&models.NodeCheckProfile{})
+			if err != nil {
+				return err
+			}
+			var row models.Airport
+			if err := saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() { fillAirport(&row, spec, profileIDs) }); err != nil {
+				return err
+			}
+			// 与编辑机场一致：分组变化时同步已导入节点的分组
+			if old != nil && old.Group != spec.Group {
+				return st.tx.Model(&models.Node{}).Where("source_id = ?", row.ID).Update("group", spec.Group).Error
+			}
+			return nil
+		},
+		remove: func(st *state, old AirportSpec) error {
+			var row models.Airport
+			if err := st.tx.Where("name = ?", old.Name).First(&row).Error; err != nil {
+				return err
+			}
+			// 与删除机场一致：保留已导入的节点，清理订阅关联与分组排序
+			if err := st.tx.Where("airport_id = ?", row.ID).Delete(&models.SubcriptionAirport{}).Error; err != nil {
+				return err
+			}
+			if err := st.tx.Where("airport_id = ?", row.ID).Delete(&models.GroupAirportSort{}).Error; err != nil {
+				return err
+			}
+			return st.tx.Delete(&row).Error
+		},
+	}
+}
+
+// ---- 分组机场排序 ----
+
+func groupSection() *section[GroupSpec] {
+	return &section[GroupSpec]{
+		kind:  "groups",
+		specs: func(d *Document) []GroupSpec { return d.Groups },
+		key:   func(s GroupSpec) string { return s.Name },
+		load: func(st *state) ([]GroupSpec, error) {
+			airportNames, err := st.idNames(&models.Airport{})
+			if err != nil {
+				return nil, err
+			}
+			var rows []models.GroupAirportSort
+			if err := st.tx.Order("group_name").Order("sort").Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]GroupSpec, 0)
+			index := make(map[string]int)
+			for _, row := range rows {
+				name, ok := airportNames[row.AirportID]
+				if !ok {
+					continue
+				}
+				i, ok := index[row.GroupName]
+				if !ok {
+					i = len(specs)
+					index[row.GroupName] = i
+					specs = append(specs, GroupSpec{Name: row.GroupName})
+				}
+				specs[i].Airports = append(specs[i].Airports, name)
+			}
+			return specs, nil
+		},
+		save: func(st *state, _ *GroupSpec, spec GroupSpec) error {
+			airportIDs, err := st.nameIDs(&models.Airport{})
+			if err != nil {
+				return err
+			}
+			if err := st.tx.Where("group_name = ?", spec.Name).Delete(&models.GroupAirportSort{}).Error; err != nil {
+				return err
+			}
+			records := make([]models.GroupAirportSort, 0, len(spec.Airports))
+			for i, name := range spec.Airports {
+				records = append(records, models.GroupAirportSort{GroupName: spec.Name, AirportID: airportIDs[name], Sort: i})
+			}
+			return st.tx.Create(&records).Error
+		},
+		remove: func(st *state, old GroupSpec) error {
+			return st.tx.Where("group_name = ?", old.Name).Delete(&models.GroupAirportSort{}).Error
+		},
+	}
+}
+
+// ---- 标签与标签规则 ----
+
+func tagSection() *section[TagSpec] {
+	return &section[TagSpec]{
+		kind:  "tags",
+		specs: func(d *Document) []TagSpec { return d.Tags },
+		key:   func(s TagSpec) string { return s.Name },
+		load: func(st *state) ([]TagSpec, error) {
+			var rows []models.Tag
+			if err := st.tx.Order("name").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]TagSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, TagSpec{Name: row.Name, Group: row.GroupName, Color: row.Color, Description: row.Description})
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *TagSpec, spec TagSpec) error {
+			var row models.Tag
+			return saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() {
+				row.Name = spec.Name
+				row.GroupName = spec.Group
+				row.Color = spec.Color
+				row.Description = spec.Description
+			})
+		},
+		remove: func(st *state, old TagSpec) error {
+			if err := st.tx.Where("tag_name = ?", old.Name).Delete(&models.TagRule{}).Error; err != nil {
+				return err
+			}
+			if err := clearTagFromNodes(st.tx, old.Name); err != nil {
+				return err
+			}
+			return st.tx.Where("name = ?", old.Name).Delete(&models.Tag{}).Error
+		},
+	}
+}
+
+// clearTagFromNodes 与删除标签一致：从节点上移除该标签
+func clearTagFromNodes(tx *gorm.DB, tagName string) error {
+	var nodes []models.Node
+	if err := tx.Select("id", "tags").Where("tags LIKE ?", "%"+tagName+"%").Find(&nodes).Error; err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		tags := strings.Split(node.Tags, ",")
+		kept := slices.DeleteFunc(slices.Clone(tags), func(tag string) bool { return strings.TrimSpace(tag) == tagName })
+		if len(kept) == len(tags) {
+			continue
+		}
+		if err := tx.Model(&models.Node{}).Where("id = ?", node.ID).Update("tags", strings.Join(kept, ",")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tagRuleKey(s TagRuleSpec) string {
+	if strings.TrimSpace(s.Tag) == "" || strings.TrimSpace(s.Name) == "" {
+		return ""
+	}
+	return s.Tag + "/" + s.Name
+}
+
+func tagRuleSection() *section[TagRuleSpec] {
+	return &section[TagRuleSpec]{
+		kind:  "tagRules",
+		specs: func(d *Document) []TagRuleSpec { return d.TagRules },
+		key:   tagRuleKey,
+		label: func(s TagRuleSpec) string { return s.Tag + "/" + s.Name },
+		load: func(st *state) ([]TagRuleSpec, error) {
+			var rows []models.TagRule
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]TagRuleSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, TagRuleSpec{
+					Tag:         row.TagName,
+					Name:        row.Name,
+					Enabled:     row.Enabled,
+					TriggerType: row.TriggerType,
+					Conditions:  row.Conditions,
+				})
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *TagRuleSpec, spec TagRuleSpec) error {
+			var row models.TagRule
+			fill := func() {
+				row.TagName = spec.Tag
+				row.Name = spec.Name
+				row.Enabled = spec.Enabled
+				row.TriggerType = spec.TriggerType
+				row.Conditions = spec.Conditions
+			}
+			if old == nil {
+				return createRow(st.tx, &row, fill)
+			}
+			if err := st.tx.Where("tag_name = ? AND name = ?", spec.Tag, spec.Name).First(&row).Error; err != nil {
+				return err
+			}
+			fill()
+			return st.tx.Save(&row).Error
+		},
+		remove: func(st *state, old TagRuleSpec) error {
+			return st.tx.Where("tag_name = ? AND name = ?", old.Tag, old.Name).Delete(&models.TagRule{}).Error
+		},
+	}
+}
+
+// ---- 国家规则 ----
+
+func countryRuleSection() *section[CountryRuleSpec] {
+	return &section[CountryRuleSpec]{
+		kind:  "countryRules",
+		specs: func(d *Document) []CountryRuleSpec { return d.CountryRules },
+		key:   func(s CountryRuleSpec) string { return s.CountryCode },
+		load: func(st *state) ([]CountryRuleSpec, error) {
+			var rows []models.CountryRule
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]CountryRuleSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, CountryRuleSpec{
+					CountryCode: row.CountryCode,
+					CountryName: row.CountryName,
+					Pattern:     row.Pattern,
+					Enabled:     row.Enabled,
+					Priority:    row.Priority,
+				})
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *CountryRuleSpec, spec CountryRuleSpec) error {
+			var row models.CountryRule
+			return saveRow(st.tx, old != nil, &row, "country_code = ?", spec.CountryCode, func() {
+				row.CountryCode = spec.CountryCode
+				row.CountryName = spec.CountryName
+				row.Pattern = spec.Pattern
+				row.Enabled = spec.Enabled
+				row.Priority = spec.Priority
+			})
+		},
+		remove: func(st *state, old CountryRuleSpec) error {
+			return st.tx.Where("country_code = ?", old.CountryCode).Delete(&models.CountryRule{}).Error
+		},
+	}
+}
+
+// ---- 脚本 ----
+
+func scriptSection() *section[ScriptSpec] {
+	return &section[ScriptSpec]{
+		kind:  "scripts",
+		specs: func(d *Document) []ScriptSpec { return d.Scripts },
+		key:   func(s ScriptSpec) string { return s.Name },
+		load: func(st *state) ([]ScriptSpec, error) {
+			var rows []models.Script
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]ScriptSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, ScriptSpec{Name: row.Name, Version: row.Version, Content: row.Content})
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *ScriptSpec, spec ScriptSpec) error {
+			var row models.Script
+			return saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() {
+				row.Name = spec.Name
+				row.Version = spec.Version
+				row.Content = spec.Content
+			})
+		},
+		remove: func(st *state, old ScriptSpec) error {
+			var row models.Script
+			if err := st.tx.Where("name = ?", old.Name).First(&row).Error; err != nil {
+				return err
+			}
+			if err := st.tx.Where("script_id = ?", row.ID).Delete(&models.SubcriptionScript{}).Error; err != nil {
+				return err
+			}
+			return st.tx.Delete(&row).Error
+		},
+	}
+}
+
+// ---- 模板 ----
+
+// validTemplateName 模板名称只能是模板目录下的文件名
+func validTemplateName(name string) bool {
+	return name != "." && name != ".." && !strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
+
+func templateSection() *section[TemplateSpec] {
+	return &section[TemplateSpec]{
+		kind:  "templates",
+		specs: func(d *Document) []TemplateSpec { return d.Templates },
+		key:   func(s TemplateSpec) string { return s.Name },
+		load: func(st *state) ([]TemplateSpec, error) {
+			var rows []models.Template
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]TemplateSpec, 0, len(rows))
+			for _, row := range rows {
+				spec := TemplateSpec{
+					Name:             row.Name,
+					Category:         row.Category,
+					RuleSource:       row.RuleSource,
+					UseProxy:         row.UseProxy,
+					ProxyLink:        row.ProxyLink,
+					EnableIncludeAll: row.EnableIncludeAll,
+				}
+				if validTemplateName(row.Name) {
+					if content, err := os.ReadFile(filepath.Join(st.templateDir, row.Name)); err == nil {
+						spec.Content = string(content)
+					}
+				}
+				specs = append(specs, spec)
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *TemplateSpec, spec TemplateSpec) error {
+			var row models.Template
+			err := saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() {
+				row.Name = spec.Name
+				row.Category = spec.Category
+				row.RuleSource = spec.RuleSource
+				row.UseProxy = spec.UseProxy
+				row.ProxyLink = spec.ProxyLink
+				row.EnableIncludeAll = spec.EnableIncludeAll
+			})
+			if err != nil {
+				return err
+			}
+			if old == nil || old.Content != spec.Content {
+				st.templateWrites[spec.Name] = spec.Content
+			}
+			return nil
+		},
+		remove: func(st *state, old TemplateSpec) error {
+			if err := st.tx.Where("name = ?", old.Name).Delete(&models.Template{}).Error; err != nil {
+				return err
+			}
+			st.templateRemoves = append(st.templateRemoves, old.Name)
+			return nil
+		},
+	}
+}
+
+// ---- Webhook ----
+
+func webhookSection() *section[WebhookSpec] {
+	return &section[WebhookSpec]{
+		kind:  "webhooks",
+		specs: func(d *Document) []WebhookSpec { return d.Webhooks },
+		key:   func(s WebhookSpec) string { return s.Name },
+		load: func(st *state) ([]WebhookSpec, error) {
+			var rows []models.Webhook
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]WebhookSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, WebhookSpec{
+					Name:        row.Name,
+					URL:         row.URL,
+					Method:      row.Method,
+					ContentType: row.ContentType,
+					Headers:     row.Headers,
+					Body:        row.Body,
+					Enabled:     row.Enabled,
+					EventKeys:   row.EventKeys,
+				})
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *WebhookSpec, spec WebhookSpec) error {
+			var row models.Webhook
+			return saveRow(st.tx, old != nil, &row, "name = ?", spec.Name, func() {
+				row.Name = spec.Name
+				row.URL = spec.URL
+				row.Method = spec.Method
+				row.ContentType = spec.ContentType
+				row.Headers = spec.Headers
+				row.Body = spec.Body
+				row.Enabled = spec.Enabled
+				row.EventKeys = spec.EventKeys
+			})
+		},
+		remove: func(st *state, old WebhookSpec) error {
+			return st.tx.Where("name = ?", old.Name).Delete(&models.Webhook{}).Error
+		},
+	}
+}
+
+// ---- 订阅 ----
+
+func subscriptionSpec(sub models.Subcription) SubscriptionSpec {
+	return SubscriptionSpec{
+		Name:                  sub.Name,
+		Config:                sub.Config,
+		IPWhitelist:           sub.IPWhitelist,
+		IPBlacklist:           sub.IPBlacklist,
+		DelayTime:             sub.DelayTime,
+		MinSpeed:              sub.MinSpeed,
+		CountryWhitelist:      sub.CountryWhitelist,
+		CountryBlacklist:      sub.CountryBlacklist,
+		NodeNameRule:          sub.NodeNameRule,
+		NodeNamePreprocess:    sub.NodeNamePreprocess,
+		NodeNameWhitelist:     sub.NodeNameWhitelist,
+		NodeNameBlacklist:     sub.NodeNameBlacklist,
+		TagWhitelist:          sub.TagWhitelist,
+		TagBlacklist:          sub.TagBlacklist,
+		ProtocolWhitelist:     sub.ProtocolWhitelist,
+		ProtocolBlacklist:     sub.ProtocolBlacklist,
+		DeduplicationRule:     sub.DeduplicationRule,
+		RefreshUsageOnRequest: sub.RefreshUsageOnRequest,
+		UpdateInterval:        sub.UpdateInterval,
+		MaxFraudScore:         sub.MaxFraudScore,
+		OnlyResidential:       sub.OnlyResidential,
+		OnlyNative:            sub.OnlyNative,
+		ResidentialType:       sub.ResidentialType,
+		IPType:                sub.IPType,
+		QualityStatus:         sub.QualityStatus,
+		UnlockProvider:        sub.UnlockProvider,
+		UnlockStatus:          sub.UnlockStatus,
+		UnlockKeyword:         sub.UnlockKeyword,
+		UnlockRules:           sub.UnlockRules,
+		UnlockRuleMode:        sub.UnlockRuleMode,
+	}
+}
+
+func fillSubscription(sub *models.Subcription, s SubscriptionSpec) {
+	sub.Name = s.Name
+	sub.Config = s.Config
+	sub.IPWhitelist = s.IPWhitelist
+	sub.IPBlacklist = s.IPBlacklist
+	sub.DelayTime = s.DelayTime
+	sub.MinSpeed = s.MinSpeed
+	sub.CountryWhitelist = s.CountryWhitelist
+	sub.CountryBlacklist = s.CountryBlacklist
+	sub.NodeNameRule = s.NodeNameRule
+	sub.NodeNamePreprocess = s.NodeNamePreprocess
+	sub.NodeNameWhitelist = s.NodeNameWhitelist
+	sub.NodeNameBlacklist = s.NodeNameBlacklist
+	sub.TagWhitelist = s.TagWhitelist
+	sub.TagBlacklist = s.TagBlacklist
+	sub.ProtocolWhitelist = s.ProtocolWhitelist
+	sub.ProtocolBlacklist = s.ProtocolBlacklist
+	sub.DeduplicationRule = s.DeduplicationRule
+	sub.RefreshUsageOnRequest = s.RefreshUsageOnRequest
+	sub.UpdateInterval = s.UpdateInterval
+	sub.MaxFraudScore = s.MaxFraudScore
+	sub.OnlyResidential = s.OnlyResidential
+	sub.OnlyNative = s.OnlyNative
+	sub.ResidentialType = s.ResidentialType
+	sub.IPType = s.IPType
+	sub.QualityStatus = s.QualityStatus
+	sub.UnlockProvider = s.UnlockProvider
+	sub.UnlockStatus = s.UnlockStatus
+	sub.UnlockKeyword = s.UnlockKeyword
+	sub.UnlockRules = s.UnlockRules
+	sub.UnlockRuleMode = s.UnlockRuleMode
+}
+
+// loadSubscriptions 读取订阅及其关联的分组、机场、脚本与链式代理规则
+func loadSubscriptions(st *state) ([]SubscriptionSpec, error) {
+	airportNames, err := st.idNames(&models.Airport{})
+	if err != nil {
+		return nil, err
+	}
+	scriptNames, err := st.idNames(&models.Script{})
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Subcription
+	if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	var groups []models.SubcriptionGroup
+	if err := st.tx.Order("subcription_id").Order("sort").Order("group_name").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	var airports []models.SubcriptionAirport
+	if err := st.tx.Order("subcription_id").Order("sort").Order("airport_id").Find(&airports).Error; err != nil {
+		return nil, err
+	}
+	var scripts []models.SubcriptionScript
+	if err := st.tx.Order("subcription_id").Order("sort").Order("script_id").Find(&scripts).Error; err != nil {
+		return nil, err
+	}
+	var chainRules []models.SubscriptionChainRule
+	if err := st.tx.Order("subscription_id").Order("sort").Order("id").Find(&chainRules).Error; err != nil {
+		return nil, err
+	}
+
+	specs := make([]SubscriptionSpec, 0, len(rows))
+	index := make(map[int]int, len(rows))
+	for _, row := range rows {
+		index[row.ID] = len(specs)
+		specs = append(specs, subscriptionSpec(row))
+	}
+	for _, group := range groups {
+		if i, ok := index[group.SubcriptionID]; ok {
+			specs[i].Groups = append(specs[i].Groups, group.GroupName)
+		}
+	}
+	for _, airport := range airports {
+		name, known := airportNames[airport.AirportID]
+		if i, ok := index[airport.SubcriptionID]; ok && known {
+			specs[i].Airports = append(specs[i].Airports, name)
+		}
+	}
+	for _, script := range scripts {
+		name, known := scriptNames[script.ScriptID]
+		if i, ok := index[script.SubcriptionID]; ok && known {
+			specs[i].Scripts = append(specs[i].Scripts, name)
+		}
+	}
+	for _, rule := range chainRules {
+		if i, ok := index[rule.SubscriptionID]; ok {
+			specs[i].ChainRules = append(specs[i].ChainRules, ChainRuleSpec{
+				Name:         rule.Name,
+				Enabled:      rule.Enabled,
+				ChainConfig:  rule.ChainConfig,
+				TargetConfig: rule.TargetConfig,
+			})
+		}
+	}
+	return specs, nil
+}
+
+func saveSubscription(st *state, old *SubscriptionSpec, spec SubscriptionSpec) error {
+	var row models.Subcription
+	fill := func() { fillSubscription(&row, spec) }
+	if old == nil {
+		row.CreateDate = time.Now().Format("2006-01-02 15:04:05")
+		if err := createRow(st.tx, &row, fill); err != nil {
+			return err
+		}
+	} else if err := saveRow(st.tx, true, &row, "name = ?", spec.Name, fill); err != nil {
+		return err
+	}
+
+	if old == nil || !slices.Equal(old.Groups, spec.Groups) {
+		if err := st.tx.Where("subcription_id = ?", row.ID).Delete(&models.SubcriptionGroup{}).Error; err != nil {
+			return err
+		}
+		for i, group := range spec.Groups {
+			if err := st.tx.Create(&models.SubcriptionGroup{SubcriptionID: row.ID, GroupName: group, Sort: i}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if old == nil || !slices.Equal(old.Airports, spec.Airports) {
+		airportIDs, err := st.nameIDs(&models.Airport{})
+		if err != nil {
+			return err
+		}
+		if err := st.tx.Where("subcription_id = ?", row.ID).Delete(&models.SubcriptionAirport{}).Error; err != nil {
+			return err
+		}
+		for i, name := range spec.Airports {
+			if err := st.tx.Create(&models.SubcriptionAirport{SubcriptionID: row.ID, AirportID: airportIDs[name], Sort: i}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if old == nil || !slices.Equal(old.Scripts, spec.Scripts) {
+		scriptIDs, err := st.nameIDs(&models.Script{})
+		if err != nil {
+			return err
+		}
+		if err := st.tx.Where("subcription_id = ?", row.ID).Delete(&models.SubcriptionScript{}).Error; err != nil {
+			return err
+		}
+		for i, name := range spec.Scripts {
+			if err := st.tx.Create(&models.SubcriptionScript{SubcriptionID: row.ID, ScriptID: scriptIDs[name], Sort: i}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if old == nil || !slices.Equal(old.ChainRules, spec.ChainRules) {
+		if err := st.tx.Where("subscription_id = ?", row.ID).Delete(&models.SubscriptionChainRule{}).Error; err != nil {
+			return err
+		}
+		for i, rule := range spec.ChainRules {
+			chainRule := models.SubscriptionChainRule{SubscriptionID: row.ID}
+			err := createRow(st.tx, &chainRule, func() {
+				chainRule.Name = rule.Name
+				chainRule.Sort = i
+				chainRule.Enabled = rule.Enabled
+				chainRule.ChainConfig = rule.ChainConfig
+				chainRule.TargetConfig = rule.TargetConfig
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeSubscription 与删除订阅一致：清理关联数据后硬删除
+func removeSubscription(st *state, old SubscriptionSpec) error {
+	var row models.Subcription
+	if err := st.tx.Where("name = ?", old.Name).First(&row).Error; err != nil {
+		return err
+	}
+	var shares []models.SubscriptionShare
+	if err := st.tx.Where("subscription_id = ?", row.ID).Find(&shares).Error; err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if err := removeShareRow(st.tx, share); err != nil {
+			return err
+		}
+	}
+	for _, model := range []any{&models.SubLogs{}, &models.SubcriptionNode{}, &models.SubcriptionGroup{}, &models.SubcriptionAirport{}, &models.SubcriptionScript{}} {
+		if err := st.tx.Where("subcription_id = ?", row.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := st.tx.Where("subscription_id = ?", row.ID).Delete(&models.SubscriptionChainRule{}).Error; err != nil {
+		return err
+	}
+	return st.tx.Unscoped().Delete(&row).Error
+}
+
+func subscriptionSection() *section[SubscriptionSpec] {
+	return &section[SubscriptionSpec]{
+		kind:   "subscriptions",
+		specs:  func(d *Document) []SubscriptionSpec { return d.Subscriptions },
+		key:    func(s SubscriptionSpec) string { return s.Name },
+		load:   loadSubscriptions,
+		save:   saveSubscription,
+		remove: removeSubscription,
+	}
+}
+
+// ---- 订阅分享 ----
+
+func shareSpec(s models.SubscriptionShare, subscriptionNames map[int]string) ShareSpec {
+	return ShareSpec{
+		Token:             s.Token,
+		Subscription:      subscriptionNames[s.SubscriptionID],
+		Name:              s.Name,
+		Enabled:           s.Enabled,
+		ExpireType:        s.ExpireType,
+		ExpireDays:        s.ExpireDays,
+		ExpireAt:          normalizeTime(s.ExpireAt),
+		LimitWindow:       s.LimitWindow,
+		MaxRequests:       s.MaxRequests,
+		MaxIPs:            s.MaxIPs,
+		SignedOnly:        s.SignedOnly,
+		MaxDevices:        s.MaxDevices,
+		CountryWhitelist:  s.CountryWhitelist,
+		CountryBlacklist:  s.CountryBlacklist,
+		TagWhitelist:      s.TagWhitelist,
+		TagBlacklist:      s.TagBlacklist,
+		ProtocolWhitelist: s.ProtocolWhitelist,
+		ProtocolBlacklist: s.ProtocolBlacklist,
+		MaxNodes:          s.MaxNodes,
+		ForceClient:       s.ForceClient,
+		ClashTemplate:     s.ClashTemplate,
+		SurgeTemplate:     s.SurgeTemplate,
+		FallbackMessage:   s.FallbackMessage,
+		QuotaBytes:        s.QuotaBytes,
+		QuotaExpireAt:     normalizeTime(s.QuotaExpireAt),
+		UsageRatio:        s.UsageRatio,
+	}
+}
+
+func fillShare(s *models.SubscriptionShare, spec ShareSpec, subscriptionIDs map[string]int) {
+	s.Token = spec.Token
+	s.SubscriptionID = subscriptionIDs[spec.Subscription]
+	s.Name = spec.Name
+	s.Enabled = spec.Enabled
+	s.ExpireType = spec.ExpireType
+	s.ExpireDays = spec.ExpireDays
+	s.ExpireAt = spec.ExpireAt
+	s.LimitWindow = spec.LimitWindow
+	s.MaxRequests = spec.MaxRequests
+	s.MaxIPs = spec.MaxIPs
+	s.SignedOnly = spec.SignedOnly
+	s.MaxDevices = spec.MaxDevices
+	s.CountryWhitelist = spec.CountryWhitelist
+	s.CountryBlacklist = spec.CountryBlacklist
+	s.TagWhitelist = spec.TagWhitelist
+	s.TagBlacklist = spec.TagBlacklist
+	s.ProtocolWhitelist = spec.ProtocolWhitelist
+	s.ProtocolBlacklist = spec.ProtocolBlacklist
+	s.MaxNodes = spec.MaxNodes
+	s.ForceClient = spec.ForceClient
+	s.ClashTemplate = spec.ClashTemplate
+	s.SurgeTemplate = spec.SurgeTemplate
+	s.FallbackMessage = spec.FallbackMessage
+	s.QuotaBytes = spec.QuotaBytes
+	s.QuotaExpireAt = spec.QuotaExpireAt
+	s.UsageRatio = spec.UsageRatio
+}
+
+// removeShareRow 与删除分享一致：同时清理访问统计与绑定设备
+func removeShareRow(tx *gorm.DB, share models.SubscriptionShare) error {
+	for _, model := range []any{&models.ShareAccessWindow{}, &models.ShareDevice{}, &models.ShareAccessEvent{}, &models.ShareAccessDaily{}} {
+		if err := tx.Where("share_id = ?", share.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&share).Error
+}
+
+// maskToken 计划中只显示分享 token 的前几位，避免在日志中泄露完整链接
+func maskToken(token string) string {
+	if len(token) <= 6 {
+		return "***"
+	}
+	return token[:6] + "***"
+}
+
+func shareSection() *section[ShareSpec] {
+	return &section[ShareSpec]{
+		kind:  "shares",
+		specs: func(d *Document) []ShareSpec { return d.Shares },
+		key:   func(s ShareSpec) string { return s.Token },
+		label: func(s ShareSpec) string { return maskToken(s.Token) },
+		load: func(st *state) ([]ShareSpec, error) {
+			subscriptionNames, err := st.idNames(&models.Subcription{})
+			if err != nil {
+				return nil, err
+			}
+			var rows []models.SubscriptionShare
+			if err := st.tx.Order("id").Find(&rows).Error; err != nil {
+				return nil, err
+			}
+			specs := make([]ShareSpec, 0, len(rows))
+			for _, row := range rows {
+				specs = append(specs, shareSpec(row, subscriptionNames))
+			}
+			return specs, nil
+		},
+		save: func(st *state, old *ShareSpec, spec ShareSpec) error {
+			subscriptionIDs, err := st.nameIDs(&models.Subcription{})
+			if err != nil {
+				return err
+			}
+			var row models.SubscriptionShare
+			return saveRow(st.tx, old != nil, &row, "token = ?", spec.Token, func() { fillShare(&row, spec, subscriptionIDs) })
+		},
+		remove: func(st *state, old ShareSpec) error {
+			var row models.SubscriptionShare
+			if err := st.tx.Where("token = ?", old.Token).First(&row).Error; err != nil {
+				return err
+			}
+			return removeShareRow(st.tx, row)
+		},
+	}
+}
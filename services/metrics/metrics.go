@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"sublink/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 所有指标的统一前缀
+const namespace = "sublink"
+
+// 通知渠道
+const (
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+)
+
+// 脚本类型
+const (
+	ScriptKindSubscription = "subscription" // 订阅输出脚本
+	ScriptKindNodeFilter   = "node_filter"  // 节点过滤脚本
+)
+
+// registry 独立的指标注册表，避免第三方库注册的全局指标混入导出结果
+var registry = prometheus.NewRegistry()
+
+var (
+	subscriptionRenders = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subscription_renders_total",
+		Help:      "订阅输出次数，按客户端类型与响应模式统计",
+	}, []string{"client", "mode"})
+	subscriptionRenderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "subscription_render_duration_seconds",
+		Help:      "订阅输出耗时，按客户端类型统计",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"client"})
+
+	airportPulls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "airport_pulls_total",
+		Help:      "机场订阅拉取次数，按机场 ID 与结果统计",
+	}, []string{"airport_id", "result"})
+	airportPullDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "airport_pull_duration_seconds",
+		Help:      "机场订阅拉取耗时",
+		Buckets:   []float64{.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"airport_id", "result"})
+	airportNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "airport_nodes",
+		Help:      "机场最近一次成功拉取后的节点数",
+	}, []string{"airport_id"})
+
+	speedTestTasks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "speedtest_tasks_total",
+		Help:      "已完成的测速任务数",
+	})
+	speedTestTaskDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "speedtest_task_duration_seconds",
+		Help:      "测速任务耗时",
+		Buckets:   []float64{5, 15, 30, 60, 120, 300, 600, 1800},
+	})
+	speedTestNodes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "speedtest_nodes_total",
+		Help:      "已测速的节点数，按结果统计",
+	}, []string{"result"})
+	speedTestTraffic = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "speedtest_traffic_bytes_total",
+		Help:      "测速消耗的流量",
+	})
+	nodeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_latency_milliseconds",
+		Help:      "节点最近一次测速的延迟，失败时为 -1",
+	}, []string{"node_id", "node", "group"})
+
+	scriptErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "script_errors_total",
+		Help:      "脚本执行失败次数，按脚本类型统计",
+	}, []string{"kind"})
+
+	notificationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notification_delivery_failures_total",
+		Help:      "通知推送失败次数，按渠道统计",
+	}, []string{"channel"})
+)
+
+// cacheCollector 采集时读取缓存管理器中各模块的条目数
+type cacheCollector struct {
+	desc *prometheus.Desc
+}
+
+func (c cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, size := range cache.Manager.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(size), name)
+	}
+}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		cacheCollector{desc: prometheus.NewDesc(namespace+"_cache_entries", "缓存模块中的条目数", []string{"cache"}, nil)},
+		subscriptionRenders, subscriptionRenderDuration,
+		airportPulls, airportPullDuration, airportNodes,
+		speedTestTasks, speedTestTaskDuration, speedTestNodes, speedTestTraffic, nodeLatency,
+		scriptErrors,
+		notificationFailures,
+	)
+}
+
+// Handler 返回 Prometheus 文本格式的指标导出处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveSubscriptionRender 记录一次订阅输出
+func ObserveSubscriptionRender(client, mode string, duration time.Duration) {
+	subscriptionRenders.WithLabelValues(client, mode).Inc()
+	subscriptionRenderDuration.WithLabelValues(client).Observe(duration.Seconds())
+}
+
+// ObserveAirportPull 记录一次机场拉取，成功时同时更新节点数
+// 按机场 ID 统计，机场改名不会产生新的时间序列。
+func ObserveAirportPull(airportID int, success bool, duration time.Duration, nodes int) {
+	id := strconv.Itoa(airportID)
+	result := resultLabel(success)
+	airportPulls.WithLabelValues(id, result).Inc()
+	airportPullDuration.WithLabelValues(id, result).Observe(duration.Seconds())
+	if success {
+		airportNodes.WithLabelValues(id).Set(float64(nodes))
+	}
+}
+
+// DeleteAirport 删除机场的全部指标，在机场删除后调用
+func DeleteAirport(airportID int) {
+	labels := prometheus.Labels{"airport_id": strconv.Itoa(airportID)}
+	airportPulls.DeletePartialMatch(labels)
+	airportPullDuration.DeletePartialMatch(labels)
+	airportNodes.DeletePartialMatch(labels)
+}
+
+// ObserveSpeedTestTask 记录一次完成的测速任务
+func ObserveSpeedTestTask(success, fail int, trafficBytes int64, duration time.Duration) {
+	speedTestTasks.Inc()
+	speedTestTaskDuration.Observe(duration.Seconds())
+	speedTestNodes.WithLabelValues(resultLabel(true)).Add(float64(success))
+	speedTestNodes.WithLabelValues(resultLabel(false)).Add(float64(fail))
+	speedTestTraffic.Add(float64(trafficBytes))
+}
+
+// SetNodeLatency 更新节点最近一次的延迟
+// 先清除该节点的旧序列，节点改名或调整分组后不会残留旧标签。
+func SetNodeLatency(nodeID int, name, group string, latency int) {
+	id := strconv.Itoa(nodeID)
+	nodeLatency.DeletePartialMatch(prometheus.Labels{"node_id": id})
+	nodeLatency.WithLabelValues(id, name, group).Set(float64(latency))
+}
+
+// DeleteNodeLatency 删除节点的延迟指标，在节点删除后调用
+func DeleteNodeLatency(nodeIDs ...int) {
+	for _, nodeID := range nodeIDs {
+		nodeLatency.DeletePartialMatch(prometheus.Labels{"node_id": strconv.Itoa(nodeID)})
+	}
+}
+
+// IncScriptError 记录一次脚本执行失败
+func IncScriptError(kind string) {
+	scriptErrors.WithLabelValues(kind).Inc()
+}
+
+// IncNotificationFailure 记录一次通知推送失败
+func IncNotificationFailure(channel string) {
+	notificationFailures.WithLabelValues(channel).Inc()
+}
+
+func resultLabel(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sublink/cache"
+)
+
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", recorder.Code)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func TestHandlerExportsRecordedMetrics(t *testing.T) {
+	entries := cache.NewMapCache(func(v string) string { return v })
+	entries.Set("a", "a")
+	entries.Set("b", "b")
+	cache.Manager.Register("metrics_test", entries)
+
+	ObserveSubscriptionRender("clash", "normal", 30*time.Millisecond)
+	ObserveAirportPull(1, true, 2*time.Second, 12)
+	ObserveAirportPull(1, false, time.Second, 0)
+	ObserveSpeedTestTask(3, 1, 2048, time.Minute)
+	SetNodeLatency(7, "node-7", "HK", 120)
+	IncScriptError(ScriptKindNodeFilter)
+	IncNotificationFailure(ChannelWebhook)
+
+	body := scrapeMetrics(t)
+	for _, want := range []string{
+		`sublink_subscription_renders_total{client="clash",mode="normal"} 1`,
+		`sublink_subscription_render_duration_seconds_count{client="clash"} 1`,
+		`sublink_airport_pulls_total{airport_id="1",result="success"} 1`,
+		`sublink_airport_pulls_total{airport_id="1",result="failure"} 1`,
+		`sublink_airport_nodes{airport_id="1"} 12`,
+		`sublink_speedtest_tasks_total 1`,
+		`sublink_speedtest_nodes_total{result="success"} 3`,
+		`sublink_speedtest_nodes_total{result="failure"} 1`,
+		`sublink_speedtest_traffic_bytes_total 2048`,
+		`sublink_node_latency_milliseconds{group="HK",node="node-7",node_id="7"} 120`,
+		`sublink_script_errors_total{kind="node_filter"} 1`,
+		`sublink_notification_delivery_failures_total{channel="webhook"} 1`,
+		`sublink_cache_entries{cache="metrics_test"} 2`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics output to contain %q", want)
+		}
+	}
+}
+
+func TestFailedAirportPullKeepsLastNodeCount(t *testing.T) {
+	ObserveAirportPull(2, true, time.Second, 5)
+	ObserveAirportPull(2, false, time.Second, 0)
+
+	if body := scrapeMetrics(t); !strings.Contains(body, `sublink_airport_nodes{airport_id="2"} 5`) {
+		t.Fatal("expected failed pull to keep the last node count")
+	}
+}
+
+func TestDeletedNodesAndAirportsDropTheirSeries(t *testing.T) {
+	SetNodeLatency(21, "node-21", "HK", 80)
+	SetNodeLatency(21, "node-21-renamed", "JP", 90)
+	SetNodeLatency(22, "node-22", "US", 150)
+	ObserveAirportPull(3, true, time.Second, 4)
+
+	body := scrapeMetrics(t)
+	if strings.Contains(body, `node="node-21",node_id="21"`) || !strings.Contains(body, `sublink_node_latency_milliseconds{group="JP",node="node-21-renamed",node_id="21"} 90`) {
+		t.Fatal("expected renamed node to replace its previous latency series")
+	}
+
+	DeleteNodeLatency(21, 22)
+	DeleteAirport(3)
+	body = scrapeMetrics(t)
+	for _, stale := range []string{`node_id="21"`, `node_id="22"`, `airport_id="3"`} {
+		if strings.Contains(body, stale) {
+			t.Errorf("expected %s series to be removed", stale)
+		}
+	}
+}
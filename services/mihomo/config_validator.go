@@ -0,0 +1,297 @@
+package mihomo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/metacubex/mihomo/adapter"
+	"github.com/metacubex/mihomo/adapter/outboundgroup"
+	"github.com/metacubex/mihomo/common/structure"
+	R "github.com/metacubex/mihomo/rules"
+	RC "github.com/metacubex/mihomo/rules/common"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置校验问题分类
+const (
+	ConfigIssueSyntax    = "syntax"    // YAML 或字段类型错误
+	ConfigIssueProxy     = "proxy"     // 节点无法被 mihomo 解析
+	ConfigIssueDuplicate = "duplicate" // 节点或代理组重名
+	ConfigIssueReference = "reference" // 代理组引用了不存在的节点、代理组或 provider
+	ConfigIssueRule      = "rule"      // 规则格式错误或目标不存在
+	ConfigIssueField     = "field"     // mihomo 不支持的字段
+)
+
+// ConfigIssue 配置校验发现的单个问题
+type ConfigIssue struct {
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// ConfigValidationResult Clash 配置校验结果
+// Errors 会导致客户端加载失败；Warnings 为 mihomo 会忽略的字段等提示。
+type ConfigValidationResult struct {
+	Valid      bool          `json:"valid"`
+	Errors     []ConfigIssue `json:"errors"`
+	Warnings   []ConfigIssue `json:"warnings"`
+	ProxyCount int           `json:"proxyCount"`
+	GroupCount int           `json:"groupCount"`
+	RuleCount  int           `json:"ruleCount"`
+}
+
+// Error 汇总错误信息，便于日志输出
+func (r ConfigValidationResult) Error() string {
+	messages := make([]string, 0, len(r.Errors))
+	for _, issue := range r.Errors {
+		messages = append(messages, issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (r *ConfigValidationResult) addError(category, format string, args ...any) {
+	r.Errors = append(r.Errors, ConfigIssue{Category: category, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ConfigValidationResult) addWarning(category, format string, args ...any) {
+	r.Warnings = append(r.Warnings, ConfigIssue{Category: category, Message: fmt.Sprintf(format, args...)})
+}
+
+// builtinProxyNames mihomo 内置的出站名称
+var builtinProxyNames = []string{"DIRECT", "REJECT", "REJECT-DROP", "PASS", "PASS-RULE", "COMPATIBLE", "GLOBAL"}
+
+// supportedGroupTypes mihomo 支持的代理组类型
+var supportedGroupTypes = []string{"select", "url-test", "fallback", "load-balance", "relay"}
+
+// geoRuleTypes 依赖 GeoIP/GeoSite 数据库的规则，只校验格式，不加载数据库
+var geoRuleTypes = []string{"GEOIP", "SRC-GEOIP", "GEOSITE", "IP-ASN", "SRC-IP-ASN"}
+
+// topLevelFields mihomo 配置支持的顶层字段，与 mihomo v1.19.29 config.RawConfig 的 yaml 标签一致，升级 mihomo 时需同步
+// 这里不直接引用 mihomo/config 包：它会链接 TUN、监听器等运行时依赖，且其 init 会替换 dns.ParseNameServer。
+var topLevelFields = []string{
+	"port", "socks-port", "redir-port", "tproxy-port", "mixed-port", "ss-config", "vmess-config",
+	"inbound-tfo", "inbound-mptcp", "authentication", "skip-auth-prefixes", "lan-allowed-ips",
+	"lan-disallowed-ips", "allow-lan", "bind-address", "mode", "unified-delay", "log-level", "ipv6",
+	"external-controller", "external-controller-routing-mark", "external-controller-pipe",
+	"external-controller-unix", "external-controller-tls", "external-controller-cors", "external-ui",
+	"external-ui-url", "external-ui-name", "external-doh-server", "secret", "interface-name",
+	"routing-mark", "tunnels", "geo-auto-update", "geo-update-interval", "geodata-mode",
+	"geodata-loader", "geosite-matcher", "tcp-concurrent", "find-process-mode",
+	"global-client-fingerprint", "global-ua", "etag-support", "keep-alive-idle", "keep-alive-interval",
+	"disable-keep-alive", "proxy-providers", "rule-providers", "proxies", "proxy-groups", "rules",
+	"sub-rules", "listeners", "hosts", "dns", "ntp", "tun", "tuic-server", "iptables", "experimental",
+	"profile", "geox-url", "sniffer", "tls", "clash-for-android",
+}
+
+// rawClashConfig 校验所需的配置结构，字段与 mihomo config.RawConfig 保持一致
+type rawClashConfig struct {
+	ProxyProvider map[string]map[string]any `yaml:"proxy-providers"`
+	RuleProvider  map[string]map[string]any `yaml:"rule-providers"`
+	Proxy         []map[string]any          `yaml:"proxies"`
+	ProxyGroup    []map[string]any          `yaml:"proxy-groups"`
+	Rule          []string                  `yaml:"rules"`
+	SubRules      map[string][]string       `yaml:"sub-rules"`
+}
+
+// groupFields 代理组支持的字段
+var groupFields = append(collectStructTags(reflect.TypeOf(outboundgroup.GroupCommonOption{}), "group"),
+	"tolerance", "strategy", "default-selected", "interface-name", "routing-mark")
+
+// collectStructTags 收集结构体字段的标签名
+func collectStructTags(t reflect.Type, tagName string) []string {
+	var tags []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if tag == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			tags = append(tags, collectStructTags(field.Type, tagName)...)
+			continue
+		}
+		if tag != "" && tag != "-" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ValidateClashConfig 使用 mihomo 的解析器校验最终输出的 Clash 配置
+// 与 mihomo 启动时不同，这里会尽量收集全部问题而不是遇到第一个错误就停止，
+// 且不会加载 GeoIP 数据库、下载 provider 或修改任何全局状态。
+func ValidateClashConfig(content []byte) ConfigValidationResult {
+	result := ConfigValidationResult{Errors: []ConfigIssue{}, Warnings: []ConfigIssue{}}
+
+	raw := &rawClashConfig{}
+	if err := yaml.Unmarshal(content, raw); err != nil {
+		result.addError(ConfigIssueSyntax, "配置解析失败: %v", err)
+		return result
+	}
+
+	var fields map[string]any
+	if err := yaml.Unmarshal(content, &fields); err == nil {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !slices.Contains(topLevelFields, key) {
+				result.addWarning(ConfigIssueField, "不支持的顶层字段: %s", key)
+			}
+		}
+	}
+
+	names := make(map[string]string, len(raw.Proxy)+len(raw.ProxyGroup))
+	for _, name := range builtinProxyNames {
+		names[name] = "builtin"
+	}
+
+	// 节点：交给 mihomo 适配器解析，确保客户端能够识别
+	for idx, mapping := range raw.Proxy {
+		name, _ := mapping["name"].(string)
+		if name == "" {
+			result.addError(ConfigIssueProxy, "第 %d 个节点缺少名称", idx+1)
+			continue
+		}
+		if _, exists := names[name]; exists {
+			result.addError(ConfigIssueDuplicate, "节点名称重复: %s", name)
+		} else {
+			names[name] = "proxy"
+		}
+		if _, err := adapter.ParseProxy(mapping); err != nil {
+			result.addError(ConfigIssueProxy, "节点 %s 无法解析: %v", name, err)
+		}
+	}
+	result.ProxyCount = len(raw.Proxy)
+
+	// 代理组：先登记名称，再校验引用，允许代理组之间前向引用
+	for idx, mapping := range raw.ProxyGroup {
+		name, _ := mapping["name"].(string)
+		if name == "" {
+			result.addError(ConfigIssueSyntax, "第 %d 个代理组缺少名称", idx+1)
+			continue
+		}
+		if kind, exists := names[name]; exists {
+			result.addError(ConfigIssueDuplicate, "代理组名称重复: %s（与%s同名）", name, describeNameKind(kind))
+			continue
+		}
+		names[name] = "group"
+	}
+	result.GroupCount = len(raw.ProxyGroup)
+
+	decoder := structure.NewDecoder(structure.Option{TagName: "group", WeaklyTypedInput: true})
+	for idx, mapping := range raw.ProxyGroup {
+		var option outboundgroup.GroupCommonOption
+		if err := decoder.Decode(mapping, &option); err != nil {
+			result.addError(ConfigIssueSyntax, "第 %d 个代理组格式错误: %v", idx+1, err)
+			continue
+		}
+		if option.Name == "" {
+			continue
+		}
+		if !slices.Contains(supportedGroupTypes, option.Type) {
+			result.addError(ConfigIssueSyntax, "代理组 %s 的类型不受支持: %s", option.Name, option.Type)
+		}
+		for _, ref := range option.Proxies {
+			if _, exists := names[ref]; !exists {
+				result.addError(ConfigIssueReference, "代理组 %s 引用了不存在的节点或代理组: %s", option.Name, ref)
+			}
+		}
+		for _, ref := range option.Use {
+			if _, exists := raw.ProxyProvider[ref]; !exists {
+				result.addError(ConfigIssueReference, "代理组 %s 引用了不存在的 proxy-provider: %s", option.Name, ref)
+			}
+		}
+		if len(option.Proxies) == 0 && len(option.Use) == 0 && !option.IncludeAll && !option.IncludeAllProxies && !option.IncludeAllProviders {
+			result.addError(ConfigIssueReference, "代理组 %s 没有任何节点", option.Name)
+		}
+		keys := make([]string, 0, len(mapping))
+		for key := range mapping {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !slices.Contains(groupFields, key) {
+				result.addWarning(ConfigIssueField, "代理组 %s 包含不支持的字段: %s", option.Name, key)
+			}
+		}
+	}
+
+	// 节点的 dialer-proxy 必须指向存在的节点或代理组
+	for _, mapping := range raw.Proxy {
+		name, _ := mapping["name"].(string)
+		if dialer, _ := mapping["dialer-proxy"].(string); dialer != "" {
+			if _, exists := names[dialer]; !exists {
+				result.addError(ConfigIssueReference, "节点 %s 的 dialer-proxy 指向不存在的节点或代理组: %s", name, dialer)
+			}
+		}
+	}
+
+	// 规则
+	for subName, subRules := range raw.SubRules {
+		validateRules(&result, "sub-rules."+subName, subRules, names, raw)
+	}
+	validateRules(&result, "rules", raw.Rule, names, raw)
+	result.RuleCount = len(raw.Rule)
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// validateRules 校验规则格式、目标与引用的 rule-provider
+func validateRules(result *ConfigValidationResult, scope string, rules []string, names map[string]string, raw *rawClashConfig) {
+	for idx, line := range rules {
+		tp, payload, target, params := RC.ParseRulePayload(line, true)
+		if target == "" {
+			result.addError(ConfigIssueRule, "%s[%d] 格式错误: %s", scope, idx, line)
+			continue
+		}
+		if tp == "SUB-RULE" {
+			if _, exists := raw.SubRules[target]; !exists {
+				result.addError(ConfigIssueRule, "%s[%d] 引用了不存在的子规则 %s: %s", scope, idx, target, line)
+			}
+		} else if _, exists := names[target]; !exists {
+			result.addError(ConfigIssueRule, "%s[%d] 的目标 %s 不存在: %s", scope, idx, target, line)
+		}
+		if tp == "RULE-SET" {
+			if _, exists := raw.RuleProvider[payload]; !exists {
+				result.addError(ConfigIssueRule, "%s[%d] 引用了不存在的 rule-provider %s: %s", scope, idx, payload, line)
+			}
+			continue
+		}
+		if tp == "SUB-RULE" || ruleNeedsGeoData(tp, payload) {
+			continue
+		}
+		if _, err := R.ParseRule(tp, payload, target, params, nil); err != nil {
+			result.addError(ConfigIssueRule, "%s[%d] 无效: %v", scope, idx, err)
+		}
+	}
+}
+
+// ruleNeedsGeoData 判断规则（含逻辑规则中的子规则）是否依赖地理数据库
+func ruleNeedsGeoData(tp, payload string) bool {
+	if slices.Contains(geoRuleTypes, tp) {
+		return true
+	}
+	if tp == "AND" || tp == "OR" || tp == "NOT" {
+		upper := strings.ToUpper(payload)
+		for _, geoType := range geoRuleTypes {
+			if strings.Contains(upper, geoType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeNameKind(kind string) string {
+	switch kind {
+	case "builtin":
+		return "内置出站"
+	case "proxy":
+		return "节点"
+	default:
+		return "代理组"
+	}
+}
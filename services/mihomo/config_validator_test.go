@@ -0,0 +1,87 @@
+package mihomo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateClashConfigAcceptsValidConfig(t *testing.T) {
+	content := `mixed-port: 7890
+proxies:
+  - {name: HK-01, type: ss, server: hk.example.com, port: 8388, cipher: aes-256-gcm, password: secret}
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: [Auto, HK-01, DIRECT]
+  - name: Auto
+    type: url-test
+    url: https://www.gstatic.com/generate_204
+    interval: 300
+    proxies: [HK-01]
+rules:
+  - DOMAIN-SUFFIX,example.com,Proxy
+  - GEOIP,CN,DIRECT
+  - MATCH,Proxy
+`
+	result := ValidateClashConfig([]byte(content))
+	if !result.Valid {
+		t.Fatalf("expected config to be valid, got errors: %+v", result.Errors)
+	}
+	if result.ProxyCount != 1 || result.GroupCount != 2 || result.RuleCount != 3 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+}
+
+func TestValidateClashConfigReportsAllIssues(t *testing.T) {
+	content := `proxies:
+  - {name: HK-01, type: ss, server: hk.example.com, port: 8388, cipher: aes-256-gcm, password: secret}
+  - {name: HK-01, type: ss, server: hk2.example.com, port: 8388, cipher: aes-256-gcm, password: secret}
+proxy-groups:
+  - name: Proxy
+    type: select
+    proxies: [HK-01, Missing]
+    colour: red
+rules:
+  - DOMAIN-SUFFIX,example.com,Nowhere
+  - RULE-SET,ads,REJECT
+  - MATCH,Proxy
+unknown-option: true
+`
+	result := ValidateClashConfig([]byte(content))
+	if result.Valid {
+		t.Fatal("expected config to be invalid")
+	}
+
+	wantErrors := map[string]string{
+		ConfigIssueDuplicate: "HK-01",
+		ConfigIssueReference: "Missing",
+		ConfigIssueRule:      "Nowhere",
+	}
+	for category, keyword := range wantErrors {
+		if !hasConfigIssue(result.Errors, category, keyword) {
+			t.Fatalf("expected %s error mentioning %q, got %+v", category, keyword, result.Errors)
+		}
+	}
+	if !hasConfigIssue(result.Errors, ConfigIssueRule, "ads") {
+		t.Fatalf("expected missing rule-provider error, got %+v", result.Errors)
+	}
+	if !hasConfigIssue(result.Warnings, ConfigIssueField, "unknown-option") || !hasConfigIssue(result.Warnings, ConfigIssueField, "colour") {
+		t.Fatalf("expected unsupported field warnings, got %+v", result.Warnings)
+	}
+}
+
+func TestValidateClashConfigRejectsInvalidYAML(t *testing.T) {
+	result := ValidateClashConfig([]byte("proxies: [\n"))
+	if result.Valid || !hasConfigIssue(result.Errors, ConfigIssueSyntax, "") {
+		t.Fatalf("expected syntax error, got %+v", result)
+	}
+}
+
+func hasConfigIssue(issues []ConfigIssue, category, keyword string) bool {
+	for _, issue := range issues {
+		if issue.Category == category && strings.Contains(issue.Message, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -140,6 +140,7 @@ func buildRuntimeConfigSummary() RuntimeConfigSummary {
 	cfg := config.Get()
 	captchaCfg := config.GetCaptchaConfig()
 	features := config.GetEnabledFeatures()
+	logFormat := emptyFallback(cfg.LogFormat, "text")
 
 	safeToShow := []RuntimeConfigItem{
 		{Label: "端口", Key: "port", Value: formatIntValue(cfg.Port, ""), ValueKey: intValueKey(cfg.Port, "plain"), ValueParams: intValueParams(cfg.Port), Env: "SUBLINK_PORT"},
@@ -148,6 +149,7 @@ func buildRuntimeConfigSummary() RuntimeConfigSummary {
 		{Label: "登录失败统计窗口", Key: "login_fail_window", Value: formatIntValue(cfg.LoginFailWindow, "分钟"), ValueKey: intValueKey(cfg.LoginFailWindow, "minutes"), ValueParams: intValueParams(cfg.LoginFailWindow), Env: "SUBLINK_LOGIN_FAIL_WINDOW"},
 		{Label: "登录封禁时长", Key: "login_ban_duration", Value: formatIntValue(cfg.LoginBanDuration, "分钟"), ValueKey: intValueKey(cfg.LoginBanDuration, "minutes"), ValueParams: intValueParams(cfg.LoginBanDuration), Env: "SUBLINK_LOGIN_BAN_DURATION"},
 		{Label: "日志级别", Key: "log_level", Value: emptyFallback(strings.ToUpper(cfg.LogLevel), "未设置"), ValueKey: nonEmptyValueKey(cfg.LogLevel, "literal", "unset"), ValueParams: literalValueParams(strings.ToUpper(cfg.LogLevel)), Env: "SUBLINK_LOG_LEVEL"},
+		{Label: "日志格式", Key: "log_format", Value: strings.ToUpper(logFormat), ValueKey: valueKey("literal"), ValueParams: literalValueParams(strings.ToUpper(logFormat)), Env: "SUBLINK_LOG_FORMAT"},
 		{Label: "验证码模式", Key: "captcha_mode", Value: formatCaptchaMode(captchaCfg), ValueKey: captchaModeValueKey(captchaCfg), Env: "SUBLINK_CAPTCHA_MODE"},
 		{Label: "可信代理规则", Key: "trusted_proxies", Value: formatTrustedProxies(cfg.TrustedProxies), ValueKey: trustedProxiesValueKey(cfg.TrustedProxies), ValueParams: countValueParams(len(cfg.TrustedProxies)), Env: "SUBLINK_TRUSTED_PROXIES"},
 		{Label: "功能开关", Key: "feature", Value: formatEnabledFeatures(features), ValueKey: enabledFeaturesValueKey(features), ValueParams: listValueParams(features), Env: "SUBLINK_FEATURE"},
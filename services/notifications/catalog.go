@@ -94,6 +94,16 @@ var eventCatalog = []EventDefinition{
 		Channels:       []Channel{ChannelWebhook, ChannelTelegram, ChannelInApp},
 		DefaultEnabled: true,
 	},
+	{
+		Key:            "security.share_abuse_disabled",
+		Name:           "分享被自动停用",
+		Description:    "订阅分享超出访问频率或 IP 数量限制并被自动停用时触发。",
+		Category:       "security",
+		CategoryName:   "安全审计",
+		Severity:       "warning",
+		Channels:       []Channel{ChannelWebhook, ChannelTelegram, ChannelInApp},
+		DefaultEnabled: true,
+	},
 }
 
 func EventCatalog() []EventDefinition {
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sublink/services/metrics"
 	"sublink/services/sse"
 	"sublink/utils"
 	"sync"
@@ -81,6 +82,7 @@ func TriggerWebhook(eventKey string, payload Payload) {
 					name = fmt.Sprintf("#%d", cfg.ID)
 				}
 				utils.Warn("发送 Webhook[%s] 通知失败: %v", name, err)
+				metrics.IncNotificationFailure(metrics.ChannelWebhook)
 			}
 		}()
 	}
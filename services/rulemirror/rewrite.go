@@ -0,0 +1,195 @@
+package rulemirror
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ProviderRef 配置中引用的一个远程规则
+type ProviderRef struct {
+	URL      string
+	Interval int
+	Behavior string // Clash provider 的 behavior，Surge 规则集为空
+}
+
+// URLResolver 返回远程规则对应的镜像地址，未镜像时返回 false
+type URLResolver func(rawURL string) (string, bool)
+
+// ExtractReferences 按模板类别提取远程规则引用
+func ExtractReferences(content, category string) []ProviderRef {
+	if category == "surge" {
+		return ExtractSurgeRuleSets(content)
+	}
+	return ExtractClashProviders(content)
+}
+
+// ExtractClashProviders 提取 Clash 配置 rule-providers 中的远程规则地址
+// 按行扫描而不是解析 YAML，模板中的变量与条件区块不会影响提取。
+func ExtractClashProviders(content string) []ProviderRef {
+	var refs []ProviderRef
+	index := make(map[string]int)
+	current := -1
+	behavior := ""
+	walkClashProviderFields(content, func(_ int, field, value string, newProvider bool) {
+		if newProvider {
+			current = -1
+			behavior = ""
+		}
+		switch field {
+		case "url":
+			if !isRemoteURL(value) {
+				return
+			}
+			if i, ok := index[value]; ok {
+				current = i
+				return
+			}
+			index[value] = len(refs)
+			current = len(refs)
+			refs = append(refs, ProviderRef{URL: value, Behavior: behavior})
+		case "behavior":
+			// behavior 可能写在 url 之前或之后
+			behavior = strings.ToLower(value)
+			if current >= 0 && refs[current].Behavior == "" {
+				refs[current].Behavior = behavior
+			}
+		case "interval":
+			if current >= 0 {
+				if interval, err := strconv.Atoi(value); err == nil && refs[current].Interval == 0 {
+					refs[current].Interval = interval
+				}
+			}
+		}
+	})
+	return refs
+}
+
+// RewriteClashProviders 将 rule-providers 中已镜像的地址替换为本地地址
+func RewriteClashProviders(content string, resolve URLResolver) string {
+	lines := strings.Split(content, "\n")
+	changed := false
+	walkClashProviderFields(content, func(lineIndex int, field, value string, _ bool) {
+		if field != "url" {
+			return
+		}
+		mirrored, ok := resolve(value)
+		if !ok {
+			return
+		}
+		line := lines[lineIndex]
+		colon := strings.Index(line, ":")
+		rest := strings.TrimSpace(line[colon+1:])
+		quote := ""
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			quote = rest[:1]
+		}
+		lines[lineIndex] = line[:colon+1] + " " + quote + mirrored + quote
+		changed = true
+	})
+	if !changed {
+		return content
+	}
+	return strings.Join(lines, "\n")
+}
+
+// walkClashProviderFields 遍历顶层 rule-providers 下每个 provider 的字段
+// newProvider 为真表示该字段所在行同时开始了一个新的 provider。
+func walkClashProviderFields(content string, visit func(lineIndex int, field, value string, newProvider bool)) {
+	inProviders := false
+	providerIndent := -1
+	for i, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimRight(rawLine, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			inProviders = strings.HasPrefix(trimmed, "rule-providers:")
+			providerIndent = -1
+			continue
+		}
+		if !inProviders {
+			continue
+		}
+		if providerIndent < 0 || indent <= providerIndent {
+			// provider 名称行，字段在下一行开始
+			providerIndent = indent
+			visit(i, "", "", true)
+			continue
+		}
+		field, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if hash := strings.Index(value, " #"); hash >= 0 {
+			value = strings.TrimSpace(value[:hash])
+		}
+		visit(i, strings.TrimSpace(field), strings.Trim(value, `"'`), false)
+	}
+}
+
+// ExtractSurgeRuleSets 提取 Surge 配置中 RULE-SET 规则引用的远程地址
+func ExtractSurgeRuleSets(content string) []ProviderRef {
+	var refs []ProviderRef
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		parts, ok := splitSurgeRuleSet(line)
+		if !ok || seen[parts[1]] {
+			continue
+		}
+		seen[parts[1]] = true
+		ref := ProviderRef{URL: parts[1]}
+		for _, option := range parts[2:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(option), "update-interval="); found {
+				ref.Interval, _ = strconv.Atoi(value)
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// RewriteSurgeRuleSets 将 RULE-SET 规则中已镜像的地址替换为本地地址
+func RewriteSurgeRuleSets(content string, resolve URLResolver) string {
+	lines := strings.Split(content, "\n")
+	changed := false
+	for i, line := range lines {
+		parts, ok := splitSurgeRuleSet(line)
+		if !ok {
+			continue
+		}
+		mirrored, ok := resolve(parts[1])
+		if !ok {
+			continue
+		}
+		lines[i] = strings.Replace(line, parts[1], mirrored, 1)
+		changed = true
+	}
+	if !changed {
+		return content
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitSurgeRuleSet 拆分引用远程地址的 RULE-SET 规则
+func splitSurgeRuleSet(line string) ([]string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(strings.ToUpper(trimmed), "RULE-SET,") {
+		return nil, false
+	}
+	parts := strings.Split(trimmed, ",")
+	if len(parts) < 3 {
+		return nil, false
+	}
+	parts[1] = strings.TrimSpace(parts[1])
+	if !isRemoteURL(parts[1]) {
+		return nil, false
+	}
+	return parts, true
+}
+
+func isRemoteURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
@@ -0,0 +1,74 @@
+package rulemirror
+
+import (
+	"strings"
+	"testing"
+)
+
+const testClashProviders = `proxies: []
+rule-providers:
+  Reject:
+    type: http
+    behavior: domain
+    url: "https://example.com/reject.yaml"
+    interval: 3600
+  Local:
+    type: file
+    path: ./local.yaml
+  Proxy:
+    url: https://example.com/proxy.list # 注释
+rules:
+  - RULE-SET,Reject,REJECT
+proxy-groups:
+  - name: auto
+    url: http://www.gstatic.com/generate_204
+`
+
+func TestExtractClashProvidersOnlyReadsRuleProviders(t *testing.T) {
+	refs := ExtractClashProviders(testClashProviders)
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 provider refs, got %+v", refs)
+	}
+	if refs[0].URL != "https://example.com/reject.yaml" || refs[0].Interval != 3600 || refs[0].Behavior != "domain" {
+		t.Fatalf("unexpected first ref: %+v", refs[0])
+	}
+	if refs[1].URL != "https://example.com/proxy.list" || refs[1].Interval != 0 {
+		t.Fatalf("unexpected second ref: %+v", refs[1])
+	}
+}
+
+func TestRewriteClashProvidersKeepsQuotesAndOtherURLs(t *testing.T) {
+	rewritten := RewriteClashProviders(testClashProviders, func(rawURL string) (string, bool) {
+		if rawURL == "https://example.com/reject.yaml" {
+			return "https://sub.example/c/rules/a.yaml?token=t", true
+		}
+		return "", false
+	})
+	if !strings.Contains(rewritten, `    url: "https://sub.example/c/rules/a.yaml?token=t"`) {
+		t.Fatalf("expected quoted mirror url, got:\n%s", rewritten)
+	}
+	if !strings.Contains(rewritten, "url: https://example.com/proxy.list # 注释") {
+		t.Fatalf("expected unmirrored provider line untouched, got:\n%s", rewritten)
+	}
+	if !strings.Contains(rewritten, "url: http://www.gstatic.com/generate_204") {
+		t.Fatalf("expected proxy group url untouched, got:\n%s", rewritten)
+	}
+}
+
+func TestRewriteSurgeRuleSets(t *testing.T) {
+	content := "[Rule]\nRULE-SET,https://example.com/Ad.list,REJECT,update-interval=7200\nRULE-SET,SYSTEM,DIRECT\nFINAL,DIRECT\n"
+	refs := ExtractSurgeRuleSets(content)
+	if len(refs) != 1 || refs[0].URL != "https://example.com/Ad.list" || refs[0].Interval != 7200 {
+		t.Fatalf("unexpected surge refs: %+v", refs)
+	}
+
+	rewritten := RewriteSurgeRuleSets(content, func(rawURL string) (string, bool) {
+		return "https://sub.example/c/rules/b.list?token=t", true
+	})
+	if !strings.Contains(rewritten, "RULE-SET,https://sub.example/c/rules/b.list?token=t,REJECT,update-interval=7200") {
+		t.Fatalf("expected mirrored rule set, got:\n%s", rewritten)
+	}
+	if !strings.Contains(rewritten, "RULE-SET,SYSTEM,DIRECT") {
+		t.Fatalf("expected built-in rule set untouched, got:\n%s", rewritten)
+	}
+}
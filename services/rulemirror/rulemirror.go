@@ -0,0 +1,330 @@
+package rulemirror
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sublink/config"
+	"sublink/models"
+	"sublink/node/protocol"
+	"sublink/utils"
+	"sync"
+	"time"
+)
+
+// 系统设置键
+const (
+	SettingEnabled   = "rule_mirror_enabled"    // 是否在订阅输出中使用镜像地址
+	SettingUseProxy  = "rule_mirror_use_proxy"  // 是否使用代理下载（模板未配置代理时生效）
+	SettingProxyLink = "rule_mirror_proxy_link" // 代理节点链接
+)
+
+const (
+	fetchTimeout     = 30 * time.Second
+	fetchConcurrency = 4
+	// maxRuleFileSize 单个规则文件大小上限，避免异常响应占满磁盘
+	maxRuleFileSize = 32 << 20
+)
+
+// refreshMu 保证同一时间只有一轮同步在执行
+var refreshMu sync.Mutex
+
+// Settings 规则镜像设置
+type Settings struct {
+	Enabled   bool   `json:"enabled"`
+	UseProxy  bool   `json:"useProxy"`
+	ProxyLink string `json:"proxyLink"`
+}
+
+// LoadSettings 读取规则镜像设置
+func LoadSettings() Settings {
+	enabled, _ := models.GetSetting(SettingEnabled)
+	useProxy, _ := models.GetSetting(SettingUseProxy)
+	proxyLink, _ := models.GetSetting(SettingProxyLink)
+	return Settings{
+		Enabled:   enabled == "true",
+		UseProxy:  useProxy == "true",
+		ProxyLink: proxyLink,
+	}
+}
+
+// SaveSettings 保存规则镜像设置
+func SaveSettings(settings Settings) error {
+	values := map[string]string{
+		SettingEnabled:   fmt.Sprintf("%t", settings.Enabled),
+		SettingUseProxy:  fmt.Sprintf("%t", settings.UseProxy),
+		SettingProxyLink: strings.TrimSpace(settings.ProxyLink),
+	}
+	for key, value := range values {
+		if err := models.SetSetting(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Enabled 是否启用规则镜像
+func Enabled() bool {
+	enabled, _ := models.GetSetting(SettingEnabled)
+	return enabled == "true"
+}
+
+// Dir 规则镜像本地存储目录
+func Dir() string {
+	return filepath.Join(config.GetDBPath(), "rules")
+}
+
+// LocalPath 返回镜像文件的本地路径，文件名非法时返回错误
+func LocalPath(fileName string) (string, error) {
+	if fileName == "" || fileName != filepath.Base(fileName) || strings.HasPrefix(fileName, ".") {
+		return "", errors.New("非法的规则文件名")
+	}
+	return filepath.Join(Dir(), fileName), nil
+}
+
+// SingBoxFileName 镜像对应的 sing-box 源格式规则集文件名
+func SingBoxFileName(mirror models.RuleMirror) string {
+	return models.RuleMirrorStem(mirror.FileName) + ".sing-box.json"
+}
+
+// SRSFileName 镜像对应的 sing-box 二进制规则集文件名
+func SRSFileName(mirror models.RuleMirror) string {
+	return models.RuleMirrorStem(mirror.FileName) + ".srs"
+}
+
+// removeLocalFiles 删除镜像的本地副本及派生的 sing-box 规则集
+func removeLocalFiles(mirror models.RuleMirror) {
+	names := []string{mirror.FileName}
+	if mirror.SupportsSingBox() {
+		names = append(names, SingBoxFileName(mirror), SRSFileName(mirror))
+	}
+	for _, name := range names {
+		if path, err := LocalPath(name); err == nil {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// WriteFileAtomic 先写入临时文件再替换目标文件，避免客户端读到写了一半的规则
+func WriteFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// MirrorURL 生成镜像文件的访问地址，使用分享 token 鉴权
+func MirrorURL(baseURL, fileName, token string) string {
+	return strings.TrimRight(baseURL, "/") + "/c/rules/" + fileName + "?token=" + url.QueryEscape(token)
+}
+
+// Resolver 返回订阅输出使用的地址替换函数
+// 仅替换启用且已成功下载过的镜像，下载失败时继续使用上次成功的本地副本。
+func Resolver(baseURL, token string) URLResolver {
+	return func(rawURL string) (string, bool) {
+		mirror, ok := models.GetRuleMirrorByURL(rawURL)
+		if !ok || !mirror.IsFresh() {
+			return "", false
+		}
+		return MirrorURL(baseURL, mirror.FileName, token), true
+	}
+}
+
+// DiscoverReferences 扫描模板目录，汇总所有模板引用的远程规则
+// 引用规则的模板开启了代理下载时，镜像沿用该模板的代理节点。
+func DiscoverReferences(templateDir string) ([]models.RuleMirrorReference, error) {
+	entries, err := os.ReadDir(templateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	loader := protocol.TemplateFileLoader(templateDir)
+	refIndex := make(map[string]int)
+	var refs []models.RuleMirrorReference
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		content, err := loader(name)
+		if err != nil {
+			utils.Warn("规则镜像读取模板 %s 失败: %v", name, err)
+			continue
+		}
+		var meta models.Template
+		hasMeta := meta.FindByName(name) == nil
+		category := models.InferTemplateCategory(name)
+		if hasMeta && meta.Category != "" {
+			category = meta.Category
+		}
+
+		for _, provider := range ExtractReferences(content, category) {
+			i, ok := refIndex[provider.URL]
+			if !ok {
+				i = len(refs)
+				refIndex[provider.URL] = i
+				refs = append(refs, models.RuleMirrorReference{URL: provider.URL, Interval: provider.Interval})
+			}
+			ref := &refs[i]
+			if ref.Behavior == "" {
+				ref.Behavior = provider.Behavior
+			}
+			ref.Templates = append(ref.Templates, name)
+			if ref.Interval <= 0 || (provider.Interval > 0 && provider.Interval < ref.Interval) {
+				ref.Interval = provider.Interval
+			}
+			if hasMeta && meta.UseProxy && !ref.UseProxy {
+				ref.UseProxy = true
+				ref.ProxyLink = meta.ProxyLink
+			}
+		}
+	}
+	return refs, nil
+}
+
+// RefreshResult 一轮同步的结果
+type RefreshResult struct {
+	Total   int `json:"total"`
+	Fetched int `json:"fetched"`
+	Failed  int `json:"failed"`
+	Removed int `json:"removed"`
+}
+
+// Refresh 同步模板引用并下载需要刷新的规则
+// force 为真时忽略刷新间隔，下载全部启用的镜像。
+func Refresh(force bool) (RefreshResult, error) {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	var result RefreshResult
+	refs, err := DiscoverReferences(protocol.DefaultTemplateDir)
+	if err != nil {
+		return result, err
+	}
+	removed, err := models.SyncRuleMirrors(refs)
+	if err != nil {
+		return result, err
+	}
+	for _, mirror := range removed {
+		removeLocalFiles(mirror)
+	}
+	result.Removed = len(removed)
+
+	mirrors := models.ListRuleMirrors()
+	result.Total = len(mirrors)
+	now := time.Now()
+	var due []models.RuleMirror
+	for _, mirror := range mirrors {
+		if !mirror.Enabled {
+			continue
+		}
+		if force || mirror.IsDue(now) {
+			due = append(due, mirror)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+	for i := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(mirror models.RuleMirror) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := fetchMirror(&mirror)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed++
+				return
+			}
+			result.Fetched++
+		}(due[i])
+	}
+	wg.Wait()
+
+	if result.Fetched > 0 || result.Failed > 0 || result.Removed > 0 {
+		utils.Info("规则镜像同步完成: 共 %d 条, 下载 %d 条, 失败 %d 条, 移除 %d 条", result.Total, result.Fetched, result.Failed, result.Removed)
+	}
+	return result, nil
+}
+
+// RefreshOne 立即下载指定镜像
+func RefreshOne(id int) (models.RuleMirror, error) {
+	mirror, ok := models.GetRuleMirrorByID(id)
+	if !ok {
+		return models.RuleMirror{}, errors.New("规则镜像不存在")
+	}
+	err := fetchMirror(&mirror)
+	return mirror, err
+}
+
+// fetchMirror 下载规则文件并原子替换本地副本，失败时保留旧副本并记录原因
+func fetchMirror(mirror *models.RuleMirror) error {
+	useProxy, proxyLink := mirror.UseProxy, mirror.ProxyLink
+	if !useProxy {
+		settings := LoadSettings()
+		useProxy, proxyLink = settings.UseProxy, settings.ProxyLink
+	}
+
+	now := time.Now()
+	err := downloadToFile(mirror, useProxy, proxyLink)
+	if err != nil {
+		utils.Warn("规则镜像下载失败 %s: %v", mirror.URL, err)
+		if recordErr := mirror.RecordFetchFailure(err.Error(), now); recordErr != nil {
+			utils.Error("记录规则镜像状态失败: %v", recordErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func downloadToFile(mirror *models.RuleMirror, useProxy bool, proxyLink string) error {
+	data, err := utils.FetchWithProxy(mirror.URL, useProxy, proxyLink, fetchTimeout, "")
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return errors.New("规则文件内容为空")
+	}
+	if len(data) > maxRuleFileSize {
+		return fmt.Errorf("规则文件超过 %d MB", maxRuleFileSize>>20)
+	}
+
+	path, err := LocalPath(mirror.FileName)
+	if err != nil {
+		return err
+	}
+	if err := WriteFileAtomic(path, data); err != nil {
+		return err
+	}
+	return mirror.RecordFetchSuccess(int64(len(data)), time.Now())
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"sublink/models"
+	"sublink/utils"
+)
+
+// StartAuditCleanupTask 启动审计日志清理定时任务
+// 每天凌晨执行一次，按保留天数删除过期的审计日志
+func (sm *SchedulerManager) StartAuditCleanupTask() error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	const auditCleanupCron = "30 3 * * *" // 每天 03:30 执行
+
+	// 如果任务已存在，先删除
+	if entryID, exists := sm.jobs[JobIDAuditCleanup]; exists {
+		sm.cron.Remove(entryID)
+		delete(sm.jobs, JobIDAuditCleanup)
+	}
+
+	entryID, err := sm.cron.AddFunc(auditCleanupCron, func() {
+		ExecuteAuditCleanupTask()
+	})
+	if err != nil {
+		utils.Error("添加审计日志清理任务失败 - Cron: %s, Error: %v", auditCleanupCron, err)
+		return err
+	}
+
+	sm.jobs[JobIDAuditCleanup] = entryID
+	utils.Info("成功添加审计日志清理任务 - Cron: %s", auditCleanupCron)
+	return nil
+}
+
+// ExecuteAuditCleanupTask 执行审计日志清理任务
+func ExecuteAuditCleanupTask() {
+	deleted, err := models.CleanupExpiredAuditLogs()
+	if err != nil {
+		utils.Error("审计日志清理任务执行失败: %v", err)
+		return
+	}
+	if deleted > 0 {
+		utils.Info("审计日志清理完成，删除 %d 条记录", deleted)
+	}
+}
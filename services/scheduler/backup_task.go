@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+
+	"sublink/services/backup"
+	"sublink/utils"
+)
+
+// StartBackupTask 按自动备份配置启动定时备份任务
+// 未启用自动备份时只移除已有任务
+func (sm *SchedulerManager) StartBackupTask() error {
+	settings, err := backup.LoadSettings()
+	if err != nil {
+		utils.Warn("读取自动备份配置失败: %v", err)
+	}
+
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	// 如果任务已存在，先删除
+	if entryID, exists := sm.jobs[JobIDBackup]; exists {
+		sm.cron.Remove(entryID)
+		delete(sm.jobs, JobIDBackup)
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	entryID, err := sm.cron.AddFunc(settings.Cron, func() {
+		ExecuteBackupTask()
+	})
+	if err != nil {
+		utils.Error("添加自动备份任务失败 - Cron: %s, Error: %v", settings.Cron, err)
+		return err
+	}
+
+	sm.jobs[JobIDBackup] = entryID
+	utils.Info("成功添加自动备份任务 - Cron: %s", settings.Cron)
+	return nil
+}
+
+// ExecuteBackupTask 执行一次自动备份
+func ExecuteBackupTask() {
+	result, err := backup.RunBackup(context.Background())
+	if err != nil {
+		utils.Error("自动备份执行失败: %v", err)
+		return
+	}
+	for _, warning := range result.Warnings {
+		utils.Warn("自动备份告警: %s", warning)
+	}
+	utils.Info("自动备份完成: %s（%s），清理过期备份 %d 个", result.File.Name, utils.FormatBytes(result.File.Size), len(result.Pruned))
+}
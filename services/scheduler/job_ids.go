@@ -6,6 +6,18 @@ const (
 	// JobIDHostCleanup Host过期清理任务ID
 	JobIDHostCleanup = -101
 
+	// JobIDRuleMirror 规则镜像同步任务ID
+	JobIDRuleMirror = -102
+
+	// JobIDAuditCleanup 审计日志清理任务ID
+	JobIDAuditCleanup = -103
+
+	// JobIDShareAnalyticsCleanup 分享访问明细清理任务ID
+	JobIDShareAnalyticsCleanup = -104
+
+	// JobIDBackup 自动备份任务ID
+	JobIDBackup = -105
+
 	// 预留区间 -100 ~ -199 用于未来系统任务
 	// 新增系统任务时按顺序递减分配ID
 )
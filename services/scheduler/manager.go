@@ -104,9 +104,55 @@ func (sm *SchedulerManager) LoadFromDatabase() error {
 		utils.Error("创建Host过期清理任务失败: %v", err)
 	}
 
+	// 启动规则镜像同步任务
+	if err := sm.StartRuleMirrorTask(); err != nil {
+		utils.Error("创建规则镜像同步任务失败: %v", err)
+	}
+
+	// 启动审计日志清理任务
+	if err := sm.StartAuditCleanupTask(); err != nil {
+		utils.Error("创建审计日志清理任务失败: %v", err)
+	}
+
+	// 启动分享访问明细清理任务
+	if err := sm.StartShareAnalyticsCleanupTask(); err != nil {
+		utils.Error("创建分享访问明细清理任务失败: %v", err)
+	}
+
+	// 启动自动备份任务
+	if err := sm.StartBackupTask(); err != nil {
+		utils.Error("创建自动备份任务失败: %v", err)
+	}
+
 	return nil
 }
 
+// JobStats 已注册定时任务数量统计
+type JobStats struct {
+	Airport   int `json:"airport"`   // 机场订阅更新任务
+	NodeCheck int `json:"nodeCheck"` // 节点检测策略任务
+	System    int `json:"system"`    // 系统内置任务
+}
+
+// JobStats 统计当前内存中已注册的定时任务数量
+func (sm *SchedulerManager) JobStats() JobStats {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	var stats JobStats
+	for jobID := range sm.jobs {
+		switch {
+		case jobID < 0:
+			stats.System++
+		case jobID >= nodeCheckJobIDOffset:
+			stats.NodeCheck++
+		default:
+			stats.Airport++
+		}
+	}
+	return stats
+}
+
 // AddJob 添加定时任务
 func (sm *SchedulerManager) AddJob(schedulerID int, cronExpr string, jobFunc func(int, string, string), id int, url string, subName string) error {
 	sm.mutex.Lock()
@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"sublink/services/rulemirror"
+	"sublink/utils"
+)
+
+// StartRuleMirrorTask 启动规则镜像同步定时任务
+// 每小时检查一次，按各镜像的刷新间隔下载远程规则；未启用规则镜像时跳过
+func (sm *SchedulerManager) StartRuleMirrorTask() error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	const ruleMirrorCron = "0 * * * *" // 每小时执行一次
+
+	// 如果任务已存在，先删除
+	if entryID, exists := sm.jobs[JobIDRuleMirror]; exists {
+		sm.cron.Remove(entryID)
+		delete(sm.jobs, JobIDRuleMirror)
+	}
+
+	entryID, err := sm.cron.AddFunc(ruleMirrorCron, func() {
+		ExecuteRuleMirrorTask()
+	})
+	if err != nil {
+		utils.Error("添加规则镜像同步任务失败 - Cron: %s, Error: %v", ruleMirrorCron, err)
+		return err
+	}
+
+	sm.jobs[JobIDRuleMirror] = entryID
+	utils.Info("成功添加规则镜像同步任务 - Cron: %s", ruleMirrorCron)
+
+	// 启动时补齐缺失或过期的镜像
+	go ExecuteRuleMirrorTask()
+	return nil
+}
+
+// ExecuteRuleMirrorTask 执行规则镜像同步任务
+func ExecuteRuleMirrorTask() {
+	if !rulemirror.Enabled() {
+		return
+	}
+	if _, err := rulemirror.Refresh(false); err != nil {
+		utils.Error("规则镜像同步任务执行失败: %v", err)
+	}
+}
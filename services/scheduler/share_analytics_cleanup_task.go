@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"sublink/models"
+	"sublink/utils"
+)
+
+// StartShareAnalyticsCleanupTask 启动分享访问明细清理定时任务
+// 每天凌晨执行一次，按保留天数删除过期的访问明细，每日汇总保留
+func (sm *SchedulerManager) StartShareAnalyticsCleanupTask() error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	const shareAnalyticsCleanupCron = "40 3 * * *" // 每天 03:40 执行
+
+	// 如果任务已存在，先删除
+	if entryID, exists := sm.jobs[JobIDShareAnalyticsCleanup]; exists {
+		sm.cron.Remove(entryID)
+		delete(sm.jobs, JobIDShareAnalyticsCleanup)
+	}
+
+	entryID, err := sm.cron.AddFunc(shareAnalyticsCleanupCron, func() {
+		ExecuteShareAnalyticsCleanupTask()
+	})
+	if err != nil {
+		utils.Error("添加分享访问明细清理任务失败 - Cron: %s, Error: %v", shareAnalyticsCleanupCron, err)
+		return err
+	}
+
+	sm.jobs[JobIDShareAnalyticsCleanup] = entryID
+	utils.Info("成功添加分享访问明细清理任务 - Cron: %s", shareAnalyticsCleanupCron)
+	return nil
+}
+
+// ExecuteShareAnalyticsCleanupTask 执行分享访问明细清理任务
+func ExecuteShareAnalyticsCleanupTask() {
+	deleted, err := models.CleanupExpiredShareAccessEvents()
+	if err != nil {
+		utils.Error("分享访问明细清理任务执行失败: %v", err)
+		return
+	}
+	if deleted > 0 {
+		utils.Info("分享访问明细清理完成，删除 %d 条记录", deleted)
+	}
+}
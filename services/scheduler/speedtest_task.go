@@ -1,11 +1,13 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"sublink/constants"
 	"sublink/models"
 	"sublink/node"
 	"sublink/services/geoip"
+	"sublink/services/metrics"
 	"sublink/services/mihomo"
 	"sublink/services/notifications"
 	"sublink/services/unlock"
@@ -41,23 +43,37 @@ func applyNodeQualityInfo(node *models.Node, quality *mihomo.QualityCheckResult)
 	node.FraudScore = -1
 }
 
+// recordNodeLatencyMetrics 将本次测速的节点延迟写入监控指标
+func recordNodeLatencyMetrics(nodes []models.Node, results []models.SpeedTestResult) {
+	byID := make(map[int]models.Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	for _, result := range results {
+		n := byID[result.NodeID]
+		metrics.SetNodeLatency(result.NodeID, n.Name, n.Group, result.DelayTime)
+	}
+}
+
 // RunSpeedTestWithConfig 使用指定配置执行节点测速（并发安全）
 // 每个任务使用独立的配置实例，完全避免配置覆盖问题
 // 采用两阶段测试策略：阶段一并发测延迟，阶段二低并发测速度
 func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, profileName string, config *SpeedTestConfig) {
+	logger := utils.WithSubsystem(utils.SubsystemSpeedTest).With("profile", profileName)
 	if len(nodes) == 0 {
-		utils.Warn("没有要检测的节点")
+		logger.Warn("没有要检测的节点")
 		return
 	}
 
+	taskStart := time.Now()
 	totalNodes := len(nodes)
-	utils.Info("开始执行节点检测，总节点数: %d, 触发类型: %s, 策略: %s", totalNodes, trigger, profileName)
+	logger.Info("开始执行节点检测，总节点数: %d, 触发类型: %s, 策略: %s", totalNodes, trigger, profileName)
 
 	// 使用 TaskManager 创建任务
 	tm := getTaskManager()
 	task, ctx, err := tm.CreateTask(models.TaskTypeSpeedTest, profileName, trigger, totalNodes)
 	if err != nil {
-		utils.Error("创建检测任务失败: %v", err)
+		logger.Error("创建检测任务失败: %v", err)
 		return
 	}
 	taskID := task.ID
@@ -65,14 +81,14 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 	// 确保任务结束时清理
 	defer func() {
 		if r := recover(); r != nil {
-			utils.Error("测速任务执行过程中发生严重错误: %v", r)
+			logger.Error("测速任务执行过程中发生严重错误: %v", r)
 			_ = tm.FailTask(taskID, fmt.Sprintf("任务执行异常: %v", r))
 		}
 	}()
 
 	// 检查是否已被取消
 	if ctx.Err() != nil {
-		utils.Info("任务已被取消: %s", taskID)
+		logger.Info("任务已被取消: %s", taskID)
 		return
 	}
 
@@ -139,7 +155,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 		latencyConcurrency = latencyController.GetCurrentConcurrency()
 	} else {
 		if latencyConcurrency > maxConcurrency {
-			utils.Warn("警告: 延迟并发数 %d 超过最大限制，已调整为 %d", latencyConcurrency, maxConcurrency)
+			logger.Warn("警告: 延迟并发数 %d 超过最大限制，已调整为 %d", latencyConcurrency, maxConcurrency)
 			latencyConcurrency = maxConcurrency
 		}
 	}
@@ -159,7 +175,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 		// 硬性并发上限：速度测试不应超过32以避免带宽竞争
 		const maxSpeedConcurrency = 32
 		if speedConcurrency > maxSpeedConcurrency {
-			utils.Warn("警告: 速度并发数 %d 超过安全上限，已调整为 %d", speedConcurrency, maxSpeedConcurrency)
+			logger.Warn("警告: 速度并发数 %d 超过安全上限，已调整为 %d", speedConcurrency, maxSpeedConcurrency)
 			speedConcurrency = maxSpeedConcurrency
 		}
 	}
@@ -207,7 +223,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 	var hostMu sync.Mutex
 
 	// ========== 阶段一：延迟测试 ==========
-	utils.Info("阶段一：开始延迟测试，并发数: %d（动态: %v），UnifiedDelay: %v", latencyConcurrency, useAdaptiveLatency, !includeHandshake)
+	logger.Info("阶段一：开始延迟测试，并发数: %d（动态: %v），UnifiedDelay: %v", latencyConcurrency, useAdaptiveLatency, !includeHandshake)
 
 	// 固定并发模式的 semaphore（仅在非动态模式下使用）
 	var latencySem chan struct{}
@@ -223,7 +239,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 			mu.Lock()
 			cancelled = true
 			mu.Unlock()
-			utils.Debug("任务被取消，停止新的延迟测试")
+			logger.Debug("任务被取消，停止新的延迟测试")
 		default:
 		}
 
@@ -306,7 +322,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 				}
 				if err != nil {
 					failCount++
-					utils.Debug("节点 [%s] 延迟测试失败: %v", n.Name, err)
+					logger.With("node_id", n.ID).Debug("节点 [%s] 延迟测试失败: %v", n.Name, err)
 					if !preserveSpeed {
 						n.Speed = -1
 						n.SpeedStatus = constants.StatusUntested // TCP模式不测速度
@@ -315,7 +331,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 					n.DelayStatus = constants.StatusTimeout
 				} else {
 					successCount++
-					utils.Debug("节点 [%s] 延迟测试成功: %d ms", n.Name, latency)
+					logger.With("node_id", n.ID).Debug("节点 [%s] 延迟测试成功: %d ms", n.Name, latency)
 					if !preserveSpeed {
 						n.Speed = 0 // TCP模式不测速度
 						n.SpeedStatus = constants.StatusUntested
@@ -329,7 +345,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 						countryCode, geoErr := geoip.GetCountryISOCode(landingIP)
 						if geoErr == nil && countryCode != "" {
 							n.LinkCountry = countryCode
-							utils.Debug("节点 [%s] 落地IP: %s, 国家: %s", n.Name, landingIP, countryCode)
+							logger.With("node_id", n.ID).Debug("节点 [%s] 落地IP: %s, 国家: %s", n.Name, landingIP, countryCode)
 						}
 					}
 
@@ -413,11 +429,11 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 		}(i, node)
 	}
 	latencyWg.Wait()
-	utils.Info("阶段一完成：延迟测试结束")
+	logger.Info("阶段一完成：延迟测试结束")
 
 	// 检查是否被取消
 	if cancelled || ctx.Err() != nil {
-		utils.Info("任务被取消，跳过阶段二 (已完成: %d/%d)", completedCount, totalNodes)
+		logger.Info("任务被取消，跳过阶段二 (已完成: %d/%d)", completedCount, totalNodes)
 		_ = tm.UpdateProgress(taskID, int(completedCount), "已取消", nil)
 		// 任务已被 CancelTask 标记为取消，无需再次更新
 		goto applyTags
@@ -425,7 +441,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 
 	// ========== 阶段二：速度测试（仅 mihomo 模式）==========
 	if speedTestMode != "tcp" {
-		utils.Info("阶段二：开始速度测试，并发数: %d（动态: %v）", speedConcurrency, useAdaptiveSpeed)
+		logger.Info("阶段二：开始速度测试，并发数: %d（动态: %v）", speedConcurrency, useAdaptiveSpeed)
 
 		// 重置进度计数器用于阶段二
 		completedCount = 0
@@ -444,7 +460,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 				mu.Lock()
 				cancelled = true
 				mu.Unlock()
-				utils.Debug("任务被取消，停止新的速度测试")
+				logger.Debug("任务被取消，停止新的速度测试")
 			default:
 			}
 
@@ -594,7 +610,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 					if detectQuality {
 						resetNodeQualityInfo(&result.node)
 					}
-					utils.Debug("节点 [%s] 速度测试失败: %v (延迟: %d ms, 已下载: %s)", result.node.Name, err, result.latency, formatBytes(bytesDownloaded))
+					logger.With("node_id", result.node.ID).Debug("节点 [%s] 速度测试失败: %v (延迟: %d ms, 已下载: %s)", result.node.Name, err, result.latency, formatBytes(bytesDownloaded))
 					result.node.Speed = -1
 					result.node.SpeedStatus = constants.StatusError
 					result.node.DelayTime = result.latency            // 保留延迟测试结果
@@ -610,7 +626,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 					if detectQuality {
 						applyNodeQualityInfo(&result.node, qualityInfo)
 					}
-					utils.Debug("节点 [%s] 测速成功: 速度 %.2f MB/s, 延迟 %d ms, 流量消耗: %s", result.node.Name, speed, result.latency, formatBytes(bytesDownloaded))
+					logger.With("node_id", result.node.ID).Debug("节点 [%s] 测速成功: 速度 %.2f MB/s, 延迟 %d ms, 流量消耗: %s", result.node.Name, speed, result.latency, formatBytes(bytesDownloaded))
 					result.node.Speed = speed
 					result.node.SpeedStatus = constants.StatusSuccess
 					result.node.DelayTime = result.latency
@@ -627,7 +643,7 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 						countryCode, geoErr := geoip.GetCountryISOCode(landingIP)
 						if geoErr == nil && countryCode != "" {
 							result.node.LinkCountry = countryCode
-							utils.Debug("节点 [%s] 落地IP: %s, 国家: %s", result.node.Name, landingIP, countryCode)
+							logger.With("node_id", result.node.ID).Debug("节点 [%s] 落地IP: %s, 国家: %s", result.node.Name, landingIP, countryCode)
 						}
 					}
 
@@ -705,23 +721,24 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 			}(nr)
 		}
 		speedWg.Wait()
-		utils.Info("阶段二完成：速度测试结束")
+		logger.Info("阶段二完成：速度测试结束")
 	}
 
 	// 检查最终是否被取消
 	if cancelled || ctx.Err() != nil {
-		utils.Info("任务被取消")
+		logger.Info("任务被取消")
 		goto applyTags
 	}
 
 	// 批量写入所有测速结果到数据库（一次性操作，减少数据库I/O）
 	if len(speedTestResults) > 0 {
 		if err := models.BatchUpdateSpeedResults(speedTestResults); err != nil {
-			utils.Error("批量更新测速结果失败: %v", err)
+			logger.Error("批量更新测速结果失败: %v", err)
 		} else {
-			utils.Debug("批量更新测速结果成功，共 %d 条记录", len(speedTestResults))
+			logger.Debug("批量更新测速结果成功，共 %d 条记录", len(speedTestResults))
 		}
 	}
+	recordNodeLatencyMetrics(nodes, speedTestResults)
 
 	// 批量保存Host映射到数据库（如果开启了持久化）
 	if persistHost && len(hostMappings) > 0 {
@@ -738,9 +755,9 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 		go func(mappings []models.HostMappingInfo) {
 			count, err := models.BatchUpsertHosts(mappings)
 			if err != nil {
-				utils.Error("批量保存Host映射失败: %v", err)
+				logger.Error("批量保存Host映射失败: %v", err)
 			} else if count > 0 {
-				utils.Info("测速Host持久化: 成功处理 %d 条", count)
+				logger.Info("测速Host持久化: 成功处理 %d 条", count)
 			}
 		}(uniqueHostMappings)
 	}
@@ -806,7 +823,8 @@ func RunSpeedTestWithConfig(nodes []models.Node, trigger models.TaskTrigger, pro
 			resultData["unlockProviders"] = unlockProviders
 			resultData["unlock"] = models.BuildUnlockAggregate(unlockSummaries, unlockProviders)
 		}
-		utils.Info("测速任务完成 - 总计: %d, 成功: %d, 失败: %d, 流量: %s", totalNodes, successCount, failCount, formatBytes(trafficTotal))
+		metrics.ObserveSpeedTestTask(int(successCount), int(failCount), trafficTotal, time.Since(taskStart))
+		logger.Info("测速任务完成 - 总计: %d, 成功: %d, 失败: %d, 流量: %s", totalNodes, successCount, failCount, formatBytes(trafficTotal))
 		_ = tm.CompleteTask(taskID, fmt.Sprintf("测速完成 (成功: %d, 失败: %d, 流量: %s)", successCount, failCount, formatBytes(trafficTotal)), resultData)
 
 		// 广播测速完成通知（让用户在通知中心看到）
@@ -839,7 +857,7 @@ applyTags:
 		// 从数据库/缓存获取最新的节点数据
 		updatedNodes, err := models.GetNodesByIDs(testedNodeIDs)
 		if err != nil {
-			utils.Warn("获取测速节点最新数据失败: %v, 使用原始数据", err)
+			logger.Warn("获取测速节点最新数据失败: %v, 使用原始数据", err)
 			applyAutoTagRules(nodes, "speed_test")
 			return
 		}
@@ -849,7 +867,7 @@ applyTags:
 
 	// 测速完成后后台静默刷新机场用量信息（测速会消耗流量）
 	go func() {
-		node.RefreshUsageForSubscriptionNodes(nodes)
+		node.RefreshUsageForSubscriptionNodes(context.Background(), nodes)
 	}()
 
 }
@@ -859,12 +877,13 @@ applyTags:
 // nodeIDs: 指定节点ID列表（可选，为空则按策略范围执行）
 // trigger: 触发类型（手动/定时）
 func ExecuteNodeCheckWithProfile(profileID int, nodeIDs []int, trigger models.TaskTrigger) {
-	utils.Info("开始执行节点检测，策略ID: %d, 触发类型: %s", profileID, trigger)
+	logger := utils.WithSubsystem(utils.SubsystemSpeedTest).With("profile_id", profileID)
+	logger.Info("开始执行节点检测，策略ID: %d, 触发类型: %s", profileID, trigger)
 
 	// 获取策略配置
 	profile, err := models.GetNodeCheckProfileByID(profileID)
 	if err != nil {
-		utils.Error("获取节点检测策略失败: %v", err)
+		logger.Error("获取节点检测策略失败: %v", err)
 		return
 	}
 
@@ -888,7 +907,7 @@ func ExecuteNodeCheckWithProfile(profileID int, nodeIDs []int, trigger models.Ta
 		if len(groups) > 0 {
 			nodes, err = new(models.Node).ListByGroups(groups)
 			if err != nil {
-				utils.Error("获取分组节点失败: %v", err)
+				logger.Error("获取分组节点失败: %v", err)
 				return
 			}
 			// 在分组基础上按标签过滤
@@ -898,20 +917,20 @@ func ExecuteNodeCheckWithProfile(profileID int, nodeIDs []int, trigger models.Ta
 		} else if len(tags) > 0 {
 			nodes, err = new(models.Node).ListByTags(tags)
 			if err != nil {
-				utils.Error("获取标签节点失败: %v", err)
+				logger.Error("获取标签节点失败: %v", err)
 				return
 			}
 		} else {
 			nodes, err = new(models.Node).List()
 			if err != nil {
-				utils.Error("获取节点列表失败: %v", err)
+				logger.Error("获取节点列表失败: %v", err)
 				return
 			}
 		}
 	}
 
 	if len(nodes) == 0 {
-		utils.Warn("没有符合条件的节点")
+		logger.Warn("没有符合条件的节点")
 		return
 	}
 
@@ -923,6 +942,6 @@ func ExecuteNodeCheckWithProfile(profileID int, nodeIDs []int, trigger models.Ta
 
 	// 更新策略的上次执行时间（保留现有的下次执行时间）
 	if err := profile.UpdateLastRunTime(new(time.Now())); err != nil {
-		utils.Warn("更新策略执行时间失败: %v", err)
+		logger.Warn("更新策略执行时间失败: %v", err)
 	}
 }
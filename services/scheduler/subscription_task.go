@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"sublink/models"
 	"sublink/node"
+	"sublink/services/metrics"
 	"sublink/services/notifications"
+	"sublink/services/tracing"
 	"sublink/utils"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ExecuteSubscriptionTask 执行订阅任务的具体业务逻辑
@@ -16,7 +21,8 @@ func ExecuteSubscriptionTask(id int, url string, subName string) {
 
 // ExecuteSubscriptionTaskWithTrigger 执行订阅任务（带触发类型）
 func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trigger models.TaskTrigger) {
-	utils.Info("执行自动获取订阅任务 - ID: %d, Name: %s, URL: %s, Trigger: %s", id, subName, url, trigger)
+	logger := utils.WithSubsystem(utils.SubsystemAirport).With("airport_id", id).With("airport", subName)
+	logger.Info("执行自动获取订阅任务 - ID: %d, Name: %s, URL: %s, Trigger: %s", id, subName, url, trigger)
 
 	// 获取最新的机场配置，以便使用最新的代理设置
 	var downloadWithProxy bool
@@ -28,7 +34,7 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 
 	airport, err := models.GetAirportByID(id)
 	if err != nil {
-		utils.Warn("获取机场配置失败 ID: %d, 使用默认设置: %v", id, err)
+		logger.Warn("获取机场配置失败 ID: %d, 使用默认设置: %v", id, err)
 	} else {
 		downloadWithProxy = airport.DownloadWithProxy
 		proxyLink = airport.ProxyLink
@@ -44,7 +50,7 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 
 	var reporter node.TaskReporter
 	if createErr != nil {
-		utils.Warn("创建订阅更新任务失败: %v，将使用降级模式", createErr)
+		logger.Warn("创建订阅更新任务失败: %v，将使用降级模式", createErr)
 		reporter = nil             // 使用 nil，将在 sub.go 中降级为 NoOpTaskReporter
 		ctx = context.Background() // 降级情况下使用 Background context
 	} else {
@@ -53,7 +59,7 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 		// 添加全局 panic 保护
 		defer func() {
 			if r := recover(); r != nil {
-				utils.Error("订阅任务执行发生 panic: %v, 任务ID: %s, 订阅名称: %s, URL: %s", r, task.ID, subName, url)
+				logger.Error("订阅任务执行发生 panic: %v, 任务ID: %s, 订阅名称: %s, URL: %s", r, task.ID, subName, url)
 				reporter.ReportFail(fmt.Sprintf("任务异常崩溃: %v", r))
 			}
 		}()
@@ -61,14 +67,24 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 		// 在调用前快速检查任务是否已被取消
 		select {
 		case <-ctx.Done():
-			utils.Info("任务在执行前已被取消: %s", subName)
+			logger.Info("任务在执行前已被取消: %s", subName)
 			return
 		default:
 		}
 	}
 
-	changedNodeIDs, usageInfo, err := node.LoadClashConfigFromURLWithReporter(ctx, id, url, subName, downloadWithProxy, proxyLink, userAgent, requestHeaders, reporter, fetchUsageInfo, skipTLSVerify)
+	pullStart := time.Now()
+	pullCtx, span := tracing.Start(ctx, "airport.pull",
+		attribute.Int("sublink.airport.id", id),
+		attribute.String("sublink.airport.name", subName),
+		attribute.String("sublink.trigger", string(trigger)),
+	)
+	changedNodeIDs, usageInfo, err := node.LoadClashConfigFromURLWithReporter(pullCtx, id, url, subName, downloadWithProxy, proxyLink, userAgent, requestHeaders, reporter, fetchUsageInfo, skipTLSVerify)
+	span.SetAttributes(attribute.Int("sublink.nodes.changed", len(changedNodeIDs)))
+	tracing.End(span, err)
 	if err != nil {
+		metrics.ObserveAirportPull(id, false, time.Since(pullStart), 0)
+		logger.Error("机场 [%s] 订阅更新失败: %v", subName, err)
 		// 仅在失败时发送通知，成功通知由 node/sub.go 中的 scheduleClashToNodeLinks 发送
 		// 这样可以避免重复通知，且成功通知包含更详细的节点统计信息
 		if reporter != nil {
@@ -86,12 +102,15 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 		return
 	}
 
+	pulledNodes, _ := models.ListBySourceID(id)
+	metrics.ObserveAirportPull(id, true, time.Since(pullStart), len(pulledNodes))
+
 	// 更新用量信息（如果开启了获取用量信息且成功获取到）
 	if fetchUsageInfo && usageInfo != nil && airport != nil {
 		if updateErr := airport.UpdateUsageInfo(usageInfo.Upload, usageInfo.Download, usageInfo.Total, usageInfo.Expire); updateErr != nil {
-			utils.Warn("更新机场用量信息失败 ID: %d: %v", id, updateErr)
+			logger.Warn("更新机场用量信息失败 ID: %d: %v", id, updateErr)
 		} else {
-			utils.Info("成功更新机场 [%s] 用量信息", subName)
+			logger.Info("成功更新机场 [%s] 用量信息", subName)
 		}
 	}
 
@@ -110,22 +129,22 @@ func ExecuteSubscriptionTaskWithTrigger(id int, url string, subName string, trig
 		go func(airportID int, airportName string, nodeCheckProfileID int, changedNodeIDs []int, changedOnly bool) {
 			nodeIDs, shouldRun, listErr := resolveUpdateAfterDetectNodeIDs(airportID, changedNodeIDs, changedOnly)
 			if listErr != nil {
-				utils.Warn("获取机场节点失败，跳过更新后检测 - ID: %d, Error: %v", airportID, listErr)
+				logger.Warn("获取机场节点失败，跳过更新后检测 - ID: %d, Error: %v", airportID, listErr)
 				return
 			}
 			if !shouldRun {
 				if changedOnly {
-					utils.Info("机场 [%s] 开启了仅检测变化/新增节点，但本次更新没有变化/新增节点，跳过更新后检测", airportName)
+					logger.Info("机场 [%s] 开启了仅检测变化/新增节点，但本次更新没有变化/新增节点，跳过更新后检测", airportName)
 				} else {
-					utils.Warn("机场 [%s] 更新后检测已启用，但没有可检测节点", airportName)
+					logger.Warn("机场 [%s] 更新后检测已启用，但没有可检测节点", airportName)
 				}
 				return
 			}
 
 			if changedOnly {
-				utils.Info("机场 [%s] 订阅更新完成，仅检测 %d 个变化/新增节点，策略 ID: %d", airportName, len(nodeIDs), nodeCheckProfileID)
+				logger.Info("机场 [%s] 订阅更新完成，仅检测 %d 个变化/新增节点，策略 ID: %d", airportName, len(nodeIDs), nodeCheckProfileID)
 			} else {
-				utils.Info("机场 [%s] 订阅更新完成，立即执行节点检测策略 ID: %d", airportName, nodeCheckProfileID)
+				logger.Info("机场 [%s] 订阅更新完成，立即执行节点检测策略 ID: %d", airportName, nodeCheckProfileID)
 			}
 
 			ExecuteNodeCheckWithProfile(nodeCheckProfileID, nodeIDs, models.TaskTriggerAirportUpdate)
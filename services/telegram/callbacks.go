@@ -19,7 +19,7 @@ func HandleCallbackQuery(bot *TelegramBot, callback *CallbackQuery) error {
 		param = parts[1]
 	}
 
-	utils.Debug("处理回调: action=%s, param=%s", action, param)
+	logger.Debug("处理回调: action=%s, param=%s", action, param)
 
 	switch action {
 	// 导航回调
@@ -79,7 +79,7 @@ func HandleCallbackQuery(bot *TelegramBot, callback *CallbackQuery) error {
 		return handleTaskCancelCallback(bot, callback, param)
 
 	default:
-		utils.Debug("未知回调: %s", data)
+		logger.Debug("未知回调: %s", data)
 		return nil
 	}
 }
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"sublink/models"
 	"sublink/services/monitor"
-	"sublink/utils"
 	"sync"
 	"time"
 )
@@ -902,7 +901,7 @@ func PullSubscription(airportID int) error {
 	if servicesWrapper != nil {
 		go servicesWrapper.ExecuteSubscriptionTaskWithTrigger(airport.ID, airport.URL, airport.Name, models.TaskTriggerManual)
 	}
-	utils.Info("Telegram 触发机场更新: %s", airport.Name)
+	logger.Info("Telegram 触发机场更新: %s", airport.Name)
 
 	return nil
 }
@@ -919,7 +918,7 @@ func ApplyAllTagRules() error {
 	if servicesWrapper != nil {
 		go servicesWrapper.ApplyAutoTagRules(nodes, "telegram_manual")
 	}
-	utils.Info("Telegram 触发标签规则应用: %d 个节点", len(nodes))
+	logger.Info("Telegram 触发标签规则应用: %d 个节点", len(nodes))
 
 	return nil
 }
@@ -5,8 +5,8 @@ import (
 	"strconv"
 	"strings"
 
+	"sublink/services/metrics"
 	"sublink/services/notifications"
-	"sublink/utils"
 )
 
 // escapeMd 转义 Telegram Markdown 特殊字符，避免用户内容中的特殊字符被错误解析
@@ -55,7 +55,8 @@ func SendNotification(eventKey string, payload notifications.Payload) {
 	}
 
 	if err := bot.SendMessage(bot.ChatID, text, "Markdown"); err != nil {
-		utils.Warn("发送 Telegram 通知失败: %v", err)
+		logger.Warn("发送 Telegram 通知失败: %v", err)
+		metrics.IncNotificationFailure(metrics.ChannelTelegram)
 	}
 }
 
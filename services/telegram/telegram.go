@@ -20,6 +20,9 @@ const (
 	TelegramAPIBase = "https://api.telegram.org/bot"
 )
 
+// logger Telegram 子系统日志
+var logger = utils.WithSubsystem(utils.SubsystemTelegram)
+
 // TelegramBot Telegram 机器人核心结构
 type TelegramBot struct {
 	Token     string
@@ -85,7 +88,7 @@ func connectionMonitor() {
 func checkAndStart() {
 	config, err := LoadConfig()
 	if err != nil {
-		utils.Error("[Telegram] 加载配置失败: %v", err)
+		logger.Error("[Telegram] 加载配置失败: %v", err)
 		return
 	}
 
@@ -93,18 +96,18 @@ func checkAndStart() {
 	if !config.Enabled || config.BotToken == "" {
 		if GetBot() != nil {
 			StopBot()
-			utils.Info("[Telegram] 机器人已禁用，停止运行")
+			logger.Info("[Telegram] 机器人已禁用，停止运行")
 		}
 		return
 	}
 
 	// 如果启用但未运行，尝试启动
 	if GetBot() == nil {
-		utils.Info("[Telegram] 检测到机器人未运行，尝试启动...")
+		logger.Info("[Telegram] 检测到机器人未运行，尝试启动...")
 		if err := StartBot(config); err != nil {
-			utils.Error("[Telegram] 启动失败: %v", err)
+			logger.Error("[Telegram] 启动失败: %v", err)
 		} else {
-			utils.Info("[Telegram] 启动成功")
+			logger.Info("[Telegram] 启动成功")
 		}
 	}
 }
@@ -191,7 +194,7 @@ func StartBot(config *Config) error {
 	}
 
 	if config.UseProxy {
-		utils.Info("[Telegram] 使用代理连接: %s", usedProxy)
+		logger.Info("[Telegram] 使用代理连接: %s", usedProxy)
 	}
 
 	bot := &TelegramBot{
@@ -211,7 +214,7 @@ func StartBot(config *Config) error {
 
 	// 设置命令菜单
 	if err := bot.SetCommands(); err != nil {
-		utils.Warn("设置命令菜单失败: %v", err)
+		logger.Warn("设置命令菜单失败: %v", err)
 	}
 
 	globalBot = bot
@@ -219,7 +222,7 @@ func StartBot(config *Config) error {
 	// 启动长轮询
 	go bot.startPolling()
 
-	utils.Info("Telegram 机器人已启动")
+	logger.Info("Telegram 机器人已启动")
 	return nil
 }
 
@@ -257,7 +260,7 @@ func (b *TelegramBot) validateToken() error {
 		return fmt.Errorf("token 无效")
 	}
 
-	utils.Info("Telegram 机器人验证成功: @%s", result.Result.Username)
+	logger.Info("Telegram 机器人验证成功: @%s", result.Result.Username)
 	b.mutex.Lock()
 	b.botUsername = result.Result.Username
 	b.botID = result.Result.ID
@@ -337,7 +340,7 @@ func (b *TelegramBot) startPolling() {
 	b.pollingActive = true
 	b.mutex.Unlock()
 
-	utils.Info("Telegram 长轮询已启动")
+	logger.Info("Telegram 长轮询已启动")
 
 	retryCount := 0
 	maxRetry := 5
@@ -345,17 +348,17 @@ func (b *TelegramBot) startPolling() {
 	for {
 		select {
 		case <-b.stopChan:
-			utils.Info("Telegram 长轮询已停止")
+			logger.Info("Telegram 长轮询已停止")
 			return
 		default:
 			updates, err := b.getUpdates()
 			if err != nil {
 				retryCount++
 				b.setError(err.Error())
-				utils.Warn("获取更新失败 (%d/%d): %v", retryCount, maxRetry, err)
+				logger.Warn("获取更新失败 (%d/%d): %v", retryCount, maxRetry, err)
 
 				if retryCount >= maxRetry {
-					utils.Warn("Telegram 连接失败次数过多，等待 30 秒后重试")
+					logger.Warn("Telegram 连接失败次数过多，等待 30 秒后重试")
 					time.Sleep(30 * time.Second)
 					retryCount = 0
 				} else {
@@ -406,11 +409,11 @@ func (b *TelegramBot) getUpdates() ([]Update, error) {
 
 // handleUpdate 处理更新
 func (b *TelegramBot) handleUpdate(update Update) {
-	utils.Debug("[Telegram] 收到更新 ID: %d", update.UpdateID)
+	logger.Debug("[Telegram] 收到更新 ID: %d", update.UpdateID)
 
 	// 处理消息
 	if update.Message != nil {
-		utils.Debug("[Telegram] 收到消息 - ChatID: %d, From: %s, Text: %s",
+		logger.Debug("[Telegram] 收到消息 - ChatID: %d, From: %s, Text: %s",
 			update.Message.Chat.ID,
 			update.Message.From.Username,
 			update.Message.Text)
@@ -420,7 +423,7 @@ func (b *TelegramBot) handleUpdate(update Update) {
 
 	// 处理回调
 	if update.CallbackQuery != nil {
-		utils.Debug("[Telegram] 收到回调 - Data: %s", update.CallbackQuery.Data)
+		logger.Debug("[Telegram] 收到回调 - Data: %s", update.CallbackQuery.Data)
 		b.handleCallback(update.CallbackQuery)
 		return
 	}
@@ -428,11 +431,11 @@ func (b *TelegramBot) handleUpdate(update Update) {
 
 // handleMessage 处理消息
 func (b *TelegramBot) handleMessage(message *Message) {
-	utils.Debug("[Telegram] 处理消息 - ChatID: %d, 已配置ChatID: %d", message.Chat.ID, b.ChatID)
+	logger.Debug("[Telegram] 处理消息 - ChatID: %d, 已配置ChatID: %d", message.Chat.ID, b.ChatID)
 
 	// 验证 Chat ID（如果已配置）
 	if b.ChatID != 0 && message.Chat.ID != b.ChatID {
-		utils.Debug("[Telegram] 忽略来自未授权聊天的消息: %d (预期: %d)", message.Chat.ID, b.ChatID)
+		logger.Debug("[Telegram] 忽略来自未授权聊天的消息: %d (预期: %d)", message.Chat.ID, b.ChatID)
 		return
 	}
 
@@ -440,7 +443,7 @@ func (b *TelegramBot) handleMessage(message *Message) {
 	if b.ChatID == 0 && strings.HasPrefix(message.Text, "/start") {
 		b.ChatID = message.Chat.ID
 		_ = models.SetSetting("telegram_chat_id", strconv.FormatInt(message.Chat.ID, 10))
-		utils.Info("[Telegram] 自动绑定 Chat ID: %d", message.Chat.ID)
+		logger.Info("[Telegram] 自动绑定 Chat ID: %d", message.Chat.ID)
 	}
 
 	// 处理命令
@@ -449,19 +452,19 @@ func (b *TelegramBot) handleMessage(message *Message) {
 		command := strings.TrimPrefix(parts[0], "/")
 		command = strings.Split(command, "@")[0] // 移除 @botname
 
-		utils.Debug("[Telegram] 处理命令: /%s", command)
+		logger.Debug("[Telegram] 处理命令: /%s", command)
 
 		handler := GetHandler(command)
 		if handler != nil {
-			utils.Debug("[Telegram] 找到处理器: %s", handler.Description())
+			logger.Debug("[Telegram] 找到处理器: %s", handler.Description())
 			if err := handler.Handle(b, message); err != nil {
-				utils.Warn("[Telegram] 处理命令 /%s 失败: %v", command, err)
+				logger.Warn("[Telegram] 处理命令 /%s 失败: %v", command, err)
 				_ = b.SendMessage(message.Chat.ID, "❌ 命令执行失败: "+err.Error(), "")
 			} else {
-				utils.Debug("[Telegram] 命令 /%s 执行成功", command)
+				logger.Debug("[Telegram] 命令 /%s 执行成功", command)
 			}
 		} else {
-			utils.Debug("[Telegram] 未找到命令处理器: /%s", command)
+			logger.Debug("[Telegram] 未找到命令处理器: /%s", command)
 			_ = b.SendMessage(message.Chat.ID, "❓ 未知命令，使用 /help 查看帮助", "")
 		}
 	}
@@ -475,7 +478,7 @@ func (b *TelegramBot) handleCallback(callback *CallbackQuery) {
 	}
 
 	if err := HandleCallbackQuery(b, callback); err != nil {
-		utils.Warn("处理回调失败: %v", err)
+		logger.Warn("处理回调失败: %v", err)
 	}
 
 	// 应答回调
@@ -9,6 +9,7 @@ import (
 )
 
 type TemplateValidationInput struct {
+	Filename      string
 	Category      string
 	OriginalText  string
 	CandidateText string
@@ -46,18 +47,28 @@ func ValidateTemplateCandidate(input TemplateValidationInput) TemplateValidation
 		result.Valid = false
 		result.Errors = append(result.Errors, "模板内容与选择的类别不匹配")
 	}
-	if trimmedCategory == "clash" {
-		if err := validateClash(input.CandidateText); err != nil {
-			result.Valid = false
-			result.Errors = append(result.Errors, err.Error())
+	// 模板变量、条件区块与 include/extends 先用示例上下文展开，再对完整结果做结构校验
+	renderedText, err := renderTemplateCandidate(input)
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, err.Error())
+	} else {
+		if trimmedCategory == "clash" {
+			if err := validateClash(renderedText); err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, err.Error())
+			}
 		}
-	}
-	if trimmedCategory == "surge" {
-		if err := validateSurge(input.CandidateText); err != nil {
-			result.Valid = false
-			result.Errors = append(result.Errors, err.Error())
+		if trimmedCategory == "surge" {
+			if err := validateSurge(renderedText); err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, err.Error())
+			}
 		}
 	}
+	if protocol.HasTemplateDirectives(input.CandidateText) {
+		result.Warnings = append(result.Warnings, "模板包含变量或条件区块，实际输出取决于订阅、分享与节点信息")
+	}
 	for _, token := range protectedTokens {
 		if strings.Contains(input.OriginalText, token) {
 			result.ProtectedTokensFound = append(result.ProtectedTokensFound, token)
@@ -73,6 +84,18 @@ func ValidateTemplateCandidate(input TemplateValidationInput) TemplateValidation
 	return result
 }
 
+// renderTemplateCandidate 展开候选模板的引用并试渲染，引用自身的候选内容按未保存版本处理
+func renderTemplateCandidate(input TemplateValidationInput) (string, error) {
+	if !protocol.HasTemplateReferences(input.CandidateText) {
+		return protocol.ValidateTemplateSyntax(input.CandidateText)
+	}
+	loader := protocol.TemplateFileLoader(protocol.DefaultTemplateDir)
+	if input.Filename != "" {
+		loader = protocol.WithTemplateOverride(loader, input.Filename, input.CandidateText)
+	}
+	return protocol.ResolveTemplate(input.Filename, input.CandidateText, strings.TrimSpace(input.Category), protocol.SampleTemplateContext(), loader)
+}
+
 func validateClash(content string) error {
 	var parsed map[string]any
 	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
@@ -130,6 +153,5 @@ func PreviewProtectedGroupBehavior(category string, template string) []string {
 	if strings.Contains(template, "include-all") || strings.Contains(template, "include-all-proxies") || strings.Contains(template, "include-all-providers") {
 		warnings = append(warnings, "模板包含 include-all 相关语义，运行时会保留自动匹配组行为")
 	}
-	_ = protocol.Config{}
 	return warnings
 }
@@ -34,3 +34,24 @@ func TestValidateTemplateCandidateAcceptsBasicSurgeTemplate(t *testing.T) {
 		t.Fatalf("expected surge template to validate, got errors: %#v", result.Errors)
 	}
 }
+
+func TestValidateTemplateCandidateChecksTemplateDirectives(t *testing.T) {
+	valid := ValidateTemplateCandidate(TemplateValidationInput{
+		Category:      "clash",
+		CandidateText: "#!template\nport: 7890\nproxies: []\n{{- if gt .Nodes.Count 0 }}\nmode: rule\n{{- end }}\n",
+	})
+	if !valid.Valid {
+		t.Fatalf("expected directive template to validate, got errors: %#v", valid.Errors)
+	}
+	if len(valid.Warnings) == 0 {
+		t.Fatal("expected warning about template directives")
+	}
+
+	invalid := ValidateTemplateCandidate(TemplateValidationInput{
+		Category:      "clash",
+		CandidateText: "#!template\nport: 7890\nproxies: []\n{{ if .Vars.dns }}\n",
+	})
+	if invalid.Valid {
+		t.Fatal("expected unterminated directive to fail validation")
+	}
+}
@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 本项目创建 span 时使用的 tracer 名称
+const tracerName = "sublink"
+
+// defaultTracesPath OTLP/HTTP 默认的链路上报路径
+const defaultTracesPath = "/v1/traces"
+
+// Config 链路追踪配置
+type Config struct {
+	Endpoint       string  // OTLP/HTTP 上报地址，为空时不启用
+	SampleRatio    float64 // 采样比例 (0, 1]，无效值按 1 处理
+	ServiceVersion string
+}
+
+// Init 初始化全局 TracerProvider 与 W3C 上下文传播
+// 未配置上报地址时保持 OpenTelemetry 默认的空实现，创建 span 几乎没有开销。
+// 返回的函数用于退出时刷新并关闭导出器。
+func Init(cfg Config) (func(context.Context) error, error) {
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpoint, err := normalizeEndpoint(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 导出器失败: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("sublinkpro"),
+			semconv.ServiceVersion(cfg.ServiceVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// normalizeEndpoint 校验上报地址，未带路径时补全 /v1/traces
+func normalizeEndpoint(raw string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("无效的链路追踪上报地址: %s", raw)
+	}
+	if parsed.Path == "" || parsed.Path == "/" {
+		parsed.Path = defaultTracesPath
+	}
+	return parsed.String(), nil
+}
+
+// Start 创建子 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer 从请求头中提取上游链路上下文并创建服务端 span
+func StartServer(r *http.Request, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误状态
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport 包装 HTTP Transport，为外发请求创建客户端 span 并注入链路上下文
+// 机场订阅地址的查询参数里通常带有 token，span 只记录 scheme/host/path，避免凭据被上报到链路后端。
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &clientTransport{base: base}
+}
+
+// clientTransport 手动创建客户端 span 的 RoundTripper
+type clientTransport struct {
+	base http.RoundTripper
+}
+
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(redactURL(req.URL)),
+		semconv.URLScheme(req.URL.Scheme),
+		semconv.ServerAddress(req.URL.Hostname()),
+	}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	outgoing := req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outgoing.Header))
+	resp, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
+
+// redactURL 去掉 userinfo、查询参数和片段，只保留 scheme://host/path
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clean := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path, RawPath: u.RawPath}
+	return clean.String()
+}
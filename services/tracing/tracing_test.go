@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNormalizeEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"http://collector:4318":            "http://collector:4318/v1/traces",
+		"https://otel.example.com/":        "https://otel.example.com/v1/traces",
+		"http://collector:4318/custom/v1":  "http://collector:4318/custom/v1",
+		" http://127.0.0.1:4318/v1/traces": "http://127.0.0.1:4318/v1/traces",
+	}
+	for raw, want := range cases {
+		got, err := normalizeEndpoint(raw)
+		if err != nil || got != want {
+			t.Errorf("normalizeEndpoint(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"collector:4318", "ftp://collector", "http://"} {
+		if _, err := normalizeEndpoint(raw); err == nil {
+			t.Errorf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestInitWithoutEndpointIsNoop(t *testing.T) {
+	shutdown, err := Init(Config{})
+	if err != nil {
+		t.Fatalf("Init without endpoint: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestTransportPropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	_ = resp.Body.Close()
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	if len(traceparent) < 36 || traceparent[3:35] != traceID {
+		t.Fatalf("expected traceparent with trace id %s, got %q", traceID, traceparent)
+	}
+	if spans := recorder.Ended(); len(spans) != 2 {
+		t.Fatalf("expected parent and client spans, got %d", len(spans))
+	}
+}
+
+func TestTransportOmitsQueryFromSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	target := strings.Replace(server.URL, "http://", "http://user:pass@", 1) + "/api/v1/client/subscribe?token=secret-token#frag"
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one client span, got %d", len(spans))
+	}
+	for _, attr := range spans[0].Attributes() {
+		value := attr.Value.Emit()
+		if strings.Contains(value, "secret-token") || strings.Contains(value, "?") || strings.Contains(value, "pass") {
+			t.Fatalf("span attribute %s leaks request details: %q", attr.Key, value)
+		}
+		if attr.Key == "url.full" && value != server.URL+"/api/v1/client/subscribe" {
+			t.Fatalf("unexpected url.full %q", value)
+		}
+	}
+}
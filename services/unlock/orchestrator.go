@@ -3,6 +3,7 @@ package unlock
 import (
 	"sublink/models"
 	"sublink/services/mihomo"
+	"sublink/utils"
 	"sync"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 func CheckUnlock(nodeLink string, timeout time.Duration, landingCountry string, providers []string) models.UnlockSummary {
 	proxyAdapter, err := mihomo.GetMihomoAdapter(nodeLink)
 	if err != nil {
+		utils.WithSubsystem(utils.SubsystemUnlock).Warn("创建解锁检测代理适配器失败: %v", err)
 		return models.UnlockSummary{Providers: buildUnlockErrorResults(providers, err.Error())}
 	}
 	return CheckUnlockWithAdapter(proxyAdapter, timeout, landingCountry, providers)
@@ -54,6 +56,8 @@ func CheckUnlockWithAdapter(proxyAdapter constant.Proxy, timeout time.Duration,
 			if result.Provider == "" {
 				result.Provider = providerName
 			}
+			utils.WithSubsystem(utils.SubsystemUnlock).With("provider", result.Provider).
+				Debug("解锁检测结果: %s, 地区: %s, 原因: %s", result.Status, result.Region, result.Reason)
 			results[index] = result
 		}(idx, provider)
 	}
@@ -172,7 +172,7 @@ func Base64Decode(s string) string {
 	// 判断是否有特殊字符来判断是标准base64还是url base64
 	match, err := regexp.MatchString(`[_-]`, s)
 	if err != nil {
-		fmt.Println(err)
+		Warn("base64 编码类型判断失败: %v", err)
 	}
 	if !match {
 		// 默认使用标准解码
@@ -203,7 +203,7 @@ func Base64Decode2(s string) string {
 	// 判断是否有特殊字符来判断是标准base64还是url base64
 	match, err := regexp.MatchString(`[_-]`, s)
 	if err != nil {
-		fmt.Println(err)
+		Warn("base64 编码类型判断失败: %v", err)
 	}
 	if !match {
 		// 默认使用标准解码
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -47,6 +48,9 @@ type Logger struct {
 	mutex     sync.RWMutex // 读写锁
 	logPath   string       // 日志目录路径
 	colorMode bool         // 是否启用颜色输出
+	format    string       // 输出格式 (text/json)
+
+	subsystemLevels map[string]int // 子系统日志等级覆盖，未设置的子系统使用全局等级
 }
 
 // 全局日志实例
@@ -59,8 +63,10 @@ var (
 func GetLogger() *Logger {
 	loggerOnce.Do(func() {
 		globalLogger = &Logger{
-			level:     LevelInfo, // 默认 INFO 等级
-			colorMode: true,      // 默认启用颜色
+			level:           LevelInfo, // 默认 INFO 等级
+			colorMode:       true,      // 默认启用颜色
+			format:          LogFormatText,
+			subsystemLevels: make(map[string]int),
 		}
 	})
 	return globalLogger
@@ -138,15 +144,20 @@ func (l *Logger) GetLevelString() string {
 	return levelNames[l.GetLevel()]
 }
 
-// shouldLog 判断是否应该记录该等级的日志
-func (l *Logger) shouldLog(level int) bool {
+// shouldLog 判断是否应该记录该等级的日志，子系统设置了等级覆盖时以覆盖值为准
+func (l *Logger) shouldLog(level int, subsystem string) bool {
 	l.mutex.RLock()
 	defer l.mutex.RUnlock()
+	if subsystem != "" {
+		if override, ok := l.subsystemLevels[subsystem]; ok {
+			return level >= override
+		}
+	}
 	return level >= l.level
 }
 
-// formatMessage 格式化日志消息
-func (l *Logger) formatMessage(level int, format string, v ...any) string {
+// buildEntry 组装日志条目
+func (l *Logger) buildEntry(level int, subsystem string, fields map[string]any, format string, v ...any) LogEntry {
 	// 获取调用者信息
 	_, file, line, ok := runtime.Caller(3)
 	caller := "???"
@@ -154,36 +165,70 @@ func (l *Logger) formatMessage(level int, format string, v ...any) string {
 		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
 	}
 
-	// 时间戳
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	// 等级名称
-	levelName := levelNames[level]
-
 	// 格式化消息
-	var msg string
+	msg := format
 	if len(v) > 0 {
 		msg = fmt.Sprintf(format, v...)
-	} else {
-		msg = format
 	}
 
-	// 组装完整日志行
-	return fmt.Sprintf("%s [%s] [%s] %s", timestamp, levelName, caller, msg)
+	return LogEntry{
+		Time:      time.Now(),
+		Level:     levelNames[level],
+		Subsystem: subsystem,
+		Caller:    caller,
+		Message:   msg,
+		Fields:    fields,
+	}
+}
+
+// formatEntry 按输出格式将日志条目转为一行文本
+func formatEntry(entry LogEntry, format string) string {
+	if format == LogFormatJSON {
+		record := make(map[string]any, len(entry.Fields)+5)
+		for key, value := range entry.Fields {
+			record[key] = value
+		}
+		// 固定字段优先，避免被同名的附加字段覆盖
+		record["time"] = entry.Time.Format(time.RFC3339Nano)
+		record["level"] = entry.Level
+		record["caller"] = entry.Caller
+		record["msg"] = entry.Message
+		if entry.Subsystem != "" {
+			record["subsystem"] = entry.Subsystem
+		}
+		raw, err := json.Marshal(record)
+		if err == nil {
+			return string(raw)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s [%s] [%s] ", entry.Time.Format("2006-01-02 15:04:05"), entry.Level, entry.Caller))
+	if entry.Subsystem != "" {
+		builder.WriteString("[" + entry.Subsystem + "] ")
+	}
+	builder.WriteString(entry.Message)
+	for _, key := range sortedFieldKeys(entry.Fields) {
+		builder.WriteString(fmt.Sprintf(" %s=%v", key, entry.Fields[key]))
+	}
+	return builder.String()
 }
 
-// output 输出日志
-func (l *Logger) output(level int, format string, v ...any) {
-	if !l.shouldLog(level) {
+// output 输出日志，同时写入最近日志缓冲区
+func (l *Logger) output(level int, subsystem string, fields map[string]any, format string, v ...any) {
+	if !l.shouldLog(level, subsystem) {
 		return
 	}
 
-	msg := l.formatMessage(level, format, v...)
+	entry := l.buildEntry(level, subsystem, fields, format, v...)
+	entry = recentLogs.append(entry)
 
 	l.mutex.RLock()
 	logger := l.logger
+	outputFormat := l.format
 	l.mutex.RUnlock()
 
+	msg := formatEntry(entry, outputFormat)
 	if logger != nil {
 		logger.Println(msg)
 	} else {
@@ -194,27 +239,27 @@ func (l *Logger) output(level int, format string, v ...any) {
 
 // Debug 输出调试日志
 func (l *Logger) Debug(format string, v ...any) {
-	l.output(LevelDebug, format, v...)
+	l.output(LevelDebug, "", nil, format, v...)
 }
 
 // Info 输出信息日志
 func (l *Logger) Info(format string, v ...any) {
-	l.output(LevelInfo, format, v...)
+	l.output(LevelInfo, "", nil, format, v...)
 }
 
 // Warn 输出警告日志
 func (l *Logger) Warn(format string, v ...any) {
-	l.output(LevelWarn, format, v...)
+	l.output(LevelWarn, "", nil, format, v...)
 }
 
 // Error 输出错误日志
 func (l *Logger) Error(format string, v ...any) {
-	l.output(LevelError, format, v...)
+	l.output(LevelError, "", nil, format, v...)
 }
 
 // Fatal 输出致命错误日志并退出程序
 func (l *Logger) Fatal(format string, v ...any) {
-	l.output(LevelFatal, format, v...)
+	l.output(LevelFatal, "", nil, format, v...)
 	os.Exit(1)
 }
 
@@ -222,27 +267,27 @@ func (l *Logger) Fatal(format string, v ...any) {
 
 // Debug 输出调试日志
 func Debug(format string, v ...any) {
-	GetLogger().output(LevelDebug, format, v...)
+	GetLogger().output(LevelDebug, "", nil, format, v...)
 }
 
 // Info 输出信息日志
 func Info(format string, v ...any) {
-	GetLogger().output(LevelInfo, format, v...)
+	GetLogger().output(LevelInfo, "", nil, format, v...)
 }
 
 // Warn 输出警告日志
 func Warn(format string, v ...any) {
-	GetLogger().output(LevelWarn, format, v...)
+	GetLogger().output(LevelWarn, "", nil, format, v...)
 }
 
 // Error 输出错误日志
 func Error(format string, v ...any) {
-	GetLogger().output(LevelError, format, v...)
+	GetLogger().output(LevelError, "", nil, format, v...)
 }
 
 // Fatal 输出致命错误日志并退出程序
 func Fatal(format string, v ...any) {
-	GetLogger().output(LevelFatal, format, v...)
+	GetLogger().output(LevelFatal, "", nil, format, v...)
 	os.Exit(1) // output normally doesn't exit for Fatal, but Logger.Fatal does. Wait Logger.Fatal calls output then Exit.
 }
 
@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志输出格式
+const (
+	LogFormatText = "text" // 文本格式（默认）
+	LogFormatJSON = "json" // 每行一个 JSON 对象
+)
+
+// 日志子系统，用于按模块筛选日志与单独设置日志等级
+const (
+	SubsystemAirport   = "airport"   // 机场订阅拉取与流量信息
+	SubsystemSpeedTest = "speedtest" // 节点测速
+	SubsystemUnlock    = "unlock"    // 流媒体解锁检测
+	SubsystemTelegram  = "telegram"  // Telegram 机器人与通知
+	SubsystemScript    = "script"    // 用户脚本执行
+	SubsystemRender    = "render"    // 订阅输出渲染
+	SubsystemConfig    = "config"    // 配置加载与密钥同步
+)
+
+// LogSubsystems 支持单独设置日志等级的子系统
+var LogSubsystems = []string{
+	SubsystemAirport,
+	SubsystemSpeedTest,
+	SubsystemUnlock,
+	SubsystemTelegram,
+	SubsystemScript,
+	SubsystemRender,
+	SubsystemConfig,
+}
+
+// recentLogCapacity 内存中保留的最近日志条数
+const recentLogCapacity = 2000
+
+// LogEntry 一条结构化日志
+type LogEntry struct {
+	Seq       uint64         `json:"seq"`
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Subsystem string         `json:"subsystem,omitempty"`
+	Caller    string         `json:"caller"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// LogQuery 最近日志查询条件，零值条件不参与过滤
+type LogQuery struct {
+	Level     string            // 最低日志等级
+	Subsystem string            // 子系统
+	Keyword   string            // 消息关键字（不区分大小写）
+	Fields    map[string]string // 附加字段需全部相等
+	AfterSeq  uint64            // 仅返回序号大于该值的日志，用于增量拉取
+	Since     time.Time         // 仅返回该时间之后的日志
+	Limit     int               // 最多返回条数，取最新的若干条
+}
+
+// logRingBuffer 固定容量的最近日志环形缓冲区
+type logRingBuffer struct {
+	mutex   sync.RWMutex
+	entries []LogEntry
+	next    int
+	seq     uint64
+}
+
+var recentLogs = &logRingBuffer{entries: make([]LogEntry, 0, recentLogCapacity)}
+
+// append 写入一条日志并分配序号
+func (b *logRingBuffer) append(entry LogEntry) LogEntry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.seq++
+	entry.Seq = b.seq
+	if len(b.entries) < recentLogCapacity {
+		b.entries = append(b.entries, entry)
+	} else {
+		b.entries[b.next] = entry
+	}
+	b.next = (b.next + 1) % recentLogCapacity
+	return entry
+}
+
+// QueryLogs 按条件查询最近日志，结果按时间正序排列
+// 返回值 lastSeq 为当前最新日志序号，前端可作为下次增量查询的 AfterSeq。
+func QueryLogs(query LogQuery) (entries []LogEntry, lastSeq uint64) {
+	minLevel := -1
+	if query.Level != "" {
+		minLevel = ParseLogLevel(query.Level)
+	}
+	keyword := strings.ToLower(strings.TrimSpace(query.Keyword))
+
+	recentLogs.mutex.RLock()
+	defer recentLogs.mutex.RUnlock()
+
+	// 从最新的日志开始向前遍历，满足条数后停止
+	total := len(recentLogs.entries)
+	for i := 0; i < total; i++ {
+		entry := recentLogs.entries[(recentLogs.next-1-i+total)%total]
+		if entry.Seq <= query.AfterSeq || (!query.Since.IsZero() && entry.Time.Before(query.Since)) {
+			break
+		}
+		if minLevel >= 0 && ParseLogLevel(entry.Level) < minLevel {
+			continue
+		}
+		if query.Subsystem != "" && entry.Subsystem != query.Subsystem {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(entry.Message), keyword) {
+			continue
+		}
+		if !matchLogFields(entry.Fields, query.Fields) {
+			continue
+		}
+		entries = append(entries, entry)
+		if query.Limit > 0 && len(entries) >= query.Limit {
+			break
+		}
+	}
+
+	// 反转为时间正序
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, recentLogs.seq
+}
+
+// matchLogFields 判断日志附加字段是否满足全部筛选条件
+func matchLogFields(fields map[string]any, want map[string]string) bool {
+	for key, value := range want {
+		actual, ok := fields[key]
+		if !ok || fmt.Sprint(actual) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedFieldKeys 返回排序后的字段名，保证文本输出顺序稳定
+func sortedFieldKeys(fields map[string]any) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsLogSubsystem 判断是否为支持的日志子系统
+func IsLogSubsystem(subsystem string) bool {
+	for _, item := range LogSubsystems {
+		if item == subsystem {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLogFormat 设置日志输出格式，无效值按 text 处理
+func SetLogFormat(format string) {
+	logger := GetLogger()
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	if strings.ToLower(strings.TrimSpace(format)) == LogFormatJSON {
+		logger.format = LogFormatJSON
+	} else {
+		logger.format = LogFormatText
+	}
+}
+
+// GetLogFormat 获取日志输出格式
+func GetLogFormat() string {
+	logger := GetLogger()
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	return logger.format
+}
+
+// SetSubsystemLevel 设置子系统日志等级，level 为空时取消覆盖、恢复使用全局等级
+func SetSubsystemLevel(subsystem, level string) error {
+	if !IsLogSubsystem(subsystem) {
+		return fmt.Errorf("未知的日志子系统: %s", subsystem)
+	}
+	logger := GetLogger()
+	level = strings.ToLower(strings.TrimSpace(level))
+	if level == "" {
+		logger.mutex.Lock()
+		delete(logger.subsystemLevels, subsystem)
+		logger.mutex.Unlock()
+		return nil
+	}
+	lvl, ok := levelFromString[level]
+	if !ok {
+		return fmt.Errorf("无效的日志等级: %s", level)
+	}
+	logger.mutex.Lock()
+	logger.subsystemLevels[subsystem] = lvl
+	logger.mutex.Unlock()
+	return nil
+}
+
+// SubsystemLevels 获取已设置覆盖的子系统日志等级，等级使用小写名称
+func SubsystemLevels() map[string]string {
+	logger := GetLogger()
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	levels := make(map[string]string, len(logger.subsystemLevels))
+	for subsystem, lvl := range logger.subsystemLevels {
+		levels[subsystem] = strings.ToLower(levelNames[lvl])
+	}
+	return levels
+}
+
+// ResetSubsystemLevels 清除全部子系统日志等级覆盖
+func ResetSubsystemLevels() {
+	logger := GetLogger()
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.subsystemLevels = make(map[string]int)
+}
+
+// SubsystemLogger 带子系统与附加字段的日志记录器
+type SubsystemLogger struct {
+	subsystem string
+	fields    map[string]any
+}
+
+// WithSubsystem 创建指定子系统的日志记录器
+func WithSubsystem(subsystem string) SubsystemLogger {
+	return SubsystemLogger{subsystem: subsystem}
+}
+
+// With 返回附加了字段的新记录器，原记录器不受影响
+func (s SubsystemLogger) With(key string, value any) SubsystemLogger {
+	fields := make(map[string]any, len(s.fields)+1)
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return SubsystemLogger{subsystem: s.subsystem, fields: fields}
+}
+
+// Debug 输出调试日志
+func (s SubsystemLogger) Debug(format string, v ...any) {
+	GetLogger().output(LevelDebug, s.subsystem, s.fields, format, v...)
+}
+
+// Info 输出信息日志
+func (s SubsystemLogger) Info(format string, v ...any) {
+	GetLogger().output(LevelInfo, s.subsystem, s.fields, format, v...)
+}
+
+// Warn 输出警告日志
+func (s SubsystemLogger) Warn(format string, v ...any) {
+	GetLogger().output(LevelWarn, s.subsystem, s.fields, format, v...)
+}
+
+// Error 输出错误日志
+func (s SubsystemLogger) Error(format string, v ...any) {
+	GetLogger().output(LevelError, s.subsystem, s.fields, format, v...)
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubsystemLevelOverride(t *testing.T) {
+	SetLogLevel("info")
+	t.Cleanup(ResetSubsystemLevels)
+
+	if err := SetSubsystemLevel(SubsystemAirport, "debug"); err != nil {
+		t.Fatalf("set airport level: %v", err)
+	}
+	if err := SetSubsystemLevel(SubsystemRender, "error"); err != nil {
+		t.Fatalf("set render level: %v", err)
+	}
+	WithSubsystem(SubsystemAirport).Debug("override-debug airport")
+	WithSubsystem(SubsystemSpeedTest).Debug("override-debug speedtest")
+	WithSubsystem(SubsystemRender).Warn("override-debug render")
+
+	entries, _ := QueryLogs(LogQuery{Keyword: "override-debug"})
+	if len(entries) != 1 || entries[0].Subsystem != SubsystemAirport {
+		t.Fatalf("expected only the airport debug entry, got %+v", entries)
+	}
+
+	if err := SetSubsystemLevel(SubsystemAirport, ""); err != nil {
+		t.Fatalf("clear airport level: %v", err)
+	}
+	if levels := SubsystemLevels(); len(levels) != 1 || levels[SubsystemRender] != "error" {
+		t.Fatalf("unexpected overrides after clearing airport: %v", levels)
+	}
+	if err := SetSubsystemLevel("unknown", "debug"); err == nil {
+		t.Fatal("expected unknown subsystem to be rejected")
+	}
+	if err := SetSubsystemLevel(SubsystemAirport, "verbose"); err == nil {
+		t.Fatal("expected invalid level to be rejected")
+	}
+}
+
+func TestQueryLogsFilters(t *testing.T) {
+	SetLogLevel("info")
+	_, before := QueryLogs(LogQuery{Limit: 1})
+
+	airport := WithSubsystem(SubsystemAirport).With("airport_id", 7)
+	airport.Info("query-filter pull started")
+	airport.Error("query-filter pull failed: timeout")
+	WithSubsystem(SubsystemAirport).With("airport_id", 8).Error("query-filter pull failed: 403")
+	Info("query-filter global message")
+
+	entries, lastSeq := QueryLogs(LogQuery{AfterSeq: before, Subsystem: SubsystemAirport, Level: "error", Fields: map[string]string{"airport_id": "7"}})
+	if len(entries) != 1 || entries[0].Message != "query-filter pull failed: timeout" {
+		t.Fatalf("expected the airport 7 error, got %+v", entries)
+	}
+	if lastSeq != before+4 {
+		t.Fatalf("expected lastSeq %d, got %d", before+4, lastSeq)
+	}
+
+	entries, _ = QueryLogs(LogQuery{AfterSeq: before, Keyword: "QUERY-FILTER", Limit: 2})
+	if len(entries) != 2 || entries[0].Seq >= entries[1].Seq || entries[1].Message != "query-filter global message" {
+		t.Fatalf("expected the latest two entries in ascending order, got %+v", entries)
+	}
+
+	if entries, _ = QueryLogs(LogQuery{AfterSeq: lastSeq}); len(entries) != 0 {
+		t.Fatalf("expected no entries after lastSeq, got %+v", entries)
+	}
+}
+
+func TestRecentLogsKeepsLatestEntries(t *testing.T) {
+	SetLogLevel("info")
+	logger := WithSubsystem(SubsystemScript)
+	for i := 0; i < recentLogCapacity+10; i++ {
+		logger.Info("ring-buffer entry %d", i)
+	}
+
+	entries, _ := QueryLogs(LogQuery{Keyword: "ring-buffer entry"})
+	if len(entries) != recentLogCapacity {
+		t.Fatalf("expected %d entries, got %d", recentLogCapacity, len(entries))
+	}
+	if entries[0].Message != "ring-buffer entry 10" {
+		t.Fatalf("expected oldest kept entry to be #10, got %q", entries[0].Message)
+	}
+}
+
+func TestFormatEntryJSON(t *testing.T) {
+	entry := LogEntry{
+		Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:     "WARN",
+		Subsystem: SubsystemTelegram,
+		Caller:    "telegram.go:10",
+		Message:   "send failed",
+		Fields:    map[string]any{"chat_id": 42, "msg": "shadowed"},
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(formatEntry(entry, LogFormatJSON)), &record); err != nil {
+		t.Fatalf("expected valid JSON: %v", err)
+	}
+	if record["msg"] != "send failed" || record["subsystem"] != SubsystemTelegram || record["chat_id"] != float64(42) {
+		t.Fatalf("unexpected JSON record: %v", record)
+	}
+
+	text := formatEntry(entry, LogFormatText)
+	if !strings.HasPrefix(text, "2026-01-02 03:04:05 [WARN] [telegram.go:10] [telegram] send failed") || !strings.HasSuffix(text, "chat_id=42 msg=shadowed") {
+		t.Fatalf("unexpected text line: %q", text)
+	}
+}
@@ -3,22 +3,34 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dop251/goja"
 )
 
+// scriptConsole 构造脚本中的 console 对象，输出写入 script 子系统日志
+func scriptConsole(clientType string) map[string]any {
+	logger := WithSubsystem(SubsystemScript).With("client", clientType)
+	printer := func(output func(format string, v ...any)) func(args ...any) {
+		return func(args ...any) {
+			output("%s", strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+		}
+	}
+	return map[string]any{
+		"log":   printer(logger.Info),
+		"info":  printer(logger.Info),
+		"warn":  printer(logger.Warn),
+		"error": printer(logger.Error),
+	}
+}
+
 // RunScript executes a JavaScript script with the given input and client type.
 // The script is expected to define a function `main(node, clientType)` that returns a string.
 func RunScript(scriptContent string, input string, clientType string) (string, error) {
 	vm := goja.New()
 
 	// Inject console object
-	_ = vm.Set("console", map[string]any{
-		"log":   fmt.Println,
-		"info":  fmt.Println,
-		"warn":  fmt.Println,
-		"error": fmt.Println,
-	})
+	_ = vm.Set("console", scriptConsole(clientType))
 
 	// Inject polyfills
 	_, err := vm.RunString(polyfills)
@@ -53,12 +65,7 @@ func RunNodeFilterScript(scriptContent string, nodesJSON []byte, clientType stri
 	vm := goja.New()
 
 	// Inject console object
-	_ = vm.Set("console", map[string]any{
-		"log":   fmt.Println,
-		"info":  fmt.Println,
-		"warn":  fmt.Println,
-		"error": fmt.Println,
-	})
+	_ = vm.Set("console", scriptConsole(clientType))
 
 	// Inject polyfills
 	_, err := vm.RunString(polyfills)
@@ -27,3 +27,11 @@ export function deleteAccessKey(id) {
     method: 'delete'
   });
 }
+
+// 获取可授予的权限范围
+export function getAccessKeyScopes() {
+  return request({
+    url: '/v1/accesskey/scopes',
+    method: 'get'
+  });
+}
@@ -0,0 +1,35 @@
+import request from './request';
+
+// 获取审计日志列表（仅管理员）
+export function getAuditLogs(params) {
+  return request({
+    url: '/v1/audit/logs',
+    method: 'get',
+    params
+  });
+}
+
+// 获取审计日志筛选项
+export function getAuditLogOptions() {
+  return request({
+    url: '/v1/audit/logs/options',
+    method: 'get'
+  });
+}
+
+// 获取审计日志设置
+export function getAuditSettings() {
+  return request({
+    url: '/v1/audit/settings',
+    method: 'get'
+  });
+}
+
+// 更新审计日志保留天数
+export function updateAuditSettings(data) {
+  return request({
+    url: '/v1/audit/settings',
+    method: 'post',
+    data
+  });
+}
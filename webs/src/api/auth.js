@@ -39,6 +39,16 @@ export function login(data) {
 }
 
 export function verifyMfaLogin(data) {
+  if (data.type === 'passkey') {
+    return request({
+      url: '/v1/auth/mfa/verify-passkey',
+      method: 'post',
+      data: {
+        challengeToken: data.challengeToken,
+        credential: data.credential
+      }
+    });
+  }
   const isRecoveryCode = data.type === 'recovery_code';
   return request({
     url: isRecoveryCode ? '/v1/auth/mfa/verify-recovery-code' : '/v1/auth/mfa/verify-totp',
@@ -55,6 +65,48 @@ export function verifyMfaLogin(data) {
   });
 }
 
+// 开始通行密钥二次验证
+export function beginPasskeyMfa(data) {
+  return request({
+    url: '/v1/auth/mfa/passkey/begin',
+    method: 'post',
+    data
+  });
+}
+
+// 获取通行密钥登录配置
+export function getPasskeyStatus() {
+  return request({
+    url: '/v1/auth/passkey',
+    method: 'get'
+  });
+}
+
+// 通行密钥免密码登录
+export function beginPasskeyLogin() {
+  return request({
+    url: '/v1/auth/passkey/begin',
+    method: 'post',
+    data: {}
+  });
+}
+
+export function finishPasskeyLogin(data) {
+  return request({
+    url: '/v1/auth/passkey/finish',
+    method: 'post',
+    data
+  });
+}
+
+// 获取单点登录配置
+export function getOIDCStatus() {
+  return request({
+    url: '/v1/auth/oidc',
+    method: 'get'
+  });
+}
+
 // 登出
 export function logout() {
   return request({
@@ -125,3 +177,34 @@ export function regenerateRecoveryCodes(data = {}) {
     data
   });
 }
+
+export function getPasskeys() {
+  return request({
+    url: '/v1/users/mfa/passkeys',
+    method: 'get'
+  });
+}
+
+export function beginPasskeyRegistration(data) {
+  return request({
+    url: '/v1/users/mfa/passkeys/begin',
+    method: 'post',
+    data
+  });
+}
+
+export function finishPasskeyRegistration(data) {
+  return request({
+    url: '/v1/users/mfa/passkeys/finish',
+    method: 'post',
+    data
+  });
+}
+
+export function deletePasskey(data) {
+  return request({
+    url: '/v1/users/mfa/passkeys/delete',
+    method: 'post',
+    data
+  });
+}
@@ -0,0 +1,80 @@
+import request from './request';
+
+// 获取自动备份配置
+export function getBackupSettings() {
+  return request({
+    url: '/v1/backup/settings',
+    method: 'get'
+  });
+}
+
+// 保存自动备份配置
+export function updateBackupSettings(data) {
+  return request({
+    url: '/v1/backup/settings',
+    method: 'post',
+    data
+  });
+}
+
+// 测试 S3 连接
+export function testBackupS3(data) {
+  return request({
+    url: '/v1/backup/s3/test',
+    method: 'post',
+    data
+  });
+}
+
+// 立即执行一次备份
+export function runBackup() {
+  return request({
+    url: '/v1/backup/run',
+    method: 'post',
+    timeout: 10 * 60 * 1000
+  });
+}
+
+// 获取本地备份列表
+export function getBackupList() {
+  return request({
+    url: '/v1/backup/list',
+    method: 'get'
+  });
+}
+
+// 下载本地备份文件
+export function downloadBackupFile(name) {
+  return request({
+    url: `/v1/backup/files/${encodeURIComponent(name)}`,
+    method: 'get',
+    responseType: 'blob',
+    timeout: 10 * 60 * 1000
+  });
+}
+
+// 恢复备份：formData 包含上传的 file，或本地备份文件名 name
+export function restoreBackup(formData) {
+  return request({
+    url: '/v1/backup/restore',
+    method: 'post',
+    data: formData,
+    timeout: 10 * 60 * 1000,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  });
+}
+
+// 校验加密备份的密码：formData 包含 passphrase，以及上传的 file 或本地备份文件名 name
+export function checkBackupKey(formData) {
+  return request({
+    url: '/v1/backup/check-key',
+    method: 'post',
+    data: formData,
+    timeout: 10 * 60 * 1000,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  });
+}
@@ -0,0 +1,39 @@
+import request from './request';
+
+// 导出声明式配置 YAML
+export function exportConfiguration() {
+  return request({
+    url: '/v1/gitops/export',
+    method: 'get',
+    responseType: 'blob',
+    timeout: 60 * 1000
+  });
+}
+
+// 计算应用配置文档的变更计划：formData 包含上传的 file
+export function planConfiguration(formData, prune) {
+  return request({
+    url: '/v1/gitops/plan',
+    method: 'post',
+    params: { prune },
+    data: formData,
+    timeout: 60 * 1000,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  });
+}
+
+// 应用配置文档：formData 包含上传的 file
+export function applyConfiguration(formData, prune) {
+  return request({
+    url: '/v1/gitops/apply',
+    method: 'post',
+    params: { prune },
+    data: formData,
+    timeout: 60 * 1000,
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  });
+}
@@ -0,0 +1,27 @@
+import request from './request';
+
+// 查询最近运行日志（仅管理员）
+export function getRecentLogs(params) {
+  return request({
+    url: '/v1/logs',
+    method: 'get',
+    params
+  });
+}
+
+// 获取全局与各子系统日志等级
+export function getLogLevels() {
+  return request({
+    url: '/v1/logs/levels',
+    method: 'get'
+  });
+}
+
+// 设置子系统日志等级，level 为空时恢复使用全局等级
+export function updateLogLevel(data) {
+  return request({
+    url: '/v1/logs/levels',
+    method: 'post',
+    data
+  });
+}
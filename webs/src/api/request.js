@@ -9,6 +9,13 @@ function resolveResponseMessage(data, fallbackKey, fallbackText) {
   return data?.msg || i18n.t(fallbackKey, fallbackText);
 }
 
+// 角色权限不足返回 403，但登录仍然有效，不应清除 token
+const ROLE_FORBIDDEN_I18N_KEY = 'backend.auth.role.forbidden';
+
+function isRoleForbidden(data) {
+  return data?.i18nKey === ROLE_FORBIDDEN_I18N_KEY;
+}
+
 function isAnonymousAuthRequest(config = {}) {
   const requestUrl = config.url || '';
   const hasAuthHeader = Boolean(config.headers?.Authorization || config.headers?.authorization);
@@ -57,9 +64,14 @@ request.interceptors.response.use(
   },
   (error) => {
     if (error.response) {
-      const { status } = error.response;
+      const { status, data } = error.response;
       const requestConfig = error.config || {};
 
+      if (status === 403 && isRoleForbidden(data)) {
+        error.message = i18n.t('auth.fallback.roleForbidden', data?.msg || '当前角色无权执行此操作');
+        return Promise.reject(error);
+      }
+
       // 401/403 - 清除 token 并跳转登录
       if ((status === 401 || status === 403) && !isAnonymousAuthRequest(requestConfig)) {
         if (status === 403) {
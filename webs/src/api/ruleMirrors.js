@@ -0,0 +1,36 @@
+import request from './request';
+
+// 获取规则镜像设置与列表
+export const getRuleMirrors = () => {
+  return request({
+    url: '/v1/rule-mirrors/list',
+    method: 'get'
+  });
+};
+
+// 保存规则镜像设置
+export const saveRuleMirrorSettings = (data) => {
+  return request({
+    url: '/v1/rule-mirrors/settings',
+    method: 'post',
+    data
+  });
+};
+
+// 刷新规则镜像（指定 id 时只刷新单个镜像）
+export const refreshRuleMirrors = (id) => {
+  return request({
+    url: '/v1/rule-mirrors/refresh',
+    method: 'post',
+    data: id ? { id } : {}
+  });
+};
+
+// 启用或停用单个规则镜像
+export const setRuleMirrorEnabled = (id, enabled) => {
+  return request({
+    url: '/v1/rule-mirrors/enabled',
+    method: 'post',
+    data: { id, enabled }
+  });
+};
@@ -207,3 +207,23 @@ export function importDatabaseMigration(formData) {
     }
   });
 }
+
+// 校验跨数据库迁移的源库与目标库
+export function checkDatabaseCopy(data) {
+  return request({
+    url: '/v1/settings/database-migration/copy/check',
+    method: 'post',
+    data,
+    timeout: 60 * 1000
+  });
+}
+
+// 启动跨数据库迁移任务
+export function startDatabaseCopy(data) {
+  return request({
+    url: '/v1/settings/database-migration/copy',
+    method: 'post',
+    data,
+    timeout: 60 * 1000
+  });
+}
@@ -83,6 +83,18 @@ export function getShareLogs(shareId) {
   });
 }
 
+/**
+ * 获取分享访问限制的当前窗口与历史窗口统计
+ * @param {number} shareId 分享ID
+ */
+export function getShareAccessUsage(shareId) {
+  return request({
+    url: '/v1/shares/access-usage',
+    method: 'get',
+    params: { shareId }
+  });
+}
+
 /**
  * 刷新分享Token
  * @param {number} id 分享ID
@@ -143,3 +155,98 @@ export function batchUpdateShares(ids, updates) {
     }
   });
 }
+
+/**
+ * 为分享生成临时签名链接
+ * @param {object} data { id, expire_hours, client?, ip? }
+ */
+export function signShare(data) {
+  return request({
+    url: '/v1/shares/sign',
+    method: 'post',
+    data
+  });
+}
+
+/**
+ * 更换签名密钥，已签发的签名链接全部失效
+ */
+export function rotateShareSignKey() {
+  return request({
+    url: '/v1/shares/sign-key/rotate',
+    method: 'post'
+  });
+}
+
+/**
+ * 获取分享绑定的设备
+ * @param {number} shareId 分享ID
+ */
+export function getShareDevices(shareId) {
+  return request({
+    url: '/v1/shares/devices',
+    method: 'get',
+    params: { shareId }
+  });
+}
+
+/**
+ * 解除分享的设备绑定
+ * @param {number} shareId 分享ID
+ * @param {number} deviceId 设备ID，为 0 时解除全部
+ */
+export function resetShareDevices(shareId, deviceId = 0) {
+  return request({
+    url: '/v1/shares/devices/reset',
+    method: 'post',
+    data: { share_id: shareId, device_id: deviceId }
+  });
+}
+
+/**
+ * 获取订阅下各分享分摊后的用量
+ * @param {number} subId 订阅ID
+ */
+export function getShareUsage(subId) {
+  return request({
+    url: '/v1/shares/usage',
+    method: 'get',
+    params: { subId }
+  });
+}
+
+/**
+ * 获取分享每日访问汇总
+ * @param {object} params { subId?, shareId?, days? }
+ */
+export function getShareAnalyticsDaily(params) {
+  return request({
+    url: '/v1/shares/analytics/daily',
+    method: 'get',
+    params
+  });
+}
+
+/**
+ * 获取访问最多的客户端
+ * @param {object} params { subId?, shareId?, days?, limit? }
+ */
+export function getShareAnalyticsClients(params) {
+  return request({
+    url: '/v1/shares/analytics/clients',
+    method: 'get',
+    params
+  });
+}
+
+/**
+ * 获取异常访问列表
+ * @param {object} params { subId?, shareId?, days? }
+ */
+export function getShareAnalyticsUnusual(params) {
+  return request({
+    url: '/v1/shares/analytics/unusual',
+    method: 'get',
+    params
+  });
+}
@@ -120,6 +120,16 @@ export function previewSubscriptionNodes(data) {
   });
 }
 
+// 使用 mihomo 校验订阅最终输出的 Clash 配置
+// params: { shareId }
+export function validateSubscriptionConfig(subId, params) {
+  return request({
+    url: `/v1/subcription/${subId}/validate-config`,
+    method: 'get',
+    params
+  });
+}
+
 // 获取协议元数据（协议列表及其可用字段）
 export function getProtocolMeta() {
   return request({
@@ -291,6 +291,15 @@ export function getTemplateUsage(params) {
   });
 }
 
+// 预览模板变量与条件区块的渲染结果
+export function renderTemplatePreview(params) {
+  return request({
+    url: '/v1/template/render',
+    method: 'get',
+    params
+  });
+}
+
 // 获取 ACL4SSR 规则预设列表
 export function getACL4SSRPresets() {
   return request({
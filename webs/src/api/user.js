@@ -25,3 +25,81 @@ export function updateProfile(data) {
     data
   });
 }
+
+// 获取用户列表（仅管理员）
+export function getUsers() {
+  return request({
+    url: '/v1/users/page',
+    method: 'get'
+  });
+}
+
+// 创建用户（仅管理员）
+export function createUser(data) {
+  return request({
+    url: '/v1/users/create',
+    method: 'post',
+    data
+  });
+}
+
+// 修改用户角色（仅管理员）
+export function updateUserRole(id, role) {
+  return request({
+    url: '/v1/users/role',
+    method: 'post',
+    data: { id, role }
+  });
+}
+
+// 重置用户密码（仅管理员）
+export function resetUserPassword(id, password) {
+  return request({
+    url: '/v1/users/reset-password',
+    method: 'post',
+    data: { id, password }
+  });
+}
+
+// 删除用户（仅管理员）
+export function deleteUser(id) {
+  return request({
+    url: '/v1/users/delete',
+    method: 'post',
+    data: { id }
+  });
+}
+
+// 获取当前用户的登录会话
+export function getSessions() {
+  return request({
+    url: '/v1/users/sessions',
+    method: 'get'
+  });
+}
+
+// 注销指定登录会话
+export function revokeSession(id) {
+  return request({
+    url: '/v1/users/sessions/revoke',
+    method: 'post',
+    data: { id }
+  });
+}
+
+// 退出所有设备，keepCurrent 为 true 时保留当前会话
+export function revokeAllSessions(keepCurrent) {
+  return request({
+    url: '/v1/users/sessions/revoke-all',
+    method: 'post',
+    data: { keepCurrent }
+  });
+}
+
+// 获取关联单点登录身份的跳转地址
+export function getOIDCLinkURL() {
+  return request({
+    url: '/v1/users/oidc/link',
+    method: 'post'
+  });
+}
@@ -14,6 +14,8 @@ import ClickAwayListener from '@mui/material/ClickAwayListener';
 import SpeedIcon from '@mui/icons-material/Speed';
 import CloudSyncIcon from '@mui/icons-material/CloudSync';
 import LocalOfferIcon from '@mui/icons-material/LocalOffer';
+import RestoreIcon from '@mui/icons-material/Restore';
+import CompareArrowsIcon from '@mui/icons-material/CompareArrows';
 import StorageIcon from '@mui/icons-material/Storage';
 import CheckCircleIcon from '@mui/icons-material/CheckCircle';
 import ErrorIcon from '@mui/icons-material/Error';
@@ -138,7 +140,9 @@ const FabTaskItem = ({ task, currentTime, theme, tokens }) => {
       speed_test: SpeedIcon,
       sub_update: CloudSyncIcon,
       tag_rule: LocalOfferIcon,
-      db_migration: StorageIcon
+      db_migration: StorageIcon,
+      backup_restore: RestoreIcon,
+      db_copy: CompareArrowsIcon
     };
 
     return {
@@ -208,6 +212,22 @@ const FabTaskItem = ({ task, currentTime, theme, tokens }) => {
       if (warnings > 0) return t('tasks.result.warnings', { count: warnings });
     }
 
+    if (task.taskType === 'backup_restore') {
+      const restoredKinds = Object.values(task.result.restored || {}).filter((count) => Number(count) > 0).length;
+      const warnings = task.result.warnings?.length || 0;
+      return warnings > 0
+        ? `${t('tasks.result.importedKinds', { count: restoredKinds })} · ${t('tasks.result.warnings', { count: warnings })}`
+        : t('tasks.result.importedKinds', { count: restoredKinds });
+    }
+
+    if (task.taskType === 'db_copy') {
+      const copiedKinds = Object.values(task.result.copied || {}).filter((count) => Number(count) > 0).length;
+      const warnings = task.result.warnings?.length || 0;
+      return warnings > 0
+        ? `${t('tasks.result.importedKinds', { count: copiedKinds })} · ${t('tasks.result.warnings', { count: warnings })}`
+        : t('tasks.result.importedKinds', { count: copiedKinds });
+    }
+
     return null;
   }, [task.result, task.taskType, t]);
 
@@ -497,6 +517,12 @@ const TaskProgressFab = () => {
     if (taskList.some((task) => task.taskType === 'db_migration' && task.status !== 'completed' && task.status !== 'error')) {
       return StorageIcon;
     }
+    if (taskList.some((task) => task.taskType === 'backup_restore' && task.status !== 'completed' && task.status !== 'error')) {
+      return RestoreIcon;
+    }
+    if (taskList.some((task) => task.taskType === 'db_copy' && task.status !== 'completed' && task.status !== 'error')) {
+      return CompareArrowsIcon;
+    }
     return CloudSyncIcon;
   }, [taskList]);
 
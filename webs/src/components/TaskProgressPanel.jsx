@@ -14,6 +14,8 @@ import Tooltip from '@mui/material/Tooltip';
 import SpeedIcon from '@mui/icons-material/Speed';
 import CloudSyncIcon from '@mui/icons-material/CloudSync';
 import LocalOfferIcon from '@mui/icons-material/LocalOffer';
+import RestoreIcon from '@mui/icons-material/Restore';
+import CompareArrowsIcon from '@mui/icons-material/CompareArrows';
 import StorageIcon from '@mui/icons-material/Storage';
 import CheckCircleIcon from '@mui/icons-material/CheckCircle';
 import ErrorIcon from '@mui/icons-material/Error';
@@ -73,7 +75,9 @@ const TaskProgressItem = ({ task, currentTime, onStopTask, isStopping }) => {
       speed_test: SpeedIcon,
       sub_update: CloudSyncIcon,
       tag_rule: LocalOfferIcon,
-      db_migration: StorageIcon
+      db_migration: StorageIcon,
+      backup_restore: RestoreIcon,
+      db_copy: CompareArrowsIcon
     };
 
     return {
@@ -172,6 +176,22 @@ const TaskProgressItem = ({ task, currentTime, onStopTask, isStopping }) => {
       }
     }
 
+    if (task.taskType === 'backup_restore') {
+      const restoredKinds = Object.values(task.result.restored || {}).filter((count) => Number(count) > 0).length;
+      const warnings = task.result.warnings?.length || 0;
+      return warnings > 0
+        ? `${t('tasks.result.importedKinds', { count: restoredKinds })} · ${t('tasks.result.warnings', { count: warnings })}`
+        : t('tasks.result.importedKinds', { count: restoredKinds });
+    }
+
+    if (task.taskType === 'db_copy') {
+      const copiedKinds = Object.values(task.result.copied || {}).filter((count) => Number(count) > 0).length;
+      const warnings = task.result.warnings?.length || 0;
+      return warnings > 0
+        ? `${t('tasks.result.importedKinds', { count: copiedKinds })} · ${t('tasks.result.warnings', { count: warnings })}`
+        : t('tasks.result.importedKinds', { count: copiedKinds });
+    }
+
     return unlockText;
   }, [task.result, task.taskType, t]);
 
@@ -7,7 +7,9 @@ export const TASK_TYPE_META = {
   speed_test: { label: 'Speed test', labelKey: 'tasks.type.speedTest', color: '#10b981' },
   sub_update: { label: 'Subscription update', labelKey: 'tasks.type.subUpdate', color: '#6366f1' },
   tag_rule: { label: 'Tag rule', labelKey: 'tasks.type.tagRule', color: '#f59e0b' },
-  db_migration: { label: 'Database migration', labelKey: 'tasks.type.dbMigration', color: '#0284c7' }
+  db_migration: { label: 'Database migration', labelKey: 'tasks.type.dbMigration', color: '#0284c7' },
+  backup_restore: { label: 'Backup restore', labelKey: 'tasks.type.backupRestore', color: '#e11d48' },
+  db_copy: { label: 'Database copy', labelKey: 'tasks.type.dbCopy', color: '#7c3aed' }
 };
 
 export const TASK_TRIGGER_META = {
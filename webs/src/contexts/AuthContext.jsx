@@ -4,7 +4,16 @@ import { useNavigate } from 'react-router-dom';
 import i18n from 'i18n';
 
 // API imports
-import { login as loginApi, logout as logoutApi, getUserInfo, verifyMfaLogin } from 'api/auth';
+import {
+  login as loginApi,
+  logout as logoutApi,
+  getUserInfo,
+  verifyMfaLogin,
+  beginPasskeyMfa,
+  beginPasskeyLogin,
+  finishPasskeyLogin
+} from 'api/auth';
+import { getPasskeyAssertion } from 'utils/webauthn';
 
 const REMEMBERED_USERNAME_KEY = 'sublink_remembered_username';
 
@@ -389,10 +398,16 @@ export function AuthProvider({ children }) {
 
   const verifyMfa = async ({ challengeToken, code, recoveryCode, type = 'totp', rememberMe = false, username = '' }) => {
     try {
+      let credential;
+      if (type === 'passkey') {
+        const optionsResponse = await beginPasskeyMfa({ challengeToken });
+        credential = await getPasskeyAssertion(optionsResponse.data.options);
+      }
       const response = await verifyMfaLogin({
         challengeToken,
         code,
         recoveryCode,
+        credential,
         type
       });
 
@@ -416,6 +431,50 @@ export function AuthProvider({ children }) {
     }
   };
 
+  // 单点登录 - 使用回调返回的 token 完成登录，不影响记住的用户名
+  const loginWithToken = async (accessToken) => {
+    try {
+      localStorage.setItem('accessToken', `Bearer ${accessToken}`);
+      const userResponse = await getUserInfo();
+      setUser(userResponse.data);
+      setIsAuthenticated(true);
+      connectSSE();
+      return { success: true };
+    } catch (error) {
+      console.error('单点登录失败:', error);
+      localStorage.removeItem('accessToken');
+      setIsAuthenticated(false);
+      return {
+        success: false,
+        message: error.message || i18n.t('auth.sso.failedDefault', '单点登录失败，请稍后重试')
+      };
+    }
+  };
+
+  // 通行密钥免密码登录
+  const loginWithPasskey = async () => {
+    try {
+      const optionsResponse = await beginPasskeyLogin();
+      const { challengeToken, options } = optionsResponse.data;
+      const credential = await getPasskeyAssertion(options);
+      const response = await finishPasskeyLogin({ challengeToken, credential });
+      const tokenPayload = normalizeTokenPayload(response);
+      if (!tokenPayload) {
+        return {
+          success: false,
+          message: i18n.t('auth.login.noToken', '登录响应缺少访问令牌，请稍后重试')
+        };
+      }
+      return loginWithToken(tokenPayload.accessToken);
+    } catch (error) {
+      console.error('通行密钥登录失败:', error);
+      return {
+        success: false,
+        message: error.message || i18n.t('auth.passkey.failedDefault', '通行密钥验证失败，请重试')
+      };
+    }
+  };
+
   // 登出
   const logout = async () => {
     try {
@@ -448,6 +507,8 @@ export function AuthProvider({ children }) {
       notifications,
       login,
       verifyMfa,
+      loginWithToken,
+      loginWithPasskey,
       logout,
       rememberedUsernameKey: REMEMBERED_USERNAME_KEY,
       clearNotification,
@@ -0,0 +1,83 @@
+// WebAuthn 工具：后端以 base64url 传输二进制字段，浏览器 API 需要 ArrayBuffer
+
+function base64UrlToBuffer(value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64 + '='.repeat((4 - (base64.length % 4)) % 4);
+  const binary = window.atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i += 1) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+function bufferToBase64Url(buffer) {
+  if (!buffer) {
+    return undefined;
+  }
+  const bytes = new Uint8Array(buffer);
+  let binary = '';
+  for (let i = 0; i < bytes.length; i += 1) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return window.btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+function decodeDescriptors(descriptors) {
+  return (descriptors || []).map((descriptor) => ({ ...descriptor, id: base64UrlToBuffer(descriptor.id) }));
+}
+
+// 浏览器是否支持通行密钥（需要 HTTPS 或 localhost）
+export function isPasskeySupported() {
+  return typeof window !== 'undefined' && Boolean(window.PublicKeyCredential) && window.isSecureContext;
+}
+
+// 调用认证器创建通行密钥，返回可直接提交给后端的 JSON
+export async function createPasskeyCredential(options) {
+  const publicKey = options.publicKey;
+  const credential = await navigator.credentials.create({
+    publicKey: {
+      ...publicKey,
+      challenge: base64UrlToBuffer(publicKey.challenge),
+      user: { ...publicKey.user, id: base64UrlToBuffer(publicKey.user.id) },
+      excludeCredentials: decodeDescriptors(publicKey.excludeCredentials)
+    }
+  });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64Url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+      attestationObject: bufferToBase64Url(credential.response.attestationObject),
+      transports: credential.response.getTransports ? credential.response.getTransports() : []
+    },
+    clientExtensionResults: credential.getClientExtensionResults()
+  };
+}
+
+// 调用认证器签名断言，返回可直接提交给后端的 JSON
+export async function getPasskeyAssertion(options) {
+  const publicKey = options.publicKey;
+  const credential = await navigator.credentials.get({
+    publicKey: {
+      ...publicKey,
+      challenge: base64UrlToBuffer(publicKey.challenge),
+      allowCredentials: decodeDescriptors(publicKey.allowCredentials)
+    }
+  });
+
+  return {
+    id: credential.id,
+    rawId: bufferToBase64Url(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+      authenticatorData: bufferToBase64Url(credential.response.authenticatorData),
+      signature: bufferToBase64Url(credential.response.signature),
+      userHandle: bufferToBase64Url(credential.response.userHandle)
+    },
+    clientExtensionResults: credential.getClientExtensionResults()
+  };
+}
@@ -31,6 +31,7 @@ import FormLabel from '@mui/material/FormLabel';
 import Typography from '@mui/material/Typography';
 import Box from '@mui/material/Box';
 import Divider from '@mui/material/Divider';
+import Autocomplete from '@mui/material/Autocomplete';
 
 // icons
 import AddIcon from '@mui/icons-material/Add';
@@ -40,10 +41,20 @@ import ContentCopyIcon from '@mui/icons-material/ContentCopy';
 
 import MainCard from 'ui-component/cards/MainCard';
 import Pagination from 'components/Pagination';
-import { getAccessKeys, createAccessKey, deleteAccessKey } from 'api/accesskeys';
+import { getAccessKeys, createAccessKey, deleteAccessKey, getAccessKeyScopes } from 'api/accesskeys';
+import { getSubscriptions } from 'api/subscriptions';
 import { useAuth } from 'contexts/AuthContext';
 import { formatDateTime } from 'i18n/locales';
 
+const EMPTY_FORM = { description: '', expirationOption: 'never', expiredAt: null, scopes: [], subscriptionIds: [], allowedIps: '' };
+
+// 逗号分隔的字段转为数组
+const splitList = (value) =>
+  (value || '')
+    .split(/[,\n]/)
+    .map((item) => item.trim())
+    .filter(Boolean);
+
 // ==============================|| API 密钥管理 ||============================== //
 
 export default function ApiKeyList() {
@@ -57,11 +68,9 @@ export default function ApiKeyList() {
   const [dialogOpen, setDialogOpen] = useState(false);
   const [showKeyDialog, setShowKeyDialog] = useState(false);
   const [newKey, setNewKey] = useState('');
-  const [formData, setFormData] = useState({
-    description: '',
-    expirationOption: 'never',
-    expiredAt: null
-  });
+  const [formData, setFormData] = useState(EMPTY_FORM);
+  const [scopeOptions, setScopeOptions] = useState([]);
+  const [subscriptionOptions, setSubscriptionOptions] = useState([]);
   const [snackbar, setSnackbar] = useState({ open: false, message: '', severity: 'success' });
 
   // 分页
@@ -127,9 +136,16 @@ export default function ApiKeyList() {
     setSnackbar({ open: true, message, severity });
   };
 
-  const handleAdd = () => {
-    setFormData({ description: '', expirationOption: 'never', expiredAt: null });
+  const handleAdd = async () => {
+    setFormData(EMPTY_FORM);
     setDialogOpen(true);
+    try {
+      const [scopesRes, subsRes] = await Promise.all([getAccessKeyScopes(), getSubscriptions()]);
+      setScopeOptions(scopesRes.data || []);
+      setSubscriptionOptions((subsRes.data || []).map((sub) => ({ id: sub.ID, name: sub.Name })));
+    } catch (error) {
+      showMessage(error.message || t('accessKeys.messages.loadFailed'), 'error');
+    }
   };
 
   const handleDelete = async (accessKey) => {
@@ -153,7 +169,10 @@ export default function ApiKeyList() {
     try {
       const params = {
         description: formData.description,
-        username: user?.username
+        username: user?.username,
+        scopes: formData.scopes,
+        subscriptionIds: formData.subscriptionIds.map((sub) => sub.id),
+        allowedIps: splitList(formData.allowedIps)
       };
 
       if (formData.expirationOption === 'custom') {
@@ -201,6 +220,23 @@ export default function ApiKeyList() {
     return <Chip label={formatDate(apiKey.ExpiredAt)} color="info" size="small" variant="outlined" />;
   };
 
+  const renderScopes = (apiKey) => {
+    const scopes = splitList(apiKey.Scopes);
+    if (scopes.length === 0) {
+      return <Chip label={t('accessKeys.scopes.full')} color="warning" size="small" variant="outlined" />;
+    }
+    return (
+      <Stack direction="row" spacing={0.5} useFlexGap flexWrap="wrap">
+        {scopes.map((scope) => (
+          <Chip key={scope} label={scope} size="small" variant="outlined" />
+        ))}
+      </Stack>
+    );
+  };
+
+  const formatLastUsed = (apiKey) =>
+    apiKey.LastUsedAt ? `${formatDate(apiKey.LastUsedAt)} (${apiKey.LastUsedIP || '-'})` : t('accessKeys.usage.never');
+
   return (
     <MainCard
       title={t('accessKeys.title')}
@@ -244,6 +280,10 @@ export default function ApiKeyList() {
                 <Typography variant="caption" color="textSecondary" display="block" gutterBottom>
                   {t('accessKeys.fields.createdAt')}: {formatDate(accessKey.CreatedAt)}
                 </Typography>
+                <Typography variant="caption" color="textSecondary" display="block" gutterBottom>
+                  {t('accessKeys.fields.lastUsed')}: {formatLastUsed(accessKey)}
+                </Typography>
+                {renderScopes(accessKey)}
                 <Divider sx={{ my: 1 }} />
                 <Stack direction="row" justifyContent="flex-end">
                   <IconButton size="small" color="error" onClick={() => handleDelete(accessKey)}>
@@ -261,7 +301,9 @@ export default function ApiKeyList() {
               <TableRow>
                 <TableCell>ID</TableCell>
                 <TableCell>{t('accessKeys.fields.description')}</TableCell>
+                <TableCell>{t('accessKeys.fields.scopes')}</TableCell>
                 <TableCell>{t('accessKeys.fields.createdAt')}</TableCell>
+                <TableCell>{t('accessKeys.fields.lastUsed')}</TableCell>
                 <TableCell>{t('accessKeys.fields.expiration')}</TableCell>
                 <TableCell align="right">{t('accessKeys.fields.actions')}</TableCell>
               </TableRow>
@@ -271,7 +313,9 @@ export default function ApiKeyList() {
                 <TableRow key={accessKey.ID} hover>
                   <TableCell>{accessKey.ID}</TableCell>
                   <TableCell>{accessKey.Description}</TableCell>
+                  <TableCell>{renderScopes(accessKey)}</TableCell>
                   <TableCell>{formatDate(accessKey.CreatedAt)}</TableCell>
+                  <TableCell>{formatLastUsed(accessKey)}</TableCell>
                   <TableCell>{getExpirationStatus(accessKey)}</TableCell>
                   <TableCell align="right">
                     <IconButton size="small" color="error" onClick={() => handleDelete(accessKey)}>
@@ -358,6 +402,40 @@ export default function ApiKeyList() {
                 }}
               />
             )}
+            <Autocomplete
+              multiple
+              options={scopeOptions}
+              value={formData.scopes}
+              onChange={(e, value) => setFormData({ ...formData, scopes: value })}
+              renderInput={(params) => (
+                <TextField {...params} label={t('accessKeys.fields.scopes')} helperText={t('accessKeys.createDialog.scopesHelper')} />
+              )}
+            />
+            <Autocomplete
+              multiple
+              options={subscriptionOptions}
+              value={formData.subscriptionIds}
+              getOptionLabel={(option) => option.name}
+              isOptionEqualToValue={(option, value) => option.id === value.id}
+              onChange={(e, value) => setFormData({ ...formData, subscriptionIds: value })}
+              renderInput={(params) => (
+                <TextField
+                  {...params}
+                  label={t('accessKeys.fields.subscriptions')}
+                  helperText={t('accessKeys.createDialog.subscriptionsHelper')}
+                />
+              )}
+            />
+            <TextField
+              fullWidth
+              multiline
+              minRows={2}
+              label={t('accessKeys.fields.allowedIps')}
+              value={formData.allowedIps}
+              onChange={(e) => setFormData({ ...formData, allowedIps: e.target.value })}
+              placeholder="192.168.1.10, 10.0.0.0/8"
+              helperText={t('accessKeys.createDialog.allowedIpsHelper')}
+            />
           </Stack>
         </DialogContent>
         <DialogActions>
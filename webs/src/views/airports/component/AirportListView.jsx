@@ -32,6 +32,7 @@ import AccountBalanceWalletIcon from '@mui/icons-material/AccountBalanceWallet';
 import AccessTimeIcon from '@mui/icons-material/AccessTime';
 import SpeedIcon from '@mui/icons-material/Speed';
 import WarningAmberIcon from '@mui/icons-material/WarningAmber';
+import ReceiptLongIcon from '@mui/icons-material/ReceiptLong';
 
 // utils
 import { formatDateTime, formatBytes, formatExpireTime, getUsageColor } from '../utils';
@@ -54,6 +55,7 @@ export default function AirportListView({
   onOpenNodes,
   onQuickCheck,
   onRefreshUsage,
+  onViewLogs,
   nodeCheckProfiles
 }) {
   const theme = useTheme();
@@ -618,6 +620,24 @@ export default function AirportListView({
                             <ContentCopyIcon sx={{ fontSize: 14 }} />
                           </IconButton>
                         </Tooltip>
+                        {onViewLogs && (
+                          <Tooltip title={t('airports.list.actions.recentLogs')} arrow>
+                            <IconButton
+                              size="small"
+                              aria-label={t('airports.list.actions.recentLogs')}
+                              onClick={() => onViewLogs(airport)}
+                              sx={{
+                                width: 28,
+                                height: 28,
+                                bgcolor: alpha(theme.palette.warning.main, 0.08),
+                                color: theme.palette.warning.main,
+                                '&:hover': { bgcolor: alpha(theme.palette.warning.main, 0.15) }
+                              }}
+                            >
+                              <ReceiptLongIcon sx={{ fontSize: 14 }} />
+                            </IconButton>
+                          </Tooltip>
+                        )}
                         <Tooltip title={t('common.edit')} arrow>
                           <IconButton
                             size="small"
@@ -685,6 +705,7 @@ AirportListView.propTypes = {
   onOpenNodes: PropTypes.func.isRequired,
   onQuickCheck: PropTypes.func.isRequired,
   onRefreshUsage: PropTypes.func,
+  onViewLogs: PropTypes.func,
   nodeCheckProfiles: PropTypes.array
 };
 
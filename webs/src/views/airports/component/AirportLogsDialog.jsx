@@ -0,0 +1,100 @@
+import { useCallback, useEffect, useState } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+import Alert from '@mui/material/Alert';
+import Box from '@mui/material/Box';
+import Button from '@mui/material/Button';
+import Chip from '@mui/material/Chip';
+import CircularProgress from '@mui/material/CircularProgress';
+import Dialog from '@mui/material/Dialog';
+import DialogActions from '@mui/material/DialogActions';
+import DialogContent from '@mui/material/DialogContent';
+import DialogTitle from '@mui/material/DialogTitle';
+import Stack from '@mui/material/Stack';
+import Typography from '@mui/material/Typography';
+
+import { getRecentLogs } from 'api/logs';
+import { formatDateTime } from '../utils';
+
+const LEVEL_COLORS = { WARN: 'warning', ERROR: 'error', FATAL: 'error' };
+
+/**
+ * 机场最近告警/错误日志对话框
+ */
+export default function AirportLogsDialog({ open, airport, onClose }) {
+  const { t } = useTranslation();
+  const [logs, setLogs] = useState([]);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState('');
+
+  const fetchLogs = useCallback(async () => {
+    if (!airport?.id) return;
+    setLoading(true);
+    setError('');
+    try {
+      const res = await getRecentLogs({ subsystem: 'airport', level: 'warn', field: `airport_id=${airport.id}`, limit: 50 });
+      setLogs([...(res.data?.items || [])].reverse());
+    } catch (err) {
+      setError(err.message || t('airports.logsDialog.loadFailed'));
+    } finally {
+      setLoading(false);
+    }
+  }, [airport?.id, t]);
+
+  useEffect(() => {
+    if (open) fetchLogs();
+  }, [open, fetchLogs]);
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="md" fullWidth>
+      <DialogTitle>{t('airports.logsDialog.title', { name: airport?.name || t('common.unknown') })}</DialogTitle>
+      <DialogContent dividers>
+        <Typography variant="body2" color="text.secondary" sx={{ mb: 2 }}>
+          {t('airports.logsDialog.hint')}
+        </Typography>
+        {error && <Alert severity="error">{error}</Alert>}
+        {loading && (
+          <Box sx={{ display: 'flex', justifyContent: 'center', py: 3 }}>
+            <CircularProgress size={28} />
+          </Box>
+        )}
+        {!loading && !error && logs.length === 0 && <Alert severity="success">{t('airports.logsDialog.empty')}</Alert>}
+        {!loading && !error && logs.length > 0 && (
+          <Stack spacing={1.5}>
+            {logs.map((entry) => (
+              <Box key={entry.seq} sx={{ p: 1.5, borderRadius: 1, border: '1px solid', borderColor: 'divider' }}>
+                <Stack direction="row" spacing={1} alignItems="center" sx={{ mb: 0.5 }}>
+                  <Chip size="small" label={entry.level} color={LEVEL_COLORS[entry.level] || 'default'} />
+                  <Typography variant="caption" color="text.secondary">
+                    {formatDateTime(entry.time)} · {entry.caller}
+                  </Typography>
+                </Stack>
+                <Typography variant="body2" sx={{ fontFamily: 'monospace', whiteSpace: 'pre-wrap', wordBreak: 'break-all' }}>
+                  {entry.message}
+                </Typography>
+              </Box>
+            ))}
+          </Stack>
+        )}
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={fetchLogs} disabled={loading}>
+          {t('common.refresh')}
+        </Button>
+        <Button variant="contained" onClick={onClose}>
+          {t('common.close')}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
+
+AirportLogsDialog.propTypes = {
+  open: PropTypes.bool.isRequired,
+  airport: PropTypes.shape({
+    id: PropTypes.number,
+    name: PropTypes.string
+  }),
+  onClose: PropTypes.func.isRequired
+};
@@ -32,6 +32,7 @@ import SpeedIcon from '@mui/icons-material/Speed';
 import AccountBalanceWalletIcon from '@mui/icons-material/AccountBalanceWallet';
 import WarningAmberIcon from '@mui/icons-material/WarningAmber';
 import EventIcon from '@mui/icons-material/Event';
+import ReceiptLongIcon from '@mui/icons-material/ReceiptLong';
 
 // utils
 import { formatDateTime, formatBytes, formatExpireTime, getUsageColor } from '../utils';
@@ -56,6 +57,7 @@ export default function AirportMobileList({
   onOpenNodes,
   onQuickCheck,
   onRefreshUsage,
+  onViewLogs,
   nodeCheckProfiles
 }) {
   const theme = useTheme();
@@ -613,6 +615,19 @@ export default function AirportMobileList({
           </ListItemIcon>
           <ListItemText>{t('airports.list.actions.quickCheck')}</ListItemText>
         </MenuItem>
+        {onViewLogs && (
+          <MenuItem
+            onClick={() => {
+              const airport = airports.find((item) => item.id === menuAirportId);
+              if (airport) handleMenuAction(onViewLogs, airport);
+            }}
+          >
+            <ListItemIcon>
+              <ReceiptLongIcon fontSize="small" color="warning" />
+            </ListItemIcon>
+            <ListItemText>{t('airports.list.actions.recentLogs')}</ListItemText>
+          </MenuItem>
+        )}
         <MenuItem
           onClick={() => {
             const airport = airports.find((item) => item.id === menuAirportId);
@@ -663,6 +678,7 @@ AirportMobileList.propTypes = {
   onOpenNodes: PropTypes.func.isRequired,
   onQuickCheck: PropTypes.func.isRequired,
   onRefreshUsage: PropTypes.func,
+  onViewLogs: PropTypes.func,
   nodeCheckProfiles: PropTypes.array
 };
 
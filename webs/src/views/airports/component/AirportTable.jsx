@@ -30,6 +30,7 @@ import UpdateIcon from '@mui/icons-material/Update';
 import SpeedIcon from '@mui/icons-material/Speed';
 import WarningAmberIcon from '@mui/icons-material/WarningAmber';
 import EventIcon from '@mui/icons-material/Event';
+import ReceiptLongIcon from '@mui/icons-material/ReceiptLong';
 
 // utils
 import { formatDateTime, formatBytes, formatExpireTime, getUsageColor } from '../utils';
@@ -51,6 +52,7 @@ export default function AirportTable({
   onOpenNodes,
   onQuickCheck,
   onRefreshUsage,
+  onViewLogs,
   nodeCheckProfiles
 }) {
   const theme = useTheme();
@@ -688,6 +690,22 @@ export default function AirportTable({
                           <ContentCopyIcon sx={{ fontSize: 16 }} />
                         </IconButton>
                       </Tooltip>
+                      {onViewLogs && (
+                        <Tooltip title={t('airports.list.actions.recentLogs')} arrow>
+                          <IconButton
+                            size="small"
+                            aria-label={t('airports.list.actions.recentLogs')}
+                            onClick={() => onViewLogs(airport)}
+                            sx={{
+                              bgcolor: alpha(theme.palette.warning.main, 0.08),
+                              color: theme.palette.warning.main,
+                              '&:hover': { bgcolor: alpha(theme.palette.warning.main, 0.15) }
+                            }}
+                          >
+                            <ReceiptLongIcon sx={{ fontSize: 16 }} />
+                          </IconButton>
+                        </Tooltip>
+                      )}
                       <Tooltip title={t('common.edit')} arrow>
                         <IconButton
                           size="small"
@@ -750,6 +768,7 @@ AirportTable.propTypes = {
   onOpenNodes: PropTypes.func.isRequired,
   onQuickCheck: PropTypes.func.isRequired,
   onRefreshUsage: PropTypes.func,
+  onViewLogs: PropTypes.func,
   nodeCheckProfiles: PropTypes.array
 };
 
@@ -4,3 +4,4 @@ export { default as AirportMobileList } from './AirportMobileList';
 export { default as AirportFormDialog } from './AirportFormDialog';
 export { default as DeleteAirportDialog } from './DeleteAirportDialog';
 export { default as AirportBatchEditDialog } from './AirportBatchEditDialog';
+export { default as AirportLogsDialog } from './AirportLogsDialog';
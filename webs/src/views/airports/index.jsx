@@ -50,6 +50,7 @@ import {
 } from 'api/airports';
 import { getNodeCheckProfiles } from 'api/nodeCheck';
 import { useTaskProgress } from 'contexts/TaskProgressContext';
+import { useAuth } from 'contexts/AuthContext';
 import { getNodeGroups, getNodeIds, getNodes, getProtocolUIMeta } from 'api/nodes';
 import ProfileSelectDialog from 'views/nodes/component/ProfileSelectDialog';
 import useResolvedColorScheme from 'hooks/useResolvedColorScheme';
@@ -62,7 +63,8 @@ import {
   AirportMobileList,
   AirportFormDialog,
   DeleteAirportDialog,
-  AirportBatchEditDialog
+  AirportBatchEditDialog,
+  AirportLogsDialog
 } from './component';
 
 // utils
@@ -246,6 +248,11 @@ export default function AirportList() {
   const [deleteTarget, setDeleteTarget] = useState(null);
   const [deleteWithNodes, setDeleteWithNodes] = useState(true);
 
+  // 最近日志对话框状态（仅管理员可查询运行日志）
+  const { user } = useAuth();
+  const [logsTarget, setLogsTarget] = useState(null);
+  const handleViewLogs = user?.role === 'admin' ? setLogsTarget : undefined;
+
   // 确认对话框状态
   const [confirmOpen, setConfirmOpen] = useState(false);
   const [confirmInfo, setConfirmInfo] = useState({ title: '', content: '', action: null });
@@ -1214,6 +1221,7 @@ export default function AirportList() {
           onOpenNodes={handleOpenNodeManagement}
           onQuickCheck={handleQuickCheck}
           onRefreshUsage={handleRefreshUsage}
+          onViewLogs={handleViewLogs}
           nodeCheckProfiles={nodeCheckProfiles}
         />
       ) : viewMode === 'list' ? (
@@ -1227,6 +1235,7 @@ export default function AirportList() {
           onOpenNodes={handleOpenNodeManagement}
           onQuickCheck={handleQuickCheck}
           onRefreshUsage={handleRefreshUsage}
+          onViewLogs={handleViewLogs}
           nodeCheckProfiles={nodeCheckProfiles}
         />
       ) : (
@@ -1240,6 +1249,7 @@ export default function AirportList() {
           onOpenNodes={handleOpenNodeManagement}
           onQuickCheck={handleQuickCheck}
           onRefreshUsage={handleRefreshUsage}
+          onViewLogs={handleViewLogs}
           nodeCheckProfiles={nodeCheckProfiles}
         />
       )}
@@ -1307,6 +1317,8 @@ export default function AirportList() {
         onConfirm={handleConfirmDelete}
       />
 
+      <AirportLogsDialog open={Boolean(logsTarget)} airport={logsTarget} onClose={() => setLogsTarget(null)} />
+
       <ProfileSelectDialog
         open={profileSelectOpen}
         onClose={() => setProfileSelectOpen(false)}
@@ -23,12 +23,15 @@ import AnimateButton from 'ui-component/extended/AnimateButton';
 import CustomFormControl from 'ui-component/extended/Form/CustomFormControl';
 import TurnstileDialog from 'ui-component/TurnstileDialog';
 import { useAuth } from 'contexts/AuthContext';
-import { getCaptcha } from 'api/auth';
+import { getCaptcha, getOIDCStatus, getPasskeyStatus } from 'api/auth';
+import { isPasskeySupported } from 'utils/webauthn';
 
 // assets
 import Visibility from '@mui/icons-material/Visibility';
 import VisibilityOff from '@mui/icons-material/VisibilityOff';
 import RefreshIcon from '@mui/icons-material/Refresh';
+import KeyIcon from '@mui/icons-material/Key';
+import FingerprintIcon from '@mui/icons-material/Fingerprint';
 
 // 验证码模式常量（与后端保持一致）
 const CAPTCHA_MODE = {
@@ -42,7 +45,7 @@ const CAPTCHA_MODE = {
 export default function AuthLogin() {
   const navigate = useNavigate();
   const { t } = useTranslation();
-  const { login, verifyMfa, rememberedUsernameKey } = useAuth();
+  const { login, verifyMfa, loginWithToken, loginWithPasskey, rememberedUsernameKey } = useAuth();
   const turnstileDialogRef = useRef(null);
 
   const [username, setUsername] = useState('');
@@ -66,6 +69,10 @@ export default function AuthLogin() {
 
   // Turnstile 弹窗状态
   const [turnstileDialogOpen, setTurnstileDialogOpen] = useState(false);
+
+  // 单点登录配置
+  const [oidcStatus, setOidcStatus] = useState({ enabled: false, displayName: '', passwordLoginDisabled: false });
+  const [passkeyLoginEnabled, setPasskeyLoginEnabled] = useState(false);
   // 获取验证码配置
   const fetchCaptcha = useCallback(async () => {
     try {
@@ -94,6 +101,77 @@ export default function AuthLogin() {
     fetchCaptcha();
   }, [fetchCaptcha]);
 
+  useEffect(() => {
+    getOIDCStatus()
+      .then((response) => setOidcStatus(response.data || {}))
+      .catch((err) => console.error('获取单点登录配置失败:', err));
+    if (isPasskeySupported()) {
+      getPasskeyStatus()
+        .then((response) => setPasskeyLoginEnabled(Boolean(response.data?.passwordless)))
+        .catch((err) => console.error('获取通行密钥配置失败:', err));
+    }
+  }, []);
+
+  // 处理单点登录回调：token 或错误信息放在 URL fragment 中
+  useEffect(() => {
+    const hash = new URLSearchParams(window.location.hash.slice(1));
+    const oidcToken = hash.get('oidc_token');
+    const oidcError = hash.get('oidc_error');
+    const oidcMfa = hash.get('oidc_mfa');
+    if (!oidcToken && !oidcError && !oidcMfa) {
+      return;
+    }
+    window.history.replaceState(null, '', window.location.pathname + window.location.search);
+
+    if (oidcError) {
+      setError(t('auth.sso.failed', { message: oidcError }));
+      return;
+    }
+    // 本地启用了二次验证的账号，单点登录后继续完成 TOTP 或通行密钥验证
+    if (oidcMfa) {
+      const methods = (hash.get('oidc_mfa_methods') || '').split(',').filter(Boolean);
+      setUsername(hash.get('oidc_user') || '');
+      setMfaChallenge({
+        challengeToken: oidcMfa,
+        methods,
+        availableMethods: methods,
+        hint: hash.get('oidc_user') || '',
+        recoveryAvailable: methods.includes('recovery_code')
+      });
+      return;
+    }
+    setLoading(true);
+    loginWithToken(oidcToken)
+      .then((result) => {
+        if (result.success) {
+          navigate('/dashboard/default');
+        } else {
+          setError(result.message);
+        }
+      })
+      .finally(() => setLoading(false));
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const handleSsoLogin = () => {
+    window.location.href = '/api/v1/auth/oidc/login';
+  };
+
+  const handlePasskeyLogin = async () => {
+    setLoading(true);
+    setError('');
+    try {
+      const result = await loginWithPasskey();
+      if (result.success) {
+        navigate('/dashboard/default');
+      } else {
+        setError(result.message || t('auth.passkey.failed'));
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
   useEffect(() => {
     try {
       const rememberedUsername = localStorage.getItem(rememberedUsernameKey);
@@ -126,6 +204,8 @@ export default function AuthLogin() {
 
   const challengeMethods = mfaChallenge?.availableMethods || [];
   const canUseRecoveryCode = mfaChallenge?.recoveryAvailable || challengeMethods.includes('recovery_code');
+  const canUsePasskey = challengeMethods.includes('passkey') && isPasskeySupported();
+  const canUseTotp = challengeMethods.length === 0 || challengeMethods.includes('totp');
   const maskedAccountHint = mfaChallenge?.hint || username;
 
   // 执行实际登录请求
@@ -236,6 +316,27 @@ export default function AuthLogin() {
     }
   };
 
+  const handlePasskeyMfa = async () => {
+    setError('');
+    setLoading(true);
+    try {
+      const result = await verifyMfa({
+        challengeToken: mfaChallenge.challengeToken,
+        type: 'passkey',
+        rememberMe,
+        username
+      });
+      if (result.success) {
+        resetMfaState();
+        navigate('/dashboard/default');
+      } else {
+        setError(result.message || t('auth.passkey.failed'));
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const handleBackToCredentials = () => {
     resetMfaState();
     setError('');
@@ -353,68 +454,20 @@ export default function AuthLogin() {
       </Alert>
 
       <Stack spacing={2}>
-        <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
-          <Chip
-            color={!useRecoveryCode ? 'secondary' : 'default'}
-            label={t('auth.mfa.authenticator')}
-            size="small"
-            variant={!useRecoveryCode ? 'filled' : 'outlined'}
-          />
-          {canUseRecoveryCode && (
-            <Chip
-              color={useRecoveryCode ? 'secondary' : 'default'}
-              label={t('auth.mfa.recoveryCode')}
-              size="small"
-              variant={useRecoveryCode ? 'filled' : 'outlined'}
-            />
-          )}
-        </Stack>
-
-        {!useRecoveryCode ? (
-          <CustomFormControl fullWidth>
-            <InputLabel htmlFor="outlined-adornment-totp-code">{t('auth.mfa.codeLabel')}</InputLabel>
-            <OutlinedInput
-              id="outlined-adornment-totp-code"
-              type="text"
-              value={mfaCode}
-              onChange={(e) => setMfaCode(e.target.value.replace(/\s+/g, '').slice(0, 8))}
-              name="totpCode"
-              label={t('auth.mfa.codeLabel')}
-              autoComplete="one-time-code"
-              autoFocus
-              inputProps={{ inputMode: 'numeric', pattern: '[0-9]*', 'aria-describedby': 'mfa-code-helper-text' }}
-            />
-          </CustomFormControl>
-        ) : (
-          <CustomFormControl fullWidth>
-            <InputLabel htmlFor="outlined-adornment-recovery-code">{t('auth.mfa.recoveryCodeLabel')}</InputLabel>
-            <OutlinedInput
-              id="outlined-adornment-recovery-code"
-              type="text"
-              value={recoveryCode}
-              onChange={(e) => setRecoveryCode(e.target.value.trimStart())}
-              name="recoveryCode"
-              label={t('auth.mfa.recoveryCodeLabel')}
-              autoComplete="one-time-code"
-              autoFocus
-            />
-          </CustomFormControl>
-        )}
-
-        <Typography id="mfa-code-helper-text" variant="caption" color="text.secondary" sx={{ mt: -1 }}>
-          {useRecoveryCode ? t('auth.mfa.recoveryCodeHint') : t('auth.mfa.authenticatorHint')}
-        </Typography>
-
-        {canUseRecoveryCode && (
+        {canUsePasskey && (
           <Button
             color="secondary"
-            variant="text"
-            onClick={() => setUseRecoveryCode((prev) => !prev)}
-            sx={{ alignSelf: 'flex-start', px: 0.5 }}
+            variant={canUseTotp ? 'outlined' : 'contained'}
+            size="large"
+            startIcon={<FingerprintIcon />}
+            onClick={handlePasskeyMfa}
+            disabled={loading}
           >
-            {useRecoveryCode ? t('auth.mfa.useAuthenticatorBtn') : t('auth.mfa.useRecoveryBtn')}
+            {t('auth.passkey.verifyBtn')}
           </Button>
         )}
+        {!canUsePasskey && !canUseTotp && <Alert severity="warning">{t('auth.passkey.unsupported')}</Alert>}
+        {canUseTotp && renderTotpFields()}
 
         <Divider />
 
@@ -422,25 +475,111 @@ export default function AuthLogin() {
           <Button variant="outlined" onClick={handleBackToCredentials} disabled={loading}>
             {t('auth.mfa.backBtn')}
           </Button>
-          <AnimateButton>
-            <Button
-              color="secondary"
-              fullWidth
-              size="large"
-              type="submit"
-              variant="contained"
-              disabled={loading}
-              sx={{ position: 'relative' }}
-            >
-              {loading && <CircularProgress size={20} color="inherit" sx={{ position: 'absolute', left: 16 }} />}
-              {loading ? t('auth.mfa.verifying') : t('auth.mfa.verifyBtn')}
-            </Button>
-          </AnimateButton>
+          {canUseTotp && (
+            <AnimateButton>
+              <Button
+                color="secondary"
+                fullWidth
+                size="large"
+                type="submit"
+                variant="contained"
+                disabled={loading}
+                sx={{ position: 'relative' }}
+              >
+                {loading && <CircularProgress size={20} color="inherit" sx={{ position: 'absolute', left: 16 }} />}
+                {loading ? t('auth.mfa.verifying') : t('auth.mfa.verifyBtn')}
+              </Button>
+            </AnimateButton>
+          )}
         </Stack>
       </Stack>
     </form>
   );
 
+  const renderTotpFields = () => (
+    <>
+      <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
+        <Chip
+          color={!useRecoveryCode ? 'secondary' : 'default'}
+          label={t('auth.mfa.authenticator')}
+          size="small"
+          variant={!useRecoveryCode ? 'filled' : 'outlined'}
+        />
+        {canUseRecoveryCode && (
+          <Chip
+            color={useRecoveryCode ? 'secondary' : 'default'}
+            label={t('auth.mfa.recoveryCode')}
+            size="small"
+            variant={useRecoveryCode ? 'filled' : 'outlined'}
+          />
+        )}
+      </Stack>
+
+      {!useRecoveryCode ? (
+        <CustomFormControl fullWidth>
+          <InputLabel htmlFor="outlined-adornment-totp-code">{t('auth.mfa.codeLabel')}</InputLabel>
+          <OutlinedInput
+            id="outlined-adornment-totp-code"
+            type="text"
+            value={mfaCode}
+            onChange={(e) => setMfaCode(e.target.value.replace(/\s+/g, '').slice(0, 8))}
+            name="totpCode"
+            label={t('auth.mfa.codeLabel')}
+            autoComplete="one-time-code"
+            autoFocus
+            inputProps={{ inputMode: 'numeric', pattern: '[0-9]*', 'aria-describedby': 'mfa-code-helper-text' }}
+          />
+        </CustomFormControl>
+      ) : (
+        <CustomFormControl fullWidth>
+          <InputLabel htmlFor="outlined-adornment-recovery-code">{t('auth.mfa.recoveryCodeLabel')}</InputLabel>
+          <OutlinedInput
+            id="outlined-adornment-recovery-code"
+            type="text"
+            value={recoveryCode}
+            onChange={(e) => setRecoveryCode(e.target.value.trimStart())}
+            name="recoveryCode"
+            label={t('auth.mfa.recoveryCodeLabel')}
+            autoComplete="one-time-code"
+            autoFocus
+          />
+        </CustomFormControl>
+      )}
+
+      <Typography id="mfa-code-helper-text" variant="caption" color="text.secondary" sx={{ mt: -1 }}>
+        {useRecoveryCode ? t('auth.mfa.recoveryCodeHint') : t('auth.mfa.authenticatorHint')}
+      </Typography>
+
+      {canUseRecoveryCode && (
+        <Button
+          color="secondary"
+          variant="text"
+          onClick={() => setUseRecoveryCode((prev) => !prev)}
+          sx={{ alignSelf: 'flex-start', px: 0.5 }}
+        >
+          {useRecoveryCode ? t('auth.mfa.useAuthenticatorBtn') : t('auth.mfa.useRecoveryBtn')}
+        </Button>
+      )}
+    </>
+  );
+
+  const renderSsoLogin = () => (
+    <>
+      <AnimateButton>
+        <Button color="secondary" fullWidth size="large" variant="outlined" startIcon={<KeyIcon />} onClick={handleSsoLogin} disabled={loading}>
+          {t('auth.sso.loginWith', { name: oidcStatus.displayName || 'SSO' })}
+        </Button>
+      </AnimateButton>
+      {!oidcStatus.passwordLoginDisabled && (
+        <Divider sx={{ my: 2 }}>
+          <Typography variant="caption" color="text.secondary">
+            {t('auth.sso.or')}
+          </Typography>
+        </Divider>
+      )}
+    </>
+  );
+
   const renderPrimaryLoginForm = () => (
     <form onSubmit={handleSubmit}>
       {error && (
@@ -449,6 +588,35 @@ export default function AuthLogin() {
         </Alert>
       )}
 
+      {oidcStatus.enabled && renderSsoLogin()}
+
+      {passkeyLoginEnabled && (
+        <Button
+          color="secondary"
+          fullWidth
+          size="large"
+          variant="outlined"
+          startIcon={<FingerprintIcon />}
+          onClick={handlePasskeyLogin}
+          disabled={loading}
+          sx={{ mb: 2 }}
+        >
+          {t('auth.passkey.loginBtn')}
+        </Button>
+      )}
+
+      {oidcStatus.passwordLoginDisabled ? (
+        <Typography variant="caption" color="text.secondary" sx={{ display: 'block', textAlign: 'center', mt: 1 }}>
+          {t('auth.sso.passwordLoginDisabled')}
+        </Typography>
+      ) : (
+        renderPasswordFields()
+      )}
+    </form>
+  );
+
+  const renderPasswordFields = () => (
+    <>
       {captchaDegraded && (
         <Alert severity="info" sx={{ mb: 2 }}>
           {t('auth.login.turnstileDegraded')}
@@ -519,7 +687,7 @@ export default function AuthLogin() {
           </Button>
         </AnimateButton>
       </Box>
-    </form>
+    </>
   );
 
   return (
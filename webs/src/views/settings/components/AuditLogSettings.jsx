@@ -0,0 +1,287 @@
+import { useCallback, useEffect, useState } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+// material-ui
+import Alert from '@mui/material/Alert';
+import Box from '@mui/material/Box';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Chip from '@mui/material/Chip';
+import Dialog from '@mui/material/Dialog';
+import DialogActions from '@mui/material/DialogActions';
+import DialogContent from '@mui/material/DialogContent';
+import DialogTitle from '@mui/material/DialogTitle';
+import Grid from '@mui/material/Grid';
+import MenuItem from '@mui/material/MenuItem';
+import Stack from '@mui/material/Stack';
+import Table from '@mui/material/Table';
+import TableBody from '@mui/material/TableBody';
+import TableCell from '@mui/material/TableCell';
+import TableContainer from '@mui/material/TableContainer';
+import TableHead from '@mui/material/TableHead';
+import TablePagination from '@mui/material/TablePagination';
+import TableRow from '@mui/material/TableRow';
+import TextField from '@mui/material/TextField';
+import Typography from '@mui/material/Typography';
+
+// icons
+import HistoryIcon from '@mui/icons-material/History';
+import RefreshIcon from '@mui/icons-material/Refresh';
+
+// project imports
+import { getAuditLogOptions, getAuditLogs, getAuditSettings, updateAuditSettings } from 'api/audit';
+
+const EMPTY_FILTERS = { actor: '', resourceType: '', action: '', success: '', start: '', end: '' };
+
+// formatSummary 将 JSON 摘要格式化显示，无法解析时原样返回
+const formatSummary = (value) => {
+  if (!value) return '';
+  try {
+    return JSON.stringify(JSON.parse(value), null, 2);
+  } catch {
+    return value;
+  }
+};
+
+// ==============================|| 审计日志 ||============================== //
+
+export default function AuditLogSettings({ showMessage }) {
+  const { t } = useTranslation();
+  const [logs, setLogs] = useState([]);
+  const [total, setTotal] = useState(0);
+  const [page, setPage] = useState(0);
+  const [pageSize, setPageSize] = useState(20);
+  const [filters, setFilters] = useState(EMPTY_FILTERS);
+  const [options, setOptions] = useState({ actors: [], resourceTypes: [], actions: [] });
+  const [retentionDays, setRetentionDays] = useState('');
+  const [detail, setDetail] = useState(null);
+  const [saving, setSaving] = useState(false);
+
+  const fetchLogs = useCallback(async () => {
+    try {
+      const params = { page: page + 1, pageSize };
+      Object.entries(filters).forEach(([key, value]) => {
+        if (value !== '') params[key] = value;
+      });
+      const res = await getAuditLogs(params);
+      setLogs(res.data?.items || []);
+      setTotal(res.data?.total || 0);
+    } catch (error) {
+      showMessage(error.message || t('settings.audit.messages.loadFailed'), 'error');
+    }
+  }, [filters, page, pageSize, showMessage, t]);
+
+  useEffect(() => {
+    fetchLogs();
+  }, [fetchLogs]);
+
+  useEffect(() => {
+    getAuditLogOptions()
+      .then((res) => setOptions({ actors: [], resourceTypes: [], actions: [], ...res.data }))
+      .catch((error) => console.error('获取审计日志筛选项失败:', error));
+    getAuditSettings()
+      .then((res) => setRetentionDays(String(res.data?.retentionDays ?? '')))
+      .catch((error) => console.error('获取审计日志设置失败:', error));
+  }, []);
+
+  const handleFilterChange = (key) => (e) => {
+    setFilters((prev) => ({ ...prev, [key]: e.target.value }));
+    setPage(0);
+  };
+
+  const handleSaveRetention = async () => {
+    setSaving(true);
+    try {
+      const res = await updateAuditSettings({ retentionDays: Number(retentionDays) });
+      showMessage(t('settings.audit.messages.retentionSaved', { count: res.data?.deleted || 0 }));
+      fetchLogs();
+    } catch (error) {
+      showMessage(error.message || t('common.saveFailed'), 'error');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const renderSelect = (key, values, renderLabel = (value) => value) => (
+    <TextField select fullWidth size="small" label={t(`settings.audit.filters.${key}`)} value={filters[key]} onChange={handleFilterChange(key)}>
+      <MenuItem value="">{t('common.all')}</MenuItem>
+      {values.map((value) => (
+        <MenuItem key={value} value={value}>
+          {renderLabel(value)}
+        </MenuItem>
+      ))}
+    </TextField>
+  );
+
+  return (
+    <Stack spacing={3}>
+      <Card variant="outlined">
+        <CardHeader
+          avatar={<HistoryIcon color="primary" />}
+          title={t('settings.audit.title')}
+          subheader={t('settings.audit.subheader')}
+          action={
+            <Button startIcon={<RefreshIcon />} onClick={fetchLogs}>
+              {t('common.refresh')}
+            </Button>
+          }
+        />
+        <CardContent>
+          <Stack spacing={2}>
+            <Grid container spacing={2}>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>{renderSelect('actor', options.actors)}</Grid>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>{renderSelect('resourceType', options.resourceTypes)}</Grid>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>{renderSelect('action', options.actions)}</Grid>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>
+                {renderSelect('success', ['true', 'false'], (value) => t(`settings.audit.result.${value}`))}
+              </Grid>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>
+                <TextField
+                  fullWidth
+                  size="small"
+                  type="date"
+                  label={t('settings.audit.filters.start')}
+                  value={filters.start}
+                  onChange={handleFilterChange('start')}
+                  slotProps={{ inputLabel: { shrink: true } }}
+                />
+              </Grid>
+              <Grid size={{ xs: 12, sm: 6, md: 2 }}>
+                <TextField
+                  fullWidth
+                  size="small"
+                  type="date"
+                  label={t('settings.audit.filters.end')}
+                  value={filters.end}
+                  onChange={handleFilterChange('end')}
+                  slotProps={{ inputLabel: { shrink: true } }}
+                />
+              </Grid>
+            </Grid>
+
+            {logs.length === 0 ? (
+              <Alert severity="info">{t('settings.audit.empty')}</Alert>
+            ) : (
+              <TableContainer>
+                <Table size="small">
+                  <TableHead>
+                    <TableRow>
+                      <TableCell>{t('settings.audit.columns.time')}</TableCell>
+                      <TableCell>{t('settings.audit.columns.actor')}</TableCell>
+                      <TableCell>{t('settings.audit.columns.resource')}</TableCell>
+                      <TableCell>{t('settings.audit.columns.action')}</TableCell>
+                      <TableCell>{t('settings.audit.columns.result')}</TableCell>
+                      <TableCell>{t('settings.audit.columns.ip')}</TableCell>
+                    </TableRow>
+                  </TableHead>
+                  <TableBody>
+                    {logs.map((log) => (
+                      <TableRow key={log.id} hover sx={{ cursor: 'pointer' }} onClick={() => setDetail(log)}>
+                        <TableCell sx={{ whiteSpace: 'nowrap' }}>{new Date(log.createdAt).toLocaleString()}</TableCell>
+                        <TableCell>
+                          {log.actor}
+                          {log.actorType === 'access_key' && (
+                            <Chip size="small" variant="outlined" sx={{ ml: 1 }} label={`API Key #${log.accessKeyId}`} />
+                          )}
+                        </TableCell>
+                        <TableCell>
+                          {log.resourceType}
+                          {log.resourceId && (
+                            <Typography component="span" variant="body2" color="text.secondary" sx={{ ml: 0.5 }}>
+                              #{log.resourceId}
+                            </Typography>
+                          )}
+                        </TableCell>
+                        <TableCell>{log.action}</TableCell>
+                        <TableCell>
+                          <Chip
+                            size="small"
+                            color={log.success ? 'success' : 'error'}
+                            label={t(`settings.audit.result.${log.success ? 'true' : 'false'}`)}
+                          />
+                        </TableCell>
+                        <TableCell>{log.clientIp}</TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              </TableContainer>
+            )}
+            <TablePagination
+              component="div"
+              count={total}
+              page={page}
+              onPageChange={(_e, newPage) => setPage(newPage)}
+              rowsPerPage={pageSize}
+              onRowsPerPageChange={(e) => {
+                setPageSize(parseInt(e.target.value, 10));
+                setPage(0);
+              }}
+              rowsPerPageOptions={[20, 50, 100]}
+              labelRowsPerPage={t('components.pagination.rowsPerPage')}
+            />
+          </Stack>
+        </CardContent>
+      </Card>
+
+      <Card variant="outlined">
+        <CardHeader title={t('settings.audit.retention.title')} subheader={t('settings.audit.retention.subheader')} />
+        <CardContent>
+          <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2} alignItems={{ sm: 'center' }}>
+            <TextField
+              size="small"
+              type="number"
+              label={t('settings.audit.retention.days')}
+              value={retentionDays}
+              onChange={(e) => setRetentionDays(e.target.value)}
+              helperText={t('settings.audit.retention.helper')}
+              slotProps={{ htmlInput: { min: 0, max: 3650 } }}
+            />
+            <Button variant="contained" onClick={handleSaveRetention} disabled={saving || retentionDays === ''}>
+              {t('common.save')}
+            </Button>
+          </Stack>
+        </CardContent>
+      </Card>
+
+      <Dialog open={Boolean(detail)} onClose={() => setDetail(null)} maxWidth="md" fullWidth>
+        <DialogTitle>{detail && `${detail.resourceType} · ${detail.action}`}</DialogTitle>
+        <DialogContent dividers>
+          {detail && (
+            <Stack spacing={2}>
+              <Typography variant="body2" color="text.secondary">
+                {detail.method} {detail.path}
+                {detail.message && ` · ${detail.message}`}
+              </Typography>
+              <Grid container spacing={2}>
+                {['before', 'after'].map((key) => (
+                  <Grid key={key} size={{ xs: 12, md: 6 }}>
+                    <Typography variant="subtitle2" gutterBottom>
+                      {t(`settings.audit.detail.${key}`)}
+                    </Typography>
+                    <Box
+                      component="pre"
+                      sx={{ m: 0, p: 1.5, bgcolor: 'action.hover', borderRadius: 1, fontSize: 12, overflow: 'auto', maxHeight: 400 }}
+                    >
+                      {formatSummary(detail[key]) || t('common.none')}
+                    </Box>
+                  </Grid>
+                ))}
+              </Grid>
+            </Stack>
+          )}
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setDetail(null)}>{t('common.close')}</Button>
+        </DialogActions>
+      </Dialog>
+    </Stack>
+  );
+}
+
+AuditLogSettings.propTypes = {
+  showMessage: PropTypes.func.isRequired
+};
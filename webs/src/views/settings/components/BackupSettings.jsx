@@ -0,0 +1,543 @@
+import { useCallback, useEffect, useRef, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+import Alert from '@mui/material/Alert';
+import Box from '@mui/material/Box';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Checkbox from '@mui/material/Checkbox';
+import Chip from '@mui/material/Chip';
+import CircularProgress from '@mui/material/CircularProgress';
+import Divider from '@mui/material/Divider';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import IconButton from '@mui/material/IconButton';
+import Stack from '@mui/material/Stack';
+import Switch from '@mui/material/Switch';
+import Table from '@mui/material/Table';
+import TableBody from '@mui/material/TableBody';
+import TableCell from '@mui/material/TableCell';
+import TableHead from '@mui/material/TableHead';
+import TableRow from '@mui/material/TableRow';
+import TextField from '@mui/material/TextField';
+import Tooltip from '@mui/material/Tooltip';
+import Typography from '@mui/material/Typography';
+
+import BackupIcon from '@mui/icons-material/Backup';
+import CloudUploadIcon from '@mui/icons-material/CloudUpload';
+import DownloadIcon from '@mui/icons-material/Download';
+import KeyIcon from '@mui/icons-material/Key';
+import PlayArrowIcon from '@mui/icons-material/PlayArrow';
+import RefreshIcon from '@mui/icons-material/Refresh';
+import RestoreIcon from '@mui/icons-material/Restore';
+import SaveIcon from '@mui/icons-material/Save';
+import ScienceIcon from '@mui/icons-material/Science';
+
+import {
+  checkBackupKey,
+  downloadBackupFile,
+  getBackupList,
+  getBackupSettings,
+  restoreBackup,
+  runBackup,
+  testBackupS3,
+  updateBackupSettings
+} from 'api/backup';
+import { useTaskProgress } from 'contexts/TaskProgressContext';
+
+const defaultForm = {
+  enabled: false,
+  cron: '0 4 * * *',
+  retentionDays: 7,
+  dir: '',
+  s3: { enabled: false, endpoint: '', region: 'us-east-1', bucket: '', prefix: '', accessKeyId: '', secretAccessKey: '', pathStyle: true },
+  encryption: { enabled: false, passphrase: '' }
+};
+
+const formatSize = (size) => `${((size || 0) / 1024 / 1024).toFixed(2)} MB`;
+
+const saveBlob = (blob, filename) => {
+  const url = window.URL.createObjectURL(blob);
+  const link = document.createElement('a');
+  link.href = url;
+  link.download = filename;
+  document.body.appendChild(link);
+  link.click();
+  document.body.removeChild(link);
+  window.URL.revokeObjectURL(url);
+};
+
+export default function BackupSettings({ showMessage }) {
+  const { t } = useTranslation();
+  const fileInputRef = useRef(null);
+  const { registerOnComplete, unregisterOnComplete } = useTaskProgress();
+
+  const [form, setForm] = useState(defaultForm);
+  const [meta, setMeta] = useState({
+    effectiveDir: '',
+    hasS3Secret: false,
+    maskedS3Secret: '',
+    s3SecretInvalid: false,
+    hasPassphrase: false,
+    passphraseInvalid: false
+  });
+  const [backups, setBackups] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [saving, setSaving] = useState(false);
+  const [testing, setTesting] = useState(false);
+  const [running, setRunning] = useState(false);
+  const [restoring, setRestoring] = useState(false);
+  const [selectedFile, setSelectedFile] = useState(null);
+  const [confirmRestore, setConfirmRestore] = useState(false);
+  const [restorePassphrase, setRestorePassphrase] = useState('');
+  const [checkingKey, setCheckingKey] = useState(false);
+
+  const syncSettings = useCallback((data) => {
+    if (!data) return;
+    setForm({
+      enabled: Boolean(data.enabled),
+      cron: data.cron || defaultForm.cron,
+      retentionDays: data.retentionDays ?? defaultForm.retentionDays,
+      dir: data.dir || '',
+      s3: { ...defaultForm.s3, ...(data.s3 || {}), secretAccessKey: '' },
+      encryption: { enabled: Boolean(data.encryption?.enabled), passphrase: '' }
+    });
+    setMeta({
+      effectiveDir: data.effectiveDir || '',
+      hasS3Secret: Boolean(data.hasS3Secret),
+      maskedS3Secret: data.maskedS3Secret || '',
+      s3SecretInvalid: Boolean(data.s3SecretInvalid),
+      hasPassphrase: Boolean(data.hasPassphrase),
+      passphraseInvalid: Boolean(data.passphraseInvalid)
+    });
+  }, []);
+
+  const fetchBackups = useCallback(async () => {
+    try {
+      const response = await getBackupList();
+      setBackups(response.data || []);
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.listFailed'), 'error');
+    }
+  }, [showMessage, t]);
+
+  const fetchAll = useCallback(async () => {
+    setLoading(true);
+    try {
+      const response = await getBackupSettings();
+      syncSettings(response.data);
+      await fetchBackups();
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.loadFailed'), 'error');
+    } finally {
+      setLoading(false);
+    }
+  }, [fetchBackups, showMessage, syncSettings, t]);
+
+  useEffect(() => {
+    fetchAll();
+  }, [fetchAll]);
+
+  useEffect(() => {
+    const handleTaskComplete = ({ taskType, status, result }) => {
+      if (taskType !== 'backup_restore') {
+        return;
+      }
+      if (status === 'completed') {
+        const warningCount = result?.warnings?.length || 0;
+        showMessage(
+          warningCount > 0
+            ? t('settings.backup.messages.restoreCompletedWithWarnings', { count: warningCount })
+            : t('settings.backup.messages.restoreCompleted'),
+          warningCount > 0 ? 'warning' : 'success'
+        );
+        fetchBackups();
+        return;
+      }
+      if (status === 'error') {
+        showMessage(t('settings.backup.messages.restoreFailed'), 'error');
+      }
+    };
+
+    registerOnComplete(handleTaskComplete);
+    return () => {
+      unregisterOnComplete(handleTaskComplete);
+    };
+  }, [fetchBackups, registerOnComplete, showMessage, t, unregisterOnComplete]);
+
+  const setField = (key) => (event) => {
+    const value = event.target.type === 'checkbox' ? event.target.checked : event.target.value;
+    setForm((prev) => ({ ...prev, [key]: value }));
+  };
+
+  const setS3Field = (key) => (event) => {
+    const value = event.target.type === 'checkbox' ? event.target.checked : event.target.value;
+    setForm((prev) => ({ ...prev, s3: { ...prev.s3, [key]: value } }));
+  };
+
+  const setEncryptionField = (key) => (event) => {
+    const value = event.target.type === 'checkbox' ? event.target.checked : event.target.value;
+    setForm((prev) => ({ ...prev, encryption: { ...prev.encryption, [key]: value } }));
+  };
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      const response = await updateBackupSettings({ ...form, retentionDays: Number(form.retentionDays) || 0 });
+      syncSettings(response.data);
+      showMessage(t('settings.backup.messages.saved'));
+    } catch (error) {
+      showMessage(error.message || t('common.saveFailed'), 'error');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleTestS3 = async () => {
+    setTesting(true);
+    try {
+      const response = await testBackupS3(form.s3);
+      showMessage(t('settings.backup.messages.s3TestSuccess', { count: response.data?.objects ?? 0 }));
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.s3TestFailed'), 'error');
+    } finally {
+      setTesting(false);
+    }
+  };
+
+  const handleRunNow = async () => {
+    setRunning(true);
+    try {
+      const response = await runBackup();
+      const warnings = response.data?.warnings || [];
+      showMessage(
+        warnings.length > 0
+          ? t('settings.backup.messages.runWithWarnings', { name: response.data?.file?.name, warning: warnings[0] })
+          : t('settings.backup.messages.runSuccess', { name: response.data?.file?.name }),
+        warnings.length > 0 ? 'warning' : 'success'
+      );
+      fetchBackups();
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.runFailed'), 'error');
+    } finally {
+      setRunning(false);
+    }
+  };
+
+  const handleDownload = async (name) => {
+    try {
+      const data = await downloadBackupFile(name);
+      if (!(data instanceof Blob) || data.size === 0) {
+        showMessage(t('profile.backup.invalidFile'), 'error');
+        return;
+      }
+      saveBlob(data, name);
+    } catch (error) {
+      showMessage(error.message || t('profile.backup.requestFailed'), 'error');
+    }
+  };
+
+  const buildSourceForm = (source) => {
+    const formData = new FormData();
+    formData.append(typeof source === 'string' ? 'name' : 'file', source);
+    if (restorePassphrase) {
+      formData.append('passphrase', restorePassphrase);
+    }
+    return formData;
+  };
+
+  const handleCheckKey = async (source) => {
+    if (!source) {
+      showMessage(t('settings.backup.messages.fileRequired'), 'error');
+      return;
+    }
+    setCheckingKey(true);
+    try {
+      const response = await checkBackupKey(buildSourceForm(source));
+      showMessage(
+        response.data?.encrypted ? t('settings.backup.messages.keyValid') : t('settings.backup.messages.keyNotEncrypted'),
+        'success'
+      );
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.keyInvalid'), 'error');
+    } finally {
+      setCheckingKey(false);
+    }
+  };
+
+  const startRestore = async (formData) => {
+    if (!confirmRestore) {
+      showMessage(t('settings.backup.messages.confirmRequired'), 'error');
+      return;
+    }
+    setRestoring(true);
+    try {
+      await restoreBackup(formData);
+      showMessage(t('settings.backup.messages.restoreStarted'));
+      setSelectedFile(null);
+      setConfirmRestore(false);
+      setRestorePassphrase('');
+    } catch (error) {
+      showMessage(error.message || t('settings.backup.messages.restoreStartFailed'), 'error');
+    } finally {
+      setRestoring(false);
+    }
+  };
+
+  const handleRestoreUpload = () => {
+    if (!selectedFile) {
+      showMessage(t('settings.backup.messages.fileRequired'), 'error');
+      return;
+    }
+    startRestore(buildSourceForm(selectedFile));
+  };
+
+  const handleRestoreLocal = (name) => {
+    startRestore(buildSourceForm(name));
+  };
+
+  const busy = saving || testing || running || restoring || checkingKey;
+
+  return (
+    <Card variant="outlined" sx={{ mb: 3 }}>
+      <CardHeader
+        avatar={<BackupIcon color="primary" />}
+        title={t('settings.backup.title')}
+        subheader={t('settings.backup.subheader')}
+        action={
+          <Tooltip title={t('settings.backup.actions.refresh')}>
+            <IconButton type="button" onClick={fetchAll} disabled={loading || busy}>
+              <RefreshIcon />
+            </IconButton>
+          </Tooltip>
+        }
+      />
+      <CardContent>
+        {loading ? (
+          <Box sx={{ display: 'flex', justifyContent: 'center', py: 4 }}>
+            <CircularProgress size={28} />
+          </Box>
+        ) : (
+          <Stack spacing={2.5}>
+            <FormControlLabel
+              control={<Switch checked={form.enabled} onChange={setField('enabled')} />}
+              label={t('settings.backup.form.enabled')}
+            />
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('settings.backup.form.cron')}
+                value={form.cron}
+                onChange={setField('cron')}
+                helperText={t('settings.backup.form.cronHelper')}
+                fullWidth
+              />
+              <TextField
+                label={t('settings.backup.form.retentionDays')}
+                type="number"
+                value={form.retentionDays}
+                onChange={setField('retentionDays')}
+                helperText={t('settings.backup.form.retentionDaysHelper')}
+                inputProps={{ min: 0 }}
+                fullWidth
+              />
+            </Stack>
+            <TextField
+              label={t('settings.backup.form.dir')}
+              value={form.dir}
+              onChange={setField('dir')}
+              placeholder={meta.effectiveDir}
+              helperText={t('settings.backup.form.dirHelper', { dir: meta.effectiveDir })}
+              fullWidth
+            />
+
+            <Divider textAlign="left">{t('settings.backup.encryption.title')}</Divider>
+            <FormControlLabel
+              control={<Switch checked={form.encryption.enabled} onChange={setEncryptionField('enabled')} />}
+              label={t('settings.backup.encryption.enabled')}
+            />
+            {form.encryption.enabled && (
+              <Stack spacing={2}>
+                {meta.passphraseInvalid && <Alert severity="warning">{t('settings.backup.encryption.passphraseInvalid')}</Alert>}
+                <Alert severity="info">{t('settings.backup.encryption.alert')}</Alert>
+                <TextField
+                  label={t('settings.backup.encryption.passphrase')}
+                  type="password"
+                  value={form.encryption.passphrase}
+                  onChange={setEncryptionField('passphrase')}
+                  placeholder={meta.hasPassphrase ? '••••••••' : ''}
+                  helperText={t(
+                    meta.hasPassphrase ? 'settings.backup.encryption.passphraseKeepHelper' : 'settings.backup.encryption.passphraseHelper'
+                  )}
+                  autoComplete="new-password"
+                  fullWidth
+                />
+              </Stack>
+            )}
+
+            <Divider textAlign="left">{t('settings.backup.s3.title')}</Divider>
+            <FormControlLabel
+              control={<Switch checked={form.s3.enabled} onChange={setS3Field('enabled')} />}
+              label={t('settings.backup.s3.enabled')}
+            />
+            {form.s3.enabled && (
+              <Stack spacing={2}>
+                {meta.s3SecretInvalid && <Alert severity="warning">{t('settings.backup.s3.secretInvalid')}</Alert>}
+                <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+                  <TextField
+                    label={t('settings.backup.s3.endpoint')}
+                    value={form.s3.endpoint}
+                    onChange={setS3Field('endpoint')}
+                    placeholder="https://s3.example.com"
+                    fullWidth
+                  />
+                  <TextField label={t('settings.backup.s3.region')} value={form.s3.region} onChange={setS3Field('region')} fullWidth />
+                </Stack>
+                <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+                  <TextField label={t('settings.backup.s3.bucket')} value={form.s3.bucket} onChange={setS3Field('bucket')} fullWidth />
+                  <TextField label={t('settings.backup.s3.prefix')} value={form.s3.prefix} onChange={setS3Field('prefix')} fullWidth />
+                </Stack>
+                <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+                  <TextField
+                    label={t('settings.backup.s3.accessKeyId')}
+                    value={form.s3.accessKeyId}
+                    onChange={setS3Field('accessKeyId')}
+                    fullWidth
+                  />
+                  <TextField
+                    label={t('settings.backup.s3.secretAccessKey')}
+                    type="password"
+                    value={form.s3.secretAccessKey}
+                    onChange={setS3Field('secretAccessKey')}
+                    placeholder={meta.hasS3Secret ? meta.maskedS3Secret : ''}
+                    helperText={meta.hasS3Secret ? t('settings.backup.s3.secretKeepHelper') : ''}
+                    fullWidth
+                  />
+                </Stack>
+                <FormControlLabel
+                  control={<Switch checked={form.s3.pathStyle} onChange={setS3Field('pathStyle')} />}
+                  label={t('settings.backup.s3.pathStyle')}
+                />
+              </Stack>
+            )}
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5} justifyContent="flex-end">
+              {form.s3.enabled && (
+                <Button variant="outlined" startIcon={<ScienceIcon />} onClick={handleTestS3} disabled={busy}>
+                  {testing ? t('settings.backup.actions.testing') : t('settings.backup.actions.testS3')}
+                </Button>
+              )}
+              <Button variant="outlined" startIcon={<PlayArrowIcon />} onClick={handleRunNow} disabled={busy}>
+                {running ? t('settings.backup.actions.running') : t('settings.backup.actions.runNow')}
+              </Button>
+              <Button variant="contained" startIcon={<SaveIcon />} onClick={handleSave} disabled={busy}>
+                {saving ? t('common.saving') : t('common.save')}
+              </Button>
+            </Stack>
+
+            <Divider textAlign="left">{t('settings.backup.list.title')}</Divider>
+            {backups.length === 0 ? (
+              <Typography variant="body2" color="text.secondary">
+                {t('settings.backup.list.empty')}
+              </Typography>
+            ) : (
+              <Box sx={{ overflowX: 'auto' }}>
+                <Table size="small">
+                  <TableHead>
+                    <TableRow>
+                      <TableCell>{t('settings.backup.list.name')}</TableCell>
+                      <TableCell>{t('settings.backup.list.size')}</TableCell>
+                      <TableCell>{t('settings.backup.list.createdAt')}</TableCell>
+                      <TableCell align="right">{t('settings.backup.list.actions')}</TableCell>
+                    </TableRow>
+                  </TableHead>
+                  <TableBody>
+                    {backups.map((file) => (
+                      <TableRow key={file.name}>
+                        <TableCell sx={{ wordBreak: 'break-all' }}>{file.name}</TableCell>
+                        <TableCell>{formatSize(file.size)}</TableCell>
+                        <TableCell>{new Date(file.createdAt).toLocaleString()}</TableCell>
+                        <TableCell align="right">
+                          <Tooltip title={t('settings.backup.actions.download')}>
+                            <IconButton size="small" onClick={() => handleDownload(file.name)} disabled={busy}>
+                              <DownloadIcon fontSize="small" />
+                            </IconButton>
+                          </Tooltip>
+                          {file.name.endsWith('.enc') && (
+                            <Tooltip title={t('settings.backup.actions.checkKey')}>
+                              <IconButton size="small" onClick={() => handleCheckKey(file.name)} disabled={busy}>
+                                <KeyIcon fontSize="small" />
+                              </IconButton>
+                            </Tooltip>
+                          )}
+                          <Tooltip title={t('settings.backup.actions.restoreThis')}>
+                            <IconButton size="small" color="error" onClick={() => handleRestoreLocal(file.name)} disabled={busy}>
+                              <RestoreIcon fontSize="small" />
+                            </IconButton>
+                          </Tooltip>
+                        </TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              </Box>
+            )}
+
+            <Divider textAlign="left">{t('settings.backup.restore.title')}</Divider>
+            <Alert severity="warning">{t('settings.backup.restore.alert')}</Alert>
+            <input
+              ref={fileInputRef}
+              type="file"
+              hidden
+              accept=".zip,.enc"
+              onChange={(event) => setSelectedFile(event.target.files?.[0] || null)}
+            />
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5} alignItems={{ xs: 'stretch', sm: 'center' }}>
+              <Button variant="outlined" startIcon={<CloudUploadIcon />} onClick={() => fileInputRef.current?.click()} disabled={busy}>
+                {t('settings.backup.actions.chooseFile')}
+              </Button>
+              {selectedFile && (
+                <Chip color="primary" variant="outlined" label={`${selectedFile.name} · ${formatSize(selectedFile.size)}`} />
+              )}
+            </Stack>
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5} alignItems={{ xs: 'stretch', sm: 'flex-start' }}>
+              <TextField
+                label={t('settings.backup.restore.passphrase')}
+                type="password"
+                value={restorePassphrase}
+                onChange={(event) => setRestorePassphrase(event.target.value)}
+                helperText={t('settings.backup.restore.passphraseHelper')}
+                autoComplete="off"
+                fullWidth
+              />
+              {selectedFile && (
+                <Button
+                  variant="outlined"
+                  startIcon={<KeyIcon />}
+                  onClick={() => handleCheckKey(selectedFile)}
+                  disabled={busy}
+                  sx={{ flexShrink: 0, mt: { sm: 1 } }}
+                >
+                  {checkingKey ? t('settings.backup.actions.checkingKey') : t('settings.backup.actions.checkKey')}
+                </Button>
+              )}
+            </Stack>
+            <FormControlLabel
+              control={<Checkbox checked={confirmRestore} onChange={(event) => setConfirmRestore(event.target.checked)} color="error" />}
+              label={
+                <Typography color="error.main" fontWeight={600}>
+                  {t('settings.backup.restore.confirm')}
+                </Typography>
+              }
+            />
+            <Box sx={{ display: 'flex', justifyContent: 'flex-end' }}>
+              <Button variant="contained" color="error" startIcon={<RestoreIcon />} onClick={handleRestoreUpload} disabled={busy}>
+                {restoring ? t('settings.backup.actions.submitting') : t('settings.backup.actions.restoreUpload')}
+              </Button>
+            </Box>
+          </Stack>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,217 @@
+import { useRef, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+import Alert from '@mui/material/Alert';
+import Box from '@mui/material/Box';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Checkbox from '@mui/material/Checkbox';
+import Chip from '@mui/material/Chip';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import Stack from '@mui/material/Stack';
+import Typography from '@mui/material/Typography';
+
+import AccountTreeIcon from '@mui/icons-material/AccountTree';
+import CloudUploadIcon from '@mui/icons-material/CloudUpload';
+import DownloadIcon from '@mui/icons-material/Download';
+import FactCheckIcon from '@mui/icons-material/FactCheck';
+import PlayArrowIcon from '@mui/icons-material/PlayArrow';
+
+import { applyConfiguration, exportConfiguration, planConfiguration } from 'api/gitops';
+
+const actionColors = { create: 'success', update: 'info', delete: 'error' };
+const actionSymbols = { create: '+', update: '~', delete: '-' };
+
+const saveBlob = (blob, filename) => {
+  const url = window.URL.createObjectURL(blob);
+  const link = document.createElement('a');
+  link.href = url;
+  link.download = filename;
+  document.body.appendChild(link);
+  link.click();
+  document.body.removeChild(link);
+  window.URL.revokeObjectURL(url);
+};
+
+const pad = (value) => String(value).padStart(2, '0');
+
+const exportFileName = () => {
+  const now = new Date();
+  const date = `${now.getFullYear()}${pad(now.getMonth() + 1)}${pad(now.getDate())}`;
+  const time = `${pad(now.getHours())}${pad(now.getMinutes())}${pad(now.getSeconds())}`;
+  return `sublinkpro-config-${date}-${time}.yaml`;
+};
+
+export default function GitOpsSettings({ showMessage }) {
+  const { t } = useTranslation();
+  const fileInputRef = useRef(null);
+
+  const [selectedFile, setSelectedFile] = useState(null);
+  const [prune, setPrune] = useState(false);
+  const [exporting, setExporting] = useState(false);
+  const [planning, setPlanning] = useState(false);
+  const [applying, setApplying] = useState(false);
+  const [plan, setPlan] = useState(null);
+
+  const buildForm = () => {
+    const formData = new FormData();
+    formData.append('file', selectedFile);
+    return formData;
+  };
+
+  const handleExport = async () => {
+    setExporting(true);
+    try {
+      const data = await exportConfiguration();
+      if (!(data instanceof Blob) || data.size === 0) {
+        showMessage(t('settings.gitops.messages.exportFailed'), 'error');
+        return;
+      }
+      saveBlob(data, exportFileName());
+    } catch (error) {
+      showMessage(error.message || t('settings.gitops.messages.exportFailed'), 'error');
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  const handleFileChange = (event) => {
+    setSelectedFile(event.target.files?.[0] || null);
+    setPlan(null);
+    event.target.value = '';
+  };
+
+  const handlePruneChange = (event) => {
+    setPrune(event.target.checked);
+    setPlan(null);
+  };
+
+  const handlePlan = async () => {
+    if (!selectedFile) {
+      showMessage(t('settings.gitops.messages.fileRequired'), 'error');
+      return;
+    }
+    setPlanning(true);
+    setPlan(null);
+    try {
+      const response = await planConfiguration(buildForm(), prune);
+      setPlan(response.data || null);
+    } catch (error) {
+      showMessage(error.message || t('settings.gitops.messages.planFailed'), 'error');
+    } finally {
+      setPlanning(false);
+    }
+  };
+
+  const handleApply = async () => {
+    setApplying(true);
+    try {
+      const response = await applyConfiguration(buildForm(), prune);
+      const result = response.data || null;
+      setPlan(result);
+      showMessage(t('settings.gitops.messages.applied'), result?.warnings?.length > 0 ? 'warning' : 'success');
+    } catch (error) {
+      showMessage(error.message || t('settings.gitops.messages.applyFailed'), 'error');
+    } finally {
+      setApplying(false);
+    }
+  };
+
+  const busy = exporting || planning || applying;
+  const hasChanges = plan && plan.create + plan.update + plan.delete > 0;
+
+  return (
+    <Card variant="outlined" sx={{ mt: 3 }}>
+      <CardHeader
+        avatar={<AccountTreeIcon color="primary" />}
+        title={t('settings.gitops.title')}
+        subheader={t('settings.gitops.subheader')}
+      />
+      <CardContent>
+        <Stack spacing={2.5}>
+          <Alert severity="info">{t('settings.gitops.alerts.intro')}</Alert>
+          <Alert severity="warning">{t('settings.gitops.alerts.secrets')}</Alert>
+
+          <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5} alignItems={{ xs: 'stretch', sm: 'center' }}>
+            <Button variant="outlined" startIcon={<DownloadIcon />} onClick={handleExport} disabled={busy}>
+              {exporting ? t('settings.gitops.actions.exporting') : t('settings.gitops.actions.export')}
+            </Button>
+            <input ref={fileInputRef} type="file" hidden accept=".yaml,.yml" onChange={handleFileChange} />
+            <Button variant="outlined" startIcon={<CloudUploadIcon />} onClick={() => fileInputRef.current?.click()} disabled={busy}>
+              {t('settings.gitops.actions.chooseFile')}
+            </Button>
+            {selectedFile && <Chip color="primary" variant="outlined" label={selectedFile.name} />}
+          </Stack>
+
+          <Stack spacing={0.5}>
+            <FormControlLabel
+              control={<Checkbox checked={prune} onChange={handlePruneChange} color="error" />}
+              label={t('settings.gitops.form.prune')}
+            />
+            <Typography variant="caption" color="text.secondary" sx={{ ml: 4 }}>
+              {t('settings.gitops.form.pruneHelper')}
+            </Typography>
+          </Stack>
+
+          {plan && (
+            <Stack spacing={1.5}>
+              <Alert severity={plan.applied || !hasChanges ? 'success' : 'info'}>
+                {t(plan.applied ? 'settings.gitops.plan.appliedSummary' : 'settings.gitops.plan.summary', {
+                  create: plan.create,
+                  update: plan.update,
+                  delete: plan.delete,
+                  unchanged: plan.unchanged
+                })}
+              </Alert>
+              {plan.changes?.length > 0 && (
+                <Box sx={{ maxHeight: 320, overflow: 'auto', border: 1, borderColor: 'divider', borderRadius: 1, p: 1.5 }}>
+                  {plan.changes.map((change, index) => (
+                    <Stack key={`${change.kind}-${change.name}-${index}`} direction="row" spacing={1} alignItems="center" sx={{ py: 0.25 }}>
+                      <Chip size="small" color={actionColors[change.action]} label={actionSymbols[change.action]} sx={{ minWidth: 32 }} />
+                      <Typography variant="body2" sx={{ fontFamily: 'monospace' }}>
+                        {change.kind}/{change.name}
+                      </Typography>
+                      {change.fields?.length > 0 && (
+                        <Typography variant="caption" color="text.secondary">
+                          ({change.fields.join(', ')})
+                        </Typography>
+                      )}
+                    </Stack>
+                  ))}
+                </Box>
+              )}
+              {plan.warnings?.length > 0 && (
+                <Alert severity="warning">
+                  <Box component="ul" sx={{ m: 0, pl: 2.5 }}>
+                    {plan.warnings.map((warning, index) => (
+                      <Box component="li" key={`${warning}-${index}`}>
+                        <Typography variant="body2">{warning}</Typography>
+                      </Box>
+                    ))}
+                  </Box>
+                </Alert>
+              )}
+            </Stack>
+          )}
+
+          <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5} justifyContent="flex-end">
+            <Button variant="outlined" startIcon={<FactCheckIcon />} onClick={handlePlan} disabled={busy || !selectedFile}>
+              {planning ? t('settings.gitops.actions.planning') : t('settings.gitops.actions.plan')}
+            </Button>
+            <Button
+              variant="contained"
+              color={plan?.delete > 0 ? 'error' : 'primary'}
+              startIcon={<PlayArrowIcon />}
+              onClick={handleApply}
+              disabled={busy || !hasChanges || plan?.applied}
+            >
+              {applying ? t('settings.gitops.actions.applying') : t('settings.gitops.actions.apply')}
+            </Button>
+          </Stack>
+        </Stack>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,211 @@
+import { useState, useEffect, useCallback } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+import Alert from '@mui/material/Alert';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Chip from '@mui/material/Chip';
+import Dialog from '@mui/material/Dialog';
+import DialogActions from '@mui/material/DialogActions';
+import DialogContent from '@mui/material/DialogContent';
+import DialogTitle from '@mui/material/DialogTitle';
+import IconButton from '@mui/material/IconButton';
+import List from '@mui/material/List';
+import ListItem from '@mui/material/ListItem';
+import ListItemText from '@mui/material/ListItemText';
+import Stack from '@mui/material/Stack';
+import TextField from '@mui/material/TextField';
+import Tooltip from '@mui/material/Tooltip';
+
+import DeleteOutlineIcon from '@mui/icons-material/DeleteOutline';
+import FingerprintIcon from '@mui/icons-material/Fingerprint';
+
+import { beginPasskeyRegistration, deletePasskey, finishPasskeyRegistration, getPasskeys } from 'api/auth';
+import { createPasskeyCredential, isPasskeySupported } from 'utils/webauthn';
+
+// ==============================|| 通行密钥管理 ||============================== //
+
+export default function PasskeySettings({ showMessage, loading, setLoading, totpEnabled }) {
+  const { t } = useTranslation();
+  const [passkeys, setPasskeys] = useState([]);
+  const [passwordless, setPasswordless] = useState(false);
+  // dialog.mode: add 注册新通行密钥，delete 删除指定通行密钥
+  const [dialog, setDialog] = useState({ open: false, mode: 'add', passkey: null });
+  const [name, setName] = useState('');
+  const [password, setPassword] = useState('');
+  const [code, setCode] = useState('');
+
+  const fetchPasskeys = useCallback(async () => {
+    try {
+      const response = await getPasskeys();
+      setPasskeys(response.data?.passkeys || []);
+      setPasswordless(Boolean(response.data?.passwordless));
+    } catch (error) {
+      console.error('获取通行密钥失败:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchPasskeys();
+  }, [fetchPasskeys]);
+
+  const openDialog = (mode, passkey = null) => {
+    setName('');
+    setPassword('');
+    setCode('');
+    setDialog({ open: true, mode, passkey });
+  };
+
+  const closeDialog = () => setDialog((prev) => ({ ...prev, open: false }));
+
+  const handleAdd = async () => {
+    const begin = await beginPasskeyRegistration({ password, code });
+    const credential = await createPasskeyCredential(begin.data.options);
+    await finishPasskeyRegistration({
+      challengeToken: begin.data.challengeToken,
+      name: name.trim(),
+      credential
+    });
+    showMessage(t('settings.profilePanel.passkeys.messages.added'));
+  };
+
+  const handleDelete = async () => {
+    await deletePasskey({ id: dialog.passkey.id, password, code });
+    showMessage(t('settings.profilePanel.passkeys.messages.deleted'));
+  };
+
+  const handleSubmit = async () => {
+    if (!password) {
+      showMessage(t('settings.profilePanel.messages.currentPasswordRequired'), 'warning');
+      return;
+    }
+    setLoading(true);
+    try {
+      if (dialog.mode === 'add') {
+        await handleAdd();
+      } else {
+        await handleDelete();
+      }
+      closeDialog();
+      fetchPasskeys();
+    } catch (error) {
+      showMessage(error.message || t('settings.profilePanel.passkeys.messages.failed'), 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const formatTime = (value) => (value ? new Date(value).toLocaleString() : t('settings.profilePanel.passkeys.neverUsed'));
+
+  return (
+    <Card variant="outlined">
+      <CardHeader
+        title={t('settings.profilePanel.passkeys.title')}
+        subheader={t('settings.profilePanel.passkeys.subheader')}
+        avatar={<FingerprintIcon color="primary" />}
+        action={<Chip label={passkeys.length} size="small" variant="outlined" />}
+      />
+      <CardContent>
+        <Stack spacing={2}>
+          {!isPasskeySupported() && <Alert severity="warning">{t('settings.profilePanel.passkeys.unsupported')}</Alert>}
+          {passwordless && <Alert severity="info">{t('settings.profilePanel.passkeys.passwordlessHint')}</Alert>}
+
+          {passkeys.length > 0 ? (
+            <List dense disablePadding>
+              {passkeys.map((passkey) => (
+                <ListItem
+                  key={passkey.id}
+                  disableGutters
+                  secondaryAction={
+                    <Tooltip title={t('common.delete')}>
+                      <IconButton edge="end" onClick={() => openDialog('delete', passkey)} disabled={loading}>
+                        <DeleteOutlineIcon fontSize="small" />
+                      </IconButton>
+                    </Tooltip>
+                  }
+                >
+                  <ListItemText
+                    primary={passkey.name}
+                    secondary={t('settings.profilePanel.passkeys.usage', {
+                      created: formatTime(passkey.createdAt),
+                      lastUsed: formatTime(passkey.lastUsedAt)
+                    })}
+                  />
+                </ListItem>
+              ))}
+            </List>
+          ) : (
+            <Alert severity="info">{t('settings.profilePanel.passkeys.empty')}</Alert>
+          )}
+
+          <Button
+            variant="contained"
+            startIcon={<FingerprintIcon />}
+            onClick={() => openDialog('add')}
+            disabled={loading || !isPasskeySupported()}
+            sx={{ alignSelf: 'flex-start' }}
+          >
+            {t('settings.profilePanel.passkeys.add')}
+          </Button>
+        </Stack>
+      </CardContent>
+
+      <Dialog open={dialog.open} onClose={loading ? undefined : closeDialog} maxWidth="xs" fullWidth>
+        <DialogTitle>
+          {dialog.mode === 'add'
+            ? t('settings.profilePanel.passkeys.add')
+            : t('settings.profilePanel.passkeys.deleteTitle', { name: dialog.passkey?.name })}
+        </DialogTitle>
+        <DialogContent>
+          <Stack spacing={2} sx={{ mt: 1 }}>
+            {dialog.mode === 'add' && (
+              <TextField
+                fullWidth
+                label={t('settings.profilePanel.passkeys.name')}
+                value={name}
+                onChange={(e) => setName(e.target.value)}
+                placeholder={t('settings.profilePanel.passkeys.namePlaceholder')}
+                inputProps={{ maxLength: 64 }}
+              />
+            )}
+            <TextField
+              fullWidth
+              type="password"
+              label={t('settings.profilePanel.fields.currentPassword')}
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              autoComplete="current-password"
+            />
+            {totpEnabled && (
+              <TextField
+                fullWidth
+                label={t('settings.profilePanel.fields.currentCode')}
+                value={code}
+                onChange={(e) => setCode(e.target.value.replace(/\s+/g, '').slice(0, 8))}
+                inputProps={{ inputMode: 'numeric', pattern: '[0-9]*' }}
+              />
+            )}
+          </Stack>
+        </DialogContent>
+        <DialogActions sx={{ px: 3, py: 2 }}>
+          <Button onClick={closeDialog} color="inherit" disabled={loading}>
+            {t('common.cancel')}
+          </Button>
+          <Button variant="contained" color={dialog.mode === 'add' ? 'primary' : 'error'} onClick={handleSubmit} disabled={loading}>
+            {dialog.mode === 'add' ? t('settings.profilePanel.passkeys.register') : t('common.delete')}
+          </Button>
+        </DialogActions>
+      </Dialog>
+    </Card>
+  );
+}
+
+PasskeySettings.propTypes = {
+  showMessage: PropTypes.func.isRequired,
+  loading: PropTypes.bool,
+  setLoading: PropTypes.func.isRequired,
+  totpEnabled: PropTypes.bool
+};
@@ -44,6 +44,9 @@ import { useAuth } from 'contexts/AuthContext';
 import { changePassword, updateProfile } from 'api/user';
 import { QRCodeSVG } from 'qrcode.react';
 import { confirmTotpSetup, disableTotp, getTotpStatus, regenerateRecoveryCodes, setupTotp } from 'api/auth';
+import PasskeySettings from './PasskeySettings';
+import SessionSettings from './SessionSettings';
+import SsoLinkSettings from './SsoLinkSettings';
 
 export default function ProfileSettings({ showMessage, loading, setLoading }) {
   const { user, logout } = useAuth();
@@ -814,6 +817,12 @@ export default function ProfileSettings({ showMessage, loading, setLoading }) {
                       </CardContent>
                     </Card>
                   )}
+
+                  <PasskeySettings showMessage={showMessage} loading={loading} setLoading={setLoading} totpEnabled={totpStatus.enabled} />
+
+                  <SsoLinkSettings showMessage={showMessage} loading={loading} setLoading={setLoading} />
+
+                  <SessionSettings showMessage={showMessage} loading={loading} setLoading={setLoading} />
                 </Stack>
               </Grid>
             </Grid>
@@ -0,0 +1,132 @@
+import { useState, useEffect, useCallback } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+import Alert from '@mui/material/Alert';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Chip from '@mui/material/Chip';
+import IconButton from '@mui/material/IconButton';
+import List from '@mui/material/List';
+import ListItem from '@mui/material/ListItem';
+import ListItemText from '@mui/material/ListItemText';
+import Stack from '@mui/material/Stack';
+import Tooltip from '@mui/material/Tooltip';
+
+import DevicesIcon from '@mui/icons-material/Devices';
+import LogoutIcon from '@mui/icons-material/Logout';
+
+import { useAuth } from 'contexts/AuthContext';
+import { getSessions, revokeAllSessions, revokeSession } from 'api/user';
+
+// ==============================|| 登录会话管理 ||============================== //
+
+export default function SessionSettings({ showMessage, loading, setLoading }) {
+  const { t } = useTranslation();
+  const { logout } = useAuth();
+  const [sessions, setSessions] = useState([]);
+
+  const fetchSessions = useCallback(async () => {
+    try {
+      const response = await getSessions();
+      setSessions(response.data || []);
+    } catch (error) {
+      console.error('获取登录会话失败:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    fetchSessions();
+  }, [fetchSessions]);
+
+  const runAction = async (action, message, logoutAfter = false) => {
+    setLoading(true);
+    try {
+      await action();
+      showMessage(message);
+      if (logoutAfter) {
+        logout();
+        return;
+      }
+      fetchSessions();
+    } catch (error) {
+      showMessage(error.message || t('settings.profilePanel.sessions.messages.failed'), 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleRevoke = (session) =>
+    runAction(() => revokeSession(session.id), t('settings.profilePanel.sessions.messages.revoked'), session.current);
+
+  const handleRevokeOthers = () => runAction(() => revokeAllSessions(true), t('settings.profilePanel.sessions.messages.othersRevoked'));
+
+  const handleRevokeAll = () => runAction(() => revokeAllSessions(false), t('settings.profilePanel.sessions.messages.allRevoked'), true);
+
+  return (
+    <Card variant="outlined">
+      <CardHeader
+        title={t('settings.profilePanel.sessions.title')}
+        subheader={t('settings.profilePanel.sessions.subheader')}
+        avatar={<DevicesIcon color="primary" />}
+        action={<Chip label={sessions.length} size="small" variant="outlined" />}
+      />
+      <CardContent>
+        <Stack spacing={2}>
+          {sessions.length > 0 ? (
+            <List dense disablePadding>
+              {sessions.map((session) => (
+                <ListItem
+                  key={session.id}
+                  disableGutters
+                  secondaryAction={
+                    <Tooltip title={t('settings.profilePanel.sessions.revoke')}>
+                      <IconButton edge="end" onClick={() => handleRevoke(session)} disabled={loading}>
+                        <LogoutIcon fontSize="small" />
+                      </IconButton>
+                    </Tooltip>
+                  }
+                >
+                  <ListItemText
+                    primary={
+                      <Stack direction="row" spacing={1} alignItems="center">
+                        <span>{session.userAgent || t('settings.profilePanel.sessions.unknownDevice')}</span>
+                        {session.current && <Chip label={t('settings.profilePanel.sessions.current')} size="small" color="primary" />}
+                      </Stack>
+                    }
+                    secondary={t('settings.profilePanel.sessions.usage', {
+                      ip: session.clientIp || '-',
+                      created: new Date(session.createdAt).toLocaleString(),
+                      lastSeen: new Date(session.lastSeenAt).toLocaleString()
+                    })}
+                    slotProps={{ primary: { component: 'div' } }}
+                    sx={{ pr: 4 }}
+                  />
+                </ListItem>
+              ))}
+            </List>
+          ) : (
+            <Alert severity="info">{t('settings.profilePanel.sessions.empty')}</Alert>
+          )}
+
+          <Stack direction={{ xs: 'column', sm: 'row' }} spacing={1.5}>
+            <Button variant="outlined" onClick={handleRevokeOthers} disabled={loading || sessions.length <= 1}>
+              {t('settings.profilePanel.sessions.revokeOthers')}
+            </Button>
+            <Button variant="contained" color="error" startIcon={<LogoutIcon />} onClick={handleRevokeAll} disabled={loading}>
+              {t('settings.profilePanel.sessions.revokeAll')}
+            </Button>
+          </Stack>
+        </Stack>
+      </CardContent>
+    </Card>
+  );
+}
+
+SessionSettings.propTypes = {
+  showMessage: PropTypes.func.isRequired,
+  loading: PropTypes.bool,
+  setLoading: PropTypes.func.isRequired
+};
@@ -0,0 +1,92 @@
+import { useState, useEffect } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Chip from '@mui/material/Chip';
+
+import LinkIcon from '@mui/icons-material/Link';
+
+import { useAuth } from 'contexts/AuthContext';
+import { getOIDCStatus } from 'api/auth';
+import { getOIDCLinkURL } from 'api/user';
+
+// ==============================|| 单点登录关联 ||============================== //
+
+export default function SsoLinkSettings({ showMessage, loading, setLoading }) {
+  const { t } = useTranslation();
+  const { user } = useAuth();
+  const [enabled, setEnabled] = useState(false);
+
+  useEffect(() => {
+    getOIDCStatus()
+      .then((response) => setEnabled(Boolean(response.data?.enabled)))
+      .catch((err) => console.error('获取单点登录配置失败:', err));
+  }, []);
+
+  // 关联结果放在 URL fragment 中，读取后立即清除
+  useEffect(() => {
+    const hash = new URLSearchParams(window.location.hash.slice(1));
+    const linked = hash.get('oidc_linked');
+    const linkError = hash.get('oidc_link_error');
+    if (!linked && !linkError) {
+      return;
+    }
+    window.history.replaceState(null, '', window.location.pathname + window.location.search);
+    if (linkError) {
+      showMessage(t('settings.profilePanel.sso.messages.failed', { message: linkError }), 'error');
+    } else {
+      showMessage(t('settings.profilePanel.sso.messages.linked'));
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const handleLink = async () => {
+    setLoading(true);
+    try {
+      const response = await getOIDCLinkURL();
+      window.location.href = response.data.url;
+    } catch (error) {
+      showMessage(t('settings.profilePanel.sso.messages.failed', { message: error.message || '' }), 'error');
+      setLoading(false);
+    }
+  };
+
+  if (!enabled) {
+    return null;
+  }
+
+  const linked = Boolean(user?.oidcLinked);
+
+  return (
+    <Card variant="outlined">
+      <CardHeader
+        title={t('settings.profilePanel.sso.title')}
+        subheader={t('settings.profilePanel.sso.subheader')}
+        avatar={<LinkIcon color="primary" />}
+        action={
+          <Chip
+            label={linked ? t('settings.profilePanel.sso.linked') : t('settings.profilePanel.sso.notLinked')}
+            color={linked ? 'success' : 'default'}
+            size="small"
+            variant="outlined"
+          />
+        }
+      />
+      <CardContent>
+        <Button variant="outlined" onClick={handleLink} disabled={loading}>
+          {linked ? t('settings.profilePanel.sso.relink') : t('settings.profilePanel.sso.link')}
+        </Button>
+      </CardContent>
+    </Card>
+  );
+}
+
+SsoLinkSettings.propTypes = {
+  showMessage: PropTypes.func.isRequired,
+  loading: PropTypes.bool,
+  setLoading: PropTypes.func.isRequired
+};
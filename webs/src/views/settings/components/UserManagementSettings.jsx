@@ -0,0 +1,261 @@
+import { useCallback, useEffect, useState } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+// material-ui
+import Alert from '@mui/material/Alert';
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardContent from '@mui/material/CardContent';
+import CardHeader from '@mui/material/CardHeader';
+import Dialog from '@mui/material/Dialog';
+import DialogActions from '@mui/material/DialogActions';
+import DialogContent from '@mui/material/DialogContent';
+import DialogTitle from '@mui/material/DialogTitle';
+import IconButton from '@mui/material/IconButton';
+import MenuItem from '@mui/material/MenuItem';
+import Stack from '@mui/material/Stack';
+import Table from '@mui/material/Table';
+import TableBody from '@mui/material/TableBody';
+import TableCell from '@mui/material/TableCell';
+import TableContainer from '@mui/material/TableContainer';
+import TableHead from '@mui/material/TableHead';
+import TableRow from '@mui/material/TableRow';
+import TextField from '@mui/material/TextField';
+import Tooltip from '@mui/material/Tooltip';
+
+// icons
+import AddIcon from '@mui/icons-material/Add';
+import DeleteIcon from '@mui/icons-material/Delete';
+import GroupIcon from '@mui/icons-material/Group';
+import LockResetIcon from '@mui/icons-material/LockReset';
+
+// project imports
+import { createUser, deleteUser, getUsers, resetUserPassword, updateUserRole } from 'api/user';
+import { useAuth } from 'contexts/AuthContext';
+import ConfirmDialog from 'components/ConfirmDialog';
+
+const ROLES = ['admin', 'operator', 'viewer'];
+const EMPTY_FORM = { username: '', nickname: '', password: '', role: 'viewer' };
+
+// ==============================|| 用户与角色管理 ||============================== //
+
+export default function UserManagementSettings({ showMessage }) {
+  const { t } = useTranslation();
+  const { user: currentUser } = useAuth();
+  const [users, setUsers] = useState([]);
+  const [createOpen, setCreateOpen] = useState(false);
+  const [form, setForm] = useState(EMPTY_FORM);
+  const [resetTarget, setResetTarget] = useState(null);
+  const [newPassword, setNewPassword] = useState('');
+  const [deleteTarget, setDeleteTarget] = useState(null);
+  const [submitting, setSubmitting] = useState(false);
+
+  const fetchUsers = useCallback(async () => {
+    try {
+      const res = await getUsers();
+      setUsers(res.data?.list || []);
+    } catch (error) {
+      showMessage(error.message || t('settings.users.messages.loadFailed'), 'error');
+    }
+  }, [showMessage, t]);
+
+  useEffect(() => {
+    fetchUsers();
+  }, [fetchUsers]);
+
+  const handleRoleChange = async (target, role) => {
+    try {
+      await updateUserRole(target.ID, role);
+      showMessage(t('settings.users.messages.roleUpdated'));
+      fetchUsers();
+    } catch (error) {
+      showMessage(error.message || t('settings.users.messages.operationFailed'), 'error');
+    }
+  };
+
+  const handleCreate = async () => {
+    setSubmitting(true);
+    try {
+      await createUser(form);
+      showMessage(t('settings.users.messages.created'));
+      setCreateOpen(false);
+      setForm(EMPTY_FORM);
+      fetchUsers();
+    } catch (error) {
+      showMessage(error.message || t('settings.users.messages.operationFailed'), 'error');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  const handleResetPassword = async () => {
+    setSubmitting(true);
+    try {
+      await resetUserPassword(resetTarget.ID, newPassword);
+      showMessage(t('settings.users.messages.passwordReset'));
+      setResetTarget(null);
+      setNewPassword('');
+    } catch (error) {
+      showMessage(error.message || t('settings.users.messages.operationFailed'), 'error');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  const handleDelete = async () => {
+    try {
+      await deleteUser(deleteTarget.ID);
+      showMessage(t('settings.users.messages.deleted'));
+      fetchUsers();
+    } catch (error) {
+      showMessage(error.message || t('settings.users.messages.operationFailed'), 'error');
+    }
+  };
+
+  return (
+    <Card variant="outlined">
+      <CardHeader
+        avatar={<GroupIcon color="primary" />}
+        title={t('settings.users.title')}
+        subheader={t('settings.users.subheader')}
+        action={
+          <Button variant="contained" startIcon={<AddIcon />} onClick={() => setCreateOpen(true)}>
+            {t('settings.users.actions.create')}
+          </Button>
+        }
+      />
+      <CardContent>
+        <Stack spacing={2}>
+          <Alert severity="info">{t('settings.users.roleHelp')}</Alert>
+          <TableContainer>
+            <Table size="small">
+              <TableHead>
+                <TableRow>
+                  <TableCell>{t('settings.users.columns.username')}</TableCell>
+                  <TableCell>{t('settings.users.columns.nickname')}</TableCell>
+                  <TableCell>{t('settings.users.columns.role')}</TableCell>
+                  <TableCell align="right">{t('settings.users.columns.actions')}</TableCell>
+                </TableRow>
+              </TableHead>
+              <TableBody>
+                {users.map((item) => {
+                  const isSelf = item.ID === currentUser?.userId;
+                  return (
+                    <TableRow key={item.ID}>
+                      <TableCell>{item.Username}</TableCell>
+                      <TableCell>{item.Nickname}</TableCell>
+                      <TableCell>
+                        <TextField
+                          select
+                          size="small"
+                          value={item.Role}
+                          onChange={(e) => handleRoleChange(item, e.target.value)}
+                          sx={{ minWidth: 140 }}
+                        >
+                          {ROLES.map((role) => (
+                            <MenuItem key={role} value={role}>
+                              {t(`settings.users.roles.${role}`)}
+                            </MenuItem>
+                          ))}
+                        </TextField>
+                      </TableCell>
+                      <TableCell align="right">
+                        <Tooltip title={t('settings.users.actions.resetPassword')}>
+                          <IconButton size="small" onClick={() => setResetTarget(item)}>
+                            <LockResetIcon fontSize="small" />
+                          </IconButton>
+                        </Tooltip>
+                        <Tooltip title={t('common.delete')}>
+                          <span>
+                            <IconButton size="small" color="error" disabled={isSelf} onClick={() => setDeleteTarget(item)}>
+                              <DeleteIcon fontSize="small" />
+                            </IconButton>
+                          </span>
+                        </Tooltip>
+                      </TableCell>
+                    </TableRow>
+                  );
+                })}
+              </TableBody>
+            </Table>
+          </TableContainer>
+        </Stack>
+      </CardContent>
+
+      <Dialog open={createOpen} onClose={() => setCreateOpen(false)} maxWidth="xs" fullWidth>
+        <DialogTitle>{t('settings.users.actions.create')}</DialogTitle>
+        <DialogContent>
+          <Stack spacing={2} sx={{ mt: 1 }}>
+            <TextField
+              label={t('settings.users.columns.username')}
+              value={form.username}
+              onChange={(e) => setForm({ ...form, username: e.target.value })}
+              fullWidth
+            />
+            <TextField
+              label={t('settings.users.columns.nickname')}
+              value={form.nickname}
+              onChange={(e) => setForm({ ...form, nickname: e.target.value })}
+              fullWidth
+            />
+            <TextField
+              type="password"
+              label={t('settings.users.fields.password')}
+              value={form.password}
+              onChange={(e) => setForm({ ...form, password: e.target.value })}
+              helperText={t('settings.users.fields.passwordHelper')}
+              fullWidth
+            />
+            <TextField select label={t('settings.users.columns.role')} value={form.role} onChange={(e) => setForm({ ...form, role: e.target.value })}>
+              {ROLES.map((role) => (
+                <MenuItem key={role} value={role}>
+                  {t(`settings.users.roles.${role}`)}
+                </MenuItem>
+              ))}
+            </TextField>
+          </Stack>
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setCreateOpen(false)}>{t('common.cancel')}</Button>
+          <Button variant="contained" onClick={handleCreate} disabled={submitting || !form.username || form.password.length < 6}>
+            {t('common.confirm')}
+          </Button>
+        </DialogActions>
+      </Dialog>
+
+      <Dialog open={Boolean(resetTarget)} onClose={() => setResetTarget(null)} maxWidth="xs" fullWidth>
+        <DialogTitle>{t('settings.users.resetPasswordTitle', { username: resetTarget?.Username || '' })}</DialogTitle>
+        <DialogContent>
+          <TextField
+            type="password"
+            label={t('settings.users.fields.password')}
+            value={newPassword}
+            onChange={(e) => setNewPassword(e.target.value)}
+            helperText={t('settings.users.fields.passwordHelper')}
+            fullWidth
+            sx={{ mt: 1 }}
+          />
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => setResetTarget(null)}>{t('common.cancel')}</Button>
+          <Button variant="contained" onClick={handleResetPassword} disabled={submitting || newPassword.length < 6}>
+            {t('common.confirm')}
+          </Button>
+        </DialogActions>
+      </Dialog>
+
+      <ConfirmDialog
+        open={Boolean(deleteTarget)}
+        title={t('settings.users.deleteTitle')}
+        content={t('settings.users.deleteConfirm', { username: deleteTarget?.Username || '' })}
+        onClose={() => setDeleteTarget(null)}
+        onConfirm={handleDelete}
+      />
+    </Card>
+  );
+}
+
+UserManagementSettings.propTypes = {
+  showMessage: PropTypes.func.isRequired
+};
@@ -19,6 +19,8 @@ import StorageIcon from '@mui/icons-material/Storage';
 import PsychologyIcon from '@mui/icons-material/Psychology';
 import CloudQueueIcon from '@mui/icons-material/CloudQueue';
 import ExtensionIcon from '@mui/icons-material/Extension';
+import GroupIcon from '@mui/icons-material/Group';
+import HistoryIcon from '@mui/icons-material/History';
 
 // project imports
 import MainCard from 'ui-component/cards/MainCard';
@@ -27,10 +29,16 @@ import SubscriptionAddressSettings from './components/SubscriptionAddressSetting
 import TelegramSettings from './components/TelegramSettings';
 import NodeDedupSettings from './components/NodeDedupSettings';
 import GlobalNodeProcessingSettings from './components/GlobalNodeProcessingSettings';
+import BackupSettings from './components/BackupSettings';
+import DatabaseCopySettings from './components/DatabaseCopySettings';
+import GitOpsSettings from './components/GitOpsSettings';
 import DatabaseMigrationSettings from './components/DatabaseMigrationSettings';
 import AIAssistantSettings from './components/AIAssistantSettings';
 import CloudflareTunnelSettings from './components/CloudflareTunnelSettings';
 import SubStoreSettings from './components/SubStoreSettings';
+import UserManagementSettings from './components/UserManagementSettings';
+import AuditLogSettings from './components/AuditLogSettings';
+import { useAuth } from 'contexts/AuthContext';
 
 // ==============================|| Tab Panel ||============================== //
 
@@ -53,6 +61,8 @@ function a11yProps(index) {
 
 export default function UserSettings() {
   const { t } = useTranslation();
+  const { user } = useAuth();
+  const isAdmin = user?.role === 'admin';
   const [searchParams, setSearchParams] = useSearchParams();
   const location = useLocation();
   const [tabValue, setTabValue] = useState(() => {
@@ -99,7 +109,9 @@ export default function UserSettings() {
       t('settings.tabs.aiAssistant'),
       'Cloudflare Tunnel',
       t('settings.tabs.subStore'),
-      t('settings.tabs.dataMigration')
+      t('settings.tabs.dataMigration'),
+      t('settings.tabs.users'),
+      t('settings.tabs.audit')
     ];
     return tabTitles[tabValue] || t('settings.title');
   };
@@ -147,6 +159,8 @@ export default function UserSettings() {
           <Tab icon={<CloudQueueIcon sx={{ mr: 1 }} />} iconPosition="start" label="Cloudflare Tunnel" {...a11yProps(6)} />
           <Tab icon={<ExtensionIcon sx={{ mr: 1 }} />} iconPosition="start" label={t('settings.tabs.subStore')} {...a11yProps(7)} />
           <Tab icon={<StorageIcon sx={{ mr: 1 }} />} iconPosition="start" label={t('settings.tabs.dataMigration')} {...a11yProps(8)} />
+          {isAdmin && <Tab icon={<GroupIcon sx={{ mr: 1 }} />} iconPosition="start" label={t('settings.tabs.users')} {...a11yProps(9)} />}
+          {isAdmin && <Tab icon={<HistoryIcon sx={{ mr: 1 }} />} iconPosition="start" label={t('settings.tabs.audit')} {...a11yProps(10)} />}
         </Tabs>
       </Box>
 
@@ -183,9 +197,24 @@ export default function UserSettings() {
       </TabPanel>
 
       <TabPanel value={tabValue} index={8}>
+        <BackupSettings showMessage={showMessage} />
         <DatabaseMigrationSettings showMessage={showMessage} />
+        <DatabaseCopySettings showMessage={showMessage} />
+        <GitOpsSettings showMessage={showMessage} />
       </TabPanel>
 
+      {isAdmin && (
+        <TabPanel value={tabValue} index={9}>
+          <UserManagementSettings showMessage={showMessage} />
+        </TabPanel>
+      )}
+
+      {isAdmin && (
+        <TabPanel value={tabValue} index={10}>
+          <AuditLogSettings showMessage={showMessage} />
+        </TabPanel>
+      )}
+
       {/* 提示消息 */}
       <Snackbar
         open={snackbar.open}
@@ -95,7 +95,7 @@ const normalizeDate = (value) => {
   return Number.isNaN(timestamp) ? 0 : timestamp;
 };
 
-export default function AccessLogsDialog({ open, logs, onClose, loading = false, title }) {
+export default function AccessLogsDialog({ open, logs, onClose, loading = false, title, usage = null }) {
   const theme = useTheme();
   const { t } = useTranslation();
   const isMobile = useMediaQuery(theme.breakpoints.down('md'));
@@ -172,6 +172,8 @@ export default function AccessLogsDialog({ open, logs, onClose, loading = false,
 
   const hasSearchKeyword = searchKeyword.trim().length > 0;
 
+  const formatLimit = (value) => (value > 0 ? value : t('subscriptions.accessLogs.usage.unlimited'));
+
   const handleSort = (field) => {
     if (field === sortField) {
       setSortOrder((currentOrder) => (currentOrder === SORT_ORDERS.asc ? SORT_ORDERS.desc : SORT_ORDERS.asc));
@@ -597,6 +599,57 @@ export default function AccessLogsDialog({ open, logs, onClose, loading = false,
           }
         }}
       >
+        {!loading && usage && (
+          <Box
+            sx={{
+              mb: 1.5,
+              px: 2,
+              py: 1.5,
+              borderRadius: 2.5,
+              bgcolor: nestedPanelSurface,
+              border: '1px solid',
+              borderColor: rowBorder
+            }}
+          >
+            <Typography variant="subtitle2" sx={{ color: primaryText, mb: 0.75 }}>
+              {t('subscriptions.accessLogs.usage.current', { minutes: usage.limit_window })}
+            </Typography>
+            <Stack direction="row" spacing={1} sx={{ flexWrap: 'wrap', rowGap: 1 }}>
+              <Chip
+                size="small"
+                label={t('subscriptions.accessLogs.usage.requests', {
+                  count: usage.current?.requests || 0,
+                  limit: formatLimit(usage.max_requests)
+                })}
+                sx={countChipSx}
+              />
+              <Chip
+                size="small"
+                label={t('subscriptions.accessLogs.usage.ips', {
+                  count: usage.current?.distinct_ips || 0,
+                  limit: formatLimit(usage.max_ips)
+                })}
+                sx={countChipSx}
+              />
+              {usage.abuse_disabled_at && <Chip size="small" color="error" variant="outlined" label={usage.abuse_reason} />}
+            </Stack>
+            {usage.history?.length > 0 && (
+              <Typography variant="caption" component="div" sx={{ color: secondaryText, mt: 1 }}>
+                {t('subscriptions.accessLogs.usage.history')}
+                {usage.history
+                  .slice(0, 6)
+                  .map((item) =>
+                    t('subscriptions.accessLogs.usage.historyItem', {
+                      time: new Date(item.window_start).toLocaleString(),
+                      requests: item.requests,
+                      ips: item.distinct_ips
+                    })
+                  )
+                  .join(' · ')}
+              </Typography>
+            )}
+          </Box>
+        )}
         {loading ? (
           <Box sx={{ display: 'flex', alignItems: 'center', justifyContent: 'center', py: 8 }}>
             <CircularProgress size={28} />
@@ -258,7 +258,61 @@ const formatExpireDate = (timestamp, language) => {
   return formatDateTime(date, language, { year: 'numeric', month: 'numeric', day: 'numeric' });
 };
 
-export default function NodePreviewDialog({ open, loading, data, tagColorMap, onClose }) {
+// 最终 Clash 配置的 mihomo 校验结果
+function ConfigValidationAlert({ validation }) {
+  const { t } = useTranslation();
+
+  if (validation.error) {
+    return (
+      <Alert severity="info" variant="outlined" sx={{ mt: 1, py: 0.5 }}>
+        {t('subscriptions.preview.configValidation.failed', { message: validation.error })}
+      </Alert>
+    );
+  }
+
+  const result = validation.validation || {};
+  const errors = result.errors || [];
+  const warnings = result.warnings || [];
+  const severity = errors.length > 0 ? 'error' : warnings.length > 0 ? 'warning' : 'success';
+  const issues = [...errors, ...warnings];
+
+  return (
+    <Alert severity={severity} variant="outlined" sx={{ mt: 1, py: 0.5, '& .MuiAlert-message': { width: '100%' } }}>
+      <Typography component="span" fontWeight={700} sx={{ mr: 1 }}>
+        {t('subscriptions.preview.configValidation.title')}
+      </Typography>
+      {errors.length > 0
+        ? t('subscriptions.preview.configValidation.invalid', { errors: errors.length, warnings: warnings.length })
+        : t('subscriptions.preview.configValidation.valid', {
+            proxies: result.proxyCount || 0,
+            groups: result.groupCount || 0,
+            rules: result.ruleCount || 0
+          })}
+      {errors.length > 0 && validation.validateOutput && (
+        <Typography variant="caption" sx={{ display: 'block' }}>
+          {validation.lastGoodAt
+            ? t('subscriptions.preview.configValidation.fallback', { time: validation.lastGoodAt })
+            : t('subscriptions.preview.configValidation.noFallback')}
+        </Typography>
+      )}
+      {issues.length > 0 && (
+        <Box component="ul" sx={{ m: 0, mt: 0.5, pl: 2, maxHeight: 120, overflowY: 'auto' }}>
+          {issues.map((issue, index) => (
+            <Typography component="li" variant="caption" key={`${issue.category}-${index}`}>
+              {issue.message}
+            </Typography>
+          ))}
+        </Box>
+      )}
+    </Alert>
+  );
+}
+
+ConfigValidationAlert.propTypes = {
+  validation: PropTypes.object.isRequired
+};
+
+export default function NodePreviewDialog({ open, loading, data, configValidation, tagColorMap, onClose }) {
   const theme = useTheme();
   const { t, i18n } = useTranslation();
   const isMobile = useMediaQuery(theme.breakpoints.down('sm'));
@@ -533,6 +587,7 @@ export default function NodePreviewDialog({ open, loading, data, tagColorMap, on
               </Typography>
               {t('subscriptions.preview.noticeText')}
             </Alert>
+            {configValidation && <ConfigValidationAlert validation={configValidation} />}
           </Box>
           <Box
             sx={{
@@ -924,6 +979,7 @@ NodePreviewDialog.propTypes = {
     TotalCount: PropTypes.number,
     FilteredCount: PropTypes.number
   }),
+  configValidation: PropTypes.object,
   tagColorMap: PropTypes.object,
   onClose: PropTypes.func.isRequired
 };
@@ -0,0 +1,212 @@
+import { useCallback, useEffect, useMemo, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import Dialog from '@mui/material/Dialog';
+import DialogTitle from '@mui/material/DialogTitle';
+import DialogContent from '@mui/material/DialogContent';
+import DialogActions from '@mui/material/DialogActions';
+import Button from '@mui/material/Button';
+import Alert from '@mui/material/Alert';
+import Chip from '@mui/material/Chip';
+import CircularProgress from '@mui/material/CircularProgress';
+import Stack from '@mui/material/Stack';
+import Typography from '@mui/material/Typography';
+import Table from '@mui/material/Table';
+import TableBody from '@mui/material/TableBody';
+import TableCell from '@mui/material/TableCell';
+import TableContainer from '@mui/material/TableContainer';
+import TableHead from '@mui/material/TableHead';
+import TableRow from '@mui/material/TableRow';
+import ToggleButton from '@mui/material/ToggleButton';
+import ToggleButtonGroup from '@mui/material/ToggleButtonGroup';
+import useMediaQuery from '@mui/material/useMediaQuery';
+import { useTheme } from '@mui/material/styles';
+
+import { getShareAnalyticsClients, getShareAnalyticsDaily, getShareAnalyticsUnusual } from '../../../api/shares';
+import useResolvedColorScheme from 'hooks/useResolvedColorScheme';
+import { getReadableTextTokens, getSurfaceTokens } from 'themes/surfaceTokens';
+
+const RANGE_OPTIONS = [7, 30, 90];
+
+const UNUSUAL_KIND_COLORS = {
+  expired: 'warning',
+  fallback: 'error',
+  non_client: 'info',
+  many_countries: 'error'
+};
+
+// ==============================|| 分享访问统计 ||============================== //
+
+export default function ShareAnalyticsDialog({ open, subscription, onClose }) {
+  const theme = useTheme();
+  const { t } = useTranslation();
+  const isMobile = useMediaQuery(theme.breakpoints.down('sm'));
+  const { isDark } = useResolvedColorScheme();
+  const { dialogSurface, mutedPanelSurface, panelBorder } = getSurfaceTokens(theme, isDark);
+  const { secondaryText } = getReadableTextTokens(theme, isDark);
+
+  const [days, setDays] = useState(7);
+  const [daily, setDaily] = useState([]);
+  const [clients, setClients] = useState([]);
+  const [unusual, setUnusual] = useState([]);
+  const [loading, setLoading] = useState(false);
+
+  const fetchAnalytics = useCallback(async () => {
+    if (!subscription?.ID) return;
+    setLoading(true);
+    try {
+      const [dailyRes, clientsRes, unusualRes] = await Promise.all([
+        getShareAnalyticsDaily({ subId: subscription.ID, days }),
+        getShareAnalyticsClients({ subId: subscription.ID, days }),
+        getShareAnalyticsUnusual({ subId: subscription.ID, days: 1 })
+      ]);
+      setDaily(dailyRes.data || []);
+      setClients(clientsRes.data || []);
+      setUnusual(unusualRes.data || []);
+    } catch (error) {
+      console.error('Failed to get share analytics:', error);
+    } finally {
+      setLoading(false);
+    }
+  }, [subscription?.ID, days]);
+
+  useEffect(() => {
+    if (open) fetchAnalytics();
+  }, [open, fetchAnalytics]);
+
+  const totals = useMemo(
+    () =>
+      daily.reduce(
+        (sum, row) => ({
+          requests: sum.requests + row.requests,
+          expired: sum.expired + row.expired,
+          fallback: sum.fallback + row.fallback
+        }),
+        { requests: 0, expired: 0, fallback: 0 }
+      ),
+    [daily]
+  );
+
+  const sectionTitle = (key) => (
+    <Typography variant="subtitle2" sx={{ color: secondaryText }}>
+      {t(`subscriptions.share.analytics.${key}`)}
+    </Typography>
+  );
+
+  return (
+    <Dialog
+      open={open}
+      onClose={onClose}
+      maxWidth="md"
+      fullWidth
+      fullScreen={isMobile}
+      slotProps={{
+        paper: { sx: { borderRadius: isMobile ? 0 : 3, bgcolor: dialogSurface, border: '1px solid', borderColor: panelBorder } }
+      }}
+    >
+      <DialogTitle sx={{ px: 2.5, py: 2, bgcolor: mutedPanelSurface, borderBottom: '1px solid', borderColor: panelBorder }}>
+        <Stack direction="row" alignItems="center" justifyContent="space-between" spacing={1}>
+          <span>{t('subscriptions.share.analytics.title', { name: subscription?.Name })}</span>
+          <ToggleButtonGroup size="small" exclusive value={days} onChange={(e, value) => value && setDays(value)}>
+            {RANGE_OPTIONS.map((value) => (
+              <ToggleButton key={value} value={value}>
+                {t('subscriptions.share.analytics.range', { days: value })}
+              </ToggleButton>
+            ))}
+          </ToggleButtonGroup>
+        </Stack>
+      </DialogTitle>
+      <DialogContent sx={{ bgcolor: dialogSurface }}>
+        {loading ? (
+          <Stack alignItems="center" sx={{ py: 6 }}>
+            <CircularProgress size={28} />
+          </Stack>
+        ) : (
+          <Stack spacing={2.5} sx={{ mt: 2 }}>
+            <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
+              <Chip label={t('subscriptions.share.analytics.totalRequests', { count: totals.requests })} />
+              <Chip color="warning" variant="outlined" label={t('subscriptions.share.analytics.totalExpired', { count: totals.expired })} />
+              <Chip color="error" variant="outlined" label={t('subscriptions.share.analytics.totalFallback', { count: totals.fallback })} />
+            </Stack>
+
+            {sectionTitle('unusualTitle')}
+            {unusual.length > 0 ? (
+              <Stack spacing={1}>
+                {unusual.map((item) => (
+                  <Stack key={`${item.share_id}-${item.kind}`} direction="row" spacing={1} alignItems="center">
+                    <Chip
+                      size="small"
+                      color={UNUSUAL_KIND_COLORS[item.kind] || 'default'}
+                      label={t(`subscriptions.share.analytics.kinds.${item.kind}`)}
+                    />
+                    <Typography variant="body2">{item.share_name || t('subscriptions.share.unnamed')}</Typography>
+                    <Typography variant="caption" sx={{ color: secondaryText }}>
+                      {item.kind === 'many_countries'
+                        ? item.countries?.join(', ')
+                        : t('subscriptions.share.analytics.times', { count: item.count })}
+                    </Typography>
+                  </Stack>
+                ))}
+              </Stack>
+            ) : (
+              <Alert severity="success">{t('subscriptions.share.analytics.noUnusual')}</Alert>
+            )}
+
+            {sectionTitle('clientsTitle')}
+            {clients.length > 0 ? (
+              <Stack direction="row" spacing={1} flexWrap="wrap" useFlexGap>
+                {clients.map((item) => (
+                  <Chip
+                    key={`${item.ua_family}-${item.client_type}`}
+                    variant="outlined"
+                    label={t('subscriptions.share.analytics.clientItem', {
+                      family: item.ua_family,
+                      client: item.client_type,
+                      count: item.requests,
+                      ips: item.unique_ips
+                    })}
+                  />
+                ))}
+              </Stack>
+            ) : (
+              <Alert severity="info">{t('subscriptions.share.analytics.empty')}</Alert>
+            )}
+
+            {sectionTitle('dailyTitle')}
+            {daily.length > 0 ? (
+              <TableContainer sx={{ border: '1px solid', borderColor: panelBorder, borderRadius: 2 }}>
+                <Table size="small">
+                  <TableHead>
+                    <TableRow>
+                      {['day', 'requests', 'uniqueIps', 'normal', 'expired', 'fallback'].map((key) => (
+                        <TableCell key={key} align={key === 'day' ? 'left' : 'right'}>
+                          {t(`subscriptions.share.analytics.columns.${key}`)}
+                        </TableCell>
+                      ))}
+                    </TableRow>
+                  </TableHead>
+                  <TableBody>
+                    {daily.map((row) => (
+                      <TableRow key={row.day}>
+                        <TableCell>{row.day}</TableCell>
+                        <TableCell align="right">{row.requests}</TableCell>
+                        <TableCell align="right">{row.unique_ips}</TableCell>
+                        <TableCell align="right">{row.normal}</TableCell>
+                        <TableCell align="right">{row.expired}</TableCell>
+                        <TableCell align="right">{row.fallback}</TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              </TableContainer>
+            ) : (
+              <Alert severity="info">{t('subscriptions.share.analytics.empty')}</Alert>
+            )}
+          </Stack>
+        )}
+      </DialogContent>
+      <DialogActions sx={{ px: 2.5, py: 1.75, bgcolor: mutedPanelSurface, borderTop: '1px solid', borderColor: panelBorder }}>
+        <Button onClick={onClose}>{t('common.close')}</Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
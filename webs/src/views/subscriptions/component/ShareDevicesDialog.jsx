@@ -0,0 +1,140 @@
+import { useCallback, useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import Dialog from '@mui/material/Dialog';
+import DialogTitle from '@mui/material/DialogTitle';
+import DialogContent from '@mui/material/DialogContent';
+import DialogActions from '@mui/material/DialogActions';
+import Button from '@mui/material/Button';
+import Alert from '@mui/material/Alert';
+import Chip from '@mui/material/Chip';
+import CircularProgress from '@mui/material/CircularProgress';
+import IconButton from '@mui/material/IconButton';
+import List from '@mui/material/List';
+import ListItem from '@mui/material/ListItem';
+import ListItemText from '@mui/material/ListItemText';
+import Stack from '@mui/material/Stack';
+import Tooltip from '@mui/material/Tooltip';
+import useMediaQuery from '@mui/material/useMediaQuery';
+import { useTheme } from '@mui/material/styles';
+import LinkOffIcon from '@mui/icons-material/LinkOff';
+
+import { formatDateTime } from 'i18n/locales';
+import { getShareDevices, resetShareDevices } from '../../../api/shares';
+import useResolvedColorScheme from 'hooks/useResolvedColorScheme';
+import { getSurfaceTokens } from 'themes/surfaceTokens';
+
+// ==============================|| 分享绑定设备 ||============================== //
+
+export default function ShareDevicesDialog({ open, share, onClose, showMessage }) {
+  const theme = useTheme();
+  const { t, i18n } = useTranslation();
+  const isMobile = useMediaQuery(theme.breakpoints.down('sm'));
+  const { isDark } = useResolvedColorScheme();
+  const { dialogSurface, mutedPanelSurface, panelBorder } = getSurfaceTokens(theme, isDark);
+  const language = i18n.resolvedLanguage || i18n.language;
+
+  const [devices, setDevices] = useState([]);
+  const [maxDevices, setMaxDevices] = useState(0);
+  const [loading, setLoading] = useState(false);
+
+  const fetchDevices = useCallback(async () => {
+    if (!share) return;
+    setLoading(true);
+    try {
+      const res = await getShareDevices(share.id);
+      setDevices(res.data?.devices || []);
+      setMaxDevices(res.data?.max_devices || 0);
+    } catch (error) {
+      console.error('Failed to get devices:', error);
+      setDevices([]);
+    } finally {
+      setLoading(false);
+    }
+  }, [share]);
+
+  useEffect(() => {
+    if (open) fetchDevices();
+  }, [open, fetchDevices]);
+
+  const handleReset = async (deviceId = 0) => {
+    try {
+      await resetShareDevices(share.id, deviceId);
+      showMessage?.(t('subscriptions.share.devices.resetSuccess'), 'success');
+      fetchDevices();
+    } catch (error) {
+      console.error('Failed to reset devices:', error);
+      showMessage?.(error.response?.data?.msg || t('subscriptions.share.devices.resetFailed'), 'error');
+    }
+  };
+
+  return (
+    <Dialog
+      open={open}
+      onClose={onClose}
+      maxWidth="sm"
+      fullWidth
+      fullScreen={isMobile}
+      slotProps={{
+        paper: { sx: { borderRadius: isMobile ? 0 : 3, bgcolor: dialogSurface, border: '1px solid', borderColor: panelBorder } }
+      }}
+    >
+      <DialogTitle sx={{ px: 2.5, py: 2, bgcolor: mutedPanelSurface, borderBottom: '1px solid', borderColor: panelBorder }}>
+        <Stack direction="row" alignItems="center" spacing={1}>
+          <span>{t('subscriptions.share.devices.title', { name: share?.name || t('subscriptions.share.unnamed') })}</span>
+          {maxDevices > 0 && <Chip size="small" label={`${devices.length} / ${maxDevices}`} />}
+        </Stack>
+      </DialogTitle>
+      <DialogContent sx={{ bgcolor: dialogSurface }}>
+        <Stack spacing={2} sx={{ mt: 2 }}>
+          {maxDevices === 0 && !loading && <Alert severity="info">{t('subscriptions.share.devices.disabled')}</Alert>}
+          {loading ? (
+            <Stack alignItems="center" sx={{ py: 4 }}>
+              <CircularProgress size={28} />
+            </Stack>
+          ) : devices.length > 0 ? (
+            <List dense disablePadding>
+              {devices.map((device) => (
+                <ListItem
+                  key={device.id}
+                  disableGutters
+                  secondaryAction={
+                    <Tooltip title={t('subscriptions.share.devices.unbind')}>
+                      <IconButton edge="end" onClick={() => handleReset(device.id)}>
+                        <LinkOffIcon fontSize="small" />
+                      </IconButton>
+                    </Tooltip>
+                  }
+                >
+                  <ListItemText
+                    primary={
+                      <Stack direction="row" spacing={1} alignItems="center">
+                        <Chip size="small" label={device.ua_family} color="primary" variant="outlined" />
+                        <span>{device.asn || device.ip_prefix}</span>
+                      </Stack>
+                    }
+                    secondary={t('subscriptions.share.devices.usage', {
+                      ip: device.last_ip,
+                      count: device.requests,
+                      first: formatDateTime(device.first_seen_at, language),
+                      last: formatDateTime(device.last_seen_at, language)
+                    })}
+                    slotProps={{ primary: { component: 'div' } }}
+                    sx={{ pr: 4 }}
+                  />
+                </ListItem>
+              ))}
+            </List>
+          ) : (
+            maxDevices > 0 && <Alert severity="info">{t('subscriptions.share.devices.empty')}</Alert>
+          )}
+        </Stack>
+      </DialogContent>
+      <DialogActions sx={{ px: 2.5, py: 1.75, bgcolor: mutedPanelSurface, borderTop: '1px solid', borderColor: panelBorder }}>
+        <Button color="error" onClick={() => handleReset(0)} disabled={devices.length === 0}>
+          {t('subscriptions.share.devices.resetAll')}
+        </Button>
+        <Button onClick={onClose}>{t('common.close')}</Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
@@ -34,15 +34,20 @@ import RefreshIcon from '@mui/icons-material/Refresh';
 import HistoryIcon from '@mui/icons-material/History';
 import DownloadIcon from '@mui/icons-material/Download';
 import SortIcon from '@mui/icons-material/Sort';
+import VpnKeyIcon from '@mui/icons-material/VpnKey';
+import DevicesIcon from '@mui/icons-material/Devices';
+import InsightsIcon from '@mui/icons-material/Insights';
 import Checkbox from '@mui/material/Checkbox';
 import Divider from '@mui/material/Divider';
 
 import {
   getShares,
+  getShareUsage,
   createShare,
   updateShare,
   deleteShare,
   getShareLogs,
+  getShareAccessUsage,
   refreshShareToken,
   batchCreateShares,
   batchDeleteShares,
@@ -52,6 +57,7 @@ import { getSubStoreSettings, getSystemDomain } from '../../../api/settings';
 import useResolvedColorScheme from 'hooks/useResolvedColorScheme';
 import { getReadableTextTokens, getSurfaceTokens } from 'themes/surfaceTokens';
 import { withAlpha } from 'utils/colorUtils';
+import { formatBytes } from 'views/airports/utils';
 import AccessLogsDialog from './AccessLogsDialog';
 import ClientUrlsDialog from './ClientUrlsDialog';
 import QrCodeDialog from './QrCodeDialog';
@@ -59,12 +65,37 @@ import ConfirmDialog from './ConfirmDialog';
 import ShareBatchCreateDialog from './ShareBatchCreateDialog';
 import ShareBatchUpdateDialog from './ShareBatchUpdateDialog';
 import ShareExportDialog from './ShareExportDialog';
+import ShareSignDialog from './ShareSignDialog';
+import ShareDevicesDialog from './ShareDevicesDialog';
+import ShareAnalyticsDialog from './ShareAnalyticsDialog';
 
 const EXPIRE_TYPE_NEVER = 0;
 const EXPIRE_TYPE_DAYS = 1;
 const EXPIRE_TYPE_DATETIME = 2;
 const SHARE_PAGE_SIZE = 50;
 
+// 分享级覆盖字段的默认值，留空表示沿用订阅设置
+const EMPTY_SHARE_OVERRIDES = {
+  country_whitelist: '',
+  country_blacklist: '',
+  tag_whitelist: '',
+  tag_blacklist: '',
+  protocol_whitelist: '',
+  protocol_blacklist: '',
+  max_nodes: 0,
+  force_client: '',
+  clash_template: '',
+  surge_template: '',
+  fallback_message: '',
+  quota_gb: 0,
+  quota_expire_at: '',
+  usage_ratio: 0
+};
+
+const BYTES_PER_GB = 1024 * 1024 * 1024;
+
+const hasUsageQuota = (share) => share.quota_bytes > 0 || share.usage_ratio > 0 || Boolean(share.quota_expire_at);
+
 const NATIVE_CLIENT_LINKS = [
   { key: 'clash', client: 'clash' },
   { key: 'mihomo', client: 'mihomo' },
@@ -117,6 +148,7 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
   const [batchUpdateOpen, setBatchUpdateOpen] = useState(false);
   const [batchUpdateMode, setBatchUpdateMode] = useState('expire'); // 'expire' or 'enabled'
   const [exportDialogOpen, setExportDialogOpen] = useState(false);
+  const [analyticsOpen, setAnalyticsOpen] = useState(false);
 
   const [systemDomainConfig, setSystemDomainConfig] = useState('');
   const [subStoreTargets, setSubStoreTargets] = useState([]);
@@ -132,7 +164,13 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
     expire_type: EXPIRE_TYPE_NEVER,
     expire_days: 30,
     expire_at: '',
-    enabled: true
+    enabled: true,
+    limit_window: 60,
+    max_requests: 0,
+    max_ips: 0,
+    max_devices: 0,
+    signed_only: false,
+    ...EMPTY_SHARE_OVERRIDES
   });
 
   const [qrOpen, setQrOpen] = useState(false);
@@ -142,8 +180,12 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
   const [logsOpen, setLogsOpen] = useState(false);
   const [logsLoading, setLogsLoading] = useState(false);
   const [logs, setLogs] = useState([]);
+  const [logsUsage, setLogsUsage] = useState(null);
   const [logsShareName, setLogsShareName] = useState('');
 
+  const [signingShare, setSigningShare] = useState(null);
+  const [devicesShare, setDevicesShare] = useState(null);
+
   const [confirmOpen, setConfirmOpen] = useState(false);
   const [confirmInfo, setConfirmInfo] = useState({ title: '', content: '', onConfirm: null });
   const dialogContentRef = useRef(null);
@@ -166,6 +208,29 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
     }
   };
 
+  const [shareUsage, setShareUsage] = useState({});
+
+  // 仅在存在设置了用量配额的分享时获取用量，避免无谓地计算订阅节点
+  const fetchShareUsage = useCallback(
+    async (items) => {
+      if (!subscription?.ID || !items.some(hasUsageQuota)) {
+        setShareUsage({});
+        return;
+      }
+      try {
+        const res = await getShareUsage(subscription.ID);
+        setShareUsage(Object.fromEntries((res.data?.shares || []).map((usage) => [usage.share_id, usage])));
+      } catch (error) {
+        console.error('Failed to get share usage:', error);
+      }
+    },
+    [subscription?.ID]
+  );
+
+  useEffect(() => {
+    fetchShareUsage(shares);
+  }, [shares, fetchShareUsage]);
+
   const fetchShares = useCallback(
     async (keyword = '', isSearch = false, customSortBy = null, customSortOrder = null, silent = false) => {
       if (!subscription?.ID) return;
@@ -322,7 +387,13 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
       expire_type: EXPIRE_TYPE_NEVER,
       expire_days: 30,
       expire_at: '',
-      enabled: true
+      enabled: true,
+      limit_window: 60,
+      max_requests: 0,
+      max_ips: 0,
+      max_devices: 0,
+      signed_only: false,
+      ...EMPTY_SHARE_OVERRIDES
     });
     setFormOpen(true);
   };
@@ -336,15 +407,36 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
       expire_type: share.expire_type || EXPIRE_TYPE_NEVER,
       expire_days: share.expire_days || 30,
       expire_at: share.expire_at ? share.expire_at.substring(0, 16) : '',
-      enabled: share.enabled !== false
+      enabled: share.enabled !== false,
+      limit_window: share.limit_window || 60,
+      max_requests: share.max_requests || 0,
+      max_ips: share.max_ips || 0,
+      max_devices: share.max_devices || 0,
+      signed_only: share.signed_only === true,
+      country_whitelist: share.country_whitelist || '',
+      country_blacklist: share.country_blacklist || '',
+      tag_whitelist: share.tag_whitelist || '',
+      tag_blacklist: share.tag_blacklist || '',
+      protocol_whitelist: share.protocol_whitelist || '',
+      protocol_blacklist: share.protocol_blacklist || '',
+      max_nodes: share.max_nodes || 0,
+      force_client: share.force_client || '',
+      clash_template: share.clash_template || '',
+      surge_template: share.surge_template || '',
+      fallback_message: share.fallback_message || '',
+      quota_gb: share.quota_bytes ? Number((share.quota_bytes / BYTES_PER_GB).toFixed(2)) : 0,
+      quota_expire_at: share.quota_expire_at ? share.quota_expire_at.substring(0, 16) : '',
+      usage_ratio: share.usage_ratio || 0
     });
     setFormOpen(true);
   };
 
   const handleSave = async () => {
     try {
+      const { quota_gb: quotaGb, ...fields } = formData;
       const data = {
-        ...formData,
+        ...fields,
+        quota_bytes: Math.round((quotaGb || 0) * BYTES_PER_GB),
         subscription_id: subscription.ID
       };
 
@@ -416,16 +508,28 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
     setLogsLoading(true);
     setLogsOpen(true);
     try {
-      const res = await getShareLogs(share.id);
-      setLogs(res.data || []);
+      const [logsRes, usageRes] = await Promise.all([getShareLogs(share.id), getShareAccessUsage(share.id).catch(() => null)]);
+      setLogs(logsRes.data || []);
+      setLogsUsage(usageRes?.data || null);
     } catch (error) {
       console.error('Failed to get logs:', error);
       setLogs([]);
+      setLogsUsage(null);
     } finally {
       setLogsLoading(false);
     }
   };
 
+  const handleOpenSign = (share, e) => {
+    e?.stopPropagation();
+    setSigningShare(share);
+  };
+
+  const handleOpenDevices = (share, e) => {
+    e?.stopPropagation();
+    setDevicesShare(share);
+  };
+
   const handleQrCode = (url, title) => {
     setQrUrl(url);
     setQrTitle(title);
@@ -729,6 +833,7 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
 
   const renderShareCard = (share) => {
     const expired = isExpired(share);
+    const usage = shareUsage[share.id];
     const accentColor = share.is_legacy ? palette.primary.main : expired ? palette.error.main : palette.info.main;
     const accentSurface = share.is_legacy
       ? withAlpha(palette.primary.main, isDark ? 0.16 : 0.06)
@@ -805,6 +910,28 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
                     {share.name || t('subscriptions.share.unnamed')}
                   </Typography>
                   {share.is_legacy && <Chip label={t('subscriptions.share.defaultChip')} size="small" sx={legacyChipSx} />}
+                  {share.abuse_disabled_at && (
+                    <Tooltip title={share.abuse_reason || ''}>
+                      <Chip
+                        label={t('subscriptions.share.limit.abuseChip')}
+                        size="small"
+                        color="error"
+                        variant="outlined"
+                        sx={{ height: 20 }}
+                      />
+                    </Tooltip>
+                  )}
+                  {usage && (
+                    <Tooltip title={t('subscriptions.share.quota.usageTooltip', { ratio: usage.ratio.toFixed(1) })}>
+                      <Chip
+                        label={`${formatBytes(usage.upload + usage.download)} / ${formatBytes(usage.total)}`}
+                        size="small"
+                        color={usage.exceeded ? 'error' : 'default'}
+                        variant="outlined"
+                        sx={{ height: 20 }}
+                      />
+                    </Tooltip>
+                  )}
                 </Stack>
                 <Typography variant="caption" sx={{ color: expired ? tertiaryText : secondaryText }}>
                   {t('subscriptions.share.cardMeta', { expire: getExpireText(share), count: share.access_count || 0 })}
@@ -817,6 +944,16 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
                   <HistoryIcon fontSize="small" />
                 </IconButton>
               </Tooltip>
+              <Tooltip title={t('subscriptions.share.actions.signedLink')}>
+                <IconButton size="small" onClick={(e) => handleOpenSign(share, e)} sx={actionIconButtonSx}>
+                  <VpnKeyIcon fontSize="small" />
+                </IconButton>
+              </Tooltip>
+              <Tooltip title={t('subscriptions.share.actions.devices')}>
+                <IconButton size="small" onClick={(e) => handleOpenDevices(share, e)} sx={actionIconButtonSx}>
+                  <DevicesIcon fontSize="small" />
+                </IconButton>
+              </Tooltip>
               <Tooltip title={t('common.edit')}>
                 <IconButton size="small" onClick={(e) => handleEdit(share, e)} sx={actionIconButtonSx}>
                   <EditIcon fontSize="small" />
@@ -913,6 +1050,11 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
             <Stack direction="row" alignItems="center" justifyContent="space-between">
               <Typography variant="h6">{t('subscriptions.share.title', { name: subscription?.Name })}</Typography>
               <Stack direction="row" spacing={1}>
+                <Tooltip title={t('subscriptions.share.analytics.open')}>
+                  <IconButton size="small" onClick={() => setAnalyticsOpen(true)} sx={iconButtonBaseSx}>
+                    <InsightsIcon fontSize="small" />
+                  </IconButton>
+                </Tooltip>
                 <IconButton size="small" onClick={handleManualRefresh} disabled={loading || searching || refreshing} sx={iconButtonBaseSx}>
                   {refreshing ? <CircularProgress size={18} /> : <RefreshIcon fontSize="small" />}
                 </IconButton>
@@ -1239,6 +1381,226 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
               />
             )}
 
+            <Divider textAlign="left">
+              <Typography variant="caption" sx={{ color: secondaryText }}>
+                {t('subscriptions.share.limit.title')}
+              </Typography>
+            </Divider>
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.limit.window')}
+                type="number"
+                value={formData.limit_window}
+                onChange={(e) => setFormData({ ...formData, limit_window: parseInt(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                slotProps={{ htmlInput: { min: 1 } }}
+              />
+              <TextField
+                label={t('subscriptions.share.limit.maxRequests')}
+                type="number"
+                value={formData.max_requests}
+                onChange={(e) => setFormData({ ...formData, max_requests: parseInt(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                slotProps={{ htmlInput: { min: 0 } }}
+              />
+              <TextField
+                label={t('subscriptions.share.limit.maxIps')}
+                type="number"
+                value={formData.max_ips}
+                onChange={(e) => setFormData({ ...formData, max_ips: parseInt(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                slotProps={{ htmlInput: { min: 0 } }}
+              />
+            </Stack>
+            <Typography variant="caption" sx={{ color: tertiaryText, mt: '4px !important' }}>
+              {t('subscriptions.share.limit.helper')}
+            </Typography>
+
+            <TextField
+              label={t('subscriptions.share.form.maxDevices')}
+              type="number"
+              value={formData.max_devices}
+              onChange={(e) => setFormData({ ...formData, max_devices: parseInt(e.target.value) || 0 })}
+              size="small"
+              fullWidth
+              helperText={t('subscriptions.share.form.maxDevicesHelper')}
+              slotProps={{ htmlInput: { min: 0, max: 100 } }}
+            />
+
+            <FormControlLabel
+              control={
+                <Switch checked={formData.signed_only} onChange={(e) => setFormData({ ...formData, signed_only: e.target.checked })} />
+              }
+              label={t('subscriptions.share.form.signedOnly')}
+            />
+            <Typography variant="caption" sx={{ color: tertiaryText, mt: '0 !important' }}>
+              {t('subscriptions.share.form.signedOnlyHelper')}
+            </Typography>
+
+            <Divider textAlign="left">
+              <Typography variant="caption" sx={{ color: secondaryText }}>
+                {t('subscriptions.share.override.title')}
+              </Typography>
+            </Divider>
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.override.countryWhitelist')}
+                value={formData.country_whitelist}
+                onChange={(e) => setFormData({ ...formData, country_whitelist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+              <TextField
+                label={t('subscriptions.share.override.countryBlacklist')}
+                value={formData.country_blacklist}
+                onChange={(e) => setFormData({ ...formData, country_blacklist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+            </Stack>
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.override.tagWhitelist')}
+                value={formData.tag_whitelist}
+                onChange={(e) => setFormData({ ...formData, tag_whitelist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+              <TextField
+                label={t('subscriptions.share.override.tagBlacklist')}
+                value={formData.tag_blacklist}
+                onChange={(e) => setFormData({ ...formData, tag_blacklist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+            </Stack>
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.override.protocolWhitelist')}
+                value={formData.protocol_whitelist}
+                onChange={(e) => setFormData({ ...formData, protocol_whitelist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+              <TextField
+                label={t('subscriptions.share.override.protocolBlacklist')}
+                value={formData.protocol_blacklist}
+                onChange={(e) => setFormData({ ...formData, protocol_blacklist: e.target.value })}
+                size="small"
+                fullWidth
+              />
+            </Stack>
+            <Typography variant="caption" sx={{ color: tertiaryText, mt: '4px !important' }}>
+              {t('subscriptions.share.override.filterHelper')}
+            </Typography>
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.override.maxNodes')}
+                type="number"
+                value={formData.max_nodes}
+                onChange={(e) => setFormData({ ...formData, max_nodes: parseInt(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                helperText={t('subscriptions.share.override.maxNodesHelper')}
+                slotProps={{ htmlInput: { min: 0 } }}
+              />
+              <FormControl fullWidth size="small">
+                <InputLabel>{t('subscriptions.share.override.forceClient')}</InputLabel>
+                <Select
+                  value={formData.force_client}
+                  label={t('subscriptions.share.override.forceClient')}
+                  onChange={(e) => setFormData({ ...formData, force_client: e.target.value })}
+                >
+                  <MenuItem value="">{t('subscriptions.share.override.forceClientNone')}</MenuItem>
+                  {visibleClientLinks.map((item) => (
+                    <MenuItem key={item.key} value={item.client}>
+                      {t(`subscriptions.share.clients.${item.key}`)}
+                    </MenuItem>
+                  ))}
+                </Select>
+              </FormControl>
+            </Stack>
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.override.clashTemplate')}
+                value={formData.clash_template}
+                onChange={(e) => setFormData({ ...formData, clash_template: e.target.value })}
+                size="small"
+                fullWidth
+                placeholder="./template/clash.yaml"
+              />
+              <TextField
+                label={t('subscriptions.share.override.surgeTemplate')}
+                value={formData.surge_template}
+                onChange={(e) => setFormData({ ...formData, surge_template: e.target.value })}
+                size="small"
+                fullWidth
+                placeholder="./template/surge.conf"
+              />
+            </Stack>
+
+            <TextField
+              label={t('subscriptions.share.override.fallbackMessage')}
+              value={formData.fallback_message}
+              onChange={(e) => setFormData({ ...formData, fallback_message: e.target.value })}
+              size="small"
+              fullWidth
+              helperText={t('subscriptions.share.override.fallbackMessageHelper')}
+              slotProps={{ htmlInput: { maxLength: 100 } }}
+            />
+
+            <Divider textAlign="left">
+              <Typography variant="caption" sx={{ color: secondaryText }}>
+                {t('subscriptions.share.quota.title')}
+              </Typography>
+            </Divider>
+
+            <Stack direction={{ xs: 'column', sm: 'row' }} spacing={2}>
+              <TextField
+                label={t('subscriptions.share.quota.quotaGb')}
+                type="number"
+                value={formData.quota_gb}
+                onChange={(e) => setFormData({ ...formData, quota_gb: parseFloat(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                slotProps={{ htmlInput: { min: 0, step: 0.5 } }}
+              />
+              <TextField
+                label={t('subscriptions.share.quota.usageRatio')}
+                type="number"
+                value={formData.usage_ratio}
+                onChange={(e) => setFormData({ ...formData, usage_ratio: parseInt(e.target.value) || 0 })}
+                size="small"
+                fullWidth
+                slotProps={{ htmlInput: { min: 0, max: 100 } }}
+              />
+              <TextField
+                label={t('subscriptions.share.quota.expireAt')}
+                type="datetime-local"
+                value={formData.quota_expire_at}
+                onChange={(e) => setFormData({ ...formData, quota_expire_at: e.target.value })}
+                size="small"
+                fullWidth
+                slotProps={{ inputLabel: { shrink: true } }}
+              />
+            </Stack>
+            <Typography variant="caption" sx={{ color: tertiaryText, mt: '4px !important' }}>
+              {t('subscriptions.share.quota.helper')}
+            </Typography>
+
+            {editingShare?.abuse_disabled_at && (
+              <Alert severity="warning">
+                {t('subscriptions.share.limit.abuseAlert', { reason: editingShare.abuse_reason || '-' })}
+              </Alert>
+            )}
+
             {editingShare && (
               <FormControlLabel
                 control={<Switch checked={formData.enabled} onChange={(e) => setFormData({ ...formData, enabled: e.target.checked })} />}
@@ -1258,11 +1620,30 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
       <AccessLogsDialog
         open={logsOpen}
         logs={logs}
+        usage={logsUsage}
         loading={logsLoading}
         title={t('subscriptions.share.logsTitle', { name: logsShareName })}
         onClose={() => setLogsOpen(false)}
       />
 
+      <ShareSignDialog
+        open={Boolean(signingShare)}
+        share={signingShare}
+        clientLinks={visibleClientLinks}
+        serverUrl={getServerUrl()}
+        onClose={() => setSigningShare(null)}
+        onCopy={copyToClipboard}
+        onQrCode={handleQrCode}
+        showMessage={showMessage}
+      />
+
+      <ShareDevicesDialog
+        open={Boolean(devicesShare)}
+        share={devicesShare}
+        onClose={() => setDevicesShare(null)}
+        showMessage={showMessage}
+      />
+
       <QrCodeDialog open={qrOpen} title={qrTitle} url={qrUrl} onClose={() => setQrOpen(false)} onCopy={copyToClipboard} />
 
       <ConfirmDialog
@@ -1296,6 +1677,8 @@ export default function ShareManageDialog({ open, subscription, onClose, showMes
         serverUrl={getServerUrl()}
         subStoreTargets={subStoreTargets}
       />
+
+      <ShareAnalyticsDialog open={analyticsOpen} subscription={subscription} onClose={() => setAnalyticsOpen(false)} />
     </>
   );
 }
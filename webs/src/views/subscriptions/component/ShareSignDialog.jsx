@@ -0,0 +1,149 @@
+import { useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+import Dialog from '@mui/material/Dialog';
+import DialogTitle from '@mui/material/DialogTitle';
+import DialogContent from '@mui/material/DialogContent';
+import DialogActions from '@mui/material/DialogActions';
+import Button from '@mui/material/Button';
+import Stack from '@mui/material/Stack';
+import TextField from '@mui/material/TextField';
+import FormControl from '@mui/material/FormControl';
+import InputLabel from '@mui/material/InputLabel';
+import Select from '@mui/material/Select';
+import MenuItem from '@mui/material/MenuItem';
+import Alert from '@mui/material/Alert';
+import IconButton from '@mui/material/IconButton';
+import InputAdornment from '@mui/material/InputAdornment';
+import Tooltip from '@mui/material/Tooltip';
+import useMediaQuery from '@mui/material/useMediaQuery';
+import { useTheme } from '@mui/material/styles';
+import ContentCopyIcon from '@mui/icons-material/ContentCopy';
+import QrCode2Icon from '@mui/icons-material/QrCode2';
+
+import { formatDateTime } from 'i18n/locales';
+import { signShare } from '../../../api/shares';
+import useResolvedColorScheme from 'hooks/useResolvedColorScheme';
+import { getSurfaceTokens } from 'themes/surfaceTokens';
+
+// ==============================|| 临时签名链接 ||============================== //
+
+export default function ShareSignDialog({ open, share, clientLinks, serverUrl, onClose, onCopy, onQrCode, showMessage }) {
+  const theme = useTheme();
+  const { t, i18n } = useTranslation();
+  const isMobile = useMediaQuery(theme.breakpoints.down('sm'));
+  const { isDark } = useResolvedColorScheme();
+  const { dialogSurface, mutedPanelSurface, panelBorder } = getSurfaceTokens(theme, isDark);
+
+  const [expireHours, setExpireHours] = useState(24);
+  const [client, setClient] = useState('');
+  const [ip, setIp] = useState('');
+  const [loading, setLoading] = useState(false);
+  const [result, setResult] = useState(null);
+
+  useEffect(() => {
+    if (open) {
+      setExpireHours(24);
+      setClient('');
+      setIp('');
+      setResult(null);
+    }
+  }, [open, share?.id]);
+
+  const handleGenerate = async () => {
+    setLoading(true);
+    try {
+      const res = await signShare({ id: share.id, expire_hours: expireHours, client, ip: ip.trim() });
+      setResult({ url: `${serverUrl}${res.data.path}`, expiresAt: res.data.expires_at });
+    } catch (error) {
+      console.error('Failed to sign share:', error);
+      showMessage?.(error.response?.data?.msg || t('subscriptions.share.sign.failed'), 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <Dialog
+      open={open}
+      onClose={onClose}
+      maxWidth="sm"
+      fullWidth
+      fullScreen={isMobile}
+      slotProps={{
+        paper: { sx: { borderRadius: isMobile ? 0 : 3, bgcolor: dialogSurface, border: '1px solid', borderColor: panelBorder } }
+      }}
+    >
+      <DialogTitle sx={{ px: 2.5, py: 2, bgcolor: mutedPanelSurface, borderBottom: '1px solid', borderColor: panelBorder }}>
+        {t('subscriptions.share.sign.title', { name: share?.name || t('subscriptions.share.unnamed') })}
+      </DialogTitle>
+      <DialogContent sx={{ bgcolor: dialogSurface }}>
+        <Stack spacing={2} sx={{ mt: 2 }}>
+          <Alert severity="info">{t('subscriptions.share.sign.description')}</Alert>
+          {!share?.signed_only && <Alert severity="warning">{t('subscriptions.share.sign.signedOnlyNotice')}</Alert>}
+          <TextField
+            label={t('subscriptions.share.sign.expireHours')}
+            type="number"
+            value={expireHours}
+            onChange={(e) => setExpireHours(parseInt(e.target.value) || 0)}
+            size="small"
+            fullWidth
+            slotProps={{ htmlInput: { min: 1, max: 720 } }}
+          />
+          <FormControl size="small" fullWidth>
+            <InputLabel>{t('subscriptions.share.sign.client')}</InputLabel>
+            <Select value={client} label={t('subscriptions.share.sign.client')} onChange={(e) => setClient(e.target.value)}>
+              <MenuItem value="">{t('subscriptions.share.sign.clientAny')}</MenuItem>
+              {clientLinks.map((item) => (
+                <MenuItem key={item.client} value={item.client}>
+                  {t(`subscriptions.share.clients.${item.key}`)}
+                </MenuItem>
+              ))}
+            </Select>
+          </FormControl>
+          <TextField
+            label={t('subscriptions.share.sign.ip')}
+            value={ip}
+            onChange={(e) => setIp(e.target.value)}
+            placeholder={t('subscriptions.share.sign.ipPlaceholder')}
+            size="small"
+            fullWidth
+          />
+          {result && (
+            <TextField
+              label={t('subscriptions.share.sign.result', { time: formatDateTime(result.expiresAt, i18n.language) })}
+              value={result.url}
+              size="small"
+              fullWidth
+              multiline
+              slotProps={{
+                input: {
+                  readOnly: true,
+                  endAdornment: (
+                    <InputAdornment position="end">
+                      <Tooltip title={t('common.copy')}>
+                        <IconButton size="small" onClick={() => onCopy(result.url)}>
+                          <ContentCopyIcon fontSize="small" />
+                        </IconButton>
+                      </Tooltip>
+                      <Tooltip title={t('subscriptions.share.sign.qrCode')}>
+                        <IconButton size="small" onClick={() => onQrCode(result.url, share?.name || t('subscriptions.share.unnamed'))}>
+                          <QrCode2Icon fontSize="small" />
+                        </IconButton>
+                      </Tooltip>
+                    </InputAdornment>
+                  )
+                }
+              }}
+            />
+          )}
+        </Stack>
+      </DialogContent>
+      <DialogActions sx={{ px: 2.5, py: 1.75, bgcolor: mutedPanelSurface, borderTop: '1px solid', borderColor: panelBorder }}>
+        <Button onClick={onClose}>{t('common.close')}</Button>
+        <Button variant="contained" onClick={handleGenerate} disabled={loading || !share}>
+          {t('subscriptions.share.sign.generate')}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
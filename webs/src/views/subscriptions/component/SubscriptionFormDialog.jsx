@@ -537,7 +537,29 @@ export default function SubscriptionFormDialog({
                       label={t('subscriptions.form.basic.realtimeUsage')}
                     />
                   </Tooltip>
+                  <Tooltip title={t('subscriptions.form.basic.validateOutputTooltip')} placement="top" arrow>
+                    <FormControlLabel
+                      control={
+                        <Checkbox
+                          checked={formData.validateOutput}
+                          onChange={(e) => setFormData({ ...formData, validateOutput: e.target.checked })}
+                        />
+                      }
+                      label={t('subscriptions.form.basic.validateOutput')}
+                    />
+                  </Tooltip>
                 </Stack>
+
+                <TextField
+                  fullWidth
+                  multiline
+                  minRows={2}
+                  label={t('subscriptions.form.basic.templateVars')}
+                  placeholder={'dns=223.5.5.5, 1.1.1.1\nregion=HK'}
+                  value={formData.templateVars}
+                  onChange={(e) => setFormData({ ...formData, templateVars: e.target.value })}
+                  helperText={t('subscriptions.form.basic.templateVarsHelper')}
+                />
               </Stack>
             </AccordionDetails>
           </Accordion>
@@ -32,7 +32,8 @@ import {
   sortSubscription,
   batchSortSubscription,
   copySubscription,
-  previewSubscriptionNodes
+  previewSubscriptionNodes,
+  validateSubscriptionConfig
 } from 'api/subscriptions';
 import { getNodeCheckMeta } from 'api/nodeCheck';
 import {
@@ -69,6 +70,24 @@ import {
   GroupSortDialog
 } from './component';
 
+// 模板变量在表单中以 KEY=VALUE 多行文本编辑，保存时转换为对象
+const formatTemplateVars = (vars) =>
+  Object.entries(vars || {})
+    .map(([key, value]) => `${key}=${value}`)
+    .join('\n');
+
+const parseTemplateVars = (text) => {
+  const vars = {};
+  (text || '').split('\n').forEach((line) => {
+    const trimmed = line.trim();
+    if (!trimmed || trimmed.startsWith('#')) return;
+    const index = trimmed.indexOf('=');
+    if (index <= 0) return;
+    vars[trimmed.slice(0, index).trim()] = trimmed.slice(index + 1).trim();
+  });
+  return vars;
+};
+
 // ==============================|| 订阅管理 ||============================== //
 
 export default function SubscriptionList() {
@@ -118,6 +137,8 @@ export default function SubscriptionList() {
     udp: false,
     cert: false,
     replaceServerWithHost: false,
+    validateOutput: false,
+    templateVars: '',
     selectionMode: 'nodes',
     selectedNodes: [],
     selectedGroups: [],
@@ -200,6 +221,7 @@ export default function SubscriptionList() {
   const [previewOpen, setPreviewOpen] = useState(false);
   const [previewLoading, setPreviewLoading] = useState(false);
   const [previewData, setPreviewData] = useState(null);
+  const [previewValidation, setPreviewValidation] = useState(null);
 
   // 分页
   const [page, setPage] = useState(0);
@@ -541,6 +563,8 @@ export default function SubscriptionList() {
       udp: false,
       cert: false,
       replaceServerWithHost: false,
+      validateOutput: false,
+      templateVars: '',
       selectionMode: 'nodes',
       selectedNodes: [],
       selectedGroups: [],
@@ -619,6 +643,8 @@ export default function SubscriptionList() {
       udp: config?.udp || false,
       cert: config?.cert || false,
       replaceServerWithHost: config?.replaceServerWithHost || false,
+      validateOutput: config?.validateOutput || false,
+      templateVars: formatTemplateVars(config?.templateVars),
       selectionMode: mode,
       selectedNodes: nodes,
       selectedGroups: groups,
@@ -707,7 +733,9 @@ export default function SubscriptionList() {
         surge: formData.surge,
         udp: formData.udp,
         cert: formData.cert,
-        replaceServerWithHost: formData.replaceServerWithHost
+        replaceServerWithHost: formData.replaceServerWithHost,
+        validateOutput: formData.validateOutput,
+        templateVars: parseTemplateVars(formData.templateVars)
       });
 
       const requestData = {
@@ -871,6 +899,7 @@ export default function SubscriptionList() {
   // 预览节点
   const handlePreview = async () => {
     setPreviewLoading(true);
+    setPreviewValidation(null);
     try {
       // 构建预览请求数据
       const previewRequest = {
@@ -928,7 +957,12 @@ export default function SubscriptionList() {
       const response = await previewSubscriptionNodes(previewRequest);
       // 成功（code === 200 时返回，否则被拦截器 reject）
       setPreviewData(response.data);
+      setPreviewValidation(null);
       setPreviewOpen(true);
+      // 已保存的订阅额外用 mihomo 校验最终配置，校验失败不影响节点预览
+      validateSubscriptionConfig(sub.ID)
+        .then((res) => setPreviewValidation(res.data))
+        .catch((error) => setPreviewValidation({ error: error.message }));
     } catch (error) {
       console.error(error);
       showMessage(error.message || '预览请求失败', 'error');
@@ -1392,6 +1426,7 @@ export default function SubscriptionList() {
         open={previewOpen}
         loading={previewLoading}
         data={previewData}
+        configValidation={previewValidation}
         tagColorMap={tagOptions.reduce((acc, tag) => {
           acc[tag.Name] = tag.Color;
           return acc;
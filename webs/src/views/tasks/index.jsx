@@ -40,6 +40,8 @@ import RefreshIcon from '@mui/icons-material/Refresh';
 import SpeedIcon from '@mui/icons-material/Speed';
 import CloudSyncIcon from '@mui/icons-material/CloudSync';
 import LocalOfferIcon from '@mui/icons-material/LocalOffer';
+import RestoreIcon from '@mui/icons-material/Restore';
+import CompareArrowsIcon from '@mui/icons-material/CompareArrows';
 import StorageIcon from '@mui/icons-material/Storage';
 import CheckCircleIcon from '@mui/icons-material/CheckCircle';
 import ErrorIcon from '@mui/icons-material/Error';
@@ -88,7 +90,9 @@ const TASK_TYPE_ICONS = {
   speed_test: SpeedIcon,
   sub_update: CloudSyncIcon,
   tag_rule: LocalOfferIcon,
-  db_migration: StorageIcon
+  db_migration: StorageIcon,
+  backup_restore: RestoreIcon,
+  db_copy: CompareArrowsIcon
 };
 
 const TASK_TRIGGER_ICONS = {
@@ -1027,6 +1031,14 @@ export default function TaskList() {
               <StorageIcon sx={{ fontSize: 16, mr: 1, color: getTaskTypeMeta('db_migration').color }} />
               {t('tasks.type.dbMigration')}
             </MenuItem>
+            <MenuItem value="backup_restore">
+              <RestoreIcon sx={{ fontSize: 16, mr: 1, color: getTaskTypeMeta('backup_restore').color }} />
+              {t('tasks.type.backupRestore')}
+            </MenuItem>
+            <MenuItem value="db_copy">
+              <CompareArrowsIcon sx={{ fontSize: 16, mr: 1, color: getTaskTypeMeta('db_copy').color }} />
+              {t('tasks.type.dbCopy')}
+            </MenuItem>
           </Select>
         </FormControl>
 
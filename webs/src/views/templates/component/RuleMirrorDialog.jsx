@@ -0,0 +1,269 @@
+import { useCallback, useEffect, useState } from 'react';
+import PropTypes from 'prop-types';
+import { useTranslation } from 'react-i18next';
+
+// material-ui
+import Dialog from '@mui/material/Dialog';
+import DialogTitle from '@mui/material/DialogTitle';
+import DialogContent from '@mui/material/DialogContent';
+import DialogActions from '@mui/material/DialogActions';
+import Button from '@mui/material/Button';
+import Stack from '@mui/material/Stack';
+import Alert from '@mui/material/Alert';
+import Box from '@mui/material/Box';
+import Chip from '@mui/material/Chip';
+import Switch from '@mui/material/Switch';
+import Tooltip from '@mui/material/Tooltip';
+import Typography from '@mui/material/Typography';
+import IconButton from '@mui/material/IconButton';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import CircularProgress from '@mui/material/CircularProgress';
+import Table from '@mui/material/Table';
+import TableBody from '@mui/material/TableBody';
+import TableCell from '@mui/material/TableCell';
+import TableContainer from '@mui/material/TableContainer';
+import TableHead from '@mui/material/TableHead';
+import TableRow from '@mui/material/TableRow';
+
+// icons
+import RefreshIcon from '@mui/icons-material/Refresh';
+import SaveIcon from '@mui/icons-material/Save';
+
+// project imports
+import { getRuleMirrors, refreshRuleMirrors, saveRuleMirrorSettings, setRuleMirrorEnabled } from 'api/ruleMirrors';
+import { getNodes } from 'api/nodes';
+import SearchableNodeSelect from 'components/SearchableNodeSelect';
+import { formatDateTime } from 'i18n/locales';
+
+const STATUS_COLORS = {
+  fresh: 'success',
+  stale: 'warning',
+  failed: 'error',
+  pending: 'default',
+  disabled: 'default'
+};
+
+const formatSize = (size) => {
+  if (!size) return '-';
+  if (size < 1024) return `${size} B`;
+  if (size < 1024 * 1024) return `${(size / 1024).toFixed(1)} KB`;
+  return `${(size / 1024 / 1024).toFixed(1)} MB`;
+};
+
+// ==============================|| 规则镜像对话框 ||============================== //
+
+export default function RuleMirrorDialog({ open, onClose, showMessage }) {
+  const { t, i18n } = useTranslation();
+  const [settings, setSettings] = useState({ enabled: false, useProxy: false, proxyLink: '' });
+  const [items, setItems] = useState([]);
+  const [loading, setLoading] = useState(false);
+  const [saving, setSaving] = useState(false);
+  const [refreshingId, setRefreshingId] = useState(null);
+  const [proxyNodes, setProxyNodes] = useState([]);
+  const [loadingNodes, setLoadingNodes] = useState(false);
+
+  const formatTime = (value) => (value ? formatDateTime(new Date(value), i18n.resolvedLanguage || i18n.language) : '-');
+
+  const fetchData = useCallback(async () => {
+    setLoading(true);
+    try {
+      const res = await getRuleMirrors();
+      if (res.data) {
+        setSettings({
+          enabled: Boolean(res.data.settings?.enabled),
+          useProxy: Boolean(res.data.settings?.useProxy),
+          proxyLink: res.data.settings?.proxyLink || ''
+        });
+        setItems(res.data.items || []);
+      }
+    } catch (error) {
+      showMessage?.(error.message || t('templates.ruleMirror.messages.loadFailed'), 'error');
+    } finally {
+      setLoading(false);
+    }
+  }, [showMessage, t]);
+
+  useEffect(() => {
+    if (open) {
+      fetchData();
+    }
+  }, [open, fetchData]);
+
+  useEffect(() => {
+    if (!open || !settings.useProxy || proxyNodes.length > 0) return;
+    setLoadingNodes(true);
+    getNodes({ pageSize: 200 })
+      .then((res) => setProxyNodes(res.data?.items || res.data || []))
+      .catch((error) => console.error(error))
+      .finally(() => setLoadingNodes(false));
+  }, [open, settings.useProxy, proxyNodes.length]);
+
+  const handleSave = async () => {
+    setSaving(true);
+    try {
+      await saveRuleMirrorSettings(settings);
+      showMessage?.(t('templates.ruleMirror.messages.saveSuccess'));
+      fetchData();
+    } catch (error) {
+      showMessage?.(error.message || t('templates.ruleMirror.messages.saveFailed'), 'error');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleRefresh = async (id) => {
+    setRefreshingId(id || 'all');
+    try {
+      const res = await refreshRuleMirrors(id);
+      showMessage?.(id ? t('templates.ruleMirror.messages.refreshSuccess') : res.msg || t('templates.ruleMirror.messages.refreshStarted'));
+      fetchData();
+    } catch (error) {
+      showMessage?.(error.message || t('templates.ruleMirror.messages.refreshFailed'), 'error');
+      fetchData();
+    } finally {
+      setRefreshingId(null);
+    }
+  };
+
+  const handleToggle = async (item) => {
+    try {
+      await setRuleMirrorEnabled(item.id, !item.enabled);
+      fetchData();
+    } catch (error) {
+      showMessage?.(error.message || t('templates.ruleMirror.messages.saveFailed'), 'error');
+    }
+  };
+
+  const failedCount = items.filter((item) => item.status === 'failed').length;
+
+  return (
+    <Dialog open={open} onClose={onClose} maxWidth="lg" fullWidth>
+      <DialogTitle>{t('templates.ruleMirror.title')}</DialogTitle>
+      <DialogContent dividers>
+        <Stack spacing={2}>
+          <Typography variant="body2" color="textSecondary">
+            {t('templates.ruleMirror.description')}
+          </Typography>
+
+          <FormControlLabel
+            control={<Switch checked={settings.enabled} onChange={(e) => setSettings({ ...settings, enabled: e.target.checked })} />}
+            label={t('templates.ruleMirror.fields.enabled')}
+          />
+          <FormControlLabel
+            control={<Switch checked={settings.useProxy} onChange={(e) => setSettings({ ...settings, useProxy: e.target.checked })} />}
+            label={t('templates.ruleMirror.fields.useProxy')}
+          />
+          {settings.useProxy && (
+            <SearchableNodeSelect
+              nodes={proxyNodes}
+              loading={loadingNodes}
+              value={proxyNodes.find((n) => n.Link === settings.proxyLink) || (settings.proxyLink ? { Link: settings.proxyLink, Name: '', ID: 0 } : null)}
+              onChange={(node) => setSettings({ ...settings, proxyLink: typeof node === 'string' ? node : node?.Link || '' })}
+              displayField="Name"
+              valueField="Link"
+              label={t('templates.fields.proxyNode')}
+              placeholder={t('templates.placeholders.proxyNode')}
+              helperText={t('templates.ruleMirror.helpers.proxyNode')}
+              freeSolo={true}
+              limit={50}
+            />
+          )}
+
+          {failedCount > 0 && <Alert severity="warning">{t('templates.ruleMirror.failedNotice', { count: failedCount })}</Alert>}
+
+          {loading ? (
+            <Box sx={{ display: 'flex', justifyContent: 'center', py: 4 }}>
+              <CircularProgress />
+            </Box>
+          ) : items.length === 0 ? (
+            <Alert severity="info">{t('templates.ruleMirror.empty')}</Alert>
+          ) : (
+            <TableContainer>
+              <Table size="small">
+                <TableHead>
+                  <TableRow>
+                    <TableCell>{t('templates.ruleMirror.columns.url')}</TableCell>
+                    <TableCell>{t('templates.ruleMirror.columns.status')}</TableCell>
+                    <TableCell>{t('templates.ruleMirror.columns.lastSuccess')}</TableCell>
+                    <TableCell>{t('templates.ruleMirror.columns.size')}</TableCell>
+                    <TableCell>{t('templates.ruleMirror.columns.templates')}</TableCell>
+                    <TableCell align="right">{t('templates.ruleMirror.columns.actions')}</TableCell>
+                  </TableRow>
+                </TableHead>
+                <TableBody>
+                  {items.map((item) => (
+                    <TableRow key={item.id}>
+                      <TableCell sx={{ maxWidth: 360, wordBreak: 'break-all' }}>
+                        <Typography variant="body2">{item.url}</Typography>
+                        {item.useProxy && (
+                          <Typography variant="caption" color="textSecondary">
+                            {t('templates.ruleMirror.viaTemplateProxy')}
+                          </Typography>
+                        )}
+                        {item.singBoxFile && (
+                          <Typography variant="caption" color="textSecondary" component="div">
+                            {t('templates.ruleMirror.singBoxFiles', {
+                              files: [item.singBoxFile, item.srsFile]
+                                .filter(Boolean)
+                                .map((file) => `/c/rules/${file}`)
+                                .join(' · ')
+                            })}
+                          </Typography>
+                        )}
+                      </TableCell>
+                      <TableCell>
+                        <Tooltip title={item.lastError || ''}>
+                          <Chip size="small" color={STATUS_COLORS[item.status] || 'default'} label={t(`templates.ruleMirror.status.${item.status}`)} />
+                        </Tooltip>
+                        {item.lastError && (
+                          <Typography variant="caption" color="error" component="div" sx={{ mt: 0.5 }}>
+                            {t('templates.ruleMirror.failCount', { count: item.failCount, time: formatTime(item.lastFetchAt) })}
+                          </Typography>
+                        )}
+                      </TableCell>
+                      <TableCell>{formatTime(item.lastSuccessAt)}</TableCell>
+                      <TableCell>{formatSize(item.size)}</TableCell>
+                      <TableCell sx={{ maxWidth: 200, wordBreak: 'break-all' }}>{item.templates}</TableCell>
+                      <TableCell align="right">
+                        <Stack direction="row" spacing={0.5} justifyContent="flex-end" alignItems="center">
+                          <Switch size="small" checked={item.enabled} onChange={() => handleToggle(item)} />
+                          <IconButton
+                            size="small"
+                            onClick={() => handleRefresh(item.id)}
+                            disabled={!item.enabled || refreshingId !== null}
+                            title={t('templates.ruleMirror.actions.refreshOne')}
+                          >
+                            {refreshingId === item.id ? <CircularProgress size={16} /> : <RefreshIcon fontSize="small" />}
+                          </IconButton>
+                        </Stack>
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+            </TableContainer>
+          )}
+        </Stack>
+      </DialogContent>
+      <DialogActions>
+        <Button onClick={onClose}>{t('common.close')}</Button>
+        <Button
+          onClick={() => handleRefresh()}
+          disabled={refreshingId !== null}
+          startIcon={refreshingId === 'all' ? <CircularProgress size={18} /> : <RefreshIcon />}
+        >
+          {t('templates.ruleMirror.actions.refreshAll')}
+        </Button>
+        <Button variant="contained" onClick={handleSave} disabled={saving} startIcon={saving ? <CircularProgress size={18} /> : <SaveIcon />}>
+          {t('common.save')}
+        </Button>
+      </DialogActions>
+    </Dialog>
+  );
+}
+
+RuleMirrorDialog.propTypes = {
+  open: PropTypes.bool.isRequired,
+  onClose: PropTypes.func.isRequired,
+  showMessage: PropTypes.func
+};
@@ -53,6 +53,7 @@ import UndoIcon from '@mui/icons-material/Undo';
 import MainCard from 'ui-component/cards/MainCard';
 import Pagination from 'components/Pagination';
 import SearchableNodeSelect from 'components/SearchableNodeSelect';
+import RuleMirrorDialog from './component/RuleMirrorDialog';
 import {
   getTemplates,
   addTemplate,
@@ -337,7 +338,14 @@ export default function TemplateList() {
   const [aiCommandOpen, setAICommandOpen] = useState(false);
   const [aiDisabledPromptOpen, setAIDisabledPromptOpen] = useState(false);
   const [errorDialog, setErrorDialog] = useState({ open: false, title: '', message: '' });
-  const [usageDialog, setUsageDialog] = useState({ open: false, title: '', message: '', subscriptions: [], action: null });
+  const [usageDialog, setUsageDialog] = useState({
+    open: false,
+    title: '',
+    message: '',
+    subscriptions: [],
+    templates: [],
+    action: null
+  });
   const [page, setPage] = useState(0);
   const [rowsPerPage, setRowsPerPage] = useState(() => {
     const saved = localStorage.getItem('templates_rowsPerPage');
@@ -358,6 +366,8 @@ export default function TemplateList() {
   const [baseTemplateLoading, setBaseTemplateLoading] = useState(false);
   const [baseTemplateSaving, setBaseTemplateSaving] = useState(false);
 
+  const [ruleMirrorDialogOpen, setRuleMirrorDialogOpen] = useState(false);
+
   const [useProxy, setUseProxy] = useState(false);
   const [proxyLink, setProxyLink] = useState('');
   const [proxyNodeOptions, setProxyNodeOptions] = useState([]);
@@ -472,10 +482,12 @@ export default function TemplateList() {
 
   const handleDelete = async (template) => {
     let usedSubscriptions = [];
+    let dependentTemplates = [];
 
     try {
       const response = await getTemplateUsage({ filename: template.file });
       usedSubscriptions = response.data?.subscriptions || [];
+      dependentTemplates = response.data?.templates || [];
     } catch (error) {
       console.log(error);
       showMessage(error.message || t('templates.messages.usageFailed'), 'error');
@@ -493,12 +505,26 @@ export default function TemplateList() {
       }
     };
 
+    // 被其他模板 include/extends 的基础模板不允许删除
+    if (dependentTemplates.length > 0) {
+      setUsageDialog({
+        open: true,
+        title: t('templates.usage.blockedTitle'),
+        message: t('templates.usage.blockedMessage', { name: template.file }),
+        subscriptions: usedSubscriptions,
+        templates: dependentTemplates,
+        action: null
+      });
+      return;
+    }
+
     if (usedSubscriptions.length > 0) {
       setUsageDialog({
         open: true,
         title: t('templates.usage.title'),
         message: t('templates.usage.message', { name: template.file }),
         subscriptions: usedSubscriptions,
+        templates: [],
         action: deleteAction
       });
       return;
@@ -1623,6 +1649,9 @@ export default function TemplateList() {
             <Button variant="outlined" size="small" color="secondary" onClick={() => handleOpenBaseTemplate('surge')}>
               {t('templates.baseTemplate.button', { category: 'Surge' })}
             </Button>
+            <Button variant="outlined" size="small" onClick={() => setRuleMirrorDialogOpen(true)}>
+              {t('templates.ruleMirror.button')}
+            </Button>
             <Button variant="contained" startIcon={<AddIcon />} onClick={handleAdd}>
               {t('templates.actions.addTemplate')}
             </Button>
@@ -2103,6 +2132,18 @@ export default function TemplateList() {
           >
             {usageDialog.message}
           </Alert>
+          {usageDialog.templates?.length > 0 && (
+            <Box sx={{ mt: 2 }}>
+              <Typography variant="subtitle2" sx={{ mb: 1 }}>
+                {t('templates.usage.dependentTemplates')}
+              </Typography>
+              <Stack spacing={1}>
+                {usageDialog.templates.map((templateName) => (
+                  <Chip key={templateName} label={templateName} color="error" variant="outlined" sx={{ width: 'fit-content' }} />
+                ))}
+              </Stack>
+            </Box>
+          )}
           {usageDialog.subscriptions?.length > 0 && (
             <Box sx={{ mt: 2 }}>
               <Typography variant="subtitle2" sx={{ mb: 1 }}>
@@ -2117,26 +2158,30 @@ export default function TemplateList() {
           )}
         </DialogContent>
         <DialogActions>
-          <Button onClick={() => setUsageDialog({ ...usageDialog, open: false, subscriptions: [], action: null })}>
+          <Button onClick={() => setUsageDialog({ ...usageDialog, open: false, subscriptions: [], templates: [], action: null })}>
             {t('common.cancel')}
           </Button>
-          <Button
-            variant="contained"
-            color="error"
-            onClick={async () => {
-              const action = usageDialog.action;
-              setUsageDialog({ open: false, title: '', message: '', subscriptions: [], action: null });
-              if (action) {
-                await action();
-              }
-            }}
-            autoFocus
-          >
-            {t('templates.actions.continueDelete')}
-          </Button>
+          {usageDialog.action && (
+            <Button
+              variant="contained"
+              color="error"
+              onClick={async () => {
+                const action = usageDialog.action;
+                setUsageDialog({ open: false, title: '', message: '', subscriptions: [], templates: [], action: null });
+                if (action) {
+                  await action();
+                }
+              }}
+              autoFocus
+            >
+              {t('templates.actions.continueDelete')}
+            </Button>
+          )}
         </DialogActions>
       </Dialog>
 
+      <RuleMirrorDialog open={ruleMirrorDialogOpen} onClose={() => setRuleMirrorDialogOpen(false)} showMessage={showMessage} />
+
       <Dialog open={baseTemplateDialogOpen} onClose={() => setBaseTemplateDialogOpen(false)} maxWidth="lg" fullWidth>
         <DialogTitle>{t('templates.baseTemplate.title', { category: baseTemplateCategory === 'clash' ? 'Clash' : 'Surge' })}</DialogTitle>
         <DialogContent>